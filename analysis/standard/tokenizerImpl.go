@@ -217,7 +217,7 @@ func zzUnpackAction(packed []int) []int {
 
 /* Translates a state to a row index in the transition table */
 var ZZ_ROWMAP = zzUnpackRowMap([]int{
-	000, 000, 000, 022, 000, 044, 000, 066, 000, 0110, 000, 0132, 000, 0154, 000, 176,
+	000, 000, 000, 022, 000, 044, 000, 066, 000, 0110, 000, 0132, 000, 0154, 000, 0176,
 	000, 0220, 000, 0242, 000, 0264, 000, 0306, 000, 0330, 000, 0352, 000, 0374, 000, int('\u010e'),
 	000, int('\u0120'), 000, 0154, 000, int('\u0132'), 000, int('\u0144'), 000, int('\u0156'), 000, 0264, 000, int('\u0168'), 000, int('\u017a'),
 })
@@ -310,7 +310,6 @@ func zzUnpackAttribute(packed []int) []int {
 			j++
 			count--
 		}
-		i += 2
 	}
 	return m
 }
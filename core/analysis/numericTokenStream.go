@@ -0,0 +1,253 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// analysis/NumericTokenStream.java
+
+const (
+	// The full precision token gets this token type assigned.
+	TOKEN_TYPE_FULL_PREC = "fullPrecNumeric"
+	// The lower precision tokens gets this token type assigned.
+	TOKEN_TYPE_LOWER_PREC = "lowerPrecNumeric"
+)
+
+/*
+Expert: This stream provides the raw prefix encoded numeric values
+for indexing numeric values that can be used by NumericRangeQuery or
+NumericRangeFilter.
+
+Note that for simple usage, IntField, LongField, FloatField or
+DoubleField is recommended. These fields disable norms and term
+freqs, as they are not usually needed during searching. If you need
+to change these settings, you should use this type with a custom
+FieldType whose NumericType is set.
+
+This stream is not intended to be used in analyzers; it's more for
+iterating the different precisions during indexing a specific
+numeric value.
+
+NOTE: as token streams are only consumed once the document is added
+to the index, if you index more than one numeric field, use a
+separate NumericTokenStream instance for each.
+
+See NumericRangeQuery for more details on the precisionStep
+parameter as well as how numeric fields work under the hood.
+*/
+type NumericTokenStream struct {
+	*TokenStreamImpl
+
+	numericAtt NumericTermAttribute
+	typeAtt    TypeAttribute
+	posIncrAtt PositionIncrementAttribute
+
+	valSize       int // valSize==0 means not initialized
+	precisionStep int
+}
+
+/*
+Creates a token stream for numeric values using the default
+precisionStep NUMERIC_PRECISION_STEP_DEFAULT (16). The stream is not
+yet initialized, before using set a value using the various
+SetXxxValue() methods.
+*/
+func NewNumericTokenStream() *NumericTokenStream {
+	return NewNumericTokenStreamWith(DEFAULT_ATTRIBUTE_FACTORY, util.NUMERIC_PRECISION_STEP_DEFAULT)
+}
+
+/*
+Creates a token stream for numeric values with the specified
+precisionStep. The stream is not yet initialized, before using set a
+value using the various SetXxxValue() methods.
+*/
+func NewNumericTokenStreamWithStep(precisionStep int) *NumericTokenStream {
+	return NewNumericTokenStreamWith(DEFAULT_ATTRIBUTE_FACTORY, precisionStep)
+}
+
+/*
+Expert: Creates a token stream for numeric values with the specified
+precisionStep using the given AttributeFactory. The stream is not yet
+initialized, before using set a value using the various
+SetXxxValue() methods.
+*/
+func NewNumericTokenStreamWith(factory util.AttributeFactory, precisionStep int) *NumericTokenStream {
+	assert2(precisionStep >= 1, "precisionStep must be >=1")
+	ans := &NumericTokenStream{
+		TokenStreamImpl: NewTokenStreamWithFactory(&numericAttributeFactory{factory}),
+		precisionStep:   precisionStep,
+	}
+	ans.numericAtt = ans.Attributes().Add("NumericTermAttribute").(NumericTermAttribute)
+	ans.typeAtt = ans.Attributes().Add("TypeAttribute").(TypeAttribute)
+	ans.posIncrAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.numericAtt.SetShift(-precisionStep)
+	return ans
+}
+
+/*
+Initializes the token stream with the supplied int64 value. Returns
+this instance, so the value can be set right after construction:
+
+	ts := NewNumericTokenStreamWithStep(precisionStep).SetLongValue(value)
+*/
+func (ts *NumericTokenStream) SetLongValue(value int64) *NumericTokenStream {
+	ts.valSize = 64
+	ts.numericAtt.Init(value, ts.valSize, ts.precisionStep, -ts.precisionStep)
+	return ts
+}
+
+/* Initializes the token stream with the supplied int32 value. */
+func (ts *NumericTokenStream) SetIntValue(value int32) *NumericTokenStream {
+	ts.valSize = 32
+	ts.numericAtt.Init(int64(value), ts.valSize, ts.precisionStep, -ts.precisionStep)
+	return ts
+}
+
+/* Initializes the token stream with the supplied float64 value. */
+func (ts *NumericTokenStream) SetDoubleValue(value float64) *NumericTokenStream {
+	ts.valSize = 64
+	ts.numericAtt.Init(util.DoubleToSortableLong(value), ts.valSize, ts.precisionStep, -ts.precisionStep)
+	return ts
+}
+
+/* Initializes the token stream with the supplied float32 value. */
+func (ts *NumericTokenStream) SetFloatValue(value float32) *NumericTokenStream {
+	ts.valSize = 32
+	ts.numericAtt.Init(int64(util.FloatToSortableInt(value)), ts.valSize, ts.precisionStep, -ts.precisionStep)
+	return ts
+}
+
+func (ts *NumericTokenStream) Reset() error {
+	if ts.valSize == 0 {
+		return errors.New("call SetXxxValue() before usage")
+	}
+	ts.numericAtt.SetShift(-ts.precisionStep)
+	return nil
+}
+
+func (ts *NumericTokenStream) IncrementToken() (bool, error) {
+	if ts.valSize == 0 {
+		return false, errors.New("call SetXxxValue() before usage")
+	}
+
+	// this will only clear all other attributes in this TokenStream
+	ts.Attributes().Clear()
+
+	shift := ts.numericAtt.IncShift()
+	if shift == 0 {
+		ts.typeAtt.SetType(TOKEN_TYPE_FULL_PREC)
+		ts.posIncrAtt.SetPositionIncrement(1)
+	} else {
+		ts.typeAtt.SetType(TOKEN_TYPE_LOWER_PREC)
+		ts.posIncrAtt.SetPositionIncrement(0)
+	}
+	return shift < ts.valSize, nil
+}
+
+/* Returns the precision step. */
+func (ts *NumericTokenStream) PrecisionStep() int {
+	return ts.precisionStep
+}
+
+/*
+Expert: Use this attribute to get the details of the currently
+generated token.
+*/
+type NumericTermAttribute interface {
+	util.Attribute
+	// Returns current shift value, undefined before first token
+	Shift() int
+	// Returns current token's raw value as int64 with all Shift()
+	// applied, undefined before first token
+	RawValue() int64
+	// Returns value size in bits (32 for float32, int32; 64 for
+	// float64, int64)
+	ValueSize() int
+	// Don't call this method!
+	Init(value int64, valSize, precisionStep, shift int)
+	// Don't call this method!
+	SetShift(shift int)
+	// Don't call this method!
+	IncShift() int
+}
+
+/* Implementation of NumericTermAttribute. */
+type NumericTermAttributeImpl struct {
+	value         int64
+	valueSize     int
+	shift         int
+	precisionStep int
+	bytes         *util.BytesRefBuilder
+}
+
+/* Creates, but does not yet initialize this attribute instance. */
+func newNumericTermAttributeImpl() *NumericTermAttributeImpl {
+	return &NumericTermAttributeImpl{bytes: util.NewBytesRefBuilder()}
+}
+
+func (a *NumericTermAttributeImpl) Interfaces() []string {
+	return []string{"NumericTermAttribute", "TermToBytesRefAttribute"}
+}
+
+func (a *NumericTermAttributeImpl) BytesRef() *util.BytesRef {
+	return a.bytes.Get()
+}
+
+func (a *NumericTermAttributeImpl) FillBytesRef() {
+	assert2(a.valueSize == 64 || a.valueSize == 32, "invalid value size: %v", a.valueSize)
+	if a.valueSize == 64 {
+		util.LongToPrefixCodedBytes(a.value, a.shift, a.bytes)
+	} else {
+		util.IntToPrefixCodedBytes(int32(a.value), a.shift, a.bytes)
+	}
+}
+
+func (a *NumericTermAttributeImpl) Shift() int         { return a.shift }
+func (a *NumericTermAttributeImpl) SetShift(shift int) { a.shift = shift }
+func (a *NumericTermAttributeImpl) ValueSize() int     { return a.valueSize }
+func (a *NumericTermAttributeImpl) IncShift() int      { a.shift += a.precisionStep; return a.shift }
+func (a *NumericTermAttributeImpl) RawValue() int64    { return a.value &^ ((1 << uint(a.shift)) - 1) }
+
+func (a *NumericTermAttributeImpl) Init(value int64, valueSize, precisionStep, shift int) {
+	a.value = value
+	a.valueSize = valueSize
+	a.precisionStep = precisionStep
+	a.shift = shift
+}
+
+func (a *NumericTermAttributeImpl) Clear() {
+	// this attribute has no contents to clear! we keep it untouched as
+	// it's fully controlled by outer class.
+}
+
+func (a *NumericTermAttributeImpl) Clone() util.AttributeImpl {
+	ans := newNumericTermAttributeImpl()
+	ans.Init(a.value, a.valueSize, a.precisionStep, a.shift)
+	return ans
+}
+
+func (a *NumericTermAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(NumericTermAttribute).Init(a.value, a.valueSize, a.precisionStep, a.shift)
+}
+
+/*
+Redirects all attributes to the delegate factory, except the
+NumericTermAttribute, and refuses the CharTermAttribute as numeric
+terms have no chars.
+*/
+type numericAttributeFactory struct {
+	delegate util.AttributeFactory
+}
+
+func (f *numericAttributeFactory) Create(name string) util.AttributeImpl {
+	switch name {
+	case "NumericTermAttribute", "TermToBytesRefAttribute":
+		return newNumericTermAttributeImpl()
+	case "CharTermAttribute":
+		panic(fmt.Sprintf("NumericTokenStream does not support %v.", name))
+	}
+	return f.delegate.Create(name)
+}
@@ -3,6 +3,7 @@ package blocktree
 import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util/automaton"
 	"github.com/balzaczyy/golucene/core/util/fst"
 )
 
@@ -68,6 +69,14 @@ func (r *FieldReader) Iterator(reuse TermsEnum) TermsEnum {
 	return newSegmentTermsEnum(r)
 }
 
+func (r *FieldReader) Intersect(compiled *automaton.CompiledAutomaton,
+	startTerm []byte) (TermsEnum, error) {
+
+	assert2(compiled.Type == automaton.AUTOMATON_TYPE_NORMAL,
+		"only NORMAL automaton can be intersected; got %v", compiled.Type)
+	return newIntersectTermsEnum(r, compiled, startTerm)
+}
+
 func (r *FieldReader) SumTotalTermFreq() int64 {
 	return r.sumTotalTermFreq
 }
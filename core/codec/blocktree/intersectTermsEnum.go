@@ -0,0 +1,476 @@
+package blocktree
+
+import (
+	"bytes"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	"github.com/balzaczyy/golucene/core/util/fst"
+	"sort"
+)
+
+// blocktree/IntersectTermsEnum.java
+
+/*
+This is used to implement efficient Terms.Intersect() for block-tree.
+Note that it cannot seek, except for the initial term on init. It
+just "nexts" through the intersection of the automaton and the terms.
+It does not use the terms index at all: on init, it loads the root
+block, and scans its way to the initial term. Likewise, in next it
+scans until it finds a term that matches the current automaton
+transition.
+*/
+type IntersectTermsEnum struct {
+	*TermsEnumImpl
+
+	in store.IndexInput
+
+	stack []*intersectTermsEnumFrame
+
+	arcs []*fst.Arc
+
+	compiled     *automaton.CompiledAutomaton
+	runAutomaton *automaton.ByteRunAutomaton
+	commonSuffix []byte
+
+	currentFrame *intersectTermsEnumFrame
+
+	term *util.BytesRefBuilder
+
+	fstReader fst.BytesReader
+
+	fr *FieldReader
+
+	savedStartTerm []byte
+}
+
+/*
+TODO: in some cases we can filter by length? eg regexp foo*bar must
+be at least length 6 bytes
+*/
+func newIntersectTermsEnum(fr *FieldReader,
+	compiled *automaton.CompiledAutomaton, startTerm []byte) (*IntersectTermsEnum, error) {
+
+	// fmt.Printf("\nintEnum.init seg=%v commonSuffix=%v\n",
+	// 	fr.parent.segment, brToString(compiled.CommonSuffixRef))
+	assert2(fr.index != nil, "terms index was not loaded")
+	ans := &IntersectTermsEnum{
+		fr:           fr,
+		compiled:     compiled,
+		runAutomaton: compiled.RunAutomaton,
+		commonSuffix: compiled.CommonSuffixRef,
+		in:           fr.parent.in.Clone(),
+		stack:        make([]*intersectTermsEnumFrame, 5),
+		arcs:         make([]*fst.Arc, 5),
+		term:         util.NewBytesRefBuilder(),
+		fstReader:    fr.index.BytesReader(),
+	}
+	ans.TermsEnumImpl = NewTermsEnumImpl(ans)
+	for i, _ := range ans.stack {
+		ans.stack[i] = newIntersectTermsEnumFrame(ans, i)
+	}
+	for i, _ := range ans.arcs {
+		ans.arcs[i] = &fst.Arc{}
+	}
+
+	// TODO: if the automaton is "smallish" we really
+	// should use the terms index to seek at least to
+	// the initial term and likely to subsequent terms
+	// (or, maybe just fallback to ATE for such cases).
+	// Else the seek cost of loading the frames will be
+	// too costly.
+
+	arc := fr.index.FirstArc(ans.arcs[0])
+	// Empty string prefix must have an output in the index!
+	assert(arc.IsFinal())
+
+	// Special pushFrame since it's the first one:
+	f := ans.stack[0]
+	f.fp, f.fpOrig = fr.rootBlockFP, fr.rootBlockFP
+	f.prefix = 0
+	f.setState(ans.runAutomaton.InitialState())
+	f.arc = arc
+	f.outputPrefix = arc.Output
+	if err := f.load(fr.rootCode); err != nil {
+		return nil, err
+	}
+
+	// for assert:
+	ans.savedStartTerm = append([]byte(nil), startTerm...)
+
+	ans.currentFrame = f
+	if startTerm != nil {
+		if err := ans.seekToStartTerm(startTerm); err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+func (e *IntersectTermsEnum) TermState() (TermState, error) {
+	if err := e.currentFrame.decodeMetaData(); err != nil {
+		return nil, err
+	}
+	return e.currentFrame.termState.Clone(), nil
+}
+
+func (e *IntersectTermsEnum) frame(ord int) *intersectTermsEnumFrame {
+	if ord >= len(e.stack) {
+		next := make([]*intersectTermsEnumFrame, util.Oversize(1+ord, util.NUM_BYTES_OBJECT_REF))
+		copy(next, e.stack)
+		for i := len(e.stack); i < len(next); i++ {
+			next[i] = newIntersectTermsEnumFrame(e, i)
+		}
+		e.stack = next
+	}
+	assert(e.stack[ord].ord == ord)
+	return e.stack[ord]
+}
+
+func (e *IntersectTermsEnum) getArc(ord int) *fst.Arc {
+	if ord >= len(e.arcs) {
+		next := make([]*fst.Arc, util.Oversize(1+ord, util.NUM_BYTES_OBJECT_REF))
+		copy(next, e.arcs)
+		for i := len(e.arcs); i < len(next); i++ {
+			next[i] = &fst.Arc{}
+		}
+		e.arcs = next
+	}
+	return e.arcs[ord]
+}
+
+func (e *IntersectTermsEnum) pushFrame(state int) (*intersectTermsEnumFrame, error) {
+	f := e.frame(1 + e.currentFrame.ord)
+
+	f.fp, f.fpOrig = e.currentFrame.lastSubFP, e.currentFrame.lastSubFP
+	f.prefix = e.currentFrame.prefix + e.currentFrame.suffix
+	// fmt.Printf("    pushFrame state=%v prefix=%v\n", state, f.prefix)
+	f.setState(state)
+
+	// Walk the arc through the index -- we only
+	// "bother" with this so we can get the floor data
+	// from the index and skip floor blocks when
+	// possible:
+	arc := e.currentFrame.arc
+	idx := e.currentFrame.prefix
+	assert(e.currentFrame.suffix > 0)
+	output := e.currentFrame.outputPrefix
+	for idx < f.prefix {
+		target := int(e.term.At(idx))
+		// TODO: we could be more efficient for the next()
+		// case by using current arc as starting point,
+		// passed to findTargetArc
+		var err error
+		if arc, err = e.fr.index.FindTargetArc(target, arc, e.getArc(1+idx), e.fstReader); err != nil {
+			return nil, err
+		}
+		assert(arc != nil)
+		output = fstOutputs.Add(output, arc.Output)
+		idx++
+	}
+
+	f.arc = arc
+	f.outputPrefix = output
+	assert(arc.IsFinal())
+	if err := f.load(fstOutputs.Add(output, arc.NextFinalOutput).([]byte)); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (e *IntersectTermsEnum) Term() []byte {
+	return e.term.Bytes()[:e.term.Length()]
+}
+
+func (e *IntersectTermsEnum) DocFreq() (int, error) {
+	if err := e.currentFrame.decodeMetaData(); err != nil {
+		return 0, err
+	}
+	return e.currentFrame.termState.DocFreq, nil
+}
+
+func (e *IntersectTermsEnum) TotalTermFreq() (int64, error) {
+	if err := e.currentFrame.decodeMetaData(); err != nil {
+		return 0, err
+	}
+	return e.currentFrame.termState.TotalTermFreq, nil
+}
+
+func (e *IntersectTermsEnum) DocsByFlags(skipDocs util.Bits, reuse DocsEnum, flags int) (DocsEnum, error) {
+	if err := e.currentFrame.decodeMetaData(); err != nil {
+		return nil, err
+	}
+	return e.fr.parent.postingsReader.Docs(e.fr.fieldInfo, e.currentFrame.termState, skipDocs, reuse, flags)
+}
+
+func (e *IntersectTermsEnum) DocsAndPositionsByFlags(skipDocs util.Bits, reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error) {
+	if e.fr.fieldInfo.IndexOptions() < INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS {
+		// Positions were not indexed:
+		return nil, nil
+	}
+
+	if err := e.currentFrame.decodeMetaData(); err != nil {
+		return nil, err
+	}
+	return e.fr.parent.postingsReader.DocsAndPositions(e.fr.fieldInfo, e.currentFrame.termState, skipDocs, reuse, flags)
+}
+
+func (e *IntersectTermsEnum) state() int {
+	state := e.currentFrame.state
+	for idx := 0; idx < e.currentFrame.suffix; idx++ {
+		state = e.runAutomaton.Step(state, int(e.currentFrame.suffixBytes[e.currentFrame.startBytePos+idx]))
+		assert(state != -1)
+	}
+	return state
+}
+
+/*
+NOTE: specialized to only doing the first-time seek, but we could
+generalize it to allow arbitrary seekExact/Ceil. Note that this is a
+seekFloor!
+*/
+func (e *IntersectTermsEnum) seekToStartTerm(target []byte) (err error) {
+	// fmt.Printf("seek to startTerm=%v\n", brToString(target))
+	assert(e.currentFrame.ord == 0)
+	e.term.Grow(len(target))
+	assert(e.arcs[0] == e.currentFrame.arc)
+
+	for idx := 0; idx <= len(target); idx++ {
+		for {
+			cf := e.currentFrame
+			savePos := cf.suffixesReader.Position()
+			saveStartBytePos := cf.startBytePos
+			saveSuffix := cf.suffix
+			saveLastSubFP := cf.lastSubFP
+			saveTermBlockOrd := cf.termState.TermBlockOrd
+
+			isSubBlock, err := cf.next()
+			if err != nil {
+				return err
+			}
+
+			// fmt.Printf("    cycle ent=%v (of %v) prefix=%v suffix=%v isBlock=%v\n",
+			// 	cf.nextEnt, cf.entCount, cf.prefix, cf.suffix, isSubBlock)
+			e.copyTerm()
+
+			if isSubBlock && bytes.HasPrefix(target, e.Term()) {
+				// Recurse
+				// fmt.Println("      recurse!")
+				if e.currentFrame, err = e.pushFrame(e.state()); err != nil {
+					return err
+				}
+				break
+			}
+
+			cmp := bytes.Compare(e.Term(), target)
+			if cmp < 0 {
+				if cf.nextEnt == cf.entCount {
+					if !cf.isLastInFloor {
+						// fmt.Println("  load floorBlock")
+						if err = cf.loadNextFloorBlock(); err != nil {
+							return err
+						}
+						continue
+					}
+					// fmt.Printf("  return term=%v\n", brToString(e.Term()))
+					return nil
+				}
+				continue
+			} else if cmp == 0 {
+				// fmt.Printf("  return term=%v\n", brToString(e.Term()))
+				return nil
+			}
+
+			// Fallback to prior entry: the semantics of
+			// this method is that the first call to
+			// next() will return the term after the
+			// requested term
+			cf.nextEnt--
+			cf.lastSubFP = saveLastSubFP
+			cf.startBytePos = saveStartBytePos
+			cf.suffix = saveSuffix
+			cf.suffixesReader.Pos = savePos
+			cf.termState.TermBlockOrd = saveTermBlockOrd
+			e.copyTerm()
+			// If the last entry was a block we don't
+			// need to bother recursing and pushing to
+			// the last term under it because the first
+			// next() will simply skip the frame anyway
+			return nil
+		}
+	}
+
+	panic("should not be here")
+}
+
+func (e *IntersectTermsEnum) Next() (term []byte, err error) {
+	// fmt.Printf("\nintEnum.next seg=%v\n", e.fr.parent.segment)
+	// fmt.Printf("  frame ord=%v prefix=%v state=%v lastInFloor?=%v fp=%v trans=%v outputPrefix=%v\n",
+	// 	e.currentFrame.ord, brToString(e.term.Bytes()[:e.currentFrame.prefix]),
+	// 	e.currentFrame.state, e.currentFrame.isLastInFloor, e.currentFrame.fp,
+	// 	e.currentFrame.transition, e.currentFrame.outputPrefix)
+
+nextTerm:
+	for {
+		// Pop finished frames
+		for e.currentFrame.nextEnt == e.currentFrame.entCount {
+			if !e.currentFrame.isLastInFloor {
+				// fmt.Println("    next-floor-block")
+				if err = e.currentFrame.loadNextFloorBlock(); err != nil {
+					return nil, err
+				}
+			} else {
+				// fmt.Println("  pop frame")
+				if e.currentFrame.ord == 0 {
+					return nil, nil
+				}
+				lastFP := e.currentFrame.fpOrig
+				e.currentFrame = e.stack[e.currentFrame.ord-1]
+				assert(e.currentFrame.lastSubFP == lastFP)
+			}
+		}
+
+		isSubBlock, err := e.currentFrame.next()
+		if err != nil {
+			return nil, err
+		}
+		cf := e.currentFrame
+		// fmt.Printf("    %v %v (of %v) suffix=%v\n", isSubBlock, cf.nextEnt, cf.entCount,
+		// 	brToString(cf.suffixBytes[cf.startBytePos:cf.startBytePos+cf.suffix]))
+
+		if cf.suffix != 0 {
+			label := int(cf.suffixBytes[cf.startBytePos])
+			for label > cf.curTransitionMax {
+				if cf.transitionIndex >= cf.transitionCount-1 {
+					// Stop processing this frame -- no further
+					// matches are possible because we've moved
+					// beyond what the max transition will allow
+					// fmt.Printf("      break: trans=%v\n", cf.transition)
+
+					// sneaky!  forces a pop above
+					cf.isLastInFloor = true
+					cf.nextEnt = cf.entCount
+					continue nextTerm
+				}
+				cf.transitionIndex++
+				e.compiled.Automaton.NextTransition(cf.transition)
+				cf.curTransitionMax = cf.transition.Max
+				// fmt.Printf("      next trans=%v\n", cf.transition)
+			}
+		}
+
+		// First test the common suffix, if set:
+		if e.commonSuffix != nil && !isSubBlock {
+			termLen := cf.prefix + cf.suffix
+			if termLen < len(e.commonSuffix) {
+				// No match
+				// fmt.Println("      skip: common suffix length")
+				continue nextTerm
+			}
+
+			suffixBytes := cf.suffixBytes
+			commonSuffixBytes := e.commonSuffix
+
+			lenInPrefix := len(e.commonSuffix) - cf.suffix
+			var suffixBytesPos int
+			commonSuffixBytesPos := 0
+
+			if lenInPrefix > 0 {
+				// A prefix of the common suffix overlaps with
+				// the suffix of the block prefix so we first
+				// test whether the prefix part matches:
+				termBytes := e.term.Bytes()
+				termBytesPos := cf.prefix - lenInPrefix
+				assert(termBytesPos >= 0)
+				termBytesPosEnd := cf.prefix
+				for termBytesPos < termBytesPosEnd {
+					if termBytes[termBytesPos] != commonSuffixBytes[commonSuffixBytesPos] {
+						// fmt.Println("      skip: common suffix mismatch (in prefix)")
+						continue nextTerm
+					}
+					termBytesPos++
+					commonSuffixBytesPos++
+				}
+				suffixBytesPos = cf.startBytePos
+			} else {
+				suffixBytesPos = cf.startBytePos + cf.suffix - len(e.commonSuffix)
+			}
+
+			// Test overlapping suffix part:
+			commonSuffixBytesPosEnd := len(e.commonSuffix)
+			for commonSuffixBytesPos < commonSuffixBytesPosEnd {
+				if suffixBytes[suffixBytesPos] != commonSuffixBytes[commonSuffixBytesPos] {
+					// fmt.Println("      skip: common suffix mismatch")
+					continue nextTerm
+				}
+				suffixBytesPos++
+				commonSuffixBytesPos++
+			}
+		}
+
+		// TODO: maybe we should do the same linear test
+		// that AutomatonTermsEnum does, so that if we
+		// reach a part of the automaton where .* is
+		// "temporarily" accepted, we just blindly .next()
+		// until the limit
+
+		// See if the term prefix matches the automaton:
+		state := cf.state
+		for idx := 0; idx < cf.suffix; idx++ {
+			state = e.runAutomaton.Step(state, int(cf.suffixBytes[cf.startBytePos+idx]))
+			if state == -1 {
+				// No match
+				continue nextTerm
+			}
+		}
+
+		if isSubBlock {
+			// Match!  Recurse:
+			// fmt.Printf("      sub-block match to state=%v; recurse fp=%v\n", state, cf.lastSubFP)
+			e.copyTerm()
+			if e.currentFrame, err = e.pushFrame(state); err != nil {
+				return nil, err
+			}
+		} else if e.runAutomaton.IsAccept(state) {
+			e.copyTerm()
+			// fmt.Printf("      term match to state=%v; return term=%v\n", state, brToString(e.Term()))
+			assert2(e.savedStartTerm == nil || bytes.Compare(e.Term(), e.savedStartTerm) > 0,
+				"saveStartTerm=%v term=%v", string(e.savedStartTerm), string(e.Term()))
+			return e.Term(), nil
+		}
+	}
+}
+
+func (e *IntersectTermsEnum) copyTerm() {
+	cf := e.currentFrame
+	// fmt.Printf("      copyTerm cur.prefix=%v cur.suffix=%v\n", cf.prefix, cf.suffix)
+	length := cf.prefix + cf.suffix
+	e.term.Grow(length)
+	e.term.SetLength(length)
+	copy(e.term.Bytes()[cf.prefix:length], cf.suffixBytes[cf.startBytePos:cf.startBytePos+cf.suffix])
+}
+
+func (e *IntersectTermsEnum) Comparator() sort.Interface {
+	return util.UTF8SortedAsUnicodeComparator
+}
+
+func (e *IntersectTermsEnum) SeekExact(text []byte) (bool, error) {
+	panic("not supported")
+}
+
+func (e *IntersectTermsEnum) SeekExactByPosition(ord int64) error {
+	panic("not supported")
+}
+
+func (e *IntersectTermsEnum) Ord() int64 {
+	panic("not supported")
+}
+
+func (e *IntersectTermsEnum) SeekCeil(text []byte) (SeekStatus, error) {
+	panic("not supported")
+}
+
+func (e *IntersectTermsEnum) String() string {
+	return "IntersectTermsEnum"
+}
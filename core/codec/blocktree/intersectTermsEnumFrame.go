@@ -0,0 +1,365 @@
+package blocktree
+
+import (
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	"github.com/balzaczyy/golucene/core/util/fst"
+)
+
+// blocktree/IntersectTermsEnumFrame.java
+
+type intersectTermsEnumFrame struct {
+	ord       int
+	fp        int64
+	fpOrig    int64
+	fpEnd     int64
+	lastSubFP int64
+
+	// State in automaton
+	state int
+
+	metaDataUpto int
+
+	suffixBytes    []byte
+	suffixesReader *store.ByteArrayDataInput
+
+	statBytes   []byte
+	statsReader *store.ByteArrayDataInput
+
+	floorData       []byte
+	floorDataReader *store.ByteArrayDataInput
+
+	// Length of prefix shared by all terms in this block
+	prefix int
+
+	// Number of entries (term or sub-block) in this block
+	entCount int
+
+	// Which term we will next read
+	nextEnt int
+
+	// True if this block is either not a floor block,
+	// or, it's the last sub-block of a floor block
+	isLastInFloor bool
+
+	// True if all entries are terms
+	isLeafBlock bool
+
+	numFollowFloorBlocks int
+	nextFloorLabel       int
+
+	transition       *automaton.Transition
+	curTransitionMax int
+	transitionIndex  int
+	transitionCount  int
+
+	arc *fst.Arc
+
+	termState *BlockTermState
+
+	// metadata buffer, holding monotonic values
+	longs []int64
+	// metadata buffer, holding general values
+	bytes       []byte
+	bytesReader *store.ByteArrayDataInput
+
+	// Cumulative output so far
+	outputPrefix interface{}
+
+	startBytePos int
+	suffix       int
+
+	ite *IntersectTermsEnum
+}
+
+func newIntersectTermsEnumFrame(ite *IntersectTermsEnum, ord int) *intersectTermsEnumFrame {
+	f := &intersectTermsEnumFrame{
+		ord:             ord,
+		suffixBytes:     make([]byte, 128),
+		suffixesReader:  store.NewEmptyByteArrayDataInput(),
+		statBytes:       make([]byte, 64),
+		statsReader:     store.NewEmptyByteArrayDataInput(),
+		floorData:       make([]byte, 32),
+		floorDataReader: store.NewEmptyByteArrayDataInput(),
+		transition:      new(automaton.Transition),
+		longs:           make([]int64, ite.fr.longsSize),
+		ite:             ite,
+	}
+	f.termState = ite.fr.parent.postingsReader.NewTermState()
+	f.termState.TotalTermFreq = -1
+	return f
+}
+
+func (f *intersectTermsEnumFrame) loadNextFloorBlock() error {
+	assert(f.numFollowFloorBlocks > 0)
+	// fmt.Printf("    loadNextFloorBlock trans=%v\n", f.transition)
+
+	for {
+		code, err := f.floorDataReader.ReadVLong()
+		if err != nil {
+			return err
+		}
+		f.fp = f.fpOrig + int64(uint64(code)>>1)
+		f.numFollowFloorBlocks--
+		// fmt.Printf("    skip floor block2!  nextFloorLabel=%c vs target=%c newFP=%v numFollowFloorBlocks=%v\n",
+		// 	f.nextFloorLabel, f.transition.Min, f.fp, f.numFollowFloorBlocks)
+		if f.numFollowFloorBlocks != 0 {
+			b, err := f.floorDataReader.ReadByte()
+			if err != nil {
+				return err
+			}
+			f.nextFloorLabel = int(b)
+		} else {
+			f.nextFloorLabel = 256
+		}
+		// fmt.Printf("    nextFloorLabel=%c\n", f.nextFloorLabel)
+		if f.numFollowFloorBlocks == 0 || f.nextFloorLabel > f.transition.Min {
+			break
+		}
+	}
+
+	return f.load(nil)
+}
+
+func (f *intersectTermsEnumFrame) setState(state int) {
+	a := f.ite.compiled.Automaton
+	f.state = state
+	f.transitionIndex = 0
+	f.transitionCount = a.NumTransitions(state)
+	if f.transitionCount != 0 {
+		a.InitTransition(state, f.transition)
+		a.NextTransition(f.transition)
+		f.curTransitionMax = f.transition.Max
+	} else {
+		f.curTransitionMax = -1
+	}
+}
+
+func (f *intersectTermsEnumFrame) load(frameIndexData []byte) (err error) {
+	// fmt.Printf("    load fp=%v fpOrig=%v frameIndexData=%v trans=%v state=%v\n",
+	// 	f.fp, f.fpOrig, frameIndexData, f.transition, f.state)
+
+	if frameIndexData != nil && f.transitionCount != 0 {
+		// Floor frame
+		if len(f.floorData) < len(frameIndexData) {
+			f.floorData = make([]byte, util.Oversize(len(frameIndexData), 1))
+		}
+		copy(f.floorData, frameIndexData)
+		f.floorDataReader.Reset(f.floorData[:len(frameIndexData)])
+		// Skip first long -- has redundant fp, hasTerms
+		// flag, isFloor flag
+		code, err := f.floorDataReader.ReadVLong()
+		if err != nil {
+			return err
+		}
+		if (code & BTT_OUTPUT_FLAG_IS_FLOOR) != 0 {
+			if f.numFollowFloorBlocks, err = asInt(f.floorDataReader.ReadVInt()); err != nil {
+				return err
+			}
+			b, err := f.floorDataReader.ReadByte()
+			if err != nil {
+				return err
+			}
+			f.nextFloorLabel = int(b)
+			// fmt.Printf("    numFollowFloorBlocks=%v nextFloorLabel=%v\n",
+			// 	f.numFollowFloorBlocks, f.nextFloorLabel)
+
+			// If current state is accept, we must process
+			// first block in case it has empty suffix:
+			if !f.ite.runAutomaton.IsAccept(f.state) {
+				// Maybe skip floor blocks:
+				assert2(f.transitionIndex == 0, "transitionIndex=%v", f.transitionIndex)
+				for f.numFollowFloorBlocks != 0 && f.nextFloorLabel <= f.transition.Min {
+					if code, err = f.floorDataReader.ReadVLong(); err != nil {
+						return err
+					}
+					f.fp = f.fpOrig + int64(uint64(code)>>1)
+					f.numFollowFloorBlocks--
+					// fmt.Printf("    skip floor block!  nextFloorLabel=%c vs target=%c newFP=%v numFollowFloorBlocks=%v\n",
+					// 	f.nextFloorLabel, f.transition.Min, f.fp, f.numFollowFloorBlocks)
+					if f.numFollowFloorBlocks != 0 {
+						if b, err = f.floorDataReader.ReadByte(); err != nil {
+							return err
+						}
+						f.nextFloorLabel = int(b)
+					} else {
+						f.nextFloorLabel = 256
+					}
+				}
+			}
+		}
+	}
+
+	in := f.ite.in
+	if err = in.Seek(f.fp); err != nil {
+		return err
+	}
+	code, err := asInt(in.ReadVInt())
+	if err != nil {
+		return err
+	}
+	f.entCount = int(uint(code) >> 1)
+	assert(f.entCount > 0)
+	f.isLastInFloor = (code & 1) != 0
+
+	// term suffixes:
+	if code, err = asInt(in.ReadVInt()); err != nil {
+		return err
+	}
+	f.isLeafBlock = (code & 1) != 0
+	numBytes := int(uint(code) >> 1)
+	// fmt.Printf("      entCount=%v lastInFloor?=%v leafBlock?=%v numSuffixBytes=%v\n",
+	// 	f.entCount, f.isLastInFloor, f.isLeafBlock, numBytes)
+	if len(f.suffixBytes) < numBytes {
+		f.suffixBytes = make([]byte, util.Oversize(numBytes, 1))
+	}
+	if err = in.ReadBytes(f.suffixBytes[:numBytes]); err != nil {
+		return err
+	}
+	f.suffixesReader.Reset(f.suffixBytes[:numBytes])
+
+	// stats
+	if numBytes, err = asInt(in.ReadVInt()); err != nil {
+		return err
+	}
+	if len(f.statBytes) < numBytes {
+		f.statBytes = make([]byte, util.Oversize(numBytes, 1))
+	}
+	if err = in.ReadBytes(f.statBytes[:numBytes]); err != nil {
+		return err
+	}
+	f.statsReader.Reset(f.statBytes[:numBytes])
+	f.metaDataUpto = 0
+
+	f.termState.TermBlockOrd = 0
+	f.nextEnt = 0
+
+	// metadata
+	if numBytes, err = asInt(in.ReadVInt()); err != nil {
+		return err
+	}
+	if f.bytes == nil {
+		f.bytes = make([]byte, util.Oversize(numBytes, 1))
+		f.bytesReader = store.NewEmptyByteArrayDataInput()
+	} else if len(f.bytes) < numBytes {
+		f.bytes = make([]byte, util.Oversize(numBytes, 1))
+	}
+	if err = in.ReadBytes(f.bytes[:numBytes]); err != nil {
+		return err
+	}
+	f.bytesReader.Reset(f.bytes[:numBytes])
+
+	if !f.isLastInFloor {
+		// Sub-blocks of a single floor block are always
+		// written one after another -- tail recurse:
+		f.fpEnd = in.FilePointer()
+	}
+	return nil
+}
+
+// TODO: maybe add scanToLabel; should give perf boost
+
+// Decodes next entry; returns true if it's a sub-block
+func (f *intersectTermsEnumFrame) next() (bool, error) {
+	if f.isLeafBlock {
+		return f.nextLeaf()
+	}
+	return f.nextNonLeaf()
+}
+
+func (f *intersectTermsEnumFrame) nextLeaf() (bool, error) {
+	assert2(f.nextEnt != -1 && f.nextEnt < f.entCount,
+		"nextEnt=%v entCount=%v fp=%v", f.nextEnt, f.entCount, f.fp)
+	f.nextEnt++
+	var err error
+	if f.suffix, err = asInt(f.suffixesReader.ReadVInt()); err != nil {
+		return false, err
+	}
+	f.startBytePos = f.suffixesReader.Position()
+	f.suffixesReader.SkipBytes(int64(f.suffix))
+	return false, nil
+}
+
+func (f *intersectTermsEnumFrame) nextNonLeaf() (bool, error) {
+	assert2(f.nextEnt != -1 && f.nextEnt < f.entCount,
+		"nextEnt=%v entCount=%v fp=%v", f.nextEnt, f.entCount, f.fp)
+	f.nextEnt++
+	code, err := f.suffixesReader.ReadVInt()
+	if err != nil {
+		return false, err
+	}
+	f.suffix = int(uint32(code) >> 1)
+	f.startBytePos = f.suffixesReader.Position()
+	f.suffixesReader.SkipBytes(int64(f.suffix))
+	if (code & 1) == 0 {
+		// A normal term
+		f.termState.TermBlockOrd++
+		return false, nil
+	}
+	// A sub-block; make sub-FP absolute:
+	subCode, err := f.suffixesReader.ReadVLong()
+	if err != nil {
+		return false, err
+	}
+	f.lastSubFP = f.fp - subCode
+	return true, nil
+}
+
+func (f *intersectTermsEnumFrame) termBlockOrd() int {
+	if f.isLeafBlock {
+		return f.nextEnt
+	}
+	return f.termState.TermBlockOrd
+}
+
+func (f *intersectTermsEnumFrame) decodeMetaData() (err error) {
+	// lazily catch up on metadata decode:
+	limit := f.termBlockOrd()
+	absolute := f.metaDataUpto == 0
+	assert(limit > 0)
+
+	// TODO: better API would be "jump straight to term=N"???
+	for f.metaDataUpto < limit {
+		// TODO: we could make "tiers" of metadata, ie,
+		// decode docFreq/totalTF but don't decode postings
+		// metadata; this way caller could get
+		// docFreq/totalTF w/o paying decode cost for
+		// postings
+
+		// TODO: if docFreq were bulk decoded we could
+		// just skipN here:
+
+		// stats
+		if f.termState.DocFreq, err = asInt(f.statsReader.ReadVInt()); err != nil {
+			return err
+		}
+		// fmt.Printf("    dF=%v\n", f.termState.DocFreq)
+		fr := f.ite.fr
+		if fr.fieldInfo.IndexOptions() != INDEX_OPT_DOCS_ONLY {
+			var n int64
+			if n, err = f.statsReader.ReadVLong(); err != nil {
+				return err
+			}
+			f.termState.TotalTermFreq = int64(f.termState.DocFreq) + n
+			// fmt.Printf("    totTF=%v\n", f.termState.TotalTermFreq)
+		}
+		// metadata
+		for i := 0; i < fr.longsSize; i++ {
+			if f.longs[i], err = f.bytesReader.ReadVLong(); err != nil {
+				return err
+			}
+		}
+		if err = fr.parent.postingsReader.DecodeTerm(f.longs,
+			f.bytesReader, fr.fieldInfo, f.termState, absolute); err != nil {
+			return err
+		}
+
+		f.metaDataUpto++
+		absolute = false
+	}
+	f.termState.TermBlockOrd = f.metaDataUpto
+	return nil
+}
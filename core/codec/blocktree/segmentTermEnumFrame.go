@@ -144,7 +144,7 @@ func (f *segmentTermsEnumFrame) loadBlock() (err error) {
 	assert(f.entCount > 0)
 	f.isLastInFloor = (code & 1) != 0
 
-	assert2(f.arc == nil || !f.isLastInFloor || !f.isFloor,
+	assert2(f.arc == nil || f.isLastInFloor || f.isFloor,
 		"fp=%v arc=%v isFloor=%v isLastInFloor=%v",
 		f.fp, f.arc, f.isFloor, f.isLastInFloor)
 
@@ -234,20 +234,113 @@ func (f *segmentTermsEnumFrame) rewind() {
 	}
 }
 
-func (f *segmentTermsEnumFrame) next() bool {
+func (f *segmentTermsEnumFrame) loadNextFloorBlock() error {
+	// fmt.Printf("    loadNextFloorBlock fp=%v fpEnd=%v\n", f.fp, f.fpEnd)
+	assert2(f.arc == nil || f.isFloor, "arc=%v isFloor=%v", f.arc, f.isFloor)
+	f.fp = f.fpEnd
+	f.nextEnt = -1
+	return f.loadBlock()
+}
+
+// Decodes next entry; returns true if it's a sub-block
+func (f *segmentTermsEnumFrame) next() (bool, error) {
 	if f.isLeafBlock {
 		return f.nextLeaf()
 	}
 	return f.nextNonLeaf()
 }
 
-// Decodes next entry; returns true if it's a sub-block
-func (f *segmentTermsEnumFrame) nextLeaf() bool {
-	panic("not implemented yet")
+func (f *segmentTermsEnumFrame) nextLeaf() (bool, error) {
+	assert2(f.nextEnt != -1 && f.nextEnt < f.entCount,
+		"nextEnt=%v entCount=%v fp=%v", f.nextEnt, f.entCount, f.fp)
+	f.nextEnt++
+	var err error
+	if f.suffix, err = asInt(f.suffixesReader.ReadVInt()); err != nil {
+		return false, err
+	}
+	f.startBytePos = f.suffixesReader.Position()
+	if err = f.readSuffix(); err != nil {
+		return false, err
+	}
+	// A normal term
+	f.ste.termExists = true
+	return false, nil
 }
 
-func (f *segmentTermsEnumFrame) nextNonLeaf() bool {
-	panic("not implemented yet")
+func (f *segmentTermsEnumFrame) nextNonLeaf() (bool, error) {
+	assert2(f.nextEnt != -1 && f.nextEnt < f.entCount,
+		"nextEnt=%v entCount=%v fp=%v", f.nextEnt, f.entCount, f.fp)
+	f.nextEnt++
+	code, err := f.suffixesReader.ReadVInt()
+	if err != nil {
+		return false, err
+	}
+	f.suffix = int(uint32(code) >> 1)
+	f.startBytePos = f.suffixesReader.Position()
+	if err = f.readSuffix(); err != nil {
+		return false, err
+	}
+	if (code & 1) == 0 {
+		// A normal term
+		f.ste.termExists = true
+		f.subCode = 0
+		f.state.TermBlockOrd++
+		return false, nil
+	}
+	// A sub-block; make sub-FP absolute:
+	f.ste.termExists = false
+	if f.subCode, err = f.suffixesReader.ReadVLong(); err != nil {
+		return false, err
+	}
+	f.lastSubFP = f.fp - f.subCode
+	// fmt.Printf("    lastSubFP=%v\n", f.lastSubFP)
+	return true, nil
+}
+
+/* Reads the current entry's suffix into the enum's term, after the block prefix. */
+func (f *segmentTermsEnumFrame) readSuffix() error {
+	termLength := f.prefix + f.suffix
+	f.ste.term.SetLength(termLength)
+	f.ste.term.Grow(termLength)
+	return f.suffixesReader.ReadBytes(f.ste.term.Bytes()[f.prefix:termLength])
+}
+
+// Only rare cases use this:
+func (f *segmentTermsEnumFrame) scanToSubBlock(subFP int64) error {
+	assert(!f.isLeafBlock)
+	// fmt.Printf("  scanToSubBlock fp=%v subFP=%v entCount=%v lastSubFP=%v\n",
+	// 	f.fp, subFP, f.entCount, f.lastSubFP)
+	if f.lastSubFP == subFP {
+		// fmt.Println("    already positioned")
+		return nil
+	}
+	assert2(subFP < f.fp, "fp=%v subFP=%v", f.fp, subFP)
+	targetSubCode := f.fp - subFP
+	for {
+		assert(f.nextEnt < f.entCount)
+		f.nextEnt++
+		code, err := f.suffixesReader.ReadVInt()
+		if err != nil {
+			return err
+		}
+		if f.isLeafBlock {
+			f.suffixesReader.SkipBytes(int64(code))
+		} else {
+			f.suffixesReader.SkipBytes(int64(uint32(code) >> 1))
+		}
+		if (code & 1) != 0 {
+			subCode, err := f.suffixesReader.ReadVLong()
+			if err != nil {
+				return err
+			}
+			if targetSubCode == subCode {
+				f.lastSubFP = subFP
+				return nil
+			}
+		} else {
+			f.state.TermBlockOrd++
+		}
+	}
 }
 
 // TODO: make this array'd so we can do bin search?
@@ -261,10 +354,10 @@ func (f *segmentTermsEnumFrame) scanToFloorFrame(target []byte) {
 	}
 
 	targetLabel := int(target[f.prefix])
-	fmt.Printf("    scanToFloorFrame fpOrig=%v targetLabel=%x vs nextFloorLabel=%x numFollowFloorBlocks=%v\n",
-		f.fpOrig, targetLabel, f.nextFloorLabel, f.numFollowFloorBlocks)
+	// fmt.Printf("    scanToFloorFrame fpOrig=%v targetLabel=%x vs nextFloorLabel=%x numFollowFloorBlocks=%v\n",
+	// 	f.fpOrig, targetLabel, f.nextFloorLabel, f.numFollowFloorBlocks)
 	if targetLabel < f.nextFloorLabel {
-		fmt.Println("      already on correct block")
+		// fmt.Println("      already on correct block")
 		return
 	}
 
@@ -283,20 +376,22 @@ func (f *segmentTermsEnumFrame) scanToFloorFrame(target []byte) {
 
 		if f.isLastInFloor {
 			f.nextFloorLabel = 256
-			fmt.Printf("        stop!  last block nextFloorLabel=%x\n", f.nextFloorLabel)
+			// fmt.Printf("        stop!  last block nextFloorLabel=%x\n", f.nextFloorLabel)
+			break
+		}
+		b, _ := f.floorDataReader.ReadByte() // ignore error
+		f.nextFloorLabel = int(b)
+		if targetLabel < f.nextFloorLabel {
+			// fmt.Printf("        stop!  nextFloorLabel=%x\n", f.nextFloorLabel)
 			break
-		} else {
-			panic("niy")
 		}
 	}
 
 	if newFP != f.fp {
 		// Force re-load of the block:
-		fmt.Printf("      force switch to fp=%v oldFP=%v\n", newFP, f.fp)
+		// fmt.Printf("      force switch to fp=%v oldFP=%v\n", newFP, f.fp)
 		f.nextEnt = -1
 		f.fp = newFP
-	} else {
-		//
 	}
 }
 
@@ -481,7 +576,7 @@ func (f *segmentTermsEnumFrame) scanToTermLeaf(target []byte, exactOnly bool) (s
 	// to the foo* block, but the last term in this block
 	// was fooz (and, eg, first term in the next block will
 	// bee fop).
-	fmt.Println("      block end")
+	// fmt.Println("      block end")
 	if exactOnly {
 		f.fillTerm()
 	}
@@ -497,14 +592,18 @@ func (f *segmentTermsEnumFrame) scanToTermLeaf(target []byte, exactOnly bool) (s
 func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 	exactOnly bool) (status SeekStatus, err error) {
 
-	fmt.Printf(
-		"    scanToTermNonLeaf: block fp=%v prefix=%v nextEnt=%v (of %v) target=%v term=%v",
-		f.fp, f.prefix, f.nextEnt, f.entCount, brToString(target), "" /*brToString(term)*/)
+	// fmt.Printf(
+	// 	"    scanToTermNonLeaf: block fp=%v prefix=%v nextEnt=%v (of %v) target=%v term=%v\n",
+	// 	f.fp, f.prefix, f.nextEnt, f.entCount, brToString(target), "" /*brToString(term)*/)
 
 	assert(f.nextEnt != -1)
 
 	if f.nextEnt == f.entCount {
-		panic("not implemented yet")
+		if exactOnly {
+			f.fillTerm()
+			f.ste.termExists = f.subCode == 0
+		}
+		return SEEK_STATUS_END, nil
 	}
 
 	assert(f.prefixMatches(target))
@@ -571,10 +670,34 @@ func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 				f.fillTerm()
 
 				if !exactOnly && !f.ste.termExists {
-					panic("niy")
+					// We are on a sub-block, and caller wants us to position
+					// to the next term after the target, so we must recurse
+					// into the sub-frame(s):
+					ste := f.ste
+					if ste.currentFrame, err = ste.pushFrameAt(nil, ste.currentFrame.lastSubFP, termLen); err != nil {
+						return 0, err
+					}
+					if err = ste.currentFrame.loadBlock(); err != nil {
+						return 0, err
+					}
+					for {
+						isSubBlock, err := ste.currentFrame.next()
+						if err != nil {
+							return 0, err
+						}
+						if !isSubBlock {
+							break
+						}
+						if ste.currentFrame, err = ste.pushFrameAt(nil, ste.currentFrame.lastSubFP, ste.term.Length()); err != nil {
+							return 0, err
+						}
+						if err = ste.currentFrame.loadBlock(); err != nil {
+							return 0, err
+						}
+					}
 				}
 
-				fmt.Println("        not found")
+				// fmt.Println("        not found")
 				return SEEK_STATUS_NOT_FOUND, nil
 			} else if stop {
 				// Exact match!
@@ -584,7 +707,7 @@ func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 
 				assert(f.ste.termExists)
 				f.fillTerm()
-				fmt.Println("        found!")
+				// fmt.Println("        found!")
 				return SEEK_STATUS_FOUND, nil
 			}
 		}
@@ -603,7 +726,7 @@ func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 	// E.g., target could be foozzz, and terms index pointed us to the
 	// foo* block, but the last term in this block was fooz (and, e.g.,
 	// first term in the next block will be fop).
-	fmt.Println("      block end")
+	// fmt.Println("      block end")
 	if exactOnly {
 		f.fillTerm()
 	}
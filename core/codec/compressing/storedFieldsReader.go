@@ -10,6 +10,7 @@ import (
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
 	"github.com/balzaczyy/golucene/core/util/packed"
+	"math"
 )
 
 // codec/compressing/CompressingStoredFieldsReader.java
@@ -194,13 +195,29 @@ func (r *CompressingStoredFieldsReader) readField(in util.DataInput,
 		}
 		visitor.StringField(info, string(data))
 	case NUMERIC_INT:
-		panic("not implemented yet")
+		var v int32
+		if v, err = in.ReadInt(); err != nil {
+			return err
+		}
+		return visitor.IntField(info, int(v))
 	case NUMERIC_FLOAT:
-		panic("not implemented yet")
+		var v int32
+		if v, err = in.ReadInt(); err != nil {
+			return err
+		}
+		return visitor.FloatField(info, math.Float32frombits(uint32(v)))
 	case NUMERIC_LONG:
-		panic("not implemented yet")
+		var v int64
+		if v, err = in.ReadLong(); err != nil {
+			return err
+		}
+		return visitor.LongField(info, v)
 	case NUMERIC_DOUBLE:
-		panic("not implemented yet")
+		var v int64
+		if v, err = in.ReadLong(); err != nil {
+			return err
+		}
+		return visitor.DoubleField(info, math.Float64frombits(uint64(v)))
 	default:
 		panic(fmt.Sprintf("Unknown type flag: %x", bits))
 	}
@@ -342,7 +359,9 @@ func (r *CompressingStoredFieldsReader) VisitDocument(docID int, visitor StoredF
 		}
 		switch status {
 		case STORED_FIELD_VISITOR_STATUS_YES:
-			r.readField(documentInput, visitor, fieldInfo, bits)
+			if err = r.readField(documentInput, visitor, fieldInfo, bits); err != nil {
+				return err
+			}
 		case STORED_FIELD_VISITOR_STATUS_NO:
 			panic("not implemented yet")
 		case STORED_FIELD_VISITOR_STATUS_STOP:
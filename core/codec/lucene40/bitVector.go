@@ -12,6 +12,12 @@ import (
 const (
 	CODEC = "BitVector"
 
+	/* Version before version tracking was added: */
+	BV_VERSION_PRE = -1
+
+	/* First version: */
+	BV_VERSION_START = 0
+
 	/* Change DGaps to encode gaps between cleared bits, not set: */
 	BV_VERSION_DGAPS_CLEARED = 1
 
@@ -22,9 +28,10 @@ const (
 )
 
 type BitVector struct {
-	bits  []byte
-	size  int
-	count int
+	bits    []byte
+	size    int
+	count   int
+	version int32
 }
 
 func NewBitVector(n int) *BitVector {
@@ -42,6 +49,12 @@ func numBytes(size int) int {
 	return bytesLength
 }
 
+func (bv *BitVector) Clone() *BitVector {
+	bits := make([]byte, len(bv.bits))
+	copy(bits, bv.bits)
+	return &BitVector{bits, bv.size, bv.count, bv.version}
+}
+
 func (bv *BitVector) Clear(bit int) {
 	assert2(bit >= 0 && bit < bv.size, "bit %v is out of bounds 0..%v", bit, bv.size-1)
 	bv.bits[bit>>3] &= ^(1 << (uint(bit) & 7))
@@ -138,6 +151,29 @@ func (bv *BitVector) InvertAll() {
 		for idx, v := range bv.bits {
 			bv.bits[idx] = byte(^v)
 		}
+		bv.clearUnusedBits()
+	}
+}
+
+/* Set all bits */
+func (bv *BitVector) setAll() {
+	for idx, _ := range bv.bits {
+		bv.bits[idx] = 0xff
+	}
+	bv.clearUnusedBits()
+	bv.count = bv.size
+}
+
+/*
+Clear any bits that are set beyond the last valid bit. Called after
+InvertAll() and setAll().
+*/
+func (bv *BitVector) clearUnusedBits() {
+	if len(bv.bits) > 0 {
+		if lastNBits := uint(bv.size) & 7; lastNBits != 0 {
+			mask := byte((1 << lastNBits) - 1)
+			bv.bits[len(bv.bits)-1] &= mask
+		}
 	}
 }
 
@@ -188,7 +224,172 @@ Indicates if the bit vector is sparse and should be saved as a d-gaps
 list, or dense, and should be saved as a bit set.
 */
 func (bv *BitVector) isSparse() bool {
-	panic("not implemented yet")
+	clearedCount := bv.size - bv.Count()
+	if clearedCount == 0 {
+		return true
+	}
+
+	avgGapLength := len(bv.bits) / clearedCount
+
+	// expected number of bytes for vInt encoding of each gap
+	var expectedDGapBytes int
+	switch {
+	case avgGapLength <= (1 << 7):
+		expectedDGapBytes = 1
+	case avgGapLength <= (1 << 14):
+		expectedDGapBytes = 2
+	case avgGapLength <= (1 << 21):
+		expectedDGapBytes = 3
+	case avgGapLength <= (1 << 28):
+		expectedDGapBytes = 4
+	default:
+		expectedDGapBytes = 5
+	}
+
+	// +1 because we write the byte itself that contains the set bit
+	bytesPerSetBit := expectedDGapBytes + 1
+
+	// note: adding 32 because we start with ((int) -1) to indicate
+	// d-gaps format.
+	expectedBits := int64(32 + 8*bytesPerSetBit*clearedCount)
+
+	// note: factor is for read/write of byte-arrays being faster than
+	// vints.
+	const factor = 10
+	return factor*expectedBits < int64(bv.size)
+}
+
+/*
+Constructs a bit vector from the file name in Directory d, as written
+by the Write() method.
+*/
+func ReadBitVector(d store.Directory, name string, ctx store.IOContext) (bv *BitVector, err error) {
+	var input store.ChecksumIndexInput
+	if input, err = d.OpenChecksumInput(name, ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = mergeError(err, input.Close())
+	}()
+
+	bv = new(BitVector)
+	firstInt, err := input.ReadInt()
+	if err != nil {
+		return nil, err
+	}
+
+	if firstInt == -2 {
+		// New format, with full header & version:
+		if bv.version, err = codec.CheckHeader(input, CODEC, BV_VERSION_START, BV_VERSION_CURRENT); err != nil {
+			return nil, err
+		}
+		var size int32
+		if size, err = input.ReadInt(); err != nil {
+			return nil, err
+		}
+		bv.size = int(size)
+	} else {
+		bv.version = BV_VERSION_PRE
+		bv.size = int(firstInt)
+	}
+	if bv.size == -1 {
+		if bv.version >= BV_VERSION_DGAPS_CLEARED {
+			err = bv.readClearedDgaps(input)
+		} else {
+			err = bv.readSetDgaps(input)
+		}
+	} else {
+		err = bv.readBits(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bv.version < BV_VERSION_DGAPS_CLEARED {
+		bv.InvertAll()
+	}
+
+	if bv.version >= BV_VERSION_CHECKSUM {
+		_, err = codec.CheckFooter(input)
+	} else {
+		err = codec.CheckEOF(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	bv.assertCount()
+	return bv, nil
+}
+
+/* Read as a bit set */
+func (bv *BitVector) readBits(input store.IndexInput) error {
+	count, err := input.ReadInt() // read count
+	if err != nil {
+		return err
+	}
+	bv.count = int(count)
+	bv.bits = make([]byte, numBytes(bv.size)) // allocate bits
+	return input.ReadBytes(bv.bits)
+}
+
+/* read as a d-gaps list */
+func (bv *BitVector) readSetDgaps(input store.IndexInput) error {
+	size, err := input.ReadInt() // (re)read size
+	if err != nil {
+		return err
+	}
+	bv.size = int(size)
+	count, err := input.ReadInt() // read count
+	if err != nil {
+		return err
+	}
+	bv.count = int(count)
+	bv.bits = make([]byte, numBytes(bv.size)) // allocate bits
+	last, n := 0, bv.Count()
+	for n > 0 {
+		gap, err := input.ReadVInt()
+		if err != nil {
+			return err
+		}
+		last += int(gap)
+		if bv.bits[last], err = input.ReadByte(); err != nil {
+			return err
+		}
+		n -= util.BitCount(bv.bits[last])
+		assert(n >= 0)
+	}
+	return nil
+}
+
+/* read as a d-gaps cleared bits list */
+func (bv *BitVector) readClearedDgaps(input store.IndexInput) error {
+	size, err := input.ReadInt() // (re)read size
+	if err != nil {
+		return err
+	}
+	bv.size = int(size)
+	count, err := input.ReadInt() // read count
+	if err != nil {
+		return err
+	}
+	bv.bits = make([]byte, numBytes(bv.size)) // allocate bits
+	bv.setAll()
+	bv.count = int(count)
+	last, numCleared := 0, bv.size-bv.count
+	for numCleared > 0 {
+		gap, err := input.ReadVInt()
+		if err != nil {
+			return err
+		}
+		last += int(gap)
+		if bv.bits[last], err = input.ReadByte(); err != nil {
+			return err
+		}
+		numCleared -= 8 - util.BitCount(bv.bits[last])
+		assert(numCleared >= 0 ||
+			last == len(bv.bits)-1 && numCleared == -(8-(bv.size&7)))
+	}
+	return nil
 }
 
 func (bv *BitVector) assertCount() {
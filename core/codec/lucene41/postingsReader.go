@@ -299,7 +299,7 @@ type blockDocsEnum struct {
 
 	docBufferUpto int
 
-	// skipper Lucene41SkipReader
+	skipper *SkipReader
 	skipped bool
 
 	startDocIn store.IndexInput
@@ -346,7 +346,7 @@ func newBlockDocsEnum(owner *Lucene41PostingsReader,
 		docIn:                  nil,
 		indexHasFreq:           fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS,
 		indexHasPos:            fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS,
-		indexHasOffsets:        fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS,
+		indexHasOffsets:        fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS,
 		indexHasPayloads:       fieldInfo.HasPayloads(),
 		encoded:                make([]byte, MAX_ENCODED_SIZE),
 	}
@@ -410,8 +410,21 @@ func (de *blockDocsEnum) refillDocs() (err error) {
 	assert(left > 0)
 
 	if left >= LUCENE41_BLOCK_SIZE {
-		fmt.Println("    fill doc block from fp=", de.docIn.FilePointer())
-		panic("not implemented yet")
+		// fmt.Println("    fill doc block from fp=", de.docIn.FilePointer())
+		if err = de.forUtil.readBlock(de.docIn, de.encoded, de.docDeltaBuffer); err != nil {
+			return
+		}
+		if de.indexHasFreq {
+			// fmt.Println("    fill freq block from fp=", de.docIn.FilePointer())
+			if de.needsFreq {
+				err = de.forUtil.readBlock(de.docIn, de.encoded, de.freqBuffer)
+			} else {
+				err = de.forUtil.skipBlock(de.docIn) // skip over freqs
+			}
+			if err != nil {
+				return
+			}
+		}
 	} else if de.docFreq == 1 {
 		de.docDeltaBuffer[0] = de.singletonDocID
 		de.freqBuffer[0] = int(de.totalTermFreq)
@@ -460,14 +473,54 @@ func (de *blockDocsEnum) NextDoc() (n int, err error) {
 
 func (de *blockDocsEnum) Advance(target int) (int, error) {
 	// TODO: make frq block load lazy/skippable
-	fmt.Printf("  FPR.advance target=%v\n", target)
+	// fmt.Printf("  FPR.advance target=%v\n", target)
 
 	// current skip docID < docIDs generated from current buffer <= next
 	// skip docID, we don't need to skip if target is buffered already
 	if de.docFreq > LUCENE41_BLOCK_SIZE && target > de.nextSkipDoc {
-		fmt.Println("load skipper")
+		// fmt.Println("load skipper")
+
+		if de.skipper == nil {
+			// Lazy init: first time this enum has ever been used for skipping
+			de.skipper = NewSkipReader(de.docIn.Clone(), maxSkipLevels,
+				LUCENE41_BLOCK_SIZE, de.indexHasPos, de.indexHasOffsets, de.indexHasPayloads)
+		}
+
+		if !de.skipped {
+			assert(de.skipOffset != -1)
+			// This is the first time this enum has skipped since reset()
+			// was called; load the skip data:
+			de.skipper.init(de.docTermStartFP+de.skipOffset, de.docTermStartFP, 0, 0, de.docFreq)
+			de.skipped = true
+		}
+
+		// always plus one to fix the result, since skip position in
+		// Lucene41SkipReader is a little different from
+		// MultiLevelSkipListReader
+		newDocUpto, err := de.skipper.SkipTo(target)
+		if err != nil {
+			return 0, err
+		}
+		newDocUpto++
+
+		if newDocUpto > de.docUpto {
+			// Skipper moved
+			// fmt.Printf("skipper moved to docUpto=%v vs current=%v; docID=%v fp=%v\n",
+			// 	newDocUpto, de.docUpto, de.skipper.Doc(), de.skipper.docPointerOfLastSkip())
+			assert2(newDocUpto%LUCENE41_BLOCK_SIZE == 0, "got %v", newDocUpto)
+			de.docUpto = newDocUpto
 
-		panic("not implemented yet")
+			// Force to read next block
+			de.docBufferUpto = LUCENE41_BLOCK_SIZE
+			de.accum = de.skipper.Doc() // actually, this is just lastSkipEntry
+			// now point to the block we want to search
+			if err = de.docIn.Seek(de.skipper.docPointerOfLastSkip()); err != nil {
+				return 0, err
+			}
+		}
+		// next time we call advance, this is used to foresee whether
+		// skipper is necessary.
+		de.nextSkipDoc = de.skipper.nextSkipDoc()
 	}
 	if de.docUpto == de.docFreq {
 		de.doc = NO_MORE_DOCS
@@ -476,13 +529,13 @@ func (de *blockDocsEnum) Advance(target int) (int, error) {
 	if de.docBufferUpto == LUCENE41_BLOCK_SIZE {
 		err := de.refillDocs()
 		if err != nil {
-			return 0, nil
+			return 0, err
 		}
 	}
 
 	// Now scan.. this is an inlined/pared down version of nextDoc():
 	for {
-		fmt.Printf("  scan doc=%v docBufferUpto=%v\n", de.accum, de.docBufferUpto)
+		// fmt.Printf("  scan doc=%v docBufferUpto=%v\n", de.accum, de.docBufferUpto)
 		de.accum += de.docDeltaBuffer[de.docBufferUpto]
 		de.docUpto++
 
@@ -497,14 +550,964 @@ func (de *blockDocsEnum) Advance(target int) (int, error) {
 	}
 
 	if de.liveDocs == nil || de.liveDocs.At(de.accum) {
-		fmt.Printf("  return doc=%v\n", de.accum)
+		// fmt.Printf("  return doc=%v\n", de.accum)
 		de.freq = de.freqBuffer[de.docBufferUpto]
 		de.docBufferUpto++
 		de.doc = de.accum
 		return de.doc, nil
 	} else {
-		fmt.Println("  now do nextDoc()")
+		// fmt.Println("  now do nextDoc()")
 		de.docBufferUpto++
 		return de.NextDoc()
 	}
 }
+
+func (de *blockDocsEnum) Cost() int64 {
+	return int64(de.docFreq)
+}
+
+func (r *Lucene41PostingsReader) DocsAndPositions(fieldInfo *FieldInfo,
+	termState *BlockTermState, liveDocs util.Bits,
+	reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error) {
+
+	indexHasOffsets := fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS
+	indexHasPayloads := fieldInfo.HasPayloads()
+
+	if (!indexHasOffsets || (flags&DOCS_POSITIONS_ENUM_FLAG_OFF_SETS) == 0) &&
+		(!indexHasPayloads || (flags&DOCS_POSITIONS_ENUM_FLAG_PAYLOADS) == 0) {
+
+		var docsAndPositionsEnum *blockDocsAndPositionsEnum
+		if v, ok := reuse.(*blockDocsAndPositionsEnum); ok {
+			docsAndPositionsEnum = v
+			if !docsAndPositionsEnum.canReuse(r.docIn, fieldInfo) {
+				docsAndPositionsEnum = newBlockDocsAndPositionsEnum(r, fieldInfo)
+			}
+		} else {
+			docsAndPositionsEnum = newBlockDocsAndPositionsEnum(r, fieldInfo)
+		}
+		return docsAndPositionsEnum.reset(liveDocs, termState.Self.(*intBlockTermState))
+	}
+
+	var everything *everythingEnum
+	if v, ok := reuse.(*everythingEnum); ok {
+		everything = v
+		if !everything.canReuse(r.docIn, fieldInfo) {
+			everything = newEverythingEnum(r, fieldInfo)
+		}
+	} else {
+		everything = newEverythingEnum(r, fieldInfo)
+	}
+	return everything.reset(liveDocs, termState.Self.(*intBlockTermState), flags)
+}
+
+type blockDocsAndPositionsEnum struct {
+	*Lucene41PostingsReader // embedded struct
+
+	encoded []byte
+
+	docDeltaBuffer []int
+	freqBuffer     []int
+	posDeltaBuffer []int
+
+	docBufferUpto int
+	posBufferUpto int
+
+	skipper *SkipReader
+	skipped bool
+
+	startDocIn store.IndexInput
+
+	docIn store.IndexInput
+	posIn store.IndexInput
+
+	indexHasOffsets  bool
+	indexHasPayloads bool
+
+	docFreq       int   // number of docs in this posting list
+	totalTermFreq int64 // number of positions in this posting list
+	docUpto       int   // how many docs we've read
+	doc           int   // doc we last read
+	accum         int   // accumulator for doc deltas
+	freq          int   // freq we last read
+	position      int   // current position
+
+	// how many positions "behind" we are; nextPosition must skip these
+	// to "catch up":
+	posPendingCount int
+
+	// Lazy pos seek: if != -1 then we must seek to this FP before
+	// reading positions:
+	posPendingFP int64
+
+	// Where this term's postings start in the .doc file:
+	docTermStartFP int64
+
+	// Where this term's postings start in the .pos file:
+	posTermStartFP int64
+
+	// Where this term's payloads/offsets start in the .pay file:
+	payTermStartFP int64
+
+	// File pointer where the last (vInt encoded) pos delta block is.
+	// We need this to know whether to bulk decode vs vInt decode the
+	// block:
+	lastPosBlockFP int64
+
+	// Where this term's skip data starts (after docTermStartFP) in the
+	// .doc file (or -1 if there is no skip data for this term):
+	skipOffset int64
+
+	nextSkipDoc int
+
+	liveDocs       util.Bits
+	singletonDocID int // docid when there is a single pulsed posting, otherwise -1
+}
+
+func newBlockDocsAndPositionsEnum(owner *Lucene41PostingsReader,
+	fieldInfo *FieldInfo) *blockDocsAndPositionsEnum {
+
+	return &blockDocsAndPositionsEnum{
+		Lucene41PostingsReader: owner,
+		docDeltaBuffer:         make([]int, MAX_DATA_SIZE),
+		freqBuffer:             make([]int, MAX_DATA_SIZE),
+		posDeltaBuffer:         make([]int, MAX_DATA_SIZE),
+		startDocIn:             owner.docIn,
+		posIn:                  owner.posIn.Clone(),
+		encoded:                make([]byte, MAX_ENCODED_SIZE),
+		indexHasOffsets:        fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS,
+		indexHasPayloads:       fieldInfo.HasPayloads(),
+	}
+}
+
+func (de *blockDocsAndPositionsEnum) canReuse(docIn store.IndexInput, fieldInfo *FieldInfo) bool {
+	return docIn == de.startDocIn &&
+		de.indexHasOffsets == (fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS) &&
+		de.indexHasPayloads == fieldInfo.HasPayloads()
+}
+
+func (de *blockDocsAndPositionsEnum) reset(liveDocs util.Bits,
+	termState *intBlockTermState) (DocsAndPositionsEnum, error) {
+
+	de.liveDocs = liveDocs
+	de.docFreq = termState.DocFreq
+	de.docTermStartFP = termState.docStartFP
+	de.posTermStartFP = termState.posStartFP
+	de.payTermStartFP = termState.payStartFP
+	de.skipOffset = termState.skipOffset
+	de.totalTermFreq = termState.TotalTermFreq
+	de.singletonDocID = termState.singletonDocID
+	if de.docFreq > 1 {
+		if de.docIn == nil {
+			// lazy init
+			de.docIn = de.startDocIn.Clone()
+		}
+		if err := de.docIn.Seek(de.docTermStartFP); err != nil {
+			return nil, err
+		}
+	}
+	de.posPendingFP = de.posTermStartFP
+	de.posPendingCount = 0
+	if termState.TotalTermFreq < LUCENE41_BLOCK_SIZE {
+		de.lastPosBlockFP = de.posTermStartFP
+	} else if termState.TotalTermFreq == LUCENE41_BLOCK_SIZE {
+		de.lastPosBlockFP = -1
+	} else {
+		de.lastPosBlockFP = de.posTermStartFP + termState.lastPosBlockOffset
+	}
+
+	de.doc = -1
+	de.accum = 0
+	de.docUpto = 0
+	if de.docFreq > LUCENE41_BLOCK_SIZE {
+		de.nextSkipDoc = LUCENE41_BLOCK_SIZE - 1 // we won't skip if target is found in first block
+	} else {
+		de.nextSkipDoc = NO_MORE_DOCS // not enough docs for skipping
+	}
+	de.docBufferUpto = LUCENE41_BLOCK_SIZE
+	de.skipped = false
+	return de, nil
+}
+
+func (de *blockDocsAndPositionsEnum) Freq() (int, error) {
+	return de.freq, nil
+}
+
+func (de *blockDocsAndPositionsEnum) DocId() int {
+	return de.doc
+}
+
+func (de *blockDocsAndPositionsEnum) refillDocs() (err error) {
+	left := de.docFreq - de.docUpto
+	assert(left > 0)
+
+	if left >= LUCENE41_BLOCK_SIZE {
+		if err = de.forUtil.readBlock(de.docIn, de.encoded, de.docDeltaBuffer); err != nil {
+			return
+		}
+		if err = de.forUtil.readBlock(de.docIn, de.encoded, de.freqBuffer); err != nil {
+			return
+		}
+	} else if de.docFreq == 1 {
+		de.docDeltaBuffer[0] = de.singletonDocID
+		de.freqBuffer[0] = int(de.totalTermFreq)
+	} else {
+		// Read vInts:
+		if err = readVIntBlock(de.docIn, de.docDeltaBuffer, de.freqBuffer, left, true); err != nil {
+			return
+		}
+	}
+	de.docBufferUpto = 0
+	return nil
+}
+
+func (de *blockDocsAndPositionsEnum) refillPositions() (err error) {
+	if de.posIn.FilePointer() == de.lastPosBlockFP {
+		count := int(de.totalTermFreq % LUCENE41_BLOCK_SIZE)
+		payloadLength := 0
+		for i := 0; i < count; i++ {
+			var code int
+			if code, err = asInt(de.posIn.ReadVInt()); err != nil {
+				return
+			}
+			if de.indexHasPayloads {
+				if (code & 1) != 0 {
+					if payloadLength, err = asInt(de.posIn.ReadVInt()); err != nil {
+						return
+					}
+				}
+				de.posDeltaBuffer[i] = int(uint(code) >> 1)
+				if payloadLength != 0 {
+					if err = de.posIn.Seek(de.posIn.FilePointer() + int64(payloadLength)); err != nil {
+						return
+					}
+				}
+			} else {
+				de.posDeltaBuffer[i] = code
+			}
+			if de.indexHasOffsets {
+				if code, err = asInt(de.posIn.ReadVInt()); err != nil {
+					return
+				}
+				if (code & 1) != 0 {
+					// offset length changed
+					if _, err = de.posIn.ReadVInt(); err != nil {
+						return
+					}
+				}
+			}
+		}
+		return nil
+	}
+	return de.forUtil.readBlock(de.posIn, de.encoded, de.posDeltaBuffer)
+}
+
+func (de *blockDocsAndPositionsEnum) NextDoc() (int, error) {
+	for {
+		if de.docUpto == de.docFreq {
+			de.doc = NO_MORE_DOCS
+			return de.doc, nil
+		}
+		if de.docBufferUpto == LUCENE41_BLOCK_SIZE {
+			if err := de.refillDocs(); err != nil {
+				return 0, err
+			}
+		}
+		de.accum += de.docDeltaBuffer[de.docBufferUpto]
+		de.freq = de.freqBuffer[de.docBufferUpto]
+		de.posPendingCount += de.freq
+		de.docBufferUpto++
+		de.docUpto++
+
+		if de.liveDocs == nil || de.liveDocs.At(de.accum) {
+			de.doc = de.accum
+			de.position = 0
+			return de.doc, nil
+		}
+	}
+}
+
+func (de *blockDocsAndPositionsEnum) Advance(target int) (int, error) {
+	// TODO: make frq block load lazy/skippable
+
+	if target > de.nextSkipDoc {
+		if de.skipper == nil {
+			// Lazy init: first time this enum has ever been used for skipping
+			de.skipper = NewSkipReader(de.docIn.Clone(), maxSkipLevels,
+				LUCENE41_BLOCK_SIZE, true, de.indexHasOffsets, de.indexHasPayloads)
+		}
+
+		if !de.skipped {
+			assert(de.skipOffset != -1)
+			// This is the first time this enum has skipped since reset()
+			// was called; load the skip data:
+			de.skipper.init(de.docTermStartFP+de.skipOffset, de.docTermStartFP,
+				de.posTermStartFP, de.payTermStartFP, de.docFreq)
+			de.skipped = true
+		}
+
+		newDocUpto, err := de.skipper.SkipTo(target)
+		if err != nil {
+			return 0, err
+		}
+		newDocUpto++
+
+		if newDocUpto > de.docUpto {
+			// Skipper moved
+			assert2(newDocUpto%LUCENE41_BLOCK_SIZE == 0, "got %v", newDocUpto)
+			de.docUpto = newDocUpto
+
+			// Force to read next block
+			de.docBufferUpto = LUCENE41_BLOCK_SIZE
+			de.accum = de.skipper.Doc()
+			if err = de.docIn.Seek(de.skipper.docPointerOfLastSkip()); err != nil {
+				return 0, err
+			}
+			de.posPendingFP = de.skipper.posPointerOfLastSkip()
+			de.posPendingCount = de.skipper.posBufferUptoOfLastSkip()
+		}
+		de.nextSkipDoc = de.skipper.nextSkipDoc()
+	}
+	if de.docUpto == de.docFreq {
+		de.doc = NO_MORE_DOCS
+		return de.doc, nil
+	}
+	if de.docBufferUpto == LUCENE41_BLOCK_SIZE {
+		if err := de.refillDocs(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Now scan... this is an inlined/pared down version of nextDoc():
+	for {
+		de.accum += de.docDeltaBuffer[de.docBufferUpto]
+		de.freq = de.freqBuffer[de.docBufferUpto]
+		de.posPendingCount += de.freq
+		de.docBufferUpto++
+		de.docUpto++
+
+		if de.accum >= target {
+			break
+		}
+		if de.docUpto == de.docFreq {
+			de.doc = NO_MORE_DOCS
+			return de.doc, nil
+		}
+	}
+
+	if de.liveDocs == nil || de.liveDocs.At(de.accum) {
+		de.position = 0
+		de.doc = de.accum
+		return de.doc, nil
+	}
+	return de.NextDoc()
+}
+
+// TODO: in theory we could avoid loading frq block when not needed,
+// ie, use skip data to load how far to seek the pos pointer ...
+// instead of having to load frq blocks only to sum up how many
+// positions to skip
+func (de *blockDocsAndPositionsEnum) skipPositions() error {
+	// Skip positions now:
+	toSkip := de.posPendingCount - de.freq
+
+	leftInBlock := LUCENE41_BLOCK_SIZE - de.posBufferUpto
+	if toSkip < leftInBlock {
+		de.posBufferUpto += toSkip
+	} else {
+		toSkip -= leftInBlock
+		for toSkip >= LUCENE41_BLOCK_SIZE {
+			assert(de.posIn.FilePointer() != de.lastPosBlockFP)
+			if err := de.forUtil.skipBlock(de.posIn); err != nil {
+				return err
+			}
+			toSkip -= LUCENE41_BLOCK_SIZE
+		}
+		if err := de.refillPositions(); err != nil {
+			return err
+		}
+		de.posBufferUpto = toSkip
+	}
+
+	de.position = 0
+	return nil
+}
+
+func (de *blockDocsAndPositionsEnum) NextPosition() (int, error) {
+	if de.posPendingFP != -1 {
+		if err := de.posIn.Seek(de.posPendingFP); err != nil {
+			return 0, err
+		}
+		de.posPendingFP = -1
+
+		// Force buffer refill:
+		de.posBufferUpto = LUCENE41_BLOCK_SIZE
+	}
+
+	if de.posPendingCount > de.freq {
+		if err := de.skipPositions(); err != nil {
+			return 0, err
+		}
+		de.posPendingCount = de.freq
+	}
+
+	if de.posBufferUpto == LUCENE41_BLOCK_SIZE {
+		if err := de.refillPositions(); err != nil {
+			return 0, err
+		}
+		de.posBufferUpto = 0
+	}
+	de.position += de.posDeltaBuffer[de.posBufferUpto]
+	de.posBufferUpto++
+	de.posPendingCount--
+	return de.position, nil
+}
+
+func (de *blockDocsAndPositionsEnum) StartOffset() (int, error) {
+	return -1, nil
+}
+
+func (de *blockDocsAndPositionsEnum) EndOffset() (int, error) {
+	return -1, nil
+}
+
+func (de *blockDocsAndPositionsEnum) Payload() ([]byte, error) {
+	return nil, nil
+}
+
+func (de *blockDocsAndPositionsEnum) Cost() int64 {
+	return int64(de.docFreq)
+}
+
+/* Also handles payloads + offsets */
+type everythingEnum struct {
+	*Lucene41PostingsReader // embedded struct
+
+	encoded []byte
+
+	docDeltaBuffer []int
+	freqBuffer     []int
+	posDeltaBuffer []int
+
+	payloadLengthBuffer    []int
+	offsetStartDeltaBuffer []int
+	offsetLengthBuffer     []int
+
+	payloadBytes    []byte
+	payloadByteUpto int
+	payloadLength   int
+	payload         []byte
+
+	lastStartOffset int
+	startOffset     int
+	endOffset       int
+
+	docBufferUpto int
+	posBufferUpto int
+
+	skipper *SkipReader
+	skipped bool
+
+	startDocIn store.IndexInput
+
+	docIn store.IndexInput
+	posIn store.IndexInput
+	payIn store.IndexInput
+
+	indexHasOffsets  bool
+	indexHasPayloads bool
+
+	docFreq       int   // number of docs in this posting list
+	totalTermFreq int64 // number of positions in this posting list
+	docUpto       int   // how many docs we've read
+	doc           int   // doc we last read
+	accum         int   // accumulator for doc deltas
+	freq          int   // freq we last read
+	position      int   // current position
+
+	// how many positions "behind" we are; nextPosition must skip these
+	// to "catch up":
+	posPendingCount int
+
+	// Lazy pos seek: if != -1 then we must seek to this FP before
+	// reading positions:
+	posPendingFP int64
+
+	// Lazy pay seek: if != -1 then we must seek to this FP before
+	// reading payloads/offsets:
+	payPendingFP int64
+
+	// Where this term's postings start in the .doc file:
+	docTermStartFP int64
+
+	// Where this term's postings start in the .pos file:
+	posTermStartFP int64
+
+	// Where this term's payloads/offsets start in the .pay file:
+	payTermStartFP int64
+
+	// File pointer where the last (vInt encoded) pos delta block is.
+	// We need this to know whether to bulk decode vs vInt decode the
+	// block:
+	lastPosBlockFP int64
+
+	// Where this term's skip data starts (after docTermStartFP) in the
+	// .doc file (or -1 if there is no skip data for this term):
+	skipOffset int64
+
+	nextSkipDoc int
+
+	liveDocs       util.Bits
+	needsOffsets   bool // true if we actually need offsets
+	needsPayloads  bool // true if we actually need payloads
+	singletonDocID int  // docid when there is a single pulsed posting, otherwise -1
+}
+
+func newEverythingEnum(owner *Lucene41PostingsReader, fieldInfo *FieldInfo) *everythingEnum {
+	ans := &everythingEnum{
+		Lucene41PostingsReader: owner,
+		docDeltaBuffer:         make([]int, MAX_DATA_SIZE),
+		freqBuffer:             make([]int, MAX_DATA_SIZE),
+		posDeltaBuffer:         make([]int, MAX_DATA_SIZE),
+		startDocIn:             owner.docIn,
+		posIn:                  owner.posIn.Clone(),
+		payIn:                  owner.payIn.Clone(),
+		encoded:                make([]byte, MAX_ENCODED_SIZE),
+		indexHasOffsets:        fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS,
+		indexHasPayloads:       fieldInfo.HasPayloads(),
+	}
+	if ans.indexHasOffsets {
+		ans.offsetStartDeltaBuffer = make([]int, MAX_DATA_SIZE)
+		ans.offsetLengthBuffer = make([]int, MAX_DATA_SIZE)
+	} else {
+		ans.startOffset = -1
+		ans.endOffset = -1
+	}
+	if ans.indexHasPayloads {
+		ans.payloadLengthBuffer = make([]int, MAX_DATA_SIZE)
+		ans.payloadBytes = make([]byte, 128)
+	}
+	return ans
+}
+
+func (de *everythingEnum) canReuse(docIn store.IndexInput, fieldInfo *FieldInfo) bool {
+	return docIn == de.startDocIn &&
+		de.indexHasOffsets == (fieldInfo.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS) &&
+		de.indexHasPayloads == fieldInfo.HasPayloads()
+}
+
+func (de *everythingEnum) reset(liveDocs util.Bits,
+	termState *intBlockTermState, flags int) (DocsAndPositionsEnum, error) {
+
+	de.liveDocs = liveDocs
+	de.docFreq = termState.DocFreq
+	de.docTermStartFP = termState.docStartFP
+	de.posTermStartFP = termState.posStartFP
+	de.payTermStartFP = termState.payStartFP
+	de.skipOffset = termState.skipOffset
+	de.totalTermFreq = termState.TotalTermFreq
+	de.singletonDocID = termState.singletonDocID
+	if de.docFreq > 1 {
+		if de.docIn == nil {
+			// lazy init
+			de.docIn = de.startDocIn.Clone()
+		}
+		if err := de.docIn.Seek(de.docTermStartFP); err != nil {
+			return nil, err
+		}
+	}
+	de.posPendingFP = de.posTermStartFP
+	de.payPendingFP = de.payTermStartFP
+	de.posPendingCount = 0
+	if termState.TotalTermFreq < LUCENE41_BLOCK_SIZE {
+		de.lastPosBlockFP = de.posTermStartFP
+	} else if termState.TotalTermFreq == LUCENE41_BLOCK_SIZE {
+		de.lastPosBlockFP = -1
+	} else {
+		de.lastPosBlockFP = de.posTermStartFP + termState.lastPosBlockOffset
+	}
+
+	de.needsOffsets = (flags & DOCS_POSITIONS_ENUM_FLAG_OFF_SETS) != 0
+	de.needsPayloads = (flags & DOCS_POSITIONS_ENUM_FLAG_PAYLOADS) != 0
+
+	de.doc = -1
+	de.accum = 0
+	de.docUpto = 0
+	if de.docFreq > LUCENE41_BLOCK_SIZE {
+		de.nextSkipDoc = LUCENE41_BLOCK_SIZE - 1 // we won't skip if target is found in first block
+	} else {
+		de.nextSkipDoc = NO_MORE_DOCS // not enough docs for skipping
+	}
+	de.docBufferUpto = LUCENE41_BLOCK_SIZE
+	de.skipped = false
+	return de, nil
+}
+
+func (de *everythingEnum) Freq() (int, error) {
+	return de.freq, nil
+}
+
+func (de *everythingEnum) DocId() int {
+	return de.doc
+}
+
+func (de *everythingEnum) refillDocs() (err error) {
+	left := de.docFreq - de.docUpto
+	assert(left > 0)
+
+	if left >= LUCENE41_BLOCK_SIZE {
+		if err = de.forUtil.readBlock(de.docIn, de.encoded, de.docDeltaBuffer); err != nil {
+			return
+		}
+		if err = de.forUtil.readBlock(de.docIn, de.encoded, de.freqBuffer); err != nil {
+			return
+		}
+	} else if de.docFreq == 1 {
+		de.docDeltaBuffer[0] = de.singletonDocID
+		de.freqBuffer[0] = int(de.totalTermFreq)
+	} else {
+		// Read vInts:
+		if err = readVIntBlock(de.docIn, de.docDeltaBuffer, de.freqBuffer, left, true); err != nil {
+			return
+		}
+	}
+	de.docBufferUpto = 0
+	return nil
+}
+
+/* Makes sure payloadBytes can hold n bytes, keeping the first keep ones. */
+func (de *everythingEnum) growPayloadBytes(n, keep int) {
+	if n > len(de.payloadBytes) {
+		payloadBytes := make([]byte, util.Oversize(n, 1))
+		copy(payloadBytes, de.payloadBytes[:keep])
+		de.payloadBytes = payloadBytes
+	}
+}
+
+func (de *everythingEnum) refillPositions() (err error) {
+	if de.posIn.FilePointer() == de.lastPosBlockFP {
+		count := int(de.totalTermFreq % LUCENE41_BLOCK_SIZE)
+		payloadLength, offsetLength := 0, 0
+		de.payloadByteUpto = 0
+		for i := 0; i < count; i++ {
+			var code int
+			if code, err = asInt(de.posIn.ReadVInt()); err != nil {
+				return
+			}
+			if de.indexHasPayloads {
+				if (code & 1) != 0 {
+					if payloadLength, err = asInt(de.posIn.ReadVInt()); err != nil {
+						return
+					}
+				}
+				de.payloadLengthBuffer[i] = payloadLength
+				de.posDeltaBuffer[i] = int(uint(code) >> 1)
+				if payloadLength != 0 {
+					de.growPayloadBytes(de.payloadByteUpto+payloadLength, de.payloadByteUpto)
+					if err = de.posIn.ReadBytes(de.payloadBytes[de.payloadByteUpto : de.payloadByteUpto+payloadLength]); err != nil {
+						return
+					}
+					de.payloadByteUpto += payloadLength
+				}
+			} else {
+				de.posDeltaBuffer[i] = code
+			}
+
+			if de.indexHasOffsets {
+				var deltaCode int
+				if deltaCode, err = asInt(de.posIn.ReadVInt()); err != nil {
+					return
+				}
+				if (deltaCode & 1) != 0 {
+					if offsetLength, err = asInt(de.posIn.ReadVInt()); err != nil {
+						return
+					}
+				}
+				de.offsetStartDeltaBuffer[i] = int(uint(deltaCode) >> 1)
+				de.offsetLengthBuffer[i] = offsetLength
+			}
+		}
+		de.payloadByteUpto = 0
+		return nil
+	}
+
+	if err = de.forUtil.readBlock(de.posIn, de.encoded, de.posDeltaBuffer); err != nil {
+		return
+	}
+	if de.indexHasPayloads {
+		if de.needsPayloads {
+			if err = de.forUtil.readBlock(de.payIn, de.encoded, de.payloadLengthBuffer); err != nil {
+				return
+			}
+			var numBytes int
+			if numBytes, err = asInt(de.payIn.ReadVInt()); err != nil {
+				return
+			}
+			de.growPayloadBytes(numBytes, 0)
+			if err = de.payIn.ReadBytes(de.payloadBytes[:numBytes]); err != nil {
+				return
+			}
+		} else {
+			// this works, because when writing a vint block we always
+			// force the first length to be written
+			if err = de.skipPayloadBlock(); err != nil {
+				return
+			}
+		}
+		de.payloadByteUpto = 0
+	}
+	if de.indexHasOffsets {
+		if de.needsOffsets {
+			if err = de.forUtil.readBlock(de.payIn, de.encoded, de.offsetStartDeltaBuffer); err != nil {
+				return
+			}
+			return de.forUtil.readBlock(de.payIn, de.encoded, de.offsetLengthBuffer)
+		}
+		if err = de.forUtil.skipBlock(de.payIn); err != nil { // skip over starts
+			return
+		}
+		return de.forUtil.skipBlock(de.payIn) // skip over lengths
+	}
+	return nil
+}
+
+/* Skips the payload lengths and bytes of a packed block in the .pay file. */
+func (de *everythingEnum) skipPayloadBlock() error {
+	if err := de.forUtil.skipBlock(de.payIn); err != nil { // skip over lengths
+		return err
+	}
+	numBytes, err := de.payIn.ReadVInt() // read length of payloadBytes
+	if err != nil {
+		return err
+	}
+	return de.payIn.Seek(de.payIn.FilePointer() + int64(numBytes)) // skip over payloadBytes
+}
+
+func (de *everythingEnum) NextDoc() (int, error) {
+	for {
+		if de.docUpto == de.docFreq {
+			de.doc = NO_MORE_DOCS
+			return de.doc, nil
+		}
+		if de.docBufferUpto == LUCENE41_BLOCK_SIZE {
+			if err := de.refillDocs(); err != nil {
+				return 0, err
+			}
+		}
+		de.accum += de.docDeltaBuffer[de.docBufferUpto]
+		de.freq = de.freqBuffer[de.docBufferUpto]
+		de.posPendingCount += de.freq
+		de.docBufferUpto++
+		de.docUpto++
+
+		if de.liveDocs == nil || de.liveDocs.At(de.accum) {
+			de.doc = de.accum
+			de.position = 0
+			de.lastStartOffset = 0
+			return de.doc, nil
+		}
+	}
+}
+
+func (de *everythingEnum) Advance(target int) (int, error) {
+	// TODO: make frq block load lazy/skippable
+
+	if target > de.nextSkipDoc {
+		if de.skipper == nil {
+			// Lazy init: first time this enum has ever been used for skipping
+			de.skipper = NewSkipReader(de.docIn.Clone(), maxSkipLevels,
+				LUCENE41_BLOCK_SIZE, true, de.indexHasOffsets, de.indexHasPayloads)
+		}
+
+		if !de.skipped {
+			assert(de.skipOffset != -1)
+			// This is the first time this enum has skipped since reset()
+			// was called; load the skip data:
+			de.skipper.init(de.docTermStartFP+de.skipOffset, de.docTermStartFP,
+				de.posTermStartFP, de.payTermStartFP, de.docFreq)
+			de.skipped = true
+		}
+
+		newDocUpto, err := de.skipper.SkipTo(target)
+		if err != nil {
+			return 0, err
+		}
+		newDocUpto++
+
+		if newDocUpto > de.docUpto {
+			// Skipper moved
+			assert2(newDocUpto%LUCENE41_BLOCK_SIZE == 0, "got %v", newDocUpto)
+			de.docUpto = newDocUpto
+
+			// Force to read next block
+			de.docBufferUpto = LUCENE41_BLOCK_SIZE
+			de.accum = de.skipper.Doc()
+			if err = de.docIn.Seek(de.skipper.docPointerOfLastSkip()); err != nil {
+				return 0, err
+			}
+			de.posPendingFP = de.skipper.posPointerOfLastSkip()
+			de.payPendingFP = de.skipper.payPointerOfLastSkip()
+			de.posPendingCount = de.skipper.posBufferUptoOfLastSkip()
+			de.lastStartOffset = 0 // new document
+			de.payloadByteUpto = de.skipper.payloadByteUptoOfLastSkip()
+		}
+		de.nextSkipDoc = de.skipper.nextSkipDoc()
+	}
+	if de.docUpto == de.docFreq {
+		de.doc = NO_MORE_DOCS
+		return de.doc, nil
+	}
+	if de.docBufferUpto == LUCENE41_BLOCK_SIZE {
+		if err := de.refillDocs(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Now scan:
+	for {
+		de.accum += de.docDeltaBuffer[de.docBufferUpto]
+		de.freq = de.freqBuffer[de.docBufferUpto]
+		de.posPendingCount += de.freq
+		de.docBufferUpto++
+		de.docUpto++
+
+		if de.accum >= target {
+			break
+		}
+		if de.docUpto == de.docFreq {
+			de.doc = NO_MORE_DOCS
+			return de.doc, nil
+		}
+	}
+
+	if de.liveDocs == nil || de.liveDocs.At(de.accum) {
+		de.position = 0
+		de.lastStartOffset = 0
+		de.doc = de.accum
+		return de.doc, nil
+	}
+	return de.NextDoc()
+}
+
+// TODO: in theory we could avoid loading frq block when not needed,
+// ie, use skip data to load how far to seek the pos pointer ...
+// instead of having to load frq blocks only to sum up how many
+// positions to skip
+func (de *everythingEnum) skipPositions() error {
+	// Skip positions now:
+	toSkip := de.posPendingCount - de.freq
+
+	leftInBlock := LUCENE41_BLOCK_SIZE - de.posBufferUpto
+	if toSkip < leftInBlock {
+		de.skipBufferedPositions(de.posBufferUpto + toSkip)
+	} else {
+		toSkip -= leftInBlock
+		for toSkip >= LUCENE41_BLOCK_SIZE {
+			assert(de.posIn.FilePointer() != de.lastPosBlockFP)
+			if err := de.forUtil.skipBlock(de.posIn); err != nil {
+				return err
+			}
+			if de.indexHasPayloads {
+				if err := de.skipPayloadBlock(); err != nil {
+					return err
+				}
+			}
+			if de.indexHasOffsets {
+				if err := de.forUtil.skipBlock(de.payIn); err != nil {
+					return err
+				}
+				if err := de.forUtil.skipBlock(de.payIn); err != nil {
+					return err
+				}
+			}
+			toSkip -= LUCENE41_BLOCK_SIZE
+		}
+		if err := de.refillPositions(); err != nil {
+			return err
+		}
+		de.payloadByteUpto = 0
+		de.posBufferUpto = 0
+		de.skipBufferedPositions(toSkip)
+	}
+
+	de.position = 0
+	de.lastStartOffset = 0
+	return nil
+}
+
+/* Moves posBufferUpto to end, skipping the payloads on the way. */
+func (de *everythingEnum) skipBufferedPositions(end int) {
+	for ; de.posBufferUpto < end; de.posBufferUpto++ {
+		if de.indexHasPayloads {
+			de.payloadByteUpto += de.payloadLengthBuffer[de.posBufferUpto]
+		}
+	}
+}
+
+func (de *everythingEnum) NextPosition() (int, error) {
+	if de.posPendingFP != -1 {
+		if err := de.posIn.Seek(de.posPendingFP); err != nil {
+			return 0, err
+		}
+		de.posPendingFP = -1
+
+		if de.payPendingFP != -1 {
+			if err := de.payIn.Seek(de.payPendingFP); err != nil {
+				return 0, err
+			}
+			de.payPendingFP = -1
+		}
+
+		// Force buffer refill:
+		de.posBufferUpto = LUCENE41_BLOCK_SIZE
+	}
+
+	if de.posPendingCount > de.freq {
+		if err := de.skipPositions(); err != nil {
+			return 0, err
+		}
+		de.posPendingCount = de.freq
+	}
+
+	if de.posBufferUpto == LUCENE41_BLOCK_SIZE {
+		if err := de.refillPositions(); err != nil {
+			return 0, err
+		}
+		de.posBufferUpto = 0
+	}
+	de.position += de.posDeltaBuffer[de.posBufferUpto]
+
+	if de.indexHasPayloads {
+		de.payloadLength = de.payloadLengthBuffer[de.posBufferUpto]
+		de.payload = de.payloadBytes[de.payloadByteUpto : de.payloadByteUpto+de.payloadLength]
+		de.payloadByteUpto += de.payloadLength
+	}
+
+	if de.indexHasOffsets {
+		de.startOffset = de.lastStartOffset + de.offsetStartDeltaBuffer[de.posBufferUpto]
+		de.endOffset = de.startOffset + de.offsetLengthBuffer[de.posBufferUpto]
+		de.lastStartOffset = de.startOffset
+	}
+
+	de.posBufferUpto++
+	de.posPendingCount--
+	return de.position, nil
+}
+
+func (de *everythingEnum) StartOffset() (int, error) {
+	return de.startOffset, nil
+}
+
+func (de *everythingEnum) EndOffset() (int, error) {
+	return de.endOffset, nil
+}
+
+func (de *everythingEnum) Payload() ([]byte, error) {
+	if de.payloadLength == 0 {
+		return nil, nil
+	}
+	return de.payload, nil
+}
+
+func (de *everythingEnum) Cost() int64 {
+	return int64(de.docFreq)
+}
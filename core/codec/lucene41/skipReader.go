@@ -0,0 +1,216 @@
+package lucene41
+
+import (
+	"github.com/balzaczyy/golucene/core/store"
+)
+
+// lucene41/Lucene41SkipReader.java
+
+/*
+Implements the skip list reader for block postings format that stores
+positions and payloads.
+
+Although this skipper uses MultiLevelSkipListReader as an interface,
+its definition of skip position will be a little different.
+
+For example, when skipInterval = blockSize = 3, df = 2*skipInterval =
+6,
+
+	0 1 2 3 4 5
+	d d d d d d    (posting list)
+	    ^     ^    (skip point in MultiLeveSkipWriter)
+	      ^        (skip point in Lucene41SkipWriter)
+
+In this case, MultiLevelSkipListReader will use the last document as
+a skip point, while Lucene41SkipReader should assume no skip point
+will comes.
+
+If we use the interface directly in Lucene41SkipReader, it may silly
+try to read another skip data after the only skip point is loaded.
+
+To illustrate this, we can call skipTo(d[5]), since skip point d[3]
+has smaller docId, and numSkipped+blockSize == df, the
+MultiLevelSkipListReader will assume the skip list isn't exhausted
+yet, and try to load a non-existed skip point.
+
+Therefore, we'll trim df before passing it to the interface. see
+trim(int).
+*/
+type SkipReader struct {
+	*store.MultiLevelSkipListReader
+
+	blockSize int
+
+	docPointer      []int64
+	posPointer      []int64
+	payPointer      []int64
+	posBufferUpto   []int
+	payloadByteUpto []int
+
+	lastPosPointer      int64
+	lastPayPointer      int64
+	lastPayloadByteUpto int
+	lastDocPointer      int64
+	lastPosBufferUpto   int
+}
+
+func NewSkipReader(skipStream store.IndexInput, maxSkipLevels, blockSize int,
+	hasPos, hasOffsets, hasPayloads bool) *SkipReader {
+
+	ans := &SkipReader{
+		blockSize:  blockSize,
+		docPointer: make([]int64, maxSkipLevels),
+	}
+	ans.MultiLevelSkipListReader = store.NewMultiLevelSkipListReader(ans, skipStream, maxSkipLevels, blockSize, 8)
+	if hasPos {
+		ans.posPointer = make([]int64, maxSkipLevels)
+		ans.posBufferUpto = make([]int, maxSkipLevels)
+		if hasPayloads {
+			ans.payloadByteUpto = make([]int, maxSkipLevels)
+		}
+		if hasOffsets || hasPayloads {
+			ans.payPointer = make([]int64, maxSkipLevels)
+		}
+	}
+	return ans
+}
+
+/*
+Trim original docFreq to tell skipReader read proper number of skip
+points.
+
+Since our definition in Lucene41Skip* is a little different from
+MultiLevelSkip* This trimmed docFreq will prevent skipReader from:
+1. silly reading a non-existed skip point after the last block
+boundary
+2. moving into the vInt block
+*/
+func (r *SkipReader) trim(df int) int {
+	if df%r.blockSize == 0 {
+		return df - 1
+	}
+	return df
+}
+
+func (r *SkipReader) init(skipPointer, docBasePointer, posBasePointer,
+	payBasePointer int64, df int) {
+
+	r.MultiLevelSkipListReader.Init(skipPointer, r.trim(df))
+	r.lastDocPointer = docBasePointer
+	r.lastPosPointer = posBasePointer
+	r.lastPayPointer = payBasePointer
+
+	for i, _ := range r.docPointer {
+		r.docPointer[i] = docBasePointer
+	}
+	if r.posPointer != nil {
+		for i, _ := range r.posPointer {
+			r.posPointer[i] = posBasePointer
+		}
+		if r.payPointer != nil {
+			for i, _ := range r.payPointer {
+				r.payPointer[i] = payBasePointer
+			}
+		}
+	} else {
+		assert(posBasePointer == 0)
+	}
+}
+
+/*
+Returns the doc pointer of the doc to which the last call of SkipTo()
+has skipped.
+*/
+func (r *SkipReader) docPointerOfLastSkip() int64 {
+	return r.lastDocPointer
+}
+
+func (r *SkipReader) posPointerOfLastSkip() int64 {
+	return r.lastPosPointer
+}
+
+func (r *SkipReader) posBufferUptoOfLastSkip() int {
+	return r.lastPosBufferUpto
+}
+
+func (r *SkipReader) payPointerOfLastSkip() int64 {
+	return r.lastPayPointer
+}
+
+func (r *SkipReader) payloadByteUptoOfLastSkip() int {
+	return r.lastPayloadByteUpto
+}
+
+func (r *SkipReader) nextSkipDoc() int {
+	return r.SkipDoc[0]
+}
+
+func (r *SkipReader) SeekChild(level int) error {
+	if err := r.MultiLevelSkipListReader.SeekChild(level); err != nil {
+		return err
+	}
+	r.docPointer[level] = r.lastDocPointer
+	if r.posPointer != nil {
+		r.posPointer[level] = r.lastPosPointer
+		r.posBufferUpto[level] = r.lastPosBufferUpto
+		if r.payloadByteUpto != nil {
+			r.payloadByteUpto[level] = r.lastPayloadByteUpto
+		}
+		if r.payPointer != nil {
+			r.payPointer[level] = r.lastPayPointer
+		}
+	}
+	return nil
+}
+
+func (r *SkipReader) SetLastSkipData(level int) {
+	r.MultiLevelSkipListReader.SetLastSkipData(level)
+	r.lastDocPointer = r.docPointer[level]
+
+	if r.posPointer != nil {
+		r.lastPosPointer = r.posPointer[level]
+		r.lastPosBufferUpto = r.posBufferUpto[level]
+		if r.payPointer != nil {
+			r.lastPayPointer = r.payPointer[level]
+		}
+		if r.payloadByteUpto != nil {
+			r.lastPayloadByteUpto = r.payloadByteUpto[level]
+		}
+	}
+}
+
+func (r *SkipReader) ReadSkipData(level int, skipStream store.IndexInput) (int, error) {
+	delta, err := asInt(skipStream.ReadVInt())
+	if err != nil {
+		return 0, err
+	}
+	n, err := skipStream.ReadVInt()
+	if err != nil {
+		return 0, err
+	}
+	r.docPointer[level] += int64(n)
+
+	if r.posPointer != nil {
+		if n, err = skipStream.ReadVInt(); err != nil {
+			return 0, err
+		}
+		r.posPointer[level] += int64(n)
+		if r.posBufferUpto[level], err = asInt(skipStream.ReadVInt()); err != nil {
+			return 0, err
+		}
+
+		if r.payloadByteUpto != nil {
+			if r.payloadByteUpto[level], err = asInt(skipStream.ReadVInt()); err != nil {
+				return 0, err
+			}
+		}
+
+		if r.payPointer != nil {
+			if n, err = skipStream.ReadVInt(); err != nil {
+				return 0, err
+			}
+			r.payPointer[level] += int64(n)
+		}
+	}
+	return delta, nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/balzaczyy/golucene/core/codec/lucene40"
 	"github.com/balzaczyy/golucene/core/codec/lucene41"
 	"github.com/balzaczyy/golucene/core/codec/lucene42"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene45"
 	"github.com/balzaczyy/golucene/core/codec/lucene46"
 	"github.com/balzaczyy/golucene/core/codec/lucene49"
 	"github.com/balzaczyy/golucene/core/codec/perfield"
@@ -38,7 +39,9 @@ func newLucene410Codec() *Lucene410Codec {
 			return LoadPostingsFormat("Lucene41")
 		}),
 		perfield.NewPerFieldDocValuesFormat(func(field string) DocValuesFormat {
-			panic("not implemented yet")
+			// Lucene410DocValuesFormat is not ported; Lucene45 reads and
+			// writes all doc values types, so it serves as the default.
+			return LoadDocValuesFormat("Lucene45")
 		}),
 		new(lucene49.Lucene49NormsFormat),
 	)}
@@ -122,7 +122,14 @@ func newLucene42DocValuesProducer(state SegmentReadState,
 	}
 
 	if version >= LUCENE42_DV_VERSION_CHECKSUM {
-		panic("niy")
+		// NOTE: data file is too costly to verify checksum against all the
+		// bytes on open, but for now we at least verify proper structure
+		// of the checksum footer: which looks for FOOTER_MAGIC +
+		// algorithmID. This is cheap and can detect some forms of
+		// corruption such as file truncation.
+		if _, err = codec.RetrieveChecksum(dvp.data); err != nil {
+			return nil, err
+		}
 	}
 
 	success = true
@@ -182,9 +189,37 @@ func (dvp *Lucene42DocValuesProducer) readFields(meta store.IndexInput,
 				entry.offset, entry.format, entry.packedIntsVersion)
 			dvp.numerics[fieldNumber] = entry
 		case LUCENE42_DV_BYTES:
-			panic("not implemented yet")
+			entry := BinaryEntry{}
+			if entry.offset, err = meta.ReadLong(); err != nil {
+				return
+			}
+			if entry.numBytes, err = meta.ReadLong(); err != nil {
+				return
+			}
+			if entry.minLength, err = asInt(meta.ReadVInt()); err != nil {
+				return
+			}
+			if entry.maxLength, err = asInt(meta.ReadVInt()); err != nil {
+				return
+			}
+			if entry.minLength != entry.maxLength {
+				if entry.packedIntsVersion, err = asInt(meta.ReadVInt()); err != nil {
+					return
+				}
+				if entry.blockSize, err = asInt(meta.ReadVInt()); err != nil {
+					return
+				}
+			}
+			dvp.binaries[fieldNumber] = entry
 		case LUCENE42_DV_FST:
-			panic("not implemented yet")
+			entry := FSTEntry{}
+			if entry.offset, err = meta.ReadLong(); err != nil {
+				return
+			}
+			if entry.numOrds, err = meta.ReadVLong(); err != nil {
+				return
+			}
+			dvp.fsts[fieldNumber] = entry
 		default:
 			return errors.New(fmt.Sprintf("invalid entry type: %v, input=%v", fieldType, meta))
 		}
@@ -217,10 +252,8 @@ func (dvp *Lucene42DocValuesProducer) loadNumeric(field *FieldInfo) (v NumericDo
 	}
 
 	switch entry.format {
-	case LUCENE42_DV_TABLE_COMPRESSED:
-		panic("not implemented yet")
-	case LUCENE42_DV_DELTA_COMPRESSED:
-		panic("not implemented yet")
+	case LUCENE42_DV_TABLE_COMPRESSED, LUCENE42_DV_DELTA_COMPRESSED, LUCENE42_DV_GCD_COMPRESSED:
+		return nil, errUnsupported(field, fmt.Sprintf("numeric format %v", entry.format))
 	case LUCENE42_DV_UNCOMPRESSED:
 		bytes := make([]byte, dvp.maxDoc)
 		if err = dvp.data.ReadBytes(bytes); err == nil {
@@ -229,27 +262,41 @@ func (dvp *Lucene42DocValuesProducer) loadNumeric(field *FieldInfo) (v NumericDo
 				return int64(bytes[docID])
 			}, nil
 		}
-	case LUCENE42_DV_GCD_COMPRESSED:
-		panic("not implemented yet")
 	default:
 		panic("assert fail")
 	}
 	return
 }
 
+/*
+Only uncompressed numeric doc values (as used by Lucene42 norms) can
+be read for now; the other types are reported as unsupported.
+*/
+func errUnsupported(field *FieldInfo, what string) error {
+	return errors.New(fmt.Sprintf(
+		"Lucene42 doc values: reading %v is not supported yet (field=%v)",
+		what, field.Name))
+}
+
 func (dvp *Lucene42DocValuesProducer) Binary(field *FieldInfo) (v BinaryDocValues, err error) {
-	panic("not implemented yet")
-	return nil, nil
+	return nil, errUnsupported(field, "binary doc values")
 }
 
 func (dvp *Lucene42DocValuesProducer) Sorted(field *FieldInfo) (v SortedDocValues, err error) {
-	panic("not implemented yet")
-	return nil, nil
+	return nil, errUnsupported(field, "sorted doc values")
 }
 
 func (dvp *Lucene42DocValuesProducer) SortedSet(field *FieldInfo) (v SortedSetDocValues, err error) {
-	panic("not implemented yet")
-	return nil, nil
+	return nil, errUnsupported(field, "sorted set doc values")
+}
+
+func (dvp *Lucene42DocValuesProducer) DocsWithField(field *FieldInfo) (util.Bits, error) {
+	switch field.DocValuesType() {
+	case DOC_VALUES_TYPE_SORTED_SET, DOC_VALUES_TYPE_SORTED:
+		return nil, errUnsupported(field, "docs with field")
+	default:
+		return util.NewMatchAllBits(dvp.maxDoc), nil
+	}
 }
 
 func (dvp *Lucene42DocValuesProducer) Close() error {
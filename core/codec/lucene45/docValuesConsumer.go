@@ -0,0 +1,605 @@
+package lucene45
+
+import (
+	"github.com/balzaczyy/golucene/core/codec"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"math"
+	"sort"
+)
+
+// codec/lucene45/Lucene45DocValuesConsumer.java
+
+const (
+	LUCENE45_DV_BLOCK_SIZE = 16384
+	ADDRESS_INTERVAL       = 16
+
+	// Compressed using packed blocks of ints.
+	DELTA_COMPRESSED = 0
+	// Compressed by computing the GCD.
+	GCD_COMPRESSED = 1
+	// Compressed by giving IDs to unique values.
+	TABLE_COMPRESSED = 2
+
+	// Uncompressed binary, written directly (fixed length).
+	BINARY_FIXED_UNCOMPRESSED = 0
+	// Uncompressed binary, written directly (variable length).
+	BINARY_VARIABLE_UNCOMPRESSED = 1
+	// Compressed binary with shared prefixes
+	BINARY_PREFIX_COMPRESSED = 2
+
+	// Standard storage for sorted set values with 1 level of
+	// indirection: docId -> address -> ord.
+	SORTED_SET_WITH_ADDRESSES = 0
+	// Single-valued sorted set values, encoded as sorted values, so no
+	// level of indirection: docId -> ord.
+	SORTED_SET_SINGLE_VALUED_SORTED = 1
+)
+
+/* Writer for Lucene45DocValuesFormat */
+type Lucene45DocValuesConsumer struct {
+	data, meta store.IndexOutput
+	maxDoc     int
+}
+
+/* expert: Creates a new writer */
+func newLucene45DocValuesConsumer(state *SegmentWriteState,
+	dataCodec, dataExtension, metaCodec, metaExtension string) (w *Lucene45DocValuesConsumer, err error) {
+
+	w = &Lucene45DocValuesConsumer{maxDoc: state.SegmentInfo.DocCount()}
+	var success = false
+	defer func() {
+		if !success {
+			util.CloseWhileSuppressingError(w)
+		}
+	}()
+
+	dataName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, dataExtension)
+	if w.data, err = state.Directory.CreateOutput(dataName, state.Context); err != nil {
+		return nil, err
+	}
+	if err = codec.WriteHeader(w.data, dataCodec, LUCENE45_DV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	metaName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, metaExtension)
+	if w.meta, err = state.Directory.CreateOutput(metaName, state.Context); err != nil {
+		return nil, err
+	}
+	if err = codec.WriteHeader(w.meta, metaCodec, LUCENE45_DV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	success = true
+	return w, nil
+}
+
+func (w *Lucene45DocValuesConsumer) AddNumericField(field *FieldInfo,
+	values func() func() (interface{}, bool)) error {
+
+	return w.addNumericField(field, values, true)
+}
+
+func (w *Lucene45DocValuesConsumer) addNumericField(field *FieldInfo,
+	values func() func() (interface{}, bool), optimizeStorage bool) (err error) {
+
+	var count int64
+	minValue, maxValue := int64(math.MaxInt64), int64(math.MinInt64)
+	var gcd int64
+	var missing bool
+	// TODO: more efficient?
+	var uniqueValues map[int64]bool
+	if optimizeStorage {
+		uniqueValues = make(map[int64]bool)
+	}
+
+	next := values()
+	for {
+		nv, ok := next()
+		if !ok {
+			break
+		}
+		var v int64
+		if nv == nil {
+			missing = true
+		} else {
+			v = nv.(int64)
+		}
+
+		if gcd != 1 {
+			if v < math.MinInt64/2 || v > math.MaxInt64/2 {
+				// in that case v - minValue might overflow and make the GCD
+				// computation return wrong results. Since these extreme
+				// values are unlikely, we just discard GCD computation for
+				// them
+				gcd = 1
+			} else if count != 0 { // minValue needs to be set first
+				gcd = util.Gcd(gcd, v-minValue)
+			}
+		}
+
+		if v < minValue {
+			minValue = v
+		}
+		if v > maxValue {
+			maxValue = v
+		}
+
+		if uniqueValues != nil {
+			if uniqueValues[v] = true; len(uniqueValues) > 256 {
+				uniqueValues = nil
+			}
+		}
+
+		count++
+	}
+
+	delta := maxValue - minValue
+
+	var format int
+	if uniqueValues != nil &&
+		(delta < 0 || packed.BitsRequired(int64(len(uniqueValues)-1)) < packed.BitsRequired(delta)) &&
+		count <= math.MaxInt32 {
+		format = TABLE_COMPRESSED
+	} else if gcd != 0 && gcd != 1 {
+		format = GCD_COMPRESSED
+	} else {
+		format = DELTA_COMPRESSED
+	}
+	if err = store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(LUCENE45_DV_NUMERIC).
+		WriteVInt(int32(format)).
+		Close(); err != nil {
+		return err
+	}
+	if missing {
+		if err = w.meta.WriteLong(w.data.FilePointer()); err != nil {
+			return err
+		}
+		if err = w.writeMissingBitset(values); err != nil {
+			return err
+		}
+	} else if err = w.meta.WriteLong(-1); err != nil {
+		return err
+	}
+	if err = store.Stream(w.meta).WriteVInt(packed.VERSION_CURRENT).
+		WriteLong(w.data.FilePointer()).
+		WriteVLong(count).
+		WriteVInt(LUCENE45_DV_BLOCK_SIZE).
+		Close(); err != nil {
+		return err
+	}
+
+	switch format {
+	case GCD_COMPRESSED:
+		if err = store.Stream(w.meta).WriteLong(minValue).WriteLong(gcd).Close(); err != nil {
+			return err
+		}
+		quotientWriter := packed.NewBlockPackedWriter(w.data, LUCENE45_DV_BLOCK_SIZE)
+		next = values()
+		for {
+			nv, ok := next()
+			if !ok {
+				break
+			}
+			var v int64
+			if nv != nil {
+				v = nv.(int64)
+			}
+			if err = quotientWriter.Add((v - minValue) / gcd); err != nil {
+				return err
+			}
+		}
+		return quotientWriter.Finish()
+
+	case DELTA_COMPRESSED:
+		writer := packed.NewBlockPackedWriter(w.data, LUCENE45_DV_BLOCK_SIZE)
+		next = values()
+		for {
+			nv, ok := next()
+			if !ok {
+				break
+			}
+			var v int64
+			if nv != nil {
+				v = nv.(int64)
+			}
+			if err = writer.Add(v); err != nil {
+				return err
+			}
+		}
+		return writer.Finish()
+
+	case TABLE_COMPRESSED:
+		decode := make([]int64, 0, len(uniqueValues))
+		for v, _ := range uniqueValues {
+			decode = append(decode, v)
+		}
+		sort.Sort(int64s(decode))
+		encode := make(map[int64]int64)
+		if err = w.meta.WriteVInt(int32(len(decode))); err != nil {
+			return err
+		}
+		for i, v := range decode {
+			if err = w.meta.WriteLong(v); err != nil {
+				return err
+			}
+			encode[v] = int64(i)
+		}
+		bitsRequired := packed.BitsRequired(int64(len(uniqueValues) - 1))
+		ordsWriter := packed.WriterNoHeader(w.data, packed.PackedFormat(packed.PACKED),
+			int(count), bitsRequired, packed.DEFAULT_BUFFER_SIZE)
+		next = values()
+		for {
+			nv, ok := next()
+			if !ok {
+				break
+			}
+			var v int64
+			if nv != nil {
+				v = nv.(int64)
+			}
+			if err = ordsWriter.Add(encode[v]); err != nil {
+				return err
+			}
+		}
+		return ordsWriter.Finish()
+
+	default:
+		panic("assert fail")
+	}
+}
+
+type int64s []int64
+
+func (a int64s) Len() int           { return len(a) }
+func (a int64s) Less(i, j int) bool { return a[i] < a[j] }
+func (a int64s) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+/* one bit per doc, set for docs which have a value */
+func (w *Lucene45DocValuesConsumer) writeMissingBitset(values func() func() (interface{}, bool)) error {
+	var bits byte
+	count := 0
+	next := values()
+	for {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		if count == 8 {
+			if err := w.data.WriteByte(bits); err != nil {
+				return err
+			}
+			count, bits = 0, 0
+		}
+		if v != nil {
+			bits |= 1 << uint(count&7)
+		}
+		count++
+	}
+	if count > 0 {
+		return w.data.WriteByte(bits)
+	}
+	return nil
+}
+
+func (w *Lucene45DocValuesConsumer) AddBinaryField(field *FieldInfo,
+	values func() func() (interface{}, bool)) (err error) {
+
+	// write the []byte data
+	if err = store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(LUCENE45_DV_BINARY).
+		Close(); err != nil {
+		return err
+	}
+	minLength, maxLength := math.MaxInt32, math.MinInt32
+	startFP := w.data.FilePointer()
+	var count int64
+	var missing bool
+	next := values()
+	for {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		var length int
+		if v == nil {
+			missing = true
+		} else {
+			bytes := v.([]byte)
+			length = len(bytes)
+			if err = w.data.WriteBytes(bytes); err != nil {
+				return err
+			}
+		}
+		if length < minLength {
+			minLength = length
+		}
+		if length > maxLength {
+			maxLength = length
+		}
+		count++
+	}
+	format := BINARY_VARIABLE_UNCOMPRESSED
+	if minLength == maxLength {
+		format = BINARY_FIXED_UNCOMPRESSED
+	}
+	if err = w.meta.WriteVInt(int32(format)); err != nil {
+		return err
+	}
+	if missing {
+		if err = w.meta.WriteLong(w.data.FilePointer()); err != nil {
+			return err
+		}
+		if err = w.writeMissingBitset(values); err != nil {
+			return err
+		}
+	} else if err = w.meta.WriteLong(-1); err != nil {
+		return err
+	}
+	if err = store.Stream(w.meta).WriteVInt(int32(minLength)).
+		WriteVInt(int32(maxLength)).
+		WriteVLong(count).
+		WriteLong(startFP).
+		Close(); err != nil {
+		return err
+	}
+
+	// if minLength == maxLength, its a fixed-length []byte, we are
+	// done (the addresses are implicit) otherwise, we need to record
+	// the length fields...
+	if minLength != maxLength {
+		if err = store.Stream(w.meta).WriteLong(w.data.FilePointer()).
+			WriteVInt(packed.VERSION_CURRENT).
+			WriteVInt(LUCENE45_DV_BLOCK_SIZE).
+			Close(); err != nil {
+			return err
+		}
+
+		writer := packed.NewMonotonicBlockPackedWriter(w.data, LUCENE45_DV_BLOCK_SIZE)
+		var addr int64
+		next = values()
+		for {
+			v, ok := next()
+			if !ok {
+				break
+			}
+			if v != nil {
+				addr += int64(len(v.([]byte)))
+			}
+			if err = writer.Add(addr); err != nil {
+				return err
+			}
+		}
+		return writer.Finish()
+	}
+	return nil
+}
+
+/* expert: writes a value dictionary for a sorted/sortedset field */
+func (w *Lucene45DocValuesConsumer) addTermsDict(field *FieldInfo,
+	values func() func() (interface{}, bool)) (err error) {
+
+	// first check if its a "fixed-length" terms dict
+	minLength, maxLength := math.MaxInt32, math.MinInt32
+	next := values()
+	for {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		length := len(v.([]byte))
+		if length < minLength {
+			minLength = length
+		}
+		if length > maxLength {
+			maxLength = length
+		}
+	}
+	if minLength == maxLength {
+		// no index needed: direct addressing by mult
+		return w.AddBinaryField(field, values)
+	}
+
+	// header
+	if err = store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(LUCENE45_DV_BINARY).
+		WriteVInt(BINARY_PREFIX_COMPRESSED).
+		WriteLong(-1).
+		Close(); err != nil {
+		return err
+	}
+	// now write the bytes: sharing prefixes within a block
+	startFP := w.data.FilePointer()
+	// currently, we have to store the delta from expected for every
+	// 1/nth term we could avoid this, but its not much and less
+	// overall RAM than the previous approach!
+	addressBuffer := store.NewRAMOutputStreamBuffer()
+	termAddresses := packed.NewMonotonicBlockPackedWriter(addressBuffer, LUCENE45_DV_BLOCK_SIZE)
+	var lastTerm []byte
+	var count int64
+	next = values()
+	for {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		term := v.([]byte)
+		if count%ADDRESS_INTERVAL == 0 {
+			if err = termAddresses.Add(w.data.FilePointer() - startFP); err != nil {
+				return err
+			}
+			// force the first term in a block to be abs-encoded
+			lastTerm = lastTerm[:0]
+		}
+
+		// prefix-code
+		sharedPrefix := util.BytesDifference(lastTerm, term)
+		if err = store.Stream(w.data).WriteVInt(int32(sharedPrefix)).
+			WriteVInt(int32(len(term) - sharedPrefix)).
+			Close(); err != nil {
+			return err
+		}
+		if err = w.data.WriteBytes(term[sharedPrefix:]); err != nil {
+			return err
+		}
+		lastTerm = append(lastTerm[:0], term...)
+		count++
+	}
+	indexStartFP := w.data.FilePointer()
+	// write addresses of indexed terms
+	if err = termAddresses.Finish(); err != nil {
+		return err
+	}
+	if err = addressBuffer.WriteTo(w.data); err != nil {
+		return err
+	}
+	return store.Stream(w.meta).WriteVInt(int32(minLength)).
+		WriteVInt(int32(maxLength)).
+		WriteVLong(count).
+		WriteLong(startFP).
+		WriteVInt(ADDRESS_INTERVAL).
+		WriteLong(indexStartFP).
+		WriteVInt(packed.VERSION_CURRENT).
+		WriteVInt(LUCENE45_DV_BLOCK_SIZE).
+		Close()
+}
+
+func (w *Lucene45DocValuesConsumer) AddSortedField(field *FieldInfo,
+	values, docToOrd func() func() (interface{}, bool)) error {
+
+	if err := store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(LUCENE45_DV_SORTED).
+		Close(); err != nil {
+		return err
+	}
+	if err := w.addTermsDict(field, values); err != nil {
+		return err
+	}
+	return w.addNumericField(field, docToOrd, false)
+}
+
+func (w *Lucene45DocValuesConsumer) AddSortedSetField(field *FieldInfo,
+	values, docToOrdCount, ords func() func() (interface{}, bool)) (err error) {
+
+	if err = store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(LUCENE45_DV_SORTED_SET).
+		Close(); err != nil {
+		return err
+	}
+
+	if isSingleValued(docToOrdCount) {
+		if err = w.meta.WriteVInt(SORTED_SET_SINGLE_VALUED_SORTED); err != nil {
+			return err
+		}
+		// The field is single-valued, we can encode it as SORTED
+		return w.AddSortedField(field, values, singletonView(docToOrdCount, ords))
+	}
+
+	if err = w.meta.WriteVInt(SORTED_SET_WITH_ADDRESSES); err != nil {
+		return err
+	}
+
+	// write the ord -> byte[] as a binary field
+	if err = w.addTermsDict(field, values); err != nil {
+		return err
+	}
+
+	// write the stream of ords as a numeric field
+	// NOTE: we could return an iterator that delta-encodes these
+	// within a doc
+	if err = w.addNumericField(field, ords, false); err != nil {
+		return err
+	}
+
+	// write the doc -> ord count as a absolute index to the stream
+	if err = store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(LUCENE45_DV_NUMERIC).
+		WriteVInt(DELTA_COMPRESSED).
+		WriteLong(-1).
+		WriteVInt(packed.VERSION_CURRENT).
+		WriteLong(w.data.FilePointer()).
+		WriteVLong(int64(w.maxDoc)).
+		WriteVInt(LUCENE45_DV_BLOCK_SIZE).
+		Close(); err != nil {
+		return err
+	}
+
+	writer := packed.NewMonotonicBlockPackedWriter(w.data, LUCENE45_DV_BLOCK_SIZE)
+	var addr int64
+	next := docToOrdCount()
+	for {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		addr += v.(int64)
+		if err = writer.Add(addr); err != nil {
+			return err
+		}
+	}
+	return writer.Finish()
+}
+
+/* Returns true if no document has more than one ordinal. */
+func isSingleValued(docToOrdCount func() func() (interface{}, bool)) bool {
+	next := docToOrdCount()
+	for {
+		v, ok := next()
+		if !ok {
+			return true
+		}
+		if v.(int64) > 1 {
+			return false
+		}
+	}
+}
+
+/*
+Returns a per-document ordinal view of a single-valued sorted set,
+with -1 for documents without a value.
+*/
+func singletonView(docToOrdCount, ords func() func() (interface{}, bool)) func() func() (interface{}, bool) {
+	return func() func() (interface{}, bool) {
+		nextCount, nextOrd := docToOrdCount(), ords()
+		return func() (interface{}, bool) {
+			v, ok := nextCount()
+			if !ok {
+				return nil, false
+			}
+			if v.(int64) == 0 {
+				return int64(-1), true
+			}
+			assert(v.(int64) == 1)
+			ord, _ := nextOrd()
+			return ord, true
+		}
+	}
+}
+
+func (w *Lucene45DocValuesConsumer) Close() (err error) {
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(w.data, w.meta)
+		} else {
+			util.CloseWhileSuppressingError(w.data, w.meta)
+		}
+	}()
+
+	if w.meta != nil {
+		if err = w.meta.WriteVInt(-1); err != nil { // write EOF marker
+			return
+		}
+		if err = codec.WriteFooter(w.meta); err != nil { // write checksum
+			return
+		}
+	}
+	if w.data != nil {
+		if err = codec.WriteFooter(w.data); err != nil {
+			return
+		}
+	}
+	success = true
+	return nil
+}
@@ -0,0 +1,114 @@
+package lucene45
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+)
+
+// codec/lucene45/Lucene45DocValuesFormat.java
+
+/*
+Lucene 4.5 DocValues format.
+
+Encodes the four per-document value types (Numeric, Binary, Sorted,
+SortedSet) with these strategies:
+
+NUMERIC:
+
+  - Delta-compressed: per-document integers written in blocks of 16k.
+    For each block the minimum value in that block is encoded, and
+    each entry is a delta from that minimum value.
+  - Table-compressed: when the number of unique values is very small
+    (< 256), and when there are unused "gaps" in the range of values
+    used (such as SmallFloat), a lookup table is written instead. Each
+    per-document entry is instead the ordinal to this table.
+  - GCD-compressed: when all numbers share a common divisor, such as
+    dates, the greatest common denominator (GCD) is computed, and
+    quotients are stored using Delta-compressed Numerics.
+
+BINARY:
+
+  - Fixed-width Binary: one large concatenated []byte is written, along
+    with the fixed length. Each document's value can be addressed
+    directly with multiplication (docID * length).
+  - Variable-width Binary: one large concatenated []byte is written,
+    along with end addresses for each document. The addresses are
+    written in blocks of 16k, with the current absolute start for the
+    block, and the average (expected) delta per entry. For each
+    document the deviation from the delta (actual - expected) is
+    written.
+  - Prefix-compressed Binary: values are written in chunks of 16, with
+    the first value written completely and other values sharing
+    prefixes. Chunk addresses are written in blocks of 16k, with the
+    current absolute start for the block, and the average (expected)
+    delta per entry. For each chunk the deviation from the delta
+    (actual - expected) is written.
+
+SORTED:
+
+  - Sorted: a mapping of ordinals to deduplicated terms is written as
+    Prefix-Compressed Binary, along with the per-document ordinals
+    written using one of the numeric strategies above.
+
+SORTED_SET:
+
+  - SortedSet: a mapping of ordinals to deduplicated terms is written
+    as Prefix-Compressed Binary, an ordinal list and per-document index
+    into this list are written using the numeric strategies above.
+
+Files:
+
+  - .dvd: DocValues data
+  - .dvm: DocValues metadata
+*/
+type Lucene45DocValuesFormat struct{}
+
+func init() {
+	RegisterDocValuesFormat(new(Lucene45DocValuesFormat))
+}
+
+func (f *Lucene45DocValuesFormat) Name() string {
+	return "Lucene45"
+}
+
+func (f *Lucene45DocValuesFormat) FieldsConsumer(state *SegmentWriteState) (DocValuesConsumer, error) {
+	return newLucene45DocValuesConsumer(state,
+		LUCENE45_DV_DATA_CODEC, LUCENE45_DV_DATA_EXTENSION,
+		LUCENE45_DV_META_CODEC, LUCENE45_DV_META_EXTENSION)
+}
+
+func (f *Lucene45DocValuesFormat) FieldsProducer(state SegmentReadState) (DocValuesProducer, error) {
+	return newLucene45DocValuesProducer(state,
+		LUCENE45_DV_DATA_CODEC, LUCENE45_DV_DATA_EXTENSION,
+		LUCENE45_DV_META_CODEC, LUCENE45_DV_META_EXTENSION)
+}
+
+const (
+	LUCENE45_DV_DATA_CODEC     = "Lucene45DocValuesData"
+	LUCENE45_DV_DATA_EXTENSION = "dvd"
+	LUCENE45_DV_META_CODEC     = "Lucene45ValuesMetadata"
+	LUCENE45_DV_META_EXTENSION = "dvm"
+
+	LUCENE45_DV_NUMERIC    = 0
+	LUCENE45_DV_BINARY     = 1
+	LUCENE45_DV_SORTED     = 2
+	LUCENE45_DV_SORTED_SET = 3
+
+	LUCENE45_DV_VERSION_START                             = 0
+	LUCENE45_DV_VERSION_SORTED_SET_SINGLE_VALUE_OPTIMIZED = 1
+	LUCENE45_DV_VERSION_CHECKSUM                          = 2
+	LUCENE45_DV_VERSION_CURRENT                           = LUCENE45_DV_VERSION_CHECKSUM
+)
+
+func assert(ok bool) {
+	if !ok {
+		panic("assert fail")
+	}
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
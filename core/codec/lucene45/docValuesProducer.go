@@ -0,0 +1,872 @@
+package lucene45
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/codec"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"sync"
+)
+
+// codec/lucene45/Lucene45DocValuesProducer.java
+
+/* reader for Lucene45DocValuesFormat */
+type Lucene45DocValuesProducer struct {
+	sync.Locker
+
+	numerics   map[int]*NumericEntry
+	binaries   map[int]*BinaryEntry
+	sortedSets map[int]*SortedSetEntry
+	ords       map[int]*NumericEntry
+	ordIndexes map[int]*NumericEntry
+	data       store.IndexInput
+	maxDoc     int
+	version    int32
+
+	// memory-resident structures
+	numericInstances  map[int]NumericDocValues
+	addressInstances  map[int]*packed.MonotonicBlockPackedReader
+	ordIndexInstances map[int]*packed.MonotonicBlockPackedReader
+}
+
+/* expert: instantiates a new reader */
+func newLucene45DocValuesProducer(state SegmentReadState,
+	dataCodec, dataExtension, metaCodec, metaExtension string) (dvp *Lucene45DocValuesProducer, err error) {
+
+	dvp = &Lucene45DocValuesProducer{
+		Locker:            new(sync.Mutex),
+		numerics:          make(map[int]*NumericEntry),
+		binaries:          make(map[int]*BinaryEntry),
+		sortedSets:        make(map[int]*SortedSetEntry),
+		ords:              make(map[int]*NumericEntry),
+		ordIndexes:        make(map[int]*NumericEntry),
+		maxDoc:            state.SegmentInfo.DocCount(),
+		numericInstances:  make(map[int]NumericDocValues),
+		addressInstances:  make(map[int]*packed.MonotonicBlockPackedReader),
+		ordIndexInstances: make(map[int]*packed.MonotonicBlockPackedReader),
+	}
+	metaName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, metaExtension)
+	// read in the entries from the metadata file.
+	var in store.ChecksumIndexInput
+	if in, err = state.Dir.OpenChecksumInput(metaName, state.Context); err != nil {
+		return nil, err
+	}
+
+	if err = func() (err error) {
+		var success = false
+		defer func() {
+			if success {
+				err = util.Close(in)
+			} else {
+				util.CloseWhileSuppressingError(in)
+			}
+		}()
+
+		if dvp.version, err = codec.CheckHeader(in, metaCodec,
+			LUCENE45_DV_VERSION_START, LUCENE45_DV_VERSION_CURRENT); err != nil {
+			return err
+		}
+		if err = dvp.readFields(in, state.FieldInfos); err != nil {
+			return err
+		}
+		if dvp.version >= LUCENE45_DV_VERSION_CHECKSUM {
+			_, err = codec.CheckFooter(in)
+		} else {
+			err = codec.CheckEOF(in)
+		}
+		if err != nil {
+			return err
+		}
+		success = true
+		return nil
+	}(); err != nil {
+		return nil, err
+	}
+
+	dataName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, dataExtension)
+	if dvp.data, err = state.Dir.OpenInput(dataName, state.Context); err != nil {
+		return nil, err
+	}
+	var success = false
+	defer func() {
+		if !success {
+			util.CloseWhileSuppressingError(dvp.data)
+		}
+	}()
+
+	var version2 int32
+	if version2, err = codec.CheckHeader(dvp.data, dataCodec,
+		LUCENE45_DV_VERSION_START, LUCENE45_DV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	if dvp.version != version2 {
+		return nil, errors.New("Format versions mismatch")
+	}
+
+	if dvp.version >= LUCENE45_DV_VERSION_CHECKSUM {
+		// NOTE: data file is too costly to verify checksum against all
+		// the bytes on open, but for now we at least verify proper
+		// structure of the checksum footer: which looks for FOOTER_MAGIC
+		// + algorithmID. This is cheap and can detect some forms of
+		// corruption such as file truncation.
+		if _, err = codec.RetrieveChecksum(dvp.data); err != nil {
+			return nil, err
+		}
+	}
+
+	success = true
+	return dvp, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) readSortedField(fieldNumber int,
+	meta store.IndexInput, infos FieldInfos) error {
+
+	// sorted = binary + numeric
+	if err := expectEntry(meta, fieldNumber, LUCENE45_DV_BINARY); err != nil {
+		return err
+	}
+	b, err := readBinaryEntry(meta)
+	if err != nil {
+		return err
+	}
+	dvp.binaries[fieldNumber] = b
+
+	if err = expectEntry(meta, fieldNumber, LUCENE45_DV_NUMERIC); err != nil {
+		return err
+	}
+	n, err := readNumericEntry(meta)
+	if err != nil {
+		return err
+	}
+	dvp.ords[fieldNumber] = n
+	return nil
+}
+
+func (dvp *Lucene45DocValuesProducer) readSortedSetFieldWithAddresses(fieldNumber int,
+	meta store.IndexInput, infos FieldInfos) error {
+
+	// sortedset = binary + numeric (addresses) + ordIndex
+	if err := dvp.readSortedField(fieldNumber, meta, infos); err != nil {
+		return err
+	}
+
+	if err := expectEntry(meta, fieldNumber, LUCENE45_DV_NUMERIC); err != nil {
+		return err
+	}
+	n, err := readNumericEntry(meta)
+	if err != nil {
+		return err
+	}
+	dvp.ordIndexes[fieldNumber] = n
+	return nil
+}
+
+/* Checks the field number and type of the next entry. */
+func expectEntry(meta store.IndexInput, fieldNumber int, typ byte) error {
+	n, err := meta.ReadVInt()
+	if err != nil {
+		return err
+	}
+	if int(n) != fieldNumber {
+		return errors.New(fmt.Sprintf(
+			"sorted entry for field: %v is corrupt (resource=%v)", fieldNumber, meta))
+	}
+	b, err := meta.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != typ {
+		return errors.New(fmt.Sprintf(
+			"sorted entry for field: %v is corrupt (resource=%v)", fieldNumber, meta))
+	}
+	return nil
+}
+
+func (dvp *Lucene45DocValuesProducer) readFields(meta store.IndexInput, infos FieldInfos) error {
+	n, err := meta.ReadVInt()
+	if err != nil {
+		return err
+	}
+	for fieldNumber := int(n); fieldNumber != -1; fieldNumber = int(n) {
+		// check should be: infos.FieldInfoByNumber(fieldNumber) != nil,
+		// but for CFS all fieldinfos in the CFS.
+		if fieldNumber < 0 {
+			return errors.New(fmt.Sprintf(
+				"Invalid field number: %v (resource=%v)", fieldNumber, meta))
+		}
+		typ, err := meta.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case LUCENE45_DV_NUMERIC:
+			if dvp.numerics[fieldNumber], err = readNumericEntry(meta); err != nil {
+				return err
+			}
+		case LUCENE45_DV_BINARY:
+			if dvp.binaries[fieldNumber], err = readBinaryEntry(meta); err != nil {
+				return err
+			}
+		case LUCENE45_DV_SORTED:
+			if err = dvp.readSortedField(fieldNumber, meta, infos); err != nil {
+				return err
+			}
+		case LUCENE45_DV_SORTED_SET:
+			ss, err := dvp.readSortedSetEntry(meta)
+			if err != nil {
+				return err
+			}
+			dvp.sortedSets[fieldNumber] = ss
+			switch ss.format {
+			case SORTED_SET_WITH_ADDRESSES:
+				err = dvp.readSortedSetFieldWithAddresses(fieldNumber, meta, infos)
+			case SORTED_SET_SINGLE_VALUED_SORTED:
+				if err = expectEntry(meta, fieldNumber, LUCENE45_DV_SORTED); err == nil {
+					err = dvp.readSortedField(fieldNumber, meta, infos)
+				}
+			default:
+				panic("assert fail")
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.New(fmt.Sprintf("invalid type: %v, resource=%v", typ, meta))
+		}
+		if n, err = meta.ReadVInt(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readNumericEntry(meta store.IndexInput) (entry *NumericEntry, err error) {
+	entry = new(NumericEntry)
+	var n int32
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.format = int(n)
+	if entry.missingOffset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.packedIntsVersion = int(n)
+	if entry.offset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if entry.count, err = meta.ReadVLong(); err != nil {
+		return nil, err
+	}
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.blockSize = int(n)
+	switch entry.format {
+	case GCD_COMPRESSED:
+		if entry.minValue, err = meta.ReadLong(); err != nil {
+			return nil, err
+		}
+		if entry.gcd, err = meta.ReadLong(); err != nil {
+			return nil, err
+		}
+	case TABLE_COMPRESSED:
+		if entry.count > 1<<31-1 {
+			return nil, errors.New(fmt.Sprintf(
+				"Cannot use TABLE_COMPRESSED with more than MAX_VALUE values, input=%v", meta))
+		}
+		if n, err = meta.ReadVInt(); err != nil {
+			return nil, err
+		}
+		if n > 256 {
+			return nil, errors.New(fmt.Sprintf(
+				"TABLE_COMPRESSED cannot have more than 256 distinct values, input=%v", meta))
+		}
+		entry.table = make([]int64, n)
+		for i, _ := range entry.table {
+			if entry.table[i], err = meta.ReadLong(); err != nil {
+				return nil, err
+			}
+		}
+	case DELTA_COMPRESSED:
+	default:
+		return nil, errors.New(fmt.Sprintf("Unknown format: %v, input=%v", entry.format, meta))
+	}
+	return entry, nil
+}
+
+func readBinaryEntry(meta store.IndexInput) (entry *BinaryEntry, err error) {
+	entry = new(BinaryEntry)
+	var n int32
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.format = int(n)
+	if entry.missingOffset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.minLength = int(n)
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.maxLength = int(n)
+	if entry.count, err = meta.ReadVLong(); err != nil {
+		return nil, err
+	}
+	if entry.offset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	switch entry.format {
+	case BINARY_FIXED_UNCOMPRESSED:
+		return entry, nil
+	case BINARY_PREFIX_COMPRESSED:
+		if n, err = meta.ReadVInt(); err != nil {
+			return nil, err
+		}
+		entry.addressInterval = int(n)
+	case BINARY_VARIABLE_UNCOMPRESSED:
+	default:
+		return nil, errors.New(fmt.Sprintf("Unknown format: %v, input=%v", entry.format, meta))
+	}
+	if entry.addressesOffset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.packedIntsVersion = int(n)
+	if n, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	entry.blockSize = int(n)
+	return entry, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) readSortedSetEntry(meta store.IndexInput) (*SortedSetEntry, error) {
+	entry := new(SortedSetEntry)
+	if dvp.version >= LUCENE45_DV_VERSION_SORTED_SET_SINGLE_VALUE_OPTIMIZED {
+		n, err := meta.ReadVInt()
+		if err != nil {
+			return nil, err
+		}
+		entry.format = int(n)
+	} else {
+		entry.format = SORTED_SET_WITH_ADDRESSES
+	}
+	if entry.format != SORTED_SET_SINGLE_VALUED_SORTED && entry.format != SORTED_SET_WITH_ADDRESSES {
+		return nil, errors.New(fmt.Sprintf("Unknown format: %v, input=%v", entry.format, meta))
+	}
+	return entry, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) Numeric(field *FieldInfo) (NumericDocValues, error) {
+	return dvp.numeric(int(field.Number), dvp.numerics[int(field.Number)])
+}
+
+/* Returns the (cached) in-memory values of the numeric entry. */
+func (dvp *Lucene45DocValuesProducer) numeric(fieldNumber int, entry *NumericEntry) (NumericDocValues, error) {
+	dvp.Lock()
+	defer dvp.Unlock()
+
+	instance, ok := dvp.numericInstances[fieldNumber]
+	if !ok {
+		var err error
+		if instance, err = dvp.loadNumeric(entry); err != nil {
+			return nil, err
+		}
+		dvp.numericInstances[fieldNumber] = instance
+	}
+	return instance, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) loadNumeric(entry *NumericEntry) (NumericDocValues, error) {
+	assert(entry != nil)
+	data := dvp.data.Clone()
+	if err := data.Seek(entry.offset); err != nil {
+		return nil, err
+	}
+
+	switch entry.format {
+	case DELTA_COMPRESSED:
+		reader, err := packed.NewBlockPackedReader(data,
+			entry.packedIntsVersion, entry.blockSize, entry.count)
+		if err != nil {
+			return nil, err
+		}
+		return func(docID int) int64 {
+			return reader.Get(int64(docID))
+		}, nil
+
+	case GCD_COMPRESSED:
+		min, mult := entry.minValue, entry.gcd
+		quotientReader, err := packed.NewBlockPackedReader(data,
+			entry.packedIntsVersion, entry.blockSize, entry.count)
+		if err != nil {
+			return nil, err
+		}
+		return func(docID int) int64 {
+			return min + mult*quotientReader.Get(int64(docID))
+		}, nil
+
+	case TABLE_COMPRESSED:
+		table := entry.table
+		bitsRequired := packed.BitsRequired(int64(len(table) - 1))
+		ords, err := packed.ReaderNoHeader(data, packed.PackedFormat(packed.PACKED),
+			int32(entry.packedIntsVersion), int32(entry.count), uint32(bitsRequired))
+		if err != nil {
+			return nil, err
+		}
+		return func(docID int) int64 {
+			return table[int(ords.Get(docID))]
+		}, nil
+
+	default:
+		panic("assert fail")
+	}
+}
+
+func (dvp *Lucene45DocValuesProducer) Binary(field *FieldInfo) (BinaryDocValues, error) {
+	bytes := dvp.binaries[int(field.Number)]
+	switch bytes.format {
+	case BINARY_FIXED_UNCOMPRESSED:
+		return dvp.fixedBinary(bytes), nil
+	case BINARY_VARIABLE_UNCOMPRESSED:
+		return dvp.variableBinary(int(field.Number), bytes)
+	case BINARY_PREFIX_COMPRESSED:
+		return dvp.compressedBinary(int(field.Number), bytes)
+	default:
+		panic("assert fail")
+	}
+}
+
+func (dvp *Lucene45DocValuesProducer) fixedBinary(bytes *BinaryEntry) BinaryDocValues {
+	return &binaryDocValues{
+		data: dvp.data.Clone(),
+		address: func(id int64) (int64, int) {
+			return bytes.offset + id*int64(bytes.maxLength), bytes.maxLength
+		},
+	}
+}
+
+/* returns an address instance for variable-length binary values. */
+func (dvp *Lucene45DocValuesProducer) addresses(fieldNumber int,
+	bytes *BinaryEntry, size int64) (*packed.MonotonicBlockPackedReader, error) {
+
+	dvp.Lock()
+	defer dvp.Unlock()
+
+	addresses, ok := dvp.addressInstances[fieldNumber]
+	if !ok {
+		data := dvp.data.Clone()
+		if err := data.Seek(bytes.addressesOffset); err != nil {
+			return nil, err
+		}
+		var err error
+		if addresses, err = packed.NewMonotonicBlockPackedReader(data,
+			bytes.packedIntsVersion, bytes.blockSize, size); err != nil {
+			return nil, err
+		}
+		dvp.addressInstances[fieldNumber] = addresses
+	}
+	return addresses, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) variableBinary(fieldNumber int,
+	bytes *BinaryEntry) (BinaryDocValues, error) {
+
+	addresses, err := dvp.addresses(fieldNumber, bytes, bytes.count)
+	if err != nil {
+		return nil, err
+	}
+	return &binaryDocValues{
+		data: dvp.data.Clone(),
+		address: func(id int64) (int64, int) {
+			var startAddress int64
+			if id > 0 {
+				startAddress = addresses.Get(id - 1)
+			}
+			endAddress := addresses.Get(id)
+			return bytes.offset + startAddress, int(endAddress - startAddress)
+		},
+	}, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) compressedBinary(fieldNumber int,
+	bytes *BinaryEntry) (BinaryDocValues, error) {
+
+	interval := int64(bytes.addressInterval)
+	size := bytes.count / interval
+	if bytes.count%interval != 0 {
+		size++
+	}
+	addresses, err := dvp.addresses(fieldNumber, bytes, size)
+	if err != nil {
+		return nil, err
+	}
+	return &compressedBinaryDocValues{
+		bytes:     bytes,
+		addresses: addresses,
+		data:      dvp.data.Clone(),
+	}, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) Sorted(field *FieldInfo) (SortedDocValues, error) {
+	fieldNumber := int(field.Number)
+	ordinals, err := dvp.numeric(fieldNumber, dvp.ords[fieldNumber])
+	if err != nil {
+		return nil, err
+	}
+	binary, err := dvp.Binary(field)
+	if err != nil {
+		return nil, err
+	}
+	return &sortedDocValues{
+		ordinals:   ordinals,
+		binary:     binary,
+		valueCount: int(dvp.binaries[fieldNumber].count),
+	}, nil
+}
+
+/* returns an address instance for sortedset ordinal lists */
+func (dvp *Lucene45DocValuesProducer) ordIndex(fieldNumber int,
+	entry *NumericEntry) (*packed.MonotonicBlockPackedReader, error) {
+
+	dvp.Lock()
+	defer dvp.Unlock()
+
+	ordIndex, ok := dvp.ordIndexInstances[fieldNumber]
+	if !ok {
+		data := dvp.data.Clone()
+		if err := data.Seek(entry.offset); err != nil {
+			return nil, err
+		}
+		var err error
+		if ordIndex, err = packed.NewMonotonicBlockPackedReader(data,
+			entry.packedIntsVersion, entry.blockSize, entry.count); err != nil {
+			return nil, err
+		}
+		dvp.ordIndexInstances[fieldNumber] = ordIndex
+	}
+	return ordIndex, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) SortedSet(field *FieldInfo) (SortedSetDocValues, error) {
+	fieldNumber := int(field.Number)
+	ss := dvp.sortedSets[fieldNumber]
+	if ss.format == SORTED_SET_SINGLE_VALUED_SORTED {
+		values, err := dvp.Sorted(field)
+		if err != nil {
+			return nil, err
+		}
+		return &singletonSortedSetDocValues{in: values}, nil
+	}
+	assert(ss.format == SORTED_SET_WITH_ADDRESSES)
+
+	ordinals, err := dvp.numeric(fieldNumber, dvp.ords[fieldNumber])
+	if err != nil {
+		return nil, err
+	}
+	ordIndex, err := dvp.ordIndex(fieldNumber, dvp.ordIndexes[fieldNumber])
+	if err != nil {
+		return nil, err
+	}
+	binary, err := dvp.Binary(field)
+	if err != nil {
+		return nil, err
+	}
+	return &sortedSetDocValues{
+		ordinals:   ordinals,
+		ordIndex:   ordIndex,
+		binary:     binary,
+		valueCount: dvp.binaries[fieldNumber].count,
+	}, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) missingBits(offset int64) (util.Bits, error) {
+	if offset == -1 {
+		return util.NewMatchAllBits(dvp.maxDoc), nil
+	}
+	data := dvp.data.Clone()
+	if err := data.Seek(offset); err != nil {
+		return nil, err
+	}
+	bits := make([]byte, (dvp.maxDoc+7)/8)
+	if err := data.ReadBytes(bits); err != nil {
+		return nil, err
+	}
+	return missingBits{bits, dvp.maxDoc}, nil
+}
+
+func (dvp *Lucene45DocValuesProducer) DocsWithField(field *FieldInfo) (util.Bits, error) {
+	switch field.DocValuesType() {
+	case DOC_VALUES_TYPE_SORTED_SET:
+		values, err := dvp.SortedSet(field)
+		if err != nil {
+			return nil, err
+		}
+		return sortedSetDocsWithValue{values, dvp.maxDoc}, nil
+	case DOC_VALUES_TYPE_SORTED:
+		values, err := dvp.Sorted(field)
+		if err != nil {
+			return nil, err
+		}
+		return sortedDocsWithValue{values, dvp.maxDoc}, nil
+	case DOC_VALUES_TYPE_BINARY:
+		return dvp.missingBits(dvp.binaries[int(field.Number)].missingOffset)
+	case DOC_VALUES_TYPE_NUMERIC:
+		return dvp.missingBits(dvp.numerics[int(field.Number)].missingOffset)
+	default:
+		panic("assert fail")
+	}
+}
+
+func (dvp *Lucene45DocValuesProducer) Close() error {
+	return dvp.data.Close()
+}
+
+/* metadata entry for a numeric docvalues field */
+type NumericEntry struct {
+	// offset to the bitset representing docsWithField, or -1 if no
+	// documents have missing values
+	missingOffset int64
+	// offset to the actual numeric values
+	offset int64
+
+	format int
+	// packed ints version used to encode these numerics
+	packedIntsVersion int
+	// count of values written
+	count int64
+	// packed ints blocksize
+	blockSize int
+
+	minValue int64
+	gcd      int64
+	table    []int64
+}
+
+/* metadata entry for a binary docvalues field */
+type BinaryEntry struct {
+	// offset to the bitset representing docsWithField, or -1 if no
+	// documents have missing values
+	missingOffset int64
+	// offset to the actual binary values
+	offset int64
+
+	format int
+	// count of values written
+	count     int64
+	minLength int
+	maxLength int
+	// offset to the addressing data that maps a value to its slice of
+	// []byte
+	addressesOffset int64
+	// interval of shared prefix chunks (when using prefix-compressed
+	// binary)
+	addressInterval int
+	// packed ints version used to encode addressing information
+	packedIntsVersion int
+	// packed ints blocksize
+	blockSize int
+}
+
+/* metadata entry for a sorted-set docvalues field */
+type SortedSetEntry struct {
+	format int
+}
+
+/*
+Binary values read from a private clone of the data file; the
+returned []byte is reused across calls.
+*/
+type binaryDocValues struct {
+	data    store.IndexInput
+	address func(id int64) (int64, int)
+	buf     []byte
+}
+
+func (v *binaryDocValues) Get(docID int) []byte {
+	offset, length := v.address(int64(docID))
+	if cap(v.buf) < length {
+		v.buf = make([]byte, length)
+	}
+	v.buf = v.buf[:length]
+	if length > 0 {
+		if err := v.data.Seek(offset); err != nil {
+			panic(err)
+		}
+		if err := v.data.ReadBytes(v.buf); err != nil {
+			panic(err)
+		}
+	}
+	return v.buf
+}
+
+/* Binary values which were prefix-compressed in chunks. */
+type compressedBinaryDocValues struct {
+	bytes     *BinaryEntry
+	addresses *packed.MonotonicBlockPackedReader
+	data      store.IndexInput
+	term      []byte
+}
+
+func (v *compressedBinaryDocValues) Get(ord int) []byte {
+	interval := int64(v.bytes.addressInterval)
+	block, pos := int64(ord)/interval, int64(ord)%interval
+	if err := v.data.Seek(v.bytes.offset + v.addresses.Get(block)); err != nil {
+		panic(err)
+	}
+	for i := int64(0); i <= pos; i++ {
+		prefix, err := v.data.ReadVInt()
+		if err != nil {
+			panic(err)
+		}
+		suffix, err := v.data.ReadVInt()
+		if err != nil {
+			panic(err)
+		}
+		length := int(prefix + suffix)
+		if cap(v.term) < length {
+			term := make([]byte, length, length+length>>1)
+			copy(term, v.term[:prefix])
+			v.term = term
+		}
+		v.term = v.term[:length]
+		if err = v.data.ReadBytes(v.term[prefix:]); err != nil {
+			panic(err)
+		}
+	}
+	return v.term
+}
+
+type sortedDocValues struct {
+	ordinals   NumericDocValues
+	binary     BinaryDocValues
+	valueCount int
+}
+
+func (v *sortedDocValues) Ord(docID int) int {
+	return int(v.ordinals(docID))
+}
+
+func (v *sortedDocValues) LookupOrd(ord int) []byte {
+	return v.binary.Get(ord)
+}
+
+func (v *sortedDocValues) ValueCount() int {
+	return v.valueCount
+}
+
+func (v *sortedDocValues) Get(docID int) []byte {
+	if ord := v.Ord(docID); ord != -1 {
+		return v.LookupOrd(ord)
+	}
+	return nil
+}
+
+type sortedSetDocValues struct {
+	ordinals          NumericDocValues
+	ordIndex          *packed.MonotonicBlockPackedReader
+	binary            BinaryDocValues
+	valueCount        int64
+	offset, endOffset int64
+}
+
+func (v *sortedSetDocValues) NextOrd() int64 {
+	if v.offset == v.endOffset {
+		return NO_MORE_ORDS
+	}
+	ord := v.ordinals(int(v.offset))
+	v.offset++
+	return ord
+}
+
+func (v *sortedSetDocValues) SetDocument(docID int) {
+	v.offset = 0
+	if docID > 0 {
+		v.offset = v.ordIndex.Get(int64(docID - 1))
+	}
+	v.endOffset = v.ordIndex.Get(int64(docID))
+}
+
+func (v *sortedSetDocValues) LookupOrd(ord int64) []byte {
+	return v.binary.Get(int(ord))
+}
+
+func (v *sortedSetDocValues) ValueCount() int64 {
+	return v.valueCount
+}
+
+/* Exposes a single-valued SortedDocValues as a SortedSetDocValues. */
+type singletonSortedSetDocValues struct {
+	in         SortedDocValues
+	currentOrd int64
+}
+
+func (v *singletonSortedSetDocValues) NextOrd() int64 {
+	ord := v.currentOrd
+	v.currentOrd = NO_MORE_ORDS
+	return ord
+}
+
+func (v *singletonSortedSetDocValues) SetDocument(docID int) {
+	v.currentOrd = int64(v.in.Ord(docID))
+}
+
+func (v *singletonSortedSetDocValues) LookupOrd(ord int64) []byte {
+	return v.in.LookupOrd(int(ord))
+}
+
+func (v *singletonSortedSetDocValues) ValueCount() int64 {
+	return int64(v.in.ValueCount())
+}
+
+/* one bit per doc, set for docs which have a value */
+type missingBits struct {
+	bits   []byte
+	maxDoc int
+}
+
+func (b missingBits) At(index int) bool {
+	return b.bits[index>>3]&(1<<uint(index&7)) != 0
+}
+
+func (b missingBits) Length() int {
+	return b.maxDoc
+}
+
+type sortedDocsWithValue struct {
+	values SortedDocValues
+	maxDoc int
+}
+
+func (b sortedDocsWithValue) At(index int) bool {
+	return b.values.Ord(index) >= 0
+}
+
+func (b sortedDocsWithValue) Length() int {
+	return b.maxDoc
+}
+
+type sortedSetDocsWithValue struct {
+	values SortedSetDocValues
+	maxDoc int
+}
+
+func (b sortedSetDocsWithValue) At(index int) bool {
+	b.values.SetDocument(index)
+	return b.values.NextOrd() != NO_MORE_ORDS
+}
+
+func (b sortedSetDocsWithValue) Length() int {
+	return b.maxDoc
+}
@@ -186,6 +186,21 @@ func (nc *NormsConsumer) AddNumericField(field *FieldInfo,
 	return nil
 }
 
+func (nc *NormsConsumer) AddBinaryField(field *FieldInfo,
+	values func() func() (interface{}, bool)) error {
+	panic("not supported")
+}
+
+func (nc *NormsConsumer) AddSortedField(field *FieldInfo,
+	values, docToOrd func() func() (interface{}, bool)) error {
+	panic("not supported")
+}
+
+func (nc *NormsConsumer) AddSortedSetField(field *FieldInfo,
+	values, docToOrdCount, ords func() func() (interface{}, bool)) error {
+	panic("not supported")
+}
+
 type Longs []int64
 
 func (a Longs) Len() int           { return len(a) }
@@ -263,10 +278,24 @@ func (m *NormMap) add(l int64) bool {
 
 /* Gets the ordinal for a previously added item. */
 func (m *NormMap) ord(l int64) int {
-	panic("niy")
+	if l >= math.MinInt8 && l <= math.MaxInt8 {
+		return int(m.singleByteRange[int(l+128)])
+	}
+	ord, ok := m.other[l]
+	assert(ok)
+	return int(ord)
 }
 
 /* Retrieves the ordinal table for previously added items. */
 func (m *NormMap) decodeTable() []int64 {
-	panic("niy")
+	decode := make([]int64, m.size)
+	for i, s := range m.singleByteRange {
+		if s >= 0 {
+			decode[s] = int64(i) - 128
+		}
+	}
+	for k, v := range m.other {
+		decode[v] = k
+	}
+	return decode
 }
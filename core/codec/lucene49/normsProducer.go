@@ -233,6 +233,10 @@ func (np *NormsProducer) SortedSet(field *FieldInfo) (SortedSetDocValues, error)
 	panic("not supported")
 }
 
+func (np *NormsProducer) DocsWithField(field *FieldInfo) (util.Bits, error) {
+	return util.NewMatchAllBits(np.maxDoc), nil
+}
+
 func (np *NormsProducer) Close() error {
 	return np.data.Close()
 }
@@ -5,6 +5,7 @@ import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/util"
 	"io"
+	"strconv"
 )
 
 // perfield/PerFieldDocValuesFormat.java
@@ -22,10 +23,11 @@ containing the format name. For example, in a per-field configuration
 instead of _1.dat fielnames would look like _1_Lucene40_0.dat.
 */
 type PerFieldDocValuesFormat struct {
+	docValuesFormatForField func(string) DocValuesFormat
 }
 
 func NewPerFieldDocValuesFormat(f func(field string) DocValuesFormat) *PerFieldDocValuesFormat {
-	return &PerFieldDocValuesFormat{}
+	return &PerFieldDocValuesFormat{f}
 }
 
 func (pf *PerFieldDocValuesFormat) Name() string {
@@ -33,13 +35,163 @@ func (pf *PerFieldDocValuesFormat) Name() string {
 }
 
 func (pf *PerFieldDocValuesFormat) FieldsConsumer(state *SegmentWriteState) (w DocValuesConsumer, err error) {
-	panic("not implemented yet")
+	return newPerFieldDocValuesWriter(pf, state), nil
 }
 
 func (pf *PerFieldDocValuesFormat) FieldsProducer(state SegmentReadState) (r DocValuesProducer, err error) {
 	return newPerFieldDocValuesReader(state)
 }
 
+const (
+	DV_PER_FIELD_FORMAT_KEY = "PerFieldDocValuesFormat.format"
+	DV_PER_FIELD_SUFFIX_KEY = "PerFieldDocValuesFormat.suffix"
+)
+
+type DocValuesConsumerAndSuffix struct {
+	consumer DocValuesConsumer
+	suffix   int
+}
+
+func (cas *DocValuesConsumerAndSuffix) Close() error {
+	return cas.consumer.Close()
+}
+
+type PerFieldDocValuesWriter struct {
+	owner             *PerFieldDocValuesFormat
+	formats           map[DocValuesFormat]*DocValuesConsumerAndSuffix
+	suffixes          map[string]int
+	segmentWriteState *SegmentWriteState
+}
+
+func newPerFieldDocValuesWriter(owner *PerFieldDocValuesFormat,
+	state *SegmentWriteState) DocValuesConsumer {
+	return &PerFieldDocValuesWriter{
+		owner,
+		make(map[DocValuesFormat]*DocValuesConsumerAndSuffix),
+		make(map[string]int),
+		state,
+	}
+}
+
+func (w *PerFieldDocValuesWriter) AddNumericField(field *FieldInfo,
+	values func() func() (interface{}, bool)) error {
+
+	consumer, err := w.instance(field)
+	if err != nil {
+		return err
+	}
+	return consumer.AddNumericField(field, values)
+}
+
+func (w *PerFieldDocValuesWriter) AddBinaryField(field *FieldInfo,
+	values func() func() (interface{}, bool)) error {
+
+	consumer, err := w.instance(field)
+	if err != nil {
+		return err
+	}
+	return consumer.AddBinaryField(field, values)
+}
+
+func (w *PerFieldDocValuesWriter) AddSortedField(field *FieldInfo,
+	values, docToOrd func() func() (interface{}, bool)) error {
+
+	consumer, err := w.instance(field)
+	if err != nil {
+		return err
+	}
+	return consumer.AddSortedField(field, values, docToOrd)
+}
+
+func (w *PerFieldDocValuesWriter) AddSortedSetField(field *FieldInfo,
+	values, docToOrdCount, ords func() func() (interface{}, bool)) error {
+
+	consumer, err := w.instance(field)
+	if err != nil {
+		return err
+	}
+	return consumer.AddSortedSetField(field, values, docToOrdCount, ords)
+}
+
+func (w *PerFieldDocValuesWriter) instance(field *FieldInfo) (DocValuesConsumer, error) {
+	var format DocValuesFormat
+	if field.DocValuesGen() != -1 {
+		// this means the field never existed in that segment, yet is
+		// applied updates
+		if formatName := field.Attribute(DV_PER_FIELD_FORMAT_KEY); formatName != "" {
+			format = LoadDocValuesFormat(formatName)
+		}
+	}
+	if format == nil {
+		format = w.owner.docValuesFormatForField(field.Name)
+	}
+	assert2(format != nil, "invalid nil DocValuesFormat for field='%v'", field.Name)
+	formatName := format.Name()
+
+	previousValue := field.PutAttribute(DV_PER_FIELD_FORMAT_KEY, formatName)
+	assert2(field.DocValuesGen() != -1 || previousValue == "",
+		"formatName=%v prevValue=%v", formatName, previousValue)
+
+	suffix := -1
+	consumer, ok := w.formats[format]
+	if !ok {
+		// First time we are seeing this format; create a new instance
+
+		if field.DocValuesGen() != -1 {
+			// even when dvGen is != -1, it can still be a new field, that
+			// never existed in the segment, and therefore doesn't have the
+			// recorded attributes yet.
+			if suffixAtt := field.Attribute(DV_PER_FIELD_SUFFIX_KEY); suffixAtt != "" {
+				n, err := strconv.Atoi(suffixAtt)
+				if err != nil {
+					return nil, err
+				}
+				suffix = n
+			}
+		}
+
+		if suffix == -1 {
+			// bump the suffix
+			if suffix, ok = w.suffixes[formatName]; !ok {
+				suffix = 0
+			} else {
+				suffix = suffix + 1
+			}
+		}
+		w.suffixes[formatName] = suffix
+
+		segmentSuffix := dvFullSegmentSuffix(w.segmentWriteState.SegmentSuffix,
+			dvSuffix(formatName, strconv.Itoa(suffix)))
+		c, err := format.FieldsConsumer(NewSegmentWriteStateFrom(w.segmentWriteState, segmentSuffix))
+		if err != nil {
+			return nil, err
+		}
+		consumer = &DocValuesConsumerAndSuffix{c, suffix}
+		w.formats[format] = consumer
+	} else {
+		// we've already seen this format, so just grab its suffix
+		_, ok := w.suffixes[formatName]
+		assert(ok)
+		suffix = consumer.suffix
+	}
+
+	previousValue = field.PutAttribute(DV_PER_FIELD_SUFFIX_KEY, strconv.Itoa(suffix))
+	assert2(field.DocValuesGen() != -1 || previousValue == "",
+		"suffix=%v prevValue=%v", suffix, previousValue)
+
+	// TODO: we should only provide the "slice" of FIS that this DVF
+	// actually sees ...
+	return consumer.consumer, nil
+}
+
+func (w *PerFieldDocValuesWriter) Close() error {
+	var subs []io.Closer
+	for _, v := range w.formats {
+		subs = append(subs, v)
+	}
+	return util.Close(subs...)
+}
+
 func dvSuffix(format, suffix string) string {
 	return format + "_" + suffix
 }
@@ -78,17 +230,19 @@ func newPerFieldDocValuesReader(state SegmentReadState) (dvp DocValuesProducer,
 	for _, fi := range state.FieldInfos.Values {
 		if fi.HasDocValues() {
 			fieldName := fi.Name
-			if formatName := fi.Attribute(PER_FIELD_FORMAT_KEY); formatName != "" {
+			if formatName := fi.Attribute(DV_PER_FIELD_FORMAT_KEY); formatName != "" {
 				// null formatName means the field is in fieldInfos, but has no docvalues!
-				suffix := fi.Attribute(PER_FIELD_SUFFIX_KEY)
-				// assert suffix != nil
+				suffix := fi.Attribute(DV_PER_FIELD_SUFFIX_KEY)
+				assert2(suffix != "", "missing attribute: %v for field: %v", DV_PER_FIELD_SUFFIX_KEY, fieldName)
 				segmentSuffix := dvFullSegmentSuffix(state.SegmentSuffix, dvSuffix(formatName, suffix))
 				if _, ok := ans.formats[segmentSuffix]; !ok {
 					newReadState := state // clone
-					newReadState.SegmentSuffix = formatName + "_" + suffix
-					if p, err := LoadDocValuesProducer(formatName, newReadState); err == nil {
-						ans.formats[segmentSuffix] = p
+					newReadState.SegmentSuffix = segmentSuffix
+					p, err := LoadDocValuesProducer(formatName, newReadState)
+					if err != nil {
+						return nil, err
 					}
+					ans.formats[segmentSuffix] = p
 				}
 				ans.fields[fieldName] = ans.formats[segmentSuffix]
 			}
@@ -126,6 +280,13 @@ func (dvp *PerFieldDocValuesReader) SortedSet(field *FieldInfo) (v SortedSetDocV
 	return nil, nil
 }
 
+func (dvp *PerFieldDocValuesReader) DocsWithField(field *FieldInfo) (v util.Bits, err error) {
+	if p, ok := dvp.fields[field.Name]; ok {
+		return p.DocsWithField(field)
+	}
+	return nil, nil
+}
+
 func (dvp *PerFieldDocValuesReader) Close() error {
 	fps := make([]DocValuesProducer, 0)
 	for _, v := range dvp.formats {
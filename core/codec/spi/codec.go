@@ -211,7 +211,9 @@ type LiveDocsFormat interface {
 	// Creates a new MutableBits, with all bits set, for the specified size.
 	NewLiveDocs(size int) util.MutableBits
 	// Creates a new MutableBits of the same bits set and size of existing.
-	// NewLiveDocs(existing util.Bits) (util.MutableBits, error)
+	NewLiveDocsFrom(existing util.Bits) (util.MutableBits, error)
+	// Read live docs bits.
+	ReadLiveDocs(dir store.Directory, info *SegmentCommitInfo, ctx store.IOContext) (util.Bits, error)
 	// Persist live docs bits. Use SegmentCommitInfo.nextDelGen() to
 	// determine the generation of the deletes file you should write to.
 	WriteLiveDocs(bits util.MutableBits, dir store.Directory,
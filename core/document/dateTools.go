@@ -0,0 +1,178 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// document/DateTools.java
+
+/*
+Provides support for converting dates to strings and vice-versa. The
+strings are structured so that lexicographic sorting orders them by
+date, which makes them suitable for use as field values and search
+terms.
+
+This class also helps you to limit the resolution of your dates. Do
+not save dates with a finer resolution than you really need, as then
+TermRangeQuery and PrefixQuery will require more memory and become
+slower.
+
+Another approach is NumericUtils, which provides a sortable binary
+representation (prefix encoded) of numeric values, which date/time
+are. For indexing a time.Time, just get the UnixNano()/1e6 and index
+this as a numeric value with LongField and use NumericRangeQuery to
+query it.
+
+All the conversions are done in GMT.
+*/
+
+/* Specifies the time granularity. */
+type Resolution int
+
+const (
+	RESOLUTION_YEAR        = Resolution(4)  // Limit a date's resolution to year granularity.
+	RESOLUTION_MONTH       = Resolution(6)  // Limit a date's resolution to month granularity.
+	RESOLUTION_DAY         = Resolution(8)  // Limit a date's resolution to day granularity.
+	RESOLUTION_HOUR        = Resolution(10) // Limit a date's resolution to hour granularity.
+	RESOLUTION_MINUTE      = Resolution(12) // Limit a date's resolution to minute granularity.
+	RESOLUTION_SECOND      = Resolution(14) // Limit a date's resolution to second granularity.
+	RESOLUTION_MILLISECOND = Resolution(17) // Limit a date's resolution to millisecond granularity.
+)
+
+// the full format, cut to the length of each resolution
+const dateToolsFullFormat = "20060102150405.000"
+
+func (r Resolution) String() string {
+	switch r {
+	case RESOLUTION_YEAR:
+		return "year"
+	case RESOLUTION_MONTH:
+		return "month"
+	case RESOLUTION_DAY:
+		return "day"
+	case RESOLUTION_HOUR:
+		return "hour"
+	case RESOLUTION_MINUTE:
+		return "minute"
+	case RESOLUTION_SECOND:
+		return "second"
+	case RESOLUTION_MILLISECOND:
+		return "millisecond"
+	}
+	return fmt.Sprintf("Resolution(%v)", int(r))
+}
+
+/* Returns the layout of the string representation of the resolution. */
+func (r Resolution) layout() string {
+	if r == RESOLUTION_MILLISECOND {
+		return dateToolsFullFormat
+	}
+	return dateToolsFullFormat[:int(r)]
+}
+
+/*
+Converts a time.Time to a string suitable for indexing, in the form
+yyyyMMddHHmmssSSS or shorter, depending on resolution; using GMT as
+timezone.
+*/
+func DateToString(date time.Time, resolution Resolution) string {
+	s := DateRound(date, resolution).Format(resolution.layout())
+	if resolution == RESOLUTION_MILLISECOND {
+		// drop the decimal point of the milliseconds
+		s = s[:14] + s[15:]
+	}
+	return s
+}
+
+/*
+Converts a millisecond time to a string suitable for indexing, in the
+form yyyyMMddHHmmssSSS or shorter, depending on resolution; using GMT
+as timezone.
+*/
+func TimeToString(ms int64, resolution Resolution) string {
+	return DateToString(msToTime(ms), resolution)
+}
+
+/*
+Converts a string produced by TimeToString() or DateToString() back
+to a time, represented as the number of milliseconds since January 1,
+1970, 00:00:00 GMT.
+*/
+func StringToTime(dateString string) (int64, error) {
+	date, err := StringToDate(dateString)
+	if err != nil {
+		return 0, err
+	}
+	return date.UnixNano() / int64(time.Millisecond), nil
+}
+
+/*
+Converts a string produced by TimeToString() or DateToString() back
+to a time.Time.
+*/
+func StringToDate(dateString string) (time.Time, error) {
+	for _, r := range []Resolution{RESOLUTION_YEAR, RESOLUTION_MONTH,
+		RESOLUTION_DAY, RESOLUTION_HOUR, RESOLUTION_MINUTE, RESOLUTION_SECOND,
+		RESOLUTION_MILLISECOND} {
+
+		if len(dateString) == int(r) {
+			if r == RESOLUTION_MILLISECOND {
+				dateString = dateString[:14] + "." + dateString[14:]
+			}
+			return time.ParseInLocation(r.layout(), dateString, time.UTC)
+		}
+	}
+	return time.Time{}, errors.New(fmt.Sprintf("Input is not a valid date string: %v", dateString))
+}
+
+/*
+Limit a date's resolution. For example, the date 2004-09-21 13:50:11
+will be changed to 2004-09-01 00:00:00 when using RESOLUTION_MONTH.
+
+The returned time is in GMT.
+*/
+func DateRound(date time.Time, resolution Resolution) time.Time {
+	date = date.UTC()
+	year, month, day := date.Date()
+	hour, min, sec := date.Clock()
+	nsec := date.Nanosecond()
+	switch resolution {
+	case RESOLUTION_YEAR:
+		month = time.January
+		fallthrough
+	case RESOLUTION_MONTH:
+		day = 1
+		fallthrough
+	case RESOLUTION_DAY:
+		hour = 0
+		fallthrough
+	case RESOLUTION_HOUR:
+		min = 0
+		fallthrough
+	case RESOLUTION_MINUTE:
+		sec = 0
+		fallthrough
+	case RESOLUTION_SECOND:
+		nsec = 0
+	case RESOLUTION_MILLISECOND:
+		// nothing to do beyond dropping sub-millisecond precision
+		nsec -= nsec % int(time.Millisecond)
+	default:
+		panic(fmt.Sprintf("unknown resolution %v", resolution))
+	}
+	return time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+}
+
+/*
+Limit a date's resolution. The time is given and returned as the
+number of milliseconds since January 1, 1970, 00:00:00 GMT.
+*/
+func TimeRound(ms int64, resolution Resolution) int64 {
+	return DateRound(msToTime(ms), resolution).UnixNano() / int64(time.Millisecond)
+}
+
+func msToTime(ms int64) time.Time {
+	return time.Unix(ms/1000, (ms%1000)*1000000).UTC()
+}
@@ -102,19 +102,23 @@ func (visitor *DocumentStoredFieldVisitor) StringField(fi *FieldInfo, value stri
 }
 
 func (visitor *DocumentStoredFieldVisitor) IntField(fi *FieldInfo, value int) error {
-	panic("not implemented yet")
+	visitor.doc.Add(NewStoredFieldInt(fi.Name, int32(value)))
+	return nil
 }
 
 func (visitor *DocumentStoredFieldVisitor) LongField(fi *FieldInfo, value int64) error {
-	panic("not implemented yet")
+	visitor.doc.Add(NewStoredFieldLong(fi.Name, value))
+	return nil
 }
 
 func (visitor *DocumentStoredFieldVisitor) FloatField(fi *FieldInfo, value float32) error {
-	panic("not implemented yet")
+	visitor.doc.Add(NewStoredFieldFloat(fi.Name, value))
+	return nil
 }
 
 func (visitor *DocumentStoredFieldVisitor) DoubleField(fi *FieldInfo, value float64) error {
-	panic("not implemented yet")
+	visitor.doc.Add(NewStoredFieldDouble(fi.Name, value))
+	return nil
 }
 
 func (visitor *DocumentStoredFieldVisitor) NeedsField(fi *FieldInfo) (status StoredFieldVisitorStatus, err error) {
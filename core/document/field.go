@@ -6,6 +6,7 @@ import (
 	"github.com/balzaczyy/golucene/core/analysis"
 	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
 	"github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
 	"io"
 	"log"
 	"strconv"
@@ -48,11 +49,19 @@ func NewFieldFromString(name, value string, ft *FieldType) *Field {
 }
 
 func (f *Field) StringValue() string {
-	switch f._data.(type) {
+	switch v := f._data.(type) {
 	case string:
-		return f._data.(string)
+		return v
 	case int:
-		return strconv.Itoa(f._data.(int))
+		return strconv.Itoa(v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
 	default:
 		log.Println("Unknown type", f._data)
 		panic("not implemented yet")
@@ -89,6 +98,40 @@ func (f *Field) NumericValue() interface{} {
 	}
 }
 
+/*
+Expert: change the value of this field. This can be used during
+indexing to re-use a single Field instance to improve indexing speed
+by avoiding GC cost of new'ing and reclaiming Field instances.
+Typically a single Document instance is re-used as well. The value
+type must stay the same.
+*/
+func (f *Field) SetIntValue(value int32) {
+	_, ok := f._data.(int32)
+	assert2(ok, fmt.Sprintf("cannot change value type from %T to int32", f._data))
+	f._data = value
+}
+
+/* Expert: change the value of this field. See SetIntValue(). */
+func (f *Field) SetLongValue(value int64) {
+	_, ok := f._data.(int64)
+	assert2(ok, fmt.Sprintf("cannot change value type from %T to int64", f._data))
+	f._data = value
+}
+
+/* Expert: change the value of this field. See SetIntValue(). */
+func (f *Field) SetFloatValue(value float32) {
+	_, ok := f._data.(float32)
+	assert2(ok, fmt.Sprintf("cannot change value type from %T to float32", f._data))
+	f._data = value
+}
+
+/* Expert: change the value of this field. See SetIntValue(). */
+func (f *Field) SetDoubleValue(value float64) {
+	_, ok := f._data.(float64)
+	assert2(ok, fmt.Sprintf("cannot change value type from %T to float64", f._data))
+	f._data = value
+}
+
 func (f *Field) BinaryValue() []byte {
 	if v, ok := f._data.([]byte); ok {
 		return v
@@ -115,8 +158,27 @@ func (f *Field) TokenStream(analyzer analysis.Analyzer, reuse analysis.TokenStre
 		return nil, nil
 	}
 
-	if nt := f.FieldType().(*FieldType).NumericType(); nt != NumericType(0) {
-		panic("not implemented yet")
+	if ft := f.FieldType().(*FieldType); ft.NumericType() != NumericType(0) {
+		nts, ok := reuse.(*analysis.NumericTokenStream)
+		if !ok || nts.PrecisionStep() != ft.NumericPrecisionStep() {
+			// lazy init the TokenStream as it is heavy to instantiate
+			// (attributes,...) if not needed (stored field loading)
+			nts = analysis.NewNumericTokenStreamWithStep(ft.NumericPrecisionStep())
+		}
+		// initialize value in TokenStream
+		switch ft.NumericType() {
+		case FIELD_TYPE_NUMERIC_INT:
+			nts.SetIntValue(f._data.(int32))
+		case FIELD_TYPE_NUMERIC_LONG:
+			nts.SetLongValue(f._data.(int64))
+		case FIELD_TYPE_NUMERIC_FLOAT:
+			nts.SetFloatValue(f._data.(float32))
+		case FIELD_TYPE_NUMERIC_DOUBLE:
+			nts.SetDoubleValue(f._data.(float64))
+		default:
+			panic("Should never get here")
+		}
+		return nts, nil
 	}
 
 	if !f.FieldType().Tokenized() {
@@ -174,6 +236,27 @@ func (ts *StringTokenStream) IncrementToken() (bool, error) {
 	return true, nil
 }
 
+func (ts *StringTokenStream) End() error {
+	err := ts.TokenStreamImpl.End()
+	if err != nil {
+		return err
+	}
+	// set final offset
+	finalOffset := len(ts.value)
+	ts.offsetAttribute.SetOffset(finalOffset, finalOffset)
+	return nil
+}
+
+func (ts *StringTokenStream) Reset() error {
+	ts.used = false
+	return nil
+}
+
+func (ts *StringTokenStream) Close() error {
+	ts.value = ""
+	return nil
+}
+
 /* Specifies whether and how a field should be stored. */
 type Store int
 
@@ -297,3 +380,424 @@ not to change it until you're done with this field.
 // func newStoredField(name string, value []byte) *StoredField {
 // 	return &StoredField{newStringField(name, value, STORED_FIELD_TYPE)}
 // }
+
+/* Create a stored-only field with the given int32 value. */
+func NewStoredFieldInt(name string, value int32) *StoredField {
+	return &StoredField{&Field{_type: STORED_FIELD_TYPE, _name: name, _data: value, _boost: 1}}
+}
+
+/* Create a stored-only field with the given int64 value. */
+func NewStoredFieldLong(name string, value int64) *StoredField {
+	return &StoredField{&Field{_type: STORED_FIELD_TYPE, _name: name, _data: value, _boost: 1}}
+}
+
+/* Create a stored-only field with the given float32 value. */
+func NewStoredFieldFloat(name string, value float32) *StoredField {
+	return &StoredField{&Field{_type: STORED_FIELD_TYPE, _name: name, _data: value, _boost: 1}}
+}
+
+/* Create a stored-only field with the given float64 value. */
+func NewStoredFieldDouble(name string, value float64) *StoredField {
+	return &StoredField{&Field{_type: STORED_FIELD_TYPE, _name: name, _data: value, _boost: 1}}
+}
+
+/* Returns a frozen numeric field type, as used by IntField and friends. */
+func newNumericFieldType(numericType NumericType, precisionStep int, stored bool) *FieldType {
+	ft := newFieldType()
+	ft.indexed = true
+	ft._tokenized = true
+	ft._omitNorms = true
+	ft._indexOptions = model.INDEX_OPT_DOCS_ONLY
+	ft.numericType = numericType
+	ft.numericPrecisionStep = precisionStep
+	ft.stored = stored
+	ft.frozen = true
+	return ft
+}
+
+/* Creates a numeric field, checking that ft matches the value type. */
+func newNumericField(name string, value interface{}, ft *FieldType, numericType NumericType) *Field {
+	assert2(name != "", "name cannot be empty")
+	assert2(ft != nil, "type can not be nil")
+	assert2(ft.numericType == numericType, fmt.Sprintf(
+		"type.numericType() must be %v but got %v", numericType, ft.numericType))
+	return &Field{_type: ft, _name: name, _data: value, _boost: 1}
+}
+
+// document/IntField.java
+
+/*
+Type for an IntField that is not stored: normalization factors,
+frequencies, and positions are omitted.
+*/
+var INT_FIELD_TYPE_NOT_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_INT, util.NUMERIC_PRECISION_STEP_DEFAULT_32, false)
+
+/*
+Type for a stored IntField: normalization factors, frequencies, and
+positions are omitted.
+*/
+var INT_FIELD_TYPE_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_INT, util.NUMERIC_PRECISION_STEP_DEFAULT_32, true)
+
+/*
+Field that indexes int32 values for efficient range filtering and
+sorting. Here's an example usage:
+
+	doc.Add(NewIntField(name, 6, STORE_NO))
+
+For optimal performance, re-use the IntField and Document instance
+for more than one document:
+
+	field := NewIntField(name, 6, STORE_NO)
+	doc := NewDocument()
+	doc.Add(field)
+
+	for ... {
+		field.SetIntValue(value)
+		writer.AddDocument(doc.Fields())
+		...
+	}
+
+See also LongField, FloatField, DoubleField.
+
+To perform range querying or filtering against an IntField, use
+NumericRangeQuery or NumericRangeFilter. To sort according to an
+IntField, use the normal numeric sort types, eg SORT_FIELD_INT.
+IntField values can also be loaded directly from FieldCache.
+
+You may add the same field name as an IntField to the same document
+more than once. Range querying and filtering will be the logical OR
+of all values; so a range query will hit all documents that have at
+least one value in the range. However sort behavior is not defined.
+If you need to sort, you should separately index a single-valued
+IntField.
+
+An IntField will consume somewhat more disk space in the index than
+an ordinary single-valued field. However, for a typical index that
+includes substantial textual content per document, this increase will
+likely be in the noise.
+
+Within Lucene, each numeric value is indexed as a trie structure,
+where each term is logically assigned to larger and larger pre-defined
+brackets (which are simply lower-precision representations of the
+value). The step size between each successive bracket is called the
+precisionStep, measured in bits. Smaller precisionStep values result
+in larger number of brackets, which consumes more disk space in the
+index but may result in faster range search performance. The default
+value, 8, was selected for a reasonable tradeoff of disk space
+consumption versus performance. You can create a custom FieldType
+and invoke the SetNumericPrecisionStep() method if you'd like to
+change the value. Note that you must also specify a congruent value
+when creating NumericRangeQuery or NumericRangeFilter. For low
+cardinality fields larger precision steps are good. If the
+cardinality is < 100, it is fair to use math.MaxInt32, which produces
+one term per value.
+
+For more information on the internals of numeric trie indexing,
+including the precisionStep configuration, see NumericRangeQuery.
+The format of indexed values is described in util.NumericUtils.
+
+If you only need to sort by numeric value, and never run range
+querying/filtering, you can index using a precisionStep of
+math.MaxInt32. This will minimize disk space consumed.
+
+More advanced users can instead use NumericTokenStream directly, when
+indexing numbers. This type is a wrapper around this token stream
+type. It can be used with any FieldType whose NumericType is set.
+*/
+type IntField struct {
+	*Field
+}
+
+/*
+Creates a stored or un-stored IntField with the provided value and
+default precisionStep NUMERIC_PRECISION_STEP_DEFAULT_32 (8).
+*/
+func NewIntField(name string, value int32, stored Store) *IntField {
+	return NewIntFieldWithType(name, value, map[Store]*FieldType{
+		STORE_YES: INT_FIELD_TYPE_STORED,
+		STORE_NO:  INT_FIELD_TYPE_NOT_STORED,
+	}[stored])
+}
+
+/*
+Expert: allows you to customize the FieldType. The type's NumericType
+must be FIELD_TYPE_NUMERIC_INT.
+*/
+func NewIntFieldWithType(name string, value int32, ft *FieldType) *IntField {
+	return &IntField{newNumericField(name, value, ft, FIELD_TYPE_NUMERIC_INT)}
+}
+
+// document/LongField.java
+
+/*
+Type for a LongField that is not stored: normalization factors,
+frequencies, and positions are omitted.
+*/
+var LONG_FIELD_TYPE_NOT_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_LONG, util.NUMERIC_PRECISION_STEP_DEFAULT, false)
+
+/*
+Type for a stored LongField: normalization factors, frequencies, and
+positions are omitted.
+*/
+var LONG_FIELD_TYPE_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_LONG, util.NUMERIC_PRECISION_STEP_DEFAULT, true)
+
+/*
+Field that indexes int64 values for efficient range filtering and
+sorting. Here's an example usage:
+
+	doc.Add(NewLongField(name, 6, STORE_NO))
+
+Any type that can be converted to int64 can also be indexed. For
+example, date/time values represented by a time.Time can be
+translated into an int64 value using its Unix() method.
+
+The default precisionStep is NUMERIC_PRECISION_STEP_DEFAULT (16).
+Everything else said for IntField applies to LongField as well.
+*/
+type LongField struct {
+	*Field
+}
+
+/*
+Creates a stored or un-stored LongField with the provided value and
+default precisionStep NUMERIC_PRECISION_STEP_DEFAULT (16).
+*/
+func NewLongField(name string, value int64, stored Store) *LongField {
+	return NewLongFieldWithType(name, value, map[Store]*FieldType{
+		STORE_YES: LONG_FIELD_TYPE_STORED,
+		STORE_NO:  LONG_FIELD_TYPE_NOT_STORED,
+	}[stored])
+}
+
+/*
+Expert: allows you to customize the FieldType. The type's NumericType
+must be FIELD_TYPE_NUMERIC_LONG.
+*/
+func NewLongFieldWithType(name string, value int64, ft *FieldType) *LongField {
+	return &LongField{newNumericField(name, value, ft, FIELD_TYPE_NUMERIC_LONG)}
+}
+
+// document/FloatField.java
+
+/*
+Type for a FloatField that is not stored: normalization factors,
+frequencies, and positions are omitted.
+*/
+var FLOAT_FIELD_TYPE_NOT_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_FLOAT, util.NUMERIC_PRECISION_STEP_DEFAULT_32, false)
+
+/*
+Type for a stored FloatField: normalization factors, frequencies, and
+positions are omitted.
+*/
+var FLOAT_FIELD_TYPE_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_FLOAT, util.NUMERIC_PRECISION_STEP_DEFAULT_32, true)
+
+/*
+Field that indexes float32 values for efficient range filtering and
+sorting. Here's an example usage:
+
+	doc.Add(NewFloatField(name, 6.0, STORE_NO))
+
+The values are indexed in their sortable int32 representation (see
+util.FloatToSortableInt()). The default precisionStep is
+NUMERIC_PRECISION_STEP_DEFAULT_32 (8). Everything else said for
+IntField applies to FloatField as well.
+*/
+type FloatField struct {
+	*Field
+}
+
+/*
+Creates a stored or un-stored FloatField with the provided value and
+default precisionStep NUMERIC_PRECISION_STEP_DEFAULT_32 (8).
+*/
+func NewFloatField(name string, value float32, stored Store) *FloatField {
+	return NewFloatFieldWithType(name, value, map[Store]*FieldType{
+		STORE_YES: FLOAT_FIELD_TYPE_STORED,
+		STORE_NO:  FLOAT_FIELD_TYPE_NOT_STORED,
+	}[stored])
+}
+
+/*
+Expert: allows you to customize the FieldType. The type's NumericType
+must be FIELD_TYPE_NUMERIC_FLOAT.
+*/
+func NewFloatFieldWithType(name string, value float32, ft *FieldType) *FloatField {
+	return &FloatField{newNumericField(name, value, ft, FIELD_TYPE_NUMERIC_FLOAT)}
+}
+
+// document/DoubleField.java
+
+/*
+Type for a DoubleField that is not stored: normalization factors,
+frequencies, and positions are omitted.
+*/
+var DOUBLE_FIELD_TYPE_NOT_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_DOUBLE, util.NUMERIC_PRECISION_STEP_DEFAULT, false)
+
+/*
+Type for a stored DoubleField: normalization factors, frequencies, and
+positions are omitted.
+*/
+var DOUBLE_FIELD_TYPE_STORED = newNumericFieldType(
+	FIELD_TYPE_NUMERIC_DOUBLE, util.NUMERIC_PRECISION_STEP_DEFAULT, true)
+
+/*
+Field that indexes float64 values for efficient range filtering and
+sorting. Here's an example usage:
+
+	doc.Add(NewDoubleField(name, 6.0, STORE_NO))
+
+The values are indexed in their sortable int64 representation (see
+util.DoubleToSortableLong()). The default precisionStep is
+NUMERIC_PRECISION_STEP_DEFAULT (16). Everything else said for
+IntField applies to DoubleField as well.
+*/
+type DoubleField struct {
+	*Field
+}
+
+/*
+Creates a stored or un-stored DoubleField with the provided value and
+default precisionStep NUMERIC_PRECISION_STEP_DEFAULT (16).
+*/
+func NewDoubleField(name string, value float64, stored Store) *DoubleField {
+	return NewDoubleFieldWithType(name, value, map[Store]*FieldType{
+		STORE_YES: DOUBLE_FIELD_TYPE_STORED,
+		STORE_NO:  DOUBLE_FIELD_TYPE_NOT_STORED,
+	}[stored])
+}
+
+/*
+Expert: allows you to customize the FieldType. The type's NumericType
+must be FIELD_TYPE_NUMERIC_DOUBLE.
+*/
+func NewDoubleFieldWithType(name string, value float64, ft *FieldType) *DoubleField {
+	return &DoubleField{newNumericField(name, value, ft, FIELD_TYPE_NUMERIC_DOUBLE)}
+}
+
+// document/NumericDocValuesField.java
+
+/* Type for numeric DocValues. */
+var NUMERIC_DOC_VALUES_FIELD_TYPE = func() *FieldType {
+	ft := newFieldType()
+	ft._docValueType = model.DOC_VALUES_TYPE_NUMERIC
+	ft.frozen = true
+	return ft
+}()
+
+/*
+Field that stores a per-document int64 value for scoring, sorting or
+value retrieval. If you also need to store the value, you should add
+a separate StoredField instance.
+*/
+type NumericDocValuesField struct {
+	*Field
+}
+
+/* Creates a new DocValues field with the specified 64-bit int64 value. */
+func NewNumericDocValuesField(name string, value int64) *NumericDocValuesField {
+	return &NumericDocValuesField{&Field{
+		_type:  NUMERIC_DOC_VALUES_FIELD_TYPE,
+		_name:  name,
+		_data:  value,
+		_boost: 1,
+	}}
+}
+
+// document/BinaryDocValuesField.java
+
+/* Type for straight bytes DocValues. */
+var BINARY_DOC_VALUES_FIELD_TYPE = func() *FieldType {
+	ft := newFieldType()
+	ft._docValueType = model.DOC_VALUES_TYPE_BINARY
+	ft.frozen = true
+	return ft
+}()
+
+/*
+Field that stores a per-document []byte value. The values are stored
+directly with no sharing, which is a good fit when the fields don't
+share (many) values, such as a title field. If values may be shared
+and sorted it's better to use SortedDocValuesField.
+
+If you also need to store the value, you should add a separate
+StoredField instance.
+*/
+type BinaryDocValuesField struct {
+	*Field
+}
+
+/* Create a new binary DocValues field. */
+func NewBinaryDocValuesField(name string, value []byte) *BinaryDocValuesField {
+	return &BinaryDocValuesField{&Field{
+		_type:  BINARY_DOC_VALUES_FIELD_TYPE,
+		_name:  name,
+		_data:  value,
+		_boost: 1,
+	}}
+}
+
+// document/SortedDocValuesField.java
+
+/* Type for sorted bytes DocValues */
+var SORTED_DOC_VALUES_FIELD_TYPE = func() *FieldType {
+	ft := newFieldType()
+	ft._docValueType = model.DOC_VALUES_TYPE_SORTED
+	ft.frozen = true
+	return ft
+}()
+
+/*
+Field that stores a per-document []byte value, indexed for sorting.
+If you also need to store the value, you should add a separate
+StoredField instance.
+*/
+type SortedDocValuesField struct {
+	*Field
+}
+
+/* Create a new sorted DocValues field. */
+func NewSortedDocValuesField(name string, bytes []byte) *SortedDocValuesField {
+	return &SortedDocValuesField{&Field{
+		_type:  SORTED_DOC_VALUES_FIELD_TYPE,
+		_name:  name,
+		_data:  bytes,
+		_boost: 1,
+	}}
+}
+
+// document/SortedSetDocValuesField.java
+
+/* Type for sorted bytes DocValues */
+var SORTED_SET_DOC_VALUES_FIELD_TYPE = func() *FieldType {
+	ft := newFieldType()
+	ft._docValueType = model.DOC_VALUES_TYPE_SORTED_SET
+	ft.frozen = true
+	return ft
+}()
+
+/*
+Field that stores a set of per-document []byte values, indexed for
+faceting, grouping and joining. Add one instance per value to the
+same document. If you also need to store the value, you should add a
+separate StoredField instance.
+*/
+type SortedSetDocValuesField struct {
+	*Field
+}
+
+/* Create a new sorted DocValues field. */
+func NewSortedSetDocValuesField(name string, bytes []byte) *SortedSetDocValuesField {
+	return &SortedSetDocValuesField{&Field{
+		_type:  SORTED_SET_DOC_VALUES_FIELD_TYPE,
+		_name:  name,
+		_data:  bytes,
+		_boost: 1,
+	}}
+}
@@ -0,0 +1,28 @@
+package index
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// index/BitsSlice.java
+
+/* Exposes a slice of an existing Bits as a new Bits. */
+type bitsSlice struct {
+	parent util.Bits
+	start  int
+	length int
+}
+
+func newBitsSlice(parent util.Bits, slice ReaderSlice) *bitsSlice {
+	assert2(slice.length >= 0, "length=%v", slice.length)
+	return &bitsSlice{parent, slice.start, slice.length}
+}
+
+func (b *bitsSlice) At(doc int) bool {
+	assert2(doc < b.length, "doc %v is out of bounds 0 .. %v", doc, b.length-1)
+	return b.parent.At(doc + b.start)
+}
+
+func (b *bitsSlice) Length() int {
+	return b.length
+}
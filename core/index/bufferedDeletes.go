@@ -1,7 +1,7 @@
 package index
 
 import (
-	// "bytes"
+	"bytes"
 	"fmt"
 	"github.com/balzaczyy/golucene/core/util"
 	"math"
@@ -13,6 +13,9 @@ import (
 /* Go slice consumes two int for an extra doc ID, assuming 50% pre-allocation. */
 const BYTES_PER_DEL_DOCID = 2 * util.NUM_BYTES_INT
 
+/* Go map (amd64) consumes about 40 bytes for an extra entry. */
+const BYTES_PER_DEL_TERM = 40 + 2*util.NUM_BYTES_OBJECT_REF + util.NUM_BYTES_INT
+
 /* Go map (amd64) consumes about 40 bytes for an extra entry. */
 const BYTES_PER_DEL_QUERY = 40 + util.NUM_BYTES_OBJECT_REF + util.NUM_BYTES_INT
 
@@ -20,6 +23,22 @@ const MAX_INT = int(math.MaxInt32)
 
 const VERBOSE = false
 
+/*
+Term is not comparable in Go since it holds a byte slice, so buffered
+delete terms are keyed by value instead.
+*/
+type termKey struct {
+	field, text string
+}
+
+func newTermKey(term *Term) termKey {
+	return termKey{term.Field, string(term.Bytes)}
+}
+
+func (k termKey) term() *Term {
+	return NewTerm(k.field, k.text)
+}
+
 /*
 Holds buffered deletes, by docID, term or query for a single segment.
 This is used to hold buffered pending deletes against the
@@ -33,8 +52,8 @@ DocumentsWriterDeleteQueue
 type BufferedUpdates struct {
 	numTermDeletes int32 // atomic
 
-	terms   map[*Term]int
-	queries map[interface{}]int
+	terms   map[termKey]int
+	queries map[Query]int
 	docIDs  []int
 
 	numericUpdates map[string]map[*Term]*DocValuesUpdate
@@ -48,37 +67,45 @@ type BufferedUpdates struct {
 
 func newBufferedUpdates() *BufferedUpdates {
 	return &BufferedUpdates{
-		terms:          make(map[*Term]int),
-		queries:        make(map[interface{}]int),
+		terms:          make(map[termKey]int),
+		queries:        make(map[Query]int),
 		numericUpdates: make(map[string]map[*Term]*DocValuesUpdate),
 		binaryUpdates:  make(map[string]map[*Term]*DocValuesUpdate),
 	}
 }
 
 func (bd *BufferedUpdates) String() string {
-	panic("not implemented yet")
-	// if VERBOSE {
-	// 	return fmt.Sprintf(
-	// 		"BufferedUpdates[gen=%v, numTerms=%v, terms=%v, queries=%v, docIDs=%v, bytesUsed=%v]",
-	// 		bd.gen, atomic.LoadInt32(&bd.numTermDeletes), bd.terms, bd.queries, bd.docIDs, bd.bytesUsed)
-	// } else {
-	// 	var buf bytes.Buffer
-	// 	fmt.Fprintf(&buf, "BufferedUpdates[gen=%v", bd.gen)
-	// 	if n := atomic.LoadInt32(&bd.numTermDeletes); n != 0 {
-	// 		fmt.Fprintf(&buf, " %v deleted terms (unique count=%v)", n, len(bd.terms))
-	// 	}
-	// 	if len(bd.queries) > 0 {
-	// 		fmt.Fprintf(&buf, " %v deleted queries", len(bd.queries))
-	// 	}
-	// 	if len(bd.docIDs) > 0 {
-	// 		fmt.Fprintf(&buf, " %v deleted docIDs", len(bd.docIDs))
-	// 	}
-	// 	if n := atomic.LoadInt64(&bd.bytesUsed); n != 0 {
-	// 		fmt.Fprintf(&buf, " bytesUsed=%v", n)
-	// 	}
-	// 	buf.WriteRune(']')
-	// 	return buf.String()
-	// }
+	if VERBOSE {
+		return fmt.Sprintf(
+			"BufferedUpdates[gen=%v, numTerms=%v, terms=%v, queries=%v, docIDs=%v, bytesUsed=%v]",
+			bd.gen, atomic.LoadInt32(&bd.numTermDeletes), bd.terms, bd.queries, bd.docIDs, bd.bytesUsed)
+	} else {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "BufferedUpdates[gen=%v", bd.gen)
+		if n := atomic.LoadInt32(&bd.numTermDeletes); n != 0 {
+			fmt.Fprintf(&buf, " %v deleted terms (unique count=%v)", n, len(bd.terms))
+		}
+		if len(bd.queries) > 0 {
+			fmt.Fprintf(&buf, " %v deleted queries", len(bd.queries))
+		}
+		if len(bd.docIDs) > 0 {
+			fmt.Fprintf(&buf, " %v deleted docIDs", len(bd.docIDs))
+		}
+		if n := atomic.LoadInt64(&bd.bytesUsed); n != 0 {
+			fmt.Fprintf(&buf, " bytesUsed=%v", n)
+		}
+		buf.WriteRune(']')
+		return buf.String()
+	}
+}
+
+func (bd *BufferedUpdates) addQuery(query Query, docIDUpto int) {
+	_, ok := bd.queries[query]
+	bd.queries[query] = docIDUpto
+	// increment bytes used only if the query wasn't added so far.
+	if !ok {
+		atomic.AddInt64(&bd.bytesUsed, BYTES_PER_DEL_QUERY)
+	}
 }
 
 func (bd *BufferedUpdates) addDocID(docID int) {
@@ -86,9 +113,33 @@ func (bd *BufferedUpdates) addDocID(docID int) {
 	atomic.AddInt64(&bd.bytesUsed, BYTES_PER_DEL_DOCID)
 }
 
+func (bd *BufferedUpdates) addTerm(term *Term, docIDUpto int) {
+	key := newTermKey(term)
+	current, ok := bd.terms[key]
+	if ok && docIDUpto < current {
+		// Only record the new number if it's greater than the current
+		// one. This is important because if multiple threads are
+		// replacing the same doc at nearly the same time, it's possible
+		// that one thread that got a higher docID is scheduled before
+		// the other threads. If we blindly replace than we can
+		// incorrectly get both docs indexed.
+		return
+	}
+
+	bd.terms[key] = docIDUpto
+	// note that if current != nil then it means there's already a
+	// buffered delete on that term, therefore we seem to over-count.
+	// this over-counting is done to respect
+	// IndexWriterConfig.MaxBufferedDeleteTerms.
+	atomic.AddInt32(&bd.numTermDeletes, 1)
+	if !ok {
+		atomic.AddInt64(&bd.bytesUsed, int64(BYTES_PER_DEL_TERM+len(term.Bytes)+len(term.Field)))
+	}
+}
+
 func (bd *BufferedUpdates) clear() {
-	bd.terms = make(map[*Term]int)
-	bd.queries = make(map[interface{}]int)
+	bd.terms = make(map[termKey]int)
+	bd.queries = make(map[Query]int)
 	bd.docIDs = nil
 	atomic.StoreInt32(&bd.numTermDeletes, 0)
 	atomic.StoreInt64(&bd.bytesUsed, 0)
@@ -135,7 +186,7 @@ func freezeBufferedUpdates(deletes *BufferedUpdates, isPrivate bool) *FrozenBuff
 		"segment private package should only have del queries")
 	var termsArray []*Term
 	for k, _ := range deletes.terms {
-		termsArray = append(termsArray, k)
+		termsArray = append(termsArray, k.term())
 	}
 	util.TimSort(TermSorter(termsArray))
 	builder := newPrefixCodedTermsBuilder()
@@ -211,11 +262,31 @@ func assert2(ok bool, msg string, args ...interface{}) {
 }
 
 func (bd *FrozenBufferedUpdates) queries() []*QueryAndLimit {
-	panic("not implemented yet")
+	ans := make([]*QueryAndLimit, len(bd._queries))
+	for i, query := range bd._queries {
+		ans[i] = &QueryAndLimit{query, bd.queryLimits[i]}
+	}
+	return ans
 }
 
 func (bd *FrozenBufferedUpdates) String() string {
-	panic("not implemented yet")
+	var buf bytes.Buffer
+	if bd.numTermDeletes != 0 {
+		fmt.Fprintf(&buf, " %v deleted terms (unique count=%v)", bd.numTermDeletes, bd.termCount)
+	}
+	if len(bd._queries) != 0 {
+		fmt.Fprintf(&buf, " %v deleted queries", len(bd._queries))
+	}
+	if bd.bytesUsed != 0 {
+		fmt.Fprintf(&buf, " bytesUsed=%v", bd.bytesUsed)
+	}
+	if len(bd.numericDVUpdates) > 0 {
+		fmt.Fprintf(&buf, " numeric DV updates=%v", len(bd.numericDVUpdates))
+	}
+	if len(bd.binaryDVUpdates) > 0 {
+		fmt.Fprintf(&buf, " binary DV updates=%v", len(bd.binaryDVUpdates))
+	}
+	return buf.String()
 }
 
 func (d *FrozenBufferedUpdates) any() bool {
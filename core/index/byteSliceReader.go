@@ -97,5 +97,15 @@ func (r *ByteSliceReader) nextSlice() {
 }
 
 func (r *ByteSliceReader) ReadBytes(buf []byte) error {
-	panic("not implemented yet")
+	for len(buf) > 0 {
+		numLeft := r.limit - r.upto
+		if numLeft >= len(buf) {
+			r.upto += copy(buf, r.buffer[r.upto:r.upto+len(buf)])
+			break
+		}
+		// read to the end of this slice, and continue with the next one
+		buf = buf[copy(buf, r.buffer[r.upto:r.limit]):]
+		r.nextSlice()
+	}
+	return nil
 }
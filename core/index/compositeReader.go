@@ -126,15 +126,15 @@ type CompositeReaderContextBuilder struct {
 	leafDocBase int
 }
 
-func newCompositeReaderContextBuilder(r CompositeReader) CompositeReaderContextBuilder {
-	return CompositeReaderContextBuilder{reader: r, leaves: list.New()}
+func newCompositeReaderContextBuilder(r CompositeReader) *CompositeReaderContextBuilder {
+	return &CompositeReaderContextBuilder{reader: r, leaves: list.New()}
 }
 
-func (b CompositeReaderContextBuilder) build() *CompositeReaderContext {
+func (b *CompositeReaderContextBuilder) build() *CompositeReaderContext {
 	return b.build4(nil, b.reader, 0, 0).(*CompositeReaderContext)
 }
 
-func (b CompositeReaderContextBuilder) build4(parent *CompositeReaderContext,
+func (b *CompositeReaderContextBuilder) build4(parent *CompositeReaderContext,
 	reader IndexReader, ord, docBase int) IndexReaderContext {
 	// log.Printf("Building context from %v(parent: %v, %v-%v)", reader, parent, ord, docBase)
 	if ar, ok := reader.(AtomicReader); ok {
@@ -158,7 +158,7 @@ func (b CompositeReaderContextBuilder) build4(parent *CompositeReaderContext,
 	newDocBase := 0
 	for i, r := range sequentialSubReaders {
 		children[i] = b.build4(newParent, r, i, newDocBase)
-		newDocBase = r.MaxDoc()
+		newDocBase += r.MaxDoc()
 	}
 	// assert newDocBase == cr.maxDoc()
 	return newParent
@@ -201,6 +201,11 @@ func (conf *IndexWriterConfig) SetMaxBufferedDocs(maxBufferedDocs int) *IndexWri
 	return conf
 }
 
+func (conf *IndexWriterConfig) SetMaxBufferedDeleteTerms(maxBufferedDeleteTerms int) *IndexWriterConfig {
+	conf.LiveIndexWriterConfigImpl.SetMaxBufferedDeleteTerms(maxBufferedDeleteTerms)
+	return conf
+}
+
 func (conf *IndexWriterConfig) SetMergedSegmentWarmer(mergeSegmentWarmer IndexReaderWarmer) *IndexWriterConfig {
 	conf.LiveIndexWriterConfigImpl.SetMergedSegmentWarmer(mergeSegmentWarmer)
 	return conf
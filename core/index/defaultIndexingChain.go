@@ -110,11 +110,13 @@ func (c *DefaultIndexingChain) writeDocValues(state *SegmentWriteState) (err err
 	docCount := state.SegmentInfo.DocCount()
 	var dvConsumer DocValuesConsumer
 	var success = false
-	if success {
-		err = util.Close(dvConsumer)
-	} else {
-		util.CloseWhileSuppressingError(dvConsumer)
-	}
+	defer func() {
+		if success {
+			err = util.Close(dvConsumer)
+		} else {
+			util.CloseWhileSuppressingError(dvConsumer)
+		}
+	}()
 
 	for _, perField := range c.fieldHash {
 		for perField != nil {
@@ -354,14 +356,12 @@ func (c *DefaultIndexingChain) processField(field IndexableField,
 			fieldCount++
 			fp.fieldGen = fieldGen
 		}
-	} else {
-		panic("not implemented yet")
 	}
 
 	// Add stored fields:
 	if fieldType.Stored() {
 		if fp == nil {
-			panic("not implemented yet")
+			fp = c.getOrAddField(fieldName, fieldType, false)
 		}
 		if fieldType.Stored() {
 			if err := func() error {
@@ -385,14 +385,66 @@ func (c *DefaultIndexingChain) processField(field IndexableField,
 
 	if dvType := fieldType.DocValueType(); int(dvType) != 0 {
 		if fp == nil {
-			panic("not implemented yet")
+			fp = c.getOrAddField(fieldName, fieldType, false)
+		}
+		if err := c.indexDocValue(fp, dvType, field); err != nil {
+			return 0, err
 		}
-		panic("not implemented yet")
 	}
 
 	return fieldCount, nil
 }
 
+/* Called from processDocument to index one field's doc values */
+func (c *DefaultIndexingChain) indexDocValue(fp *PerField,
+	dvType DocValuesType, field IndexableField) error {
+
+	hasDocValues := fp.fieldInfo.HasDocValues()
+
+	// This will panic if the caller tried to change the DV type for
+	// the field:
+	fp.fieldInfo.SetDocValueType(dvType)
+	if !hasDocValues {
+		c.fieldInfos.GlobalFieldNumbers().SetDocValuesType(
+			int(fp.fieldInfo.Number), fp.fieldInfo.Name, dvType)
+	}
+
+	docId := c.docState.docID
+
+	switch dvType {
+	case DOC_VALUES_TYPE_NUMERIC:
+		if fp.docValuesWriter == nil {
+			fp.docValuesWriter = newNumericDocValuesWriter(fp.fieldInfo, c.docWriter._bytesUsed, true)
+		}
+		v, ok := field.NumericValue().(int64)
+		assert2(ok, "field '%v' must have a 64-bit integer value for NUMERIC doc values",
+			field.Name())
+		fp.docValuesWriter.(*NumericDocValuesWriter).addValue(docId, v)
+
+	case DOC_VALUES_TYPE_BINARY:
+		if fp.docValuesWriter == nil {
+			fp.docValuesWriter = newBinaryDocValuesWriter(fp.fieldInfo, c.docWriter._bytesUsed)
+		}
+		fp.docValuesWriter.(*BinaryDocValuesWriter).addValue(docId, field.BinaryValue())
+
+	case DOC_VALUES_TYPE_SORTED:
+		if fp.docValuesWriter == nil {
+			fp.docValuesWriter = newSortedDocValuesWriter(fp.fieldInfo, c.docWriter._bytesUsed)
+		}
+		return fp.docValuesWriter.(*SortedDocValuesWriter).addValue(docId, field.BinaryValue())
+
+	case DOC_VALUES_TYPE_SORTED_SET:
+		if fp.docValuesWriter == nil {
+			fp.docValuesWriter = newSortedSetDocValuesWriter(fp.fieldInfo, c.docWriter._bytesUsed)
+		}
+		return fp.docValuesWriter.(*SortedSetDocValuesWriter).addValue(docId, field.BinaryValue())
+
+	default:
+		panic(fmt.Sprintf("unrecognized DocValues.Type: %v", dvType))
+	}
+	return nil
+}
+
 /*
 Returns a previously created PerField, or nil if this field name
 wasn't seen yet.
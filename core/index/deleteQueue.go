@@ -48,6 +48,7 @@ neither to the global deletes.
 */
 type DocumentsWriterDeleteQueue struct {
 	tail                  *Node // volatile
+	tailLock              sync.Mutex
 	globalSlice           *DeleteSlice
 	globalBufferedUpdates *BufferedUpdates
 	globalBufferLock      sync.Locker
@@ -76,9 +77,63 @@ func newDocumentsWriterDeleteQueueWith(globalBufferedUpdates *BufferedUpdates, g
 	}
 }
 
-/* Invariant for document update */
-func (q *DocumentsWriterDeleteQueue) add(term *Term, slice *DeleteSlice) {
-	panic("not implemented yet")
+func (dq *DocumentsWriterDeleteQueue) addDeleteQueries(queries ...Query) {
+	dq.addNode(newNode(queries))
+	dq.tryApplyGlobalSlice()
+}
+
+func (dq *DocumentsWriterDeleteQueue) addDeleteTerms(terms ...*Term) {
+	dq.addNode(newNode(terms))
+	dq.tryApplyGlobalSlice()
+}
+
+/*
+Invariant for document update
+*/
+func (dq *DocumentsWriterDeleteQueue) add(term *Term, slice *DeleteSlice) {
+	termNode := newNode(term)
+	dq.addNode(termNode)
+	// this is an update request where the term is the updated documents
+	// delTerm. in that case we need to guarantee that this insert is
+	// atomic with regards to the given delete slice. This means if two
+	// threads try to update the same document with in turn the same
+	// delTerm one of them must win. By taking the node we have created
+	// for our del term as the new tail it is guaranteed that if another
+	// thread adds the same right after us we will apply this delete
+	// next time we update our slice and one of the two competing
+	// updates wins!
+	slice.tail = termNode
+	assert2(slice.head != slice.tail, "slice head and tail must differ after add")
+	dq.tryApplyGlobalSlice() // TODO doing this each time is not necessary maybe
+	// we can do it just every n times or so?
+}
+
+func (dq *DocumentsWriterDeleteQueue) addNode(item *Node) {
+	// Lucene Java appends lock-free by CAS on the tail; here the tail is
+	// simply guarded by a mutex.
+	dq.tailLock.Lock()
+	defer dq.tailLock.Unlock()
+	dq.tail.next = item
+	dq.tail = item
+}
+
+func (dq *DocumentsWriterDeleteQueue) currentTail() *Node {
+	dq.tailLock.Lock()
+	defer dq.tailLock.Unlock()
+	return dq.tail
+}
+
+func (dq *DocumentsWriterDeleteQueue) tryApplyGlobalSlice() {
+	// The global buffer must be locked but we don't need to update
+	// them if there is an update going on right now. It is sufficient
+	// to apply the deletes that have been added after the current in
+	// progress global slice is applied. Go has no tryLock, so we simply
+	// wait for the lock here.
+	dq.globalBufferLock.Lock()
+	defer dq.globalBufferLock.Unlock()
+	if dq.updateSlice(dq.globalSlice) {
+		dq.globalSlice.apply(dq.globalBufferedUpdates, MAX_INT)
+	}
 }
 
 func (dq *DocumentsWriterDeleteQueue) freezeGlobalBuffer(callerSlice *DeleteSlice) *FrozenBufferedUpdates {
@@ -88,7 +143,7 @@ func (dq *DocumentsWriterDeleteQueue) freezeGlobalBuffer(callerSlice *DeleteSlic
 	// Here we freeze the global buffer so we need to lock it, apply
 	// all deletes in the queue and reset the global slice to let the
 	// GC prune the queue.
-	currentTail := dq.tail
+	currentTail := dq.currentTail()
 	// take the current tail and make this local. Any changes after
 	// this call are applied later and not relevant here
 	if callerSlice != nil {
@@ -113,17 +168,17 @@ func (dq *DocumentsWriterDeleteQueue) anyChanges() bool {
 	// and if globalBufferedUpdates has changes
 	return dq.globalBufferedUpdates.any() ||
 		!dq.globalSlice.isEmpty() ||
-		dq.globalSlice.tail != dq.tail ||
-		dq.tail.next != nil
+		dq.globalSlice.tail != dq.currentTail() ||
+		dq.currentTail().next != nil
 }
 
 func (dq *DocumentsWriterDeleteQueue) newSlice() *DeleteSlice {
-	return newDeleteSlice(dq.tail)
+	return newDeleteSlice(dq.currentTail())
 }
 
 func (q *DocumentsWriterDeleteQueue) updateSlice(slice *DeleteSlice) bool {
-	if slice.tail != q.tail { // if we are the same just
-		slice.tail = q.tail
+	if tail := q.currentTail(); slice.tail != tail { // if we are the same just
+		slice.tail = tail
 		return true
 	}
 	return false
@@ -133,11 +188,15 @@ func (dq *DocumentsWriterDeleteQueue) clear() {
 	dq.globalBufferLock.Lock()
 	defer dq.globalBufferLock.Unlock()
 
-	currentTail := dq.tail
+	currentTail := dq.currentTail()
 	dq.globalSlice.head, dq.globalSlice.tail = currentTail, currentTail
 	dq.globalBufferedUpdates.clear()
 }
 
+func (dq *DocumentsWriterDeleteQueue) numGlobalTermDeletes() int {
+	return int(atomic.LoadInt32(&dq.globalBufferedUpdates.numTermDeletes))
+}
+
 func (q *DocumentsWriterDeleteQueue) RamBytesUsed() int64 {
 	return atomic.LoadInt64(&q.globalBufferedUpdates.bytesUsed)
 }
@@ -203,6 +262,19 @@ func newNode(item interface{}) *Node {
 	return &Node{item: item}
 }
 
-func (node *Node) apply(BufferedUpdates *BufferedUpdates, docIDUpto int) {
-	panic("sentinel item must never be applied")
+func (node *Node) apply(bufferedUpdates *BufferedUpdates, docIDUpto int) {
+	switch item := node.item.(type) {
+	case *Term:
+		bufferedUpdates.addTerm(item, docIDUpto)
+	case []*Term:
+		for _, term := range item {
+			bufferedUpdates.addTerm(term, docIDUpto)
+		}
+	case []Query:
+		for _, query := range item {
+			bufferedUpdates.addQuery(query, docIDUpto)
+		}
+	default:
+		panic("sentinel item must never be applied")
+	}
 }
@@ -1,12 +1,14 @@
 package index
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
-	"log"
 	"math"
 	"sort"
 	"sync"
@@ -33,15 +35,31 @@ func (a SegInfoByDelGen) Len() int           { return len(a) }
 func (a SegInfoByDelGen) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a SegInfoByDelGen) Less(i, j int) bool { return a[i].BufferedUpdatesGen < a[j].BufferedUpdatesGen }
 
-type Query interface{}
+/*
+A delete-by-query operation. Since index cannot import search, a
+query here only has to find its matches within a single segment,
+which is all that applying the delete needs. A search.Query is passed
+wrapped in a search.QueryWrapperFilter, which implements it.
+*/
+type Query interface {
+	/*
+		Returns an iterator over the documents of the given leaf that
+		match the query and are accepted by acceptDocs, or nil if none
+		matches.
+	*/
+	MatchingDocs(context *AtomicReaderContext, acceptDocs util.Bits) (DocIdSetIterator, error)
+}
 
 type QueryAndLimit struct {
+	query Query
+	limit int
 }
 
 // index/CoalescedUpdates.java
 
 type CoalescedUpdates struct {
 	_queries         map[Query]int
+	iterables        []*PrefixCodedTerms
 	numericDVUpdates []*DocValuesUpdate
 	binaryDVUpdates  []*DocValuesUpdate
 }
@@ -53,19 +71,45 @@ func newCoalescedUpdates() *CoalescedUpdates {
 }
 
 func (cd *CoalescedUpdates) String() string {
-	panic("not implemented yet")
+	// note: we could add/collect more debugging information
+	return fmt.Sprintf("CoalescedUpdates(termSets=%v,queries=%v,numericDVUpdates=%v,binaryDVUpdates=%v)",
+		len(cd.iterables), len(cd._queries), len(cd.numericDVUpdates), len(cd.binaryDVUpdates))
 }
 
 func (cd *CoalescedUpdates) update(in *FrozenBufferedUpdates) {
-	panic("not implemented yet")
+	cd.iterables = append(cd.iterables, in.terms)
+
+	for _, query := range in._queries {
+		cd._queries[query] = MAX_INT
+	}
+
+	// DocValues updates are not supported yet; see DocValuesUpdate
+	assert(len(in.numericDVUpdates) == 0 && len(in.binaryDVUpdates) == 0)
 }
 
+/* Returns the coalesced delete terms, sorted and without duplicates. */
 func (cd *CoalescedUpdates) terms() []*Term {
-	panic("not implemented yet")
+	var terms []*Term
+	for _, iterable := range cd.iterables {
+		terms = append(terms, iterable.terms()...)
+	}
+	util.TimSort(TermSorter(terms))
+	var ans []*Term
+	for i, term := range terms {
+		if i == 0 || term.Field != terms[i-1].Field ||
+			!bytes.Equal(term.Bytes, terms[i-1].Bytes) {
+			ans = append(ans, term)
+		}
+	}
+	return ans
 }
 
 func (cd *CoalescedUpdates) queries() []*QueryAndLimit {
-	panic("not implemented yet")
+	var ans []*QueryAndLimit
+	for query, limit := range cd._queries {
+		ans = append(ans, &QueryAndLimit{query, limit})
+	}
+	return ans
 }
 
 /*
@@ -109,7 +153,31 @@ func newBufferedUpdatesStream(infoStream util.InfoStream) *BufferedUpdatesStream
 
 /* Appends a new packet of buffered deletes to the stream, setting its generation: */
 func (s *BufferedUpdatesStream) push(packet *FrozenBufferedUpdates) int64 {
-	panic("not implemented yet")
+	s.Lock() // synchronized
+	defer s.Unlock()
+
+	// The insert operation must be atomic. If we let threads increment
+	// the gen and push the packet afterwards we risk that packets are
+	// out of order. With DWPT this is possible if two or more flushes
+	// are racing for pushing updates. If the pushed packets get our of
+	// order would loose documents since deletes are applied to the
+	// wrong segments.
+	packet.gen = s.nextGen
+	s.nextGen++
+	assert(packet.any())
+	s.assertDeleteStats()
+	assert(packet.gen < s.nextGen)
+	assert2(len(s.updates) == 0 || s.updates[len(s.updates)-1].gen < packet.gen,
+		"Delete packets must be in order")
+	s.updates = append(s.updates, packet)
+	atomic.AddInt32(&s.numTerms, int32(packet.numTermDeletes))
+	atomic.AddInt64(&s.bytesUsed, int64(packet.bytesUsed))
+	if s.infoStream.IsEnabled("BD") {
+		s.infoStream.Message("BD", "push deletes %v delGen=%v packetCount=%v totBytesUsed=%v",
+			packet, packet.gen, len(s.updates), atomic.LoadInt64(&s.bytesUsed))
+	}
+	s.assertDeleteStats()
+	return packet.gen
 }
 
 func (ds *BufferedUpdatesStream) clear() {
@@ -173,7 +241,7 @@ func (ds *BufferedUpdatesStream) applyDeletesAndUpdates(readerPool *ReaderPool,
 	var allDeleted []*SegmentCommitInfo
 
 	for infosIDX >= 0 {
-		log.Printf("BD: cycle delIDX=%v infoIDX=%v", delIDX, infosIDX)
+		// log.Printf("BD: cycle delIDX=%v infoIDX=%v", delIDX, infosIDX)
 
 		var packet *FrozenBufferedUpdates
 		if delIDX >= 0 {
@@ -183,7 +251,7 @@ func (ds *BufferedUpdatesStream) applyDeletesAndUpdates(readerPool *ReaderPool,
 		segGen := info.BufferedUpdatesGen
 
 		if packet != nil && segGen < packet.gen {
-			log.Println("  coalesce")
+			// log.Println("  coalesce")
 			if coalescedUpdates == nil {
 				coalescedUpdates = newCoalescedUpdates()
 			}
@@ -203,7 +271,7 @@ func (ds *BufferedUpdatesStream) applyDeletesAndUpdates(readerPool *ReaderPool,
 			assertn(packet.isSegmentPrivate,
 				"Packet and Segments deletegen can only match on a segment private del packet gen=%v",
 				segGen)
-			log.Println("  eq")
+			// log.Println("  eq")
 
 			// Lockorder: IW -> BD -> RP
 			assert(readerPool.infoIsLive(info))
@@ -219,7 +287,7 @@ func (ds *BufferedUpdatesStream) applyDeletesAndUpdates(readerPool *ReaderPool,
 				}()
 				dvUpdates := newDocValuesFieldUpdatesContainer()
 				if coalescedUpdates != nil {
-					fmt.Println("    del coalesced")
+					// fmt.Println("    del coalesced")
 					var delta int64
 					delta, err = ds._applyTermDeletes(coalescedUpdates.terms(), rld, reader)
 					if err == nil {
@@ -237,7 +305,7 @@ func (ds *BufferedUpdatesStream) applyDeletesAndUpdates(readerPool *ReaderPool,
 						return
 					}
 				}
-				fmt.Println("    del exact")
+				// fmt.Println("    del exact")
 				// Don't delete by Term here; DWPT already did that on flush:
 				var delta int64
 				delta, err = applyQueryDeletes(packet.queries(), rld, reader)
@@ -289,7 +357,7 @@ func (ds *BufferedUpdatesStream) applyDeletesAndUpdates(readerPool *ReaderPool,
 			info.SetBufferedUpdatesGen(gen)
 
 		} else {
-			log.Println("  gt")
+			// log.Println("  gt")
 
 			if coalescedUpdates != nil {
 				// Lock order: IW -> BD -> RP
@@ -427,20 +495,124 @@ func (ds *BufferedUpdatesStream) pruneUpdates(count int) {
 /* Delete by term */
 func (ds *BufferedUpdatesStream) _applyTermDeletes(terms []*Term,
 	rld *ReadersAndUpdates, reader *SegmentReader) (int64, error) {
-	panic("not implemented yet")
+
+	var delCount int64
+	fields := reader.Fields()
+	if fields == nil {
+		// This reader has no postings
+		return 0, nil
+	}
+
+	var termsEnum TermsEnum
+	var currentField string
+	var fieldInited bool
+	var docs DocsEnum
+
+	var any = false
+
+	for _, term := range terms {
+		// Since we visit terms sorted, we gain performance by re-using
+		// the same TermsEnum and seeking only forwards
+		if !fieldInited || term.Field != currentField {
+			assert(!fieldInited || currentField < term.Field)
+			currentField, fieldInited = term.Field, true
+			if terms := fields.Terms(currentField); terms != nil {
+				termsEnum = terms.Iterator(termsEnum)
+			} else {
+				termsEnum = nil
+			}
+		}
+
+		if termsEnum == nil {
+			continue
+		}
+
+		ok, err := termsEnum.SeekExact(term.Bytes)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		// we don't need term frequencies for this
+		if docs, err = termsEnum.Docs(rld.liveDocs(), docs); err != nil {
+			return 0, err
+		}
+		if docs == nil {
+			continue
+		}
+		for {
+			docID, err := docs.NextDoc()
+			if err != nil {
+				return 0, err
+			}
+			if docID == NO_MORE_DOCS {
+				break
+			}
+			if !any {
+				if err = rld.initWritableLiveDocs(); err != nil {
+					return 0, err
+				}
+				any = true
+			}
+			// NOTE: there is no limit check on the docID when deleting by
+			// Term (unlike by Query) because on flush we apply all Term
+			// deletes to each segment. So all Term deleting here is
+			// against prior segments:
+			if rld.delete(docID) {
+				delCount++
+			}
+		}
+	}
+	return delCount, nil
 }
 
 /* DocValues updates */
 func (ds *BufferedUpdatesStream) applyDocValuesUpdates(updates []*DocValuesUpdate,
 	rld *ReadersAndUpdates, reader *SegmentReader,
 	dvUpdatesCntainer *DocValuesFieldUpdatesContainer) error {
+
+	if len(updates) == 0 {
+		return nil
+	}
 	panic("not implemented yet")
 }
 
 /* Delete by query */
 func applyQueryDeletes(queries []*QueryAndLimit,
 	rld *ReadersAndUpdates, reader *SegmentReader) (int64, error) {
-	panic("not implemented yet")
+
+	var delCount int64
+	readerContext := reader.Context().(*AtomicReaderContext)
+	var any = false
+	for _, ent := range queries {
+		it, err := ent.query.MatchingDocs(readerContext, reader.LiveDocs())
+		if err != nil {
+			return 0, err
+		}
+		if it == nil {
+			continue
+		}
+		for {
+			doc, err := it.NextDoc()
+			if err != nil {
+				return 0, err
+			}
+			if doc >= ent.limit {
+				break
+			}
+			if !any {
+				if err = rld.initWritableLiveDocs(); err != nil {
+					return 0, err
+				}
+				any = true
+			}
+			if rld.delete(doc) {
+				delCount++
+			}
+		}
+	}
+	return delCount, nil
 }
 
 func (ds *BufferedUpdatesStream) assertDeleteStats() {
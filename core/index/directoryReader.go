@@ -14,9 +14,9 @@ const DEFAULT_TERMS_INDEX_DIVISOR = 1
 
 type DirectoryReader interface {
 	IndexReader
-	// doOpenIfChanged() error
+	doOpenIfChanged() (DirectoryReader, error)
 	// doOpenIfChanged(c IndexCommit) error
-	// doOpenIfChanged(w IndexWriter, c IndexCommit) error
+	doOpenIfChangedFromWriter(w *IndexWriter, applyAllDeletes bool) (DirectoryReader, error)
 	Version() int64
 	IsCurrent() bool
 }
@@ -42,6 +42,86 @@ func OpenDirectoryReader(directory store.Directory) (r DirectoryReader, err erro
 	return openStandardDirectoryReader(directory, nil, DEFAULT_TERMS_INDEX_DIVISOR)
 }
 
+/*
+Open a near real time DirectoryReader from the IndexWriter.
+
+applyAllDeletes: if true, all buffered deletes will be applied (made
+visible) in the returned reader. If false, the deletes are not
+applied but remain buffered (in IndexWriter) so that they will be
+applied in the future. Applying deletes can be costly, so if your app
+can tolerate deleted documents being returned you might gain some
+performance by passing false.
+*/
+func OpenDirectoryReaderFromWriter(w *IndexWriter, applyAllDeletes bool) (DirectoryReader, error) {
+	return w.getReader(applyAllDeletes)
+}
+
+/*
+If the index has changed since the provided reader was opened, open
+and return a new reader; else, return nil. The new reader, if not
+nil, will be the same type of reader as the previous one, ie an NRT
+reader will open a new NRT reader, a MultiReader will open a new
+MultiReader, etc.
+
+This method is typically far less costly than opening a fully new
+DirectoryReader as it shares resources (for example sub-readers) with
+the provided DirectoryReader, when possible.
+
+The provided reader is not closed (you are responsible for doing so);
+if a new reader is returned you also must eventually close it. Be
+sure to never close a reader while other goroutines are still using
+it.
+*/
+func OpenIfChanged(oldReader DirectoryReader) (DirectoryReader, error) {
+	newReader, err := oldReader.doOpenIfChanged()
+	if err != nil {
+		return nil, err
+	}
+	assert(newReader != oldReader)
+	return newReader, nil
+}
+
+/*
+Expert: if there changes (committed or not) in the IndexWriter versus
+what the provided reader is searching, then open and return a new
+IndexReader searching both committed and uncommitted changes from
+the writer; else, return nil (though, the current implementation
+never returns nil).
+
+This provides "near real-time" searching, in that changes made
+during an IndexWriter session can be quickly made available for
+searching without closing the writer nor calling Commit().
+
+It's near real-time because there is no hard guarantee on how
+quickly you can get a new reader after making changes with
+IndexWriter. You'll have to experiment in your situation to determine
+if it's fast enough.
+
+The very first time this method is called, this writer instance will
+make every effort to pool the readers that it opens for doing merges,
+applying deletes, etc. This means additional resources (RAM, file
+descriptors, CPU time) will be consumed.
+
+For lower latency on reopening a reader, you should call
+IndexWriterConfig.SetMergedSegmentWarmer() to pre-warm a newly
+merged segment before it's committed to the index. This is important
+for minimizing index-to-search delay after a large merge.
+
+If an addIndexes* call is running in another goroutine, then this
+reader will only search those segments from the foreign index that
+have been successfully copied over, so far.
+*/
+func OpenIfChangedFromWriter(oldReader DirectoryReader, w *IndexWriter,
+	applyAllDeletes bool) (DirectoryReader, error) {
+
+	newReader, err := oldReader.doOpenIfChangedFromWriter(w, applyAllDeletes)
+	if err != nil {
+		return nil, err
+	}
+	assert(newReader != oldReader)
+	return newReader, nil
+}
+
 /*
 Returns true if an index likely exists at the specified directory. Note that
 if a corrupt index exists, or if an index in the process of committing
@@ -88,15 +168,23 @@ func IsIndexFileExists(files []string) bool {
 
 type StandardDirectoryReader struct {
 	*DirectoryReaderImpl
-	writer       *IndexWriter // NRT
-	segmentInfos *SegmentInfos
+	writer                *IndexWriter // NRT
+	segmentInfos          *SegmentInfos
+	termInfosIndexDivisor int
+	applyAllDeletes       bool
 }
 
-// TODO support IndexWriter
+/* called only from static open() methods */
 func newStandardDirectoryReader(directory store.Directory, readers []AtomicReader,
-	sis *SegmentInfos, termInfosIndexDivisor int, applyAllDeletes bool) *StandardDirectoryReader {
+	writer *IndexWriter, sis *SegmentInfos, termInfosIndexDivisor int,
+	applyAllDeletes bool) *StandardDirectoryReader {
 	// log.Printf("Initializing StandardDirectoryReader with %v sub readers...", len(readers))
-	ans := &StandardDirectoryReader{segmentInfos: sis}
+	ans := &StandardDirectoryReader{
+		writer:                writer,
+		segmentInfos:          sis,
+		termInfosIndexDivisor: termInfosIndexDivisor,
+		applyAllDeletes:       applyAllDeletes,
+	}
 	ans.DirectoryReaderImpl = newDirectoryReader(ans, directory, readers)
 	return ans
 }
@@ -125,7 +213,7 @@ func openStandardDirectoryReader(directory store.Directory,
 			readers[i] = sr
 		}
 		// log.Printf("Obtained %v SegmentReaders.", len(readers))
-		return newStandardDirectoryReader(directory, readers, sis, termInfosIndexDivisor, false), nil
+		return newStandardDirectoryReader(directory, readers, nil, sis, termInfosIndexDivisor, false), nil
 	}).run(commit)
 	if err != nil {
 		return nil, err
@@ -133,6 +221,134 @@ func openStandardDirectoryReader(directory store.Directory,
 	return obj.(*StandardDirectoryReader), err
 }
 
+/* Used by near real-time search */
+func openStandardDirectoryReaderFromWriter(writer *IndexWriter,
+	infos *SegmentInfos, applyAllDeletes bool) (r DirectoryReader, err error) {
+
+	// IndexWriter synchronizes externally before calling us, which
+	// ensures infos will not change; so there's no need to process
+	// segments in reverse order
+	dir := writer.Directory()
+	segmentInfos := infos.Clone()
+	var readers []AtomicReader
+	var success = false
+	defer func() {
+		if !success {
+			for _, r := range readers {
+				r.decRef() // keep going - we want to clean up as much as possible
+			}
+		}
+	}()
+
+	infosUpto := 0
+	for _, info := range infos.Segments {
+		assert(info.Info.Dir == dir)
+		rld := writer.readerPool.get(info, true)
+		if err = func() (err error) {
+			defer func() {
+				err = mergeError(err, writer.readerPool.release(rld))
+			}()
+			reader, err := rld.readOnlyClone(store.IO_CONTEXT_READ)
+			if err != nil {
+				return err
+			}
+			if reader.NumDocs() > 0 || writer.keepFullyDeletedSegments {
+				// Steal the ref:
+				readers = append(readers, reader)
+				infosUpto++
+			} else {
+				segmentInfos.Segments = append(segmentInfos.Segments[:infosUpto],
+					segmentInfos.Segments[infosUpto+1:]...)
+				return reader.decRef()
+			}
+			return nil
+		}(); err != nil {
+			return nil, err
+		}
+	}
+
+	// IndexWriter is locked here; take the deleter ref directly
+	// rather than through incRefDeleter():
+	writer.ensureOpen()
+	writer.deleter.incRef(segmentInfos, false)
+
+	r = newStandardDirectoryReader(dir, readers, writer, segmentInfos,
+		writer.config.ReaderTermsIndexDivisor(), applyAllDeletes)
+	success = true
+	return r, nil
+}
+
+/*
+This constructor is only used for doOpenIfChanged(SegmentInfos),
+as well as NRT replication.
+*/
+func openStandardDirectoryReaderFromReaders(directory store.Directory,
+	infos *SegmentInfos, oldReaders []IndexReader,
+	termInfosIndexDivisor int) (r DirectoryReader, err error) {
+
+	// we put the old SegmentReaders in a map, that allows us to lookup
+	// a reader using its segment name
+	segmentReaders := make(map[string]int)
+	for i, r := range oldReaders {
+		segmentReaders[r.(*SegmentReader).SegmentName()] = i
+	}
+
+	newReaders := make([]AtomicReader, len(infos.Segments))
+	var success = false
+	defer func() {
+		if !success {
+			for _, r := range newReaders {
+				if r != nil {
+					r.decRef() // keep going - we want to clean up as much as possible
+				}
+			}
+		}
+	}()
+
+	for i := len(infos.Segments) - 1; i >= 0; i-- {
+		info := infos.Segments[i]
+		// find SegmentReader for this segment
+		var oldReader *SegmentReader
+		if idx, ok := segmentReaders[info.Info.Name]; ok {
+			oldReader = oldReaders[idx].(*SegmentReader)
+		}
+
+		var newReader *SegmentReader
+		if oldReader == nil || info.Info.IsCompoundFile() != oldReader.si.Info.IsCompoundFile() {
+			// this is a new reader; in case we hit an error we can close
+			// it safely
+			if newReader, err = NewSegmentReader(info, termInfosIndexDivisor,
+				store.IO_CONTEXT_READ); err != nil {
+				return nil, err
+			}
+		} else if oldReader.si.DelGen() == info.DelGen() &&
+			oldReader.si.FieldInfosGen() == info.FieldInfosGen() {
+			// No change; this reader will be shared between the old and
+			// the new one, so we must incRef it:
+			oldReader.incRef()
+			newReader = oldReader
+		} else {
+			assert(info.Info.Dir == oldReader.si.Info.Dir)
+			assert(info.HasDeletions() || info.HasFieldUpdates())
+			if oldReader.si.DelGen() == info.DelGen() {
+				// only DV updates
+				newReader, err = newSegmentReaderWithLiveDocs(info, oldReader,
+					oldReader.LiveDocs(), oldReader.NumDocs())
+			} else {
+				// both DV and liveDocs have changed
+				newReader, err = newSegmentReaderFromReader(info, oldReader)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		newReaders[i] = newReader
+	}
+	success = true
+	return newStandardDirectoryReader(directory, newReaders, nil, infos,
+		termInfosIndexDivisor, false), nil
+}
+
 func (r *StandardDirectoryReader) String() string {
 	var buf bytes.Buffer
 	buf.WriteString("StandardDirectoryReader(")
@@ -155,22 +371,77 @@ func (r *StandardDirectoryReader) Version() int64 {
 	return r.segmentInfos.version
 }
 
+func (r *StandardDirectoryReader) doOpenIfChanged() (DirectoryReader, error) {
+	// If we were obtained by writer.getReader(), re-ask the writer to
+	// get a new reader. Once that writer is closed, fall back to the
+	// last commit in the directory, as IsCurrent() does.
+	if r.writer != nil && !r.writer.isClosed() {
+		return r.doOpenFromWriter()
+	}
+	return r.doOpenNoWriter()
+}
+
+func (r *StandardDirectoryReader) doOpenIfChangedFromWriter(w *IndexWriter,
+	applyAllDeletes bool) (DirectoryReader, error) {
+
+	r.ensureOpen()
+	if w == r.writer && applyAllDeletes == r.applyAllDeletes {
+		return r.doOpenFromWriter()
+	}
+	return w.getReader(applyAllDeletes)
+}
+
+func (r *StandardDirectoryReader) doOpenFromWriter() (DirectoryReader, error) {
+	if r.writer.nrtIsCurrent(r.segmentInfos) {
+		return nil, nil
+	}
+
+	reader, err := r.writer.getReader(r.applyAllDeletes)
+	if err != nil {
+		return nil, err
+	}
+
+	// If in fact no changes took place, return nil:
+	if reader.Version() == r.segmentInfos.version {
+		return nil, reader.decRef()
+	}
+	return reader, nil
+}
+
+func (r *StandardDirectoryReader) doOpenNoWriter() (DirectoryReader, error) {
+	r.ensureOpen()
+	if r.IsCurrent() {
+		return nil, nil
+	}
+	obj, err := NewFindSegmentsFile(r.directory, func(segmentFileName string) (interface{}, error) {
+		infos := &SegmentInfos{}
+		if err := infos.Read(r.directory, segmentFileName); err != nil {
+			return nil, err
+		}
+		return openStandardDirectoryReaderFromReaders(r.directory, infos,
+			r.getSequentialSubReaders(), r.termInfosIndexDivisor)
+	}).run(nil)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(DirectoryReader), nil
+}
+
 func (r *StandardDirectoryReader) IsCurrent() bool {
 	r.ensureOpen()
-	// if writer == nill || writer.IsClosed() {
-	// Fully read the segments file: this ensures that it's
-	// completely written so that if
-	// IndexWriter.prepareCommit has been called (but not
-	// yet commit), then the reader will still see itself as
-	// current:
-	sis := SegmentInfos{}
-	sis.ReadAll(r.directory)
-
-	// we loaded SegmentInfos from the directory
-	return sis.version == r.segmentInfos.version
-	// } else {
-	// return writer.nrtIsCurrent(r.segmentInfos)
-	// }
+	if r.writer == nil || r.writer.isClosed() {
+		// Fully read the segments file: this ensures that it's
+		// completely written so that if
+		// IndexWriter.prepareCommit has been called (but not
+		// yet commit), then the reader will still see itself as
+		// current:
+		sis := SegmentInfos{}
+		sis.ReadAll(r.directory)
+
+		// we loaded SegmentInfos from the directory
+		return sis.version == r.segmentInfos.version
+	}
+	return r.writer.nrtIsCurrent(r.segmentInfos)
 }
 
 func (r *StandardDirectoryReader) doClose() error {
@@ -191,8 +462,13 @@ func (r *StandardDirectoryReader) doClose() error {
 		}()
 	}
 
-	if w := r.writer; w != nil {
-		panic("not implemented yet")
+	if w := r.writer; w != nil && !w.isClosed() {
+		// If our original writer was closed before we were, this may
+		// leave some un-referenced files in the index, which is
+		// harmless. The next time IW is opened on the index, it will
+		// delete them.
+		w.decRefDeleter(r.segmentInfos)
+
 		// Since we just closed, writer may now be able to delete unused files:
 		w.deletePendingFiles()
 	}
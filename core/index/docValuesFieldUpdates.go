@@ -1,20 +1,48 @@
 package index
 
+import (
+	"fmt"
+)
+
 /* Holds updates of a single DocValues field, for a set of documents. */
 type DocValuesFieldUpdates struct {
 }
 
+/*
+Returns true if this instance contains any updates. DocValues updates
+are not ported yet, so no update is ever recorded here.
+*/
+func (u *DocValuesFieldUpdates) any() bool {
+	return false
+}
+
 type DocValuesFieldUpdatesContainer struct {
+	numericDVUpdates map[string]*DocValuesFieldUpdates
+	binaryDVUpdates  map[string]*DocValuesFieldUpdates
 }
 
 func newDocValuesFieldUpdatesContainer() *DocValuesFieldUpdatesContainer {
-	panic("not implemented yte")
+	return &DocValuesFieldUpdatesContainer{
+		numericDVUpdates: make(map[string]*DocValuesFieldUpdates),
+		binaryDVUpdates:  make(map[string]*DocValuesFieldUpdates),
+	}
 }
 
 func (c *DocValuesFieldUpdatesContainer) any() bool {
-	panic("not implemented yet")
+	for _, updates := range c.numericDVUpdates {
+		if updates.any() {
+			return true
+		}
+	}
+	for _, updates := range c.binaryDVUpdates {
+		if updates.any() {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *DocValuesFieldUpdatesContainer) String() string {
-	panic("not implemented yet")
+	return fmt.Sprintf("numericDVUpdates=%v binaryDVUpdates=%v",
+		c.numericDVUpdates, c.binaryDVUpdates)
 }
@@ -5,6 +5,7 @@ import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/util"
 	"github.com/balzaczyy/golucene/core/util/packed"
+	"sort"
 )
 
 type DocValuesWriter interface {
@@ -64,7 +65,7 @@ func (w *NumericDocValuesWriter) docsWithFieldBytesUsed() int64 {
 	if w.docsWithField == nil {
 		return 0
 	}
-	return util.SizeOf(w.docsWithField.Bits()) + 64
+	return w.docsWithField.RamBytesUsed() + 64
 }
 
 func (w *NumericDocValuesWriter) updateBytesUsed() {
@@ -80,10 +81,9 @@ func (w *NumericDocValuesWriter) flush(state *SegmentWriteState,
 
 	maxDoc := state.SegmentInfo.DocCount()
 	values := w.pending.Build()
-	dvConsumer.AddNumericField(w.fieldInfo, func() func() (interface{}, bool) {
+	return dvConsumer.AddNumericField(w.fieldInfo, func() func() (interface{}, bool) {
 		return newNumericIterator(maxDoc, values, w.docsWithField)
 	})
-	return nil
 }
 
 /* Iterates over the values we have in ram */
@@ -116,3 +116,430 @@ func newNumericIterator(maxDoc int,
 		return value, true
 	}
 }
+
+// index/BinaryDocValuesWriter.java
+
+/* Buffers up pending []byte per doc, then flushes when segment flushes. */
+type BinaryDocValuesWriter struct {
+	bytes         []byte
+	lengths       packed.PackedLongValuesBuilder
+	docsWithField *util.FixedBitSet
+	iwBytesUsed   util.Counter
+	bytesUsed     int64
+	fieldInfo     *FieldInfo
+	addedValues   int
+}
+
+func newBinaryDocValuesWriter(fieldInfo *FieldInfo,
+	iwBytesUsed util.Counter) *BinaryDocValuesWriter {
+	ans := &BinaryDocValuesWriter{
+		fieldInfo:     fieldInfo,
+		iwBytesUsed:   iwBytesUsed,
+		docsWithField: util.NewFixedBitSetOf(64),
+	}
+	ans.lengths = packed.DeltaPackedBuilder(packed.PackedInts.COMPACT)
+	ans.bytesUsed = ans.docsWithFieldBytesUsed()
+	ans.iwBytesUsed.AddAndGet(ans.bytesUsed)
+	return ans
+}
+
+func (w *BinaryDocValuesWriter) addValue(docId int, value []byte) {
+	assert2(docId >= w.addedValues,
+		"DocValuesField '%v' appears more than once in this document (only one value is allowed per field)",
+		w.fieldInfo.Name)
+
+	// Fill in any holes:
+	for w.addedValues < docId {
+		w.addedValues++
+		w.lengths.Add(0)
+	}
+	w.addedValues++
+	w.lengths.Add(int64(len(value)))
+	w.bytes = append(w.bytes, value...)
+	w.docsWithField = util.EnsureFixedBitSet(w.docsWithField, docId)
+	w.docsWithField.Set(docId)
+	w.updateBytesUsed()
+}
+
+func (w *BinaryDocValuesWriter) docsWithFieldBytesUsed() int64 {
+	// size of the []int64 + some overhead
+	return w.docsWithField.RamBytesUsed() + 64
+}
+
+func (w *BinaryDocValuesWriter) updateBytesUsed() {
+	newBytesUsed := w.lengths.RamBytesUsed() + int64(cap(w.bytes)) +
+		w.docsWithFieldBytesUsed()
+	w.iwBytesUsed.AddAndGet(newBytesUsed - w.bytesUsed)
+	w.bytesUsed = newBytesUsed
+}
+
+func (w *BinaryDocValuesWriter) finish(maxDoc int) {}
+
+func (w *BinaryDocValuesWriter) flush(state *SegmentWriteState,
+	dvConsumer DocValuesConsumer) error {
+
+	maxDoc := state.SegmentInfo.DocCount()
+	lengths := w.lengths.Build()
+	return dvConsumer.AddBinaryField(w.fieldInfo, func() func() (interface{}, bool) {
+		return newBinaryIterator(maxDoc, w.bytes, lengths, w.docsWithField)
+	})
+}
+
+/* Iterates over the values we have in ram */
+func newBinaryIterator(maxDoc int, bytes []byte,
+	lengths packed.PackedLongValues,
+	docsWithField *util.FixedBitSet) func() (interface{}, bool) {
+
+	upto, size, offset := 0, int(lengths.Size()), 0
+	iter := lengths.Iterator()
+	return func() (interface{}, bool) {
+		if upto >= maxDoc {
+			return nil, false
+		}
+		var value interface{}
+		if upto < size {
+			v, _ := iter()
+			length := int(v.(int64))
+			if docsWithField.At(upto) {
+				value = bytes[offset : offset+length]
+			}
+			offset += length
+		}
+		upto++
+		return value, true
+	}
+}
+
+// index/SortedDocValuesWriter.java
+
+const EMPTY_ORD = -1
+
+/* Buffers up pending []byte per doc, deref and sorting via int ord, then flushes when segment flushes. */
+type SortedDocValuesWriter struct {
+	hash        *util.BytesRefHash
+	pending     packed.PackedLongValuesBuilder
+	iwBytesUsed util.Counter
+	bytesUsed   int64 // this currently only tracks differences in 'pending'
+	fieldInfo   *FieldInfo
+}
+
+func newSortedDocValuesWriter(fieldInfo *FieldInfo,
+	iwBytesUsed util.Counter) *SortedDocValuesWriter {
+	ans := &SortedDocValuesWriter{
+		fieldInfo:   fieldInfo,
+		iwBytesUsed: iwBytesUsed,
+		hash: util.NewBytesRefHash(
+			util.NewByteBlockPool(util.NewDirectTrackingAllocator(iwBytesUsed)),
+			util.BYTES_REF_HASH_DEFAULT_CAPACITY,
+			util.NewDirectBytesStartArray(util.BYTES_REF_HASH_DEFAULT_CAPACITY, iwBytesUsed)),
+		pending: packed.DeltaPackedBuilder(packed.PackedInts.COMPACT),
+	}
+	ans.bytesUsed = ans.pending.RamBytesUsed()
+	ans.iwBytesUsed.AddAndGet(ans.bytesUsed)
+	return ans
+}
+
+func (w *SortedDocValuesWriter) addValue(docId int, value []byte) error {
+	assert2(int64(docId) >= w.pending.Size(),
+		"DocValuesField '%v' appears more than once in this document (only one value is allowed per field)",
+		w.fieldInfo.Name)
+
+	// Fill in any holes:
+	for int64(docId) > w.pending.Size() {
+		w.pending.Add(EMPTY_ORD)
+	}
+
+	return w.addOneValue(value)
+}
+
+func (w *SortedDocValuesWriter) finish(maxDoc int) {
+	for w.pending.Size() < int64(maxDoc) {
+		w.pending.Add(EMPTY_ORD)
+	}
+	w.updateBytesUsed()
+}
+
+func (w *SortedDocValuesWriter) addOneValue(value []byte) error {
+	termId, err := w.hash.Add(value)
+	if err != nil {
+		return err
+	}
+	if termId < 0 {
+		termId = -termId - 1
+	} else {
+		// reserve additional space for each unique value:
+		// 1. when indexing, when hash is 50% full, rehash() suddenly
+		//    needs 2*size ints. TODO: can this same OOM happen in
+		//    THPF?
+		// 2. when flushing, we need 1 int per value (slot in the
+		//    ordMap).
+		w.iwBytesUsed.AddAndGet(2 * util.NUM_BYTES_INT)
+	}
+
+	w.pending.Add(int64(termId))
+	w.updateBytesUsed()
+	return nil
+}
+
+func (w *SortedDocValuesWriter) updateBytesUsed() {
+	newBytesUsed := w.pending.RamBytesUsed()
+	w.iwBytesUsed.AddAndGet(newBytesUsed - w.bytesUsed)
+	w.bytesUsed = newBytesUsed
+}
+
+func (w *SortedDocValuesWriter) flush(state *SegmentWriteState,
+	dvConsumer DocValuesConsumer) error {
+
+	maxDoc := state.SegmentInfo.DocCount()
+	assert(w.pending.Size() == int64(maxDoc))
+	valueCount := w.hash.Size()
+	ords := w.pending.Build()
+
+	sortedValues := w.hash.Sort(util.UTF8SortedAsUnicodeLess)
+	ordMap := make([]int, valueCount)
+	for ord := 0; ord < valueCount; ord++ {
+		ordMap[sortedValues[ord]] = ord
+	}
+
+	return dvConsumer.AddSortedField(w.fieldInfo,
+		// ord -> value
+		func() func() (interface{}, bool) {
+			return newValuesIterator(w.hash, sortedValues, valueCount)
+		},
+		// doc -> ord
+		func() func() (interface{}, bool) {
+			return newOrdsIterator(ords, ordMap, maxDoc)
+		})
+}
+
+/* Iterates over the unique values we have in ram */
+func newValuesIterator(hash *util.BytesRefHash, sortedValues []int,
+	valueCount int) func() (interface{}, bool) {
+
+	ordUpto := 0
+	scratch := util.NewEmptyBytesRef()
+	return func() (interface{}, bool) {
+		if ordUpto >= valueCount {
+			return nil, false
+		}
+		hash.Get(sortedValues[ordUpto], scratch)
+		ordUpto++
+		return scratch.ToBytes(), true
+	}
+}
+
+/* Iterates over the ords for each doc we have in ram */
+func newOrdsIterator(ords packed.PackedLongValues, ordMap []int,
+	maxDoc int) func() (interface{}, bool) {
+
+	iter := ords.Iterator()
+	docUpto := 0
+	return func() (interface{}, bool) {
+		if docUpto >= maxDoc {
+			return nil, false
+		}
+		assert(int64(docUpto) < ords.Size())
+		v, _ := iter()
+		ord := v.(int64)
+		docUpto++
+		if ord == EMPTY_ORD {
+			return int64(EMPTY_ORD), true
+		}
+		return int64(ordMap[int(ord)]), true
+	}
+}
+
+// index/SortedSetDocValuesWriter.java
+
+/* Buffers up pending []byte per doc, deref and sorting via int ord, then flushes when segment flushes. */
+type SortedSetDocValuesWriter struct {
+	hash          *util.BytesRefHash
+	pending       packed.PackedLongValuesBuilder // stream of all termIDs
+	pendingCounts packed.PackedLongValuesBuilder // termIDs per doc
+	iwBytesUsed   util.Counter
+	bytesUsed     int64 // this only tracks differences in 'pending' and 'pendingCounts'
+	fieldInfo     *FieldInfo
+	currentDoc    int
+	currentValues []int
+	currentUpto   int
+	maxCount      int
+}
+
+func newSortedSetDocValuesWriter(fieldInfo *FieldInfo,
+	iwBytesUsed util.Counter) *SortedSetDocValuesWriter {
+	ans := &SortedSetDocValuesWriter{
+		fieldInfo:   fieldInfo,
+		iwBytesUsed: iwBytesUsed,
+		hash: util.NewBytesRefHash(
+			util.NewByteBlockPool(util.NewDirectTrackingAllocator(iwBytesUsed)),
+			util.BYTES_REF_HASH_DEFAULT_CAPACITY,
+			util.NewDirectBytesStartArray(util.BYTES_REF_HASH_DEFAULT_CAPACITY, iwBytesUsed)),
+		pending:       packed.PackedBuilder(packed.PackedInts.COMPACT),
+		pendingCounts: packed.DeltaPackedBuilder(packed.PackedInts.COMPACT),
+		currentValues: make([]int, 8),
+	}
+	ans.bytesUsed = ans.pending.RamBytesUsed() + ans.pendingCounts.RamBytesUsed()
+	ans.iwBytesUsed.AddAndGet(ans.bytesUsed)
+	return ans
+}
+
+func (w *SortedSetDocValuesWriter) addValue(docId int, value []byte) error {
+	if docId != w.currentDoc {
+		w.finishCurrentDoc()
+	}
+
+	// Fill in any holes:
+	for w.currentDoc < docId {
+		w.pendingCounts.Add(0) // no values
+		w.currentDoc++
+	}
+
+	err := w.addOneValue(value)
+	w.updateBytesUsed()
+	return err
+}
+
+/* finalize currentDoc: this deduplicates the current term ids */
+func (w *SortedSetDocValuesWriter) finishCurrentDoc() {
+	values := w.currentValues[:w.currentUpto]
+	sort.Ints(values)
+	lastValue, count := -1, 0
+	for _, termId := range values {
+		// if it's not a duplicate
+		if termId != lastValue {
+			w.pending.Add(int64(termId)) // record the term id
+			count++
+		}
+		lastValue = termId
+	}
+	// record the number of unique term ids for this doc
+	w.pendingCounts.Add(int64(count))
+	if count > w.maxCount {
+		w.maxCount = count
+	}
+	w.currentUpto = 0
+	w.currentDoc++
+}
+
+func (w *SortedSetDocValuesWriter) finish(maxDoc int) {
+	w.finishCurrentDoc()
+
+	// fill in any holes
+	for i := w.currentDoc; i < maxDoc; i++ {
+		w.pendingCounts.Add(0) // no values
+	}
+
+	w.updateBytesUsed()
+}
+
+func (w *SortedSetDocValuesWriter) addOneValue(value []byte) error {
+	termId, err := w.hash.Add(value)
+	if err != nil {
+		return err
+	}
+	if termId < 0 {
+		termId = -termId - 1
+	} else {
+		// reserve additional space for each unique value:
+		// 1. when indexing, when hash is 50% full, rehash() suddenly
+		//    needs 2*size ints. TODO: can this same OOM happen in
+		//    THPF?
+		// 2. when flushing, we need 1 int per value (slot in the
+		//    ordMap).
+		w.iwBytesUsed.AddAndGet(2 * util.NUM_BYTES_INT)
+	}
+
+	if w.currentUpto == len(w.currentValues) {
+		w.currentValues = util.GrowIntSlice(w.currentValues, len(w.currentValues)+1)
+		w.iwBytesUsed.AddAndGet(int64(len(w.currentValues)-w.currentUpto) * util.NUM_BYTES_INT)
+	}
+
+	w.currentValues[w.currentUpto] = termId
+	w.currentUpto++
+	return nil
+}
+
+func (w *SortedSetDocValuesWriter) updateBytesUsed() {
+	newBytesUsed := w.pending.RamBytesUsed() + w.pendingCounts.RamBytesUsed()
+	w.iwBytesUsed.AddAndGet(newBytesUsed - w.bytesUsed)
+	w.bytesUsed = newBytesUsed
+}
+
+func (w *SortedSetDocValuesWriter) flush(state *SegmentWriteState,
+	dvConsumer DocValuesConsumer) error {
+
+	maxDoc := state.SegmentInfo.DocCount()
+	maxCountPerDoc := w.maxCount
+	assert(w.pendingCounts.Size() == int64(maxDoc))
+	valueCount := w.hash.Size()
+	ords := w.pending.Build()
+	ordCounts := w.pendingCounts.Build()
+
+	sortedValues := w.hash.Sort(util.UTF8SortedAsUnicodeLess)
+	ordMap := make([]int, valueCount)
+	for ord := 0; ord < valueCount; ord++ {
+		ordMap[sortedValues[ord]] = ord
+	}
+
+	return dvConsumer.AddSortedSetField(w.fieldInfo,
+		// ord -> value
+		func() func() (interface{}, bool) {
+			return newValuesIterator(w.hash, sortedValues, valueCount)
+		},
+		// doc -> ordCount
+		func() func() (interface{}, bool) {
+			return newOrdCountIterator(ordCounts, maxDoc)
+		},
+		// ords
+		func() func() (interface{}, bool) {
+			return newSortedSetOrdsIterator(ords, ordCounts, ordMap, maxCountPerDoc)
+		})
+}
+
+/* Iterates over the ords for each doc we have in ram */
+func newSortedSetOrdsIterator(ords, ordCounts packed.PackedLongValues,
+	ordMap []int, maxCount int) func() (interface{}, bool) {
+
+	iter, counts := ords.Iterator(), ordCounts.Iterator()
+	numOrds := ords.Size()
+	currentDoc := make([]int, maxCount)
+	currentUpto, currentLength := 0, 0
+	var ordUpto int64
+	return func() (interface{}, bool) {
+		if ordUpto >= numOrds {
+			return nil, false
+		}
+		for currentUpto == currentLength {
+			// refill next doc, and sort remapped ords within the doc.
+			currentUpto = 0
+			v, _ := counts()
+			currentLength = int(v.(int64))
+			for i := 0; i < currentLength; i++ {
+				v, _ = iter()
+				currentDoc[i] = ordMap[int(v.(int64))]
+			}
+			sort.Ints(currentDoc[:currentLength])
+		}
+		ord := currentDoc[currentUpto]
+		currentUpto++
+		ordUpto++
+		// TODO: make reusable Number
+		return int64(ord), true
+	}
+}
+
+func newOrdCountIterator(ordCounts packed.PackedLongValues,
+	maxDoc int) func() (interface{}, bool) {
+
+	iter := ordCounts.Iterator()
+	docUpto := 0
+	return func() (interface{}, bool) {
+		if docUpto >= maxDoc {
+			return nil, false
+		}
+		assert(int64(docUpto) < ordCounts.Size())
+		docUpto++
+		v, _ := iter()
+		return v, true
+	}
+}
@@ -114,6 +114,36 @@ func newDocumentsWriter(writer *IndexWriter, config LiveIndexWriterConfig,
 	return ans
 }
 
+func (dw *DocumentsWriter) deleteQueries(queries ...Query) (bool, error) {
+	dw.Lock() // synchronized
+	defer dw.Unlock()
+
+	// TODO why is this synchronized?
+	deleteQueue := dw.deleteQueue
+	deleteQueue.addDeleteQueries(queries...)
+	dw.flushControl.doOnDelete()
+	return dw.applyAllDeletes(deleteQueue)
+}
+
+// NOTE: we could check here if the docID was deleted, and skip it.
+// However, this is somewhat dangerous because it can yield non-
+// deterministic behavior since we may see the docID before we see
+// the term that caused it to be deleted. This would mean some (but
+// not all) of its postings may make it into the index, which'd alter
+// the docFreq for those terms. We could fix this by doing two passes,
+// i.e. first sweep marks all del docs, and 2nd sweep does the real
+// flush, but I suspect that'd add too much time to flush.
+func (dw *DocumentsWriter) deleteTerms(terms ...*Term) (bool, error) {
+	dw.Lock() // synchronized
+	defer dw.Unlock()
+
+	// TODO why is this synchronized?
+	deleteQueue := dw.deleteQueue
+	deleteQueue.addDeleteTerms(terms...)
+	dw.flushControl.doOnDelete()
+	return dw.applyAllDeletes(deleteQueue)
+}
+
 func (dw *DocumentsWriter) applyAllDeletes(deleteQueue *DocumentsWriterDeleteQueue) (bool, error) {
 	if dw.flushControl.getAndResetApplyAllDeletes() {
 		if deleteQueue != nil && !dw.flushControl.fullFlush {
@@ -167,6 +197,61 @@ func (dw *DocumentsWriter) abort(writer *IndexWriter) {
 	success = true
 }
 
+/*
+Aborts all buffered documents and deletes, and returns with all
+ThreadStates locked; the caller must call unlockAllAfterAbortAll()
+with the returned states. The caller must hold the IndexWriter's full
+flush lock.
+*/
+func (dw *DocumentsWriter) lockAndAbortAll(indexWriter *IndexWriter) []*ThreadState {
+	dw.Lock()
+	defer dw.Unlock()
+
+	if dw.infoStream.IsEnabled("DW") {
+		dw.infoStream.Message("DW", "lockAndAbortAll")
+	}
+	var success = false
+	var states []*ThreadState
+	defer func() {
+		if dw.infoStream.IsEnabled("DW") {
+			dw.infoStream.Message("DW", "finished lockAndAbortAll success=%v", success)
+		}
+		if !success {
+			// if something happens here we unlock all states again
+			dw._unlockAllAfterAbortAll(states)
+		}
+	}()
+
+	dw.deleteQueue.clear()
+	newFilesSet := make(map[string]bool)
+	for i, limit := 0, dw.perThreadPool.numActiveThreadState(); i < limit; i++ {
+		perThread := dw.perThreadPool.lock(i, true)
+		states = append(states, perThread)
+		dw.abortThreadState(perThread, newFilesSet)
+	}
+	dw.deleteQueue.clear()
+	dw.flushControl.abortPendingFlushes(newFilesSet)
+	dw.putEvent(newDeleteNewFilesEvent(newFilesSet))
+	dw.flushControl.waitForFlush()
+	success = true
+	return states
+}
+
+func (dw *DocumentsWriter) unlockAllAfterAbortAll(states []*ThreadState) {
+	dw.Lock()
+	defer dw.Unlock()
+	dw._unlockAllAfterAbortAll(states)
+}
+
+func (dw *DocumentsWriter) _unlockAllAfterAbortAll(states []*ThreadState) {
+	if dw.infoStream.IsEnabled("DW") {
+		dw.infoStream.Message("DW", "unlockAll")
+	}
+	for _, perThread := range states {
+		dw.perThreadPool.release(perThread)
+	}
+}
+
 func (dw *DocumentsWriter) abortThreadState(perThread *ThreadState, newFiles map[string]bool) {
 	if perThread.isActive { // we might be closed
 		if perThread.dwpt != nil {
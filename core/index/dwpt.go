@@ -343,7 +343,7 @@ func (dwpt *DocumentsWriterPerThread) flush() (fs *FlushedSegment, err error) {
 	if err != nil {
 		return nil, err
 	}
-	dwpt.pendingUpdates.terms = make(map[*Term]int)
+	dwpt.pendingUpdates.terms = make(map[termKey]int)
 	files := make(map[string]bool)
 	dwpt.directory.EachCreatedFiles(func(name string) {
 		files[name] = true
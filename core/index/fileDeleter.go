@@ -500,6 +500,11 @@ func (fd *IndexFileDeleter) decRefFiles(files []string) {
 	}
 }
 
+func (fd *IndexFileDeleter) decRef(segmentInfos *SegmentInfos) {
+	// assert locked()
+	fd.decRefFiles(segmentInfos.files(fd.directory, false))
+}
+
 func (fd *IndexFileDeleter) decRefFilesWhileSuppressingError(files []string) {
 	for _, file := range files {
 		fd.decRefFileWhileSuppressingError(file)
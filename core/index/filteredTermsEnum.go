@@ -0,0 +1,236 @@
+package index
+
+import (
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// index/FilteredTermsEnum.java
+
+// Return value, if term should be accepted or the iteration should END.
+type AcceptStatus int
+
+const (
+	// Accept the term and position the enum at the next term.
+	ACCEPT_STATUS_YES = AcceptStatus(1)
+	/*
+		Accept the term and advance (NextSeekTerm()) to the next term.
+	*/
+	ACCEPT_STATUS_YES_AND_SEEK = AcceptStatus(2)
+	// Reject the term and position the enum at the next term.
+	ACCEPT_STATUS_NO = AcceptStatus(3)
+	/*
+		Reject the term and advance (NextSeekTerm()) to the next term.
+	*/
+	ACCEPT_STATUS_NO_AND_SEEK = AcceptStatus(4)
+	/*
+		Reject the term and stop enumerating.
+	*/
+	ACCEPT_STATUS_END = AcceptStatus(5)
+)
+
+type FilteredTermsEnumSPI interface {
+	/*
+		Return if term is accepted, not accepted or the iteration should
+		ended (and possibly seek).
+	*/
+	Accept(term []byte) (AcceptStatus, error)
+	/*
+		On the first call to Next() or if Accept() returns
+		ACCEPT_STATUS_YES_AND_SEEK or ACCEPT_STATUS_NO_AND_SEEK, this
+		method will be called to eventually seek the underlying TermsEnum
+		to a new position. On the first call, currentTerm will be nil,
+		later calls will provide the term the underlying enum is
+		positioned at. This method returns per default only one time the
+		initial seek term and then nil, so no repositioning is ever done.
+
+		Override this method, if you want a more sophisticated TermsEnum,
+		that repositions the iterator during enumeration. If this method
+		always returns nil the enum is empty.
+
+		Please note: This method should always provide a greater term
+		than the last enumerated term, else the behaviour of this enum
+		violates the contract for TermsEnums.
+	*/
+	NextSeekTerm(currentTerm []byte) ([]byte, error)
+}
+
+/*
+Abstract class for enumerating a subset of all terms.
+
+Term enumerations are always ordered by Comparator. Each term in the
+enumeration is greater than all that precede it.
+
+Please note: Consumers of this enum cannot call Seek(), it is
+forward only; it panics when a seeking method is called.
+*/
+type FilteredTermsEnum struct {
+	spi FilteredTermsEnumSPI
+
+	initialSeekTerm []byte
+	doSeek          bool
+	actualTerm      []byte
+
+	tenum TermsEnum
+}
+
+/*
+Creates a filtered TermsEnum on a terms enum. If startWithSeek is
+true, the enum will first seek to the term returned by NextSeekTerm()
+before enumerating.
+*/
+func NewFilteredTermsEnum(spi FilteredTermsEnumSPI, tenum TermsEnum,
+	startWithSeek bool) *FilteredTermsEnum {
+
+	assert(tenum != nil)
+	return &FilteredTermsEnum{
+		spi:    spi,
+		tenum:  tenum,
+		doSeek: startWithSeek,
+	}
+}
+
+/*
+Use this method to set the initial []byte to seek before iterating.
+This is a convenience method for subclasses that do not override
+NextSeekTerm(). If the initial seek term is nil (default), the enum
+is empty.
+
+You can only use this method, if you keep the default implementation
+of NextSeekTerm().
+*/
+func (e *FilteredTermsEnum) SetInitialSeekTerm(term []byte) {
+	e.initialSeekTerm = term
+}
+
+func (e *FilteredTermsEnum) NextSeekTerm(currentTerm []byte) ([]byte, error) {
+	t := e.initialSeekTerm
+	e.initialSeekTerm = nil
+	return t, nil
+}
+
+// Returns the related attributes, the returned AttributeSource is
+// shared with the delegate TermsEnum.
+func (e *FilteredTermsEnum) Attributes() *util.AttributeSource {
+	return e.tenum.Attributes()
+}
+
+func (e *FilteredTermsEnum) Term() []byte {
+	return e.tenum.Term()
+}
+
+func (e *FilteredTermsEnum) Comparator() sort.Interface {
+	return e.tenum.Comparator()
+}
+
+func (e *FilteredTermsEnum) DocFreq() (int, error) {
+	return e.tenum.DocFreq()
+}
+
+func (e *FilteredTermsEnum) TotalTermFreq() (int64, error) {
+	return e.tenum.TotalTermFreq()
+}
+
+// This enum does not support seeking!
+func (e *FilteredTermsEnum) SeekExact(term []byte) (bool, error) {
+	panic("FilteredTermsEnum does not support seeking")
+}
+
+// This enum does not support seeking!
+func (e *FilteredTermsEnum) SeekCeil(term []byte) (SeekStatus, error) {
+	panic("FilteredTermsEnum does not support seeking")
+}
+
+// This enum does not support seeking!
+func (e *FilteredTermsEnum) SeekExactByPosition(ord int64) error {
+	panic("FilteredTermsEnum does not support seeking")
+}
+
+// This enum does not support seeking!
+func (e *FilteredTermsEnum) SeekExactFromLast(term []byte, state TermState) error {
+	panic("FilteredTermsEnum does not support seeking")
+}
+
+func (e *FilteredTermsEnum) Ord() int64 {
+	return e.tenum.Ord()
+}
+
+func (e *FilteredTermsEnum) Docs(bits util.Bits, reuse DocsEnum) (DocsEnum, error) {
+	return e.tenum.Docs(bits, reuse)
+}
+
+func (e *FilteredTermsEnum) DocsByFlags(bits util.Bits, reuse DocsEnum, flags int) (DocsEnum, error) {
+	return e.tenum.DocsByFlags(bits, reuse, flags)
+}
+
+func (e *FilteredTermsEnum) DocsAndPositions(bits util.Bits, reuse DocsAndPositionsEnum) (DocsAndPositionsEnum, error) {
+	return e.tenum.DocsAndPositions(bits, reuse)
+}
+
+func (e *FilteredTermsEnum) DocsAndPositionsByFlags(bits util.Bits, reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error) {
+	return e.tenum.DocsAndPositionsByFlags(bits, reuse, flags)
+}
+
+// Returns the filtered enums term state
+func (e *FilteredTermsEnum) TermState() (TermState, error) {
+	assert(e.tenum != nil)
+	return e.tenum.TermState()
+}
+
+func (e *FilteredTermsEnum) Next() (term []byte, err error) {
+	for {
+		// Seek or forward the iterator
+		if e.doSeek {
+			e.doSeek = false
+			var t []byte
+			if t, err = e.spi.NextSeekTerm(e.actualTerm); err != nil {
+				return nil, err
+			}
+			// Make sure we always seek forward:
+			assert2(e.actualTerm == nil || t == nil || util.UTF8SortedAsUnicodeLess(e.actualTerm, t),
+				"curTerm=%v seekTerm=%v", e.actualTerm, t)
+			if t == nil {
+				return nil, nil
+			}
+			var status SeekStatus
+			if status, err = e.tenum.SeekCeil(t); err != nil {
+				return nil, err
+			}
+			if status == SEEK_STATUS_END {
+				// no more terms to seek to or enum exhausted
+				return nil, nil
+			}
+			e.actualTerm = e.tenum.Term()
+		} else {
+			if e.actualTerm, err = e.tenum.Next(); err != nil {
+				return nil, err
+			}
+			if e.actualTerm == nil {
+				// enum exhausted
+				return nil, nil
+			}
+		}
+
+		// check if term is accepted
+		var status AcceptStatus
+		if status, err = e.spi.Accept(e.actualTerm); err != nil {
+			return nil, err
+		}
+		switch status {
+		case ACCEPT_STATUS_YES_AND_SEEK:
+			e.doSeek = true
+			// term accepted, but we need to seek so fall-through
+			fallthrough
+		case ACCEPT_STATUS_YES:
+			// term accepted
+			return e.actualTerm, nil
+		case ACCEPT_STATUS_NO_AND_SEEK:
+			// invalid term, seek next time
+			e.doSeek = true
+		case ACCEPT_STATUS_END:
+			// we are supposed to end the enum
+			return nil, nil
+		}
+	}
+}
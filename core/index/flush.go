@@ -156,13 +156,26 @@ func newFlushByRamOrCountsPolicy() *FlushByRamOrCountsPolicy {
 }
 
 func (p *FlushByRamOrCountsPolicy) onDelete(control *DocumentsWriterFlushControl, state *ThreadState) {
-	panic("not implemented yet")
+	if p.flushOnDeleteTerms() {
+		// flush this state by num del terms
+		if control.numGlobalTermDeletes() >= p.indexWriterConfig.MaxBufferedDeleteTerms() {
+			control.setApplyAllDeletes()
+		}
+	}
+	if p.flushOnRAM() && control.deleteBytesUsed() >
+		int64(1024*1024*p.indexWriterConfig.RAMBufferSizeMB()) {
+		control.setApplyAllDeletes()
+		if p.infoStream.IsEnabled("FP") {
+			p.infoStream.Message("FP", "force apply deletes bytesUsed=%v vs ramBufferMB=%v",
+				control.deleteBytesUsed(), p.indexWriterConfig.RAMBufferSizeMB())
+		}
+	}
 }
 
 func (p *FlushByRamOrCountsPolicy) onInsert(control *DocumentsWriterFlushControl, state *ThreadState) {
 	if p.flushOnDocCount() && state.dwpt.numDocsInRAM >= p.indexWriterConfig.MaxBufferedDocs() {
 		// flush this state by num docs
-		control.setFlushPending(state)
+		control._setFlushPending(state)
 	} else if p.flushOnRAM() { // flush by RAM
 		limit := int64(p.indexWriterConfig.RAMBufferSizeMB() * 1024 * 1024)
 		totalRam := control._activeBytes + control.deleteBytesUsed() // safe w/o sync
@@ -180,7 +193,15 @@ func (p *FlushByRamOrCountsPolicy) onInsert(control *DocumentsWriterFlushControl
 /* Marks the mos tram consuming active DWPT flush pending */
 func (p *FlushByRamOrCountsPolicy) markLargestWriterPending(control *DocumentsWriterFlushControl,
 	perThreadState *ThreadState, currentBytesPerThread int64) {
-	control.setFlushPending(p.findLargestNonPendingWriter(control, perThreadState))
+	control._setFlushPending(p.findLargestNonPendingWriter(control, perThreadState))
+}
+
+/*
+Returns true if this FlushPolicy flushes on
+IndexWriterConfig.MaxBufferedDeleteTerms(), otherwise false.
+*/
+func (p *FlushByRamOrCountsPolicy) flushOnDeleteTerms() bool {
+	return p.indexWriterConfig.MaxBufferedDeleteTerms() != DISABLE_AUTO_FLUSH
 }
 
 /* Returns true if this FLushPolicy flushes on IndexWriterConfig.MaxBufferedDocs(), otherwise false */
@@ -28,7 +28,20 @@ func newDocumentsWriterFlushQueue() *DocumentsWriterFlushQueue {
 }
 
 func (fq *DocumentsWriterFlushQueue) addDeletes(deleteQueue *DocumentsWriterDeleteQueue) error {
-	panic("not implemented yet")
+	fq.Lock()
+	defer fq.Unlock()
+
+	fq.incTickets() // first inc the ticket count - freeze opens a window for anyChanges() to fail
+	var success = false
+	defer func() {
+		if !success {
+			fq.decTickets()
+		}
+	}()
+
+	fq.queue.PushBack(newGlobalDeletesTicket(deleteQueue.freezeGlobalBuffer(nil)))
+	success = true
+	return nil
 }
 
 func (fq *DocumentsWriterFlushQueue) incTickets() {
@@ -187,6 +200,25 @@ func (t *FlushTicketImpl) finishFlush(indexWriter *IndexWriter,
 	return t.publishFlushedSegment(indexWriter, newSegment, bufferedUpdates)
 }
 
+type GlobalDeletesTicket struct {
+	*FlushTicketImpl
+}
+
+func newGlobalDeletesTicket(frozenUpdates *FrozenBufferedUpdates) *GlobalDeletesTicket {
+	return &GlobalDeletesTicket{newFlushTicket(frozenUpdates)}
+}
+
+func (ticket *GlobalDeletesTicket) publish(writer *IndexWriter) error {
+	assertn(!ticket.published, "ticket was already publised - can not publish twice")
+	ticket.published = true
+	// its a global ticket - no segment to publish
+	return ticket.finishFlush(writer, nil, ticket.frozenUpdates)
+}
+
+func (ticket *GlobalDeletesTicket) canPublish() bool {
+	return true
+}
+
 type SegmentFlushTicket struct {
 	*FlushTicketImpl
 	segment *FlushedSegment
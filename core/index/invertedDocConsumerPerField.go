@@ -255,6 +255,13 @@ func (h *TermsHashPerFieldImpl) writeByte(stream int, b byte) {
 	h.intUptos[h.intUptoStart+stream]++
 }
 
+func (h *TermsHashPerFieldImpl) writeBytes(stream int, b []byte) {
+	// TODO: optimize
+	for _, v := range b {
+		h.writeByte(stream, v)
+	}
+}
+
 func (h *TermsHashPerFieldImpl) writeVInt(stream, i int) {
 	assert(stream < h.streamCount)
 	for (i & ^0x7F) != 0 {
@@ -19,7 +19,9 @@ All the fields are either readonly or volatile.
 type LiveIndexWriterConfig interface {
 	TermIndexInterval() int
 	MaxBufferedDocs() int
+	MaxBufferedDeleteTerms() int
 	RAMBufferSizeMB() float64
+	ReaderTermsIndexDivisor() int
 	Similarity() Similarity
 	Codec() Codec
 	MergePolicy() MergePolicy
@@ -192,6 +194,33 @@ func (conf *LiveIndexWriterConfigImpl) MaxBufferedDocs() int {
 	return conf.maxBufferedDocs
 }
 
+/*
+Determines the maximum number of delete-by-term operations that will
+be buffered before both the buffered in-memory delete terms and
+queries are applied and flushed.
+
+Disabled by default (writer flushes by RAM usage).
+
+NOTE: This setting won't trigger a segment flush.
+
+Takes effect immediately, but only the next time a document is added,
+updated or deleted.
+*/
+func (conf *LiveIndexWriterConfigImpl) SetMaxBufferedDeleteTerms(maxBufferedDeleteTerms int) *LiveIndexWriterConfigImpl {
+	assert2(maxBufferedDeleteTerms == DISABLE_AUTO_FLUSH || maxBufferedDeleteTerms >= 1,
+		"maxBufferedDeleteTerms must at least be 1 when enabled")
+	conf.maxBufferedDeleteTerms = maxBufferedDeleteTerms
+	return conf
+}
+
+/*
+Returns the number of buffered deleted terms that will trigger a
+flush of all buffered deletes if enabled.
+*/
+func (conf *LiveIndexWriterConfigImpl) MaxBufferedDeleteTerms() int {
+	return conf.maxBufferedDeleteTerms
+}
+
 /*
 Expert: MergePolicy is invoked whenver there are changes to the
 segments in the index. Its role is to select which merges to do, if
@@ -241,6 +270,11 @@ func (conf *LiveIndexWriterConfigImpl) SetReaderTermsIndexDivisor(divisor int) *
 	return conf
 }
 
+/* Returns the termInfosIndexDivisor. */
+func (conf *LiveIndexWriterConfigImpl) ReaderTermsIndexDivisor() int {
+	return conf.readerTermsIndexDivisor
+}
+
 func (conf *LiveIndexWriterConfigImpl) Similarity() Similarity {
 	return conf.similarity
 }
@@ -5,7 +5,8 @@ import (
 )
 
 const (
-	DOCS_ENUM_FLAG_FREQS = 1
+	DOCS_ENUM_FLAG_NONE  = 0 // flag to pass to TermsEnum.DocsByFlags() if you don't require term frequencies in the DocsEnum
+	DOCS_ENUM_FLAG_FREQS = 1 // flag to pass to TermsEnum.DocsByFlags() if you require term frequencies in the DocsEnum
 )
 
 type DocsEnum interface {
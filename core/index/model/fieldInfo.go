@@ -100,8 +100,16 @@ func (info *FieldInfo) update(indexed, storeTermVector, omitNorms, storePayloads
 	info.checkConsistency()
 }
 
+/* Records that payloads exist for this field; ignored unless positions are indexed. */
+func (info *FieldInfo) SetStorePayloads() {
+	if info.indexed && info.indexOptions >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS {
+		info.storePayloads = true
+	}
+	info.checkConsistency()
+}
+
 func (info *FieldInfo) SetDocValueType(v DocValuesType) {
-	assert2(int(info.docValueType) != 0 && info.docValueType != v,
+	assert2(int(info.docValueType) == 0 || info.docValueType == v,
 		"cannot change DocValues type from %v to %v for field '%v'",
 		info.docValueType, v, info.Name)
 	info.docValueType = v
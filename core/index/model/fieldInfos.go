@@ -108,6 +108,14 @@ func NewFieldNumbers() *FieldNumbers {
 	}
 }
 
+func (fn *FieldNumbers) Clear() {
+	fn.Lock()
+	defer fn.Unlock()
+	fn.numberToName = make(map[int]string)
+	fn.nameToNumber = make(map[string]int)
+	fn.docValuesType = make(map[string]DocValuesType)
+}
+
 func (fn *FieldNumbers) AddOrGet(info *FieldInfo) int {
 	return fn.addOrGet(info.Name, int(info.Number), info.docValueType)
 }
@@ -155,6 +163,25 @@ func (fn *FieldNumbers) addOrGet(name string, preferredNumber int, dv DocValuesT
 	return number
 }
 
+func (fn *FieldNumbers) SetDocValuesType(number int, name string, dv DocValuesType) {
+	fn.Lock()
+	defer fn.Unlock()
+
+	assert2(fn.numberToName[number] == name,
+		"field number %v is already mapped to field name '%v', not '%v'",
+		number, fn.numberToName[number], name)
+	n, ok := fn.nameToNumber[name]
+	assert2(ok && n == number,
+		"field name '%v' is already mapped to field number %v, not %v",
+		name, n, number)
+	if currentDv, ok := fn.docValuesType[name]; ok && dv != 0 && currentDv != 0 {
+		assert2(currentDv == dv,
+			"cannot change DocValues type from %v to %v for field '%v'",
+			currentDv, dv, name)
+	}
+	fn.docValuesType[name] = dv
+}
+
 type FieldInfosBuilder struct {
 	byName             map[string]*FieldInfo
 	globalFieldNumbers *FieldNumbers
@@ -168,6 +195,11 @@ func NewFieldInfosBuilder(globalFieldNumbers *FieldNumbers) *FieldInfosBuilder {
 	}
 }
 
+/* Returns the global field numbers this builder assigns from. */
+func (b *FieldInfosBuilder) GlobalFieldNumbers() *FieldNumbers {
+	return b.globalFieldNumbers
+}
+
 func assert(ok bool) {
 	assert2(ok, "assert fail")
 }
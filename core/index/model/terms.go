@@ -1,7 +1,22 @@
 package model
 
+import (
+	"github.com/balzaczyy/golucene/core/util/automaton"
+)
+
 type Terms interface {
 	Iterator(reuse TermsEnum) TermsEnum
+	/*
+		Returns a TermsEnum that iterates over all terms that are
+		accepted by the provided CompiledAutomaton. If the startTerm is
+		provided then the returned enum will only accept terms > startTerm,
+		but you still must call Next() first to get to the first term.
+		Note that the provided startTerm must be accepted by the
+		automaton.
+
+		NOTE: the returned TermsEnum cannot seek.
+	*/
+	Intersect(compiled *automaton.CompiledAutomaton, startTerm []byte) (TermsEnum, error)
 	DocCount() int
 	SumTotalTermFreq() int64
 	SumDocFreq() int64
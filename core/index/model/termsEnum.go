@@ -33,7 +33,7 @@ type TermsEnum interface {
 	term was found, or EOF was hit. The target term may
 	be before or after the current term. If this returns
 	SeekStatus.END, then enum is unpositioned. */
-	SeekCeil(text []byte) SeekStatus
+	SeekCeil(text []byte) (SeekStatus, error)
 	/* Seeks to the specified term by ordinal (position) as
 	previously returned by ord. The target ord
 	may be before or after the current ord, and must be
@@ -87,14 +87,14 @@ type TermsEnum interface {
 	Do not call this when the enum is unpositioned. This
 	method will return nil if positions were not
 	indexed. */
-	DocsAndPositions(liveDocs util.Bits, reuse DocsAndPositionsEnum) DocsAndPositionsEnum
+	DocsAndPositions(liveDocs util.Bits, reuse DocsAndPositionsEnum) (DocsAndPositionsEnum, error)
 	/* Get DocsAndPositionEnum for the current term,
 	with control over whether offsets and payloads are
 	required. Some codecs may be able to optimize their
 	implementation when offsets and/or payloads are not required.
 	Do not call this when the enum is unpositioned. This
 	will return nil if positions were not indexed. */
-	DocsAndPositionsByFlags(liveDocs util.Bits, reuse DocsAndPositionsEnum, flags int) DocsAndPositionsEnum
+	DocsAndPositionsByFlags(liveDocs util.Bits, reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error)
 	/* Expert: Returns the TermsEnum internal state to position the TermsEnum
 	without re-seeking the term dictionary.
 
@@ -126,7 +126,8 @@ func (e *TermsEnumImpl) Attributes() *util.AttributeSource {
 }
 
 func (e *TermsEnumImpl) SeekExact(text []byte) (ok bool, err error) {
-	return e.SeekCeil(text) == SEEK_STATUS_FOUND, nil
+	status, err := e.SeekCeil(text)
+	return status == SEEK_STATUS_FOUND, err
 }
 
 func (e *TermsEnumImpl) SeekExactFromLast(text []byte, state TermState) error {
@@ -145,7 +146,7 @@ func (e *TermsEnumImpl) Docs(liveDocs util.Bits, reuse DocsEnum) (DocsEnum, erro
 	return e.DocsByFlags(liveDocs, reuse, DOCS_ENUM_FLAG_FREQS)
 }
 
-func (e *TermsEnumImpl) DocsAndPositions(liveDocs util.Bits, reuse DocsAndPositionsEnum) DocsAndPositionsEnum {
+func (e *TermsEnumImpl) DocsAndPositions(liveDocs util.Bits, reuse DocsAndPositionsEnum) (DocsAndPositionsEnum, error) {
 	return e.DocsAndPositionsByFlags(liveDocs, reuse, DOCS_POSITIONS_ENUM_FLAG_OFF_SETS|DOCS_POSITIONS_ENUM_FLAG_PAYLOADS)
 }
 
@@ -183,6 +184,14 @@ func (e *EmptyTermsEnum) SeekCeilUsingCache(term []byte, useCache bool) SeekStat
 	return SEEK_STATUS_END
 }
 
+func (e *EmptyTermsEnum) SeekCeil(term []byte) (SeekStatus, error) {
+	return SEEK_STATUS_END, nil
+}
+
+func (e *EmptyTermsEnum) SeekExact(term []byte) (bool, error) {
+	return false, nil
+}
+
 func (e *EmptyTermsEnum) SeekExactByPosition(ord int64) error {
 	return nil
 }
@@ -211,7 +220,7 @@ func (e *EmptyTermsEnum) DocsByFlags(liveDocs util.Bits, reuse DocsEnum, flags i
 	panic("this method should never be called")
 }
 
-func (e *EmptyTermsEnum) DocsAndPositionsByFlags(liveDocs util.Bits, reuse DocsAndPositionsEnum, flags int) DocsAndPositionsEnum {
+func (e *EmptyTermsEnum) DocsAndPositionsByFlags(liveDocs util.Bits, reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error) {
 	panic("this method should never be called")
 }
 
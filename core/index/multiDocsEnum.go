@@ -0,0 +1,162 @@
+package index
+
+import (
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// index/MultiDocsEnum.java
+
+/* Holds a DocsEnum along with the corresponding ReaderSlice. */
+type docsEnumWithSlice struct {
+	docsEnum DocsEnum
+	slice    ReaderSlice
+}
+
+/*
+Exposes DocsEnum, merged from DocsEnum API of sub-segments.
+*/
+type MultiDocsEnum struct {
+	parent      *MultiTermsEnum
+	subDocsEnum []DocsEnum
+	subs        []docsEnumWithSlice
+	numSubs     int
+	upto        int
+	current     DocsEnum
+	currentBase int
+	doc         int
+}
+
+/*
+Sole constructor. parent is the MultiTermsEnum that created us, and
+subReaderCount is how many sub-readers are being merged.
+*/
+func newMultiDocsEnum(parent *MultiTermsEnum, subReaderCount int) *MultiDocsEnum {
+	return &MultiDocsEnum{
+		parent:      parent,
+		subDocsEnum: make([]DocsEnum, subReaderCount),
+		subs:        make([]docsEnumWithSlice, subReaderCount),
+		doc:         -1,
+	}
+}
+
+func (e *MultiDocsEnum) reset(subs []docsEnumWithSlice) *MultiDocsEnum {
+	e.numSubs = copy(e.subs, subs)
+	e.upto = -1
+	e.doc = -1
+	e.current = nil
+	return e
+}
+
+/* Returns true if this instance can be reused by the provided MultiTermsEnum. */
+func (e *MultiDocsEnum) canReuse(parent *MultiTermsEnum) bool {
+	return e.parent == parent
+}
+
+func (e *MultiDocsEnum) Freq() (int, error) {
+	assert(e.current != nil)
+	return e.current.Freq()
+}
+
+func (e *MultiDocsEnum) DocId() int {
+	return e.doc
+}
+
+func (e *MultiDocsEnum) Advance(target int) (int, error) {
+	assert(target > e.doc)
+	for {
+		if e.current != nil {
+			var doc int
+			var err error
+			if target < e.currentBase {
+				// target was in the previous slice but there was no
+				// matching doc after it
+				doc, err = e.current.NextDoc()
+			} else {
+				doc, err = e.current.Advance(target - e.currentBase)
+			}
+			if err != nil {
+				return 0, err
+			}
+			if doc == NO_MORE_DOCS {
+				e.current = nil
+			} else {
+				e.doc = doc + e.currentBase
+				return e.doc, nil
+			}
+		} else if e.upto == e.numSubs-1 {
+			e.doc = NO_MORE_DOCS
+			return e.doc, nil
+		} else {
+			e.upto++
+			e.current = e.subs[e.upto].docsEnum
+			e.currentBase = e.subs[e.upto].slice.start
+		}
+	}
+}
+
+func (e *MultiDocsEnum) NextDoc() (int, error) {
+	for {
+		if e.current == nil {
+			if e.upto == e.numSubs-1 {
+				e.doc = NO_MORE_DOCS
+				return e.doc, nil
+			}
+			e.upto++
+			e.current = e.subs[e.upto].docsEnum
+			e.currentBase = e.subs[e.upto].slice.start
+		}
+
+		doc, err := e.current.NextDoc()
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			e.doc = e.currentBase + doc
+			return e.doc, nil
+		}
+		e.current = nil
+	}
+}
+
+func (e *MultiDocsEnum) Cost() (cost int64) {
+	for _, sub := range e.subs[:e.numSubs] {
+		cost += sub.docsEnum.Cost()
+	}
+	return
+}
+
+// index/MultiDocsAndPositionsEnum.java
+
+/*
+Exposes DocsAndPositionsEnum, merged from DocsAndPositionsEnum API of
+sub-segments.
+*/
+type MultiDocsAndPositionsEnum struct {
+	*MultiDocsEnum
+}
+
+func newMultiDocsAndPositionsEnum(parent *MultiTermsEnum, subReaderCount int) *MultiDocsAndPositionsEnum {
+	return &MultiDocsAndPositionsEnum{newMultiDocsEnum(parent, subReaderCount)}
+}
+
+func (e *MultiDocsAndPositionsEnum) positions() DocsAndPositionsEnum {
+	assert(e.current != nil)
+	return e.current.(DocsAndPositionsEnum)
+}
+
+func (e *MultiDocsAndPositionsEnum) NextPosition() (int, error) {
+	return e.positions().NextPosition()
+}
+
+func (e *MultiDocsAndPositionsEnum) StartOffset() (int, error) {
+	return e.positions().StartOffset()
+}
+
+func (e *MultiDocsAndPositionsEnum) EndOffset() (int, error) {
+	return e.positions().EndOffset()
+}
+
+func (e *MultiDocsAndPositionsEnum) Payload() ([]byte, error) {
+	return e.positions().Payload()
+}
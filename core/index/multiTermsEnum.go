@@ -0,0 +1,419 @@
+package index
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// index/MultiTermsEnum.java
+
+/*
+Exposes TermsEnum API, merged from TermsEnum API of sub-segments.
+This does a merge sort, by term text, of the sub-readers.
+*/
+type MultiTermsEnum struct {
+	*TermsEnumImpl
+
+	queue       *termMergeQueue
+	subs        []*termsEnumWithSlice // all of our subs (one per sub-reader)
+	currentSubs []*termsEnumWithSlice // current subs that have at least one term for this field
+	top         []*termsEnumWithSlice
+	subDocs     []docsEnumWithSlice
+
+	lastSeek      []byte
+	lastSeekExact bool
+
+	numTop  int
+	numSubs int
+	current []byte
+}
+
+/* Holds a TermsEnum along with the index of its sub-reader. */
+type termsEnumIndex struct {
+	subIndex  int
+	termsEnum TermsEnum
+}
+
+/* Sole constructor. slices holds the sub-reader slices to merge. */
+func newMultiTermsEnum(slices []ReaderSlice) *MultiTermsEnum {
+	ans := &MultiTermsEnum{
+		queue:       new(termMergeQueue),
+		subs:        make([]*termsEnumWithSlice, len(slices)),
+		currentSubs: make([]*termsEnumWithSlice, len(slices)),
+		top:         make([]*termsEnumWithSlice, len(slices)),
+		subDocs:     make([]docsEnumWithSlice, 0, len(slices)),
+	}
+	ans.TermsEnumImpl = NewTermsEnumImpl(ans)
+	for i, slice := range slices {
+		ans.subs[i] = &termsEnumWithSlice{index: i, subSlice: slice}
+	}
+	return ans
+}
+
+/*
+The terms array must be newly created TermsEnum, ie Next() has not
+yet been called. Returns EMPTY_TERMS_ENUM if none of them has a term.
+*/
+func (e *MultiTermsEnum) reset(termsEnumsIndex []termsEnumIndex) (TermsEnum, error) {
+	assert(len(termsEnumsIndex) <= len(e.top))
+	e.numSubs, e.numTop = 0, 0
+	*e.queue = (*e.queue)[:0]
+	for _, v := range termsEnumsIndex {
+		term, err := v.termsEnum.Next()
+		if err != nil {
+			return nil, err
+		}
+		if term != nil {
+			entry := e.subs[v.subIndex]
+			entry.reset(v.termsEnum, term)
+			heap.Push(e.queue, entry)
+			e.currentSubs[e.numSubs] = entry
+			e.numSubs++
+		} // else field has no terms
+	}
+
+	if e.queue.Len() == 0 {
+		return EMPTY_TERMS_ENUM, nil
+	}
+	return e, nil
+}
+
+func (e *MultiTermsEnum) SeekExact(term []byte) (bool, error) {
+	*e.queue = (*e.queue)[:0]
+	e.numTop = 0
+
+	seekOpt := e.lastSeek != nil && bytes.Compare(e.lastSeek, term) <= 0
+
+	e.lastSeek = nil
+	e.lastSeekExact = true
+
+	for _, sub := range e.currentSubs[:e.numSubs] {
+		var found bool
+		// LUCENE-2130: if we had just seek'd already, prior to this
+		// seek, and the new seek term is after the previous one, don't
+		// try to re-seek this sub if its current term is already beyond
+		// this new seek term. Doing so is a waste because this sub will
+		// simply seek to the same spot.
+		if seekOpt {
+			if sub.current != nil {
+				if cmp := bytes.Compare(term, sub.current); cmp == 0 {
+					found = true
+				} else if cmp > 0 {
+					var err error
+					if found, err = sub.terms.SeekExact(term); err != nil {
+						return false, err
+					}
+				}
+			}
+		} else {
+			var err error
+			if found, err = sub.terms.SeekExact(term); err != nil {
+				return false, err
+			}
+		}
+
+		if found {
+			e.top[e.numTop] = sub
+			e.numTop++
+			sub.current = sub.terms.Term()
+			e.current = sub.current
+			assert(bytes.Equal(term, sub.current))
+		}
+	}
+
+	// if at least one sub had exact match to the requested term then
+	// we found match
+	return e.numTop > 0, nil
+}
+
+func (e *MultiTermsEnum) SeekCeil(term []byte) (SeekStatus, error) {
+	*e.queue = (*e.queue)[:0]
+	e.numTop = 0
+	e.lastSeekExact = false
+
+	seekOpt := e.lastSeek != nil && bytes.Compare(e.lastSeek, term) <= 0
+
+	e.lastSeek = append(e.lastSeek[:0], term...)
+
+	for _, sub := range e.currentSubs[:e.numSubs] {
+		var status SeekStatus
+		if seekOpt {
+			if sub.current != nil {
+				if cmp := bytes.Compare(term, sub.current); cmp == 0 {
+					status = SEEK_STATUS_FOUND
+				} else if cmp < 0 {
+					status = SEEK_STATUS_NOT_FOUND
+				} else {
+					var err error
+					if status, err = sub.terms.SeekCeil(term); err != nil {
+						return 0, err
+					}
+				}
+			} else {
+				status = SEEK_STATUS_END
+			}
+		} else {
+			var err error
+			if status, err = sub.terms.SeekCeil(term); err != nil {
+				return 0, err
+			}
+		}
+
+		switch status {
+		case SEEK_STATUS_FOUND:
+			e.top[e.numTop] = sub
+			e.numTop++
+			sub.current = sub.terms.Term()
+			e.current = sub.current
+		case SEEK_STATUS_NOT_FOUND:
+			sub.current = sub.terms.Term()
+			assert(sub.current != nil)
+			heap.Push(e.queue, sub)
+		default:
+			// enum exhausted
+			sub.current = nil
+		}
+	}
+
+	if e.numTop > 0 {
+		// at least one sub had exact match to the requested term
+		return SEEK_STATUS_FOUND, nil
+	} else if e.queue.Len() > 0 {
+		// no sub had exact match, but at least one sub found a term
+		// after the requested term -- advance to that next term:
+		e.pullTop()
+		return SEEK_STATUS_NOT_FOUND, nil
+	}
+	return SEEK_STATUS_END, nil
+}
+
+func (e *MultiTermsEnum) SeekExactByPosition(ord int64) error {
+	return errors.New("MultiTermsEnum does not support seeking by ord")
+}
+
+func (e *MultiTermsEnum) Ord() int64 {
+	panic("not supported")
+}
+
+func (e *MultiTermsEnum) TermState() (TermState, error) {
+	return nil, errors.New("MultiTermsEnum does not support TermState")
+}
+
+func (e *MultiTermsEnum) pullTop() {
+	// extract all subs from the queue that have the same top term
+	assert(e.numTop == 0)
+	for {
+		e.top[e.numTop] = heap.Pop(e.queue).(*termsEnumWithSlice)
+		e.numTop++
+		if e.queue.Len() == 0 || !bytes.Equal((*e.queue)[0].current, e.top[0].current) {
+			break
+		}
+	}
+	e.current = e.top[0].current
+}
+
+func (e *MultiTermsEnum) pushTop() (err error) {
+	// call Next() on each top, and put back into queue
+	for _, top := range e.top[:e.numTop] {
+		if top.current, err = top.terms.Next(); err != nil {
+			return
+		}
+		if top.current != nil {
+			heap.Push(e.queue, top)
+		} // else no more fields in this reader
+	}
+	e.numTop = 0
+	return nil
+}
+
+func (e *MultiTermsEnum) Next() ([]byte, error) {
+	if e.lastSeekExact {
+		// Must SeekCeil at this point, so those subs that didn't have
+		// the term can find the following term. NOTE: we could save
+		// some CPU by only SeekCeil the subs that didn't match the last
+		// exact seek... but most impls short-circuit if you SeekCeil to
+		// term they are already on.
+		status, err := e.SeekCeil(e.current)
+		if err != nil {
+			return nil, err
+		}
+		assert(status == SEEK_STATUS_FOUND)
+		e.lastSeekExact = false
+	}
+	e.lastSeek = nil
+
+	// restore queue
+	if err := e.pushTop(); err != nil {
+		return nil, err
+	}
+
+	// gather equal top fields
+	if e.queue.Len() > 0 {
+		e.pullTop()
+	} else {
+		e.current = nil
+	}
+	return e.current, nil
+}
+
+func (e *MultiTermsEnum) Term() []byte {
+	return e.current
+}
+
+func (e *MultiTermsEnum) Comparator() sort.Interface {
+	return util.UTF8SortedAsUnicodeComparator
+}
+
+func (e *MultiTermsEnum) DocFreq() (int, error) {
+	sum := 0
+	for _, top := range e.top[:e.numTop] {
+		df, err := top.terms.DocFreq()
+		if err != nil {
+			return 0, err
+		}
+		sum += df
+	}
+	return sum, nil
+}
+
+func (e *MultiTermsEnum) TotalTermFreq() (int64, error) {
+	var sum int64
+	for _, top := range e.top[:e.numTop] {
+		v, err := top.terms.TotalTermFreq()
+		if err != nil {
+			return 0, err
+		}
+		if v == -1 {
+			return v, nil
+		}
+		sum += v
+	}
+	return sum, nil
+}
+
+/* Returns the live docs of the given sub-reader, or nil if there are no deletions. */
+func subLiveDocs(liveDocs util.Bits, slice ReaderSlice) util.Bits {
+	if liveDocs == nil {
+		return nil
+	}
+	return newBitsSlice(liveDocs, slice)
+}
+
+func (e *MultiTermsEnum) DocsByFlags(liveDocs util.Bits, reuse DocsEnum, flags int) (DocsEnum, error) {
+	// Can only reuse if incoming enum is also a MultiDocsEnum and was
+	// previously created w/ this MultiTermsEnum:
+	docsEnum, ok := reuse.(*MultiDocsEnum)
+	if !ok || !docsEnum.canReuse(e) {
+		docsEnum = newMultiDocsEnum(e, len(e.subs))
+	}
+
+	e.subDocs = e.subDocs[:0]
+	for _, entry := range e.top[:e.numTop] {
+		assert2(entry.index < len(docsEnum.subDocsEnum), "%v vs %v; %v",
+			entry.index, len(docsEnum.subDocsEnum), len(e.subs))
+		subDocsEnum, err := entry.terms.DocsByFlags(subLiveDocs(liveDocs, entry.subSlice),
+			docsEnum.subDocsEnum[entry.index], flags)
+		if err != nil {
+			return nil, err
+		}
+		assert2(subDocsEnum != nil, "One of our subs cannot provide a DocsEnum")
+		docsEnum.subDocsEnum[entry.index] = subDocsEnum
+		e.subDocs = append(e.subDocs, docsEnumWithSlice{subDocsEnum, entry.subSlice})
+	}
+
+	if len(e.subDocs) == 0 {
+		return nil, nil
+	}
+	return docsEnum.reset(e.subDocs), nil
+}
+
+func (e *MultiTermsEnum) DocsAndPositionsByFlags(liveDocs util.Bits,
+	reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error) {
+
+	// Can only reuse if incoming enum is also a
+	// MultiDocsAndPositionsEnum and was previously created w/ this
+	// MultiTermsEnum:
+	docsAndPositionsEnum, ok := reuse.(*MultiDocsAndPositionsEnum)
+	if !ok || !docsAndPositionsEnum.canReuse(e) {
+		docsAndPositionsEnum = newMultiDocsAndPositionsEnum(e, len(e.subs))
+	}
+
+	e.subDocs = e.subDocs[:0]
+	for _, entry := range e.top[:e.numTop] {
+		b := subLiveDocs(liveDocs, entry.subSlice)
+		var subReuse DocsAndPositionsEnum
+		if v, ok := docsAndPositionsEnum.subDocsEnum[entry.index].(DocsAndPositionsEnum); ok {
+			subReuse = v
+		}
+		subPostings, err := entry.terms.DocsAndPositionsByFlags(b, subReuse, flags)
+		if err != nil {
+			return nil, err
+		}
+		if subPostings != nil {
+			docsAndPositionsEnum.subDocsEnum[entry.index] = subPostings
+			e.subDocs = append(e.subDocs, docsEnumWithSlice{subPostings, entry.subSlice})
+			continue
+		}
+		docs, err := entry.terms.DocsByFlags(b, nil, DOCS_ENUM_FLAG_NONE)
+		if err != nil {
+			return nil, err
+		}
+		if docs != nil {
+			// At least one of our subs does not store offsets or
+			// positions -- we can't correctly produce a
+			// MultiDocsAndPositionsEnum
+			return nil, nil
+		}
+	}
+
+	if len(e.subDocs) == 0 {
+		return nil, nil
+	}
+	docsAndPositionsEnum.reset(e.subDocs)
+	return docsAndPositionsEnum, nil
+}
+
+func (e *MultiTermsEnum) String() string {
+	return "MultiTermsEnum()"
+}
+
+type termsEnumWithSlice struct {
+	subSlice ReaderSlice
+	terms    TermsEnum
+	current  []byte
+	index    int
+}
+
+func (e *termsEnumWithSlice) reset(terms TermsEnum, term []byte) {
+	e.terms = terms
+	e.current = term
+}
+
+/* Orders subs by their current term, then by sub-reader order. */
+type termMergeQueue []*termsEnumWithSlice
+
+func (q termMergeQueue) Len() int      { return len(q) }
+func (q termMergeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q termMergeQueue) Less(i, j int) bool {
+	if cmp := bytes.Compare(q[i].current, q[j].current); cmp != 0 {
+		return cmp < 0
+	}
+	return q[i].subSlice.start < q[j].subSlice.start
+}
+
+func (q *termMergeQueue) Push(x interface{}) {
+	*q = append(*q, x.(*termsEnumWithSlice))
+}
+
+func (q *termMergeQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	ans := old[n-1]
+	*q = old[:n-1]
+	return ans
+}
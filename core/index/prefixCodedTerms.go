@@ -4,6 +4,8 @@ import (
 	"github.com/balzaczyy/golucene/core/store"
 )
 
+// index/PrefixCodedTerms.java
+
 /* Prefix codes term instances (prefixes are shared) */
 type PrefixCodedTerms struct {
 	buffer *store.RAMFile
@@ -17,24 +19,84 @@ func (terms *PrefixCodedTerms) RamBytesUsed() int64 {
 	return terms.buffer.RamBytesUsed()
 }
 
+/* Decodes all terms, in the order they were added. */
+func (terms *PrefixCodedTerms) terms() []*Term {
+	input, err := store.NewRAMInputStream("PrefixCodedTermsIterator", terms.buffer)
+	if err != nil {
+		panic(err)
+	}
+	var ans []*Term
+	var field string
+	var bytes []byte
+	for input.FilePointer() < input.Length() {
+		code, err := input.ReadVInt()
+		if err == nil && (code&1) != 0 {
+			// new field
+			field, err = input.ReadString()
+		}
+		var suffix int32
+		if err == nil {
+			suffix, err = input.ReadVInt()
+		}
+		if err != nil {
+			panic(err) // RAM resident; should never happen
+		}
+		prefix := int(uint32(code) >> 1)
+		term := make([]byte, prefix+int(suffix))
+		copy(term, bytes[:prefix])
+		if err = input.ReadBytes(term[prefix:]); err != nil {
+			panic(err)
+		}
+		bytes = term
+		ans = append(ans, NewTermFromBytes(field, term))
+	}
+	return ans
+}
+
 /* Builds a PrefixCodedTerms: call add repeatedly, then finish. */
 type PrefixCodedTermsBuilder struct {
-	buffer *store.RAMFile
-	output *store.RAMOutputStream
+	buffer   *store.RAMFile
+	output   *store.RAMOutputStream
+	lastTerm *Term
 }
 
 func newPrefixCodedTermsBuilder() *PrefixCodedTermsBuilder {
 	f := store.NewRAMFileBuffer()
 	return &PrefixCodedTermsBuilder{
-		buffer: f,
-		output: store.NewRAMOutputStream(f, false),
+		buffer:   f,
+		output:   store.NewRAMOutputStream(f, false),
+		lastTerm: NewEmptyTerm(""),
 	}
 }
 
+/* add a term */
 func (b *PrefixCodedTermsBuilder) add(term *Term) {
-	panic("not implemented yet")
+	assert(b.lastTerm.Field == "" && len(b.lastTerm.Bytes) == 0 ||
+		TermSorter([]*Term{b.lastTerm, term}).Less(0, 1))
+	prefix := sharedPrefix(b.lastTerm.Bytes, term.Bytes)
+	suffix := len(term.Bytes) - prefix
+	var err error
+	if term.Field == b.lastTerm.Field {
+		err = b.output.WriteVInt(int32(prefix << 1))
+	} else {
+		err = b.output.WriteVInt(int32(prefix<<1 | 1))
+		if err == nil {
+			err = b.output.WriteString(term.Field)
+		}
+	}
+	if err == nil {
+		err = b.output.WriteVInt(int32(suffix))
+	}
+	if err == nil {
+		err = b.output.WriteBytes(term.Bytes[prefix:])
+	}
+	if err != nil {
+		panic(err)
+	}
+	b.lastTerm = term
 }
 
+/* return finalized form */
 func (b *PrefixCodedTermsBuilder) finish() *PrefixCodedTerms {
 	err := b.output.Close()
 	if err != nil {
@@ -42,3 +104,13 @@ func (b *PrefixCodedTermsBuilder) finish() *PrefixCodedTerms {
 	}
 	return newPrefixCodedTerms(b.buffer)
 }
+
+func sharedPrefix(term1, term2 []byte) int {
+	pos := 0
+	for limit := len(term1); pos < limit && pos < len(term2); pos++ {
+		if term1[pos] != term2[pos] {
+			break
+		}
+	}
+	return pos
+}
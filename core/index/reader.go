@@ -94,6 +94,33 @@ func newIndexReader(spi IndexReaderImplSPI) *IndexReaderImpl {
 	}
 }
 
+/*
+Expert: increments the refCount of this IndexReader instance.
+RefCounts are used to determine when a reader can be closed safely,
+i.e. as soon as there are no more references. Be sure to always call
+a corresponding decRef(), in a finally clause; otherwise the reader
+may never be closed.
+*/
+func (r *IndexReaderImpl) incRef() {
+	if !r.tryIncRef() {
+		r.ensureOpen()
+	}
+}
+
+/*
+Expert: increments the refCount of this IndexReader instance only if
+the IndexReader has not been closed yet and returns true iff the
+refCount was successfully incremented, otherwise false.
+*/
+func (r *IndexReaderImpl) tryIncRef() bool {
+	for count := atomic.LoadInt32(&r.refCount); count > 0; count = atomic.LoadInt32(&r.refCount) {
+		if atomic.CompareAndSwapInt32(&r.refCount, count, count+1) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *IndexReaderImpl) decRef() error {
 	// only check refcount here (don't call ensureOpen()), so we can
 	// still close the reader if it was made invalid by a child:
@@ -239,11 +266,29 @@ type ARFieldsReader interface {
 	Terms(field string) Terms
 	Fields() Fields
 	LiveDocs() util.Bits
+	// Get the FieldInfos describing all fields in this reader.
+	FieldInfos() FieldInfos
 	/** Returns {@link NumericDocValues} representing norms
 	 *  for this field, or null if no {@link NumericDocValues}
 	 *  were indexed. The returned instance should only be
 	 *  used by a single thread. */
 	NormValues(field string) (ndv NumericDocValues, err error)
+	// Returns NumericDocValues for this field, or nil if no
+	// NumericDocValues were indexed for this field.
+	NumericDocValues(field string) (NumericDocValues, error)
+	// Returns BinaryDocValues for this field, or nil if no
+	// BinaryDocValues were indexed for this field.
+	BinaryDocValues(field string) (BinaryDocValues, error)
+	// Returns SortedDocValues for this field, or nil if no
+	// SortedDocValues were indexed for this field.
+	SortedDocValues(field string) (SortedDocValues, error)
+	// Returns SortedSetDocValues for this field, or nil if no
+	// SortedSetDocValues were indexed for this field.
+	SortedSetDocValues(field string) (SortedSetDocValues, error)
+	// Returns a Bits at the size of MaxDoc(), with turned on bits for
+	// each docid that does have a value for this field, or nil if no
+	// doc values were indexed for this field.
+	DocsWithField(field string) (util.Bits, error)
 }
 
 type AtomicReader interface {
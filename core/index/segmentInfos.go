@@ -881,6 +881,15 @@ func (sis *SegmentInfos) rollbackSegmentInfos(infos []*SegmentCommitInfo) {
 	}
 }
 
+/* Returns sum of all segment's docCounts. */
+func (sis *SegmentInfos) totalDocCount() int {
+	count := 0
+	for _, info := range sis.Segments {
+		count += info.Info.DocCount()
+	}
+	return count
+}
+
 func (sis *SegmentInfos) Clear() {
 	for i, _ := range sis.Segments {
 		sis.Segments[i] = nil
@@ -894,5 +903,24 @@ Remove the provided SegmentCommitInfo.
 WARNING: O(N) cost
 */
 func (sis *SegmentInfos) remove(si *SegmentCommitInfo) {
-	panic("not implemented yet")
+	if idx := sis.indexOf(si); idx != -1 {
+		segments := make([]*SegmentCommitInfo, 0, len(sis.Segments)-1)
+		segments = append(segments, sis.Segments[:idx]...)
+		sis.Segments = append(segments, sis.Segments[idx+1:]...)
+	}
+}
+
+/*
+Return the index of the provided SegmentCommitInfo, or -1 if it's not
+present.
+
+WARNING: O(N) cost
+*/
+func (sis *SegmentInfos) indexOf(si *SegmentCommitInfo) int {
+	for i, info := range sis.Segments {
+		if info == si {
+			return i
+		}
+	}
+	return -1
 }
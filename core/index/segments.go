@@ -11,6 +11,8 @@ import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
+	"io"
+	"sync"
 	"sync/atomic"
 )
 
@@ -34,6 +36,9 @@ type SegmentReader struct {
 	core    *SegmentCoreReaders
 
 	fieldInfos FieldInfos
+
+	dvProducersByField map[string]DocValuesProducer
+	dvProducers        []io.Closer
 }
 
 /**
@@ -74,35 +79,128 @@ func NewSegmentReader(si *SegmentCommitInfo,
 
 	codec := si.Info.Codec().(Codec)
 	if si.HasDeletions() {
-		panic("not supported yet")
+		// NOTE: the bitvector is stored using the regular directory, not cfs
+		if r.liveDocs, err = codec.LiveDocsFormat().ReadLiveDocs(r.Directory(), si, store.IO_CONTEXT_READONCE); err != nil {
+			return nil, err
+		}
 	} else {
 		assert(si.DelCount() == 0)
 	}
 	r.numDocs = si.Info.DocCount() - si.DelCount()
 
 	if r.fieldInfos.HasDocValues {
-		r.initDocValuesProducers(codec)
+		if err = r.initDocValuesProducers(codec); err != nil {
+			return nil, err
+		}
+	}
+	success = true
+	return r, nil
+}
+
+/*
+Create new SegmentReader sharing core from a previous SegmentReader
+and loading new live docs from a new deletes file. Used by
+OpenIfChanged().
+*/
+func newSegmentReaderFromReader(si *SegmentCommitInfo, sr *SegmentReader) (*SegmentReader, error) {
+	liveDocs, err := si.Info.Codec().(Codec).LiveDocsFormat().ReadLiveDocs(
+		si.Info.Dir, si, store.IO_CONTEXT_READONCE)
+	if err != nil {
+		return nil, err
+	}
+	return newSegmentReaderWithLiveDocs(si, sr, liveDocs, si.Info.DocCount()-si.DelCount())
+}
+
+/*
+Create new SegmentReader sharing core from a previous SegmentReader
+and using the provided in-memory liveDocs. Used by IndexWriter to
+provide a new NRT reader.
+*/
+func newSegmentReaderWithLiveDocs(si *SegmentCommitInfo, sr *SegmentReader,
+	liveDocs util.Bits, numDocs int) (r *SegmentReader, err error) {
+
+	r = &SegmentReader{}
+	r.AtomicReaderImpl = newAtomicReader(r)
+	r.ARFieldsReader = r
+
+	r.si = si
+	r.liveDocs = liveDocs
+	r.numDocs = numDocs
+	r.core = sr.core
+	r.core.incRef()
+
+	var success = false
+	defer func() {
+		if !success {
+			r.core.decRef()
+		}
+	}()
+
+	if r.fieldInfos, err = ReadFieldInfos(si); err != nil {
+		return nil, err
+	}
+	if r.fieldInfos.HasDocValues {
+		if err = r.initDocValuesProducers(si.Info.Codec().(Codec)); err != nil {
+			return nil, err
+		}
 	}
 	success = true
 	return r, nil
 }
 
 /* initialize the per-field DocValuesProducer */
-func (r *SegmentReader) initDocValuesProducers(codec Codec) error {
-	// var dir store.Directory
-	// if r.core.cfsReader != nil {
-	// 	dir = r.core.cfsReader
-	// } else {
-	// 	dir = r.si.Info.Dir
-	// }
-	// dvFormat := codec.DocValuesFormat()
-
-	// termsIndexDivisor := r.core.termsIndexDivisor
-	if !r.si.HasFieldUpdates() {
-		panic("not implemented yet")
+func (r *SegmentReader) initDocValuesProducers(codec Codec) (err error) {
+	var dir store.Directory
+	if r.core.cfsReader != nil {
+		dir = r.core.cfsReader
+	} else {
+		dir = r.si.Info.Dir
 	}
+	dvFormat := codec.DocValuesFormat()
+	r.dvProducersByField = make(map[string]DocValuesProducer)
 
-	panic("not implemented yet")
+	var success = false
+	defer func() {
+		if !success {
+			util.CloseWhileSuppressingError(r.dvProducers...)
+		}
+	}()
+
+	for gen, infos := range r.genInfos() {
+		dvDir := dir
+		var segmentSuffix string
+		if gen != -1 {
+			// gen'd files are written outside CFS, so use SegInfo directory
+			dvDir = r.si.Info.Dir
+			segmentSuffix = strconv.FormatInt(gen, 36)
+		}
+		state := NewSegmentReadState(dvDir, r.si.Info, NewFieldInfos(infos),
+			store.IO_CONTEXT_READ, r.core.termsIndexDivisor)
+		state.SegmentSuffix = segmentSuffix
+		var dvp DocValuesProducer
+		if dvp, err = dvFormat.FieldsProducer(state); err != nil {
+			return err
+		}
+		r.dvProducers = append(r.dvProducers, dvp)
+		for _, fi := range infos {
+			r.dvProducersByField[fi.Name] = dvp
+		}
+	}
+	success = true
+	return nil
+}
+
+/* Groups the fields with doc values by their doc values generation. */
+func (r *SegmentReader) genInfos() map[int64][]*FieldInfo {
+	genInfos := make(map[int64][]*FieldInfo)
+	for _, fi := range r.fieldInfos.Values {
+		if !fi.HasDocValues() {
+			continue
+		}
+		gen := fi.DocValuesGen()
+		genInfos[gen] = append(genInfos[gen], fi)
+	}
+	return genInfos
 }
 
 /* Reads the most recent FieldInfos of the given segment info. */
@@ -144,9 +242,8 @@ func (r *SegmentReader) LiveDocs() util.Bits {
 }
 
 func (r *SegmentReader) doClose() error {
-	panic("not implemented yet")
 	r.core.decRef()
-	return nil
+	return util.Close(r.dvProducers...)
 }
 
 func (r *SegmentReader) FieldInfos() FieldInfos {
@@ -220,6 +317,21 @@ func (r *SegmentReader) CoreCacheKey() interface{} {
 	return r.core
 }
 
+/*
+Expert: adds a CoreClosedListener to this reader's shared core, which
+is notified once all readers sharing the core are closed.
+*/
+func (r *SegmentReader) AddCoreClosedListener(listener CoreClosedListener) {
+	r.ensureOpen()
+	r.core.addCoreClosedListener(listener)
+}
+
+/* Expert: removes a CoreClosedListener from this reader's shared core. */
+func (r *SegmentReader) RemoveCoreClosedListener(listener CoreClosedListener) {
+	r.ensureOpen()
+	r.core.removeCoreClosedListener(listener)
+}
+
 func (r *SegmentReader) CombinedCoreAndDeletesKey() interface{} {
 	return r
 }
@@ -228,24 +340,68 @@ func (r *SegmentReader) TermInfosIndexDivisor() int {
 	return r.core.termsIndexDivisor
 }
 
+/*
+Returns the FieldInfo that corresponds to the given field and type,
+or nil if the field does not exist, or not indexed with the requested
+DocValuesType.
+*/
+func (r *SegmentReader) dvField(field string, typ DocValuesType) *FieldInfo {
+	fi := r.fieldInfos.FieldInfoByName(field)
+	if fi == nil {
+		// Field does not exist
+		return nil
+	}
+	if fi.DocValuesType() == 0 {
+		// Field was not indexed with doc values
+		return nil
+	}
+	if fi.DocValuesType() != typ {
+		// Field DocValues are different than requested type
+		return nil
+	}
+	return fi
+}
+
 func (r *SegmentReader) NumericDocValues(field string) (v NumericDocValues, err error) {
 	r.ensureOpen()
-	panic("not implemented yet")
+	if fi := r.dvField(field, DOC_VALUES_TYPE_NUMERIC); fi != nil {
+		return r.dvProducersByField[field].Numeric(fi)
+	}
+	return nil, nil
 }
 
 func (r *SegmentReader) BinaryDocValues(field string) (v BinaryDocValues, err error) {
 	r.ensureOpen()
-	panic("not implemented yet")
+	if fi := r.dvField(field, DOC_VALUES_TYPE_BINARY); fi != nil {
+		return r.dvProducersByField[field].Binary(fi)
+	}
+	return nil, nil
 }
 
 func (r *SegmentReader) SortedDocValues(field string) (v SortedDocValues, err error) {
 	r.ensureOpen()
-	panic("not implemented yet")
+	if fi := r.dvField(field, DOC_VALUES_TYPE_SORTED); fi != nil {
+		return r.dvProducersByField[field].Sorted(fi)
+	}
+	return nil, nil
 }
 
 func (r *SegmentReader) SortedSetDocValues(field string) (v SortedSetDocValues, err error) {
 	r.ensureOpen()
-	panic("not implemented yet")
+	if fi := r.dvField(field, DOC_VALUES_TYPE_SORTED_SET); fi != nil {
+		return r.dvProducersByField[field].SortedSet(fi)
+	}
+	return nil, nil
+}
+
+func (r *SegmentReader) DocsWithField(field string) (v util.Bits, err error) {
+	r.ensureOpen()
+	fi := r.fieldInfos.FieldInfoByName(field)
+	if fi == nil || !fi.HasDocValues() {
+		// Field does not exist or does not index doc values
+		return nil, nil
+	}
+	return r.dvProducersByField[field].DocsWithField(fi)
 }
 
 func (r *SegmentReader) NormValues(field string) (v NumericDocValues, err error) {
@@ -253,8 +409,12 @@ func (r *SegmentReader) NormValues(field string) (v NumericDocValues, err error)
 	return r.core.normValues(r.fieldInfos, field)
 }
 
+/*
+Called when the shared core of SegmentReaders is closed, so that
+caches keyed by CoreCacheKey() can drop their entries.
+*/
 type CoreClosedListener interface {
-	onClose(r interface{})
+	OnClose(ownerCoreCacheKey interface{})
 }
 
 // index/SegmentCoreReaders.java
@@ -283,9 +443,8 @@ type SegmentCoreReaders struct {
 	fieldsReaderLocal func() StoredFieldsReader
 	normsLocal        func() map[string]interface{}
 
-	addListener    chan CoreClosedListener
-	removeListener chan CoreClosedListener
-	notifyListener chan bool
+	coreClosedListeners     map[CoreClosedListener]bool
+	coreClosedListenersLock sync.Mutex
 }
 
 func newSegmentCoreReaders(owner *SegmentReader, dir store.Directory, si *SegmentCommitInfo,
@@ -300,47 +459,12 @@ func newSegmentCoreReaders(owner *SegmentReader, dir store.Directory, si *Segmen
 		normsLocal: func() map[string]interface{} {
 			return make(map[string]interface{})
 		},
+		coreClosedListeners: make(map[CoreClosedListener]bool),
 	}
 	self.fieldsReaderLocal = func() StoredFieldsReader {
 		return self.fieldsReaderOrig.Clone()
 	}
 
-	// fmt.Println("Initializing listeners...")
-	self.addListener = make(chan CoreClosedListener)
-	self.removeListener = make(chan CoreClosedListener)
-	self.notifyListener = make(chan bool)
-	// TODO re-enable later
-	go func() { // ensure listners are synchronized
-		coreClosedListeners := make([]CoreClosedListener, 0)
-		isRunning := true
-		var listener CoreClosedListener
-		for isRunning {
-			// fmt.Println("Listening for events...")
-			select {
-			case listener = <-self.addListener:
-				coreClosedListeners = append(coreClosedListeners, listener)
-			case listener = <-self.removeListener:
-				n := len(coreClosedListeners)
-				for i, v := range coreClosedListeners {
-					if v == listener {
-						newListeners := make([]CoreClosedListener, 0, n-1)
-						newListeners = append(newListeners, coreClosedListeners[0:i]...)
-						newListeners = append(newListeners, coreClosedListeners[i+1:]...)
-						coreClosedListeners = newListeners
-						break
-					}
-				}
-			case <-self.notifyListener:
-				fmt.Println("Shutting down SegmentCoreReaders...")
-				isRunning = false
-				for _, v := range coreClosedListeners {
-					v.onClose(self)
-				}
-			}
-		}
-		fmt.Println("Listeners are done.")
-	}()
-
 	var success = false
 	ans := self
 	defer func() {
@@ -424,12 +548,37 @@ func (r *SegmentCoreReaders) normValues(infos FieldInfos,
 	return
 }
 
+func (r *SegmentCoreReaders) incRef() {
+	assert(atomic.AddInt32(&r.refCount, 1) > 1)
+}
+
 func (r *SegmentCoreReaders) decRef() {
 	if atomic.AddInt32(&r.refCount, -1) == 0 {
-		fmt.Println("--- closing core readers")
+		// fmt.Println("--- closing core readers")
 		util.Close( /*self.termVectorsLocal, self.fieldsReaderLocal,  r.normsLocal,*/
 			r.fields, r.termVectorsReaderOrig, r.fieldsReaderOrig,
 			r.cfsReader, r.normsProducer)
-		r.notifyListener <- true
+		r.notifyCoreClosedListeners()
 	}
 }
+
+func (r *SegmentCoreReaders) notifyCoreClosedListeners() {
+	r.coreClosedListenersLock.Lock()
+	defer r.coreClosedListenersLock.Unlock()
+	for listener, _ := range r.coreClosedListeners {
+		// SegmentReader uses our instance as its coreCacheKey:
+		listener.OnClose(r)
+	}
+}
+
+func (r *SegmentCoreReaders) addCoreClosedListener(listener CoreClosedListener) {
+	r.coreClosedListenersLock.Lock()
+	defer r.coreClosedListenersLock.Unlock()
+	r.coreClosedListeners[listener] = true
+}
+
+func (r *SegmentCoreReaders) removeCoreClosedListener(listener CoreClosedListener) {
+	r.coreClosedListenersLock.Lock()
+	defer r.coreClosedListenersLock.Unlock()
+	delete(r.coreClosedListeners, listener)
+}
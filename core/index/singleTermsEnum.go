@@ -0,0 +1,40 @@
+package index
+
+import (
+	"bytes"
+	. "github.com/balzaczyy/golucene/core/index/model"
+)
+
+// index/SingleTermsEnum.java
+
+/*
+Subclass of FilteredTermsEnum for enumerating a single term.
+
+For example, this can be used by MultiTermQuery implementations that
+need only visit one term, but want to preserve MultiTermQuery
+semantics such as RewriteMethod.
+*/
+type SingleTermsEnum struct {
+	*FilteredTermsEnum
+	singleRef []byte
+}
+
+/*
+Creates a new SingleTermsEnum.
+
+After calling the constructor the enumeration is already pointing to
+the term, if it exists.
+*/
+func NewSingleTermsEnum(tenum TermsEnum, termText []byte) *SingleTermsEnum {
+	ans := &SingleTermsEnum{singleRef: termText}
+	ans.FilteredTermsEnum = NewFilteredTermsEnum(ans, tenum, true)
+	ans.SetInitialSeekTerm(termText)
+	return ans
+}
+
+func (e *SingleTermsEnum) Accept(term []byte) (AcceptStatus, error) {
+	if bytes.Equal(term, e.singleRef) {
+		return ACCEPT_STATUS_YES, nil
+	}
+	return ACCEPT_STATUS_END, nil
+}
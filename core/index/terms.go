@@ -5,6 +5,7 @@ import (
 	// "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/automaton"
 	// "sort"
 )
 
@@ -113,7 +114,7 @@ func NewTermContextFromTerm(ctx IndexReaderContext, t *Term) (tc *TermContext, e
 					if err != nil {
 						return nil, err
 					}
-					perReaderTermState.register(termState, leaf.Ord, df, tf)
+					perReaderTermState.Register(termState, leaf.Ord, df, tf)
 				}
 			}
 		}
@@ -121,7 +122,11 @@ func NewTermContextFromTerm(ctx IndexReaderContext, t *Term) (tc *TermContext, e
 	return perReaderTermState, nil
 }
 
-func (tc *TermContext) register(state TermState, ord, docFreq int, totalTermFreq int64) {
+/*
+Registers and associates a TermState with an leaf ordinal. The leaf
+ordinal should be derived from an IndexReaderContext's leaf ord.
+*/
+func (tc *TermContext) Register(state TermState, ord, docFreq int, totalTermFreq int64) {
 	assert2(state != nil, "state must not be nil")
 	assert(ord >= 0 && ord < len(tc.states))
 	assert2(tc.states[ord] == nil, "state for ord: %v already registered", ord)
@@ -150,7 +155,39 @@ func NewMultiTerms(subs []Terms, subSlices []ReaderSlice) *MultiTerms {
 }
 
 func (mt *MultiTerms) Iterator(reuse TermsEnum) TermsEnum {
-	panic("not implemented yet")
+	var termsEnums []termsEnumIndex
+	for i, sub := range mt.subs {
+		if termsEnum := sub.Iterator(nil); termsEnum != nil {
+			termsEnums = append(termsEnums, termsEnumIndex{i, termsEnum})
+		}
+	}
+	if len(termsEnums) == 0 {
+		return EMPTY_TERMS_ENUM
+	}
+	ans, err := newMultiTermsEnum(mt.subSlices).reset(termsEnums)
+	if err != nil {
+		panic(err) // Iterator() can't report errors
+	}
+	return ans
+}
+
+func (mt *MultiTerms) Intersect(compiled *automaton.CompiledAutomaton,
+	startTerm []byte) (TermsEnum, error) {
+
+	var termsEnums []termsEnumIndex
+	for i, sub := range mt.subs {
+		termsEnum, err := sub.Intersect(compiled, startTerm)
+		if err != nil {
+			return nil, err
+		}
+		if termsEnum != nil {
+			termsEnums = append(termsEnums, termsEnumIndex{i, termsEnum})
+		}
+	}
+	if len(termsEnums) == 0 {
+		return EMPTY_TERMS_ENUM, nil
+	}
+	return newMultiTermsEnum(mt.subSlices).reset(termsEnums)
 }
 
 func (mt *MultiTerms) DocCount() int {
@@ -222,8 +222,7 @@ func newFreqProxTermsWriterPerField(invertState *FieldInvertState,
 func (w *FreqProxTermsWriterPerField) finish() error {
 	err := w.TermsHashPerFieldImpl.finish()
 	if err == nil && w.sawPayloads {
-		panic("not implemented yet")
-		// w.fieldInfo.SetStorePayloads()
+		w.fieldInfo.SetStorePayloads()
 	}
 	return err
 }
@@ -271,7 +270,10 @@ func (w *FreqProxTermsWriterPerField) writeProx(termId, proxCode int) {
 	} else {
 		payload := w.payloadAttribute.Payload()
 		if len(payload) > 0 {
-			panic("not implemented yet")
+			w.writeVInt(1, (proxCode<<1)|1)
+			w.writeVInt(1, len(payload))
+			w.writeBytes(1, payload)
+			w.sawPayloads = true
 		} else {
 			w.writeVInt(1, proxCode<<1)
 		}
@@ -322,7 +324,15 @@ func (w *FreqProxTermsWriterPerField) addTerm(termId int) {
 	assert(!w.hasFreq || postings.termFreqs[termId] > 0)
 
 	if !w.hasFreq {
-		panic("not implemented yet")
+		assert(postings.termFreqs == nil)
+		if w.docState.docID != postings.lastDocIDs[termId] {
+			// New document; now encode docCode for previous doc:
+			assert(w.docState.docID > postings.lastDocIDs[termId])
+			w.writeVInt(0, postings.lastDocCodes[termId])
+			postings.lastDocCodes[termId] = w.docState.docID - postings.lastDocIDs[termId]
+			postings.lastDocIDs[termId] = w.docState.docID
+			w.fieldState.uniqueTermCount++
+		}
 	} else if w.docState.docID != postings.lastDocIDs[termId] {
 		assert2(w.docState.docID > postings.lastDocIDs[termId],
 			"id: %v postings ID: %v termID: %v",
@@ -498,7 +508,7 @@ func (w *FreqProxTermsWriterPerField) flush(fieldName string,
 
 	assert(!writeOffsets || writePositions)
 
-	var segUpdates map[*Term]int
+	var segUpdates map[termKey]int
 	if state.SegUpdates != nil && len(state.SegUpdates.(*BufferedUpdates).terms) > 0 {
 		segUpdates = state.SegUpdates.(*BufferedUpdates).terms
 	}
@@ -514,7 +524,6 @@ func (w *FreqProxTermsWriterPerField) flush(fieldName string,
 	sumTotalTermFreq := int64(0)
 	sumDocFreq := int64(0)
 
-	protoTerm := NewEmptyTerm(fieldName)
 	for i := 0; i < numTerms; i++ {
 		termId := termIDs[i]
 		// fmt.Printf("term=%v\n", termId)
@@ -539,8 +548,7 @@ func (w *FreqProxTermsWriterPerField) flush(fieldName string,
 
 		delDocLimit := 0
 		if segUpdates != nil {
-			protoTerm.Bytes = text.ToBytes()
-			if docIDUpto, ok := segUpdates[protoTerm]; ok {
+			if docIDUpto, ok := segUpdates[termKey{fieldName, string(text.ToBytes())}]; ok {
 				delDocLimit = docIDUpto
 			}
 		}
@@ -612,7 +620,18 @@ func (w *FreqProxTermsWriterPerField) flush(fieldName string,
 				return err
 			}
 			if docId < delDocLimit {
-				panic("not implemented yet")
+				// Mark it deleted. TODO: we could also skip writing its
+				// postings; this would be deterministic (just for this
+				// Term's docs).
+
+				// TODO: can we do this reach-around in a cleaner way????
+				if state.LiveDocs == nil {
+					state.LiveDocs = w.docState.docWriter.codec.LiveDocsFormat().NewLiveDocs(state.SegmentInfo.DocCount())
+				}
+				if state.LiveDocs.At(docId) {
+					state.DelCountOnFlush++
+					state.LiveDocs.Clear(docId)
+				}
 			}
 
 			totalTermFreq += int64(termFreq)
@@ -624,6 +643,7 @@ func (w *FreqProxTermsWriterPerField) flush(fieldName string,
 				// we did record positions (& maybe payload) and/or offsets
 				position := 0
 				// offset := 0
+				var payload []byte
 				for j := 0; j < termFreq; j++ {
 					var thisPayload []byte
 
@@ -635,7 +655,18 @@ func (w *FreqProxTermsWriterPerField) flush(fieldName string,
 						position += int(uint(code) >> 1)
 
 						if (code & 1) != 0 {
-							panic("not implemented yet")
+							// This position has a payload
+							payloadLength, err := prox.ReadVInt()
+							if err != nil {
+								return err
+							}
+							if cap(payload) < int(payloadLength) {
+								payload = make([]byte, payloadLength)
+							}
+							thisPayload = payload[:payloadLength]
+							if err = prox.ReadBytes(thisPayload); err != nil {
+								return err
+							}
 						}
 
 						if readOffsets {
@@ -83,8 +83,8 @@ var DefaultSimilarity func() Similarity
 
 // Use a seprate goroutine to protect closing control
 type ClosingControl struct {
-	_closed  bool // volatile
-	_closing bool // volatile
+	_closed  int32 // atomic
+	_closing int32 // atomic
 	closer   chan func() (bool, error)
 	done     chan error
 }
@@ -100,15 +100,18 @@ func newClosingControl() *ClosingControl {
 
 func (cc *ClosingControl) daemon() {
 	var err error
-	for !cc._closed {
+	for !cc.closed() {
 		err = nil
 		select {
 		case f := <-cc.closer:
 			log.Println("...closing...")
-			if !cc._closed {
-				cc._closing = true
-				cc._closed, err = f()
-				cc._closing = false
+			if !cc.closed() {
+				atomic.StoreInt32(&cc._closing, 1)
+				var ok bool
+				if ok, err = f(); ok {
+					atomic.StoreInt32(&cc._closed, 1)
+				}
+				atomic.StoreInt32(&cc._closing, 0)
 			}
 			cc.done <- err
 		}
@@ -119,11 +122,19 @@ func (cc *ClosingControl) daemon() {
 // Used internally to throw an AlreadyClosedError if this IndexWriter
 // has been closed or is in the process of closing.
 func (cc *ClosingControl) ensureOpen(failIfClosing bool) {
-	assert2(!cc._closed && (!failIfClosing || !cc._closing), "this IndexWriter is closed")
+	assert2(!cc.closed() && (!failIfClosing || !cc.closing()), "this IndexWriter is closed")
+}
+
+func (cc *ClosingControl) closed() bool {
+	return atomic.LoadInt32(&cc._closed) != 0
+}
+
+func (cc *ClosingControl) closing() bool {
+	return atomic.LoadInt32(&cc._closing) != 0
 }
 
 func (cc *ClosingControl) close(f func() (ok bool, err error)) error {
-	if cc._closed {
+	if cc.closed() {
 		return nil // already closed
 	}
 	cc.closer <- f
@@ -430,6 +441,115 @@ func NewIndexWriter(d store.Directory, conf *IndexWriterConfig) (w *IndexWriter,
 	return ans, nil
 }
 
+/*
+Expert: returns a readonly reader, covering all committed as well as
+un-committed changes to the index. This provides "near real-time"
+searching, in that changes made during an IndexWriter session can be
+quickly made available for searching without closing the writer nor
+calling Commit().
+
+Note that this is functionally equivalent to calling Commit() and
+then using OpenDirectoryReader() to open a new reader. But the
+turnaround time of this method should be faster since it avoids the
+potentially costly Commit().
+
+You must close the returned reader once you are done using it.
+
+It's near real-time because there is no hard guarantee on how
+quickly you can get a new reader after making changes with
+IndexWriter. You'll have to experiment in your situation to
+determine if it's fast enough.
+
+The resulting reader supports OpenIfChanged(), but that call will
+simply forward back to this method (though this may change in the
+future).
+
+The very first time this method is called, this writer instance
+will make every effort to pool the readers that it opens for doing
+merges, applying deletes, etc. This means additional resources (RAM,
+file descriptors, CPU time) will be consumed.
+*/
+func (w *IndexWriter) getReader(applyAllDeletes bool) (r DirectoryReader, err error) {
+	w.ensureOpen()
+
+	start := time.Now()
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "flush at getReader")
+	}
+	// Do this up front before flushing so that the readers obtained
+	// during this flush are pooled, the first time this method is
+	// called:
+	w.poolReaders = true
+	if err = w.doBeforeFlush(); err != nil {
+		return nil, err
+	}
+
+	var success2 = false
+	defer func() {
+		if !success2 && r != nil {
+			util.CloseWhileSuppressingError(r)
+		}
+	}()
+
+	anySegmentFlushed, err := func() (anySegmentFlushed bool, err error) {
+		w.fullFlushLock.Lock()
+		defer w.fullFlushLock.Unlock()
+
+		var success = false
+		defer func() {
+			if !success && w.infoStream.IsEnabled("IW") {
+				w.infoStream.Message("IW", "hit error during NRT reader")
+			}
+			// Done: finish the full flush!
+			w.docWriter.finishFullFlush(success)
+			w.docWriter.processEvents(w, false, true)
+			if err2 := w.doAfterFlush(); err2 != nil && err == nil {
+				err = err2
+			}
+		}()
+
+		if anySegmentFlushed, err = w.docWriter.flushAllThreads(w); err != nil {
+			return
+		}
+		if !anySegmentFlushed {
+			// flushCount is incremented in flushAllThreads
+			atomic.AddInt32(&w.flushCount, 1)
+		}
+		success = true
+
+		// Prevent segmentInfos from changing while opening the reader;
+		// in theory we could do similar retry logic, just like we do
+		// when loading segments_N
+		w.Lock()
+		defer w.Unlock()
+		if err = w._maybeApplyDeletes(applyAllDeletes); err != nil {
+			return
+		}
+		if r, err = openStandardDirectoryReaderFromWriter(w, w.segmentInfos, applyAllDeletes); err != nil {
+			return
+		}
+		if w.infoStream.IsEnabled("IW") {
+			w.infoStream.Message("IW", "return reader version=%v reader=%v", r.Version(), r)
+		}
+		return
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	if anySegmentFlushed {
+		if err = w.maybeMerge(w.config.MergePolicy(), MERGE_TRIGGER_FULL_FLUSH,
+			UNBOUNDED_MAX_MERGE_SEGMENTS); err != nil {
+			return nil, err
+		}
+	}
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "getReader took %v", time.Now().Sub(start))
+	}
+	success2 = true
+	return r, nil
+}
+
 // func (w *IndexWriter) fieldInfos(info *SegmentInfo) (infos FieldInfos, err error) {
 // 	var cfsDir store.Directory
 // 	if info.IsCompoundFile() {
@@ -608,6 +728,118 @@ func (w *IndexWriter) UpdateDocument(term *Term, doc []IndexableField, analyzer
 	return nil
 }
 
+/*
+Deletes the document(s) containing any of the terms. All given
+deletes are applied and flushed atomically at the same time.
+*/
+func (w *IndexWriter) DeleteDocuments(terms ...*Term) error {
+	w.ensureOpen()
+	ok, err := w.docWriter.deleteTerms(terms...)
+	if err != nil {
+		return err
+	}
+	if ok {
+		_, err = w.docWriter.processEvents(w, true, false)
+	}
+	return err
+}
+
+/*
+Deletes the document(s) matching any of the provided queries. All
+given deletes are applied and flushed atomically at the same time.
+
+The queries are resolved against each segment through
+Query.MatchingDocs() when the deletes are applied. To delete by a
+search.Query, wrap it with search.NewQueryWrapperFilter().
+*/
+func (w *IndexWriter) DeleteDocumentsByQuery(queries ...Query) error {
+	w.ensureOpen()
+	ok, err := w.docWriter.deleteQueries(queries...)
+	if err != nil {
+		return err
+	}
+	if ok {
+		_, err = w.docWriter.processEvents(w, true, false)
+	}
+	return err
+}
+
+/*
+Delete all documents in the index.
+
+This method will drop all buffered documents and will remove all
+segments from the index. This change will not be visible until a
+Commit() has been called. This method can be rolled back using
+Rollback().
+
+NOTE: this method is much faster than using DeleteDocuments(), but
+the index is still 'empty' from a directory's point of view until
+the next commit.
+
+NOTE: this method will forcefully abort all merges in progress. If
+other goroutines are running ForceMerge() or any of the addIndexes
+methods, they will receive errors.
+*/
+func (w *IndexWriter) DeleteAll() error {
+	w.ensureOpen()
+	// Remove any buffered docs
+	var success = false
+	// hold the full flush lock to prevent concurrency commits / NRT
+	// reopens to get in our way and do unnecessary work. -- if we don't
+	// lock this here we might get in trouble if
+	w.fullFlushLock.Lock()
+	defer w.fullFlushLock.Unlock()
+
+	// We first abort and trash everything we have in-memory and keep
+	// the thread-states locked, the lockAndAbortAll operation also
+	// guarantees "point in time semantics" ie. the checkpoint that we
+	// need in terms of logical happens-before relationship in the DW.
+	// So we do abort all in memory structures. We also drop global
+	// field numbering before during abort to make sure it's just like
+	// a fresh index.
+	states := w.docWriter.lockAndAbortAll(w)
+	defer func() {
+		w.docWriter.unlockAllAfterAbortAll(states)
+		if !success && w.infoStream.IsEnabled("IW") {
+			w.infoStream.Message("IW", "hit error during deleteAll")
+		}
+	}()
+
+	if _, err := w.docWriter.processEvents(w, false, true); err != nil {
+		return err
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	// Abort any pending merges; abortAllMerges() lets merges run again
+	// once it returns
+	w.abortAllMerges()
+
+	// Remove all segments
+	atomic.AddInt64(&w.pendingNumDocs, -int64(w.segmentInfos.totalDocCount()))
+	w.segmentInfos.Clear()
+	// Ask deleter to locate unreferenced files & remove them:
+	if err := w.deleter.checkpoint(w.segmentInfos, false); err != nil {
+		return err
+	}
+	// don't refresh the deleter here since there might be concurrent
+	// indexing requests coming in opening files on the directory after
+	// we called DW.abort() if we do so these indexing requests might
+	// hit FNF errors. We will remove the files incrementally as we go...
+
+	// Don't bother saving any changes in our segmentInfos
+	if err := w.readerPool.dropAll(false); err != nil {
+		return err
+	}
+	// Mark that the index has changed
+	w.changeCount++
+	w.segmentInfos.changed()
+	w.globalFieldNumberMap.Clear()
+	success = true
+	return nil
+}
+
 func (w *IndexWriter) newSegmentName() string {
 	// Cannot synchronize on IndexWriter because that causes deadlook
 	// Ian: but why?
@@ -918,7 +1150,7 @@ func (w *IndexWriter) rollbackInternal() (ok bool, err error) {
 		return nil
 	}()
 
-	return err != nil, err
+	return err == nil, err
 }
 
 /*
@@ -945,6 +1177,10 @@ segments file, but does NOT bump segmentInfos.version.
 func (w *IndexWriter) checkpointNoSIS() (err error) {
 	w.Lock() // synchronized
 	defer w.Unlock()
+	return w._checkpointNoSIS()
+}
+
+func (w *IndexWriter) _checkpointNoSIS() error {
 	w.changeCount++
 	return w.deleter.checkpoint(w.segmentInfos, false)
 }
@@ -1380,7 +1616,7 @@ func (w *IndexWriter) _applyAllDeletesAndUpdates() error {
 		return err
 	}
 	if result.anyDeletes {
-		err = w.checkpoint()
+		err = w._checkpoint()
 		if err != nil {
 			return err
 		}
@@ -1403,7 +1639,7 @@ func (w *IndexWriter) _applyAllDeletesAndUpdates() error {
 				}
 			}
 		}
-		err = w.checkpoint()
+		err = w._checkpoint()
 		if err != nil {
 			return err
 		}
@@ -1641,6 +1877,30 @@ func (w *IndexWriter) testPoint(message string) {
 
 // L4356
 
+/*
+Returns true if the given SegmentInfos, as held by a near real-time
+reader, still reflects every change made through this writer.
+*/
+func (w *IndexWriter) nrtIsCurrent(infos *SegmentInfos) bool {
+	w.Lock() // synchronized
+	defer w.Unlock()
+	w.ensureOpen()
+	return infos.version == w.segmentInfos.version &&
+		!w.docWriter.anyChanges() && !w.bufferedUpdatesStream.any()
+}
+
+func (w *IndexWriter) isClosed() bool {
+	return w.ClosingControl.closed()
+}
+
+/* Called by DirectoryReader.doClose() */
+func (w *IndexWriter) decRefDeleter(segmentInfos *SegmentInfos) {
+	w.Lock() // synchronized
+	defer w.Unlock()
+	w.ClosingControl.ensureOpen(false)
+	w.deleter.decRef(segmentInfos)
+}
+
 /* Called by DirectoryReader.doClose() */
 func (w *IndexWriter) deletePendingFiles() {
 	w.deleter.deletePendingFiles()
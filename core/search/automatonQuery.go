@@ -0,0 +1,275 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	"unicode/utf8"
+)
+
+// search/AutomatonQuery.java
+
+/*
+A Query that will match terms against a finite-state machine.
+
+This query will match documents that contain terms accepted by a
+given finite-state machine. The automaton can be constructed with the
+automaton API. Alternatively, it can be created from a regular
+expression with RegexpQuery or from the standard Lucene wildcard
+syntax with WildcardQuery.
+
+When the query is executed, it will create an equivalent DFA of the
+finite-state machine, and will enumerate the term dictionary in an
+intelligent way to reduce the number of comparisons. For example: the
+regular expression of [dl]og? will make approximately four
+comparisons: do, dog, lo, and log.
+*/
+type AutomatonQuery struct {
+	*MultiTermQuery
+	// the automaton to match index terms against
+	automaton *automaton.Automaton
+	compiled  *automaton.CompiledAutomaton
+	// term containing the field, and possibly some pattern structure
+	term *index.Term
+}
+
+/*
+Create a new AutomatonQuery from an Automaton.
+
+term contains the field, and possibly some pattern structure. The
+term text is ignored.
+*/
+func NewAutomatonQuery(term *index.Term, a *automaton.Automaton) *AutomatonQuery {
+	ans := new(AutomatonQuery)
+	ans.init(ans, term, a)
+	return ans
+}
+
+func (q *AutomatonQuery) init(self MultiTermQuerySPI, term *index.Term, a *automaton.Automaton) {
+	q.MultiTermQuery = NewMultiTermQuery(self, term.Field)
+	q.term = term
+	q.automaton = a
+	q.compiled = automaton.NewCompiledAutomaton(a)
+}
+
+func (q *AutomatonQuery) TermsEnum(terms Terms) (TermsEnum, error) {
+	switch q.compiled.Type {
+	case automaton.AUTOMATON_TYPE_NONE:
+		return EMPTY_TERMS_ENUM, nil
+	case automaton.AUTOMATON_TYPE_ALL:
+		return terms.Iterator(nil), nil
+	case automaton.AUTOMATON_TYPE_SINGLE:
+		return index.NewSingleTermsEnum(terms.Iterator(nil), q.compiled.Term), nil
+	case automaton.AUTOMATON_TYPE_NORMAL:
+		return terms.Intersect(q.compiled, nil)
+	default:
+		panic("unknown automaton type")
+	}
+}
+
+/* Returns the automaton used to create this query */
+func (q *AutomatonQuery) Automaton() *automaton.Automaton {
+	return q.automaton
+}
+
+func (q *AutomatonQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteRune(':')
+	}
+	buf.WriteString("AutomatonQuery {\n")
+	buf.WriteString(fmt.Sprintf("%v", q.automaton))
+	buf.WriteRune('}')
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+func boostString(boost float32) string {
+	if boost != 1.0 {
+		return fmt.Sprintf("^%v", boost)
+	}
+	return ""
+}
+
+// search/PrefixQuery.java
+
+/*
+A Query that matches documents containing terms with a specified
+prefix. A PrefixQuery is built by QueryParser for input like app*.
+
+This query uses the CONSTANT_SCORE_FILTER_REWRITE rewrite method.
+*/
+type PrefixQuery struct {
+	*AutomatonQuery
+}
+
+/* Constructs a query for terms starting with prefix. */
+func NewPrefixQuery(prefix *index.Term) *PrefixQuery {
+	ans := new(PrefixQuery)
+	ans.AutomatonQuery = new(AutomatonQuery)
+	ans.init(ans, prefix, PrefixQueryToAutomaton(prefix.Bytes))
+	return ans
+}
+
+/*
+Build an automaton accepting all terms with the specified prefix.
+*/
+func PrefixQueryToAutomaton(prefix []byte) *automaton.Automaton {
+	return automaton.Concatenate(
+		automaton.MakeString(string(prefix)),
+		automaton.MakeAnyString())
+}
+
+/* Returns the prefix of this query. */
+func (q *PrefixQuery) Prefix() *index.Term {
+	return q.term
+}
+
+/* Prints a user-readable version of this query. */
+func (q *PrefixQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteRune(':')
+	}
+	buf.Write(q.term.Bytes)
+	buf.WriteRune('*')
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+// search/WildcardQuery.java
+
+const (
+	WILDCARD_STRING = '*'  // String equality with support for wildcards
+	WILDCARD_CHAR   = '?'  // Char equality with support for wildcards
+	WILDCARD_ESCAPE = '\\' // Escape character
+)
+
+/*
+Implements the wildcard search query. Supported wildcards are *, which
+matches any character sequence (including the empty one), and ?,
+which matches any single character. '\' is the escape character.
+
+Note this query can be slow, as it needs to iterate over many terms.
+In order to prevent extremely slow WildcardQueries, a Wildcard term
+should not start with the wildcard *
+
+This query uses the CONSTANT_SCORE_FILTER_REWRITE rewrite method.
+*/
+type WildcardQuery struct {
+	*AutomatonQuery
+}
+
+/* Constructs a query for terms matching term. */
+func NewWildcardQuery(term *index.Term) *WildcardQuery {
+	ans := new(WildcardQuery)
+	ans.AutomatonQuery = new(AutomatonQuery)
+	ans.init(ans, term, WildcardQueryToAutomaton(term))
+	return ans
+}
+
+/*
+Convert Lucene wildcard syntax into an automaton.
+*/
+func WildcardQueryToAutomaton(wildcardquery *index.Term) *automaton.Automaton {
+	var automata []*automaton.Automaton
+
+	wildcardText := string(wildcardquery.Bytes)
+
+	for i := 0; i < len(wildcardText); {
+		c, length := utf8.DecodeRuneInString(wildcardText[i:])
+		switch c {
+		case WILDCARD_STRING:
+			automata = append(automata, automaton.MakeAnyString())
+		case WILDCARD_CHAR:
+			automata = append(automata, automaton.MakeAnyChar())
+		case WILDCARD_ESCAPE:
+			// add the next codepoint instead, if it exists
+			if i+length < len(wildcardText) {
+				nextChar, nextLength := utf8.DecodeRuneInString(wildcardText[i+length:])
+				automata = append(automata, automaton.MakeChar(int(nextChar)))
+				length += nextLength
+				break
+			} // else fallthru, lenient parsing with a trailing \
+			fallthrough
+		default:
+			automata = append(automata, automaton.MakeChar(int(c)))
+		}
+		i += length
+	}
+
+	return automaton.ConcatenateN(automata)
+}
+
+/* Returns the pattern term. */
+func (q *WildcardQuery) Term() *index.Term {
+	return q.term
+}
+
+/* Prints a user-readable version of this query. */
+func (q *WildcardQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteRune(':')
+	}
+	buf.Write(q.term.Bytes)
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+// search/RegexpQuery.java
+
+/*
+A fast regular expression query based on the automaton package.
+
+  - Comparisons are fast
+  - The term dictionary is enumerated in an intelligent way, to avoid
+    comparisons. See AutomatonQuery for more details.
+
+The supported syntax is documented in the automaton.RegExp type. Note
+this might be different than other regular expression
+implementations.
+
+Note this query can be slow, as it needs to iterate over many terms.
+In order to prevent extremely slow RegexpQueries, a Regexp term
+should not start with the expression .*
+*/
+type RegexpQuery struct {
+	*AutomatonQuery
+}
+
+/*
+Constructs a query for terms matching term.
+
+By default, all regular expression features are enabled.
+*/
+func NewRegexpQuery(term *index.Term) *RegexpQuery {
+	return NewRegexpQueryWithFlags(term, automaton.ALL)
+}
+
+/* Constructs a query for terms matching term. */
+func NewRegexpQueryWithFlags(term *index.Term, flags int) *RegexpQuery {
+	ans := new(RegexpQuery)
+	ans.AutomatonQuery = new(AutomatonQuery)
+	ans.init(ans, term, automaton.NewRegExpWithFlag(string(term.Bytes), flags).ToAutomaton())
+	return ans
+}
+
+/* Prints a user-readable version of this query. */
+func (q *RegexpQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteRune(':')
+	}
+	buf.WriteRune('/')
+	buf.Write(q.term.Bytes)
+	buf.WriteRune('/')
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
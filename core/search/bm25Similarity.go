@@ -0,0 +1,319 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+)
+
+// search/similarities/BM25Similarity.java
+
+/* Cache of decoded bytes. */
+var bm25NormTable = func() []float32 {
+	table := make([]float32, 256)
+	for i := 1; i < 256; i++ {
+		f := util.Byte315ToFloat(byte(i))
+		table[i] = 1 / (f * f)
+	}
+	table[0] = 1 / table[255] // otherwise inf
+	return table
+}()
+
+/*
+BM25 Similarity. Introduced in Stephen E. Robertson, Steve Walker,
+Susan Jones, Micheline Hancock-Beaulieu, and Mike Gatford. Okapi at
+TREC-3. In Proceedings of the Third Text REtrieval Conference
+(TREC 1994). Gaithersburg, USA, November 1994.
+*/
+type BM25Similarity struct {
+	k1 float32
+	b  float32
+	// True if overlap tokens (tokens with a position of increment of
+	// zero) are discounted from the document's length.
+	discountOverlaps bool
+}
+
+/*
+BM25 with these default values:
+
+	k1 = 1.2
+	b = 0.75
+*/
+func NewBM25Similarity() *BM25Similarity {
+	return NewBM25SimilarityWithParams(1.2, 0.75)
+}
+
+/*
+BM25 with the supplied parameter values. k1 controls non-linear term
+frequency normalization (saturation); b controls to what degree
+document length normalizes tf values.
+*/
+func NewBM25SimilarityWithParams(k1, b float32) *BM25Similarity {
+	return &BM25Similarity{k1: k1, b: b, discountOverlaps: true}
+}
+
+/* Implemented as log(1 + (numDocs - docFreq + 0.5)/(docFreq + 0.5)). */
+func (sim *BM25Similarity) idf(docFreq, numDocs int64) float32 {
+	return float32(math.Log(1 + (float64(numDocs-docFreq)+0.5)/(float64(docFreq)+0.5)))
+}
+
+/* Implemented as 1 / (distance + 1). */
+func (sim *BM25Similarity) sloppyFreq(distance int) float32 {
+	return 1 / float32(distance+1)
+}
+
+/*
+The default implementation computes the average as sumTotalTermFreq /
+maxDoc, or returns 1 if the index does not store sumTotalTermFreq.
+*/
+func (sim *BM25Similarity) avgFieldLength(collectionStats CollectionStatistics) float32 {
+	sumTotalTermFreq := collectionStats.sumTotalTermFreq
+	if sumTotalTermFreq <= 0 {
+		return 1 // field does not exist, or stat is unsupported
+	}
+	return float32(float64(sumTotalTermFreq) / float64(collectionStats.maxDoc))
+}
+
+/*
+The default implementation encodes boost / sqrt(length) with
+SmallFloat.floatToByte315(). This is compatible with Lucene's default
+implementation. If you change this, then you should change
+decodeNormValue() to match.
+*/
+func (sim *BM25Similarity) encodeNormValue(boost float32, fieldLength int) int64 {
+	return int64(util.FloatToByte315(boost / float32(math.Sqrt(float64(fieldLength)))))
+}
+
+/*
+The default implementation returns 1 / f^2 where f is
+SmallFloat.byte315ToFloat().
+*/
+func (sim *BM25Similarity) decodeNormValue(norm int64) float32 {
+	return bm25NormTable[int(norm&0xff)] // & 0xFF maps negative bytes to positive above 127
+}
+
+/*
+Sets whether overlap tokens (Tokens with 0 position increment) are
+ignored when computing norm. By default this is true, meaning overlap
+tokens do not count when computing norms.
+*/
+func (sim *BM25Similarity) SetDiscountOverlaps(v bool) {
+	sim.discountOverlaps = v
+}
+
+/* Returns true if overlap tokens are discounted from the document's length. */
+func (sim *BM25Similarity) DiscountOverlaps() bool {
+	return sim.discountOverlaps
+}
+
+func (sim *BM25Similarity) ComputeNorm(state *index.FieldInvertState) int64 {
+	numTerms := state.Length()
+	if sim.discountOverlaps {
+		numTerms -= state.NumOverlap()
+	}
+	return sim.encodeNormValue(state.Boost(), numTerms)
+}
+
+/* Implemented as overlap / maxOverlap. */
+func (sim *BM25Similarity) Coord(overlap, maxOverlap int) float32 {
+	return float32(overlap) / float32(maxOverlap)
+}
+
+/* BM25 doesn't normalize queries, so this returns 1. */
+func (sim *BM25Similarity) QueryNorm(valueForNormalization float32) float32 {
+	return 1
+}
+
+/*
+Computes a score factor for a simple term and returns an explanation
+for that score factor.
+
+The default implementation uses:
+
+	idf(docFreq, maxDoc)
+*/
+func (sim *BM25Similarity) idfExplainTerm(collectionStats CollectionStatistics,
+	termStats TermStatistics) Explanation {
+
+	df, max := termStats.DocFreq, collectionStats.maxDoc
+	idf := sim.idf(df, max)
+	return NewExplanation(idf, fmt.Sprintf("idf(docFreq=%v, maxDocs=%v)", df, max))
+}
+
+/*
+Computes a score factor for a phrase.
+
+The default implementation sums the idf factor for each term in the
+phrase.
+*/
+func (sim *BM25Similarity) idfExplainPhrase(collectionStats CollectionStatistics,
+	termStats []TermStatistics) Explanation {
+
+	exp := NewExplanation(0, "idf(), sum of:")
+	for _, stat := range termStats {
+		termIdf := sim.idfExplainTerm(collectionStats, stat)
+		exp.AddDetail(termIdf)
+		exp.value += termIdf.Value()
+	}
+	return exp
+}
+
+func (sim *BM25Similarity) computeWeight(queryBoost float32,
+	collectionStats CollectionStatistics, termStats ...TermStatistics) SimWeight {
+
+	var idf Explanation
+	if len(termStats) == 1 {
+		idf = sim.idfExplainTerm(collectionStats, termStats[0])
+	} else {
+		idf = sim.idfExplainPhrase(collectionStats, termStats)
+	}
+
+	avgdl := sim.avgFieldLength(collectionStats)
+
+	// compute freq-independent part of bm25 equation across all norm values
+	cache := make([]float32, 256)
+	for i := range cache {
+		cache[i] = sim.k1 * ((1 - sim.b) + sim.b*sim.decodeNormValue(int64(i))/avgdl)
+	}
+	return newBM25Stats(collectionStats.field, idf, queryBoost, avgdl, cache)
+}
+
+func (sim *BM25Similarity) simScorer(stats SimWeight, ctx *index.AtomicReaderContext) (SimScorer, error) {
+	bm25stats := stats.(*bm25Stats)
+	norms, err := ctx.Reader().(index.AtomicReader).NormValues(bm25stats.field)
+	if err != nil {
+		return nil, err
+	}
+	return newBM25DocScorer(sim, bm25stats, norms), nil
+}
+
+type bm25DocScorer struct {
+	owner       *BM25Similarity
+	stats       *bm25Stats
+	weightValue float32 // boost * idf * (k1 + 1)
+	norms       NumericDocValues
+	cache       []float32
+}
+
+func newBM25DocScorer(owner *BM25Similarity, stats *bm25Stats,
+	norms NumericDocValues) *bm25DocScorer {
+
+	return &bm25DocScorer{
+		owner:       owner,
+		stats:       stats,
+		weightValue: stats.weight * (owner.k1 + 1),
+		norms:       norms,
+		cache:       stats.cache,
+	}
+}
+
+func (s *bm25DocScorer) Score(doc int, freq float32) float32 {
+	// if there are no norms, we act as if b=0
+	norm := s.owner.k1
+	if s.norms != nil {
+		norm = s.cache[int(s.norms(doc)&0xff)]
+	}
+	return s.weightValue * freq / (freq + norm)
+}
+
+func (s *bm25DocScorer) computeSlopFactor(distance int) float32 {
+	return s.owner.sloppyFreq(distance)
+}
+
+func (s *bm25DocScorer) explain(doc int, freq Explanation) Explanation {
+	return s.owner.explainScore(doc, freq, s.stats, s.norms)
+}
+
+/* Collection statistics for the BM25 model. */
+type bm25Stats struct {
+	// BM25's idf
+	idf Explanation
+	// The average document length.
+	avgdl float32
+	// query's inner boost
+	queryBoost float32
+	// query's outer boost (only for explain)
+	topLevelBoost float32
+	// weight (idf * boost)
+	weight float32
+	// field name, for pulling norms
+	field string
+	// precomputed norm[256] with k1 * ((1 - b) + b * dl / avgdl)
+	cache []float32
+}
+
+func newBM25Stats(field string, idf Explanation, queryBoost, avgdl float32,
+	cache []float32) *bm25Stats {
+
+	ans := &bm25Stats{
+		field:      field,
+		idf:        idf,
+		queryBoost: queryBoost,
+		avgdl:      avgdl,
+		cache:      cache,
+	}
+	ans.Normalize(1, 1)
+	return ans
+}
+
+func (stats *bm25Stats) ValueForNormalization() float32 {
+	// we return a TF-IDF like normalization to be nice, but we don't
+	// actually normalize ourselves.
+	queryWeight := stats.idf.Value() * stats.queryBoost
+	return queryWeight * queryWeight
+}
+
+func (stats *bm25Stats) Normalize(queryNorm, topLevelBoost float32) {
+	// we don't normalize with queryNorm at all, we just capture the
+	// top-level boost
+	stats.topLevelBoost = topLevelBoost
+	stats.weight = stats.idf.Value() * stats.queryBoost * topLevelBoost
+}
+
+func (sim *BM25Similarity) explainScore(doc int, freq Explanation,
+	stats *bm25Stats, norms NumericDocValues) Explanation {
+
+	result := NewExplanation(0, fmt.Sprintf("score(doc=%v,freq=%v), product of:", doc, freq.Value()))
+
+	boostExpl := NewExplanation(stats.queryBoost*stats.topLevelBoost, "boost")
+	if boostExpl.value != 1 {
+		result.AddDetail(boostExpl)
+	}
+
+	result.AddDetail(stats.idf)
+
+	tfNormExpl := NewExplanation(0, "tfNorm, computed from:")
+	tfNormExpl.AddDetail(freq)
+	tfNormExpl.AddDetail(NewExplanation(sim.k1, "parameter k1"))
+	f := freq.Value()
+	if norms == nil {
+		tfNormExpl.AddDetail(NewExplanation(0, "parameter b (norms omitted for field)"))
+		tfNormExpl.value = (f * (sim.k1 + 1)) / (f + sim.k1)
+	} else {
+		doclen := sim.decodeNormValue(norms(doc))
+		tfNormExpl.AddDetail(NewExplanation(sim.b, "parameter b"))
+		tfNormExpl.AddDetail(NewExplanation(stats.avgdl, "avgFieldLength"))
+		tfNormExpl.AddDetail(NewExplanation(doclen, "fieldLength"))
+		tfNormExpl.value = (f * (sim.k1 + 1)) /
+			(f + sim.k1*(1-sim.b+sim.b*doclen/stats.avgdl))
+	}
+	result.AddDetail(tfNormExpl)
+	result.value = boostExpl.value * stats.idf.Value() * tfNormExpl.value
+	return result
+}
+
+func (sim *BM25Similarity) String() string {
+	return fmt.Sprintf("BM25(k1=%v,b=%v)", sim.k1, sim.b)
+}
+
+/* Returns the k1 parameter. */
+func (sim *BM25Similarity) K1() float32 {
+	return sim.k1
+}
+
+/* Returns the b parameter. */
+func (sim *BM25Similarity) B() float32 {
+	return sim.b
+}
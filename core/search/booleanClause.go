@@ -39,3 +39,19 @@ func (c *BooleanClause) IsProhibited() bool {
 func (c *BooleanClause) IsRequired() bool {
 	return c.occur == MUST
 }
+
+func (c *BooleanClause) Query() Query {
+	return c.query
+}
+
+func (c *BooleanClause) SetQuery(query Query) {
+	c.query = query
+}
+
+func (c *BooleanClause) Occur() Occur {
+	return c.occur
+}
+
+func (c *BooleanClause) SetOccur(occur Occur) {
+	c.occur = occur
+}
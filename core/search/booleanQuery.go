@@ -2,12 +2,21 @@ package search
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/balzaczyy/golucene/core/index"
 	"github.com/balzaczyy/golucene/core/util"
 )
 
 const maxClauseCount = 1024
 
+/*
+Returns the maximum number of clauses permitted, 1024. A BooleanQuery
+refuses any more clauses than this.
+*/
+func MaxClauseCount() int {
+	return maxClauseCount
+}
+
 type BooleanQuery struct {
 	*AbstractQuery
 	clauses          []*BooleanClause
@@ -27,6 +36,37 @@ func NewBooleanQueryDisableCoord(disableCoord bool) *BooleanQuery {
 	return ans
 }
 
+/* Returns true iff Similarity.Coord() is disabled in scoring for this query instance. */
+func (q *BooleanQuery) IsCoordDisabled() bool {
+	return q.disableCoord
+}
+
+/*
+Specifies a minimum number of the optional BooleanClauses which must
+be satisfied.
+
+By default no optional clauses are necessary for a match (unless
+there are no required clauses). If this method is used, then the
+specified number of clauses is required.
+
+Use of this method is totally independent of specifying that any
+specific clauses are required (or prohibited). This number will only
+be compared against the number of matching optional clauses.
+*/
+func (q *BooleanQuery) SetMinimumNumberShouldMatch(min int) {
+	q.minNrShouldMatch = min
+}
+
+/* Gets the minimum number of the optional BooleanClauses which must be satisfied. */
+func (q *BooleanQuery) MinimumNumberShouldMatch() int {
+	return q.minNrShouldMatch
+}
+
+/* Returns the list of clauses in this query. */
+func (q *BooleanQuery) Clauses() []*BooleanClause {
+	return q.clauses
+}
+
 func (q *BooleanQuery) Add(query Query, occur Occur) {
 	q.AddClause(NewBooleanClause(query, occur))
 }
@@ -37,6 +77,7 @@ func (q *BooleanQuery) AddClause(clause *BooleanClause) {
 }
 
 type BooleanWeight struct {
+	*WeightImpl
 	owner        *BooleanQuery
 	similarity   Similarity
 	weights      []Weight
@@ -52,6 +93,7 @@ func newBooleanWeight(owner *BooleanQuery,
 		similarity:   searcher.similarity,
 		disableCoord: disableCoord,
 	}
+	w.WeightImpl = NewWeightImpl(w)
 	var subWeight Weight
 	for _, c := range owner.clauses {
 		if subWeight, err = c.query.CreateWeight(searcher); err != nil {
@@ -95,14 +137,92 @@ func (w *BooleanWeight) Normalize(norm, topLevelBoost float32) {
 }
 
 func (w *BooleanWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
-	panic("not implemented yet")
+	sumExpl := newEmptyComplexExplanation()
+	sumExpl.description = "sum of:"
+	coord := 0
+	var sum float32
+	fail := false
+	minShouldMatch := w.owner.minNrShouldMatch
+	shouldMatchCount := 0
+	for i, subWeight := range w.weights {
+		c := w.owner.clauses[i]
+		subScorer, err := subWeight.Scorer(context, context.Reader().(index.AtomicReader).LiveDocs())
+		if err != nil {
+			return nil, err
+		}
+		if subScorer == nil {
+			if c.IsRequired() {
+				fail = true
+				sumExpl.AddDetail(NewExplanation(0, fmt.Sprintf(
+					"no match on required clause (%v)", c.query.ToString(""))))
+			}
+			continue
+		}
+		e, err := subWeight.Explain(context, doc)
+		if err != nil {
+			return nil, err
+		}
+		if e.IsMatch() {
+			if !c.IsProhibited() {
+				sumExpl.AddDetail(e)
+				sum += e.Value()
+				coord++
+				if c.occur == SHOULD {
+					shouldMatchCount++
+				}
+			} else {
+				r := NewExplanation(0, fmt.Sprintf(
+					"match on prohibited clause (%v)", c.query.ToString("")))
+				r.AddDetail(e)
+				sumExpl.AddDetail(r)
+				fail = true
+			}
+		} else if c.IsRequired() {
+			r := NewExplanation(0, fmt.Sprintf(
+				"no match on required clause (%v)", c.query.ToString("")))
+			r.AddDetail(e)
+			sumExpl.AddDetail(r)
+			fail = true
+		}
+	}
+	if fail {
+		sumExpl.match = false
+		sumExpl.value = 0
+		sumExpl.description = "Failure to meet condition(s) of required/prohibited clause(s)"
+		return sumExpl, nil
+	}
+	if shouldMatchCount < minShouldMatch {
+		sumExpl.match = false
+		sumExpl.value = 0
+		sumExpl.description = fmt.Sprintf(
+			"Failure to match minimum number of optional clauses: %v", minShouldMatch)
+		return sumExpl, nil
+	}
+
+	sumExpl.match = 0 < coord
+	sumExpl.value = sum
+
+	coordFactor := float32(1)
+	if !w.disableCoord {
+		coordFactor = w.coord(coord, w.maxCoord)
+	}
+	if coordFactor == 1 {
+		return sumExpl, nil // eliminate wrapper
+	}
+	result := NewComplexExplanation(sumExpl.IsMatch(), sum*coordFactor, "product of:")
+	result.AddDetail(sumExpl)
+	result.AddDetail(NewExplanation(coordFactor, fmt.Sprintf("coord(%v/%v)", coord, w.maxCoord)))
+	return result, nil
 }
 
 func (w *BooleanWeight) BulkScorer(context *index.AtomicReaderContext,
 	scoreDocsInOrder bool, acceptDocs util.Bits) (BulkScorer, error) {
 
 	if scoreDocsInOrder || w.owner.minNrShouldMatch > 1 {
-		panic("not implemented yet")
+		// TODO: (LUCENE-4872) in some cases BooleanScorer may be faster
+		// for minNrShouldMatch but the same is even true of pure
+		// conjunctions...
+		return w.WeightImpl.BulkScorer(context, scoreDocsInOrder, acceptDocs)
 	}
 
 	var prohibited, optional []BulkScorer
@@ -117,7 +237,9 @@ func (w *BooleanWeight) BulkScorer(context *index.AtomicReaderContext,
 				return nil, nil
 			}
 		} else if c.IsRequired() {
-			panic("not implemented yet")
+			// TODO: there are some cases where BooleanScorer would handle
+			// conjunctions faster than BooleanScorer2...
+			return w.WeightImpl.BulkScorer(context, scoreDocsInOrder, acceptDocs)
 		} else if c.IsProhibited() {
 			prohibited = append(prohibited, subScorer)
 		} else {
@@ -128,6 +250,161 @@ func (w *BooleanWeight) BulkScorer(context *index.AtomicReaderContext,
 	return newBooleanScorer(w, w.disableCoord, w.owner.minNrShouldMatch, optional, prohibited, w.maxCoord), nil
 }
 
+func (w *BooleanWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	// initially the user provided value, but if minNrShouldMatch ==
+	// len(optional), we will optimize and move these to required,
+	// making this 0
+	minShouldMatch := w.owner.minNrShouldMatch
+
+	var required, prohibited, optional []Scorer
+	for i, subWeight := range w.weights {
+		c := w.owner.clauses[i]
+		subScorer, err := subWeight.Scorer(context, acceptDocs)
+		if err != nil {
+			return nil, err
+		}
+		if subScorer == nil {
+			if c.IsRequired() {
+				return nil, nil
+			}
+		} else if c.IsRequired() {
+			required = append(required, subScorer)
+		} else if c.IsProhibited() {
+			prohibited = append(prohibited, subScorer)
+		} else {
+			optional = append(optional, subScorer)
+		}
+	}
+
+	// scorer simplifications:
+
+	if len(optional) == minShouldMatch {
+		// any optional clauses are in fact required
+		required = append(required, optional...)
+		optional = nil
+		minShouldMatch = 0
+	}
+
+	if len(required) == 0 && len(optional) == 0 {
+		// no required and optional clauses.
+		return nil, nil
+	} else if len(optional) < minShouldMatch {
+		// either >1 req scorer, or there are 0 req scorers and at least 1
+		// optional scorer. Therefore if there are not enough optional
+		// scorers no documents will be matched by the query
+		return nil, nil
+	}
+
+	// three cases: conjunction, disjunction, or mix
+
+	// pure conjunction
+	if len(optional) == 0 {
+		return w.excl(w.req(required, w.disableCoord), prohibited), nil
+	}
+
+	// pure disjunction
+	if len(required) == 0 {
+		return w.excl(w.opt(optional, minShouldMatch, w.disableCoord), prohibited), nil
+	}
+
+	// conjunction-disjunction mix:
+	// we create the required and optional pieces with coord disabled,
+	// and then combine the two: if minNrShouldMatch > 0, then its a
+	// conjunction: because the optional side must match. otherwise its
+	// required + optional, factoring the number of optional terms into
+	// the coord calculation
+
+	req := w.excl(w.req(required, true), prohibited)
+	opt := w.opt(optional, minShouldMatch, true)
+
+	// TODO: clean this up: its horrible
+	if w.disableCoord {
+		if minShouldMatch > 0 {
+			return newConjunctionScorer(w, []Scorer{req, opt}, 1), nil
+		}
+		return newReqOptSumScorer(req, opt), nil
+	} else if len(optional) == 1 {
+		if minShouldMatch > 0 {
+			return newConjunctionScorer(w, []Scorer{req, opt},
+				w.coord(len(required)+1, w.maxCoord)), nil
+		}
+		coordReq := w.coord(len(required), w.maxCoord)
+		coordBoth := w.coord(len(required)+1, w.maxCoord)
+		return newReqSingleOptScorer(req, opt, coordReq, coordBoth), nil
+	} else {
+		if minShouldMatch > 0 {
+			return newCoordinatingConjunctionScorer(w, w.coords(), req, len(required), opt), nil
+		}
+		return newReqMultiOptScorer(req, opt, len(required), w.coords()), nil
+	}
+}
+
+func (w *BooleanWeight) req(required []Scorer, disableCoord bool) Scorer {
+	if len(required) == 1 {
+		req := required[0]
+		if !disableCoord && w.maxCoord > 1 {
+			return newBoostedScorer(req, w.coord(1, w.maxCoord))
+		}
+		return req
+	}
+	coord := float32(1)
+	if !disableCoord {
+		coord = w.coord(len(required), w.maxCoord)
+	}
+	return newConjunctionScorer(w, required, coord)
+}
+
+func (w *BooleanWeight) excl(main Scorer, prohibited []Scorer) Scorer {
+	switch len(prohibited) {
+	case 0:
+		return main
+	case 1:
+		return newReqExclScorer(main, prohibited[0])
+	default:
+		coords := make([]float32, len(prohibited)+1)
+		for i, _ := range coords {
+			coords[i] = 1
+		}
+		return newReqExclScorer(main, newDisjunctionSumScorer(w, prohibited, coords))
+	}
+}
+
+func (w *BooleanWeight) opt(optional []Scorer, minShouldMatch int,
+	disableCoord bool) Scorer {
+
+	if len(optional) == 1 {
+		opt := optional[0]
+		if !disableCoord && w.maxCoord > 1 {
+			return newBoostedScorer(opt, w.coord(1, w.maxCoord))
+		}
+		return opt
+	}
+
+	var coords []float32
+	if disableCoord {
+		coords = make([]float32, len(optional)+1)
+		for i, _ := range coords {
+			coords[i] = 1
+		}
+	} else {
+		coords = w.coords()
+	}
+	if minShouldMatch > 1 {
+		return newMinShouldMatchSumScorer(w, optional, minShouldMatch, coords)
+	}
+	return newDisjunctionSumScorer(w, optional, coords)
+}
+
+func (w *BooleanWeight) coords() []float32 {
+	coords := make([]float32, w.maxCoord+1)
+	for i := 1; i < len(coords); i++ {
+		coords[i] = w.coord(i, w.maxCoord)
+	}
+	return coords
+}
+
 func (w *BooleanWeight) IsScoresDocsOutOfOrder() bool {
 	if w.owner.minNrShouldMatch > 1 {
 		// BS2 (in-order) will be used by scorer()
@@ -151,33 +428,65 @@ func (w *BooleanWeight) IsScoresDocsOutOfOrder() bool {
 	return true
 }
 
+/* Returns a shallow copy of this query, with its own clause list. */
+func (q *BooleanQuery) Clone() *BooleanQuery {
+	ans := NewBooleanQueryDisableCoord(q.disableCoord)
+	ans.clauses = make([]*BooleanClause, len(q.clauses))
+	copy(ans.clauses, q.clauses)
+	ans.minNrShouldMatch = q.minNrShouldMatch
+	ans.SetBoost(q.Boost())
+	return ans
+}
+
 func (q *BooleanQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
 	return newBooleanWeight(q, searcher, q.disableCoord)
 }
 
-func (q *BooleanQuery) Rewrite(reader index.IndexReader) Query {
-	if q.minNrShouldMatch == 0 && len(q.clauses) == 1 {
-		panic("not implemented yet")
+func (q *BooleanQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	if q.minNrShouldMatch == 0 && len(q.clauses) == 1 { // optimize 1-clause queries
+		if c := q.clauses[0]; !c.IsProhibited() { // just return clause
+			query, err := c.query.Rewrite(reader) // rewrite first
+			if err != nil {
+				return nil, err
+			}
+
+			if q.Boost() == 1 {
+				return query, nil
+			}
+			// Since the BooleanQuery only has 1 clause, the BooleanQuery
+			// will be written out. Therefore the rewritten Query's boost
+			// must incorporate both the clause's boost, and the boost of
+			// the BooleanQuery itself. There is no generic Query clone, so
+			// we only do so when the rewrite produced a new instance.
+			if query != c.query {
+				query.SetBoost(q.Boost() * query.Boost())
+				return query, nil
+			}
+		}
 	}
 
 	var clone *BooleanQuery // recursively rewrite
-	for _, c := range q.clauses {
-		if query := c.query.Rewrite(reader); query != c.query {
+	for i, c := range q.clauses {
+		query, err := c.query.Rewrite(reader)
+		if err != nil {
+			return nil, err
+		}
+		if query != c.query {
 			// clause rewrote: must clone
 			if clone == nil {
 				// The BooleanQuery clone is lazily initialized so only
 				// initialize it if a rewritten clause differs from the
 				// original clause (and hasn't been initialized already). If
 				// nothing difers, the clone isn't needlessly created
-				panic("not implemented yet")
+				clone = q.Clone()
 			}
-			panic("not implemented yet")
+			clone.clauses[i] = NewBooleanClause(query, c.occur)
 		}
 	}
 	if clone != nil {
-		return clone // some clauses rewrote
+		return clone, nil // some clauses rewrote
 	}
-	return q
+	return q, nil
 }
 
 func (q *BooleanQuery) ToString(field string) string {
@@ -216,11 +525,11 @@ func (q *BooleanQuery) ToString(field string) string {
 	}
 
 	if q.minNrShouldMatch > 0 {
-		panic("not implemented yet")
+		fmt.Fprintf(&buf, "~%v", q.minNrShouldMatch)
 	}
 
 	if q.Boost() != 1 {
-		panic("not implemented yet")
+		fmt.Fprintf(&buf, "^%v", q.Boost())
 	}
 
 	return buf.String()
@@ -1,6 +1,8 @@
 package search
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/balzaczyy/golucene/core/index"
 )
 
@@ -46,9 +48,9 @@ func (c *BooleanScorerCollector) Collect(doc int) (err error) {
 	return nil
 }
 
-func (c *BooleanScorerCollector) SetNextReader(*index.AtomicReaderContext) {}
-func (c *BooleanScorerCollector) SetScorer(scorer Scorer)                  { c.scorer = scorer }
-func (c *BooleanScorerCollector) AcceptsDocsOutOfOrder() bool              { return true }
+func (c *BooleanScorerCollector) SetNextReader(*index.AtomicReaderContext) error { return nil }
+func (c *BooleanScorerCollector) SetScorer(scorer Scorer)                        { c.scorer = scorer }
+func (c *BooleanScorerCollector) AcceptsDocsOutOfOrder() bool                    { return true }
 
 type Bucket struct {
 	doc   int // tells if bucket is valid
@@ -168,8 +170,15 @@ func (s *BooleanScorer) ScoreAndCollectUpto(collector Collector, max int) (more
 			// check prohibited & required
 			if (s.current.bits & PROHIBITED_MASK) == 0 {
 
+				// NOTE: we never embed a BooleanScorer inside another today,
+				// so max is always NO_MORE_DOCS... but in theory an outside
+				// app could pass a different max so we must check it:
 				if s.current.doc >= max {
-					panic("not implemented yet")
+					tmp := s.current
+					s.current = s.current.next
+					tmp.next = s.bucketTable.first
+					s.bucketTable.first = tmp
+					continue
 				}
 
 				if s.current.coord >= s.minNrShouldMatch {
@@ -186,7 +195,9 @@ func (s *BooleanScorer) ScoreAndCollectUpto(collector Collector, max int) (more
 		}
 
 		if s.bucketTable.first != nil {
-			panic("not implemented yet")
+			s.current = s.bucketTable.first
+			s.bucketTable.first = s.current.next
+			return true, nil
 		}
 
 		// refill the queue
@@ -210,7 +221,13 @@ func (s *BooleanScorer) ScoreAndCollectUpto(collector Collector, max int) (more
 }
 
 func (s *BooleanScorer) String() string {
-	panic("not implemented yet")
+	var buf bytes.Buffer
+	buf.WriteString("boolean(")
+	for sub := s.scorers; sub != nil; sub = sub.next {
+		fmt.Fprintf(&buf, "%v ", sub.scorer)
+	}
+	buf.WriteString(")")
+	return buf.String()
 }
 
 type FakeScorer struct {
@@ -234,7 +251,7 @@ func (s *FakeScorer) DocId() int               { return s.doc }
 func (s *FakeScorer) Freq() (int, error)       { return s.freq, nil }
 func (s *FakeScorer) NextDoc() (int, error)    { panic("FakeScorer doesn't support nextDoc()") }
 func (s *FakeScorer) Score() (float32, error)  { return s.score, nil }
+func (s *FakeScorer) Cost() int64              { return 1 }
 
-// func (s *FakeScorer) Cost() int64             { return 1 }
 // func (s *FakeScorer) Weight() Weight          { panic("not supported") }
 // func (s *FakeScorer) Children() []ChildScorer { panic("not supported") }
@@ -0,0 +1,136 @@
+package search
+
+// search/BooleanTopLevelScorers.java
+
+/*
+Internal document-at-a-time scorers used to deal with stupid coord()
+computation.
+*/
+
+/*
+Used when there is more than one scorer in a query, but a segment
+only had one non-nil scorer. This just wraps that scorer directly to
+factor in coord().
+*/
+type BoostedScorer struct {
+	Scorer
+	boost float32
+}
+
+func newBoostedScorer(in Scorer, boost float32) *BoostedScorer {
+	return &BoostedScorer{in, boost}
+}
+
+func (s *BoostedScorer) Score() (float32, error) {
+	score, err := s.Scorer.Score()
+	return score * s.boost, err
+}
+
+/*
+Used when there are mandatory clauses with one optional clause: we
+compute coord based on whether the optional clause matched or not.
+*/
+type ReqSingleOptScorer struct {
+	*ReqOptSumScorer
+	// coord factor if just the required part matches
+	coordReq float32
+	// coord factor if both required and optional part matches
+	coordBoth float32
+}
+
+func newReqSingleOptScorer(reqScorer, optScorer Scorer,
+	coordReq, coordBoth float32) *ReqSingleOptScorer {
+
+	return &ReqSingleOptScorer{
+		ReqOptSumScorer: newReqOptSumScorer(reqScorer, optScorer),
+		coordReq:        coordReq,
+		coordBoth:       coordBoth,
+	}
+}
+
+func (s *ReqSingleOptScorer) Score() (float32, error) {
+	reqScore, optScore, optMatched, err := s.scores()
+	if err != nil {
+		return 0, err
+	}
+	if optMatched {
+		return (reqScore + optScore) * s.coordBoth, nil
+	}
+	return reqScore * s.coordReq, nil
+}
+
+/*
+Used when there are mandatory clauses with optional clauses: we
+compute coord based on how many optional subscorers matched (freq).
+*/
+type ReqMultiOptScorer struct {
+	*ReqOptSumScorer
+	requiredCount int
+	coords        []float32
+}
+
+func newReqMultiOptScorer(reqScorer, optScorer Scorer,
+	requiredCount int, coords []float32) *ReqMultiOptScorer {
+
+	return &ReqMultiOptScorer{
+		ReqOptSumScorer: newReqOptSumScorer(reqScorer, optScorer),
+		requiredCount:   requiredCount,
+		coords:          coords,
+	}
+}
+
+func (s *ReqMultiOptScorer) Score() (float32, error) {
+	reqScore, optScore, optMatched, err := s.scores()
+	if err != nil {
+		return 0, err
+	}
+	if optMatched {
+		freq, err := s.optScorer.Freq()
+		if err != nil {
+			return 0, err
+		}
+		return (reqScore + optScore) * s.coords[s.requiredCount+freq], nil
+	}
+	return reqScore * s.coords[s.requiredCount], nil
+}
+
+/*
+Used when there are mandatory clauses with minShouldMatch > 0: the
+optional side must match, so this is a conjunction, but coord is
+computed based on how many optional subscorers matched (freq).
+*/
+type CoordinatingConjunctionScorer struct {
+	*ConjunctionScorer
+	coords   []float32
+	reqCount int
+	req      Scorer
+	opt      Scorer
+}
+
+func newCoordinatingConjunctionScorer(weight Weight, coords []float32,
+	req Scorer, reqCount int, opt Scorer) *CoordinatingConjunctionScorer {
+
+	return &CoordinatingConjunctionScorer{
+		ConjunctionScorer: newConjunctionScorer(weight, []Scorer{req, opt}, 1),
+		coords:            coords,
+		reqCount:          reqCount,
+		req:               req,
+		opt:               opt,
+	}
+}
+
+func (s *CoordinatingConjunctionScorer) Score() (float32, error) {
+	reqScore, err := s.req.Score()
+	if err != nil {
+		return 0, err
+	}
+	optScore, err := s.opt.Score()
+	if err != nil {
+		return 0, err
+	}
+	freq, err := s.opt.Freq()
+	if err != nil {
+		return 0, err
+	}
+	return (reqScore + optScore) * s.coords[s.reqCount+freq], nil
+}
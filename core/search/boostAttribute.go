@@ -0,0 +1,73 @@
+package search
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/BoostAttribute.java
+
+/*
+Add this Attribute to a TermsEnum returned by
+MultiTermQuerySPI.TermsEnum() and update the boost on each returned
+term. This enables to control the boost factor for each matching
+term in SCORING_BOOLEAN_QUERY_REWRITE or
+TopTermsScoringBooleanQueryRewrite mode. FuzzyQuery is using this to
+take the edit distance into account.
+
+Please note: This attribute is intended to be added only by the
+TermsEnum to itself in its constructor and consumed by the
+MultiTermQuery.RewriteMethod.
+*/
+type BoostAttribute interface {
+	util.Attribute
+	// Sets the boost in this attribute
+	SetBoost(boost float32)
+	// Retrieves the boost, default is 1.0
+	Boost() float32
+}
+
+// search/BoostAttributeImpl.java
+
+/* Implementation class for BoostAttribute. */
+type BoostAttributeImpl struct {
+	boost float32
+}
+
+func newBoostAttributeImpl() *BoostAttributeImpl {
+	return &BoostAttributeImpl{1.0}
+}
+
+func (a *BoostAttributeImpl) Interfaces() []string {
+	return []string{"BoostAttribute"}
+}
+
+func (a *BoostAttributeImpl) SetBoost(boost float32) {
+	a.boost = boost
+}
+
+func (a *BoostAttributeImpl) Boost() float32 {
+	return a.boost
+}
+
+func (a *BoostAttributeImpl) Clone() util.AttributeImpl {
+	return &BoostAttributeImpl{a.boost}
+}
+
+func (a *BoostAttributeImpl) Clear() {
+	a.boost = 1.0
+}
+
+func (a *BoostAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(BoostAttribute).SetBoost(a.boost)
+}
+
+/*
+Returns the BoostAttribute of the TermsEnum, adding a default one if
+the TermsEnum has none yet.
+*/
+func boostAttribute(atts *util.AttributeSource) BoostAttribute {
+	if !atts.Has("BoostAttribute") {
+		atts.AddImpl(newBoostAttributeImpl())
+	}
+	return atts.Get("BoostAttribute").(BoostAttribute)
+}
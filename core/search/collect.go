@@ -2,6 +2,7 @@ package search
 
 import (
 	"container/heap"
+	"errors"
 	"fmt"
 	"github.com/balzaczyy/golucene/core/index"
 	"math"
@@ -56,13 +57,186 @@ type TopDocs struct {
 	maxScore  float64
 }
 
+/* Returns the maximum score value encountered, or NaN if not tracked. */
+func (docs TopDocs) MaxScore() float64 {
+	return docs.maxScore
+}
+
+/*
+Returns the index of the TopDocs this hit was taken from by
+MergeTopDocs() or MergeTopFieldDocs(), or -1 if the hit wasn't
+merged.
+*/
+func (d *ScoreDoc) ShardIndex() int {
+	return d.shardIndex
+}
+
+// search/TopDocs.java#merge
+
+/* Refers to one hit in one of the merged TopDocs. */
+type shardRef struct {
+	shardIndex int // which shard (index into shardHits)
+	hitIndex   int // which hit within the shard
+}
+
+/*
+Returns a new TopDocs, containing topN results across the provided
+TopDocs, sorting by score. Each TopDocs instance must be sorted by
+score, as returned by TopScoreDocCollector. The ShardIndex() of each
+returned hit is the index of the TopDocs it came from, and hits of
+the same score are ordered by ShardIndex() first.
+*/
+func MergeTopDocs(topN int, shardHits []TopDocs) TopDocs {
+	return mergeTopDocs(topN, shardHits, true)
+}
+
+/*
+Merges the TopDocs as MergeTopDocs() does. If setShardIndex is false,
+the hits are returned as is, so hits merged from the slices of a
+single index look the same as those of a serial search.
+*/
+func mergeTopDocs(topN int, shardHits []TopDocs, setShardIndex bool) TopDocs {
+	merged, _ := mergeShardHits(topN, shardHits, setShardIndex, func(first, second *shardRef) int {
+		firstScore := shardHits[first.shardIndex].ScoreDocs[first.hitIndex].Score
+		secondScore := shardHits[second.shardIndex].ScoreDocs[second.hitIndex].Score
+		return compareFloat32(secondScore, firstScore)
+	})
+	return merged
+}
+
+/*
+Returns a new TopFieldDocs, containing topN results across the
+provided TopFieldDocs, sorting by sort. Each TopFieldDocs instance
+must have been sorted by the same Sort, with its fields filled in,
+as returned by TopFieldCollector with fillFields set. The
+ShardIndex() of each returned hit is the index of the TopFieldDocs
+it came from.
+*/
+func MergeTopFieldDocs(sort *Sort, topN int, shardHits []TopFieldDocs) TopFieldDocs {
+	return mergeTopFieldDocs(sort, topN, shardHits, true)
+}
+
+/*
+Merges the TopFieldDocs as MergeTopFieldDocs() does; see
+mergeTopDocs() for setShardIndex.
+*/
+func mergeTopFieldDocs(sort *Sort, topN int, shardHits []TopFieldDocs, setShardIndex bool) TopFieldDocs {
+	assert2(sort != nil, "Sort must not be nil")
+	// the comparators only compare the given values, so need no slots
+	comparators := make([]FieldComparator, len(sort.fields))
+	reverseMul := make([]int, len(sort.fields))
+	for i, field := range sort.fields {
+		comparators[i] = field.comparator(1, i)
+		if field.reverse {
+			reverseMul[i] = -1
+		} else {
+			reverseMul[i] = 1
+		}
+	}
+
+	topDocs := make([]TopDocs, len(shardHits))
+	for i, shard := range shardHits {
+		assert2(len(shard.FieldDocs) == len(shard.ScoreDocs), "shard %v has no FieldDocs for its hits", i)
+		topDocs[i] = shard.TopDocs
+	}
+	merged, refs := mergeShardHits(topN, topDocs, setShardIndex, func(first, second *shardRef) int {
+		firstFields := shardHits[first.shardIndex].FieldDocs[first.hitIndex].Fields
+		secondFields := shardHits[second.shardIndex].FieldDocs[second.hitIndex].Fields
+		assert2(len(firstFields) == len(comparators) && len(secondFields) == len(comparators),
+			"FieldDoc.Fields must match the sort; pass fillFields=true to TopFieldCollector")
+		for i, comparator := range comparators {
+			if c := reverseMul[i] * comparator.CompareValues(firstFields[i], secondFields[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	fieldDocs := make([]*FieldDoc, len(refs))
+	for i, ref := range refs {
+		fields := shardHits[ref.shardIndex].FieldDocs[ref.hitIndex].Fields
+		fieldDocs[i] = &FieldDoc{merged.ScoreDocs[i], fields}
+	}
+	return TopFieldDocs{merged, sort.fields, fieldDocs}
+}
+
+/*
+Merges the hits of the shards, which must each be sorted by compare.
+Hits comparing equal are ordered by shard first, then as their shard
+ordered them. Returns the merged hits, and where each one came from.
+*/
+func mergeShardHits(topN int, shardHits []TopDocs, setShardIndex bool,
+	compare func(first, second *shardRef) int) (TopDocs, []shardRef) {
+
+	pq := &PriorityQueue{}
+	pq.less = func(i, j int) bool {
+		first, second := pq.items[i].(*shardRef), pq.items[j].(*shardRef)
+		if c := compare(first, second); c != 0 {
+			return c < 0
+		}
+		// Tie break: earlier shard wins
+		if first.shardIndex != second.shardIndex {
+			return first.shardIndex < second.shardIndex
+		}
+		// Tie break in same shard: resolve however the shard had resolved it
+		return first.hitIndex < second.hitIndex
+	}
+
+	totalHitCount, availHitCount := 0, 0
+	maxScore := -math.MaxFloat64
+	for i, shard := range shardHits {
+		// totalHits can be non-zero even if no hits were collected, when
+		// SearchAfter() was used
+		totalHitCount += shard.TotalHits
+		if len(shard.ScoreDocs) > 0 {
+			availHitCount += len(shard.ScoreDocs)
+			heap.Push(pq, &shardRef{i, 0})
+			maxScore = math.Max(maxScore, shard.maxScore)
+		}
+	}
+	if availHitCount == 0 {
+		maxScore = math.NaN()
+	}
+
+	if availHitCount < topN {
+		topN = availHitCount
+	}
+	hits := make([]*ScoreDoc, 0, topN)
+	refs := make([]shardRef, 0, topN)
+	for len(hits) < topN {
+		ref := pq.items[0].(*shardRef)
+		hit := shardHits[ref.shardIndex].ScoreDocs[ref.hitIndex]
+		if setShardIndex {
+			hit = newShardedScoreDoc(hit.Doc, hit.Score, ref.shardIndex)
+		}
+		hits = append(hits, hit)
+		refs = append(refs, *ref)
+		if ref.hitIndex++; ref.hitIndex < len(shardHits[ref.shardIndex].ScoreDocs) {
+			// Not done with this shard: re-sort it by its next hit
+			pq.updateTop()
+		} else {
+			heap.Pop(pq)
+		}
+	}
+	return TopDocs{totalHitCount, hits, maxScore}, refs
+}
+
 type Collector interface {
 	SetScorer(s Scorer)
 	Collect(doc int) error
-	SetNextReader(ctx *index.AtomicReaderContext)
+	SetNextReader(ctx *index.AtomicReaderContext) error
 	AcceptsDocsOutOfOrder() bool
 }
 
+// search/CollectionTerminatedException.java
+
+/*
+Returned by Collector.Collect() or Collector.SetNextReader() to stop
+collecting the current segment. IndexSearcher swallows it and moves
+on to the next segment, so the search itself still succeeds.
+*/
+var ErrCollectionTerminated = errors.New("collection terminated")
+
 // search/TopDocsCollector.java
 /**
  * A base class for all collectors that return a {@link TopDocs} output. This
@@ -152,14 +326,14 @@ func (c *abstractTopDocsCollector) TopDocs() TopDocs {
 	// In case pq was populated with sentinel values, there might be less
 	// results than pq.size(). Therefore return all results until either
 	// pq.size() or totalHits.
-	return c.TopDocsRange(0, c.topDocsSize())
+	return c.TopDocsRange(0, c.TopDocsCreator.topDocsSize())
 }
 
 func (c *abstractTopDocsCollector) TopDocsRange(start, howMany int) TopDocs {
 	// In case pq was populated with sentinel values, there might be less
 	// results than pq.size(). Therefore return all results until either
 	// pq.size() or totalHits.
-	size := c.topDocsSize()
+	size := c.TopDocsCreator.topDocsSize()
 
 	// Don't bother to throw an exception, just return an empty TopDocs in case
 	// the parameters are invalid or out of range.
@@ -185,7 +359,7 @@ func (c *abstractTopDocsCollector) TopDocsRange(start, howMany int) TopDocs {
 	}
 
 	// Get the requested results from pq.
-	c.populateResults(results, howMany)
+	c.TopDocsCreator.populateResults(results, howMany)
 
 	return c.newTopDocs(results, start)
 }
@@ -243,8 +417,9 @@ func (c *TopScoreDocCollector) newTopDocs(results []*ScoreDoc, start int) TopDoc
 	return TopDocs{c.TotalHits, results, maxScore}
 }
 
-func (c *TopScoreDocCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+func (c *TopScoreDocCollector) SetNextReader(ctx *index.AtomicReaderContext) error {
 	c.docBase = ctx.DocBase
+	return nil
 }
 
 func (c *TopScoreDocCollector) SetScorer(scorer Scorer) {
@@ -260,13 +435,12 @@ func NewTopScoreDocCollector(numHits int, after *ScoreDoc, docsScoredInOrder boo
 		if after == nil {
 			return newInOrderTopScoreDocCollector(numHits)
 		}
-		panic("not implemented yet")
-		// TODO support paging
+		return newInOrderPagingScoreDocCollector(after, numHits)
 	} else {
 		if after == nil {
 			return newOutOfOrderTopScoreDocCollector(numHits)
 		}
-		panic("not implemented yet")
+		return newOutOfOrderPagingScoreDocCollector(after, numHits)
 	}
 }
 
@@ -344,3 +518,150 @@ func (c *OutOfOrderTopScoreDocCollector) Collect(doc int) (err error) {
 func (c *OutOfOrderTopScoreDocCollector) AcceptsDocsOutOfOrder() bool {
 	return true
 }
+
+// Assumes docs are scored in order.
+type InOrderPagingScoreDocCollector struct {
+	*TopScoreDocCollector
+	after *ScoreDoc
+	// this is always after.doc - docBase, to save an add when score == after.score
+	afterDoc      int
+	collectedHits int
+}
+
+func newInOrderPagingScoreDocCollector(after *ScoreDoc, numHits int) *InOrderPagingScoreDocCollector {
+	c := &InOrderPagingScoreDocCollector{
+		TopScoreDocCollector: newTocScoreDocCollector(numHits),
+		after:                after,
+	}
+	c.TopDocsCreator = c
+	return c
+}
+
+func (c *InOrderPagingScoreDocCollector) Collect(doc int) (err error) {
+	var score float32
+	if score, err = c.scorer.Score(); err != nil {
+		return err
+	}
+
+	// This collector cannot handle these scores:
+	assert(score != -math.MaxFloat32)
+	assert(!math.IsNaN(float64(score)))
+
+	c.TotalHits++
+
+	if score > c.after.Score || (score == c.after.Score && doc <= c.afterDoc) {
+		// hit was collected on a previous page
+		return nil
+	}
+
+	if score <= c.pqTop.Score {
+		// Since docs are returned in-order (i.e., increasing doc Id), a document
+		// with equal score to pqTop.score cannot compete since HitQueue favors
+		// documents with lower doc Ids. Therefore reject those docs too.
+		return nil
+	}
+	c.collectedHits++
+	c.pqTop.Doc = doc + c.docBase
+	c.pqTop.Score = score
+	c.pqTop = c.pq.updateTop().(*ScoreDoc)
+	return nil
+}
+
+func (c *InOrderPagingScoreDocCollector) AcceptsDocsOutOfOrder() bool {
+	return false
+}
+
+func (c *InOrderPagingScoreDocCollector) SetNextReader(ctx *index.AtomicReaderContext) error {
+	if err := c.TopScoreDocCollector.SetNextReader(ctx); err != nil {
+		return err
+	}
+	c.afterDoc = c.after.Doc - c.docBase
+	return nil
+}
+
+func (c *InOrderPagingScoreDocCollector) topDocsSize() int {
+	if n := c.pq.Len(); c.collectedHits >= n {
+		return n
+	}
+	return c.collectedHits
+}
+
+func (c *InOrderPagingScoreDocCollector) newTopDocs(results []*ScoreDoc, start int) TopDocs {
+	if results == nil {
+		return TopDocs{c.TotalHits, []*ScoreDoc{}, math.NaN()}
+	}
+	return TopDocs{c.TotalHits, results, math.NaN()}
+}
+
+type OutOfOrderPagingScoreDocCollector struct {
+	*TopScoreDocCollector
+	after *ScoreDoc
+	// this is always after.doc - docBase, to save an add when score == after.score
+	afterDoc      int
+	collectedHits int
+}
+
+func newOutOfOrderPagingScoreDocCollector(after *ScoreDoc, numHits int) *OutOfOrderPagingScoreDocCollector {
+	c := &OutOfOrderPagingScoreDocCollector{
+		TopScoreDocCollector: newTocScoreDocCollector(numHits),
+		after:                after,
+	}
+	c.TopDocsCreator = c
+	return c
+}
+
+func (c *OutOfOrderPagingScoreDocCollector) Collect(doc int) (err error) {
+	var score float32
+	if score, err = c.scorer.Score(); err != nil {
+		return err
+	}
+
+	// This collector cannot handle NaN
+	assert(!math.IsNaN(float64(score)))
+
+	c.TotalHits++
+	if score > c.after.Score || (score == c.after.Score && doc <= c.afterDoc) {
+		// hit was collected on a previous page
+		return nil
+	}
+	if score < c.pqTop.Score {
+		// Doesn't compete w/ bottom entry in queue
+		return nil
+	}
+	doc += c.docBase
+	if score == c.pqTop.Score && doc > c.pqTop.Doc {
+		// Break tie in score by doc ID:
+		return nil
+	}
+	c.collectedHits++
+	c.pqTop.Doc = doc
+	c.pqTop.Score = score
+	c.pqTop = c.pq.updateTop().(*ScoreDoc)
+	return nil
+}
+
+func (c *OutOfOrderPagingScoreDocCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+func (c *OutOfOrderPagingScoreDocCollector) SetNextReader(ctx *index.AtomicReaderContext) error {
+	if err := c.TopScoreDocCollector.SetNextReader(ctx); err != nil {
+		return err
+	}
+	c.afterDoc = c.after.Doc - c.docBase
+	return nil
+}
+
+func (c *OutOfOrderPagingScoreDocCollector) topDocsSize() int {
+	if n := c.pq.Len(); c.collectedHits >= n {
+		return n
+	}
+	return c.collectedHits
+}
+
+func (c *OutOfOrderPagingScoreDocCollector) newTopDocs(results []*ScoreDoc, start int) TopDocs {
+	if results == nil {
+		return TopDocs{c.TotalHits, []*ScoreDoc{}, math.NaN()}
+	}
+	return TopDocs{c.TotalHits, results, math.NaN()}
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+)
+
+// search/ConjunctionScorer.java
+
+/* Scorer for conjunctions, sets of queries, all of which are required. */
+type ConjunctionScorer struct {
+	*abstractScorer
+	lastDoc      int
+	docsAndFreqs []*docsAndFreqs
+	lead         *docsAndFreqs
+	coord        float32
+}
+
+func newConjunctionScorer(weight Weight, scorers []Scorer, coord float32) *ConjunctionScorer {
+	ans := &ConjunctionScorer{
+		lastDoc:      -1,
+		docsAndFreqs: make([]*docsAndFreqs, len(scorers)),
+		coord:        coord,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	for i, scorer := range scorers {
+		ans.docsAndFreqs[i] = newDocsAndFreqs(scorer)
+	}
+	// Sort the array the first time to allow the least frequent
+	// DocsEnum to lead the matching.
+	sort.Stable(docsAndFreqsByCost(ans.docsAndFreqs))
+	ans.lead = ans.docsAndFreqs[0] // least frequent DocsEnum leads the intersection
+	return ans
+}
+
+func (s *ConjunctionScorer) doNext(doc int) (int, error) {
+	var err error
+	for {
+		// doc may already be NO_MORE_DOCS here, but we don't check
+		// explicitly since all scorers should advance to NO_MORE_DOCS,
+		// match, then return that value.
+		advanced := false
+		for _, sub := range s.docsAndFreqs[1:] {
+			// invariant: sub.doc <= doc at this point.
+			// sub.doc may already be equal to doc if we advanced the head
+			// on the previous iteration and the advance on the lead scorer
+			// exactly matched.
+			if sub.doc < doc {
+				if sub.doc, err = sub.scorer.Advance(doc); err != nil {
+					return 0, err
+				}
+				if sub.doc > doc {
+					// DocsEnum beyond the current doc - break and advance lead
+					// to the new highest doc.
+					doc = sub.doc
+					advanced = true
+					break
+				}
+			}
+		}
+		if !advanced {
+			// success - all DocsEnums are on the same doc
+			return doc, nil
+		}
+		// advance head for next iteration
+		if s.lead.doc, err = s.lead.scorer.Advance(doc); err != nil {
+			return 0, err
+		}
+		doc = s.lead.doc
+	}
+}
+
+func (s *ConjunctionScorer) Advance(target int) (doc int, err error) {
+	if s.lead.doc, err = s.lead.scorer.Advance(target); err != nil {
+		return 0, err
+	}
+	if s.lastDoc, err = s.doNext(s.lead.doc); err != nil {
+		return 0, err
+	}
+	return s.lastDoc, nil
+}
+
+func (s *ConjunctionScorer) DocId() int {
+	return s.lastDoc
+}
+
+func (s *ConjunctionScorer) NextDoc() (doc int, err error) {
+	if s.lead.doc, err = s.lead.scorer.NextDoc(); err != nil {
+		return 0, err
+	}
+	if s.lastDoc, err = s.doNext(s.lead.doc); err != nil {
+		return 0, err
+	}
+	return s.lastDoc, nil
+}
+
+func (s *ConjunctionScorer) Cost() int64 {
+	return s.lead.scorer.Cost()
+}
+
+func (s *ConjunctionScorer) Score() (float32, error) {
+	// TODO: sum into a float64 and cast to float32 if we ever send
+	// required clauses to BS1
+	var sum float32
+	for _, docs := range s.docsAndFreqs {
+		score, err := docs.scorer.Score()
+		if err != nil {
+			return 0, err
+		}
+		sum += score
+	}
+	return sum * s.coord, nil
+}
+
+func (s *ConjunctionScorer) Freq() (int, error) {
+	return len(s.docsAndFreqs), nil
+}
+
+func (s *ConjunctionScorer) String() string {
+	return fmt.Sprintf("ConjunctionScorer(%v)", s.weight)
+}
+
+type docsAndFreqs struct {
+	scorer Scorer
+	cost   int64
+	doc    int
+}
+
+func newDocsAndFreqs(scorer Scorer) *docsAndFreqs {
+	return &docsAndFreqs{scorer: scorer, cost: scorer.Cost(), doc: -1}
+}
+
+type docsAndFreqsByCost []*docsAndFreqs
+
+func (s docsAndFreqsByCost) Len() int           { return len(s) }
+func (s docsAndFreqsByCost) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s docsAndFreqsByCost) Less(i, j int) bool { return s[i].cost < s[j].cost }
@@ -0,0 +1,287 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/ConstantScoreQuery.java
+
+/*
+A query that wraps another query or a filter and simply returns a
+constant score equal to the query boost for every document that
+matches the filter or query. For queries it therefore simply strips
+of all scores and returns a constant one.
+*/
+type ConstantScoreQuery struct {
+	*AbstractQuery
+	filter Filter
+	query  Query
+}
+
+/*
+Strips off scores from the passed in Query. The hits will get a
+constant score dependent on the boost factor of this query.
+*/
+func NewConstantScoreQueryFromQuery(query Query) *ConstantScoreQuery {
+	assert2(query != nil, "Query may not be nil")
+	ans := &ConstantScoreQuery{query: query}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/*
+Wraps a Filter as a Query. The hits will get a constant score
+dependent on the boost factor of this query.
+*/
+func NewConstantScoreQuery(filter Filter) *ConstantScoreQuery {
+	assert2(filter != nil, "Filter may not be nil")
+	ans := &ConstantScoreQuery{filter: filter}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/* Returns the encapsulated filter, returns nil if a query is wrapped. */
+func (q *ConstantScoreQuery) Filter() Filter {
+	return q.filter
+}
+
+/* Returns the encapsulated query, returns nil if a filter is wrapped. */
+func (q *ConstantScoreQuery) Query() Query {
+	return q.query
+}
+
+func (q *ConstantScoreQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	if q.query != nil {
+		rewritten, err := q.query.Rewrite(reader)
+		if err != nil {
+			return nil, err
+		}
+		if rewritten != q.query {
+			ans := NewConstantScoreQueryFromQuery(rewritten)
+			ans.SetBoost(q.Boost())
+			return ans, nil
+		}
+	} else {
+		assert(q.filter != nil)
+		// Fix outdated usage pattern from Lucene 2.x/early-3.x: because
+		// ConstantScoreQuery only accepted filters, QueryWrapperFilter
+		// was used to wrap queries.
+		if qwf, ok := q.filter.(*QueryWrapperFilter); ok {
+			rewritten, err := qwf.Query().Rewrite(reader)
+			if err != nil {
+				return nil, err
+			}
+			ans := NewConstantScoreQueryFromQuery(rewritten)
+			ans.SetBoost(q.Boost())
+			return ans, nil
+		}
+	}
+	return q, nil
+}
+
+func (q *ConstantScoreQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	return newConstantWeight(q, ss)
+}
+
+func (q *ConstantScoreQuery) ToString(field string) string {
+	var boost string
+	if q.Boost() != 1.0 {
+		boost = fmt.Sprintf("^%v", q.Boost())
+	}
+	if q.query != nil {
+		return fmt.Sprintf("ConstantScore(%v)%v", q.query.ToString(field), boost)
+	}
+	return fmt.Sprintf("ConstantScore(%v)%v", q.filter, boost)
+}
+
+type ConstantWeight struct {
+	*WeightImpl
+	owner       *ConstantScoreQuery
+	innerWeight Weight
+	queryNorm   float32
+	queryWeight float32
+}
+
+func newConstantWeight(owner *ConstantScoreQuery, searcher *IndexSearcher) (*ConstantWeight, error) {
+	ans := &ConstantWeight{owner: owner}
+	ans.WeightImpl = NewWeightImpl(ans)
+	if owner.query != nil {
+		var err error
+		if ans.innerWeight, err = owner.query.CreateWeight(searcher); err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+func (w *ConstantWeight) ValueForNormalization() float32 {
+	// we calculate sumOfSquaredWeights of the inner weight, but ignore
+	// it (just to initialize everything)
+	if w.innerWeight != nil {
+		w.innerWeight.ValueForNormalization()
+	}
+	w.queryWeight = w.owner.Boost()
+	return w.queryWeight * w.queryWeight
+}
+
+func (w *ConstantWeight) Normalize(norm float32, topLevelBoost float32) {
+	w.queryNorm = norm * topLevelBoost
+	w.queryWeight *= w.queryNorm
+	// we normalize the inner weight, but ignore it (just to initialize
+	// everything)
+	if w.innerWeight != nil {
+		w.innerWeight.Normalize(norm, topLevelBoost)
+	}
+}
+
+func (w *ConstantWeight) IsScoresDocsOutOfOrder() bool {
+	if w.innerWeight != nil {
+		return w.innerWeight.IsScoresDocsOutOfOrder()
+	}
+	return false
+}
+
+func (w *ConstantWeight) BulkScorer(context *index.AtomicReaderContext,
+	scoreDocsInOrder bool, acceptDocs util.Bits) (BulkScorer, error) {
+
+	if w.owner.filter != nil {
+		assert(w.owner.query == nil)
+		return w.WeightImpl.BulkScorer(context, scoreDocsInOrder, acceptDocs)
+	}
+	assert(w.owner.query != nil && w.innerWeight != nil)
+	bulkScorer, err := w.innerWeight.BulkScorer(context, scoreDocsInOrder, acceptDocs)
+	if err != nil || bulkScorer == nil {
+		return nil, err
+	}
+	return newConstantBulkScorer(bulkScorer, w, w.queryWeight), nil
+}
+
+func (w *ConstantWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	var disi DocIdSetIterator
+	if w.owner.filter != nil {
+		assert(w.owner.query == nil)
+		dis, err := w.owner.filter.DocIdSet(context, acceptDocs)
+		if err != nil || dis == nil {
+			return nil, err
+		}
+		if disi, err = dis.Iterator(); err != nil || disi == nil {
+			return nil, err
+		}
+	} else {
+		assert(w.owner.query != nil && w.innerWeight != nil)
+		scorer, err := w.innerWeight.Scorer(context, acceptDocs)
+		if err != nil || scorer == nil {
+			return nil, err
+		}
+		disi = scorer
+	}
+	return newConstantScorer(disi, w, w.queryWeight), nil
+}
+
+func (w *ConstantWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
+	cs, err := w.Scorer(context, context.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	exists := false
+	if cs != nil {
+		target, err := cs.Advance(doc)
+		if err != nil {
+			return nil, err
+		}
+		exists = target == doc
+	}
+
+	if exists {
+		result := NewComplexExplanation(true, w.queryWeight,
+			fmt.Sprintf("%v, product of:", w.owner))
+		result.AddDetail(NewExplanation(w.owner.Boost(), "boost"))
+		result.AddDetail(NewExplanation(w.queryNorm, "queryNorm"))
+		return result, nil
+	}
+	return NewComplexExplanation(false, 0,
+		fmt.Sprintf("%v doesn't match id %v", w.owner, doc)), nil
+}
+
+/*
+We return this as our BulkScorer so that if the ConstantScoreQuery
+wraps a query with its own optimized top-level scorer (e.g.
+BooleanScorer) we can use that top-level scorer.
+*/
+type ConstantBulkScorer struct {
+	*BulkScorerImpl
+	bulkScorer BulkScorer
+	weight     Weight
+	theScore   float32
+}
+
+func newConstantBulkScorer(bulkScorer BulkScorer, weight Weight, theScore float32) *ConstantBulkScorer {
+	ans := &ConstantBulkScorer{
+		bulkScorer: bulkScorer,
+		weight:     weight,
+		theScore:   theScore,
+	}
+	ans.BulkScorerImpl = newBulkScorer(ans)
+	return ans
+}
+
+func (s *ConstantBulkScorer) ScoreAndCollectUpto(collector Collector, max int) (bool, error) {
+	return s.bulkScorer.ScoreAndCollectUpto(&constantCollector{collector, s}, max)
+}
+
+/* Replaces the scorer passed to the wrapped collector with a constant one. */
+type constantCollector struct {
+	Collector
+	owner *ConstantBulkScorer
+}
+
+func (c *constantCollector) SetScorer(scorer Scorer) {
+	// we must wrap again here, but using the scorer passed in as
+	// parameter:
+	c.Collector.SetScorer(newConstantScorer(scorer, c.owner.weight, c.owner.theScore))
+}
+
+type ConstantScorer struct {
+	*abstractScorer
+	docIdSetIterator DocIdSetIterator
+	theScore         float32
+}
+
+func newConstantScorer(docIdSetIterator DocIdSetIterator, w Weight, theScore float32) *ConstantScorer {
+	ans := &ConstantScorer{
+		docIdSetIterator: docIdSetIterator,
+		theScore:         theScore,
+	}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans
+}
+
+func (s *ConstantScorer) NextDoc() (int, error) {
+	return s.docIdSetIterator.NextDoc()
+}
+
+func (s *ConstantScorer) DocId() int {
+	return s.docIdSetIterator.DocId()
+}
+
+func (s *ConstantScorer) Score() (float32, error) {
+	assert(s.docIdSetIterator.DocId() != NO_MORE_DOCS)
+	return s.theScore, nil
+}
+
+func (s *ConstantScorer) Freq() (int, error) {
+	return 1, nil
+}
+
+func (s *ConstantScorer) Advance(target int) (int, error) {
+	return s.docIdSetIterator.Advance(target)
+}
+
+func (s *ConstantScorer) Cost() int64 {
+	return s.docIdSetIterator.Cost()
+}
@@ -0,0 +1,445 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+// search/similarities/DFRSimilarity.java
+
+/*
+Implements the divergence from randomness (DFR) framework introduced
+in Gianni Amati and Cornelis Joost Van Rijsbergen. 2002. Probabilistic
+models of information retrieval based on measuring the divergence from
+randomness. ACM Trans. Inf. Syst. 20, 4 (October 2002), 357-389.
+
+The DFR scoring formula is composed of three separate components: the
+basic model, the aftereffect and an additional normalization
+component, represented by the types BasicModel, AfterEffect and
+Normalization, respectively. The names of these types were chosen to
+match the names of their counterparts in the Terrier IR engine.
+
+To construct a DFRSimilarity, you must specify the implementations
+for all three components of DFR:
+
+	BasicModel: Basic model of information content:
+		BasicModelBE: Limiting form of Bose-Einstein
+		BasicModelG: Geometric approximation of Bose-Einstein
+		BasicModelP: Poisson approximation of the Binomial
+		BasicModelD: Divergence approximation of the Binomial
+		BasicModelIn: Inverse document frequency
+		BasicModelIne: Inverse expected document frequency [mixture of Poisson and IDF]
+		BasicModelIF: Inverse term frequency [approximation of I(ne)]
+	AfterEffect: First normalization of information gain:
+		AfterEffectL: Laplace's law of succession
+		AfterEffectB: Ratio of two Bernoulli processes
+		NoAfterEffect: no first normalization
+	Normalization: Second (length) normalization:
+		NormalizationH1: Uniform distribution of term frequency
+		NormalizationH2: term frequency density inversely related to length
+		NormalizationH3: term frequency normalization provided by Dirichlet prior
+		NormalizationZ: term frequency normalization provided by a Zipfian relation
+		NoNormalization: no second normalization
+
+Note that qtf, the multiplicity of term-occurrence in the query, is
+not handled by this implementation.
+*/
+type DFRSimilarity struct {
+	*SimilarityBase
+	// The basic model for information content.
+	basicModel BasicModel
+	// The first normalization of the information content.
+	afterEffect AfterEffect
+	// The term frequency normalization.
+	normalization Normalization
+}
+
+/*
+Creates DFRSimilarity from the three components.
+
+Note that NoAfterEffect and NoNormalization may be used to disable
+the corresponding component.
+*/
+func NewDFRSimilarity(basicModel BasicModel, afterEffect AfterEffect,
+	normalization Normalization) *DFRSimilarity {
+
+	assert2(basicModel != nil && afterEffect != nil && normalization != nil,
+		"nil parameters not allowed.")
+	ans := &DFRSimilarity{
+		basicModel:    basicModel,
+		afterEffect:   afterEffect,
+		normalization: normalization,
+	}
+	ans.SimilarityBase = newSimilarityBase(ans)
+	return ans
+}
+
+func (sim *DFRSimilarity) score(s simStats, freq, docLen float32) float32 {
+	stats := s.basic()
+	tfn := sim.normalization.tfn(stats, freq, docLen)
+	return stats.totalBoost * sim.basicModel.score(stats, tfn) * sim.afterEffect.score(stats, tfn)
+}
+
+func (sim *DFRSimilarity) addExplanation(expl *ExplanationImpl, s simStats,
+	doc int, freq, docLen float32) {
+
+	stats := s.basic()
+	if stats.totalBoost != 1 {
+		expl.AddDetail(NewExplanation(stats.totalBoost, "boost"))
+	}
+
+	normExpl := sim.normalization.explain(stats, freq, docLen)
+	tfn := normExpl.Value()
+	expl.AddDetail(normExpl)
+	expl.AddDetail(sim.basicModel.explain(stats, tfn))
+	expl.AddDetail(sim.afterEffect.explain(stats, tfn))
+}
+
+func (sim *DFRSimilarity) String() string {
+	return fmt.Sprintf("DFR %v%v%v", sim.basicModel, sim.afterEffect, sim.normalization)
+}
+
+/* Returns the basic model of information content. */
+func (sim *DFRSimilarity) BasicModel() BasicModel {
+	return sim.basicModel
+}
+
+/* Returns the first normalization. */
+func (sim *DFRSimilarity) AfterEffect() AfterEffect {
+	return sim.afterEffect
+}
+
+/* Returns the second normalization. */
+func (sim *DFRSimilarity) Normalization() Normalization {
+	return sim.normalization
+}
+
+// search/similarities/BasicModel.java
+
+/*
+This type acts as the base for the implementations of the basic
+models of information content in the DFR framework.
+*/
+type BasicModel interface {
+	// Returns the informative content score.
+	score(stats *BasicStats, tfn float32) float32
+	// Returns an explanation for the score.
+	explain(stats *BasicStats, tfn float32) Explanation
+	String() string
+}
+
+/*
+The default explanation of a basic model. Most basic models use the
+number of documents and the total term frequency to compute Inf1.
+Models that use other statistics must provide their own explanation.
+*/
+func explainBasicModel(m BasicModel, stats *BasicStats, tfn float32) Explanation {
+	result := NewExplanation(m.score(stats, tfn), fmt.Sprintf("%v, computed from: ", simpleName(m)))
+	result.AddDetail(NewExplanation(tfn, "tfn"))
+	result.AddDetail(NewExplanation(float32(stats.numberOfDocuments), "numberOfDocuments"))
+	result.AddDetail(NewExplanation(float32(stats.totalTermFreq), "totalTermFreq"))
+	return result
+}
+
+// search/similarities/BasicModelBE.java
+
+/*
+Limiting form of the Bose-Einstein model. The formula used in Lucene
+differs slightly from the one in the original paper: F is increased
+by tfn+1 and N is increased by F.
+
+WARNING: for terms that do not meet the expected random distribution
+(e.g. stopwords), this model may give poor performance, such as
+abnormally high scores for low tf values.
+*/
+type BasicModelBE struct{}
+
+func NewBasicModelBE() *BasicModelBE {
+	return &BasicModelBE{}
+}
+
+func (m *BasicModelBE) score(stats *BasicStats, tfn float32) float32 {
+	F := float64(stats.totalTermFreq) + 1 + float64(tfn)
+	// approximation only holds true when F << N, so we use N += F
+	N := F + float64(stats.numberOfDocuments)
+	return float32(-log2((N-1)*math.E) + basicModelBEF(N+F-1, N+F-float64(tfn)-2) -
+		basicModelBEF(F, F-float64(tfn)))
+}
+
+/* The f helper function defined for B_E. */
+func basicModelBEF(n, m float64) float64 {
+	return (m+0.5)*log2(n/m) + (n-m)*log2(n)
+}
+
+func (m *BasicModelBE) explain(stats *BasicStats, tfn float32) Explanation {
+	return explainBasicModel(m, stats, tfn)
+}
+
+func (m *BasicModelBE) String() string {
+	return "Be"
+}
+
+// search/similarities/BasicModelD.java
+
+/*
+Implements the approximation of the binomial model with the
+divergence for DFR. The formula used in Lucene differs slightly from
+the one in the original paper: to avoid underflow for small values of
+N and F, N is increased by 1 and F is always increased by tfn+1.
+
+WARNING: for terms that do not meet the expected random distribution
+(e.g. stopwords), this model may give poor performance, such as
+abnormally high scores for low tf values.
+*/
+type BasicModelD struct{}
+
+func NewBasicModelD() *BasicModelD {
+	return &BasicModelD{}
+}
+
+func (m *BasicModelD) score(stats *BasicStats, tfn float32) float32 {
+	// we have to ensure phi is always < 1 for tiny TTF values,
+	// otherwise nphi can go negative, resulting in NaN. cleanest way is
+	// to unconditionally always add tfn to totalTermFreq to create a
+	// 'normalized' F.
+	F := float64(stats.totalTermFreq) + 1 + float64(tfn)
+	phi := float64(tfn) / F
+	nphi := 1 - phi
+	p := 1 / (float64(stats.numberOfDocuments) + 1)
+	D := phi*log2(phi/p) + nphi*log2(nphi/(1-p))
+	return float32(D*F + 0.5*log2(1+2*math.Pi*float64(tfn)*nphi))
+}
+
+func (m *BasicModelD) explain(stats *BasicStats, tfn float32) Explanation {
+	return explainBasicModel(m, stats, tfn)
+}
+
+func (m *BasicModelD) String() string {
+	return "D"
+}
+
+// search/similarities/BasicModelG.java
+
+/*
+Geometric as limiting form of the Bose-Einstein model. The formula
+used in Lucene differs slightly from the one in the original paper:
+F is increased by 1 and N is increased by F.
+*/
+type BasicModelG struct{}
+
+func NewBasicModelG() *BasicModelG {
+	return &BasicModelG{}
+}
+
+func (m *BasicModelG) score(stats *BasicStats, tfn float32) float32 {
+	// just like in BE, approximation only holds true when F << N, so we
+	// use lambda = F / (N + F)
+	F := float64(stats.totalTermFreq) + 1
+	N := float64(stats.numberOfDocuments)
+	lambda := F / (N + F)
+	// -log(1 / (lambda + 1)) -> log(lambda + 1)
+	return float32(log2(lambda+1) + float64(tfn)*log2((1+lambda)/lambda))
+}
+
+func (m *BasicModelG) explain(stats *BasicStats, tfn float32) Explanation {
+	return explainBasicModel(m, stats, tfn)
+}
+
+func (m *BasicModelG) String() string {
+	return "G"
+}
+
+// search/similarities/BasicModelIF.java
+
+/* An approximation of the I(ne) model. */
+type BasicModelIF struct{}
+
+func NewBasicModelIF() *BasicModelIF {
+	return &BasicModelIF{}
+}
+
+func (m *BasicModelIF) score(stats *BasicStats, tfn float32) float32 {
+	N := stats.numberOfDocuments
+	F := stats.totalTermFreq
+	return tfn * float32(log2(1+float64(N+1)/(float64(F)+0.5)))
+}
+
+func (m *BasicModelIF) explain(stats *BasicStats, tfn float32) Explanation {
+	return explainBasicModel(m, stats, tfn)
+}
+
+func (m *BasicModelIF) String() string {
+	return "I(F)"
+}
+
+// search/similarities/BasicModelIn.java
+
+/* The basic tf-idf model of randomness. */
+type BasicModelIn struct{}
+
+func NewBasicModelIn() *BasicModelIn {
+	return &BasicModelIn{}
+}
+
+func (m *BasicModelIn) score(stats *BasicStats, tfn float32) float32 {
+	N := stats.numberOfDocuments
+	n := stats.docFreq
+	return tfn * float32(log2(float64(N+1)/(float64(n)+0.5)))
+}
+
+func (m *BasicModelIn) explain(stats *BasicStats, tfn float32) Explanation {
+	result := NewExplanation(m.score(stats, tfn), fmt.Sprintf("%v, computed from: ", simpleName(m)))
+	result.AddDetail(NewExplanation(tfn, "tfn"))
+	result.AddDetail(NewExplanation(float32(stats.numberOfDocuments), "numberOfDocuments"))
+	result.AddDetail(NewExplanation(float32(stats.docFreq), "docFreq"))
+	return result
+}
+
+func (m *BasicModelIn) String() string {
+	return "I(n)"
+}
+
+// search/similarities/BasicModelIne.java
+
+/*
+Tf-idf model of randomness, based on a mixture of Poisson and inverse
+document frequency.
+*/
+type BasicModelIne struct{}
+
+func NewBasicModelIne() *BasicModelIne {
+	return &BasicModelIne{}
+}
+
+func (m *BasicModelIne) score(stats *BasicStats, tfn float32) float32 {
+	N := float64(stats.numberOfDocuments)
+	F := float64(stats.totalTermFreq)
+	ne := N * (1 - math.Pow((N-1)/N, F))
+	return tfn * float32(log2((N+1)/(ne+0.5)))
+}
+
+func (m *BasicModelIne) explain(stats *BasicStats, tfn float32) Explanation {
+	return explainBasicModel(m, stats, tfn)
+}
+
+func (m *BasicModelIne) String() string {
+	return "I(ne)"
+}
+
+// search/similarities/BasicModelP.java
+
+/*
+Implements the Poisson approximation for the binomial model for DFR.
+
+WARNING: for terms that do not meet the expected random distribution
+(e.g. stopwords), this model may give poor performance, such as
+abnormally high scores for low tf values.
+*/
+type BasicModelP struct{}
+
+/* log2(Math.E), precomputed. */
+var log2E = log2(math.E)
+
+func NewBasicModelP() *BasicModelP {
+	return &BasicModelP{}
+}
+
+func (m *BasicModelP) score(stats *BasicStats, tfn float32) float32 {
+	lambda := (float32(stats.totalTermFreq) + 1) / (float32(stats.numberOfDocuments) + 1)
+	return float32(float64(tfn)*log2(float64(tfn/lambda)) +
+		float64(lambda+1/(12*tfn)-tfn)*log2E +
+		0.5*log2(2*math.Pi*float64(tfn)))
+}
+
+func (m *BasicModelP) explain(stats *BasicStats, tfn float32) Explanation {
+	return explainBasicModel(m, stats, tfn)
+}
+
+func (m *BasicModelP) String() string {
+	return "P"
+}
+
+// search/similarities/AfterEffect.java
+
+/*
+This type acts as the base for the implementations of the first
+normalization of the informative content in the DFR framework. This
+component is also called the after effect and is defined by the
+formula Inf2 = 1 - Prob2, where Prob2 measures the information gain.
+*/
+type AfterEffect interface {
+	// Returns the aftereffect score.
+	score(stats *BasicStats, tfn float32) float32
+	// Returns an explanation for the score.
+	explain(stats *BasicStats, tfn float32) Explanation
+	String() string
+}
+
+/* Implementation used when there is no aftereffect. */
+type NoAfterEffect struct{}
+
+func NewNoAfterEffect() *NoAfterEffect {
+	return &NoAfterEffect{}
+}
+
+func (ae *NoAfterEffect) score(stats *BasicStats, tfn float32) float32 {
+	return 1
+}
+
+func (ae *NoAfterEffect) explain(stats *BasicStats, tfn float32) Explanation {
+	return NewExplanation(1, "no aftereffect")
+}
+
+func (ae *NoAfterEffect) String() string {
+	return ""
+}
+
+// search/similarities/AfterEffectB.java
+
+/* Model of the information gain based on the ratio of two Bernoulli processes. */
+type AfterEffectB struct{}
+
+func NewAfterEffectB() *AfterEffectB {
+	return &AfterEffectB{}
+}
+
+func (ae *AfterEffectB) score(stats *BasicStats, tfn float32) float32 {
+	F := stats.totalTermFreq + 1
+	n := stats.docFreq + 1
+	return float32(F+1) / (float32(n) * (tfn + 1))
+}
+
+func (ae *AfterEffectB) explain(stats *BasicStats, tfn float32) Explanation {
+	result := NewExplanation(ae.score(stats, tfn), fmt.Sprintf("%v, computed from: ", simpleName(ae)))
+	result.AddDetail(NewExplanation(tfn, "tfn"))
+	result.AddDetail(NewExplanation(float32(stats.totalTermFreq), "totalTermFreq"))
+	result.AddDetail(NewExplanation(float32(stats.docFreq), "docFreq"))
+	return result
+}
+
+func (ae *AfterEffectB) String() string {
+	return "B"
+}
+
+// search/similarities/AfterEffectL.java
+
+/* Model of the information gain based on Laplace's law of succession. */
+type AfterEffectL struct{}
+
+func NewAfterEffectL() *AfterEffectL {
+	return &AfterEffectL{}
+}
+
+func (ae *AfterEffectL) score(stats *BasicStats, tfn float32) float32 {
+	return 1 / (tfn + 1)
+}
+
+func (ae *AfterEffectL) explain(stats *BasicStats, tfn float32) Explanation {
+	result := NewExplanation(ae.score(stats, tfn), fmt.Sprintf("%v, computed from: ", simpleName(ae)))
+	result.AddDetail(NewExplanation(tfn, "tfn"))
+	return result
+}
+
+func (ae *AfterEffectL) String() string {
+	return "L"
+}
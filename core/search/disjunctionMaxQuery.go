@@ -0,0 +1,322 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/DisjunctionMaxQuery.java
+
+/*
+A query that generates the union of documents produced by its
+subqueries, and that scores each document with the maximum score for
+that document as produced by any subquery, plus a tie breaking
+increment for any additional matching subqueries. This is useful when
+searching for a word in multiple fields with different boost factors
+(so that the fields cannot be combined equivalently into a single
+search field). We want the primary score to be the one associated
+with the highest boost, not the sum of the field scores (as
+BooleanQuery would give).
+
+If the query is "albino elephant" this ensures that "albino" matching
+one field and "elephant" matching another gets a higher score than
+"albino" matching both fields.
+
+To get this result, use both BooleanQuery and DisjunctionMaxQuery:
+for each term a DisjunctionMaxQuery searches for it in each field,
+while the set of these DisjunctionMaxQuery's is combined into a
+BooleanQuery. The tie breaker capability allows results that include
+the same term in multiple fields to be judged better than results
+that include this term in only the best of those multiple fields,
+without confusing this with the better case of two different terms
+in the multiple fields.
+*/
+type DisjunctionMaxQuery struct {
+	*AbstractQuery
+	// The subqueries
+	disjuncts []Query
+	// Multiple of the non-max disjunct scores added into our final
+	// score. Non-zero values support tie-breaking.
+	tieBreakerMultiplier float32
+}
+
+/*
+Creates a new DisjunctionMaxQuery. tieBreakerMultiplier is the score
+of each non-maximum disjunct for a document is multiplied by this
+weight and added into the final score. If non-zero, the value should
+be small, on the order of 0.1, which says that 10 occurrences of word
+in a lower-scored field that is also in a higher scored field is just
+as good as a unique word in the lower scored field (i.e., one that is
+not in any higher scored field).
+*/
+func NewDisjunctionMaxQuery(disjuncts []Query, tieBreakerMultiplier float32) *DisjunctionMaxQuery {
+	ans := &DisjunctionMaxQuery{
+		disjuncts:            append([]Query(nil), disjuncts...),
+		tieBreakerMultiplier: tieBreakerMultiplier,
+	}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/* Add a subquery to this disjunction. */
+func (q *DisjunctionMaxQuery) Add(query Query) {
+	q.disjuncts = append(q.disjuncts, query)
+}
+
+/* Returns the disjuncts. */
+func (q *DisjunctionMaxQuery) Disjuncts() []Query {
+	return q.disjuncts
+}
+
+/* Returns the tie breaker value for multiple matches. */
+func (q *DisjunctionMaxQuery) TieBreakerMultiplier() float32 {
+	return q.tieBreakerMultiplier
+}
+
+func (q *DisjunctionMaxQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newDisjunctionMaxWeight(q, searcher)
+}
+
+/*
+Optimize our representation and our subqueries representations.
+*/
+func (q *DisjunctionMaxQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	if len(q.disjuncts) == 1 {
+		singleton := q.disjuncts[0]
+		result, err := singleton.Rewrite(reader)
+		if err != nil {
+			return nil, err
+		}
+		if q.Boost() == 1 {
+			return result, nil
+		}
+		// The rewritten query's boost must incorporate our own boost.
+		// There is no generic Query clone, so we only do so when the
+		// rewrite produced a new instance.
+		if result != singleton {
+			result.SetBoost(q.Boost() * result.Boost())
+			return result, nil
+		}
+	}
+
+	var clone *DisjunctionMaxQuery
+	for i, clause := range q.disjuncts {
+		rewrite, err := clause.Rewrite(reader)
+		if err != nil {
+			return nil, err
+		}
+		if rewrite != clause {
+			if clone == nil {
+				clone = q.Clone()
+			}
+			clone.disjuncts[i] = rewrite
+		}
+	}
+	if clone != nil {
+		return clone, nil
+	}
+	return q, nil
+}
+
+/* Create a shallow copy of us -- used in rewriting if necessary */
+func (q *DisjunctionMaxQuery) Clone() *DisjunctionMaxQuery {
+	clone := NewDisjunctionMaxQuery(q.disjuncts, q.tieBreakerMultiplier)
+	clone.SetBoost(q.Boost())
+	return clone
+}
+
+func (q *DisjunctionMaxQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	buf.WriteRune('(')
+	for i, subquery := range q.disjuncts {
+		if _, ok := subquery.(*BooleanQuery); ok { // wrap sub-bools in parens
+			buf.WriteRune('(')
+			buf.WriteString(subquery.ToString(field))
+			buf.WriteRune(')')
+		} else {
+			buf.WriteString(subquery.ToString(field))
+		}
+		if i != len(q.disjuncts)-1 {
+			buf.WriteString(" | ")
+		}
+	}
+	buf.WriteRune(')')
+	if q.tieBreakerMultiplier != 0 {
+		fmt.Fprintf(&buf, "~%v", q.tieBreakerMultiplier)
+	}
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+/*
+Expert: the Weight for DisjunctionMaxQuery, used to normalize, score
+and explain these queries.
+*/
+type DisjunctionMaxWeight struct {
+	*WeightImpl
+	owner *DisjunctionMaxQuery
+	// The Weights for our subqueries, in 1-1 correspondence with
+	// disjuncts
+	weights []Weight
+}
+
+/* Construct the Weight for this Query searched by searcher. Recursively construct subquery weights. */
+func newDisjunctionMaxWeight(owner *DisjunctionMaxQuery,
+	searcher *IndexSearcher) (*DisjunctionMaxWeight, error) {
+
+	ans := &DisjunctionMaxWeight{owner: owner}
+	ans.WeightImpl = NewWeightImpl(ans)
+	for _, disjunctQuery := range owner.disjuncts {
+		w, err := disjunctQuery.CreateWeight(searcher)
+		if err != nil {
+			return nil, err
+		}
+		ans.weights = append(ans.weights, w)
+	}
+	return ans, nil
+}
+
+func (w *DisjunctionMaxWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.owner)
+}
+
+/* Compute the sum of squared weights of us applied to our subqueries. Used for normalization. */
+func (w *DisjunctionMaxWeight) ValueForNormalization() float32 {
+	var max, sum float32
+	for _, currentWeight := range w.weights {
+		sub := currentWeight.ValueForNormalization()
+		sum += sub
+		if sub > max {
+			max = sub
+		}
+	}
+	boost := w.owner.Boost()
+	tie := w.owner.tieBreakerMultiplier
+	return (((sum - max) * tie * tie) + max) * boost * boost
+}
+
+/* Apply the computed normalization factor to our subqueries */
+func (w *DisjunctionMaxWeight) Normalize(norm, topLevelBoost float32) {
+	topLevelBoost *= w.owner.Boost() // Incorporate our boost
+	for _, wt := range w.weights {
+		wt.Normalize(norm, topLevelBoost)
+	}
+}
+
+func (w *DisjunctionMaxWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+/* Create the scorer used to score our associated DisjunctionMaxQuery */
+func (w *DisjunctionMaxWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	var scorers []Scorer
+	for _, wt := range w.weights {
+		// we will advance() subscorers
+		subScorer, err := wt.Scorer(context, acceptDocs)
+		if err != nil {
+			return nil, err
+		}
+		if subScorer != nil {
+			scorers = append(scorers, subScorer)
+		}
+	}
+	switch len(scorers) {
+	case 0:
+		// no sub-scorers had any documents
+		return nil, nil
+	case 1:
+		// only one sub-scorer in this segment
+		return scorers[0], nil
+	}
+	return newDisjunctionMaxScorer(w, w.owner.tieBreakerMultiplier, scorers), nil
+}
+
+/* Explain the score we computed for doc */
+func (w *DisjunctionMaxWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
+	if len(w.owner.disjuncts) == 1 {
+		return w.weights[0].Explain(context, doc)
+	}
+	result := newEmptyComplexExplanation()
+	tie := w.owner.tieBreakerMultiplier
+	if tie == 0 {
+		result.description = "max of:"
+	} else {
+		result.description = fmt.Sprintf("max plus %v times others of:", tie)
+	}
+	var max, sum float32
+	for _, wt := range w.weights {
+		e, err := wt.Explain(context, doc)
+		if err != nil {
+			return nil, err
+		}
+		if e.IsMatch() {
+			result.match = true
+			result.AddDetail(e)
+			sum += e.Value()
+			if e.Value() > max {
+				max = e.Value()
+			}
+		}
+	}
+	result.value = max + (sum-max)*tie
+	return result, nil
+}
+
+// search/DisjunctionMaxScorer.java
+
+/*
+The Scorer for DisjunctionMaxQuery. The union of all documents
+generated by the subquery scorers is generated in document number
+order. The score for each document is the maximum of the scores
+computed by the subquery scorers that generate that document, plus
+tieBreakerMultiplier times the sum of the scores for the other
+subqueries that generate the document.
+*/
+type DisjunctionMaxScorer struct {
+	*DisjunctionScorer
+	// Multiplier applied to non-maximum-scoring subqueries for a
+	// document as they are summed into the result.
+	tieBreakerMultiplier float32
+	scoreSum             float32
+	scoreMax             float32
+}
+
+/*
+Creates a new instance of DisjunctionMaxScorer. subScorers is the
+sub scorers this Scorer should iterate on.
+*/
+func newDisjunctionMaxScorer(weight Weight, tieBreakerMultiplier float32,
+	subScorers []Scorer) *DisjunctionMaxScorer {
+
+	ans := &DisjunctionMaxScorer{tieBreakerMultiplier: tieBreakerMultiplier}
+	ans.DisjunctionScorer = newDisjunctionScorer(weight, subScorers, ans)
+	return ans
+}
+
+func (s *DisjunctionMaxScorer) reset() {
+	s.scoreSum, s.scoreMax = 0, 0
+}
+
+func (s *DisjunctionMaxScorer) accum(subScorer Scorer) error {
+	subScore, err := subScorer.Score()
+	if err != nil {
+		return err
+	}
+	s.scoreSum += subScore
+	if subScore > s.scoreMax {
+		s.scoreMax = subScore
+	}
+	return nil
+}
+
+func (s *DisjunctionMaxScorer) final() float32 {
+	return s.scoreMax + (s.scoreSum-s.scoreMax)*s.tieBreakerMultiplier
+}
+
+func (s *DisjunctionMaxScorer) String() string {
+	return fmt.Sprintf("DisjunctionMaxScorer(%v)", s.weight)
+}
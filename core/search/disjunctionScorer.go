@@ -0,0 +1,268 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"math"
+)
+
+// search/DisjunctionScorer.java
+
+type DisjunctionScorerSPI interface {
+	// Reset score state for a new match
+	reset()
+	// Increment score state for a new match
+	accum(subScorer Scorer) error
+	// Return final score
+	final() float32
+}
+
+/* Base class for Scorers that score disjunctions. */
+type DisjunctionScorer struct {
+	*abstractScorer
+	spi DisjunctionScorerSPI
+	// The scorers in a min-heap ordered by DocId()
+	subScorers []Scorer
+	numScorers int
+
+	// The document number of the current match.
+	doc int
+	// Number of matching scorers for the current match, or -1 if not
+	// yet computed.
+	freq int
+}
+
+func newDisjunctionScorer(weight Weight, subScorers []Scorer,
+	spi DisjunctionScorerSPI) *DisjunctionScorer {
+
+	ans := &DisjunctionScorer{
+		spi:        spi,
+		subScorers: subScorers,
+		numScorers: len(subScorers),
+		doc:        -1,
+		freq:       -1,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	ans.heapify()
+	return ans
+}
+
+/*
+Organize subScorers into a min heap with scorers generating the
+earliest document on top.
+*/
+func (s *DisjunctionScorer) heapify() {
+	for i := (s.numScorers >> 1) - 1; i >= 0; i-- {
+		s.heapAdjust(i)
+	}
+}
+
+/*
+The subtree of subScorers at root is a min heap except possibly for
+its root element. Bubble the root down as required to make the
+subtree a heap.
+*/
+func (s *DisjunctionScorer) heapAdjust(root int) {
+	scorer := s.subScorers[root]
+	doc := scorer.DocId()
+	i := root
+	for i <= (s.numScorers>>1)-1 {
+		lchild := (i << 1) + 1
+		lscorer := s.subScorers[lchild]
+		ldoc := lscorer.DocId()
+		rdoc, rchild := math.MaxInt32, (i<<1)+2
+		var rscorer Scorer
+		if rchild < s.numScorers {
+			rscorer = s.subScorers[rchild]
+			rdoc = rscorer.DocId()
+		}
+		if ldoc < doc {
+			if rdoc < ldoc {
+				s.subScorers[i] = rscorer
+				s.subScorers[rchild] = scorer
+				i = rchild
+			} else {
+				s.subScorers[i] = lscorer
+				s.subScorers[lchild] = scorer
+				i = lchild
+			}
+		} else if rdoc < doc {
+			s.subScorers[i] = rscorer
+			s.subScorers[rchild] = scorer
+			i = rchild
+		} else {
+			return
+		}
+	}
+}
+
+/* Remove the root Scorer from subScorers and re-establish it as a heap */
+func (s *DisjunctionScorer) heapRemoveRoot() {
+	if s.numScorers == 1 {
+		s.subScorers[0] = nil
+		s.numScorers = 0
+	} else {
+		s.subScorers[0] = s.subScorers[s.numScorers-1]
+		s.subScorers[s.numScorers-1] = nil
+		s.numScorers--
+		s.heapAdjust(0)
+	}
+}
+
+func (s *DisjunctionScorer) DocId() int {
+	return s.doc
+}
+
+func (s *DisjunctionScorer) NextDoc() (int, error) {
+	assert(s.doc != NO_MORE_DOCS)
+	for {
+		doc, err := s.subScorers[0].NextDoc()
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			s.heapAdjust(0)
+		} else {
+			s.heapRemoveRoot()
+			if s.numScorers == 0 {
+				s.doc = NO_MORE_DOCS
+				return s.doc, nil
+			}
+		}
+		if docId := s.subScorers[0].DocId(); docId != s.doc {
+			s.freq = -1
+			s.doc = docId
+			return s.doc, nil
+		}
+	}
+}
+
+func (s *DisjunctionScorer) Cost() int64 {
+	var sum int64
+	for _, sub := range s.subScorers[:s.numScorers] {
+		sum += sub.Cost()
+	}
+	return sum
+}
+
+func (s *DisjunctionScorer) Advance(target int) (int, error) {
+	assert(s.doc != NO_MORE_DOCS)
+	for {
+		doc, err := s.subScorers[0].Advance(target)
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			s.heapAdjust(0)
+		} else {
+			s.heapRemoveRoot()
+			if s.numScorers == 0 {
+				s.doc = NO_MORE_DOCS
+				return s.doc, nil
+			}
+		}
+		if docId := s.subScorers[0].DocId(); docId >= target {
+			s.freq = -1
+			s.doc = docId
+			return s.doc, nil
+		}
+	}
+}
+
+/* if we haven't already computed freq + score, do so */
+func (s *DisjunctionScorer) visitScorers() error {
+	s.spi.reset()
+	s.freq = 1
+	if err := s.spi.accum(s.subScorers[0]); err != nil {
+		return err
+	}
+	return s.visit(0)
+}
+
+/*
+Recursively iterate all subScorers that generated last doc computing
+sum and max.
+*/
+func (s *DisjunctionScorer) visit(root int) error {
+	i1 := (root << 1) + 1
+	i2 := (root << 1) + 2
+	if i1 < s.numScorers && s.subScorers[i1].DocId() == s.doc {
+		s.freq++
+		if err := s.spi.accum(s.subScorers[i1]); err != nil {
+			return err
+		}
+		if err := s.visit(i1); err != nil {
+			return err
+		}
+	}
+	if i2 < s.numScorers && s.subScorers[i2].DocId() == s.doc {
+		s.freq++
+		if err := s.spi.accum(s.subScorers[i2]); err != nil {
+			return err
+		}
+		if err := s.visit(i2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DisjunctionScorer) Score() (float32, error) {
+	if err := s.visitScorers(); err != nil {
+		return 0, err
+	}
+	return s.spi.final(), nil
+}
+
+func (s *DisjunctionScorer) Freq() (int, error) {
+	if s.freq < 0 {
+		if err := s.visitScorers(); err != nil {
+			return 0, err
+		}
+	}
+	return s.freq, nil
+}
+
+// search/DisjunctionSumScorer.java
+
+/*
+A Scorer for OR like queries, counterpart of ConjunctionScorer.
+*/
+type DisjunctionSumScorer struct {
+	*DisjunctionScorer
+	score float64
+	coord []float32
+}
+
+/*
+Construct a DisjunctionScorer. subScorers is an array of at least two
+subscorers. coord is a table of coordination factors.
+*/
+func newDisjunctionSumScorer(weight Weight, subScorers []Scorer,
+	coord []float32) *DisjunctionSumScorer {
+
+	ans := &DisjunctionSumScorer{coord: coord}
+	ans.DisjunctionScorer = newDisjunctionScorer(weight, subScorers, ans)
+	return ans
+}
+
+func (s *DisjunctionSumScorer) reset() {
+	s.score = 0
+}
+
+func (s *DisjunctionSumScorer) accum(subScorer Scorer) error {
+	score, err := subScorer.Score()
+	if err != nil {
+		return err
+	}
+	s.score += float64(score)
+	return nil
+}
+
+func (s *DisjunctionSumScorer) final() float32 {
+	return float32(s.score) * s.coord[s.freq]
+}
+
+func (s *DisjunctionSumScorer) String() string {
+	return fmt.Sprintf("DisjunctionSumScorer(%v)", s.weight)
+}
@@ -0,0 +1,237 @@
+package search
+
+import (
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/DocIdSet.java
+
+/*
+A DocIdSet contains a set of doc ids. Implementing classes must only
+implement Iterator() to provide access to the set.
+*/
+type DocIdSet interface {
+	/*
+		Provides a DocIdSetIterator to access the set. This
+		implementation can return nil if there are no docs that match.
+	*/
+	Iterator() (DocIdSetIterator, error)
+	/*
+		Optionally provides a Bits interface for random access to
+		matching documents. Returns nil, if this DocIdSet does not
+		support random access. In contrast to Iterator(), a return value
+		of nil does not imply that no documents match the filter! The
+		default implementation does not provide random access, so you
+		only need to implement this method if your DocIdSet can guarantee
+		random access to every docid in O(1) time without external disk
+		access (as Bits interface cannot return error). This is generally
+		true for bit sets like FixedBitSet, which return itself if they
+		are used as DocIdSet.
+	*/
+	Bits() util.Bits
+	/*
+		This method is a hint for CachingWrapperFilter, if this DocIdSet
+		should be cached without copying it. The default is to return
+		false. If you have an own DocIdSet implementation that does its
+		iteration very effective and fast without doing disk I/O,
+		override this method and return true.
+	*/
+	IsCacheable() bool
+}
+
+/* An empty DocIdSet instance */
+var EMPTY_DOCIDSET = DocIdSet(emptyDocIdSet(0))
+
+type emptyDocIdSet int
+
+func (s emptyDocIdSet) Iterator() (DocIdSetIterator, error) {
+	return newEmptyDocIdSetIterator(), nil
+}
+
+// we explicitly provide no random access, as this filter is 100%
+// sparse and iterator exits faster
+func (s emptyDocIdSet) Bits() util.Bits {
+	return nil
+}
+
+func (s emptyDocIdSet) IsCacheable() bool {
+	return true
+}
+
+// search/DocIdSetIterator.java
+
+/* An empty DocIdSetIterator instance */
+type emptyDocIdSetIterator struct {
+	exhausted bool
+}
+
+func newEmptyDocIdSetIterator() *emptyDocIdSetIterator {
+	return new(emptyDocIdSetIterator)
+}
+
+func (it *emptyDocIdSetIterator) DocId() int {
+	if it.exhausted {
+		return NO_MORE_DOCS
+	}
+	return -1
+}
+
+func (it *emptyDocIdSetIterator) NextDoc() (int, error) {
+	it.exhausted = true
+	return NO_MORE_DOCS, nil
+}
+
+func (it *emptyDocIdSetIterator) Cost() int64 {
+	return 0
+}
+
+func (it *emptyDocIdSetIterator) Advance(target int) (int, error) {
+	it.exhausted = true
+	return NO_MORE_DOCS, nil
+}
+
+// search/FilteredDocIdSet.java
+
+type FilteredDocIdSetSPI interface {
+	// Validation method to determine whether a docid should be in the
+	// result set.
+	Match(docid int) bool
+}
+
+/*
+Abstract decorator class for a DocIdSet implementation that provides
+on-demand filtering/validation mechanism on a given DocIdSet.
+
+The benefit of this class is it never materializes the full bitset
+for the filter. Instead, the Match() method is invoked on-demand, per
+docID visited during searching. If you know few docIDs will be
+visited, and the logic behind Match() is relatively costly, this may
+be a better way to filter than a bitset.
+*/
+type FilteredDocIdSet struct {
+	spi      FilteredDocIdSetSPI
+	innerSet DocIdSet
+}
+
+func NewFilteredDocIdSet(spi FilteredDocIdSetSPI, innerSet DocIdSet) *FilteredDocIdSet {
+	return &FilteredDocIdSet{spi, innerSet}
+}
+
+/* This DocIdSet implementation is cacheable if the inner set is cacheable. */
+func (s *FilteredDocIdSet) IsCacheable() bool {
+	return s.innerSet.IsCacheable()
+}
+
+func (s *FilteredDocIdSet) Bits() util.Bits {
+	if bits := s.innerSet.Bits(); bits != nil {
+		return &filteredBits{bits, s.spi}
+	}
+	return nil
+}
+
+type filteredBits struct {
+	bits util.Bits
+	spi  FilteredDocIdSetSPI
+}
+
+func (b *filteredBits) At(docid int) bool {
+	return b.bits.At(docid) && b.spi.Match(docid)
+}
+
+func (b *filteredBits) Length() int {
+	return b.bits.Length()
+}
+
+/* Implementation of the contract to build a DocIdSetIterator. */
+func (s *FilteredDocIdSet) Iterator() (DocIdSetIterator, error) {
+	iterator, err := s.innerSet.Iterator()
+	if err != nil || iterator == nil {
+		return nil, err
+	}
+	return NewFilteredDocIdSetIterator(s.spi, iterator), nil
+}
+
+// search/FilteredDocIdSetIterator.java
+
+/*
+Abstract decorator class of a DocIdSetIterator implementation that
+provides on-demand filter/validation mechanism on an underlying
+DocIdSetIterator. See FilteredDocIdSet.
+*/
+type FilteredDocIdSetIterator struct {
+	spi       FilteredDocIdSetSPI
+	innerIter DocIdSetIterator
+	doc       int
+}
+
+func NewFilteredDocIdSetIterator(spi FilteredDocIdSetSPI,
+	innerIter DocIdSetIterator) *FilteredDocIdSetIterator {
+
+	assert2(innerIter != nil, "null iterator")
+	return &FilteredDocIdSetIterator{spi, innerIter, -1}
+}
+
+func (it *FilteredDocIdSetIterator) DocId() int {
+	return it.doc
+}
+
+func (it *FilteredDocIdSetIterator) NextDoc() (doc int, err error) {
+	for {
+		if it.doc, err = it.innerIter.NextDoc(); err != nil {
+			return 0, err
+		}
+		if it.doc == NO_MORE_DOCS || it.spi.Match(it.doc) {
+			return it.doc, nil
+		}
+	}
+}
+
+func (it *FilteredDocIdSetIterator) Cost() int64 {
+	return it.innerIter.Cost()
+}
+
+func (it *FilteredDocIdSetIterator) Advance(target int) (doc int, err error) {
+	if it.doc, err = it.innerIter.Advance(target); err != nil {
+		return 0, err
+	}
+	if it.doc != NO_MORE_DOCS && !it.spi.Match(it.doc) {
+		return it.NextDoc()
+	}
+	return it.doc, nil
+}
+
+// search/BitsFilteredDocIdSet.java
+
+/*
+This implementation supplies a filtered DocIdSet, that excludes all
+docids which are not in a Bits instance. This is especially useful in
+Filter to apply the acceptDocs passed to DocIdSet() before returning
+the final DocIdSet.
+*/
+type BitsFilteredDocIdSet struct {
+	*FilteredDocIdSet
+	acceptDocs util.Bits
+}
+
+/*
+Convenience wrapper method: If acceptDocs == nil it returns the
+original set without wrapping.
+*/
+func WrapBitsFilteredDocIdSet(set DocIdSet, acceptDocs util.Bits) DocIdSet {
+	if set == nil || acceptDocs == nil {
+		return set
+	}
+	return newBitsFilteredDocIdSet(set, acceptDocs)
+}
+
+func newBitsFilteredDocIdSet(innerSet DocIdSet, acceptDocs util.Bits) *BitsFilteredDocIdSet {
+	assert2(acceptDocs != nil, "acceptDocs is nil")
+	ans := &BitsFilteredDocIdSet{acceptDocs: acceptDocs}
+	ans.FilteredDocIdSet = NewFilteredDocIdSet(ans, innerSet)
+	return ans
+}
+
+func (s *BitsFilteredDocIdSet) Match(docid int) bool {
+	return s.acceptDocs.At(docid)
+}
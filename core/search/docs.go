@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	. "github.com/balzaczyy/golucene/core/index/model"
 	// . "github.com/balzaczyy/golucene/core/search/model"
@@ -12,6 +13,8 @@ import (
 type Scorer interface {
 	DocsEnum
 	IScorer
+	// Returns the Weight that created this scorer. It may be nil.
+	Weight() Weight
 	// ScoreAndCollect(c Collector) error
 }
 
@@ -37,6 +40,10 @@ func newScorer(spi ScorerSPI, w Weight) *abstractScorer {
 	return &abstractScorer{spi: spi, weight: w}
 }
 
+func (s *abstractScorer) Weight() Weight {
+	return s.weight
+}
+
 /** Scores and collects all matching documents.
  * @param collector The collector to which all matching documents are passed.
  */
@@ -95,3 +102,33 @@ func (bs *BulkScorerImpl) ScoreAndCollect(collector Collector) (err error) {
 	_, err = bs.spi.ScoreAndCollectUpto(collector, math.MaxInt32)
 	return
 }
+
+/* Doc ids scored between context checks; small enough to stop soon after cancellation. */
+const scoreWindowSize = 4096
+
+/*
+Like BulkScorer.ScoreAndCollect(), but unless ctx can never be
+cancelled, the documents are scored in windows, and ctx is checked
+between two windows so that a runaway query can be stopped, in which
+case ctx.Err() is returned.
+*/
+func scoreAndCollectContext(ctx context.Context, scorer BulkScorer, collector Collector) (err error) {
+	done := ctx.Done()
+	if done == nil {
+		return scorer.ScoreAndCollect(collector)
+	}
+	for windowEnd, more := 0, true; more; {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
+		if windowEnd += scoreWindowSize; windowEnd > math.MaxInt32 {
+			windowEnd = math.MaxInt32
+		}
+		if more, err = scorer.ScoreAndCollectUpto(collector, windowEnd); err != nil {
+			return
+		}
+	}
+	return nil
+}
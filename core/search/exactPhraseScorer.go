@@ -0,0 +1,287 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// search/ExactPhraseScorer.java
+
+const exactPhraseChunk = 4096
+
+type chunkState struct {
+	posEnum  DocsAndPositionsEnum
+	offset   int
+	posUpto  int
+	posLimit int
+	pos      int
+	lastPos  int
+}
+
+type ExactPhraseScorer struct {
+	*abstractScorer
+	endMinus1 int
+
+	gen    int
+	counts []int
+	gens   []int
+
+	chunkStates []*chunkState
+	lead        DocsAndPositionsEnum
+
+	docId int
+	freq  int
+
+	docScorer SimScorer
+}
+
+func newExactPhraseScorer(weight Weight, postings []*postingsAndFreq,
+	docScorer SimScorer) *ExactPhraseScorer {
+
+	ans := &ExactPhraseScorer{
+		endMinus1:   len(postings) - 1,
+		counts:      make([]int, exactPhraseChunk),
+		gens:        make([]int, exactPhraseChunk),
+		chunkStates: make([]*chunkState, len(postings)),
+		lead:        postings[0].postings,
+		docId:       -1,
+		docScorer:   docScorer,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	for i, p := range postings {
+		ans.chunkStates[i] = &chunkState{posEnum: p.postings, offset: -p.position}
+	}
+	return ans
+}
+
+func (s *ExactPhraseScorer) doNext(doc int) (int, error) {
+	var err error
+	for {
+		// TODO: don't dup this logic from conjunctionscorer :)
+		advanced := false
+		for _, cs := range s.chunkStates[1:] {
+			de := cs.posEnum
+			if de.DocId() < doc {
+				d, err := de.Advance(doc)
+				if err != nil {
+					return 0, err
+				}
+				if d > doc {
+					// DocsEnum beyond the current doc - break and advance lead
+					// to the new highest doc.
+					doc = d
+					advanced = true
+					break
+				}
+			}
+		}
+
+		if !advanced {
+			// all DocsEnums are on the same doc
+			if doc == NO_MORE_DOCS {
+				return doc, nil
+			}
+			freq, err := s.phraseFreq()
+			if err != nil {
+				return 0, err
+			}
+			if freq > 0 {
+				return doc, nil // success: matches phrase
+			}
+			// doesn't match phrase
+			if doc, err = s.lead.NextDoc(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		// advance head for next iteration
+		if doc, err = s.lead.Advance(doc); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (s *ExactPhraseScorer) NextDoc() (doc int, err error) {
+	if doc, err = s.lead.NextDoc(); err != nil {
+		return 0, err
+	}
+	if s.docId, err = s.doNext(doc); err != nil {
+		return 0, err
+	}
+	return s.docId, nil
+}
+
+func (s *ExactPhraseScorer) Cost() int64 {
+	return s.lead.Cost()
+}
+
+func (s *ExactPhraseScorer) Advance(target int) (doc int, err error) {
+	if doc, err = s.lead.Advance(target); err != nil {
+		return 0, err
+	}
+	if s.docId, err = s.doNext(doc); err != nil {
+		return 0, err
+	}
+	return s.docId, nil
+}
+
+func (s *ExactPhraseScorer) String() string {
+	return fmt.Sprintf("ExactPhraseScorer(%v)", s.weight)
+}
+
+func (s *ExactPhraseScorer) Freq() (int, error) {
+	return s.freq, nil
+}
+
+func (s *ExactPhraseScorer) DocId() int {
+	return s.docId
+}
+
+func (s *ExactPhraseScorer) Score() (float32, error) {
+	return s.docScorer.Score(s.docId, float32(s.freq)), nil
+}
+
+/* Advances cs to its next position, returning false if it ran out. */
+func (cs *chunkState) nextPosition() (bool, error) {
+	if cs.posUpto == cs.posLimit {
+		return false, nil
+	}
+	cs.posUpto++
+	pos, err := cs.posEnum.NextPosition()
+	if err != nil {
+		return false, err
+	}
+	cs.pos = cs.offset + pos
+	return true, nil
+}
+
+func (s *ExactPhraseScorer) phraseFreq() (int, error) {
+	s.freq = 0
+
+	// init chunks
+	for _, cs := range s.chunkStates {
+		var err error
+		if cs.posLimit, err = cs.posEnum.Freq(); err != nil {
+			return 0, err
+		}
+		pos, err := cs.posEnum.NextPosition()
+		if err != nil {
+			return 0, err
+		}
+		cs.pos = cs.offset + pos
+		cs.posUpto = 1
+		cs.lastPos = -1
+	}
+
+	chunkStart := 0
+	chunkEnd := exactPhraseChunk
+
+	// process chunk by chunk
+	end := false
+
+	// TODO: we could fold in chunkStart into offset and save one
+	// subtract per pos incr
+
+	for !end {
+		s.gen++
+
+		if s.gen == 0 {
+			// wraparound
+			for i, _ := range s.gens {
+				s.gens[i] = 0
+			}
+			s.gen++
+		}
+
+		// first term
+		{
+			cs := s.chunkStates[0]
+			for cs.pos < chunkEnd {
+				if cs.pos > cs.lastPos {
+					cs.lastPos = cs.pos
+					posIndex := cs.pos - chunkStart
+					s.counts[posIndex] = 1
+					assert(s.gens[posIndex] != s.gen)
+					s.gens[posIndex] = s.gen
+				}
+
+				ok, err := cs.nextPosition()
+				if err != nil {
+					return 0, err
+				}
+				if !ok {
+					end = true
+					break
+				}
+			}
+		}
+
+		// middle terms
+		viable := true
+		for t := 1; t < s.endMinus1; t++ {
+			cs := s.chunkStates[t]
+			viable = false
+			for cs.pos < chunkEnd {
+				if cs.pos > cs.lastPos {
+					cs.lastPos = cs.pos
+					posIndex := cs.pos - chunkStart
+					if posIndex >= 0 && s.gens[posIndex] == s.gen && s.counts[posIndex] == t {
+						// viable
+						s.counts[posIndex]++
+						viable = true
+					}
+				}
+
+				ok, err := cs.nextPosition()
+				if err != nil {
+					return 0, err
+				}
+				if !ok {
+					end = true
+					break
+				}
+			}
+
+			if !viable {
+				break
+			}
+		}
+
+		if !viable {
+			// petered out for this chunk
+			chunkStart += exactPhraseChunk
+			chunkEnd += exactPhraseChunk
+			continue
+		}
+
+		// last term
+		{
+			cs := s.chunkStates[s.endMinus1]
+			for cs.pos < chunkEnd {
+				if cs.pos > cs.lastPos {
+					cs.lastPos = cs.pos
+					posIndex := cs.pos - chunkStart
+					if posIndex >= 0 && s.gens[posIndex] == s.gen && s.counts[posIndex] == s.endMinus1 {
+						s.freq++
+					}
+				}
+
+				ok, err := cs.nextPosition()
+				if err != nil {
+					return 0, err
+				}
+				if !ok {
+					end = true
+					break
+				}
+			}
+		}
+
+		chunkStart += exactPhraseChunk
+		chunkEnd += exactPhraseChunk
+	}
+
+	return s.freq, nil
+}
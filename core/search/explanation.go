@@ -31,7 +31,8 @@ type ExplanationImpl struct {
 	details     []Explanation // sub-explanations
 }
 
-func newExplanation(value float32, description string) *ExplanationImpl {
+/* Creates an explanation node of the given value and description. */
+func NewExplanation(value float32, description string) *ExplanationImpl {
 	ans := &ExplanationImpl{value: value, description: description}
 	ans.spi = ans
 	return ans
@@ -51,7 +52,7 @@ func (exp *ExplanationImpl) Details() []Explanation {
 }
 
 // Adds a sub-node to this explanation node
-func (exp *ExplanationImpl) addDetail(detail Explanation) {
+func (exp *ExplanationImpl) AddDetail(detail Explanation) {
 	exp.details = append(exp.details, detail)
 }
 
@@ -94,9 +95,10 @@ func newEmptyComplexExplanation() *ComplexExplanation {
 	return ans
 }
 
-func newComplexExplanation(match bool, value float32, desc string) *ComplexExplanation {
+/* Creates an explanation node with an explicit match status. */
+func NewComplexExplanation(match bool, value float32, desc string) *ComplexExplanation {
 	ans := new(ComplexExplanation)
-	ans.ExplanationImpl = newExplanation(value, desc)
+	ans.ExplanationImpl = NewExplanation(value, desc)
 	ans.spi = ans
 	ans.match = match
 	return ans
@@ -0,0 +1,780 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// search/FieldCache.java
+
+/*
+Expert: Maintains caches of term values.
+
+Values are built per segment by uninverting the indexed terms of a
+field, and cached against the segment's core, so they are shared by
+all readers opened on the same segment. If the field was indexed
+with the matching doc values type instead, those values are returned
+directly without caching.
+*/
+type FieldCache interface {
+	// Returns an int32 for every document in the reader, parsed from
+	// the terms of the given field with the given parser. If parser is
+	// nil, the terms are parsed as decimal strings. If
+	// setDocsWithField is true, the Bits of documents with a value
+	// for the field are also cached for DocsWithField().
+	Ints(reader index.AtomicReader, field string, parser IntParser,
+		setDocsWithField bool) (FieldCacheInts, error)
+	// Returns an int64 for every document in the reader. See Ints().
+	Longs(reader index.AtomicReader, field string, parser LongParser,
+		setDocsWithField bool) (FieldCacheLongs, error)
+	// Returns a float32 for every document in the reader. See Ints().
+	Floats(reader index.AtomicReader, field string, parser FloatParser,
+		setDocsWithField bool) (FieldCacheFloats, error)
+	// Returns a float64 for every document in the reader. See Ints().
+	Doubles(reader index.AtomicReader, field string, parser DoubleParser,
+		setDocsWithField bool) (FieldCacheDoubles, error)
+	// Checks the internal cache for an appropriate entry, and if none
+	// is found, reads the terms in field and returns a bit set at the
+	// size of reader.MaxDoc(), with turned on bits for each docid
+	// that does have a value for this field.
+	DocsWithField(reader index.AtomicReader, field string) (util.Bits, error)
+	// Checks the internal cache for an appropriate entry, and if none
+	// is found, reads the term values in field and returns a
+	// SortedDocValues instance, providing a method to retrieve the
+	// term (as []byte) per document.
+	TermsIndex(reader index.AtomicReader, field string) (SortedDocValues, error)
+	// Expert: drops all cache entries associated with this reader
+	// core cache key.
+	PurgeByCacheKey(coreCacheKey interface{})
+	// Expert: instructs the FieldCache to forcefully purge all cache
+	// entries.
+	PurgeAllCaches()
+}
+
+/* Field values as 32-bit signed integers */
+type FieldCacheInts func(docID int) int32
+
+/* Field values as 64-bit signed long integers */
+type FieldCacheLongs func(docID int) int64
+
+/* Field values as 32-bit floats */
+type FieldCacheFloats func(docID int) float32
+
+/* Field values as 64-bit doubles */
+type FieldCacheDoubles func(docID int) float64
+
+var (
+	EMPTY_INTS    = FieldCacheInts(func(int) int32 { return 0 })
+	EMPTY_LONGS   = FieldCacheLongs(func(int) int64 { return 0 })
+	EMPTY_FLOATS  = FieldCacheFloats(func(int) float32 { return 0 })
+	EMPTY_DOUBLES = FieldCacheDoubles(func(int) float64 { return 0 })
+)
+
+/* Marker interface as super-interface to all parsers. */
+type FieldCacheParser interface {
+	// Returns a TermsEnum that only iterates the terms to be parsed,
+	// which allows the parser to skip terms which are not valid.
+	TermsEnum(terms Terms) TermsEnum
+}
+
+/* Interface to parse int32 from document fields. */
+type IntParser interface {
+	FieldCacheParser
+	// Return an int32 representation of this field's value.
+	ParseInt(term []byte) (int32, error)
+}
+
+/* Interface to parse int64 from document fields. */
+type LongParser interface {
+	FieldCacheParser
+	// Return an int64 representation of this field's value.
+	ParseLong(term []byte) (int64, error)
+}
+
+/* Interface to parse float32 from document fields. */
+type FloatParser interface {
+	FieldCacheParser
+	// Return a float32 representation of this field's value.
+	ParseFloat(term []byte) (float32, error)
+}
+
+/* Interface to parse float64 from document fields. */
+type DoubleParser interface {
+	FieldCacheParser
+	// Return a float64 representation of this field's value.
+	ParseDouble(term []byte) (float64, error)
+}
+
+type textParser struct{}
+
+func (p *textParser) TermsEnum(terms Terms) TermsEnum {
+	return terms.Iterator(nil)
+}
+
+/* The default parser for int32 values, which are encoded as decimal strings. */
+var DEFAULT_INT_PARSER IntParser = new(defaultIntParser)
+
+type defaultIntParser struct{ textParser }
+
+func (p *defaultIntParser) ParseInt(term []byte) (int32, error) {
+	n, err := strconv.ParseInt(string(term), 10, 32)
+	return int32(n), err
+}
+
+func (p *defaultIntParser) String() string { return "FieldCache.DEFAULT_INT_PARSER" }
+
+/* The default parser for int64 values, which are encoded as decimal strings. */
+var DEFAULT_LONG_PARSER LongParser = new(defaultLongParser)
+
+type defaultLongParser struct{ textParser }
+
+func (p *defaultLongParser) ParseLong(term []byte) (int64, error) {
+	return strconv.ParseInt(string(term), 10, 64)
+}
+
+func (p *defaultLongParser) String() string { return "FieldCache.DEFAULT_LONG_PARSER" }
+
+/* The default parser for float32 values, which are encoded as decimal strings. */
+var DEFAULT_FLOAT_PARSER FloatParser = new(defaultFloatParser)
+
+type defaultFloatParser struct{ textParser }
+
+func (p *defaultFloatParser) ParseFloat(term []byte) (float32, error) {
+	f, err := strconv.ParseFloat(string(term), 32)
+	return float32(f), err
+}
+
+func (p *defaultFloatParser) String() string { return "FieldCache.DEFAULT_FLOAT_PARSER" }
+
+/* The default parser for float64 values, which are encoded as decimal strings. */
+var DEFAULT_DOUBLE_PARSER DoubleParser = new(defaultDoubleParser)
+
+type defaultDoubleParser struct{ textParser }
+
+func (p *defaultDoubleParser) ParseDouble(term []byte) (float64, error) {
+	return strconv.ParseFloat(string(term), 64)
+}
+
+func (p *defaultDoubleParser) String() string { return "FieldCache.DEFAULT_DOUBLE_PARSER" }
+
+/*
+A parser instance for int32 values encoded by util.NumericUtils, e.g.
+when indexed via IntField/NumericTokenStream.
+*/
+var NUMERIC_UTILS_INT_PARSER IntParser = new(numericUtilsIntParser)
+
+type numericUtilsIntParser struct{}
+
+func (p *numericUtilsIntParser) ParseInt(term []byte) (int32, error) {
+	return util.PrefixCodedToInt(term)
+}
+
+func (p *numericUtilsIntParser) TermsEnum(terms Terms) TermsEnum {
+	return newFullPrecisionTermsEnum(terms.Iterator(nil), util.PrefixCodedIntShift)
+}
+
+func (p *numericUtilsIntParser) String() string { return "FieldCache.NUMERIC_UTILS_INT_PARSER" }
+
+/*
+A parser instance for int64 values encoded by util.NumericUtils, e.g.
+when indexed via LongField/NumericTokenStream.
+*/
+var NUMERIC_UTILS_LONG_PARSER LongParser = new(numericUtilsLongParser)
+
+type numericUtilsLongParser struct{}
+
+func (p *numericUtilsLongParser) ParseLong(term []byte) (int64, error) {
+	return util.PrefixCodedToLong(term)
+}
+
+func (p *numericUtilsLongParser) TermsEnum(terms Terms) TermsEnum {
+	return newFullPrecisionTermsEnum(terms.Iterator(nil), util.PrefixCodedLongShift)
+}
+
+func (p *numericUtilsLongParser) String() string { return "FieldCache.NUMERIC_UTILS_LONG_PARSER" }
+
+/*
+A parser instance for float32 values encoded with util.NumericUtils,
+e.g. when indexed via FloatField/NumericTokenStream.
+*/
+var NUMERIC_UTILS_FLOAT_PARSER FloatParser = new(numericUtilsFloatParser)
+
+type numericUtilsFloatParser struct{}
+
+func (p *numericUtilsFloatParser) ParseFloat(term []byte) (float32, error) {
+	v, err := util.PrefixCodedToInt(term)
+	return util.SortableIntToFloat(v), err
+}
+
+func (p *numericUtilsFloatParser) TermsEnum(terms Terms) TermsEnum {
+	return newFullPrecisionTermsEnum(terms.Iterator(nil), util.PrefixCodedIntShift)
+}
+
+func (p *numericUtilsFloatParser) String() string { return "FieldCache.NUMERIC_UTILS_FLOAT_PARSER" }
+
+/*
+A parser instance for float64 values encoded with util.NumericUtils,
+e.g. when indexed via DoubleField/NumericTokenStream.
+*/
+var NUMERIC_UTILS_DOUBLE_PARSER DoubleParser = new(numericUtilsDoubleParser)
+
+type numericUtilsDoubleParser struct{}
+
+func (p *numericUtilsDoubleParser) ParseDouble(term []byte) (float64, error) {
+	v, err := util.PrefixCodedToLong(term)
+	return util.SortableLongToDouble(v), err
+}
+
+func (p *numericUtilsDoubleParser) TermsEnum(terms Terms) TermsEnum {
+	return newFullPrecisionTermsEnum(terms.Iterator(nil), util.PrefixCodedLongShift)
+}
+
+func (p *numericUtilsDoubleParser) String() string { return "FieldCache.NUMERIC_UTILS_DOUBLE_PARSER" }
+
+/*
+Filters a TermsEnum of prefix coded numbers to only the full precision
+terms, i.e. those with a shift value of 0. As the lower precision
+terms sort after them, the enum ends at the first one.
+*/
+type fullPrecisionTermsEnum struct {
+	*index.FilteredTermsEnum
+	shift func(term []byte) (int, error)
+}
+
+func newFullPrecisionTermsEnum(tenum TermsEnum, shift func([]byte) (int, error)) *fullPrecisionTermsEnum {
+	ans := &fullPrecisionTermsEnum{shift: shift}
+	ans.FilteredTermsEnum = index.NewFilteredTermsEnum(ans, tenum, false)
+	return ans
+}
+
+func (e *fullPrecisionTermsEnum) Accept(term []byte) (index.AcceptStatus, error) {
+	shift, err := e.shift(term)
+	if err != nil {
+		return 0, err
+	}
+	if shift == 0 {
+		return index.ACCEPT_STATUS_YES, nil
+	}
+	return index.ACCEPT_STATUS_END, nil
+}
+
+/* Expert: The cache used internally by sorting and range query classes. */
+var DEFAULT_FIELD_CACHE FieldCache = newFieldCacheImpl()
+
+// search/FieldCacheImpl.java
+
+/* Expert: The default cache implementation, storing all values in memory. */
+type fieldCacheImpl struct {
+	sync.Locker
+	caches    map[interface{}]map[fieldCacheKey]interface{}
+	purgeCore *purgeListener
+}
+
+/* Expert: Every composite-key in the internal cache is of this type. */
+type fieldCacheKey struct {
+	kind   string
+	field  string
+	parser FieldCacheParser
+}
+
+func newFieldCacheImpl() *fieldCacheImpl {
+	c := &fieldCacheImpl{
+		Locker: &sync.Mutex{},
+		caches: make(map[interface{}]map[fieldCacheKey]interface{}),
+	}
+	c.purgeCore = &purgeListener{c.PurgeByCacheKey}
+	return c
+}
+
+func (c *fieldCacheImpl) PurgeByCacheKey(coreCacheKey interface{}) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.caches, coreCacheKey)
+}
+
+func (c *fieldCacheImpl) PurgeAllCaches() {
+	c.Lock()
+	defer c.Unlock()
+	c.caches = make(map[interface{}]map[fieldCacheKey]interface{})
+}
+
+/*
+Returns the cached value for the given key, or creates it with the
+given function. Values are created outside the lock; if two
+goroutines race, both compute the value and the last one wins. The
+entries of a segment core are purged once the core is closed.
+*/
+func (c *fieldCacheImpl) get(reader index.AtomicReader, key fieldCacheKey,
+	create func() (interface{}, error)) (interface{}, error) {
+
+	readerKey := coreCacheKey(reader)
+	c.Lock()
+	value, ok := c.caches[readerKey][key]
+	c.Unlock()
+	if ok {
+		return value, nil
+	}
+
+	// not under the lock, as the listener is notified under the
+	// core's lock and then takes ours
+	addCoreClosedListener(reader, c.purgeCore)
+	value, err := create()
+	if err != nil {
+		return nil, err
+	}
+	c.put(readerKey, key, value)
+	return value, nil
+}
+
+func (c *fieldCacheImpl) put(readerKey interface{}, key fieldCacheKey, value interface{}) {
+	c.Lock()
+	defer c.Unlock()
+	innerCache, ok := c.caches[readerKey]
+	if !ok {
+		innerCache = make(map[fieldCacheKey]interface{})
+		c.caches[readerKey] = innerCache
+	}
+	innerCache[key] = value
+}
+
+/*
+Decides how a field should be read: returns ok=false if the field
+has no indexed terms to uninvert, or an error if the field was
+indexed with doc values of another type.
+*/
+func uninvertible(reader index.AtomicReader, field string) (ok bool, err error) {
+	info := reader.FieldInfos().FieldInfoByName(field)
+	if info == nil {
+		return false, nil
+	} else if info.HasDocValues() {
+		return false, errors.New(fmt.Sprintf("Type mismatch: %v was indexed as %v",
+			field, info.DocValuesType()))
+	}
+	return info.IsIndexed(), nil
+}
+
+/*
+Walks all terms of the field, calling visitTerm for each term and
+visitDoc for every document containing the term, and returns the
+Bits of documents which have a value if setDocsWithField is true.
+*/
+func uninvert(reader index.AtomicReader, field string, setDocsWithField bool,
+	termsEnum func(terms Terms) TermsEnum,
+	visitTerm func(term []byte) error, visitDoc func(docID int)) (util.Bits, error) {
+
+	maxDoc := reader.MaxDoc()
+	terms := reader.Terms(field)
+	if terms == nil {
+		return nil, nil
+	}
+
+	var docsWithField util.Bits
+	if setDocsWithField {
+		if termsDocCount := terms.DocCount(); termsDocCount == maxDoc {
+			// Fast case: all docs have this field:
+			docsWithField = util.NewMatchAllBits(maxDoc)
+			setDocsWithField = false
+		}
+	}
+
+	var bits *util.FixedBitSet
+	var docs DocsEnum
+	te := termsEnum(terms)
+	for {
+		term, err := te.Next()
+		if err != nil {
+			return nil, err
+		}
+		if term == nil {
+			break
+		}
+		if err = visitTerm(term); err != nil {
+			return nil, err
+		}
+		if docs, err = te.DocsByFlags(nil, docs, DOCS_ENUM_FLAG_NONE); err != nil {
+			return nil, err
+		}
+		for {
+			docID, err := docs.NextDoc()
+			if err != nil {
+				return nil, err
+			}
+			if docID == NO_MORE_DOCS {
+				break
+			}
+			visitDoc(docID)
+			if setDocsWithField {
+				if bits == nil {
+					// Lazy init
+					bits = util.NewFixedBitSetOf(maxDoc)
+				}
+				bits.Set(docID)
+			}
+		}
+	}
+	if bits != nil {
+		return bits, nil
+	}
+	return docsWithField, nil
+}
+
+/* Caches the docs with field computed while uninverting, if any. */
+func (c *fieldCacheImpl) setDocsWithField(reader index.AtomicReader,
+	field string, docsWithField util.Bits) {
+
+	if docsWithField == nil {
+		return
+	}
+	if bits, ok := docsWithField.(*util.FixedBitSet); ok {
+		if bits.Cardinality() >= reader.MaxDoc() {
+			// The cardinality of the BitSet is maxDoc if all documents
+			// have a value.
+			docsWithField = util.NewMatchAllBits(reader.MaxDoc())
+		}
+	}
+	c.put(coreCacheKey(reader), fieldCacheKey{kind: "docsWithField", field: field}, docsWithField)
+}
+
+func (c *fieldCacheImpl) Ints(reader index.AtomicReader, field string,
+	parser IntParser, setDocsWithField bool) (FieldCacheInts, error) {
+
+	valuesIn, err := reader.NumericDocValues(field)
+	if err != nil {
+		return nil, err
+	}
+	if valuesIn != nil {
+		// Not cached here by FieldCacheImpl (cached instead per-thread
+		// by SegmentReader):
+		return func(docID int) int32 {
+			return int32(valuesIn(docID))
+		}, nil
+	}
+	if ok, err := uninvertible(reader, field); !ok || err != nil {
+		return EMPTY_INTS, err
+	}
+	if parser == nil {
+		// Must delegate to the wrapper (vs simply setting parser =
+		// DEFAULT_INT_PARSER) so the cache key includes the parser; fall
+		// back to the NumericUtils encoding if the terms are no decimals:
+		ans, err := c.Ints(reader, field, DEFAULT_INT_PARSER, setDocsWithField)
+		if _, ok := err.(*strconv.NumError); ok {
+			return c.Ints(reader, field, NUMERIC_UTILS_INT_PARSER, setDocsWithField)
+		}
+		return ans, err
+	}
+
+	v, err := c.get(reader, fieldCacheKey{"int", field, parser}, func() (interface{}, error) {
+		var values []int32
+		var currentValue int32
+		docsWithField, err := uninvert(reader, field, setDocsWithField, parser.TermsEnum,
+			func(term []byte) (err error) {
+				currentValue, err = parser.ParseInt(term)
+				if values == nil {
+					// Lazy alloc so for the numeric field case (which
+					// will hit a parse error on the first term) we don't
+					// double-alloc:
+					values = make([]int32, reader.MaxDoc())
+				}
+				return
+			}, func(docID int) {
+				values[docID] = currentValue
+			})
+		if err != nil {
+			return nil, err
+		}
+		c.setDocsWithField(reader, field, docsWithField)
+		if values == nil {
+			return EMPTY_INTS, nil
+		}
+		return FieldCacheInts(func(docID int) int32 { return values[docID] }), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(FieldCacheInts), nil
+}
+
+func (c *fieldCacheImpl) Longs(reader index.AtomicReader, field string,
+	parser LongParser, setDocsWithField bool) (FieldCacheLongs, error) {
+
+	valuesIn, err := reader.NumericDocValues(field)
+	if err != nil {
+		return nil, err
+	}
+	if valuesIn != nil {
+		// Not cached here by FieldCacheImpl (cached instead per-thread
+		// by SegmentReader):
+		return FieldCacheLongs(valuesIn), nil
+	}
+	if ok, err := uninvertible(reader, field); !ok || err != nil {
+		return EMPTY_LONGS, err
+	}
+	if parser == nil {
+		// Must delegate to the wrapper (vs simply setting parser =
+		// DEFAULT_LONG_PARSER) so the cache key includes the parser; fall
+		// back to the NumericUtils encoding if the terms are no decimals:
+		ans, err := c.Longs(reader, field, DEFAULT_LONG_PARSER, setDocsWithField)
+		if _, ok := err.(*strconv.NumError); ok {
+			return c.Longs(reader, field, NUMERIC_UTILS_LONG_PARSER, setDocsWithField)
+		}
+		return ans, err
+	}
+
+	v, err := c.get(reader, fieldCacheKey{"long", field, parser}, func() (interface{}, error) {
+		var values []int64
+		var currentValue int64
+		docsWithField, err := uninvert(reader, field, setDocsWithField, parser.TermsEnum,
+			func(term []byte) (err error) {
+				currentValue, err = parser.ParseLong(term)
+				if values == nil {
+					values = make([]int64, reader.MaxDoc())
+				}
+				return
+			}, func(docID int) {
+				values[docID] = currentValue
+			})
+		if err != nil {
+			return nil, err
+		}
+		c.setDocsWithField(reader, field, docsWithField)
+		if values == nil {
+			return EMPTY_LONGS, nil
+		}
+		return FieldCacheLongs(func(docID int) int64 { return values[docID] }), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(FieldCacheLongs), nil
+}
+
+func (c *fieldCacheImpl) Floats(reader index.AtomicReader, field string,
+	parser FloatParser, setDocsWithField bool) (FieldCacheFloats, error) {
+
+	valuesIn, err := reader.NumericDocValues(field)
+	if err != nil {
+		return nil, err
+	}
+	if valuesIn != nil {
+		// Not cached here by FieldCacheImpl (cached instead per-thread
+		// by SegmentReader):
+		return func(docID int) float32 {
+			return math.Float32frombits(uint32(valuesIn(docID)))
+		}, nil
+	}
+	if ok, err := uninvertible(reader, field); !ok || err != nil {
+		return EMPTY_FLOATS, err
+	}
+	if parser == nil {
+		// Must delegate to the wrapper (vs simply setting parser =
+		// DEFAULT_FLOAT_PARSER) so the cache key includes the parser; fall
+		// back to the NumericUtils encoding if the terms are no decimals:
+		ans, err := c.Floats(reader, field, DEFAULT_FLOAT_PARSER, setDocsWithField)
+		if _, ok := err.(*strconv.NumError); ok {
+			return c.Floats(reader, field, NUMERIC_UTILS_FLOAT_PARSER, setDocsWithField)
+		}
+		return ans, err
+	}
+
+	v, err := c.get(reader, fieldCacheKey{"float", field, parser}, func() (interface{}, error) {
+		var values []float32
+		var currentValue float32
+		docsWithField, err := uninvert(reader, field, setDocsWithField, parser.TermsEnum,
+			func(term []byte) (err error) {
+				currentValue, err = parser.ParseFloat(term)
+				if values == nil {
+					values = make([]float32, reader.MaxDoc())
+				}
+				return
+			}, func(docID int) {
+				values[docID] = currentValue
+			})
+		if err != nil {
+			return nil, err
+		}
+		c.setDocsWithField(reader, field, docsWithField)
+		if values == nil {
+			return EMPTY_FLOATS, nil
+		}
+		return FieldCacheFloats(func(docID int) float32 { return values[docID] }), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(FieldCacheFloats), nil
+}
+
+func (c *fieldCacheImpl) Doubles(reader index.AtomicReader, field string,
+	parser DoubleParser, setDocsWithField bool) (FieldCacheDoubles, error) {
+
+	valuesIn, err := reader.NumericDocValues(field)
+	if err != nil {
+		return nil, err
+	}
+	if valuesIn != nil {
+		// Not cached here by FieldCacheImpl (cached instead per-thread
+		// by SegmentReader):
+		return func(docID int) float64 {
+			return math.Float64frombits(uint64(valuesIn(docID)))
+		}, nil
+	}
+	if ok, err := uninvertible(reader, field); !ok || err != nil {
+		return EMPTY_DOUBLES, err
+	}
+	if parser == nil {
+		// Must delegate to the wrapper (vs simply setting parser =
+		// DEFAULT_DOUBLE_PARSER) so the cache key includes the parser; fall
+		// back to the NumericUtils encoding if the terms are no decimals:
+		ans, err := c.Doubles(reader, field, DEFAULT_DOUBLE_PARSER, setDocsWithField)
+		if _, ok := err.(*strconv.NumError); ok {
+			return c.Doubles(reader, field, NUMERIC_UTILS_DOUBLE_PARSER, setDocsWithField)
+		}
+		return ans, err
+	}
+
+	v, err := c.get(reader, fieldCacheKey{"double", field, parser}, func() (interface{}, error) {
+		var values []float64
+		var currentValue float64
+		docsWithField, err := uninvert(reader, field, setDocsWithField, parser.TermsEnum,
+			func(term []byte) (err error) {
+				currentValue, err = parser.ParseDouble(term)
+				if values == nil {
+					values = make([]float64, reader.MaxDoc())
+				}
+				return
+			}, func(docID int) {
+				values[docID] = currentValue
+			})
+		if err != nil {
+			return nil, err
+		}
+		c.setDocsWithField(reader, field, docsWithField)
+		if values == nil {
+			return EMPTY_DOUBLES, nil
+		}
+		return FieldCacheDoubles(func(docID int) float64 { return values[docID] }), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(FieldCacheDoubles), nil
+}
+
+func (c *fieldCacheImpl) DocsWithField(reader index.AtomicReader, field string) (util.Bits, error) {
+	info := reader.FieldInfos().FieldInfoByName(field)
+	if info == nil {
+		// field does not exist or has no value
+		return util.NewMatchNoBits(reader.MaxDoc()), nil
+	} else if info.HasDocValues() {
+		return reader.DocsWithField(field)
+	} else if !info.IsIndexed() {
+		return util.NewMatchNoBits(reader.MaxDoc()), nil
+	}
+
+	v, err := c.get(reader, fieldCacheKey{kind: "docsWithField", field: field}, func() (interface{}, error) {
+		docsWithField, err := uninvert(reader, field, true,
+			func(terms Terms) TermsEnum { return terms.Iterator(nil) },
+			func([]byte) error { return nil }, func(int) {})
+		if err != nil {
+			return nil, err
+		}
+		if docsWithField == nil {
+			return util.NewMatchNoBits(reader.MaxDoc()), nil
+		}
+		if bits, ok := docsWithField.(*util.FixedBitSet); ok &&
+			bits.Cardinality() >= reader.MaxDoc() {
+			return util.NewMatchAllBits(reader.MaxDoc()), nil
+		}
+		return docsWithField, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(util.Bits), nil
+}
+
+func (c *fieldCacheImpl) TermsIndex(reader index.AtomicReader, field string) (SortedDocValues, error) {
+	valuesIn, err := reader.SortedDocValues(field)
+	if err != nil {
+		return nil, err
+	}
+	if valuesIn != nil {
+		// Not cached here by FieldCacheImpl (cached instead per-thread
+		// by SegmentReader):
+		return valuesIn, nil
+	}
+	if ok, err := uninvertible(reader, field); !ok || err != nil {
+		return EMPTY_SORTED_DOC_VALUES, err
+	}
+
+	v, err := c.get(reader, fieldCacheKey{kind: "termsIndex", field: field}, func() (interface{}, error) {
+		// Holds the actual term data, expanded.
+		var terms [][]byte
+		// Holds, for each doc, 1+ the ord of its term; 0 means the doc
+		// has no term.
+		docToTermOrd := packed.NewGrowableWriter(1, reader.MaxDoc(), packed.PackedInts.FAST)
+		_, err := uninvert(reader, field, false,
+			func(terms Terms) TermsEnum { return terms.Iterator(nil) },
+			func(term []byte) error {
+				terms = append(terms, append([]byte(nil), term...))
+				return nil
+			}, func(docID int) {
+				docToTermOrd.Set(docID, int64(len(terms)))
+			})
+		if err != nil {
+			return nil, err
+		}
+		return &sortedDocValuesImpl{terms, docToTermOrd}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(SortedDocValues), nil
+}
+
+/* Uninverted terms of a field, implementing SortedDocValues. */
+type sortedDocValuesImpl struct {
+	terms        [][]byte
+	docToTermOrd *packed.GrowableWriter
+}
+
+func (v *sortedDocValuesImpl) Ord(docID int) int {
+	// Subtract 1, matching the 1+ord we did when storing, so that
+	// missing values, which are 0 in the packed ints, are returned
+	// as -1 ord:
+	return int(v.docToTermOrd.Get(docID)) - 1
+}
+
+func (v *sortedDocValuesImpl) LookupOrd(ord int) []byte {
+	return v.terms[ord]
+}
+
+func (v *sortedDocValuesImpl) ValueCount() int {
+	return len(v.terms)
+}
+
+func (v *sortedDocValuesImpl) Get(docID int) []byte {
+	if ord := v.Ord(docID); ord != -1 {
+		return v.LookupOrd(ord)
+	}
+	return nil
+}
+
+/* An empty SortedDocValues which returns nil for every document */
+var EMPTY_SORTED_DOC_VALUES SortedDocValues = emptySortedDocValues{}
+
+type emptySortedDocValues struct{}
+
+func (v emptySortedDocValues) Ord(docID int) int        { return -1 }
+func (v emptySortedDocValues) LookupOrd(ord int) []byte { return nil }
+func (v emptySortedDocValues) ValueCount() int          { return 0 }
+func (v emptySortedDocValues) Get(docID int) []byte     { return nil }
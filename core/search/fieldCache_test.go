@@ -0,0 +1,63 @@
+package search
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestCachesPurgedOnCoreClose(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return NewDefaultSimilarity()
+	}
+	path := ".gltest_purge"
+	os.RemoveAll(path)
+	defer os.RemoveAll(path)
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i := 0; i < 10; i++ {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("num", strconv.Itoa(i), docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		if i == 4 {
+			err = writer.Commit()
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+		}
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	leaves := len(reader.Leaves())
+	It(t).Should("expect 2 segments, but got %v", leaves).Assert(leaves == 2)
+
+	c := newFieldCacheImpl()
+	f := NewCachingWrapperFilter(NewQueryWrapperFilter(NewMatchAllDocsQuery()))
+	for _, ctx := range reader.Leaves() {
+		_, err = c.Ints(ctx.Reader().(index.AtomicReader), "num", nil, false)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		_, err = f.DocIdSet(ctx, nil)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	It(t).Should("expect %v cached cores, but got %v", leaves, len(c.caches)).Verify(len(c.caches) == leaves)
+	It(t).Should("expect %v cached cores, but got %v", leaves, len(f.cache)).Verify(len(f.cache) == leaves)
+
+	err = reader.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect no cached cores, but got %v", len(c.caches)).Verify(len(c.caches) == 0)
+	It(t).Should("expect no cached cores, but got %v", len(f.cache)).Verify(len(f.cache) == 0)
+}
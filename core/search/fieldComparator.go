@@ -0,0 +1,749 @@
+package search
+
+import (
+	"bytes"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+)
+
+// search/FieldComparator.java
+
+/*
+Expert: a FieldComparator compares hits so as to determine their
+sort order when collecting the top results with TopFieldCollector.
+The concrete public FieldComparator classes here correspond to the
+SortField types.
+
+This API is designed to achieve high performance sorting, by exposing
+a tight interaction with FieldValueHitQueue as it visits hits.
+Whenever a hit is competitive, it's enrolled into a virtual slot,
+which is an int ranging from 0 to numHits-1. The FieldComparator is
+made aware of segment transitions during searching in case any
+internal state it's tracking needs to be recomputed during these
+transitions.
+
+A comparator must define these functions:
+
+  - Compare: compare a hit at 'slot a' with hit 'slot b'.
+  - SetBottom: this method is called by FieldValueHitQueue to notify
+    the FieldComparator of the current weakest ("bottom") slot. Note
+    that this slot may not hold the weakest value according to your
+    comparator, in cases where your comparator is not the primary
+    one (ie, is only used to break ties from the comparators before
+    it).
+  - CompareBottom: compare a new hit (docID) against the "weakest"
+    (bottom) entry in the queue.
+  - Copy: installs a new hit into the priority queue. The
+    FieldValueHitQueue calls this method when a new hit is
+    competitive.
+  - SetNextReader: invoked when the search is switching to the next
+    segment. You may need to update internal state of the comparator,
+    for example retrieving new values from the FieldCache.
+  - Value: return the sort value stored in the specified slot. This
+    is only called at the end of the search, in order to populate
+    FieldDoc.Fields when returning the top results.
+  - CompareValues: compare two values returned by Value, so that
+    the top results of several searches can be merged.
+*/
+type FieldComparator interface {
+	// Compare hit at slot1 with hit at slot2. Returns any N < 0 if
+	// slot2's value is sorted after slot1, any N > 0 if the slot2's
+	// value is sorted before slot1 and 0 if they are equal.
+	Compare(slot1, slot2 int) int
+	// Set the bottom slot, ie the "weakest" (sorted last) entry in the
+	// queue. When CompareBottom is called, you should compare against
+	// this slot. This will always be called before CompareBottom.
+	SetBottom(slot int)
+	// Compare the bottom of the queue with this doc. This will only
+	// invoked after SetBottom has been called. This should return the
+	// same result as Compare(bottomSlot, otherSlot) as if bottomSlot
+	// is the slot passed to SetBottom and otherSlot is a slot holding
+	// the value of doc.
+	CompareBottom(doc int) (int, error)
+	// This method is called when a new hit is competitive. You should
+	// copy any state associated with this document that will be
+	// required for future comparisons, into the specified slot.
+	Copy(slot, doc int) error
+	// Set a new AtomicReaderContext. All subsequent docIDs are relative
+	// to the current reader (you must add docBase if you need to map
+	// it to a top-level docID). Returns the comparator to use for this
+	// segment; most comparators can just return themselves.
+	SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error)
+	// Sets the Scorer to use in case a document's score is needed.
+	SetScorer(scorer Scorer)
+	// Return the actual value in the slot.
+	Value(slot int) interface{}
+	// Compare two values returned by Value(), in the same order as
+	// Compare() does.
+	CompareValues(first, second interface{}) int
+}
+
+func compareFloat32(a, b float32) int {
+	return compareFloat64(float64(a), float64(b))
+}
+
+/* Total order on float64, where NaN sorts after everything, like Java's Double.compare(). */
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	case a == b:
+		return 0
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return 1
+	default:
+		return -1
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+/* Base for comparators of numeric fields, which may substitute a missing value. */
+type numericComparator struct {
+	field         string
+	missingValue  interface{}
+	docsWithField util.Bits
+}
+
+func (c *numericComparator) setNextReader(context *index.AtomicReaderContext) (err error) {
+	c.docsWithField = nil
+	if c.missingValue != nil {
+		reader := context.Reader().(index.AtomicReader)
+		if c.docsWithField, err = DEFAULT_FIELD_CACHE.DocsWithField(reader, c.field); err != nil {
+			return err
+		}
+		// optimization to remove unneeded checks on the bit interface:
+		if _, ok := c.docsWithField.(util.MatchAllBits); ok {
+			c.docsWithField = nil
+		}
+	}
+	return nil
+}
+
+/* Returns true if the doc has no value, and so the missing value should be used. */
+func (c *numericComparator) missing(doc int, zero bool) bool {
+	return c.docsWithField != nil && zero && !c.docsWithField.At(doc)
+}
+
+func (c *numericComparator) SetScorer(scorer Scorer) {}
+
+/*
+Parses field's values as int32 (using FieldCache.Ints() and sorts by
+ascending value.
+*/
+type IntComparator struct {
+	*numericComparator
+	values              []int32
+	parser              IntParser
+	currentReaderValues FieldCacheInts
+	bottom              int32
+	missingValue        int32
+}
+
+func newIntComparator(numHits int, field string, parser FieldCacheParser,
+	missingValue interface{}) *IntComparator {
+
+	ans := &IntComparator{
+		numericComparator: &numericComparator{field: field, missingValue: missingValue},
+		values:            make([]int32, numHits),
+	}
+	if parser != nil {
+		ans.parser = parser.(IntParser)
+	}
+	if missingValue != nil {
+		ans.missingValue = missingValue.(int32)
+	}
+	return ans
+}
+
+func (c *IntComparator) Compare(slot1, slot2 int) int {
+	return compareInt64(int64(c.values[slot1]), int64(c.values[slot2]))
+}
+
+func (c *IntComparator) value(doc int) int32 {
+	v := c.currentReaderValues(doc)
+	// Test for v == 0 to save Bits.get method call for the common
+	// case (doc has value and value is non-zero):
+	if c.missing(doc, v == 0) {
+		return c.missingValue
+	}
+	return v
+}
+
+func (c *IntComparator) CompareBottom(doc int) (int, error) {
+	return compareInt64(int64(c.bottom), int64(c.value(doc))), nil
+}
+
+func (c *IntComparator) Copy(slot, doc int) error {
+	c.values[slot] = c.value(doc)
+	return nil
+}
+
+func (c *IntComparator) SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error) {
+	// NOTE: must do this before calling super otherwise we compute the
+	// docsWithField Bits twice!
+	var err error
+	reader := context.Reader().(index.AtomicReader)
+	if c.currentReaderValues, err = DEFAULT_FIELD_CACHE.Ints(
+		reader, c.field, c.parser, c.numericComparator.missingValue != nil); err != nil {
+		return nil, err
+	}
+	return c, c.setNextReader(context)
+}
+
+func (c *IntComparator) SetBottom(slot int) {
+	c.bottom = c.values[slot]
+}
+
+func (c *IntComparator) Value(slot int) interface{} {
+	return c.values[slot]
+}
+
+func (c *IntComparator) CompareValues(first, second interface{}) int {
+	return compareInt64(int64(first.(int32)), int64(second.(int32)))
+}
+
+/*
+Parses field's values as int64 (using FieldCache.Longs() and sorts by
+ascending value.
+*/
+type LongComparator struct {
+	*numericComparator
+	values              []int64
+	parser              LongParser
+	currentReaderValues FieldCacheLongs
+	bottom              int64
+	missingValue        int64
+}
+
+func newLongComparator(numHits int, field string, parser FieldCacheParser,
+	missingValue interface{}) *LongComparator {
+
+	ans := &LongComparator{
+		numericComparator: &numericComparator{field: field, missingValue: missingValue},
+		values:            make([]int64, numHits),
+	}
+	if parser != nil {
+		ans.parser = parser.(LongParser)
+	}
+	if missingValue != nil {
+		ans.missingValue = missingValue.(int64)
+	}
+	return ans
+}
+
+func (c *LongComparator) Compare(slot1, slot2 int) int {
+	return compareInt64(c.values[slot1], c.values[slot2])
+}
+
+func (c *LongComparator) value(doc int) int64 {
+	v := c.currentReaderValues(doc)
+	// Test for v == 0 to save Bits.get method call for the common
+	// case (doc has value and value is non-zero):
+	if c.missing(doc, v == 0) {
+		return c.missingValue
+	}
+	return v
+}
+
+func (c *LongComparator) CompareBottom(doc int) (int, error) {
+	return compareInt64(c.bottom, c.value(doc)), nil
+}
+
+func (c *LongComparator) Copy(slot, doc int) error {
+	c.values[slot] = c.value(doc)
+	return nil
+}
+
+func (c *LongComparator) SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error) {
+	// NOTE: must do this before calling super otherwise we compute the
+	// docsWithField Bits twice!
+	var err error
+	reader := context.Reader().(index.AtomicReader)
+	if c.currentReaderValues, err = DEFAULT_FIELD_CACHE.Longs(
+		reader, c.field, c.parser, c.numericComparator.missingValue != nil); err != nil {
+		return nil, err
+	}
+	return c, c.setNextReader(context)
+}
+
+func (c *LongComparator) SetBottom(slot int) {
+	c.bottom = c.values[slot]
+}
+
+func (c *LongComparator) Value(slot int) interface{} {
+	return c.values[slot]
+}
+
+func (c *LongComparator) CompareValues(first, second interface{}) int {
+	return compareInt64(first.(int64), second.(int64))
+}
+
+/*
+Parses field's values as float32 (using FieldCache.Floats() and sorts
+by ascending value.
+*/
+type FloatComparator struct {
+	*numericComparator
+	values              []float32
+	parser              FloatParser
+	currentReaderValues FieldCacheFloats
+	bottom              float32
+	missingValue        float32
+}
+
+func newFloatComparator(numHits int, field string, parser FieldCacheParser,
+	missingValue interface{}) *FloatComparator {
+
+	ans := &FloatComparator{
+		numericComparator: &numericComparator{field: field, missingValue: missingValue},
+		values:            make([]float32, numHits),
+	}
+	if parser != nil {
+		ans.parser = parser.(FloatParser)
+	}
+	if missingValue != nil {
+		ans.missingValue = missingValue.(float32)
+	}
+	return ans
+}
+
+func (c *FloatComparator) Compare(slot1, slot2 int) int {
+	return compareFloat32(c.values[slot1], c.values[slot2])
+}
+
+func (c *FloatComparator) value(doc int) float32 {
+	v := c.currentReaderValues(doc)
+	// Test for v == 0 to save Bits.get method call for the common
+	// case (doc has value and value is non-zero):
+	if c.missing(doc, v == 0) {
+		return c.missingValue
+	}
+	return v
+}
+
+func (c *FloatComparator) CompareBottom(doc int) (int, error) {
+	return compareFloat32(c.bottom, c.value(doc)), nil
+}
+
+func (c *FloatComparator) Copy(slot, doc int) error {
+	c.values[slot] = c.value(doc)
+	return nil
+}
+
+func (c *FloatComparator) SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error) {
+	// NOTE: must do this before calling super otherwise we compute the
+	// docsWithField Bits twice!
+	var err error
+	reader := context.Reader().(index.AtomicReader)
+	if c.currentReaderValues, err = DEFAULT_FIELD_CACHE.Floats(
+		reader, c.field, c.parser, c.numericComparator.missingValue != nil); err != nil {
+		return nil, err
+	}
+	return c, c.setNextReader(context)
+}
+
+func (c *FloatComparator) SetBottom(slot int) {
+	c.bottom = c.values[slot]
+}
+
+func (c *FloatComparator) Value(slot int) interface{} {
+	return c.values[slot]
+}
+
+func (c *FloatComparator) CompareValues(first, second interface{}) int {
+	return compareFloat32(first.(float32), second.(float32))
+}
+
+/*
+Parses field's values as float64 (using FieldCache.Doubles() and
+sorts by ascending value.
+*/
+type DoubleComparator struct {
+	*numericComparator
+	values              []float64
+	parser              DoubleParser
+	currentReaderValues FieldCacheDoubles
+	bottom              float64
+	missingValue        float64
+}
+
+func newDoubleComparator(numHits int, field string, parser FieldCacheParser,
+	missingValue interface{}) *DoubleComparator {
+
+	ans := &DoubleComparator{
+		numericComparator: &numericComparator{field: field, missingValue: missingValue},
+		values:            make([]float64, numHits),
+	}
+	if parser != nil {
+		ans.parser = parser.(DoubleParser)
+	}
+	if missingValue != nil {
+		ans.missingValue = missingValue.(float64)
+	}
+	return ans
+}
+
+func (c *DoubleComparator) Compare(slot1, slot2 int) int {
+	return compareFloat64(c.values[slot1], c.values[slot2])
+}
+
+func (c *DoubleComparator) value(doc int) float64 {
+	v := c.currentReaderValues(doc)
+	// Test for v == 0 to save Bits.get method call for the common
+	// case (doc has value and value is non-zero):
+	if c.missing(doc, v == 0) {
+		return c.missingValue
+	}
+	return v
+}
+
+func (c *DoubleComparator) CompareBottom(doc int) (int, error) {
+	return compareFloat64(c.bottom, c.value(doc)), nil
+}
+
+func (c *DoubleComparator) Copy(slot, doc int) error {
+	c.values[slot] = c.value(doc)
+	return nil
+}
+
+func (c *DoubleComparator) SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error) {
+	// NOTE: must do this before calling super otherwise we compute the
+	// docsWithField Bits twice!
+	var err error
+	reader := context.Reader().(index.AtomicReader)
+	if c.currentReaderValues, err = DEFAULT_FIELD_CACHE.Doubles(
+		reader, c.field, c.parser, c.numericComparator.missingValue != nil); err != nil {
+		return nil, err
+	}
+	return c, c.setNextReader(context)
+}
+
+func (c *DoubleComparator) SetBottom(slot int) {
+	c.bottom = c.values[slot]
+}
+
+func (c *DoubleComparator) Value(slot int) interface{} {
+	return c.values[slot]
+}
+
+func (c *DoubleComparator) CompareValues(first, second interface{}) int {
+	return compareFloat64(first.(float64), second.(float64))
+}
+
+/*
+Sorts by descending relevance. NOTE: if you are sorting only by
+descending relevance and then secondarily by ascending docID,
+performance is faster using TopScoreDocCollector directly (which
+IndexSearcher.Search() uses when no Sort is specified).
+*/
+type RelevanceComparator struct {
+	scores []float32
+	bottom float32
+	scorer Scorer
+}
+
+func newRelevanceComparator(numHits int) *RelevanceComparator {
+	return &RelevanceComparator{scores: make([]float32, numHits)}
+}
+
+func (c *RelevanceComparator) Compare(slot1, slot2 int) int {
+	return compareFloat32(c.scores[slot2], c.scores[slot1])
+}
+
+func (c *RelevanceComparator) CompareBottom(doc int) (int, error) {
+	score, err := c.scorer.Score()
+	if err != nil {
+		return 0, err
+	}
+	assert(!math.IsNaN(float64(score)))
+	return compareFloat32(score, c.bottom), nil
+}
+
+func (c *RelevanceComparator) Copy(slot, doc int) (err error) {
+	c.scores[slot], err = c.scorer.Score()
+	assert(err != nil || !math.IsNaN(float64(c.scores[slot])))
+	return
+}
+
+func (c *RelevanceComparator) SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error) {
+	return c, nil
+}
+
+func (c *RelevanceComparator) SetBottom(bottom int) {
+	c.bottom = c.scores[bottom]
+}
+
+func (c *RelevanceComparator) SetScorer(scorer Scorer) {
+	c.scorer = scorer
+}
+
+func (c *RelevanceComparator) Value(slot int) interface{} {
+	return c.scores[slot]
+}
+
+func (c *RelevanceComparator) CompareValues(first, second interface{}) int {
+	// reversed intentionally because relevance by default sorts
+	// descending:
+	return compareFloat32(second.(float32), first.(float32))
+}
+
+/* Sorts by ascending docID */
+type DocComparator struct {
+	docIDs  []int
+	docBase int
+	bottom  int
+}
+
+func newDocComparator(numHits int) *DocComparator {
+	return &DocComparator{docIDs: make([]int, numHits)}
+}
+
+func (c *DocComparator) Compare(slot1, slot2 int) int {
+	// No overflow risk because docIDs are non-negative
+	return c.docIDs[slot1] - c.docIDs[slot2]
+}
+
+func (c *DocComparator) CompareBottom(doc int) (int, error) {
+	// No overflow risk because docIDs are non-negative
+	return c.bottom - (c.docBase + doc), nil
+}
+
+func (c *DocComparator) Copy(slot, doc int) error {
+	c.docIDs[slot] = c.docBase + doc
+	return nil
+}
+
+func (c *DocComparator) SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error) {
+	// TODO: can we "map" our docIDs to the current reader? saves
+	// having to then subtract on every compare call
+	c.docBase = context.DocBase
+	return c, nil
+}
+
+func (c *DocComparator) SetBottom(bottom int) {
+	c.bottom = c.docIDs[bottom]
+}
+
+func (c *DocComparator) SetScorer(scorer Scorer) {}
+
+func (c *DocComparator) Value(slot int) interface{} {
+	return c.docIDs[slot]
+}
+
+func (c *DocComparator) CompareValues(first, second interface{}) int {
+	// No overflow risk because docIDs are non-negative
+	return first.(int) - second.(int)
+}
+
+/*
+Sorts by field's natural Term sort order, using ordinals. This is
+functionally equivalent to TermValComparator, but it first resolves
+the string to their relative ordinal positions (using the index
+returned by FieldCache.TermsIndex()), and does most comparisons
+using the ordinals. For medium to large results, this comparator
+will be much faster than TermValComparator. For very small result
+sets it may be slower.
+*/
+type TermOrdValComparator struct {
+	// Ords for each slot.
+	ords []int
+	// Values for each slot.
+	values [][]byte
+	// Which reader last copied a value into the slot. When we compare
+	// two slots, we just compare-by-ord if the readerGen is the same;
+	// else we must compare the values (slower).
+	readerGen []int
+	// Gen of current reader we are on.
+	currentReaderGen int
+	// Current reader's doc ord/values.
+	termsIndex SortedDocValues
+
+	field string
+
+	// Bottom slot, or -1 if queue isn't full yet
+	bottomSlot int
+	// Bottom ord (same as ords[bottomSlot] once bottomSlot is set).
+	// Cached for faster compares.
+	bottomOrd int
+	// True if current bottom slot matches the current reader.
+	bottomSameReader bool
+	// Bottom value (same as values[bottomSlot] once bottomSlot is
+	// set). Cached for faster compares.
+	bottomValue []byte
+
+	// -1 if missing values are sorted first, 1 if they are sorted last
+	missingSortCmp int
+	// Which ordinal to use for a missing value.
+	missingOrd int
+}
+
+/*
+Creates this, with control over how missing values are sorted. Pass
+sortMissingLast=true to put missing values at the end.
+*/
+func newTermOrdValComparator(numHits int, field string, sortMissingLast bool) *TermOrdValComparator {
+	ans := &TermOrdValComparator{
+		ords:             make([]int, numHits),
+		values:           make([][]byte, numHits),
+		readerGen:        make([]int, numHits),
+		currentReaderGen: -1,
+		field:            field,
+		bottomSlot:       -1,
+	}
+	if sortMissingLast {
+		ans.missingSortCmp = 1
+		ans.missingOrd = math.MaxInt32
+	} else {
+		ans.missingSortCmp = -1
+		ans.missingOrd = -1
+	}
+	return ans
+}
+
+func (c *TermOrdValComparator) Compare(slot1, slot2 int) int {
+	if c.readerGen[slot1] == c.readerGen[slot2] {
+		return c.ords[slot1] - c.ords[slot2]
+	}
+
+	val1, val2 := c.values[slot1], c.values[slot2]
+	if val1 == nil {
+		if val2 == nil {
+			return 0
+		}
+		return c.missingSortCmp
+	} else if val2 == nil {
+		return -c.missingSortCmp
+	}
+	return bytes.Compare(val1, val2)
+}
+
+func (c *TermOrdValComparator) CompareBottom(doc int) (int, error) {
+	assert(c.bottomSlot != -1)
+	docOrd := c.termsIndex.Ord(doc)
+	if docOrd == -1 {
+		docOrd = c.missingOrd
+	}
+	if c.bottomSameReader {
+		// ord is precisely comparable, even in the equal case
+		return c.bottomOrd - docOrd, nil
+	} else if c.bottomOrd >= docOrd {
+		// the equals case always means bottom is > doc (because we set
+		// bottomOrd to the lower bound in SetBottom):
+		return 1, nil
+	}
+	return -1, nil
+}
+
+func (c *TermOrdValComparator) Copy(slot, doc int) error {
+	ord := c.termsIndex.Ord(doc)
+	if ord == -1 {
+		ord = c.missingOrd
+		c.values[slot] = nil
+	} else {
+		assert(ord >= 0)
+		c.values[slot] = append(c.values[slot][:0], c.termsIndex.LookupOrd(ord)...)
+	}
+	c.ords[slot] = ord
+	c.readerGen[slot] = c.currentReaderGen
+	return nil
+}
+
+func (c *TermOrdValComparator) SetNextReader(context *index.AtomicReaderContext) (FieldComparator, error) {
+	var err error
+	reader := context.Reader().(index.AtomicReader)
+	if c.termsIndex, err = DEFAULT_FIELD_CACHE.TermsIndex(reader, c.field); err != nil {
+		return nil, err
+	}
+	c.currentReaderGen++
+
+	if c.bottomSlot != -1 {
+		// Recompute bottomOrd/SameReader
+		c.SetBottom(c.bottomSlot)
+	}
+	return c, nil
+}
+
+func (c *TermOrdValComparator) SetBottom(bottom int) {
+	c.bottomSlot = bottom
+
+	c.bottomValue = c.values[c.bottomSlot]
+	if c.currentReaderGen == c.readerGen[c.bottomSlot] {
+		c.bottomOrd = c.ords[c.bottomSlot]
+		c.bottomSameReader = true
+	} else if c.bottomValue == nil {
+		// missingOrd is null for all segments
+		assert(c.ords[c.bottomSlot] == c.missingOrd)
+		c.bottomOrd = c.missingOrd
+		c.bottomSameReader = true
+		c.readerGen[c.bottomSlot] = c.currentReaderGen
+	} else {
+		index := lookupTerm(c.termsIndex, c.bottomValue)
+		if index < 0 {
+			c.bottomOrd = -index - 2
+			c.bottomSameReader = false
+		} else {
+			c.bottomOrd = index
+			// exact value match
+			c.bottomSameReader = true
+			c.readerGen[c.bottomSlot] = c.currentReaderGen
+			c.ords[c.bottomSlot] = c.bottomOrd
+		}
+	}
+}
+
+func (c *TermOrdValComparator) SetScorer(scorer Scorer) {}
+
+func (c *TermOrdValComparator) Value(slot int) interface{} {
+	if v := c.values[slot]; v != nil {
+		return v
+	}
+	return nil
+}
+
+func (c *TermOrdValComparator) CompareValues(first, second interface{}) int {
+	if first == nil {
+		if second == nil {
+			return 0
+		}
+		return c.missingSortCmp
+	} else if second == nil {
+		return -c.missingSortCmp
+	}
+	return bytes.Compare(first.([]byte), second.([]byte))
+}
+
+/*
+If key exists, returns its ordinal, else returns -insertionPoint-1,
+like binary search over the sorted values.
+*/
+func lookupTerm(values SortedDocValues, key []byte) int {
+	low, high := 0, values.ValueCount()-1
+	for low <= high {
+		mid := int(uint(low+high) >> 1)
+		cmp := bytes.Compare(values.LookupOrd(mid), key)
+		if cmp < 0 {
+			low = mid + 1
+		} else if cmp > 0 {
+			high = mid - 1
+		} else {
+			return mid // key found
+		}
+	}
+	return -(low + 1) // key not found
+}
@@ -0,0 +1,640 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/FilteredQuery.java
+
+/*
+A query that applies a filter to the results of another query.
+
+Note: the bits are retrieved from the filter each time this query is
+used in a search - use a CachingWrapperFilter to avoid regenerating
+the bits every time.
+*/
+type FilteredQuery struct {
+	*AbstractQuery
+	query    Query
+	filter   Filter
+	strategy FilterStrategy
+}
+
+/*
+Constructs a new query which applies a filter to the results of the
+original query. Filter.DocIdSet() will be called every time this
+query is used in a search.
+*/
+func NewFilteredQuery(query Query, filter Filter) *FilteredQuery {
+	return NewFilteredQueryWithStrategy(query, filter, RANDOM_ACCESS_FILTER_STRATEGY)
+}
+
+/*
+Expert: Constructs a new query which applies a filter to the results
+of the original query, using the given FilterStrategy to combine the
+query's scorer with the filter's DocIdSet.
+*/
+func NewFilteredQueryWithStrategy(query Query, filter Filter, strategy FilterStrategy) *FilteredQuery {
+	assert2(query != nil && filter != nil, "Query and filter cannot be null.")
+	assert2(strategy != nil, "FilterStrategy can not be null")
+	ans := &FilteredQuery{
+		query:    query,
+		filter:   filter,
+		strategy: strategy,
+	}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/*
+Returns a Weight that applies the filter to the enclosed query's
+Weight. This is accomplished by overriding the Scorer returned by the
+Weight.
+*/
+func (q *FilteredQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	weight, err := q.query.CreateWeight(ss)
+	if err != nil {
+		return nil, err
+	}
+	return &FilteredWeight{q, weight}, nil
+}
+
+/* Rewrites the query. Returns a new FilteredQuery wrapping the rewritten query, if the wrapped query rewrote. */
+func (q *FilteredQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	queryRewritten, err := q.query.Rewrite(reader)
+	if err != nil {
+		return nil, err
+	}
+	if queryRewritten != q.query {
+		rewritten := NewFilteredQueryWithStrategy(queryRewritten, q.filter, q.strategy)
+		rewritten.SetBoost(q.Boost())
+		return rewritten, nil
+	}
+	return q, nil
+}
+
+/* Returns this FilteredQuery's (unfiltered) Query */
+func (q *FilteredQuery) Query() Query {
+	return q.query
+}
+
+/* Returns this FilteredQuery's filter */
+func (q *FilteredQuery) Filter() Filter {
+	return q.filter
+}
+
+/* Returns this FilteredQuery's FilterStrategy */
+func (q *FilteredQuery) Strategy() FilterStrategy {
+	return q.strategy
+}
+
+func (q *FilteredQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	buf.WriteString("filtered(")
+	buf.WriteString(q.query.ToString(field))
+	buf.WriteString(")->")
+	buf.WriteString(fmt.Sprintf("%v", q.filter))
+	if q.boost != 1.0 {
+		buf.WriteString(fmt.Sprintf("^%v", q.boost))
+	}
+	return buf.String()
+}
+
+type FilteredWeight struct {
+	*FilteredQuery
+	weight Weight
+}
+
+func (w *FilteredWeight) IsScoresDocsOutOfOrder() bool {
+	return true
+}
+
+func (w *FilteredWeight) ValueForNormalization() float32 {
+	return w.weight.ValueForNormalization() * w.Boost() * w.Boost() // boost sub-weight
+}
+
+func (w *FilteredWeight) Normalize(norm float32, topLevelBoost float32) {
+	w.weight.Normalize(norm, topLevelBoost*w.Boost()) // incorporate boost
+}
+
+func (w *FilteredWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	inner, err := w.weight.Explain(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	docIdSet, err := w.filter.DocIdSet(ctx, ctx.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	var it DocIdSetIterator
+	if docIdSet != nil {
+		if it, err = docIdSet.Iterator(); err != nil {
+			return nil, err
+		}
+	}
+	if it == nil {
+		it = newEmptyDocIdSetIterator()
+	}
+	target, err := it.Advance(doc)
+	if err != nil {
+		return nil, err
+	}
+	if target == doc {
+		return inner, nil
+	}
+	result := NewExplanation(0, fmt.Sprintf("failure to match filter: %v", w.filter))
+	result.AddDetail(inner)
+	return result, nil
+}
+
+/* return a filtering scorer */
+func (w *FilteredWeight) Scorer(ctx *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	filterDocIdSet, err := w.filter.DocIdSet(ctx, acceptDocs)
+	if err != nil || filterDocIdSet == nil {
+		// this means the filter does not accept any documents.
+		return nil, err
+	}
+	return w.strategy.FilteredScorer(ctx, w.weight, filterDocIdSet)
+}
+
+/* return a filtering top scorer */
+func (w *FilteredWeight) BulkScorer(ctx *index.AtomicReaderContext,
+	scoreDocsInOrder bool, acceptDocs util.Bits) (BulkScorer, error) {
+
+	filterDocIdSet, err := w.filter.DocIdSet(ctx, acceptDocs)
+	if err != nil || filterDocIdSet == nil {
+		// this means the filter does not accept any documents.
+		return nil, err
+	}
+	return w.strategy.FilteredBulkScorer(ctx, w.weight, scoreDocsInOrder, filterDocIdSet)
+}
+
+func (w *FilteredWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.FilteredQuery)
+}
+
+/*
+A scorer that consults the filter iff a document was matched by the
+delegate scorer. This is useful if the filter computation is more
+expensive than document scoring or if the filter has a linear running
+time to compute the next matching doc like exact geo distances.
+*/
+type QueryFirstScorer struct {
+	*abstractScorer
+	scorer     Scorer
+	scorerDoc  int
+	filterBits util.Bits
+}
+
+func newQueryFirstScorer(weight Weight, filterBits util.Bits, other Scorer) *QueryFirstScorer {
+	ans := &QueryFirstScorer{
+		scorer:     other,
+		scorerDoc:  -1,
+		filterBits: filterBits,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	return ans
+}
+
+func (s *QueryFirstScorer) NextDoc() (doc int, err error) {
+	for {
+		if doc, err = s.scorer.NextDoc(); err != nil {
+			return 0, err
+		}
+		if doc == NO_MORE_DOCS || s.filterBits.At(doc) {
+			s.scorerDoc = doc
+			return doc, nil
+		}
+	}
+}
+
+func (s *QueryFirstScorer) Cost() int64 {
+	return s.scorer.Cost()
+}
+
+func (s *QueryFirstScorer) Advance(target int) (doc int, err error) {
+	if doc, err = s.scorer.Advance(target); err != nil {
+		return 0, err
+	}
+	if doc != NO_MORE_DOCS && !s.filterBits.At(doc) {
+		return s.NextDoc()
+	}
+	s.scorerDoc = doc
+	return doc, nil
+}
+
+func (s *QueryFirstScorer) DocId() int {
+	return s.scorerDoc
+}
+
+func (s *QueryFirstScorer) Score() (float32, error) {
+	return s.scorer.Score()
+}
+
+func (s *QueryFirstScorer) Freq() (int, error) {
+	return s.scorer.Freq()
+}
+
+/*
+A BulkScorer that consults the filter iff a document was matched by
+the delegate scorer.
+*/
+type QueryFirstBulkScorer struct {
+	*BulkScorerImpl
+	scorer     Scorer
+	filterBits util.Bits
+}
+
+func newQueryFirstBulkScorer(scorer Scorer, filterBits util.Bits) *QueryFirstBulkScorer {
+	ans := &QueryFirstBulkScorer{
+		scorer:     scorer,
+		filterBits: filterBits,
+	}
+	ans.BulkScorerImpl = newBulkScorer(ans)
+	return ans
+}
+
+func (s *QueryFirstBulkScorer) ScoreAndCollectUpto(collector Collector, maxDoc int) (bool, error) {
+	// the normalization trick already applies the boost of this query,
+	// so we can use the wrapped scorer directly:
+	collector.SetScorer(s.scorer)
+	if s.scorer.DocId() == -1 {
+		if _, err := s.scorer.NextDoc(); err != nil {
+			return false, err
+		}
+	}
+	for scorerDoc := s.scorer.DocId(); scorerDoc < maxDoc; scorerDoc = s.scorer.DocId() {
+		if s.filterBits.At(scorerDoc) {
+			if err := collector.Collect(scorerDoc); err != nil {
+				return false, err
+			}
+		}
+		if _, err := s.scorer.NextDoc(); err != nil {
+			return false, err
+		}
+	}
+	return s.scorer.DocId() != NO_MORE_DOCS, nil
+}
+
+type leapFrogScorerSPI interface {
+	primaryNext() (int, error)
+}
+
+/*
+A Scorer that uses a "leap-frog" approach (also called "zig-zag
+join"). The scorer and the filter take turns trying to advance to
+each other's next matching document, often jumping past the target
+document. When both land on the same document, it's collected.
+*/
+type LeapFrogScorer struct {
+	*abstractScorer
+	spi          leapFrogScorerSPI
+	secondary    DocIdSetIterator
+	primary      DocIdSetIterator
+	scorer       Scorer
+	primaryDoc   int
+	secondaryDoc int
+}
+
+func newLeapFrogScorer(weight Weight, primary, secondary DocIdSetIterator,
+	coScorer Scorer) *LeapFrogScorer {
+
+	ans := &LeapFrogScorer{
+		primary:      primary,
+		secondary:    secondary,
+		scorer:       coScorer,
+		primaryDoc:   -1,
+		secondaryDoc: -1,
+	}
+	ans.spi = ans
+	ans.abstractScorer = newScorer(ans, weight)
+	return ans
+}
+
+func (s *LeapFrogScorer) advanceToNextCommonDoc() (err error) {
+	for {
+		if s.secondaryDoc < s.primaryDoc {
+			if s.secondaryDoc, err = s.secondary.Advance(s.primaryDoc); err != nil {
+				return err
+			}
+		} else if s.secondaryDoc == s.primaryDoc {
+			return nil
+		} else {
+			if s.primaryDoc, err = s.primary.Advance(s.secondaryDoc); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *LeapFrogScorer) NextDoc() (doc int, err error) {
+	if s.primaryDoc, err = s.spi.primaryNext(); err != nil {
+		return 0, err
+	}
+	if err = s.advanceToNextCommonDoc(); err != nil {
+		return 0, err
+	}
+	return s.primaryDoc, nil
+}
+
+func (s *LeapFrogScorer) Cost() int64 {
+	if primary, secondary := s.primary.Cost(), s.secondary.Cost(); primary < secondary {
+		return primary
+	} else {
+		return secondary
+	}
+}
+
+func (s *LeapFrogScorer) primaryNext() (int, error) {
+	return s.primary.NextDoc()
+}
+
+func (s *LeapFrogScorer) Advance(target int) (doc int, err error) {
+	if target > s.primaryDoc {
+		if s.primaryDoc, err = s.primary.Advance(target); err != nil {
+			return 0, err
+		}
+	}
+	if err = s.advanceToNextCommonDoc(); err != nil {
+		return 0, err
+	}
+	return s.primaryDoc, nil
+}
+
+func (s *LeapFrogScorer) DocId() int {
+	return s.secondaryDoc
+}
+
+func (s *LeapFrogScorer) Score() (float32, error) {
+	return s.scorer.Score()
+}
+
+func (s *LeapFrogScorer) Freq() (int, error) {
+	return s.scorer.Freq()
+}
+
+/*
+Extends the LeapFrogScorer with the filter iterator already advanced
+to its first doc.
+*/
+type PrimaryAdvancedLeapFrogScorer struct {
+	*LeapFrogScorer
+	firstFilteredDoc int
+}
+
+func newPrimaryAdvancedLeapFrogScorer(weight Weight, firstFilteredDoc int,
+	filterIter DocIdSetIterator, other Scorer) *PrimaryAdvancedLeapFrogScorer {
+
+	ans := &PrimaryAdvancedLeapFrogScorer{
+		LeapFrogScorer:   newLeapFrogScorer(weight, filterIter, other, other),
+		firstFilteredDoc: firstFilteredDoc,
+	}
+	ans.primaryDoc = firstFilteredDoc // initialize to prevent and advance call to move it further
+	ans.spi = ans
+	return ans
+}
+
+func (s *PrimaryAdvancedLeapFrogScorer) primaryNext() (int, error) {
+	if s.secondaryDoc != -1 {
+		return s.LeapFrogScorer.primaryNext()
+	}
+	return s.firstFilteredDoc, nil
+}
+
+/*
+Abstract class that defines how the filter (DocIdSet) applied during
+document collection.
+*/
+type FilterStrategy interface {
+	/*
+		Returns a filtered Scorer based on this strategy.
+
+		context is the AtomicReaderContext for which to return the
+		Scorer; weight is the FilteredQuery's Weight to create the
+		filtered scorer; docIdSet is the filter DocIdSet to apply.
+	*/
+	FilteredScorer(*index.AtomicReaderContext, Weight, DocIdSet) (Scorer, error)
+	/*
+		Returns a filtered BulkScorer based on this strategy. This is an
+		optional method: the default implementation just calls
+		FilteredScorer() and wraps that into a BulkScorer.
+	*/
+	FilteredBulkScorer(*index.AtomicReaderContext, Weight, bool, DocIdSet) (BulkScorer, error)
+}
+
+type FilterStrategySPI interface {
+	FilteredScorer(*index.AtomicReaderContext, Weight, DocIdSet) (Scorer, error)
+}
+
+type FilterStrategyImpl struct {
+	spi FilterStrategySPI
+}
+
+func NewFilterStrategy(spi FilterStrategySPI) *FilterStrategyImpl {
+	return &FilterStrategyImpl{spi}
+}
+
+func (s *FilterStrategyImpl) FilteredBulkScorer(ctx *index.AtomicReaderContext,
+	weight Weight, scoreDocsInOrder bool, docIdSet DocIdSet) (BulkScorer, error) {
+
+	scorer, err := s.spi.FilteredScorer(ctx, weight, docIdSet)
+	if err != nil || scorer == nil {
+		return nil, err
+	}
+	// this impl always scores docs in order, so we can ignore scoreDocsInOrder:
+	return newDefaultScorer(scorer), nil
+}
+
+/*
+A FilterStrategy that conditionally uses a random access filter if
+the given DocIdSet supports random access (returns a non-nil value
+from DocIdSet.Bits()) and UseRandomAccess() returns true. Otherwise
+this strategy falls back to a "zig-zag join" (LEAP_FROG_FILTER_FIRST_STRATEGY)
+strategy.
+
+Note: This strategy is the default strategy in FilteredQuery.
+*/
+var RANDOM_ACCESS_FILTER_STRATEGY = NewRandomAccessFilterStrategy()
+
+/*
+A filter strategy that uses a "leap-frog" approach (also called
+"zig-zag join"). The scorer and the filter take turns trying to
+advance to each other's next matching document, often jumping past
+the target document. When both land on the same document, it's
+collected.
+
+Note: This strategy uses the filter to lead the iteration.
+*/
+var LEAP_FROG_FILTER_FIRST_STRATEGY = newLeapFrogFilterStrategy(false)
+
+/*
+A filter strategy that uses a "leap-frog" approach (also called
+"zig-zag join"). The scorer and the filter take turns trying to
+advance to each other's next matching document, often jumping past
+the target document. When both land on the same document, it's
+collected.
+
+Note: This strategy uses the query to lead the iteration.
+*/
+var LEAP_FROG_QUERY_FIRST_STRATEGY = newLeapFrogFilterStrategy(true)
+
+/*
+A filter strategy that advances the Query or rather its Scorer first
+and consults the filter DocIdSet for each matched document.
+
+Note: this strategy requires a DocIdSet.Bits() to return a non-nil
+value. Otherwise this strategy falls back to LEAP_FROG_QUERY_FIRST_STRATEGY.
+
+Use this strategy if the filter computation is more expensive than
+document scoring or if the filter has a linear running time to
+compute the next matching doc like exact geo distances.
+*/
+var QUERY_FIRST_FILTER_STRATEGY = newQueryFirstFilterStrategy()
+
+type RandomAccessFilterStrategySPI interface {
+	/*
+		Expert: decides if a filter should be executed as "random-access"
+		or not. Random-access means the filter "filters" in a similar way
+		as deleted docs are filtered in Lucene. This is faster when the
+		filter accepts many documents. However, when the filter is very
+		sparse, it can be faster to execute the query+filter as a
+		conjunction in some cases.
+
+		The default implementation returns true if the first document
+		accepted by the filter is < 100.
+	*/
+	UseRandomAccess(bits util.Bits, firstFilterDoc int) bool
+}
+
+/*
+A FilterStrategy that conditionally uses a random access filter if
+the given DocIdSet supports random access (returns a non-nil value
+from DocIdSet.Bits()) and UseRandomAccess() returns true. Otherwise
+this strategy falls back to a "zig-zag join" (LEAP_FROG_FILTER_FIRST_STRATEGY)
+strategy.
+*/
+type RandomAccessFilterStrategy struct {
+	*FilterStrategyImpl
+	spi RandomAccessFilterStrategySPI
+}
+
+func NewRandomAccessFilterStrategy() *RandomAccessFilterStrategy {
+	ans := new(RandomAccessFilterStrategy)
+	ans.FilterStrategyImpl = NewFilterStrategy(ans)
+	ans.spi = ans
+	return ans
+}
+
+func (s *RandomAccessFilterStrategy) FilteredScorer(ctx *index.AtomicReaderContext,
+	weight Weight, docIdSet DocIdSet) (Scorer, error) {
+
+	filterIter, err := docIdSet.Iterator()
+	if err != nil || filterIter == nil {
+		// this means the filter does not accept any documents.
+		return nil, err
+	}
+
+	firstFilterDoc, err := filterIter.NextDoc()
+	if err != nil || firstFilterDoc == NO_MORE_DOCS {
+		return nil, err
+	}
+
+	filterAcceptDocs := docIdSet.Bits()
+	// force if RA is requested
+	if filterAcceptDocs != nil && s.spi.UseRandomAccess(filterAcceptDocs, firstFilterDoc) {
+		// if we are using random access, we return the inner scorer, just with other acceptDocs
+		return weight.Scorer(ctx, filterAcceptDocs)
+	}
+	// we are gonna advance() this scorer, so we set inorder=true/toplevel=false
+	// we pass nil as acceptDocs, as our filter has already respected acceptDocs, no need to do twice
+	scorer, err := weight.Scorer(ctx, nil)
+	if err != nil || scorer == nil {
+		return nil, err
+	}
+	return newPrimaryAdvancedLeapFrogScorer(weight, firstFilterDoc, filterIter, scorer), nil
+}
+
+func (s *RandomAccessFilterStrategy) UseRandomAccess(bits util.Bits, firstFilterDoc int) bool {
+	// TODO once we have a cost API on filters and scorers we should rethink this heuristic
+	return firstFilterDoc < 100
+}
+
+type LeapFrogFilterStrategy struct {
+	*FilterStrategyImpl
+	scorerFirst bool
+}
+
+func newLeapFrogFilterStrategy(scorerFirst bool) *LeapFrogFilterStrategy {
+	ans := &LeapFrogFilterStrategy{scorerFirst: scorerFirst}
+	ans.FilterStrategyImpl = NewFilterStrategy(ans)
+	return ans
+}
+
+func (s *LeapFrogFilterStrategy) FilteredScorer(ctx *index.AtomicReaderContext,
+	weight Weight, docIdSet DocIdSet) (Scorer, error) {
+
+	filterIter, err := docIdSet.Iterator()
+	if err != nil || filterIter == nil {
+		// this means the filter does not accept any documents.
+		return nil, err
+	}
+	// we pass nil as acceptDocs, as our filter has already respected acceptDocs, no need to do twice
+	scorer, err := weight.Scorer(ctx, nil)
+	if err != nil || scorer == nil {
+		return nil, err
+	}
+	if s.scorerFirst {
+		return newLeapFrogScorer(weight, scorer, filterIter, scorer), nil
+	}
+	return newLeapFrogScorer(weight, filterIter, scorer, scorer), nil
+}
+
+/*
+A FilterStrategy that advances the Scorer first and consults the
+DocIdSet for each matched document.
+*/
+type QueryFirstFilterStrategy struct {
+	*FilterStrategyImpl
+}
+
+func newQueryFirstFilterStrategy() *QueryFirstFilterStrategy {
+	ans := new(QueryFirstFilterStrategy)
+	ans.FilterStrategyImpl = NewFilterStrategy(ans)
+	return ans
+}
+
+func (s *QueryFirstFilterStrategy) FilteredScorer(ctx *index.AtomicReaderContext,
+	weight Weight, docIdSet DocIdSet) (Scorer, error) {
+
+	filterAcceptDocs := docIdSet.Bits()
+	if filterAcceptDocs == nil {
+		// Filter does not provide random-access Bits; we must use leap-frog:
+		return LEAP_FROG_QUERY_FIRST_STRATEGY.FilteredScorer(ctx, weight, docIdSet)
+	}
+	scorer, err := weight.Scorer(ctx, nil)
+	if err != nil || scorer == nil {
+		return nil, err
+	}
+	return newQueryFirstScorer(weight, filterAcceptDocs, scorer), nil
+}
+
+func (s *QueryFirstFilterStrategy) FilteredBulkScorer(ctx *index.AtomicReaderContext,
+	weight Weight, scoreDocsInOrder bool, docIdSet DocIdSet) (BulkScorer, error) {
+
+	filterAcceptDocs := docIdSet.Bits()
+	if filterAcceptDocs == nil {
+		// Filter does not provide random-access Bits; we must use leap-frog:
+		return LEAP_FROG_QUERY_FIRST_STRATEGY.FilteredBulkScorer(ctx, weight, scoreDocsInOrder, docIdSet)
+	}
+	scorer, err := weight.Scorer(ctx, nil)
+	if err != nil || scorer == nil {
+		return nil, err
+	}
+	return newQueryFirstBulkScorer(scorer, filterAcceptDocs), nil
+}
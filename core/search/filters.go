@@ -1,4 +1,272 @@
 package search
 
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sync"
+)
+
+// search/Filter.java
+
+/* Abstract base class for restricting which documents may be returned during searching. */
 type Filter interface {
+	/*
+		Creates a DocIdSet enumerating the documents that should be
+		permitted in search results. NOTE: nil can be returned if no
+		documents are accepted by this Filter.
+
+		Note: This method will be called once per segment in the index
+		during searching. The returned DocIdSet must refer to document
+		IDs for that segment, not for the top-level reader.
+
+		acceptDocs are Bits that represent the allowable docs to match
+		(typically deleted docs but possibly filtering other documents).
+	*/
+	DocIdSet(context *index.AtomicReaderContext, acceptDocs util.Bits) (DocIdSet, error)
+}
+
+// search/QueryWrapperFilter.java
+
+/*
+Constrains search results to only match those which also match a
+provided query.
+
+This could be used, for example, with a NumericRangeQuery on a
+suitably formatted date field to implement date filtering. One could
+re-use a single CachingWrapperFilter(QueryWrapperFilter) that matches,
+e.g., only documents modified within the last week. This would only
+need to be reconstructed once per day.
+*/
+type QueryWrapperFilter struct {
+	query Query
+}
+
+/* Constructs a filter which only matches documents matching query. */
+func NewQueryWrapperFilter(query Query) *QueryWrapperFilter {
+	assert2(query != nil, "Query may not be null")
+	return &QueryWrapperFilter{query}
+}
+
+/* Returns the inner Query */
+func (f *QueryWrapperFilter) Query() Query {
+	return f.query
+}
+
+func (f *QueryWrapperFilter) DocIdSet(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (DocIdSet, error) {
+
+	// get a private context that is used to rewrite, createWeight and
+	// score eventually
+	privateContext := context.Reader().Context().(*index.AtomicReaderContext)
+	weight, err := NewIndexSearcherFromContext(privateContext).CreateNormalizedWeight(f.query)
+	if err != nil {
+		return nil, err
+	}
+	return &queryWrapperDocIdSet{weight, privateContext, acceptDocs}, nil
+}
+
+/*
+Returns the documents of a single segment matching the wrapped query,
+so that the filter can be passed to
+IndexWriter.DeleteDocumentsByQuery().
+*/
+func (f *QueryWrapperFilter) MatchingDocs(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (DocIdSetIterator, error) {
+
+	docs, err := f.DocIdSet(context, acceptDocs)
+	if err != nil || docs == nil {
+		return nil, err
+	}
+	return docs.Iterator()
+}
+
+func (f *QueryWrapperFilter) String() string {
+	return fmt.Sprintf("QueryWrapperFilter(%v)", f.query)
+}
+
+type queryWrapperDocIdSet struct {
+	weight         Weight
+	privateContext *index.AtomicReaderContext
+	acceptDocs     util.Bits
+}
+
+func (s *queryWrapperDocIdSet) Iterator() (DocIdSetIterator, error) {
+	scorer, err := s.weight.Scorer(s.privateContext, s.acceptDocs)
+	if err != nil || scorer == nil {
+		return nil, err
+	}
+	return scorer, nil
+}
+
+func (s *queryWrapperDocIdSet) Bits() util.Bits {
+	return nil
+}
+
+func (s *queryWrapperDocIdSet) IsCacheable() bool {
+	return false
+}
+
+// search/CachingWrapperFilter.java
+
+/*
+Wraps another Filter's result and caches it. The purpose is to allow
+filters to simply filter, and then wrap with this class to add
+caching.
+
+Doc id sets are cached per segment core, as FixedBitSets unless the
+wrapped filter already returns a cacheable DocIdSet. Since Go has no
+weak references, the entries of a core are purged once the core is
+closed instead.
+*/
+type CachingWrapperFilter struct {
+	filter Filter
+
+	sync.Locker
+	cache     map[interface{}]DocIdSet
+	purgeCore *purgeListener
+
+	// for testing; guarded by the lock
+	hitCount, missCount int
+}
+
+/* Wraps another filter's result and caches it. */
+func NewCachingWrapperFilter(filter Filter) *CachingWrapperFilter {
+	f := &CachingWrapperFilter{
+		filter: filter,
+		Locker: &sync.Mutex{},
+		cache:  make(map[interface{}]DocIdSet),
+	}
+	f.purgeCore = &purgeListener{func(coreCacheKey interface{}) {
+		f.Lock()
+		defer f.Unlock()
+		delete(f.cache, coreCacheKey)
+	}}
+	return f
+}
+
+/* Returns the Filter this CachingWrapperFilter wraps */
+func (f *CachingWrapperFilter) Filter() Filter {
+	return f.filter
+}
+
+/*
+Provide the DocIdSet to be cached, using the DocIdSet provided by the
+wrapped Filter. This implementation returns the given DocIdSet, if
+IsCacheable() returns true, else it copies the DocIdSetIterator into
+a cacheable FixedBitSet.
+*/
+func (f *CachingWrapperFilter) docIdSetToCache(docIdSet DocIdSet,
+	reader index.AtomicReader) (DocIdSet, error) {
+
+	if docIdSet == nil {
+		// this is better than returning nil, as the nonnull result can
+		// be cached
+		return EMPTY_DOCIDSET, nil
+	}
+	if docIdSet.IsCacheable() {
+		return docIdSet, nil
+	}
+	it, err := docIdSet.Iterator()
+	if err != nil {
+		return nil, err
+	}
+	// nil is allowed to be returned by Iterator(), in this case we
+	// wrap with the empty set, which is cacheable.
+	if it == nil {
+		return EMPTY_DOCIDSET, nil
+	}
+	return f.cacheImpl(it, reader)
+}
+
+/* Default cache implementation: copies the iterator into a FixedBitSet. */
+func (f *CachingWrapperFilter) cacheImpl(iterator DocIdSetIterator,
+	reader index.AtomicReader) (DocIdSet, error) {
+
+	bits := util.NewFixedBitSetOf(reader.MaxDoc())
+	if err := bits.Or(iterator); err != nil {
+		return nil, err
+	}
+	return bits, nil
+}
+
+func (f *CachingWrapperFilter) DocIdSet(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (DocIdSet, error) {
+
+	reader := context.Reader().(index.AtomicReader)
+	key := coreCacheKey(reader)
+
+	f.Lock()
+	docIdSet, ok := f.cache[key]
+	if ok {
+		f.hitCount++
+	} else {
+		f.missCount++
+	}
+	f.Unlock()
+
+	if !ok {
+		addCoreClosedListener(reader, f.purgeCore)
+		set, err := f.filter.DocIdSet(context, nil)
+		if err != nil {
+			return nil, err
+		}
+		if docIdSet, err = f.docIdSetToCache(set, reader); err != nil {
+			return nil, err
+		}
+		assert(docIdSet.IsCacheable())
+		f.Lock()
+		// another goroutine may have cached this core meanwhile; keep
+		// its set so that all callers share the same one
+		if cached, ok := f.cache[key]; ok {
+			docIdSet = cached
+		} else {
+			f.cache[key] = docIdSet
+		}
+		f.Unlock()
+	}
+
+	if docIdSet == EMPTY_DOCIDSET {
+		return nil, nil
+	}
+	return WrapBitsFilteredDocIdSet(docIdSet, acceptDocs), nil
+}
+
+/*
+Returns the key for the core of the reader, shared across reopens of
+the same segment; falls back to the reader itself.
+*/
+func coreCacheKey(reader index.AtomicReader) interface{} {
+	if r, ok := reader.(interface {
+		CoreCacheKey() interface{}
+	}); ok {
+		return r.CoreCacheKey()
+	}
+	return reader
+}
+
+/* Purges a per-core cache once the segment core is closed. */
+type purgeListener struct {
+	purge func(coreCacheKey interface{})
+}
+
+func (l *purgeListener) OnClose(ownerCoreCacheKey interface{}) {
+	l.purge(ownerCoreCacheKey)
+}
+
+/*
+Registers listener on the core of the reader, if the reader supports
+it; see coreCacheKey().
+*/
+func addCoreClosedListener(reader index.AtomicReader, listener index.CoreClosedListener) {
+	if r, ok := reader.(interface {
+		AddCoreClosedListener(index.CoreClosedListener)
+	}); ok {
+		r.AddCoreClosedListener(listener)
+	}
+}
+
+func (f *CachingWrapperFilter) String() string {
+	return fmt.Sprintf("CachingWrapperFilter(%v)", f.filter)
 }
@@ -0,0 +1,276 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	"unicode/utf8"
+)
+
+// search/FuzzyQuery.java
+
+const (
+	FUZZY_DEFAULT_MAX_EDITS      = automaton.MAXIMUM_SUPPORTED_DISTANCE
+	FUZZY_DEFAULT_PREFIX_LENGTH  = 0
+	FUZZY_DEFAULT_MAX_EXPANSIONS = 50
+	FUZZY_DEFAULT_TRANSPOSITIONS = true
+)
+
+/*
+Implements the fuzzy search query. The similarity measurement is
+based on the Damerau-Levenshtein (optimal string alignment)
+algorithm, though you can explicitly choose classic Levenshtein by
+passing false to the transpositions parameter.
+
+This query uses TopTermsScoringBooleanQueryRewrite as default. So
+terms will be collected and scored according to their edit distance.
+Only the top terms are used for building the BooleanQuery. It is not
+recommended to change the rewrite mode for fuzzy queries.
+
+At most, this query will match terms up to
+automaton.MAXIMUM_SUPPORTED_DISTANCE edits. Higher distances (especially
+with transpositions enabled), are generally not useful and will match
+a significant amount of the term dictionary. If you really want this,
+consider using an n-gram indexing technique (such as the
+SpellChecker in the suggest module) instead.
+
+NOTE: terms of length 1 or 2 will sometimes not match because of how
+the scaled distance between two terms is computed. For a term to
+match, the edit distance between the terms must be less than the
+minimum length term (either the input term, or the candidate term).
+For example, FuzzyQuery on term "abcd" with maxEdits=2 will not match
+an indexed term "ab", and FuzzyQuery on term "a" with maxEdits=2 will
+not match an indexed term "abc".
+*/
+type FuzzyQuery struct {
+	*MultiTermQuery
+	maxEdits       int
+	maxExpansions  int
+	transpositions bool
+	prefixLength   int
+	term           *index.Term
+}
+
+/*
+Create a new FuzzyQuery that will match terms with an edit distance
+of at most maxEdits to term. If a prefixLength > 0 is specified, a
+common prefix of that length is also required.
+
+maxEdits must be between 0 and MAXIMUM_SUPPORTED_DISTANCE;
+prefixLength is the length of common (non-fuzzy) prefix;
+maxExpansions is the maximum number of terms to match, and if this
+number is greater than maxClauseCount when the query is rewritten,
+then the maxClauseCount will be used instead; transpositions tells if
+transpositions should be treated as a primitive edit operation. If
+this is false, comparisons will implement the classic Levenshtein
+algorithm.
+*/
+func NewFuzzyQueryWithOptions(term *index.Term, maxEdits, prefixLength,
+	maxExpansions int, transpositions bool) *FuzzyQuery {
+
+	assert2(maxEdits >= 0 && maxEdits <= automaton.MAXIMUM_SUPPORTED_DISTANCE,
+		"maxEdits must be between 0 and %v", automaton.MAXIMUM_SUPPORTED_DISTANCE)
+	assert2(prefixLength >= 0, "prefixLength cannot be negative.")
+	assert2(maxExpansions > 0, "maxExpansions must be positive.")
+
+	ans := &FuzzyQuery{
+		term:           term,
+		maxEdits:       maxEdits,
+		prefixLength:   prefixLength,
+		transpositions: transpositions,
+		maxExpansions:  maxExpansions,
+	}
+	ans.MultiTermQuery = NewMultiTermQuery(ans, term.Field)
+	ans.SetRewriteMethod(NewTopTermsScoringBooleanQueryRewrite(maxExpansions))
+	return ans
+}
+
+/*
+Calls NewFuzzyQueryWithOptions(term, maxEdits, prefixLength,
+FUZZY_DEFAULT_MAX_EXPANSIONS, FUZZY_DEFAULT_TRANSPOSITIONS).
+*/
+func NewFuzzyQueryWithEdits(term *index.Term, maxEdits, prefixLength int) *FuzzyQuery {
+	return NewFuzzyQueryWithOptions(term, maxEdits, prefixLength,
+		FUZZY_DEFAULT_MAX_EXPANSIONS, FUZZY_DEFAULT_TRANSPOSITIONS)
+}
+
+/* Calls NewFuzzyQueryWithEdits(term, FUZZY_DEFAULT_MAX_EDITS, 0). */
+func NewFuzzyQuery(term *index.Term) *FuzzyQuery {
+	return NewFuzzyQueryWithEdits(term, FUZZY_DEFAULT_MAX_EDITS, FUZZY_DEFAULT_PREFIX_LENGTH)
+}
+
+/* Returns the maximum number of edit distances allowed for this query to match. */
+func (q *FuzzyQuery) MaxEdits() int {
+	return q.maxEdits
+}
+
+/*
+Returns the non-fuzzy prefix length. This is the number of characters
+at the start of a term that must be identical (not fuzzy) to the
+query term if the query is to match that term.
+*/
+func (q *FuzzyQuery) PrefixLength() int {
+	return q.prefixLength
+}
+
+/*
+Returns true if transpositions should be treated as a primitive edit
+operation. If this is false, comparisons will implement the classic
+Levenshtein algorithm.
+*/
+func (q *FuzzyQuery) Transpositions() bool {
+	return q.transpositions
+}
+
+/* Returns the pattern term. */
+func (q *FuzzyQuery) Term() *index.Term {
+	return q.term
+}
+
+func (q *FuzzyQuery) TermsEnum(terms Terms) (TermsEnum, error) {
+	if q.maxEdits == 0 || q.prefixLength >= utf8.RuneCount(q.term.Bytes) {
+		// can only match if it's exact
+		return index.NewSingleTermsEnum(terms.Iterator(nil), q.term.Bytes), nil
+	}
+	return newFuzzyTermsEnum(terms, q.term, q.maxEdits, q.prefixLength, q.transpositions)
+}
+
+func (q *FuzzyQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteRune(':')
+	}
+	buf.Write(q.term.Bytes)
+	buf.WriteRune('~')
+	buf.WriteString(fmt.Sprintf("%v", q.maxEdits))
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+/*
+Helper function to convert from deprecated "minimumSimilarity"
+fractions to raw edit distances.
+*/
+func FloatToEdits(minimumSimilarity float32, termLen int) int {
+	if minimumSimilarity >= 1 {
+		if minimumSimilarity > automaton.MAXIMUM_SUPPORTED_DISTANCE {
+			return automaton.MAXIMUM_SUPPORTED_DISTANCE
+		}
+		return int(minimumSimilarity)
+	} else if minimumSimilarity == 0 {
+		return 0 // 0 means exact, not infinite # of edits!
+	}
+	edits := int((1 - float64(minimumSimilarity)) * float64(termLen))
+	if edits > automaton.MAXIMUM_SUPPORTED_DISTANCE {
+		return automaton.MAXIMUM_SUPPORTED_DISTANCE
+	}
+	return edits
+}
+
+// search/FuzzyTermsEnum.java
+
+/*
+Subclass of TermsEnum for enumerating all terms that are similar to
+the specified filter term.
+
+Term enumerations are always ordered by Comparator. Each term in the
+enumeration is greater than all that precede it.
+
+The terms dictionary is intersected with the Levenshtein automaton of
+the maximum edit distance; the accepted terms are then boosted by
+their exact edit distance, which is found by running the automata of
+the smaller distances.
+*/
+type FuzzyTermsEnum struct {
+	*index.FilteredTermsEnum
+
+	boostAtt BoostAttribute
+
+	// one matcher per edit distance, matchers[0] being nil as the exact
+	// match is checked against termRef
+	matchers []*automaton.ByteRunAutomaton
+	termRef  []byte
+	// the length of the term in code points
+	termLength int
+}
+
+/*
+Constructor for enumeration of all terms from specified terms which
+share a prefix of length prefixLength with term and which have an
+edit distance of at most maxEdits to term.
+*/
+func newFuzzyTermsEnum(terms Terms, term *index.Term, maxEdits,
+	prefixLength int, transpositions bool) (*FuzzyTermsEnum, error) {
+
+	assert(maxEdits > 0 && maxEdits <= automaton.MAXIMUM_SUPPORTED_DISTANCE)
+
+	termText := []rune(string(term.Bytes))
+	termLength := len(termText)
+	// The prefix could be longer than the word. It's kind of silly
+	// though. It means we must match the entire word.
+	realPrefixLength := prefixLength
+	if realPrefixLength > termLength {
+		realPrefixLength = termLength
+	}
+
+	builder := automaton.NewLevenshteinAutomata(string(termText[realPrefixLength:]), transpositions)
+	prefix := string(termText[:realPrefixLength])
+	matchers := make([]*automaton.ByteRunAutomaton, maxEdits)
+	for i := 1; i < maxEdits; i++ {
+		matchers[i] = automaton.NewByteRunAutomaton(builder.ToAutomatonWithPrefix(i, prefix), false)
+	}
+	compiled := automaton.NewCompiledAutomaton(builder.ToAutomatonWithPrefix(maxEdits, prefix))
+	assert(compiled.Type == automaton.AUTOMATON_TYPE_NORMAL)
+
+	tenum, err := terms.Intersect(compiled, nil)
+	if err != nil {
+		return nil, err
+	}
+	ans := &FuzzyTermsEnum{
+		matchers:   matchers,
+		termRef:    term.Bytes,
+		termLength: termLength,
+	}
+	ans.FilteredTermsEnum = index.NewFilteredTermsEnum(ans, tenum, false)
+	ans.boostAtt = boostAttribute(ans.Attributes())
+	return ans, nil
+}
+
+/*
+Accepts every term of the intersection, boosted by its exact edit
+distance, unless the distance covers the whole term.
+*/
+func (e *FuzzyTermsEnum) Accept(term []byte) (index.AcceptStatus, error) {
+	// we are wrapping an intersect() TermsEnum, so we know the outer
+	// DFA always matches. Now compute exact edit distance.
+	ed := len(e.matchers)
+	for ed > 0 && e.matches(term, ed-1) {
+		ed--
+	}
+
+	// scale to a rough similarity
+	if ed == 0 { // exact match
+		e.boostAtt.SetBoost(1.0)
+		return index.ACCEPT_STATUS_YES, nil
+	}
+	codePointCount := utf8.RuneCount(term)
+	if codePointCount > e.termLength {
+		codePointCount = e.termLength
+	}
+	if similarity := 1 - float32(ed)/float32(codePointCount); similarity > 0 {
+		e.boostAtt.SetBoost(similarity)
+		return index.ACCEPT_STATUS_YES, nil
+	}
+	return index.ACCEPT_STATUS_NO, nil
+}
+
+/* Returns true if term is within k edits of the query term */
+func (e *FuzzyTermsEnum) matches(term []byte, k int) bool {
+	if k == 0 {
+		return bytes.Equal(term, e.termRef)
+	}
+	return e.matchers[k].Run(term)
+}
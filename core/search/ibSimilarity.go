@@ -0,0 +1,243 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+// search/similarities/IBSimilarity.java
+
+/*
+Provides a framework for the family of information-based models, as
+described in Stéphane Clinchant and Eric Gaussier. 2010. Information-
+based models for ad hoc IR. In Proceeding of the 33rd international
+ACM SIGIR conference on Research and development in information
+retrieval (SIGIR '10). ACM, New York, NY, USA, 234-241.
+
+The retrieval function is of the form RSV(q, d) = ∑ -x^q_w log
+Prob(X_w ≥ t^d_w | λ_w), where
+
+x^q_w is the query boost;
+
+X_w is a random variable that counts the occurrences of word w;
+
+t^d_w is the normalized term frequency;
+
+λ_w is a parameter.
+
+The framework described in the paper has many similarities to the DFR
+framework (see DFRSimilarity). It is possible that the two
+Similarities will be merged at one point.
+
+To construct an IBSimilarity, you must specify the implementations
+for all three components of the Information-Based model:
+
+	Distribution: Probabilistic distribution used to model term occurrence
+		DistributionLL: Log-logistic
+		DistributionSPL: Smoothed power-law
+	Lambda: λ_w parameter of the probability distribution
+		LambdaDF: N_w/N or average number of documents where w occurs
+		LambdaTTF: F_w/N or average number of occurrences of w in the collection
+	Normalization: Term frequency normalization
+		Any supported DFR normalization (listed in DFRSimilarity)
+*/
+type IBSimilarity struct {
+	*SimilarityBase
+	// The probabilistic distribution used to model term occurrence.
+	distribution Distribution
+	// The lambda (λ_w) parameter.
+	lambda Lambda
+	// The term frequency normalization.
+	normalization Normalization
+}
+
+/* Creates IBSimilarity from the three components. */
+func NewIBSimilarity(distribution Distribution, lambda Lambda,
+	normalization Normalization) *IBSimilarity {
+
+	ans := &IBSimilarity{
+		distribution:  distribution,
+		lambda:        lambda,
+		normalization: normalization,
+	}
+	ans.SimilarityBase = newSimilarityBase(ans)
+	return ans
+}
+
+func (sim *IBSimilarity) score(s simStats, freq, docLen float32) float32 {
+	stats := s.basic()
+	return stats.totalBoost * sim.distribution.score(stats,
+		sim.normalization.tfn(stats, freq, docLen), sim.lambda.lambda(stats))
+}
+
+func (sim *IBSimilarity) addExplanation(expl *ExplanationImpl, s simStats,
+	doc int, freq, docLen float32) {
+
+	stats := s.basic()
+	if stats.totalBoost != 1 {
+		expl.AddDetail(NewExplanation(stats.totalBoost, "boost"))
+	}
+	normExpl := sim.normalization.explain(stats, freq, docLen)
+	lambdaExpl := sim.lambda.explain(stats)
+	expl.AddDetail(normExpl)
+	expl.AddDetail(lambdaExpl)
+	expl.AddDetail(sim.distribution.explain(stats, normExpl.Value(), lambdaExpl.Value()))
+}
+
+/*
+The name of IB methods follow the pattern IB <distribution>
+<lambda><normalization>. The name of the distribution is the same as
+in the original paper; for the names of lambda parameters, refer to
+the doc of the Lambda types.
+*/
+func (sim *IBSimilarity) String() string {
+	return fmt.Sprintf("IB %v-%v%v", sim.distribution, sim.lambda, sim.normalization)
+}
+
+/* Returns the distribution. */
+func (sim *IBSimilarity) Distribution() Distribution {
+	return sim.distribution
+}
+
+/* Returns the distribution's lambda parameter. */
+func (sim *IBSimilarity) Lambda() Lambda {
+	return sim.lambda
+}
+
+/* Returns the term frequency normalization. */
+func (sim *IBSimilarity) Normalization() Normalization {
+	return sim.normalization
+}
+
+// search/similarities/Distribution.java
+
+/*
+The probabilistic distribution used to model term occurrence in
+information-based models.
+*/
+type Distribution interface {
+	// Computes the score.
+	score(stats *BasicStats, tfn, lambda float32) float32
+	// Explains the score. Returns the name of the model only, since
+	// both tfn and lambda are explained elsewhere.
+	explain(stats *BasicStats, tfn, lambda float32) Explanation
+	String() string
+}
+
+// search/similarities/DistributionLL.java
+
+/*
+Log-logistic distribution.
+
+Unlike for DFR, the natural logarithm is used, as it is faster to
+compute and the original paper does not express any preference to a
+specific base.
+*/
+type DistributionLL struct{}
+
+func NewDistributionLL() *DistributionLL {
+	return &DistributionLL{}
+}
+
+func (d *DistributionLL) score(stats *BasicStats, tfn, lambda float32) float32 {
+	return float32(-math.Log(float64(lambda / (tfn + lambda))))
+}
+
+func (d *DistributionLL) explain(stats *BasicStats, tfn, lambda float32) Explanation {
+	return NewExplanation(d.score(stats, tfn, lambda), simpleName(d))
+}
+
+func (d *DistributionLL) String() string {
+	return "LL"
+}
+
+// search/similarities/DistributionSPL.java
+
+/*
+The smoothed power-law (SPL) distribution for the information-based
+framework that is described in the original paper.
+
+Unlike for DFR, the natural logarithm is used, as it is faster to
+compute and the original paper does not express any preference to a
+specific base.
+*/
+type DistributionSPL struct{}
+
+func NewDistributionSPL() *DistributionSPL {
+	return &DistributionSPL{}
+}
+
+func (d *DistributionSPL) score(stats *BasicStats, tfn, lambda float32) float32 {
+	if lambda == 1 {
+		lambda = 0.99
+	}
+	return float32(-math.Log((math.Pow(float64(lambda), float64(tfn/(tfn+1))) -
+		float64(lambda)) / float64(1-lambda)))
+}
+
+func (d *DistributionSPL) explain(stats *BasicStats, tfn, lambda float32) Explanation {
+	return NewExplanation(d.score(stats, tfn, lambda), simpleName(d))
+}
+
+func (d *DistributionSPL) String() string {
+	return "SPL"
+}
+
+// search/similarities/Lambda.java
+
+/* The lambda (λ_w) parameter in information-based models. */
+type Lambda interface {
+	// Computes the lambda parameter.
+	lambda(stats *BasicStats) float32
+	// Explains the lambda parameter.
+	explain(stats *BasicStats) Explanation
+	String() string
+}
+
+// search/similarities/LambdaDF.java
+
+/* Computes lambda as docFreq+1 / numberOfDocuments+1. */
+type LambdaDF struct{}
+
+func NewLambdaDF() *LambdaDF {
+	return &LambdaDF{}
+}
+
+func (l *LambdaDF) lambda(stats *BasicStats) float32 {
+	return (float32(stats.docFreq) + 1) / (float32(stats.numberOfDocuments) + 1)
+}
+
+func (l *LambdaDF) explain(stats *BasicStats) Explanation {
+	result := NewExplanation(l.lambda(stats), fmt.Sprintf("%v, computed from: ", simpleName(l)))
+	result.AddDetail(NewExplanation(float32(stats.docFreq), "docFreq"))
+	result.AddDetail(NewExplanation(float32(stats.numberOfDocuments), "numberOfDocuments"))
+	return result
+}
+
+func (l *LambdaDF) String() string {
+	return "D"
+}
+
+// search/similarities/LambdaTTF.java
+
+/* Computes lambda as totalTermFreq+1 / numberOfDocuments+1. */
+type LambdaTTF struct{}
+
+func NewLambdaTTF() *LambdaTTF {
+	return &LambdaTTF{}
+}
+
+func (l *LambdaTTF) lambda(stats *BasicStats) float32 {
+	return (float32(stats.totalTermFreq) + 1) / (float32(stats.numberOfDocuments) + 1)
+}
+
+func (l *LambdaTTF) explain(stats *BasicStats) Explanation {
+	result := NewExplanation(l.lambda(stats), fmt.Sprintf("%v, computed from: ", simpleName(l)))
+	result.AddDetail(NewExplanation(float32(stats.totalTermFreq), "totalTermFreq"))
+	result.AddDetail(NewExplanation(float32(stats.numberOfDocuments), "numberOfDocuments"))
+	return result
+}
+
+func (l *LambdaTTF) String() string {
+	return "L"
+}
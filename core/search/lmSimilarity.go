@@ -0,0 +1,246 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+// search/similarities/LMSimilarity.java
+
+/* Stores the collection distribution of the current term. */
+type LMStats struct {
+	*BasicStats
+	// The probability that the current term is generated by the
+	// collection.
+	collectionProbability float32
+}
+
+func NewLMStats(field string, queryBoost float32) *LMStats {
+	return &LMStats{BasicStats: NewBasicStats(field, queryBoost)}
+}
+
+/* Returns the probability that the current term is generated by the collection. */
+func (stats *LMStats) CollectionProbability() float32 {
+	return stats.collectionProbability
+}
+
+/* A strategy for computing the collection language model. */
+type CollectionModel interface {
+	// Computes the probability p(w|C) according to the language model
+	// strategy for the current term.
+	computeProbability(stats *BasicStats) float32
+	// The name of the collection model strategy.
+	name() string
+}
+
+/*
+Models p(w|C) as the number of occurrences of the term in the
+collection, divided by the total number of tokens + 1.
+*/
+type DefaultCollectionModel struct{}
+
+func NewDefaultCollectionModel() *DefaultCollectionModel {
+	return &DefaultCollectionModel{}
+}
+
+func (m *DefaultCollectionModel) computeProbability(stats *BasicStats) float32 {
+	return (float32(stats.totalTermFreq) + 1) / (float32(stats.numberOfFieldTokens) + 1)
+}
+
+func (m *DefaultCollectionModel) name() string {
+	return ""
+}
+
+type LMSimilaritySPI interface {
+	SimilarityBaseSPI
+	// Returns the name of the LM method. The values of the parameters
+	// should be included as well. Used in String().
+	Name() string
+}
+
+/*
+Abstract superclass for language modeling Similarities. The following
+inner types are introduced:
+
+LMStats, which defines a new statistic, the probability that the
+collection language model generates the current term;
+
+CollectionModel, which is a strategy interface for object that
+compute the collection language model p(w|C);
+
+DefaultCollectionModel, an implementation of the former, that
+computes the term probability as the number of occurrences of the
+term in the collection, divided by the total number of tokens.
+*/
+type LMSimilarity struct {
+	*SimilarityBase
+	spi LMSimilaritySPI
+	// The collection model.
+	collectionModel CollectionModel
+}
+
+func newLMSimilarity(spi LMSimilaritySPI, collectionModel CollectionModel) *LMSimilarity {
+	return &LMSimilarity{
+		SimilarityBase:  newSimilarityBase(spi),
+		spi:             spi,
+		collectionModel: collectionModel,
+	}
+}
+
+func (sim *LMSimilarity) newStats(field string, queryBoost float32) simStats {
+	return NewLMStats(field, queryBoost)
+}
+
+/* Computes the collection probability of the current term in addition to the usual statistics. */
+func (sim *LMSimilarity) fillBasicStats(stats simStats,
+	collectionStats CollectionStatistics, termStats TermStatistics) {
+
+	sim.SimilarityBase.fillBasicStats(stats, collectionStats, termStats)
+	stats.(*LMStats).collectionProbability = sim.collectionModel.computeProbability(stats.basic())
+}
+
+func (sim *LMSimilarity) addExplanation(expl *ExplanationImpl, stats simStats,
+	doc int, freq, docLen float32) {
+
+	expl.AddDetail(NewExplanation(sim.collectionModel.computeProbability(stats.basic()),
+		"collection probability"))
+}
+
+/*
+Returns the name of the LM method. If a custom collection model
+strategy is used, its name is included as well.
+*/
+func (sim *LMSimilarity) String() string {
+	if coll := sim.collectionModel.name(); coll != "" {
+		return fmt.Sprintf("LM %v - %v", sim.spi.Name(), coll)
+	}
+	return fmt.Sprintf("LM %v", sim.spi.Name())
+}
+
+// search/similarities/LMDirichletSimilarity.java
+
+/*
+Bayesian smoothing using Dirichlet priors. From Chengxiang Zhai and
+John Lafferty. 2001. A study of smoothing methods for language models
+applied to Ad Hoc information retrieval. In Proceedings of the 24th
+annual international ACM SIGIR conference on Research and development
+in information retrieval (SIGIR '01). ACM, New York, NY, USA, 334-342.
+
+The formula as defined the paper assigns a negative score to documents
+that contain the term, but with fewer occurrences than predicted by
+the collection language model. The Lucene implementation returns 0
+for such documents.
+*/
+type LMDirichletSimilarity struct {
+	*LMSimilarity
+	// The μ parameter.
+	mu float32
+}
+
+/* Instantiates the similarity with the default μ value of 2000. */
+func NewLMDirichletSimilarity() *LMDirichletSimilarity {
+	return NewLMDirichletSimilarityWithMu(2000)
+}
+
+/* Instantiates the similarity with the provided μ parameter. */
+func NewLMDirichletSimilarityWithMu(mu float32) *LMDirichletSimilarity {
+	return NewLMDirichletSimilarityWithModel(NewDefaultCollectionModel(), mu)
+}
+
+/* Instantiates the similarity with the provided collection model and μ parameter. */
+func NewLMDirichletSimilarityWithModel(collectionModel CollectionModel, mu float32) *LMDirichletSimilarity {
+	ans := &LMDirichletSimilarity{mu: mu}
+	ans.LMSimilarity = newLMSimilarity(ans, collectionModel)
+	return ans
+}
+
+func (sim *LMDirichletSimilarity) score(stats simStats, freq, docLen float32) float32 {
+	score := stats.basic().totalBoost * float32(
+		math.Log(float64(1+freq/(sim.mu*stats.(*LMStats).collectionProbability)))+
+			math.Log(float64(sim.mu/(docLen+sim.mu))))
+	if score > 0 {
+		return score
+	}
+	return 0
+}
+
+func (sim *LMDirichletSimilarity) addExplanation(expl *ExplanationImpl, stats simStats,
+	doc int, freq, docLen float32) {
+
+	if boost := stats.basic().totalBoost; boost != 1 {
+		expl.AddDetail(NewExplanation(boost, "boost"))
+	}
+	expl.AddDetail(NewExplanation(sim.mu, "mu"))
+	expl.AddDetail(NewExplanation(float32(math.Log(float64(
+		1+freq/(sim.mu*stats.(*LMStats).collectionProbability)))), "term weight"))
+	expl.AddDetail(NewExplanation(float32(math.Log(float64(sim.mu/(docLen+sim.mu)))), "document norm"))
+	sim.LMSimilarity.addExplanation(expl, stats, doc, freq, docLen)
+}
+
+/* Returns the μ parameter. */
+func (sim *LMDirichletSimilarity) Mu() float32 {
+	return sim.mu
+}
+
+func (sim *LMDirichletSimilarity) Name() string {
+	return fmt.Sprintf("Dirichlet(%f)", sim.mu)
+}
+
+// search/similarities/LMJelinekMercerSimilarity.java
+
+/*
+Language model based on the Jelinek-Mercer smoothing method. From
+Chengxiang Zhai and John Lafferty. 2001. A study of smoothing methods
+for language models applied to Ad Hoc information retrieval. In
+Proceedings of the 24th annual international ACM SIGIR conference on
+Research and development in information retrieval (SIGIR '01). ACM,
+New York, NY, USA, 334-342.
+
+The model has a single parameter, λ. According to said paper, the
+optimal value depends on both the collection and the query. The
+optimal value is around 0.1 for title queries and 0.7 for long
+queries.
+*/
+type LMJelinekMercerSimilarity struct {
+	*LMSimilarity
+	// The λ parameter.
+	lambda float32
+}
+
+/* Instantiates with the specified λ parameter. */
+func NewLMJelinekMercerSimilarity(lambda float32) *LMJelinekMercerSimilarity {
+	return NewLMJelinekMercerSimilarityWithModel(NewDefaultCollectionModel(), lambda)
+}
+
+/* Instantiates with the specified collection model and λ parameter. */
+func NewLMJelinekMercerSimilarityWithModel(collectionModel CollectionModel,
+	lambda float32) *LMJelinekMercerSimilarity {
+
+	ans := &LMJelinekMercerSimilarity{lambda: lambda}
+	ans.LMSimilarity = newLMSimilarity(ans, collectionModel)
+	return ans
+}
+
+func (sim *LMJelinekMercerSimilarity) score(stats simStats, freq, docLen float32) float32 {
+	return stats.basic().totalBoost * float32(math.Log(float64(
+		1+((1-sim.lambda)*freq/docLen)/(sim.lambda*stats.(*LMStats).collectionProbability))))
+}
+
+func (sim *LMJelinekMercerSimilarity) addExplanation(expl *ExplanationImpl, stats simStats,
+	doc int, freq, docLen float32) {
+
+	if boost := stats.basic().totalBoost; boost != 1 {
+		expl.AddDetail(NewExplanation(boost, "boost"))
+	}
+	expl.AddDetail(NewExplanation(sim.lambda, "lambda"))
+	sim.LMSimilarity.addExplanation(expl, stats, doc, freq, docLen)
+}
+
+/* Returns the λ parameter. */
+func (sim *LMJelinekMercerSimilarity) Lambda() float32 {
+	return sim.lambda
+}
+
+func (sim *LMJelinekMercerSimilarity) Name() string {
+	return fmt.Sprintf("Jelinek-Mercer(%f)", sim.lambda)
+}
@@ -0,0 +1,128 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/MatchAllDocsQuery.java
+
+/*
+A query that matches all documents.
+*/
+type MatchAllDocsQuery struct {
+	*AbstractQuery
+}
+
+func NewMatchAllDocsQuery() *MatchAllDocsQuery {
+	ans := new(MatchAllDocsQuery)
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+func (q *MatchAllDocsQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	return newMatchAllDocsWeight(q), nil
+}
+
+func (q *MatchAllDocsQuery) ToString(field string) string {
+	return fmt.Sprintf("*:*%v", boostString(q.Boost()))
+}
+
+type matchAllDocsWeight struct {
+	*WeightImpl
+	owner       *MatchAllDocsQuery
+	queryWeight float32
+	queryNorm   float32
+}
+
+func newMatchAllDocsWeight(owner *MatchAllDocsQuery) *matchAllDocsWeight {
+	ans := &matchAllDocsWeight{owner: owner}
+	ans.WeightImpl = NewWeightImpl(ans)
+	return ans
+}
+
+func (w *matchAllDocsWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.owner)
+}
+
+func (w *matchAllDocsWeight) ValueForNormalization() float32 {
+	w.queryWeight = w.owner.Boost()
+	return w.queryWeight * w.queryWeight
+}
+
+func (w *matchAllDocsWeight) Normalize(norm float32, topLevelBoost float32) {
+	w.queryNorm = norm * topLevelBoost
+	w.queryWeight *= w.queryNorm
+}
+
+func (w *matchAllDocsWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *matchAllDocsWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	return newMatchAllScorer(context.Reader().MaxDoc(), acceptDocs, w, w.queryWeight), nil
+}
+
+func (w *matchAllDocsWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
+	// explain query weight
+	queryExpl := NewComplexExplanation(true, w.queryWeight, "MatchAllDocsQuery, product of:")
+	if w.owner.Boost() != 1 {
+		queryExpl.AddDetail(NewExplanation(w.owner.Boost(), "boost"))
+	}
+	queryExpl.AddDetail(NewExplanation(w.queryNorm, "queryNorm"))
+	return queryExpl, nil
+}
+
+type matchAllScorer struct {
+	*abstractScorer
+	score    float32
+	doc      int
+	maxDoc   int
+	liveDocs util.Bits
+}
+
+func newMatchAllScorer(maxDoc int, liveDocs util.Bits, w Weight, score float32) *matchAllScorer {
+	ans := &matchAllScorer{
+		score:    score,
+		doc:      -1,
+		maxDoc:   maxDoc,
+		liveDocs: liveDocs,
+	}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans
+}
+
+func (s *matchAllScorer) DocId() int {
+	return s.doc
+}
+
+func (s *matchAllScorer) NextDoc() (int, error) {
+	for s.doc++; s.doc < s.maxDoc; s.doc++ {
+		if s.liveDocs == nil || s.liveDocs.At(s.doc) {
+			return s.doc, nil
+		}
+	}
+	s.doc = NO_MORE_DOCS
+	return s.doc, nil
+}
+
+func (s *matchAllScorer) Cost() int64 {
+	return int64(s.maxDoc)
+}
+
+func (s *matchAllScorer) Score() (float32, error) {
+	return s.score, nil
+}
+
+func (s *matchAllScorer) Freq() (int, error) {
+	return 1, nil
+}
+
+func (s *matchAllScorer) Advance(target int) (int, error) {
+	s.doc = target - 1
+	return s.NextDoc()
+}
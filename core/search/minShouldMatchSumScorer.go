@@ -0,0 +1,379 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"math"
+	"sort"
+)
+
+// search/MinShouldMatchSumScorer.java
+
+/*
+A Scorer for OR like queries, counterpart of ConjunctionScorer. This
+Scorer implements Scorer.Advance() and uses Advance() on the given
+Scorers.
+
+This implementation uses the minimumMatch constraint actively to
+efficiently prune the number of candidates, it is hence a mixture
+between a pure DisjunctionScorer and a ConjunctionScorer.
+*/
+type MinShouldMatchSumScorer struct {
+	*abstractScorer
+
+	// The overall number of non-finalized scorers
+	numScorers int
+	// The minimum number of scorers that should match
+	mm int
+
+	// A static array of all subscorers sorted by decreasing cost
+	sortedSubScorers []Scorer
+	// A monotonically increasing index into the array pointing to the
+	// next subscorer that is to be excluded
+	sortedSubScorersIdx int
+
+	subScorers []Scorer // the first numScorers-(mm-1) entries are valid
+	nrInHeap   int      // 0..(numScorers-(mm-1)-1)
+
+	// mmStack is supposed to contain the most costly subScorers that
+	// still did not run out of docs, sorted by increasing sparsity of
+	// docs returned by that subScorer. For now, the cost of subscorers
+	// is assumed to be inversely correlated with sparsity.
+	mmStack []Scorer // of size mm-1: 0..mm-2, always full
+
+	// The document number of the current match.
+	doc int
+	// The number of subscorers that provide the current match.
+	nrMatchers int
+	score      float64
+
+	coord []float32
+}
+
+/*
+Construct a MinShouldMatchSumScorer. subScorers must contain at least
+two scorers, and minimumNrMatchers must be positive and no more than
+the number of subScorers.
+*/
+func newMinShouldMatchSumScorer(weight Weight, subScorers []Scorer,
+	minimumNrMatchers int, coord []float32) *MinShouldMatchSumScorer {
+
+	assert2(minimumNrMatchers > 0, "Minimum nr of matchers must be positive")
+	assert2(len(subScorers) > 1, "There must be at least 2 subScorers")
+
+	ans := &MinShouldMatchSumScorer{
+		numScorers: len(subScorers),
+		nrInHeap:   len(subScorers),
+		mm:         minimumNrMatchers,
+		doc:        -1,
+		nrMatchers: -1,
+		score:      math.NaN(),
+		coord:      coord,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	// sort subScorers by decreasing cost
+	ans.sortedSubScorers = make([]Scorer, len(subScorers))
+	copy(ans.sortedSubScorers, subScorers)
+	sort.Stable(scorersByDecreasingCost(ans.sortedSubScorers))
+	// take mm-1 most costly subscorers aside
+	ans.mmStack = make([]Scorer, ans.mm-1)
+	copy(ans.mmStack, ans.sortedSubScorers[:ans.mm-1])
+	ans.nrInHeap -= ans.mm - 1
+	ans.sortedSubScorersIdx = ans.mm - 1
+	// take remaining into heap, if any, and heapify
+	ans.subScorers = make([]Scorer, ans.nrInHeap)
+	copy(ans.subScorers, ans.sortedSubScorers[ans.mm-1:])
+	ans.minheapHeapify()
+	return ans
+}
+
+type scorersByDecreasingCost []Scorer
+
+func (s scorersByDecreasingCost) Len() int           { return len(s) }
+func (s scorersByDecreasingCost) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s scorersByDecreasingCost) Less(i, j int) bool { return s[i].Cost() > s[j].Cost() }
+
+func (s *MinShouldMatchSumScorer) Cost() int64 {
+	// cost for merging of lists analog to DisjunctionSumScorer
+	var costCandidateGeneration int64
+	for _, sub := range s.subScorers[:s.nrInHeap] {
+		costCandidateGeneration += sub.Cost()
+	}
+	// heap-merge cost, plus advance() cost
+	return costCandidateGeneration + costCandidateGeneration*int64(s.nrInHeap)
+}
+
+func (s *MinShouldMatchSumScorer) NextDoc() (int, error) {
+	assert(s.doc != NO_MORE_DOCS)
+	for {
+		// to remove current doc, call NextDoc() on all subScorers on
+		// current doc within heap
+		for s.subScorers[0].DocId() == s.doc {
+			doc, err := s.subScorers[0].NextDoc()
+			if err != nil {
+				return 0, err
+			}
+			if doc != NO_MORE_DOCS {
+				s.minheapSiftDown(0)
+			} else {
+				s.minheapRemoveRoot()
+				s.numScorers--
+				if s.numScorers < s.mm {
+					s.doc = NO_MORE_DOCS
+					return s.doc, nil
+				}
+			}
+		}
+
+		if err := s.evaluateSmallestDocInHeap(); err != nil {
+			return 0, err
+		}
+
+		if s.nrMatchers >= s.mm { // doc satisfies mm constraint
+			return s.doc, nil
+		}
+	}
+}
+
+func (s *MinShouldMatchSumScorer) evaluateSmallestDocInHeap() error {
+	// within heap, subScorers[0] now contains the next candidate doc
+	s.doc = s.subScorers[0].DocId()
+	if s.doc == NO_MORE_DOCS {
+		s.nrMatchers = math.MaxInt32 // stop looping
+		return nil
+	}
+	// 1. score and count number of matching subScorers within heap
+	score, err := s.subScorers[0].Score()
+	if err != nil {
+		return err
+	}
+	s.score = float64(score)
+	s.nrMatchers = 1
+	if err = s.countMatches(1); err != nil {
+		return err
+	}
+	if err = s.countMatches(2); err != nil {
+		return err
+	}
+	// 2. score and count number of matching subScorers within stack,
+	// short-circuit: stop when mm can't be reached for current doc,
+	// then perform on heap next()
+	// TODO instead Advance() might be possible, but complicates things
+	for i := s.mm - 2; i >= 0; i-- { // first advance sparsest subScorer
+		sub := s.mmStack[i]
+		subDoc := sub.DocId()
+		if subDoc < s.doc {
+			if subDoc, err = sub.Advance(s.doc); err != nil {
+				return err
+			}
+		}
+		if subDoc != NO_MORE_DOCS {
+			if subDoc == s.doc { // either it was already on doc, or got there via Advance()
+				s.nrMatchers++
+				if score, err = sub.Score(); err != nil {
+					return err
+				}
+				s.score += float64(score)
+			} else if s.nrMatchers+i < s.mm {
+				// scorer advanced to next after doc, and too few
+				// subScorers left for current doc, abort advancing
+				return nil // continue looping TODO consider Advance() here
+			}
+		} else { // subScorer exhausted
+			s.numScorers--
+			if s.numScorers < s.mm { // too few subScorers left
+				s.doc = NO_MORE_DOCS
+				s.nrMatchers = math.MaxInt32 // stop looping
+				return nil
+			}
+			// shift RHS of array left
+			copy(s.mmStack[i:], s.mmStack[i+1:])
+			// find next most costly subScorer within heap
+			// TODO can this be done better?
+			for !s.minheapRemove(s.sortedSubScorers[s.sortedSubScorersIdx]) {
+				s.sortedSubScorersIdx++
+			}
+			s.sortedSubScorersIdx++
+			// add the subScorer removed from heap to stack
+			s.mmStack[s.mm-2] = s.sortedSubScorers[s.sortedSubScorersIdx-1]
+
+			if s.nrMatchers+i < s.mm { // too few subScorers left, abort advancing
+				return nil // continue looping TODO consider Advance() here
+			}
+		}
+	}
+	return nil
+}
+
+// TODO: this currently scores, but so did the previous impl
+// TODO: remove recursion.
+func (s *MinShouldMatchSumScorer) countMatches(root int) error {
+	if root < s.nrInHeap && s.subScorers[root].DocId() == s.doc {
+		s.nrMatchers++
+		score, err := s.subScorers[root].Score()
+		if err != nil {
+			return err
+		}
+		s.score += float64(score)
+		if err = s.countMatches((root << 1) + 1); err != nil {
+			return err
+		}
+		return s.countMatches((root << 1) + 2)
+	}
+	return nil
+}
+
+/*
+Returns the score of the current document matching the query.
+Initially invalid, until NextDoc() is called the first time.
+*/
+func (s *MinShouldMatchSumScorer) Score() (float32, error) {
+	return s.coord[s.nrMatchers] * float32(s.score), nil
+}
+
+func (s *MinShouldMatchSumScorer) DocId() int {
+	return s.doc
+}
+
+func (s *MinShouldMatchSumScorer) Freq() (int, error) {
+	return s.nrMatchers, nil
+}
+
+/*
+Advances to the first match beyond the current whose document number
+is greater than or equal to a given target.
+
+The implementation uses the Advance() method on the subscorers.
+*/
+func (s *MinShouldMatchSumScorer) Advance(target int) (int, error) {
+	if s.numScorers < s.mm {
+		s.doc = NO_MORE_DOCS
+		return s.doc, nil
+	}
+	// advance all Scorers in heap at smaller docs to at least target
+	for s.subScorers[0].DocId() < target {
+		doc, err := s.subScorers[0].Advance(target)
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			s.minheapSiftDown(0)
+		} else {
+			s.minheapRemoveRoot()
+			s.numScorers--
+			if s.numScorers < s.mm {
+				s.doc = NO_MORE_DOCS
+				return s.doc, nil
+			}
+		}
+	}
+
+	if err := s.evaluateSmallestDocInHeap(); err != nil {
+		return 0, err
+	}
+
+	if s.nrMatchers >= s.mm {
+		return s.doc, nil
+	}
+	return s.NextDoc()
+}
+
+/*
+Organize subScorers into a min heap with scorers generating the
+earliest document on top.
+*/
+func (s *MinShouldMatchSumScorer) minheapHeapify() {
+	for i := (s.nrInHeap >> 1) - 1; i >= 0; i-- {
+		s.minheapSiftDown(i)
+	}
+}
+
+/*
+The subtree of subScorers at root is a min heap except possibly for
+its root element. Bubble the root down as required to make the
+subtree a heap.
+*/
+func (s *MinShouldMatchSumScorer) minheapSiftDown(root int) {
+	// TODO could this implementation also move rather than swapping
+	// neighbours?
+	scorer := s.subScorers[root]
+	doc := scorer.DocId()
+	i := root
+	for i <= (s.nrInHeap>>1)-1 {
+		lchild := (i << 1) + 1
+		lscorer := s.subScorers[lchild]
+		ldoc := lscorer.DocId()
+		rdoc, rchild := math.MaxInt32, (i<<1)+2
+		var rscorer Scorer
+		if rchild < s.nrInHeap {
+			rscorer = s.subScorers[rchild]
+			rdoc = rscorer.DocId()
+		}
+		if ldoc < doc {
+			if rdoc < ldoc {
+				s.subScorers[i] = rscorer
+				s.subScorers[rchild] = scorer
+				i = rchild
+			} else {
+				s.subScorers[i] = lscorer
+				s.subScorers[lchild] = scorer
+				i = lchild
+			}
+		} else if rdoc < doc {
+			s.subScorers[i] = rscorer
+			s.subScorers[rchild] = scorer
+			i = rchild
+		} else {
+			return
+		}
+	}
+}
+
+func (s *MinShouldMatchSumScorer) minheapSiftUp(i int) {
+	scorer := s.subScorers[i]
+	doc := scorer.DocId()
+	// find right place for scorer
+	for i > 0 {
+		parent := (i - 1) >> 1
+		if s.subScorers[parent].DocId() <= doc {
+			break // done, found right place
+		}
+		// move root down, make space
+		s.subScorers[i] = s.subScorers[parent]
+		i = parent
+	}
+	s.subScorers[i] = scorer
+}
+
+/* Remove the root Scorer from subScorers and re-establish it as a heap */
+func (s *MinShouldMatchSumScorer) minheapRemoveRoot() {
+	if s.nrInHeap == 1 {
+		s.nrInHeap = 0
+	} else {
+		s.nrInHeap--
+		s.subScorers[0] = s.subScorers[s.nrInHeap]
+		s.minheapSiftDown(0)
+	}
+}
+
+/*
+Removes a given Scorer from the heap by placing end of heap at that
+position and bubbling it either up or down
+*/
+func (s *MinShouldMatchSumScorer) minheapRemove(scorer Scorer) bool {
+	// find scorer: O(nrInHeap)
+	for i := 0; i < s.nrInHeap; i++ {
+		if s.subScorers[i] == scorer { // remove scorer
+			s.nrInHeap--
+			s.subScorers[i] = s.subScorers[s.nrInHeap]
+			s.minheapSiftUp(i)
+			s.minheapSiftDown(i)
+			return true
+		}
+	}
+	return false // scorer already exhausted
+}
+
+func (s *MinShouldMatchSumScorer) String() string {
+	return fmt.Sprintf("MinShouldMatchSumScorer(%v)", s.weight)
+}
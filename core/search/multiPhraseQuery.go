@@ -0,0 +1,542 @@
+package search
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"sort"
+)
+
+// search/MultiPhraseQuery.java
+
+/*
+MultiPhraseQuery is a generalized version of PhraseQuery, with an
+added method AddAt() for adding more than one term at the same
+position that are treated as a disjunction (OR). To use this class,
+to search for the phrase "Microsoft app*" first use Add(Term) on the
+term "Microsoft", then find all terms that have "app" as prefix using
+IndexReader.Terms(), and use AddAt(terms, position) to add them to
+the query.
+*/
+type MultiPhraseQuery struct {
+	*AbstractQuery
+	field      string
+	termArrays [][]*index.Term
+	positions  []int
+	slop       int
+}
+
+/* Constructs an empty multi-phrase query. */
+func NewMultiPhraseQuery() *MultiPhraseQuery {
+	ans := &MultiPhraseQuery{}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/*
+Sets the phrase slop for this query.
+See PhraseQuery.SetSlop().
+*/
+func (q *MultiPhraseQuery) SetSlop(s int) {
+	assert2(s >= 0, "slop value cannot be negative")
+	q.slop = s
+}
+
+/*
+Returns the phrase slop for this query.
+See PhraseQuery.Slop().
+*/
+func (q *MultiPhraseQuery) Slop() int {
+	return q.slop
+}
+
+/*
+Adds a term, or several alternative terms, to the end of the query
+phrase. The relative position of the terms is the one immediately
+after the last terms added.
+*/
+func (q *MultiPhraseQuery) Add(terms ...*index.Term) {
+	position := 0
+	if len(q.positions) > 0 {
+		position = q.positions[len(q.positions)-1] + 1
+	}
+	q.AddAt(terms, position)
+}
+
+/*
+Allows to specify the relative position of terms within the phrase.
+*/
+func (q *MultiPhraseQuery) AddAt(terms []*index.Term, position int) {
+	assert2(len(terms) > 0, "terms cannot be empty")
+	if len(q.termArrays) == 0 {
+		q.field = terms[0].Field
+	}
+	for _, term := range terms {
+		if term.Field != q.field {
+			panic(fmt.Sprintf("All phrase terms must be in the same field (%v): %v", q.field, term))
+		}
+	}
+
+	q.termArrays = append(q.termArrays, terms)
+	q.positions = append(q.positions, position)
+}
+
+/* Returns the list of term arrays in this phrase. */
+func (q *MultiPhraseQuery) TermArrays() [][]*index.Term {
+	return q.termArrays
+}
+
+/* Returns the relative positions of terms in this phrase. */
+func (q *MultiPhraseQuery) Positions() []int {
+	return q.positions
+}
+
+func (q *MultiPhraseQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	switch len(q.termArrays) {
+	case 0:
+		bq := NewBooleanQuery()
+		bq.SetBoost(q.Boost())
+		return bq, nil
+	case 1: // optimize one-term case
+		boq := NewBooleanQueryDisableCoord(true)
+		for _, term := range q.termArrays[0] {
+			boq.Add(NewTermQuery(term), SHOULD)
+		}
+		boq.SetBoost(q.Boost())
+		return boq, nil
+	default:
+		return q, nil
+	}
+}
+
+type MultiPhraseWeight struct {
+	*WeightImpl
+	*MultiPhraseQuery
+	similarity   Similarity
+	stats        SimWeight
+	termContexts map[string]*index.TermContext
+}
+
+func newMultiPhraseWeight(owner *MultiPhraseQuery,
+	searcher *IndexSearcher) (*MultiPhraseWeight, error) {
+
+	ctx := searcher.TopReaderContext()
+	termContexts := make(map[string]*index.TermContext)
+
+	// compute idf
+	var allTermStats []TermStatistics
+	for _, terms := range owner.termArrays {
+		for _, term := range terms {
+			termContext, ok := termContexts[termKey(term)]
+			if !ok {
+				var err error
+				if termContext, err = index.NewTermContextFromTerm(ctx, term); err != nil {
+					return nil, err
+				}
+				termContexts[termKey(term)] = termContext
+			}
+			allTermStats = append(allTermStats, searcher.TermStatistics(term, termContext))
+		}
+	}
+	ans := &MultiPhraseWeight{
+		MultiPhraseQuery: owner,
+		similarity:       searcher.similarity,
+		stats: searcher.similarity.computeWeight(owner.boost,
+			searcher.CollectionStatistics(owner.field), allTermStats...),
+		termContexts: termContexts,
+	}
+	ans.WeightImpl = NewWeightImpl(ans)
+	return ans, nil
+}
+
+func (w *MultiPhraseWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.MultiPhraseQuery)
+}
+
+func (w *MultiPhraseWeight) ValueForNormalization() float32 {
+	return w.stats.ValueForNormalization()
+}
+
+func (w *MultiPhraseWeight) Normalize(queryNorm, topLevelBoost float32) {
+	w.stats.Normalize(queryNorm, topLevelBoost)
+}
+
+func (w *MultiPhraseWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *MultiPhraseWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	assert(len(w.termArrays) > 0)
+	reader := context.Reader().(index.AtomicReader)
+	postingsFreqs := make([]*postingsAndFreq, len(w.termArrays))
+
+	fieldTerms := reader.Terms(w.field)
+	if fieldTerms == nil {
+		return nil, nil
+	}
+
+	// Reuse single TermsEnum below:
+	termsEnum := fieldTerms.Iterator(nil)
+
+	for pos, terms := range w.termArrays {
+		var postingsEnum DocsAndPositionsEnum
+		var docFreq int
+
+		if len(terms) > 1 {
+			var err error
+			if postingsEnum, err = newUnionDocsAndPositionsEnum(acceptDocs,
+				context, terms, w.termContexts, termsEnum); err != nil {
+				return nil, err
+			}
+
+			// coarse -- this overcounts since a given doc can have more
+			// than one term:
+			for _, term := range terms {
+				termState := w.termContexts[termKey(term)].State(context.Ord)
+				if termState == nil { // term doesn't exist in reader
+					continue
+				}
+				if err = termsEnum.SeekExactFromLast(term.Bytes, termState); err != nil {
+					return nil, err
+				}
+				n, err := termsEnum.DocFreq()
+				if err != nil {
+					return nil, err
+				}
+				docFreq += n
+			}
+
+			if docFreq == 0 {
+				// None of the terms are in this reader
+				return nil, nil
+			}
+		} else {
+			term := terms[0]
+			termState := w.termContexts[termKey(term)].State(context.Ord)
+			if termState == nil { // term doesn't exist in reader
+				return nil, nil
+			}
+			err := termsEnum.SeekExactFromLast(term.Bytes, termState)
+			if err != nil {
+				return nil, err
+			}
+			if postingsEnum, err = termsEnum.DocsAndPositionsByFlags(acceptDocs, nil, 0); err != nil {
+				return nil, err
+			}
+
+			if postingsEnum == nil {
+				// term does exist, but has no positions
+				return nil, errors.New(fmt.Sprintf(
+					"field '%v' was indexed without position data; cannot run PhraseQuery (term=%v)",
+					term.Field, string(term.Bytes)))
+			}
+
+			if docFreq, err = termsEnum.DocFreq(); err != nil {
+				return nil, err
+			}
+		}
+
+		postingsFreqs[pos] = newPostingsAndFreq(postingsEnum, docFreq, w.positions[pos], terms...)
+	}
+
+	simScorer, err := w.similarity.simScorer(w.stats, context)
+	if err != nil {
+		return nil, err
+	}
+	if w.slop == 0 { // optimize exact case
+		// sort by increasing docFreq order
+		sort.Stable(postingsAndFreqSorter(postingsFreqs))
+		return newExactPhraseScorer(w, postingsFreqs, simScorer), nil
+	}
+	return newSloppyPhraseScorer(w, postingsFreqs, w.slop, simScorer), nil
+}
+
+func (w *MultiPhraseWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
+	scorer, err := w.Scorer(context, context.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	if scorer != nil {
+		newDoc, err := scorer.Advance(doc)
+		if err != nil {
+			return nil, err
+		}
+		if newDoc == doc {
+			var freq float32
+			if w.slop == 0 {
+				n, err := scorer.Freq()
+				if err != nil {
+					return nil, err
+				}
+				freq = float32(n)
+			} else {
+				freq = scorer.(*SloppyPhraseScorer).sloppyFreq
+			}
+			docScorer, err := w.similarity.simScorer(w.stats, context)
+			if err != nil {
+				return nil, err
+			}
+			scoreExplanation := docScorer.explain(doc,
+				NewExplanation(freq, fmt.Sprintf("phraseFreq=%v", freq)))
+			ans := NewComplexExplanation(true, scoreExplanation.Value(),
+				fmt.Sprintf("weight(%v in %v) [%v], result of:",
+					w.MultiPhraseQuery, doc, reflect.TypeOf(w.similarity)))
+			ans.AddDetail(scoreExplanation)
+			return ans, nil
+		}
+	}
+	return NewComplexExplanation(false, 0, "no matching term"), nil
+}
+
+func (q *MultiPhraseQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newMultiPhraseWeight(q, searcher)
+}
+
+func (q *MultiPhraseQuery) ToString(f string) string {
+	var buf bytes.Buffer
+	if q.field != "" && q.field != f {
+		buf.WriteString(q.field)
+		buf.WriteRune(':')
+	}
+
+	buf.WriteRune('"')
+	lastPos := -1
+	for k, terms := range q.termArrays {
+		position := q.positions[k]
+		if k > 0 {
+			buf.WriteRune(' ')
+			for j := 1; j < position-lastPos; j++ {
+				buf.WriteString("? ")
+			}
+		}
+		if len(terms) > 1 {
+			buf.WriteRune('(')
+			for j, term := range terms {
+				if j > 0 {
+					buf.WriteRune(' ')
+				}
+				buf.Write(term.Bytes)
+			}
+			buf.WriteRune(')')
+		} else {
+			buf.Write(terms[0].Bytes)
+		}
+		lastPos = position
+	}
+	buf.WriteRune('"')
+
+	if q.slop != 0 {
+		fmt.Fprintf(&buf, "~%v", q.slop)
+	}
+
+	if q.boost != 1 {
+		fmt.Fprintf(&buf, "^%v", q.boost)
+	}
+
+	return buf.String()
+}
+
+/* Takes the logical union of multiple DocsAndPositionsEnum iterators. */
+type UnionDocsAndPositionsEnum struct {
+	doc     int
+	freq    int
+	queue   *docsQueue
+	posList *intQueue
+	cost    int64
+}
+
+func newUnionDocsAndPositionsEnum(liveDocs util.Bits,
+	context *index.AtomicReaderContext, terms []*index.Term,
+	termContexts map[string]*index.TermContext,
+	termsEnum TermsEnum) (*UnionDocsAndPositionsEnum, error) {
+
+	var docsEnums []DocsAndPositionsEnum
+	for _, term := range terms {
+		termState := termContexts[termKey(term)].State(context.Ord)
+		if termState == nil { // term doesn't exist in reader
+			continue
+		}
+		if err := termsEnum.SeekExactFromLast(term.Bytes, termState); err != nil {
+			return nil, err
+		}
+		postings, err := termsEnum.DocsAndPositionsByFlags(liveDocs, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if postings == nil {
+			// term does exist, but has no positions
+			return nil, errors.New(fmt.Sprintf(
+				"field '%v' was indexed without position data; cannot run PhraseQuery (term=%v)",
+				term.Field, string(term.Bytes)))
+		}
+		docsEnums = append(docsEnums, postings)
+	}
+
+	queue, err := newDocsQueue(docsEnums)
+	if err != nil {
+		return nil, err
+	}
+	var cost int64
+	for _, postings := range docsEnums {
+		cost += postings.Cost()
+	}
+	return &UnionDocsAndPositionsEnum{
+		doc:     -1,
+		queue:   queue,
+		posList: new(intQueue),
+		cost:    cost,
+	}, nil
+}
+
+func (e *UnionDocsAndPositionsEnum) NextDoc() (int, error) {
+	if e.queue.Len() == 0 {
+		e.doc = NO_MORE_DOCS
+		return e.doc, nil
+	}
+
+	// TODO: move this init into positions(): if the search doesn't
+	// need the positions for this doc then don't waste CPU merging
+	// them:
+	e.posList.clear()
+	e.doc = e.queue.top().DocId()
+
+	// merge sort all positions together
+	for e.queue.Len() > 0 && e.queue.top().DocId() == e.doc {
+		postings := e.queue.top()
+		freq, err := postings.Freq()
+		if err != nil {
+			return 0, err
+		}
+		for i := 0; i < freq; i++ {
+			pos, err := postings.NextPosition()
+			if err != nil {
+				return 0, err
+			}
+			e.posList.add(pos)
+		}
+
+		doc, err := postings.NextDoc()
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			heap.Fix(e.queue, 0)
+		} else {
+			heap.Pop(e.queue)
+		}
+	}
+
+	e.posList.sort()
+	e.freq = e.posList.size()
+
+	return e.doc, nil
+}
+
+func (e *UnionDocsAndPositionsEnum) NextPosition() (int, error) {
+	return e.posList.next(), nil
+}
+
+func (e *UnionDocsAndPositionsEnum) StartOffset() (int, error) {
+	return -1, nil
+}
+
+func (e *UnionDocsAndPositionsEnum) EndOffset() (int, error) {
+	return -1, nil
+}
+
+func (e *UnionDocsAndPositionsEnum) Payload() ([]byte, error) {
+	return nil, nil
+}
+
+func (e *UnionDocsAndPositionsEnum) Advance(target int) (int, error) {
+	for e.queue.Len() > 0 && target > e.queue.top().DocId() {
+		postings := heap.Pop(e.queue).(DocsAndPositionsEnum)
+		doc, err := postings.Advance(target)
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			heap.Push(e.queue, postings)
+		}
+	}
+	return e.NextDoc()
+}
+
+func (e *UnionDocsAndPositionsEnum) Cost() int64 {
+	return e.cost
+}
+
+func (e *UnionDocsAndPositionsEnum) Freq() (int, error) {
+	return e.freq, nil
+}
+
+func (e *UnionDocsAndPositionsEnum) DocId() int {
+	return e.doc
+}
+
+/* A min heap of DocsAndPositionsEnum ordered by doc id, to be used with container/heap. */
+type docsQueue []DocsAndPositionsEnum
+
+func newDocsQueue(docsEnums []DocsAndPositionsEnum) (*docsQueue, error) {
+	ans := make(docsQueue, 0, len(docsEnums))
+	for _, postings := range docsEnums {
+		doc, err := postings.NextDoc()
+		if err != nil {
+			return nil, err
+		}
+		if doc != NO_MORE_DOCS {
+			ans = append(ans, postings)
+		}
+	}
+	heap.Init(&ans)
+	return &ans, nil
+}
+
+func (q docsQueue) Len() int                  { return len(q) }
+func (q docsQueue) Less(i, j int) bool        { return q[i].DocId() < q[j].DocId() }
+func (q docsQueue) Swap(i, j int)             { q[i], q[j] = q[j], q[i] }
+func (q docsQueue) top() DocsAndPositionsEnum { return q[0] }
+func (q *docsQueue) Push(x interface{})       { *q = append(*q, x.(DocsAndPositionsEnum)) }
+func (q *docsQueue) Pop() interface{} {
+	n := len(*q)
+	ans := (*q)[n-1]
+	*q = (*q)[:n-1]
+	return ans
+}
+
+/* A queue of positions, filled for one doc and then drained in order. */
+type intQueue struct {
+	array []int
+	index int
+}
+
+func (q *intQueue) add(i int) {
+	q.array = append(q.array, i)
+}
+
+func (q *intQueue) next() int {
+	ans := q.array[q.index]
+	q.index++
+	return ans
+}
+
+func (q *intQueue) sort() {
+	sort.Ints(q.array[q.index:])
+}
+
+func (q *intQueue) clear() {
+	q.array = q.array[:0]
+	q.index = 0
+}
+
+func (q *intQueue) size() int {
+	return len(q.array) - q.index
+}
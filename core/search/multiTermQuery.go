@@ -0,0 +1,206 @@
+package search
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/MultiTermQuery.java
+
+/*
+An abstract Query that matches documents containing a subset of terms
+provided by a FilteredTermsEnum enumeration.
+
+This query cannot be used directly; you must subclass it and define
+TermsEnum() to provide a FilteredTermsEnum that iterates through the
+terms to be matched.
+
+NOTE: if RewriteMethod is either CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE
+or SCORING_BOOLEAN_QUERY_REWRITE, you may encounter a
+ErrTooManyClauses error during searching, which happens when the
+number of terms to be searched exceeds maxClauseCount. Setting
+RewriteMethod to CONSTANT_SCORE_FILTER_REWRITE prevents this.
+
+The default rewrite method is CONSTANT_SCORE_FILTER_REWRITE: it
+doesn't spend CPU computing unhelpful scores. If you need scoring
+(like FuzzyQuery), use TopTermsScoringBooleanQueryRewrite which uses
+a priority queue to only collect competitive terms and not hit this
+limitation.
+*/
+type MultiTermQuery struct {
+	*AbstractQuery
+	spi           MultiTermQuerySPI
+	field         string
+	rewriteMethod RewriteMethod
+}
+
+type MultiTermQuerySPI interface {
+	QuerySPI
+	/*
+		Construct the enumeration to be used, expanding the pattern term.
+		This method should only be called if the field exists (ie,
+		implementations can assume the field does exist). This method
+		should not return nil (should instead return EMPTY_TERMS_ENUM) if
+		no terms match.
+	*/
+	TermsEnum(terms Terms) (TermsEnum, error)
+}
+
+/*
+Constructs a query matching terms that cannot be represented with a
+single Term.
+*/
+func NewMultiTermQuery(self MultiTermQuerySPI, field string) *MultiTermQuery {
+	assert2(field != "", "field must not be empty")
+	return &MultiTermQuery{
+		AbstractQuery: NewAbstractQuery(self),
+		spi:           self,
+		field:         field,
+		rewriteMethod: CONSTANT_SCORE_FILTER_REWRITE,
+	}
+}
+
+/*
+Returns the embedded MultiTermQuery, so that the wrapping queries
+can be recognized without knowing their concrete types.
+*/
+func (q *MultiTermQuery) multiTermQuery() *MultiTermQuery {
+	return q
+}
+
+/* Returns the field name for this query */
+func (q *MultiTermQuery) Field() string {
+	return q.field
+}
+
+/*
+To rewrite to a simpler form, instead return a simpler enum from
+TermsEnum(). For example, to rewrite to a single term, return a
+SingleTermsEnum.
+*/
+func (q *MultiTermQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	return q.rewriteMethod.Rewrite(reader, q)
+}
+
+/* Returns the rewrite method used to build the final query. */
+func (q *MultiTermQuery) RewriteMethod() RewriteMethod {
+	return q.rewriteMethod
+}
+
+/*
+Sets the rewrite method to be used when executing the query. You can
+use one of the four core methods, or implement your own subclass of
+RewriteMethod.
+*/
+func (q *MultiTermQuery) SetRewriteMethod(method RewriteMethod) {
+	q.rewriteMethod = method
+}
+
+/* Abstract class that defines how the query is rewritten. */
+type RewriteMethod interface {
+	Rewrite(reader index.IndexReader, query *MultiTermQuery) (Query, error)
+}
+
+/*
+A rewrite method that first creates a private Filter, by visiting
+each term in sequence and marking all docs for that term. Matching
+documents are assigned a constant score equal to the query's boost.
+
+This method is faster than the BooleanQuery rewrite methods when the
+number of matched terms or matched documents is non-trivial. Also, it
+will never hit an errant ErrTooManyClauses error.
+*/
+var CONSTANT_SCORE_FILTER_REWRITE = RewriteMethod(constantScoreFilterRewrite(0))
+
+type constantScoreFilterRewrite int
+
+func (r constantScoreFilterRewrite) Rewrite(reader index.IndexReader,
+	query *MultiTermQuery) (Query, error) {
+
+	result := NewConstantScoreQuery(newMultiTermQueryWrapperFilter(query))
+	result.SetBoost(query.Boost())
+	return result, nil
+}
+
+func (r constantScoreFilterRewrite) String() string {
+	return "CONSTANT_SCORE_FILTER_REWRITE"
+}
+
+// search/MultiTermQueryWrapperFilter.java
+
+/*
+A wrapper for MultiTermQuery, that exposes its functionality as a
+Filter.
+
+MultiTermQueryWrapperFilter is not designed to be used by itself.
+Normally you subclass it to provide a Filter counterpart for a
+MultiTermQuery subclass.
+
+This class also provides the functionality behind
+CONSTANT_SCORE_FILTER_REWRITE; this is why it is not abstract.
+*/
+type MultiTermQueryWrapperFilter struct {
+	query *MultiTermQuery
+}
+
+/* Wrap a MultiTermQuery as a Filter. */
+func newMultiTermQueryWrapperFilter(query *MultiTermQuery) *MultiTermQueryWrapperFilter {
+	return &MultiTermQueryWrapperFilter{query}
+}
+
+/* Returns the field name for this query */
+func (f *MultiTermQueryWrapperFilter) Field() string {
+	return f.query.field
+}
+
+/*
+Returns a DocIdSet with documents that should be permitted in search
+results.
+*/
+func (f *MultiTermQueryWrapperFilter) DocIdSet(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (DocIdSet, error) {
+
+	reader := context.Reader().(index.AtomicReader)
+	terms := reader.Terms(f.query.field)
+	if terms == nil {
+		// field does not exist
+		return nil, nil
+	}
+
+	termsEnum, err := f.query.spi.TermsEnum(terms)
+	if err != nil {
+		return nil, err
+	}
+	assert(termsEnum != nil)
+	term, err := termsEnum.Next()
+	if err != nil || term == nil {
+		return nil, err
+	}
+	// fill into a FixedBitSet
+	bitSet := util.NewFixedBitSetOf(reader.MaxDoc())
+	var docsEnum DocsEnum
+	for term != nil {
+		if docsEnum, err = termsEnum.DocsByFlags(acceptDocs, docsEnum, DOCS_ENUM_FLAG_NONE); err != nil {
+			return nil, err
+		}
+		docId, err := docsEnum.NextDoc()
+		for ; err == nil && docId != NO_MORE_DOCS; docId, err = docsEnum.NextDoc() {
+			bitSet.Set(docId)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if term, err = termsEnum.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return bitSet, nil
+}
+
+func (f *MultiTermQueryWrapperFilter) String() string {
+	// query.ToString should be ok for the filter, too, if the query
+	// boost is 1.0
+	return f.query.spi.ToString("")
+}
@@ -0,0 +1,214 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+// search/similarities/Normalization.java
+
+/*
+This type acts as the base for the implementations of the term
+frequency normalization methods in the DFR framework.
+*/
+type Normalization interface {
+	// Returns the normalized term frequency.
+	tfn(stats *BasicStats, tf, length float32) float32
+	// Returns an explanation for the normalized term frequency.
+	explain(stats *BasicStats, tf, length float32) Explanation
+	String() string
+}
+
+/*
+The default explanation of a normalization, which uses the field
+length of the document and the average field length to compute the
+normalized term frequency.
+*/
+func explainNormalization(n Normalization, stats *BasicStats, tf, length float32) Explanation {
+	result := NewExplanation(n.tfn(stats, tf, length), fmt.Sprintf("%v, computed from: ", simpleName(n)))
+	result.AddDetail(NewExplanation(tf, "tf"))
+	result.AddDetail(NewExplanation(stats.avgFieldLength, "avgFieldLength"))
+	result.AddDetail(NewExplanation(length, "len"))
+	return result
+}
+
+/* Implementation used when there is no normalization. */
+type NoNormalization struct{}
+
+func NewNoNormalization() *NoNormalization {
+	return &NoNormalization{}
+}
+
+func (n *NoNormalization) tfn(stats *BasicStats, tf, length float32) float32 {
+	return tf
+}
+
+func (n *NoNormalization) explain(stats *BasicStats, tf, length float32) Explanation {
+	return NewExplanation(1, "no normalization")
+}
+
+func (n *NoNormalization) String() string {
+	return ""
+}
+
+// search/similarities/NormalizationH1.java
+
+/*
+Normalization model that assumes a uniform distribution of the term
+frequency.
+
+While this model is parameterless in the original article, information-
+based models (see IBSimilarity) introduced a multiplying factor. The
+default value for the c parameter is 1.
+*/
+type NormalizationH1 struct {
+	c float32
+}
+
+/* Calls NewNormalizationH1WithC(1). */
+func NewNormalizationH1() *NormalizationH1 {
+	return NewNormalizationH1WithC(1)
+}
+
+/*
+Creates NormalizationH1 with the supplied parameter c, the
+hyper-parameter that controls the term frequency normalization with
+respect to the document length.
+*/
+func NewNormalizationH1WithC(c float32) *NormalizationH1 {
+	return &NormalizationH1{c}
+}
+
+func (n *NormalizationH1) tfn(stats *BasicStats, tf, length float32) float32 {
+	return tf * n.c * (stats.avgFieldLength / length)
+}
+
+func (n *NormalizationH1) explain(stats *BasicStats, tf, length float32) Explanation {
+	return explainNormalization(n, stats, tf, length)
+}
+
+func (n *NormalizationH1) String() string {
+	return "1"
+}
+
+/* Returns the c parameter. */
+func (n *NormalizationH1) C() float32 {
+	return n.c
+}
+
+// search/similarities/NormalizationH2.java
+
+/*
+Normalization model in which the term frequency is inversely related
+to the length.
+
+While this model is parameterless in the original article, the
+thesis introduces the parameterized variant. The default value for
+the c parameter is 1.
+*/
+type NormalizationH2 struct {
+	c float32
+}
+
+/* Calls NewNormalizationH2WithC(1). */
+func NewNormalizationH2() *NormalizationH2 {
+	return NewNormalizationH2WithC(1)
+}
+
+/*
+Creates NormalizationH2 with the supplied parameter c, the
+hyper-parameter that controls the term frequency normalization with
+respect to the document length.
+*/
+func NewNormalizationH2WithC(c float32) *NormalizationH2 {
+	return &NormalizationH2{c}
+}
+
+func (n *NormalizationH2) tfn(stats *BasicStats, tf, length float32) float32 {
+	return float32(float64(tf) * log2(1+float64(n.c*stats.avgFieldLength/length)))
+}
+
+func (n *NormalizationH2) explain(stats *BasicStats, tf, length float32) Explanation {
+	return explainNormalization(n, stats, tf, length)
+}
+
+func (n *NormalizationH2) String() string {
+	return "2"
+}
+
+/* Returns the c parameter. */
+func (n *NormalizationH2) C() float32 {
+	return n.c
+}
+
+// search/similarities/NormalizationH3.java
+
+/* Dirichlet Priors normalization. */
+type NormalizationH3 struct {
+	mu float32
+}
+
+/* Calls NewNormalizationH3WithMu(800). */
+func NewNormalizationH3() *NormalizationH3 {
+	return NewNormalizationH3WithMu(800)
+}
+
+/* Creates NormalizationH3 with the supplied parameter μ, the smoothing parameter. */
+func NewNormalizationH3WithMu(mu float32) *NormalizationH3 {
+	return &NormalizationH3{mu}
+}
+
+func (n *NormalizationH3) tfn(stats *BasicStats, tf, length float32) float32 {
+	return (tf + n.mu*((float32(stats.totalTermFreq)+1)/(float32(stats.numberOfFieldTokens)+1))) /
+		(length + n.mu) * n.mu
+}
+
+func (n *NormalizationH3) explain(stats *BasicStats, tf, length float32) Explanation {
+	return explainNormalization(n, stats, tf, length)
+}
+
+func (n *NormalizationH3) String() string {
+	return fmt.Sprintf("3(%v)", n.mu)
+}
+
+/* Returns the parameter μ. */
+func (n *NormalizationH3) Mu() float32 {
+	return n.mu
+}
+
+// search/similarities/NormalizationZ.java
+
+/* Pareto-Zipf Normalization. */
+type NormalizationZ struct {
+	z float32
+}
+
+/* Calls NewNormalizationZWithZ(0.3). */
+func NewNormalizationZ() *NormalizationZ {
+	return NewNormalizationZWithZ(0.30)
+}
+
+/*
+Creates NormalizationZ with the supplied parameter z, which
+represents A/(A+1) where A measures the specificity of the language.
+*/
+func NewNormalizationZWithZ(z float32) *NormalizationZ {
+	return &NormalizationZ{z}
+}
+
+func (n *NormalizationZ) tfn(stats *BasicStats, tf, length float32) float32 {
+	return float32(float64(tf) * math.Pow(float64(stats.avgFieldLength/length), float64(n.z)))
+}
+
+func (n *NormalizationZ) explain(stats *BasicStats, tf, length float32) Explanation {
+	return explainNormalization(n, stats, tf, length)
+}
+
+func (n *NormalizationZ) String() string {
+	return fmt.Sprintf("Z(%v)", n.z)
+}
+
+/* Returns the parameter z. */
+func (n *NormalizationZ) Z() float32 {
+	return n.z
+}
@@ -0,0 +1,680 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+)
+
+// search/NumericRangeQuery.java
+
+/*
+A Query that matches numeric values within a specified range. To use
+this, you must first index the numeric values using IntField,
+FloatField, LongField or DoubleField (expert: NumericTokenStream). If
+your terms are instead textual, you should use TermRangeQuery.
+NumericRangeFilter is the filter equivalent of this query.
+
+You create a new NumericRangeQuery with the static factory methods,
+eg:
+
+	min, max := float32(0.03), float32(0.10)
+	q := NewFloatRangeQuery("weight", &min, &max, true, true)
+
+matches all documents whose float valued "weight" field ranges from
+0.03 to 0.10, inclusive. A nil bound leaves that end of the range
+open.
+
+The performance of NumericRangeQuery is much better than the
+corresponding TermRangeQuery because the number of terms that must be
+searched is usually far fewer, thanks to trie indexing, described
+below.
+
+You can optionally specify a precisionStep when creating this query.
+This is necessary if you've changed this configuration from its
+default value when indexing. The same precisionStep must be used at
+indexing and querying.
+
+A nil min or max bound means the range is open ended on that side.
+The bounds must have the Go type of the data type of the query, i.e.
+int32 for NewIntRangeQuery(), int64 for NewLongRangeQuery(), float32
+for NewFloatRangeQuery() and float64 for NewDoubleRangeQuery().
+
+# How it works
+
+See the publication about panFMP, where this algorithm was described
+(referred to as TrieRangeQuery): Schindler, U, Diepenbroek, M, 2008.
+Generic XML-based Framework for Metadata Portals. Computers &
+Geosciences 34 (12), 1947-1955.
+
+A quote from this paper: Because Apache Lucene is a full-text search
+engine and not a conventional database, it cannot handle numerical
+ranges (e.g., field value is inside user defined bounds, even dates
+are numerical values). We have developed an extension to Apache
+Lucene that stores the numerical values in a special string-encoded
+format with variable precision (all numerical values like float64,
+int64, float32, and int32 are converted to lexicographic sortable
+string representations and stored with different precisions (for a
+more detailed description of how the values are stored, see
+util.NumericUtils). A range is then divided recursively into multiple
+intervals for searching: The center of the range is searched only
+with the lowest possible precision in the trie, while the boundaries
+are matched more exactly. This reduces the number of terms
+dramatically.
+
+For the variant that stores int64 values in 8 different precisions
+(each reduced by 8 bits) that uses a lowest precision of 1 byte, the
+index contains only a maximum of 256 distinct values in the lowest
+precision. Overall, a range could consist of a theoretical maximum of
+7*255*2 + 255 = 3825 distinct terms (when there is a term for every
+distinct value of an 8-byte-number in the index and the range covers
+almost all of them; a maximum of 255 distinct values is used because
+it would always be possible to reduce the full 256 values to one term
+with degraded precision). In practice, we have seen up to 300 terms
+in most cases (index with 500,000 metadata records and a uniform
+value distribution).
+
+# Precision step
+
+You can choose any precisionStep when encoding values. Lower step
+values mean more precisions and so more terms in index (and index
+gets larger). The number of indexed terms per value is (those are
+generated by NumericTokenStream):
+
+	indexedTermsPerValue = ceil(bitsPerValue / precisionStep)
+
+As the lower precision terms are shared by many values, the
+additional terms only slightly grow the term dictionary (approx. 7%
+for precisionStep=4), but have a larger impact on the postings (the
+postings file will have more entries, as every document is linked to
+indexedTermsPerValue terms instead of one). The formula to estimate
+the growth of the term dictionary in comparison to one term per value:
+
+	growth = 1 / (1 - 1/(2^precisionStep)) - 1
+
+On the other hand, if the precisionStep is smaller, the maximum
+number of terms to match reduces, which optimizes query speed. The
+formula to calculate the maximum number of terms that will be visited
+while executing the query is:
+
+	maxQueryTerms = (indexedTermsPerValue-1) * (2^precisionStep - 1) * 2 + (2^precisionStep - 1)
+
+For longs stored using a precision step of 4, maxQueryTerms = 15*15*2
++ 15 = 465, and for a precision step of 2, maxQueryTerms = 31*3*2 + 3
+= 189. But the faster search speed is reduced by more seeking in the
+term enum of the index. Because of this, the ideal precisionStep
+value can only be found out by testing. Important: You can compare
+query speed for different precision steps, but the index must have
+been built with the same precisionStep.
+
+Good values for precisionStep are depending on usage and data type:
+
+  - The default is NUMERIC_PRECISION_STEP_DEFAULT (16) for 64 bit data
+    types and NUMERIC_PRECISION_STEP_DEFAULT_32 (8) for 32 bit data
+    types, which is used, when no precisionStep is given.
+  - Ideal value in most cases for 64 bit data types (int64, float64)
+    is 6 or 8.
+  - Ideal value in most cases for 32 bit data types (int32, float32)
+    is 4.
+  - For low cardinality fields larger precision steps are good. If
+    the cardinality is < 100, it is fair to use math.MaxInt32 (see
+    below).
+  - Steps >=64 for int64/float64 and >=32 for int32/float32 produces
+    one token per value in the index and querying is as slow as a
+    conventional TermRangeQuery. But it can be used to produce fields,
+    that are solely used for sorting (in this case simply use
+    math.MaxInt32 as precisionStep). Using IntField, LongField,
+    FloatField or DoubleField for sorting is ideal, because building
+    the field cache is much faster than with text-only numbers. These
+    fields have one term per value and therefore also work with term
+    enumeration for building distinct lists (e.g. facets / preselected
+    values to search for). Sorting is also possible with range query
+    optimized fields using one of the above precisionSteps.
+
+Comparisons of the different types of RangeQueries on an index with
+about 500,000 docs showed that TermRangeQuery in boolean rewrite mode
+(with raised BooleanQuery clause count) took about 30-40 secs to
+complete, TermRangeQuery in constant score filter rewrite mode took 5
+secs and executing this class took <100ms to complete (on an Opteron64
+machine, Java 1.5, 8 bit precision step). This query type was
+developed for a geographic portal, where the performance for e.g.
+bounding boxes or exact date/time stamps is important.
+*/
+type NumericRangeQuery struct {
+	*MultiTermQuery
+	precisionStep              int
+	dataType                   docu.NumericType
+	min, max                   interface{}
+	minInclusive, maxInclusive bool
+}
+
+func newNumericRangeQuery(field string, precisionStep int, dataType docu.NumericType,
+	min, max interface{}, minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	assert2(precisionStep >= 1, "precisionStep must be >=1")
+	ans := &NumericRangeQuery{
+		precisionStep: precisionStep,
+		dataType:      dataType,
+		min:           min,
+		max:           max,
+		minInclusive:  minInclusive,
+		maxInclusive:  maxInclusive,
+	}
+	ans.MultiTermQuery = NewMultiTermQuery(ans, field)
+	return ans
+}
+
+/* Returns the bound, or nil if the range is open ended there. */
+func int64Bound(v *int64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func int32Bound(v *int32) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func float64Bound(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func float32Bound(v *float32) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries an int64 range
+using the given precisionStep. You can have half-open ranges (which
+are in fact </<= or >/>= queries) by setting the min or max value to
+nil. By setting inclusive to false, it will match all documents
+excluding the bounds, with inclusive on, the boundaries are hits,
+too.
+*/
+func NewLongRangeQueryWithStep(field string, precisionStep int,
+	min, max *int64, minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return newNumericRangeQuery(field, precisionStep, docu.FIELD_TYPE_NUMERIC_LONG,
+		int64Bound(min), int64Bound(max), minInclusive, maxInclusive)
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries an int64 range
+using the default precisionStep NUMERIC_PRECISION_STEP_DEFAULT (16).
+*/
+func NewLongRangeQuery(field string, min, max *int64,
+	minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return NewLongRangeQueryWithStep(field, util.NUMERIC_PRECISION_STEP_DEFAULT,
+		min, max, minInclusive, maxInclusive)
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries an int32 range
+using the given precisionStep. You can have half-open ranges (which
+are in fact </<= or >/>= queries) by setting the min or max value to
+nil. By setting inclusive to false, it will match all documents
+excluding the bounds, with inclusive on, the boundaries are hits,
+too.
+*/
+func NewIntRangeQueryWithStep(field string, precisionStep int,
+	min, max *int32, minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return newNumericRangeQuery(field, precisionStep, docu.FIELD_TYPE_NUMERIC_INT,
+		int32Bound(min), int32Bound(max), minInclusive, maxInclusive)
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries an int32 range
+using the default precisionStep NUMERIC_PRECISION_STEP_DEFAULT_32 (8).
+*/
+func NewIntRangeQuery(field string, min, max *int32,
+	minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return NewIntRangeQueryWithStep(field, util.NUMERIC_PRECISION_STEP_DEFAULT_32,
+		min, max, minInclusive, maxInclusive)
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries a float64
+range using the given precisionStep. You can have half-open ranges
+(which are in fact </<= or >/>= queries) by setting the min or max
+value to nil. math.NaN() will never match a half-open range, to hit
+NaN use a query with min == max == math.NaN(). By setting inclusive
+to false, it will match all documents excluding the bounds, with
+inclusive on, the boundaries are hits, too.
+*/
+func NewDoubleRangeQueryWithStep(field string, precisionStep int,
+	min, max *float64, minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return newNumericRangeQuery(field, precisionStep, docu.FIELD_TYPE_NUMERIC_DOUBLE,
+		float64Bound(min), float64Bound(max), minInclusive, maxInclusive)
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries a float64
+range using the default precisionStep NUMERIC_PRECISION_STEP_DEFAULT
+(16).
+*/
+func NewDoubleRangeQuery(field string, min, max *float64,
+	minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return NewDoubleRangeQueryWithStep(field, util.NUMERIC_PRECISION_STEP_DEFAULT,
+		min, max, minInclusive, maxInclusive)
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries a float32
+range using the given precisionStep. You can have half-open ranges
+(which are in fact </<= or >/>= queries) by setting the min or max
+value to nil. NaN will never match a half-open range, to hit NaN use
+a query with min == max == NaN. By setting inclusive to false, it
+will match all documents excluding the bounds, with inclusive on, the
+boundaries are hits, too.
+*/
+func NewFloatRangeQueryWithStep(field string, precisionStep int,
+	min, max *float32, minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return newNumericRangeQuery(field, precisionStep, docu.FIELD_TYPE_NUMERIC_FLOAT,
+		float32Bound(min), float32Bound(max), minInclusive, maxInclusive)
+}
+
+/*
+Factory that creates a NumericRangeQuery, that queries a float32
+range using the default precisionStep
+NUMERIC_PRECISION_STEP_DEFAULT_32 (8).
+*/
+func NewFloatRangeQuery(field string, min, max *float32,
+	minInclusive, maxInclusive bool) *NumericRangeQuery {
+
+	return NewFloatRangeQueryWithStep(field, util.NUMERIC_PRECISION_STEP_DEFAULT_32,
+		min, max, minInclusive, maxInclusive)
+}
+
+func (q *NumericRangeQuery) TermsEnum(terms Terms) (TermsEnum, error) {
+	// compare the bounds by their sortable representation, which
+	// orders NaN above positive infinity
+	if q.min != nil && q.max != nil && q.sortableBound(q.min) > q.sortableBound(q.max) {
+		return EMPTY_TERMS_ENUM, nil
+	}
+	return newNumericRangeTermsEnum(q, terms.Iterator(nil)), nil
+}
+
+/*
+Returns the bound as int64 in the sortable representation the values
+are indexed with.
+*/
+func (q *NumericRangeQuery) sortableBound(bound interface{}) int64 {
+	switch v := bound.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float32:
+		return int64(util.FloatToSortableInt(v))
+	case float64:
+		return util.DoubleToSortableLong(v)
+	}
+	panic(fmt.Sprintf("invalid numeric bound: %v", bound))
+}
+
+/* Returns true if the lower endpoint is inclusive */
+func (q *NumericRangeQuery) IncludesMin() bool { return q.minInclusive }
+
+/* Returns true if the upper endpoint is inclusive */
+func (q *NumericRangeQuery) IncludesMax() bool { return q.maxInclusive }
+
+/* Returns the lower value of this range query, or nil if open ended */
+func (q *NumericRangeQuery) Min() interface{} { return q.min }
+
+/* Returns the upper value of this range query, or nil if open ended */
+func (q *NumericRangeQuery) Max() interface{} { return q.max }
+
+/* Returns the precision step. */
+func (q *NumericRangeQuery) PrecisionStep() int { return q.precisionStep }
+
+func (q *NumericRangeQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.Field() != field {
+		buf.WriteString(q.Field())
+		buf.WriteRune(':')
+	}
+	if q.minInclusive {
+		buf.WriteRune('[')
+	} else {
+		buf.WriteRune('{')
+	}
+	if q.min == nil {
+		buf.WriteRune('*')
+	} else {
+		fmt.Fprintf(&buf, "%v", q.min)
+	}
+	buf.WriteString(" TO ")
+	if q.max == nil {
+		buf.WriteRune('*')
+	} else {
+		fmt.Fprintf(&buf, "%v", q.max)
+	}
+	if q.maxInclusive {
+		buf.WriteRune(']')
+	} else {
+		buf.WriteRune('}')
+	}
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+var (
+	numericLongNegativeInfinity = util.DoubleToSortableLong(math.Inf(-1))
+	numericLongPositiveInfinity = util.DoubleToSortableLong(math.Inf(1))
+	numericIntNegativeInfinity  = util.FloatToSortableInt(float32(math.Inf(-1)))
+	numericIntPositiveInfinity  = util.FloatToSortableInt(float32(math.Inf(1)))
+)
+
+/*
+Subclass of FilteredTermsEnum for enumerating all terms that match
+the sub-ranges for trie range queries, using flex API.
+
+WARNING: This term enumeration is not guaranteed to be always ordered
+by Comparator. The ordering depends on how SplitLongRange() and
+SplitIntRange() generates the sub-ranges. For MultiTermQuery ordering
+is not relevant.
+*/
+type numericRangeTermsEnum struct {
+	*index.FilteredTermsEnum
+
+	currentLowerBound, currentUpperBound []byte
+	// pairs of lower and upper bounds of the sub-ranges
+	rangeBounds [][]byte
+}
+
+func newNumericRangeTermsEnum(q *NumericRangeQuery, tenum TermsEnum) *numericRangeTermsEnum {
+	ans := new(numericRangeTermsEnum)
+	ans.FilteredTermsEnum = index.NewFilteredTermsEnum(ans, tenum, true)
+	addRange := func(minPrefixCoded, maxPrefixCoded []byte) {
+		ans.rangeBounds = append(ans.rangeBounds, minPrefixCoded, maxPrefixCoded)
+	}
+
+	switch q.dataType {
+	case docu.FIELD_TYPE_NUMERIC_LONG, docu.FIELD_TYPE_NUMERIC_DOUBLE:
+		// lower
+		var minBound int64 = math.MinInt64
+		if q.dataType == docu.FIELD_TYPE_NUMERIC_DOUBLE {
+			minBound = numericLongNegativeInfinity
+		}
+		if q.min != nil {
+			minBound = q.sortableBound(q.min)
+			if !q.minInclusive {
+				if minBound == math.MaxInt64 {
+					break
+				}
+				minBound++
+			}
+		}
+
+		// upper
+		var maxBound int64 = math.MaxInt64
+		if q.dataType == docu.FIELD_TYPE_NUMERIC_DOUBLE {
+			maxBound = numericLongPositiveInfinity
+		}
+		if q.max != nil {
+			maxBound = q.sortableBound(q.max)
+			if !q.maxInclusive {
+				if maxBound == math.MinInt64 {
+					break
+				}
+				maxBound--
+			}
+		}
+
+		util.SplitLongRange(addRange, q.precisionStep, minBound, maxBound)
+
+	case docu.FIELD_TYPE_NUMERIC_INT, docu.FIELD_TYPE_NUMERIC_FLOAT:
+		// lower
+		var minBound int32 = math.MinInt32
+		if q.dataType == docu.FIELD_TYPE_NUMERIC_FLOAT {
+			minBound = numericIntNegativeInfinity
+		}
+		if q.min != nil {
+			minBound = int32(q.sortableBound(q.min))
+			if !q.minInclusive {
+				if minBound == math.MaxInt32 {
+					break
+				}
+				minBound++
+			}
+		}
+
+		// upper
+		var maxBound int32 = math.MaxInt32
+		if q.dataType == docu.FIELD_TYPE_NUMERIC_FLOAT {
+			maxBound = numericIntPositiveInfinity
+		}
+		if q.max != nil {
+			maxBound = int32(q.sortableBound(q.max))
+			if !q.maxInclusive {
+				if maxBound == math.MinInt32 {
+					break
+				}
+				maxBound--
+			}
+		}
+
+		util.SplitIntRange(addRange, q.precisionStep, minBound, maxBound)
+
+	default:
+		// should never happen
+		panic("Invalid NumericType")
+	}
+	return ans
+}
+
+func (e *numericRangeTermsEnum) nextRange() {
+	assert(len(e.rangeBounds)%2 == 0)
+	e.currentLowerBound = e.rangeBounds[0]
+	assert2(e.currentUpperBound == nil || !util.UTF8SortedAsUnicodeLess(e.currentLowerBound, e.currentUpperBound),
+		"The current upper bound must be <= the new lower bound")
+	e.currentUpperBound = e.rangeBounds[1]
+	e.rangeBounds = e.rangeBounds[2:]
+}
+
+func (e *numericRangeTermsEnum) NextSeekTerm(term []byte) ([]byte, error) {
+	for len(e.rangeBounds) >= 2 {
+		e.nextRange()
+
+		// if the new upper bound is before the term parameter, the
+		// sub-range is never a hit
+		if term != nil && util.UTF8SortedAsUnicodeLess(e.currentUpperBound, term) {
+			continue
+		}
+		// never seek backwards, so use current term if lower bound is
+		// smaller
+		if term != nil && util.UTF8SortedAsUnicodeLess(e.currentLowerBound, term) {
+			return term, nil
+		}
+		return e.currentLowerBound, nil
+	}
+
+	// no more sub-range enums available
+	assert(len(e.rangeBounds) == 0)
+	e.currentLowerBound, e.currentUpperBound = nil, nil
+	return nil, nil
+}
+
+func (e *numericRangeTermsEnum) Accept(term []byte) (index.AcceptStatus, error) {
+	for e.currentUpperBound == nil || util.UTF8SortedAsUnicodeLess(e.currentUpperBound, term) {
+		if len(e.rangeBounds) == 0 {
+			return index.ACCEPT_STATUS_END, nil
+		}
+		// peek next sub-range, only seek if the current term is smaller
+		// than next lower bound
+		if util.UTF8SortedAsUnicodeLess(term, e.rangeBounds[0]) {
+			return index.ACCEPT_STATUS_NO_AND_SEEK, nil
+		}
+		// step forward to next range without seeking, as next lower
+		// range bound is less or equal current term
+		e.nextRange()
+	}
+	return index.ACCEPT_STATUS_YES, nil
+}
+
+// search/NumericRangeFilter.java
+
+/*
+A Filter that only accepts numeric values within a specified range.
+To use this, you must first index the numeric values using IntField,
+FloatField, LongField or DoubleField (expert: NumericTokenStream).
+
+You create a new NumericRangeFilter with the static factory methods,
+eg:
+
+	min, max := float32(0.03), float32(0.10)
+	f := NewFloatRangeFilter("weight", &min, &max, true, true)
+
+accepts all documents whose float valued "weight" field ranges from
+0.03 to 0.10, inclusive. See NumericRangeQuery for details on how
+Lucene indexes and searches numeric valued fields.
+*/
+type NumericRangeFilter struct {
+	*MultiTermQueryWrapperFilter
+	query *NumericRangeQuery
+}
+
+func newNumericRangeFilter(query *NumericRangeQuery) *NumericRangeFilter {
+	return &NumericRangeFilter{newMultiTermQueryWrapperFilter(query.MultiTermQuery), query}
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters an int64
+range using the given precisionStep. You can have half-open ranges
+(which are in fact </<= or >/>= queries) by setting the min or max
+value to nil. By setting inclusive to false, it will match all
+documents excluding the bounds, with inclusive on, the boundaries
+are hits, too.
+*/
+func NewLongRangeFilterWithStep(field string, precisionStep int,
+	min, max *int64, minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewLongRangeQueryWithStep(field, precisionStep,
+		min, max, minInclusive, maxInclusive))
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters an int64
+range using the default precisionStep NUMERIC_PRECISION_STEP_DEFAULT
+(16).
+*/
+func NewLongRangeFilter(field string, min, max *int64,
+	minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewLongRangeQuery(field, min, max, minInclusive, maxInclusive))
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters an int32
+range using the given precisionStep. You can have half-open ranges
+(which are in fact </<= or >/>= queries) by setting the min or max
+value to nil. By setting inclusive to false, it will match all
+documents excluding the bounds, with inclusive on, the boundaries
+are hits, too.
+*/
+func NewIntRangeFilterWithStep(field string, precisionStep int,
+	min, max *int32, minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewIntRangeQueryWithStep(field, precisionStep,
+		min, max, minInclusive, maxInclusive))
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters an int32
+range using the default precisionStep
+NUMERIC_PRECISION_STEP_DEFAULT_32 (8).
+*/
+func NewIntRangeFilter(field string, min, max *int32,
+	minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewIntRangeQuery(field, min, max, minInclusive, maxInclusive))
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters a float64
+range using the given precisionStep. You can have half-open ranges
+(which are in fact </<= or >/>= queries) by setting the min or max
+value to nil. math.NaN() will never match a half-open range, to hit
+NaN use a query with min == max == math.NaN(). By setting inclusive
+to false, it will match all documents excluding the bounds, with
+inclusive on, the boundaries are hits, too.
+*/
+func NewDoubleRangeFilterWithStep(field string, precisionStep int,
+	min, max *float64, minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewDoubleRangeQueryWithStep(field, precisionStep,
+		min, max, minInclusive, maxInclusive))
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters a float64
+range using the default precisionStep NUMERIC_PRECISION_STEP_DEFAULT
+(16).
+*/
+func NewDoubleRangeFilter(field string, min, max *float64,
+	minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewDoubleRangeQuery(field, min, max, minInclusive, maxInclusive))
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters a float32
+range using the given precisionStep. You can have half-open ranges
+(which are in fact </<= or >/>= queries) by setting the min or max
+value to nil. NaN will never match a half-open range, to hit NaN use
+a query with min == max == NaN. By setting inclusive to false, it
+will match all documents excluding the bounds, with inclusive on, the
+boundaries are hits, too.
+*/
+func NewFloatRangeFilterWithStep(field string, precisionStep int,
+	min, max *float32, minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewFloatRangeQueryWithStep(field, precisionStep,
+		min, max, minInclusive, maxInclusive))
+}
+
+/*
+Factory that creates a NumericRangeFilter, that filters a float32
+range using the default precisionStep
+NUMERIC_PRECISION_STEP_DEFAULT_32 (8).
+*/
+func NewFloatRangeFilter(field string, min, max *float32,
+	minInclusive, maxInclusive bool) *NumericRangeFilter {
+
+	return newNumericRangeFilter(NewFloatRangeQuery(field, min, max, minInclusive, maxInclusive))
+}
+
+/* Returns true if the lower endpoint is inclusive */
+func (f *NumericRangeFilter) IncludesMin() bool { return f.query.IncludesMin() }
+
+/* Returns true if the upper endpoint is inclusive */
+func (f *NumericRangeFilter) IncludesMax() bool { return f.query.IncludesMax() }
+
+/* Returns the lower value of this range filter, or nil if open ended */
+func (f *NumericRangeFilter) Min() interface{} { return f.query.Min() }
+
+/* Returns the upper value of this range filter, or nil if open ended */
+func (f *NumericRangeFilter) Max() interface{} { return f.query.Max() }
+
+/* Returns the precision step. */
+func (f *NumericRangeFilter) PrecisionStep() int { return f.query.PrecisionStep() }
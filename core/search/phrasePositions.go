@@ -0,0 +1,116 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// search/PhrasePositions.java
+
+/* Position of a term in a document that takes into account the term offset within the phrase. */
+type PhrasePositions struct {
+	doc      int                  // current doc
+	position int                  // position in doc
+	count    int                  // remaining pos in this doc
+	offset   int                  // position in phrase
+	ord      int                  // unique across all PhrasePositions instances
+	postings DocsAndPositionsEnum // stream of docs & positions
+	next     *PhrasePositions     // used to make lists
+	rptGroup int                  // >=0 indicates that this is a repeating PP
+	rptInd   int                  // index in the rptGroup
+	terms    []*index.Term        // for repetitions initialization
+}
+
+func newPhrasePositions(postings DocsAndPositionsEnum, o, ord int,
+	terms []*index.Term) *PhrasePositions {
+
+	return &PhrasePositions{
+		postings: postings,
+		offset:   o,
+		ord:      ord,
+		terms:    terms,
+		rptGroup: -1,
+	}
+}
+
+/* Increments to next doc */
+func (pp *PhrasePositions) nextDoc() (ok bool, err error) {
+	if pp.doc, err = pp.postings.NextDoc(); err != nil {
+		return false, err
+	}
+	return pp.doc != NO_MORE_DOCS, nil
+}
+
+func (pp *PhrasePositions) skipTo(target int) (ok bool, err error) {
+	if pp.doc, err = pp.postings.Advance(target); err != nil {
+		return false, err
+	}
+	return pp.doc != NO_MORE_DOCS, nil
+}
+
+func (pp *PhrasePositions) firstPosition() (err error) {
+	if pp.count, err = pp.postings.Freq(); err != nil { // read first pos
+		return err
+	}
+	_, err = pp.nextPosition()
+	return err
+}
+
+/*
+Go to next location of this term current document, and set position
+as location - offset, so that a matching exact phrase is easily
+identified when all PhrasePositions have exactly the same position.
+*/
+func (pp *PhrasePositions) nextPosition() (bool, error) {
+	if pp.count <= 0 {
+		return false, nil
+	}
+	pp.count-- // read subsequent pos's
+	pos, err := pp.postings.NextPosition()
+	if err != nil {
+		return false, err
+	}
+	pp.position = pos - pp.offset
+	return true, nil
+}
+
+/* for debug purposes */
+func (pp *PhrasePositions) String() string {
+	s := fmt.Sprintf("d:%v o:%v p:%v c:%v", pp.doc, pp.offset, pp.position, pp.count)
+	if pp.rptGroup >= 0 {
+		s += fmt.Sprintf(" rpt:%v,i%v", pp.rptGroup, pp.rptInd)
+	}
+	return s
+}
+
+// search/PhraseQueue.java
+
+/* A min heap of PhrasePositions, to be used with container/heap. */
+type phraseQueue []*PhrasePositions
+
+func (pq phraseQueue) Len() int      { return len(pq) }
+func (pq phraseQueue) Swap(i, j int) { pq[i], pq[j] = pq[j], pq[i] }
+func (pq phraseQueue) Less(i, j int) bool {
+	pp1, pp2 := pq[i], pq[j]
+	if pp1.doc != pp2.doc {
+		return pp1.doc < pp2.doc
+	}
+	if pp1.position != pp2.position {
+		return pp1.position < pp2.position
+	}
+	// same doc and pp.position, so decide by actual term positions.
+	// rely on: pp.position == tp.position - offset.
+	if pp1.offset != pp2.offset {
+		return pp1.offset < pp2.offset
+	}
+	return pp1.ord < pp2.ord
+}
+func (pq *phraseQueue) Push(x interface{}) { *pq = append(*pq, x.(*PhrasePositions)) }
+func (pq *phraseQueue) Pop() interface{} {
+	n := len(*pq)
+	ans := (*pq)[n-1]
+	*pq = (*pq)[:n-1]
+	return ans
+}
@@ -0,0 +1,353 @@
+package search
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"sort"
+)
+
+// search/PhraseQuery.java
+
+/*
+A Query that matches documents containing a particular sequence of
+terms. A PhraseQuery is built by QueryParser for input like "new york".
+
+This query may be combined with other terms or queries with a
+BooleanQuery.
+*/
+type PhraseQuery struct {
+	*AbstractQuery
+	field       string
+	terms       []*index.Term
+	positions   []int
+	maxPosition int
+	slop        int
+}
+
+/* Constructs an empty phrase query. */
+func NewPhraseQuery() *PhraseQuery {
+	ans := &PhraseQuery{}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/*
+Sets the number of other words permitted between words in query
+phrase. If zero, then this is an exact phrase search. For larger
+values this works like a WITHIN or NEAR operator.
+
+The slop is in fact an edit-distance, where the units correspond to
+moves of terms in the query phrase out of position. For example, to
+switch the order of two words requires two moves (the first move
+places the words atop one another), so to permit re-orderings of
+phrases, the slop must be at least two.
+
+More exact matches are scored higher than sloppier matches, thus
+search results are sorted by exactness.
+
+The slop is zero by default, requiring exact matches.
+*/
+func (q *PhraseQuery) SetSlop(s int) {
+	assert2(s >= 0, "slop value cannot be negative")
+	q.slop = s
+}
+
+/* Returns the slop. See SetSlop(). */
+func (q *PhraseQuery) Slop() int {
+	return q.slop
+}
+
+/*
+Adds a term to the end of the query phrase. The relative position of
+the term is the one immediately after the last term added.
+*/
+func (q *PhraseQuery) Add(term *index.Term) {
+	position := 0
+	if len(q.positions) > 0 {
+		position = q.positions[len(q.positions)-1] + 1
+	}
+	q.AddAt(term, position)
+}
+
+/*
+Adds a term to the end of the query phrase. The relative position of
+the term within the phrase is specified explicitly. This allows e.g.
+phrases with more than one term at the same position or phrases with
+gaps (e.g. in connection with stopwords).
+*/
+func (q *PhraseQuery) AddAt(term *index.Term, position int) {
+	if len(q.terms) == 0 {
+		q.field = term.Field
+	} else if term.Field != q.field {
+		panic(fmt.Sprintf("All phrase terms must be in the same field: %v", term))
+	}
+
+	q.terms = append(q.terms, term)
+	q.positions = append(q.positions, position)
+	if position > q.maxPosition {
+		q.maxPosition = position
+	}
+}
+
+/* Returns the set of terms in this phrase. */
+func (q *PhraseQuery) Terms() []*index.Term {
+	return q.terms
+}
+
+/* Returns the relative positions of terms in this phrase. */
+func (q *PhraseQuery) Positions() []int {
+	return q.positions
+}
+
+func (q *PhraseQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	switch len(q.terms) {
+	case 0:
+		bq := NewBooleanQuery()
+		bq.SetBoost(q.Boost())
+		return bq, nil
+	case 1:
+		tq := NewTermQuery(q.terms[0])
+		tq.SetBoost(q.Boost())
+		return tq, nil
+	default:
+		return q, nil
+	}
+}
+
+type postingsAndFreq struct {
+	postings DocsAndPositionsEnum
+	docFreq  int
+	position int
+	terms    []*index.Term
+}
+
+func newPostingsAndFreq(postings DocsAndPositionsEnum, docFreq,
+	position int, terms ...*index.Term) *postingsAndFreq {
+
+	if len(terms) > 1 {
+		terms2 := make([]*index.Term, len(terms))
+		copy(terms2, terms)
+		sort.Sort(index.TermSorter(terms2))
+		terms = terms2
+	}
+	return &postingsAndFreq{postings, docFreq, position, terms}
+}
+
+type postingsAndFreqSorter []*postingsAndFreq
+
+func (s postingsAndFreqSorter) Len() int      { return len(s) }
+func (s postingsAndFreqSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s postingsAndFreqSorter) Less(i, j int) bool {
+	a, b := s[i], s[j]
+	if a.docFreq != b.docFreq {
+		return a.docFreq < b.docFreq
+	}
+	if a.position != b.position {
+		return a.position < b.position
+	}
+	if len(a.terms) != len(b.terms) {
+		return len(a.terms) < len(b.terms)
+	}
+	for k, t := range a.terms {
+		if terms := index.TermSorter([]*index.Term{t, b.terms[k]}); terms.Less(0, 1) {
+			return true
+		} else if terms.Less(1, 0) {
+			return false
+		}
+	}
+	return false
+}
+
+type PhraseWeight struct {
+	*WeightImpl
+	*PhraseQuery
+	similarity Similarity
+	stats      SimWeight
+	states     []*index.TermContext
+}
+
+func newPhraseWeight(owner *PhraseQuery, searcher *IndexSearcher) (*PhraseWeight, error) {
+	ctx := searcher.TopReaderContext()
+	states := make([]*index.TermContext, len(owner.terms))
+	termStats := make([]TermStatistics, len(owner.terms))
+	for i, term := range owner.terms {
+		var err error
+		if states[i], err = index.NewTermContextFromTerm(ctx, term); err != nil {
+			return nil, err
+		}
+		termStats[i] = searcher.TermStatistics(term, states[i])
+	}
+	ans := &PhraseWeight{
+		PhraseQuery: owner,
+		similarity:  searcher.similarity,
+		stats: searcher.similarity.computeWeight(owner.boost,
+			searcher.CollectionStatistics(owner.field), termStats...),
+		states: states,
+	}
+	ans.WeightImpl = NewWeightImpl(ans)
+	return ans, nil
+}
+
+func (w *PhraseWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.PhraseQuery)
+}
+
+func (w *PhraseWeight) ValueForNormalization() float32 {
+	return w.stats.ValueForNormalization()
+}
+
+func (w *PhraseWeight) Normalize(queryNorm, topLevelBoost float32) {
+	w.stats.Normalize(queryNorm, topLevelBoost)
+}
+
+func (w *PhraseWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *PhraseWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	assert(len(w.terms) > 0)
+	reader := context.Reader().(index.AtomicReader)
+	postingsFreqs := make([]*postingsAndFreq, len(w.terms))
+
+	fieldTerms := reader.Terms(w.field)
+	if fieldTerms == nil {
+		return nil, nil
+	}
+
+	// Reuse single TermsEnum below:
+	te := fieldTerms.Iterator(nil)
+
+	for i, t := range w.terms {
+		state := w.states[i].State(context.Ord)
+		if state == nil { // term doesn't exist in this segment
+			assert2(w.termNotInReader(reader, t), "no termstate found but term exists in reader")
+			return nil, nil
+		}
+		if err := te.SeekExactFromLast(t.Bytes, state); err != nil {
+			return nil, err
+		}
+		postingsEnum, err := te.DocsAndPositionsByFlags(acceptDocs, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		// PhraseQuery on a field that did not index positions.
+		if postingsEnum == nil {
+			// term does exist, but has no positions
+			return nil, errors.New(fmt.Sprintf(
+				"field '%v' was indexed without position data; cannot run PhraseQuery (term=%v)",
+				t.Field, string(t.Bytes)))
+		}
+		docFreq, err := te.DocFreq()
+		if err != nil {
+			return nil, err
+		}
+		postingsFreqs[i] = newPostingsAndFreq(postingsEnum, docFreq, w.positions[i], t)
+	}
+
+	simScorer, err := w.similarity.simScorer(w.stats, context)
+	if err != nil {
+		return nil, err
+	}
+	if w.slop == 0 { // optimize exact case
+		// sort by increasing docFreq order
+		sort.Sort(postingsAndFreqSorter(postingsFreqs))
+		return newExactPhraseScorer(w, postingsFreqs, simScorer), nil
+	}
+	return newSloppyPhraseScorer(w, postingsFreqs, w.slop, simScorer), nil
+}
+
+// only called from assert
+func (w *PhraseWeight) termNotInReader(reader index.AtomicReader, term *index.Term) bool {
+	n, err := reader.DocFreq(term)
+	assert(err == nil)
+	return n == 0
+}
+
+func (w *PhraseWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
+	scorer, err := w.Scorer(context, context.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	if scorer != nil {
+		newDoc, err := scorer.Advance(doc)
+		if err != nil {
+			return nil, err
+		}
+		if newDoc == doc {
+			var freq float32
+			if w.slop == 0 {
+				n, err := scorer.Freq()
+				if err != nil {
+					return nil, err
+				}
+				freq = float32(n)
+			} else {
+				freq = scorer.(*SloppyPhraseScorer).sloppyFreq
+			}
+			docScorer, err := w.similarity.simScorer(w.stats, context)
+			if err != nil {
+				return nil, err
+			}
+			scoreExplanation := docScorer.explain(doc,
+				NewExplanation(freq, fmt.Sprintf("phraseFreq=%v", freq)))
+			ans := NewComplexExplanation(true, scoreExplanation.Value(),
+				fmt.Sprintf("weight(%v in %v) [%v], result of:",
+					w.PhraseQuery, doc, reflect.TypeOf(w.similarity)))
+			ans.AddDetail(scoreExplanation)
+			return ans, nil
+		}
+	}
+	return NewComplexExplanation(false, 0, "no matching term"), nil
+}
+
+func (q *PhraseQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newPhraseWeight(q, searcher)
+}
+
+func (q *PhraseQuery) ToString(f string) string {
+	var buf bytes.Buffer
+	if q.field != "" && q.field != f {
+		buf.WriteString(q.field)
+		buf.WriteRune(':')
+	}
+
+	buf.WriteRune('"')
+	pieces := make([]string, q.maxPosition+1)
+	for i, term := range q.terms {
+		pos := q.positions[i]
+		if s := pieces[pos]; s == "" {
+			pieces[pos] = string(term.Bytes)
+		} else {
+			pieces[pos] = s + "|" + string(term.Bytes)
+		}
+	}
+	for i, s := range pieces {
+		if i > 0 {
+			buf.WriteRune(' ')
+		}
+		if s == "" {
+			buf.WriteRune('?')
+		} else {
+			buf.WriteString(s)
+		}
+	}
+	buf.WriteRune('"')
+
+	if q.slop != 0 {
+		fmt.Fprintf(&buf, "~%v", q.slop)
+	}
+
+	if q.boost != 1 {
+		fmt.Fprintf(&buf, "^%v", q.boost)
+	}
+
+	return buf.String()
+}
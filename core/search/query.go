@@ -15,7 +15,7 @@ type Query interface {
 	Boost() float32
 	QuerySPI
 	CreateWeight(ss *IndexSearcher) (w Weight, err error)
-	Rewrite(r index.IndexReader) Query
+	Rewrite(r index.IndexReader) (Query, error)
 }
 
 type QuerySPI interface {
@@ -45,6 +45,6 @@ func (q *AbstractQuery) CreateWeight(ss *IndexSearcher) (w Weight, err error) {
 	panic(fmt.Sprintf("Query %v does not implement createWeight", q))
 }
 
-func (q *AbstractQuery) Rewrite(r index.IndexReader) Query {
-	return q.value
+func (q *AbstractQuery) Rewrite(r index.IndexReader) (Query, error) {
+	return q.value, nil
 }
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// search/ReqExclScorer.java
+
+/*
+A Scorer for queries with a required subscorer and an excluding
+(prohibited) sub DocIdSetIterator.
+
+This Scorer implements Scorer.Advance(), and it uses the Advance() on
+the given scorers.
+*/
+type ReqExclScorer struct {
+	*abstractScorer
+	reqScorer Scorer
+	exclDisi  DocIdSetIterator
+	doc       int
+	cost      int64 // kept, as reqScorer is dropped once exhausted
+}
+
+/*
+Construct a ReqExclScorer. reqScorer is the scorer that must match,
+except where exclDisi also matches.
+*/
+func newReqExclScorer(reqScorer Scorer, exclDisi DocIdSetIterator) *ReqExclScorer {
+	ans := &ReqExclScorer{
+		reqScorer: reqScorer,
+		exclDisi:  exclDisi,
+		doc:       -1,
+		cost:      reqScorer.Cost(),
+	}
+	ans.abstractScorer = newScorer(ans, reqScorer.Weight())
+	return ans
+}
+
+func (s *ReqExclScorer) NextDoc() (doc int, err error) {
+	if s.reqScorer == nil {
+		return s.doc, nil
+	}
+	if s.doc, err = s.reqScorer.NextDoc(); err != nil {
+		return 0, err
+	}
+	if s.doc == NO_MORE_DOCS {
+		s.reqScorer = nil // exhausted, nothing left
+		return s.doc, nil
+	}
+	if s.exclDisi == nil {
+		return s.doc, nil
+	}
+	if s.doc, err = s.toNonExcluded(); err != nil {
+		return 0, err
+	}
+	return s.doc, nil
+}
+
+/*
+Advance to non excluded doc.
+
+On entry:
+- reqScorer != nil,
+- exclScorer != nil,
+- reqScorer was advanced once via NextDoc() or Advance() and
+reqScorer.DocId() may still be excluded.
+
+Advances reqScorer a non excluded required doc, if any.
+*/
+func (s *ReqExclScorer) toNonExcluded() (int, error) {
+	exclDoc := s.exclDisi.DocId()
+	reqDoc := s.reqScorer.DocId() // may be excluded
+	var err error
+	for {
+		if reqDoc < exclDoc {
+			return reqDoc, nil // reqScorer advanced to before exclScorer, ie. not excluded
+		} else if reqDoc > exclDoc {
+			if exclDoc, err = s.exclDisi.Advance(reqDoc); err != nil {
+				return 0, err
+			}
+			if exclDoc == NO_MORE_DOCS {
+				s.exclDisi = nil // exhausted, no more exclusions
+				return reqDoc, nil
+			}
+			if exclDoc > reqDoc {
+				return reqDoc, nil // not excluded
+			}
+		}
+		if reqDoc, err = s.reqScorer.NextDoc(); err != nil {
+			return 0, err
+		}
+		if reqDoc == NO_MORE_DOCS {
+			break
+		}
+	}
+	s.reqScorer = nil // exhausted, nothing left
+	return NO_MORE_DOCS, nil
+}
+
+func (s *ReqExclScorer) DocId() int {
+	return s.doc
+}
+
+/*
+Returns the score of the current document matching the query.
+Initially invalid, until NextDoc() is called the first time.
+*/
+func (s *ReqExclScorer) Score() (float32, error) {
+	// reqScorer may be nil when NextDoc() or Advance() already return
+	// NO_MORE_DOCS
+	return s.reqScorer.Score()
+}
+
+func (s *ReqExclScorer) Freq() (int, error) {
+	return s.reqScorer.Freq()
+}
+
+func (s *ReqExclScorer) Cost() int64 {
+	return s.cost
+}
+
+func (s *ReqExclScorer) Advance(target int) (doc int, err error) {
+	if s.reqScorer == nil {
+		s.doc = NO_MORE_DOCS
+		return s.doc, nil
+	}
+	if s.exclDisi == nil {
+		if s.doc, err = s.reqScorer.Advance(target); err != nil {
+			return 0, err
+		}
+		return s.doc, nil
+	}
+	if doc, err = s.reqScorer.Advance(target); err != nil {
+		return 0, err
+	}
+	if doc == NO_MORE_DOCS {
+		s.reqScorer = nil
+		s.doc = NO_MORE_DOCS
+		return s.doc, nil
+	}
+	if s.doc, err = s.toNonExcluded(); err != nil {
+		return 0, err
+	}
+	return s.doc, nil
+}
+
+func (s *ReqExclScorer) String() string {
+	return fmt.Sprintf("ReqExclScorer(%v)", s.weight)
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// search/ReqOptSumScorer.java
+
+/*
+A Scorer for queries with a required part and an optional part.
+Delays Advance() on the optional part until a Score() is needed.
+
+This Scorer implements Scorer.Advance().
+*/
+type ReqOptSumScorer struct {
+	*abstractScorer
+	// The scorers passed from the constructor. These are set to nil as
+	// soon as their NextDoc() or Advance() returns NO_MORE_DOCS.
+	reqScorer Scorer
+	optScorer Scorer
+}
+
+/* Construct a ReqOptScorer. */
+func newReqOptSumScorer(reqScorer, optScorer Scorer) *ReqOptSumScorer {
+	assert(reqScorer != nil)
+	assert(optScorer != nil)
+	ans := &ReqOptSumScorer{
+		reqScorer: reqScorer,
+		optScorer: optScorer,
+	}
+	ans.abstractScorer = newScorer(ans, reqScorer.Weight())
+	return ans
+}
+
+func (s *ReqOptSumScorer) NextDoc() (int, error) {
+	return s.reqScorer.NextDoc()
+}
+
+func (s *ReqOptSumScorer) Cost() int64 {
+	return s.reqScorer.Cost()
+}
+
+func (s *ReqOptSumScorer) Advance(target int) (int, error) {
+	return s.reqScorer.Advance(target)
+}
+
+func (s *ReqOptSumScorer) DocId() int {
+	return s.reqScorer.DocId()
+}
+
+/*
+Returns the score of the current document matching the query.
+Initially invalid, until NextDoc() is called the first time.
+*/
+func (s *ReqOptSumScorer) Score() (float32, error) {
+	// TODO: sum into a float64 and cast to float32 if we ever send
+	// required clauses to BS1
+	reqScore, optScore, _, err := s.scores()
+	return reqScore + optScore, err
+}
+
+/*
+Returns the score of the required scorer, and that of the optional
+scorer if it matches the current document.
+*/
+func (s *ReqOptSumScorer) scores() (reqScore, optScore float32, optMatched bool, err error) {
+	curDoc := s.reqScorer.DocId()
+	if reqScore, err = s.reqScorer.Score(); err != nil {
+		return
+	}
+	if s.optScorer == nil {
+		return
+	}
+
+	optScorerDoc := s.optScorer.DocId()
+	if optScorerDoc < curDoc {
+		if optScorerDoc, err = s.optScorer.Advance(curDoc); err != nil {
+			return
+		}
+		if optScorerDoc == NO_MORE_DOCS {
+			s.optScorer = nil
+			return
+		}
+	}
+
+	if optScorerDoc == curDoc {
+		optScore, err = s.optScorer.Score()
+		optMatched = true
+	}
+	return
+}
+
+func (s *ReqOptSumScorer) Freq() (int, error) {
+	// we might have deferred advance()
+	_, _, optMatched, err := s.scores()
+	if err != nil {
+		return 0, err
+	}
+	if optMatched {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+func (s *ReqOptSumScorer) String() string {
+	return fmt.Sprintf("ReqOptSumScorer(%v)", s.weight)
+}
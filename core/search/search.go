@@ -1,12 +1,15 @@
 package search
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
 	"github.com/balzaczyy/golucene/core/index"
 	"github.com/balzaczyy/golucene/core/util"
 	"log"
 	"math"
+	"sync"
 )
 
 /* Define service that can be overrided */
@@ -17,12 +20,23 @@ type IndexSearcherSPI interface {
 	SearchLWC([]*index.AtomicReaderContext, Weight, Collector) error
 }
 
+/*
+Optionally implemented by an IndexSearcherSPI to make SearchLWC()
+stop once the search context is cancelled. It is only used for
+contexts that can be cancelled; SearchLWC() serves all other
+searches, and all searches of an IndexSearcherSPI without it.
+*/
+type IndexSearcherContextSPI interface {
+	SearchLWCContext(context.Context, []*index.AtomicReaderContext, Weight, Collector) error
+}
+
 // IndexSearcher
 type IndexSearcher struct {
 	spi           IndexSearcherSPI
 	reader        index.IndexReader
 	readerContext index.IndexReaderContext
 	leafContexts  []*index.AtomicReaderContext
+	leafSlices    [][]*index.AtomicReaderContext // nil unless searching concurrently
 	similarity    Similarity
 }
 
@@ -34,26 +48,222 @@ func NewIndexSearcher(r index.IndexReader) *IndexSearcher {
 func NewIndexSearcherFromContext(context index.IndexReaderContext) *IndexSearcher {
 	// assert2(context.isTopLevel, "IndexSearcher's ReaderContext must be topLevel for reader %v", context.reader())
 	defaultSimilarity := NewDefaultSimilarity()
-	ss := &IndexSearcher{nil, context.Reader(), context, context.Leaves(), defaultSimilarity}
+	ss := &IndexSearcher{
+		reader:        context.Reader(),
+		readerContext: context,
+		leafContexts:  context.Leaves(),
+		similarity:    defaultSimilarity,
+	}
 	ss.spi = ss
 	return ss
 }
 
+/*
+Creates a searcher which searches the segments of the reader
+concurrently. The segments are grouped into at most parallelism
+slices of about the same number of documents, and each slice is
+searched on its own goroutine, before the hits of all slices are
+merged, by score or by the sort of SearchSorted(). SearchCollector()
+still searches the segments one after another.
+*/
+func NewIndexSearcherWithExecutor(r index.IndexReader, parallelism int) *IndexSearcher {
+	assert2(parallelism > 0, "parallelism must be positive: %v", parallelism)
+	ss := NewIndexSearcher(r)
+	ss.leafSlices = slices(ss.leafContexts, parallelism)
+	return ss
+}
+
+/*
+Groups the leaves into at most n slices of contiguous leaves, each
+holding about the same number of documents.
+*/
+func slices(leaves []*index.AtomicReaderContext, n int) [][]*index.AtomicReaderContext {
+	if n > len(leaves) {
+		n = len(leaves)
+	}
+	if n <= 1 {
+		return [][]*index.AtomicReaderContext{leaves}
+	}
+	total := 0
+	for _, leaf := range leaves {
+		total += leaf.Reader().MaxDoc()
+	}
+	var ans [][]*index.AtomicReaderContext
+	start, docs := 0, 0
+	for i, leaf := range leaves {
+		docs += leaf.Reader().MaxDoc()
+		// close the slice once it holds its share of the documents, or
+		// when the remaining leaves are just enough for the other slices
+		if len(ans) < n-1 && (docs*n >= total*(len(ans)+1) || len(leaves)-i-1 == n-1-len(ans)) {
+			ans = append(ans, leaves[start:i+1])
+			start = i + 1
+		}
+	}
+	return append(ans, leaves[start:])
+}
+
 /* Expert: set the similarity implementation used by this IndexSearcher. */
 func (ss *IndexSearcher) SetSimilarity(similarity Similarity) {
 	ss.similarity = similarity
 }
 
+/* Expert: returns the similarity implementation used by this IndexSearcher. */
+func (ss *IndexSearcher) Similarity() Similarity {
+	return ss.similarity
+}
+
+/* Return the IndexReader this searches. */
+func (ss *IndexSearcher) IndexReader() index.IndexReader {
+	return ss.reader
+}
+
 func (ss *IndexSearcher) SearchTop(q Query, n int) (topDocs TopDocs, err error) {
-	return ss.Search(q, nil, n)
+	return ss.SearchTopContext(context.Background(), q, n)
+}
+
+/*
+Like SearchTop(), but stops with ctx.Err() once ctx is cancelled or
+its deadline passes.
+*/
+func (ss *IndexSearcher) SearchTopContext(ctx context.Context, q Query, n int) (topDocs TopDocs, err error) {
+	return ss.SearchContext(ctx, q, nil, n)
 }
 
 func (ss *IndexSearcher) Search(q Query, f Filter, n int) (topDocs TopDocs, err error) {
+	return ss.SearchContext(context.Background(), q, f, n)
+}
+
+/*
+Like Search(), but stops with ctx.Err() once ctx is cancelled or its
+deadline passes.
+*/
+func (ss *IndexSearcher) SearchContext(ctx context.Context, q Query, f Filter, n int) (topDocs TopDocs, err error) {
 	w, err := ss.spi.CreateNormalizedWeight(ss.spi.WrapFilter(q, f))
 	if err != nil {
 		return TopDocs{}, err
 	}
-	return ss.searchWSI(w, nil, n), nil
+	return ss.searchWSI(ctx, w, nil, n)
+}
+
+/*
+Finds the top n hits for query where all results are after a
+previous result (after).
+
+By passing the bottom result from a previous page as after, this
+method can be used for efficient 'deep-paging' across potentially
+large result sets.
+*/
+func (ss *IndexSearcher) SearchAfter(after *ScoreDoc, q Query, n int) (topDocs TopDocs, err error) {
+	return ss.SearchAfterContext(context.Background(), after, q, n)
+}
+
+/*
+Like SearchAfter(), but stops with ctx.Err() once ctx is cancelled
+or its deadline passes.
+*/
+func (ss *IndexSearcher) SearchAfterContext(ctx context.Context, after *ScoreDoc,
+	q Query, n int) (topDocs TopDocs, err error) {
+
+	if after != nil && after.Doc >= ss.reader.MaxDoc() {
+		return TopDocs{}, errors.New(fmt.Sprintf(
+			"after.doc exceeds the number of documents in the reader: after.doc=%v limit=%v",
+			after.Doc, ss.reader.MaxDoc()))
+	}
+	w, err := ss.spi.CreateNormalizedWeight(ss.spi.WrapFilter(q, nil))
+	if err != nil {
+		return TopDocs{}, err
+	}
+	return ss.searchWSI(ctx, w, after, n)
+}
+
+/*
+Search implementation with arbitrary sorting. Finds the top n hits
+for query, applying filter if non-nil, and sorting the hits by the
+criteria in sort.
+
+NOTE: this does not compute scores by default; if you need scores,
+sort by FIELD_SCORE or use a TopFieldCollector that tracks them.
+*/
+func (ss *IndexSearcher) SearchSorted(q Query, f Filter, n int, sort *Sort) (topDocs TopFieldDocs, err error) {
+	return ss.SearchSortedContext(context.Background(), q, f, n, sort)
+}
+
+/*
+Like SearchSorted(), but stops with ctx.Err() once ctx is cancelled
+or its deadline passes.
+*/
+func (ss *IndexSearcher) SearchSortedContext(ctx context.Context, q Query, f Filter,
+	n int, sort *Sort) (topDocs TopFieldDocs, err error) {
+
+	w, err := ss.spi.CreateNormalizedWeight(ss.spi.WrapFilter(q, f))
+	if err != nil {
+		return TopFieldDocs{}, err
+	}
+	return ss.searchWSF(ctx, w, n, sort, true, false, false)
+}
+
+/*
+Lower-level search API. Collector.Collect() is called for every
+matching document, applying filter if non-nil. The segments are
+always searched one after another, as the collector is shared.
+
+A collector may return ErrCollectionTerminated to skip the rest of
+the current segment. Once ctx is cancelled or its deadline passes,
+the search stops with ctx.Err(), leaving the collector with the
+hits collected so far.
+*/
+func (ss *IndexSearcher) SearchCollector(ctx context.Context, q Query, f Filter, c Collector) error {
+	w, err := ss.spi.CreateNormalizedWeight(ss.spi.WrapFilter(q, f))
+	if err != nil {
+		return err
+	}
+	return ss.searchLWC(ctx, ss.leafContexts, w, c)
+}
+
+/*
+Just like searchWSI(), but you choose whether or not the fields in
+the returned FieldDocs are filled in, and whether doc scores and the
+max score are computed. When the leaves are searched in slices, the
+fields are always filled in, as the hits of the slices are merged by
+them.
+*/
+func (ss *IndexSearcher) searchWSF(ctx context.Context, w Weight, nDocs int, sort *Sort,
+	fillFields, doDocScores, doMaxScore bool) (TopFieldDocs, error) {
+
+	assert2(sort != nil, "Sort must not be nil")
+	limit := ss.reader.MaxDoc()
+	if limit == 0 {
+		limit = 1
+	}
+	if nDocs > limit {
+		nDocs = limit
+	}
+	if len(ss.leafSlices) <= 1 {
+		return ss.searchLWSF(ctx, ss.leafContexts, w, nDocs, sort, fillFields, doDocScores, doMaxScore)
+	}
+
+	hits := make([]TopFieldDocs, len(ss.leafSlices))
+	if err := ss.searchSlices(ctx, func(ctx context.Context, i int, slice []*index.AtomicReaderContext) (err error) {
+		hits[i], err = ss.searchLWSF(ctx, slice, w, nDocs, sort, true, doDocScores, doMaxScore)
+		return
+	}); err != nil {
+		return TopFieldDocs{}, err
+	}
+	return mergeTopFieldDocs(sort, nDocs, hits, false), nil
+}
+
+/*
+Just like searchLWSI(), but sorts the hits by sort; see searchWSF().
+*/
+func (ss *IndexSearcher) searchLWSF(ctx context.Context, leaves []*index.AtomicReaderContext,
+	w Weight, nDocs int, sort *Sort, fillFields, doDocScores, doMaxScore bool) (TopFieldDocs, error) {
+
+	collector := NewTopFieldCollector(sort, nDocs, fillFields,
+		doDocScores, doMaxScore, !w.IsScoresDocsOutOfOrder())
+	if err := ss.searchLWC(ctx, leaves, w, collector); err != nil {
+		return TopFieldDocs{}, err
+	}
+	return collector.TopFieldDocs(), nil
 }
 
 /** Expert: Low-level search implementation.  Finds the top <code>n</code>
@@ -64,9 +274,55 @@ func (ss *IndexSearcher) Search(q Query, f Filter, n int) (topDocs TopDocs, err
  * @throws BooleanQuery.TooManyClauses If a query would exceed
  *         {@link BooleanQuery#getMaxClauseCount()} clauses.
  */
-func (ss *IndexSearcher) searchWSI(w Weight, after *ScoreDoc, nDocs int) TopDocs {
-	// TODO support concurrent search
-	return ss.searchLWSI(ss.leafContexts, w, after, nDocs)
+func (ss *IndexSearcher) searchWSI(ctx context.Context, w Weight, after *ScoreDoc, nDocs int) (TopDocs, error) {
+	limit := ss.reader.MaxDoc()
+	if limit == 0 {
+		limit = 1
+	}
+	if nDocs > limit {
+		nDocs = limit
+	}
+	if len(ss.leafSlices) <= 1 {
+		return ss.searchLWSI(ctx, ss.leafContexts, w, after, nDocs)
+	}
+
+	hits := make([]TopDocs, len(ss.leafSlices))
+	if err := ss.searchSlices(ctx, func(ctx context.Context, i int, slice []*index.AtomicReaderContext) (err error) {
+		hits[i], err = ss.searchLWSI(ctx, slice, w, after, nDocs)
+		return
+	}); err != nil {
+		return TopDocs{}, err
+	}
+	return mergeTopDocs(nDocs, hits, false), nil
+}
+
+/*
+Calls search for every leaf slice, each on its own goroutine, and
+waits for all of them. Once a slice fails, the search of the other
+slices is cancelled, and the first error is returned.
+*/
+func (ss *IndexSearcher) searchSlices(ctx context.Context,
+	search func(ctx context.Context, i int, slice []*index.AtomicReaderContext) error) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var err error
+	for i, slice := range ss.leafSlices {
+		wg.Add(1)
+		go func(i int, slice []*index.AtomicReaderContext) {
+			defer wg.Done()
+			if sliceErr := search(ctx, i, slice); sliceErr != nil {
+				once.Do(func() {
+					err = sliceErr
+					cancel() // no need to search the other slices
+				})
+			}
+		}(i, slice)
+	}
+	wg.Wait()
+	return err
 }
 
 /** Expert: Low-level search implementation.  Finds the top <code>n</code>
@@ -77,46 +333,76 @@ func (ss *IndexSearcher) searchWSI(w Weight, after *ScoreDoc, nDocs int) TopDocs
  * @throws BooleanQuery.TooManyClauses If a query would exceed
  *         {@link BooleanQuery#getMaxClauseCount()} clauses.
  */
-func (ss *IndexSearcher) searchLWSI(leaves []*index.AtomicReaderContext,
-	w Weight, after *ScoreDoc, nDocs int) TopDocs {
-	// single thread
-	limit := ss.reader.MaxDoc()
-	if limit == 0 {
-		limit = 1
+func (ss *IndexSearcher) searchLWSI(ctx context.Context, leaves []*index.AtomicReaderContext,
+	w Weight, after *ScoreDoc, nDocs int) (TopDocs, error) {
+
+	collector := NewTopScoreDocCollector(nDocs, after, !w.IsScoresDocsOutOfOrder())
+	if err := ss.searchLWC(ctx, leaves, w, collector); err != nil {
+		return TopDocs{}, err
 	}
-	if nDocs > limit {
-		nDocs = limit
+	return collector.TopDocs(), nil
+}
+
+/* Dispatches to the SPI, honoring ctx if the SPI supports it. */
+func (ss *IndexSearcher) searchLWC(ctx context.Context, leaves []*index.AtomicReaderContext,
+	w Weight, c Collector) error {
+
+	if spi, ok := ss.spi.(IndexSearcherContextSPI); ok && ctx.Done() != nil {
+		return spi.SearchLWCContext(ctx, leaves, w, c)
 	}
-	collector := NewTopScoreDocCollector(nDocs, after, !w.IsScoresDocsOutOfOrder())
-	ss.spi.SearchLWC(leaves, w, collector)
-	return collector.TopDocs()
+	return ss.spi.SearchLWC(leaves, w, c)
+}
+
+/*
+Lower-level search API. Searches the given leaves one after another.
+ErrCollectionTerminated returned by the collector only ends the
+current leaf.
+*/
+func (ss *IndexSearcher) SearchLWC(leaves []*index.AtomicReaderContext, w Weight, c Collector) error {
+	return ss.SearchLWCContext(context.Background(), leaves, w, c)
 }
 
-func (ss *IndexSearcher) SearchLWC(leaves []*index.AtomicReaderContext, w Weight, c Collector) (err error) {
+/*
+Like SearchLWC(), but stops with ctx.Err() once ctx is cancelled.
+*/
+func (ss *IndexSearcher) SearchLWCContext(ctx context.Context, leaves []*index.AtomicReaderContext,
+	w Weight, c Collector) error {
+
 	// TODO: should we make this
 	// threaded...?  the Collector could be sync'd?
 	// always use single thread:
-	for _, ctx := range leaves { // search each subreader
-		// TODO catch CollectionTerminatedException
-		c.SetNextReader(ctx)
+	for _, leaf := range leaves { // search each subreader
+		// stop between segments once the search is cancelled
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.SetNextReader(leaf); err == ErrCollectionTerminated {
+			// there is no doc of interest in this reader context
+			// continue with the following leaf
+			continue
+		} else if err != nil {
+			return err
+		}
 
-		scorer, err := w.BulkScorer(ctx, !c.AcceptsDocsOutOfOrder(),
-			ctx.Reader().(index.AtomicReader).LiveDocs())
+		scorer, err := w.BulkScorer(leaf, !c.AcceptsDocsOutOfOrder(),
+			leaf.Reader().(index.AtomicReader).LiveDocs())
 		if err != nil {
 			return err
 		}
 		if scorer != nil {
-			err = scorer.ScoreAndCollect(c)
-		} // TODO catch CollectionTerminatedException
+			if err = scoreAndCollectContext(ctx, scorer, c); err != nil && err != ErrCollectionTerminated {
+				return err
+			} // collection was terminated prematurely, continue with the following leaf
+		}
 	}
-	return
+	return nil
 }
 
 func (ss *IndexSearcher) WrapFilter(q Query, f Filter) Query {
 	if f == nil {
 		return q
 	}
-	panic("FilteredQuery not supported yet")
+	return NewFilteredQuery(q, f)
 }
 
 /*
@@ -172,12 +458,12 @@ func (ss *IndexSearcher) CreateNormalizedWeight(q Query) (w Weight, err error) {
 
 func (ss *IndexSearcher) Rewrite(q Query) (Query, error) {
 	log.Printf("Rewriting '%v'...", q)
-	after := q.Rewrite(ss.reader)
-	for after != q {
+	after, err := q.Rewrite(ss.reader)
+	for err == nil && after != q {
 		q = after
-		after = q.Rewrite(ss.reader)
+		after, err = q.Rewrite(ss.reader)
 	}
-	return q, nil
+	return q, err
 }
 
 // Returns this searhcers the top-level IndexReaderContext
@@ -241,6 +527,9 @@ type SimScorer interface {
 	 * @return document's score
 	 */
 	Score(doc int, freq float32) float32
+	// Computes the amount of a sloppy phrase match, based on an edit
+	// distance.
+	computeSlopFactor(distance int) float32
 	// Explain the score for a single document
 	explain(int, Explanation) Explanation
 }
@@ -267,6 +556,16 @@ type ITFIDFSimilarity interface {
 	 * @return a score factor based on a term's within-document frequency
 	 */
 	tf(freq float32) float32
+	// Computes the amount of a sloppy phrase match, based on an edit
+	// distance. This value is summed for each sloppy phrase match in a
+	// document to form the frequency to be used in scoring instead of
+	// the exact term count.
+	//
+	// A phrase match with a small edit distance to a document passage
+	// more closely matches the document, so implementations of this
+	// method usually return larger values when the edit distance is
+	// small and smaller values when it is large.
+	sloppyFreq(distance int) float32
 	/** Computes a score factor based on a term's document frequency (the number
 	 * of documents which contain the term).  This value is multiplied by the
 	 * {@link #tf(float)} factor for each term in the query and these products are
@@ -300,10 +599,15 @@ func newTFIDFSimilarity(spi ITFIDFSimilarity) *TFIDFSimilarity {
 	return &TFIDFSimilarity{spi}
 }
 
+/* Decodes a normalization factor stored in an index. */
+func (ts *TFIDFSimilarity) DecodeNormValue(norm int64) float32 {
+	return ts.spi.decodeNormValue(norm)
+}
+
 func (ts *TFIDFSimilarity) idfExplainTerm(collectionStats CollectionStatistics, termStats TermStatistics) Explanation {
 	df, max := termStats.DocFreq, collectionStats.maxDoc
 	idf := ts.spi.idf(df, max)
-	return newExplanation(idf, fmt.Sprintf("idf(docFreq=%v, maxDocs=%v)", df, max))
+	return NewExplanation(idf, fmt.Sprintf("idf(docFreq=%v, maxDocs=%v)", df, max))
 }
 
 func (ts *TFIDFSimilarity) idfExplainPhrase(collectionStats CollectionStatistics, termStats []TermStatistics) Explanation {
@@ -311,9 +615,9 @@ func (ts *TFIDFSimilarity) idfExplainPhrase(collectionStats CollectionStatistics
 	var idf float32 = 0
 	for i, stat := range termStats {
 		details[i] = ts.idfExplainTerm(collectionStats, stat)
-		idf += details[i].(*ExplanationImpl).value
+		idf += details[i].Value()
 	}
-	ans := newExplanation(idf, fmt.Sprintf("idf(), sum of:"))
+	ans := NewExplanation(idf, fmt.Sprintf("idf(), sum of:"))
 	ans.details = details
 	return ans
 }
@@ -360,6 +664,10 @@ func (ss *tfIDFSimScorer) Score(doc int, freq float32) float32 {
 	return raw * ss.owner.spi.decodeNormValue(ss.norms(doc)) // normalize for field
 }
 
+func (ss *tfIDFSimScorer) computeSlopFactor(distance int) float32 {
+	return ss.owner.spi.sloppyFreq(distance)
+}
+
 func (ss *tfIDFSimScorer) explain(doc int, freq Explanation) Explanation {
 	return ss.owner.explainScore(doc, freq, ss.stats, ss.norms)
 }
@@ -382,7 +690,7 @@ func newIDFStats(field string, idf Explanation, queryBoost float32) *idfStats {
 		field:       field,
 		idf:         idf,
 		queryBoost:  queryBoost,
-		queryWeight: idf.(*ExplanationImpl).value * queryBoost, // compute query weight
+		queryWeight: idf.Value() * queryBoost, // compute query weight
 	}
 }
 
@@ -393,50 +701,50 @@ func (stats *idfStats) ValueForNormalization() float32 {
 
 func (stats *idfStats) Normalize(queryNorm float32, topLevelBoost float32) {
 	stats.queryNorm = queryNorm * topLevelBoost
-	stats.queryWeight *= stats.queryNorm                                 // normalize query weight
-	stats.value = stats.queryWeight * stats.idf.(*ExplanationImpl).value // idf for document
+	stats.queryWeight *= stats.queryNorm                // normalize query weight
+	stats.value = stats.queryWeight * stats.idf.Value() // idf for document
 }
 
 func (ss *TFIDFSimilarity) explainScore(doc int, freq Explanation,
 	stats *idfStats, norms NumericDocValues) Explanation {
 
 	// explain query weight
-	boostExpl := newExplanation(stats.queryBoost, "boost")
-	queryNormExpl := newExplanation(stats.queryNorm, "queryNorm")
-	queryExpl := newExplanation(
+	boostExpl := NewExplanation(stats.queryBoost, "boost")
+	queryNormExpl := NewExplanation(stats.queryNorm, "queryNorm")
+	queryExpl := NewExplanation(
 		boostExpl.value*stats.idf.Value()*queryNormExpl.value,
 		"queryWeight, product of:")
 	if stats.queryBoost != 1 {
-		queryExpl.addDetail(boostExpl)
+		queryExpl.AddDetail(boostExpl)
 	}
-	queryExpl.addDetail(stats.idf)
-	queryExpl.addDetail(queryNormExpl)
+	queryExpl.AddDetail(stats.idf)
+	queryExpl.AddDetail(queryNormExpl)
 
 	// explain field weight
-	tfExplanation := newExplanation(ss.spi.tf(freq.Value()),
+	tfExplanation := NewExplanation(ss.spi.tf(freq.Value()),
 		fmt.Sprintf("tf(freq=%v), with freq of:", freq.Value()))
-	tfExplanation.addDetail(freq)
+	tfExplanation.AddDetail(freq)
 	fieldNorm := float32(1)
 	if norms != nil {
 		fieldNorm = ss.spi.decodeNormValue(norms(doc))
 	}
-	fieldNormExpl := newExplanation(fieldNorm, fmt.Sprintf("fieldNorm(doc=%v)", doc))
-	fieldExpl := newExplanation(
+	fieldNormExpl := NewExplanation(fieldNorm, fmt.Sprintf("fieldNorm(doc=%v)", doc))
+	fieldExpl := NewExplanation(
 		tfExplanation.value*stats.idf.Value()*fieldNormExpl.value,
 		fmt.Sprintf("fieldWeight in %v, product of:", doc))
-	fieldExpl.addDetail(tfExplanation)
-	fieldExpl.addDetail(stats.idf)
-	fieldExpl.addDetail(fieldNormExpl)
+	fieldExpl.AddDetail(tfExplanation)
+	fieldExpl.AddDetail(stats.idf)
+	fieldExpl.AddDetail(fieldNormExpl)
 
 	if queryExpl.value == 1 {
 		return fieldExpl
 	}
 
 	// combine them
-	ans := newExplanation(queryExpl.value*fieldExpl.value,
+	ans := NewExplanation(queryExpl.value*fieldExpl.value,
 		fmt.Sprintf("score(doc=%v,freq=%v), product of:", doc, freq))
-	ans.addDetail(queryExpl)
-	ans.addDetail(fieldExpl)
+	ans.AddDetail(queryExpl)
+	ans.AddDetail(fieldExpl)
 	return ans
 }
 
@@ -510,6 +818,11 @@ func (ds *DefaultSimilarity) tf(freq float32) float32 {
 	return float32(math.Sqrt(float64(freq)))
 }
 
+/* Implemented as 1/(distance+1). */
+func (ds *DefaultSimilarity) sloppyFreq(distance int) float32 {
+	return 1.0 / float32(distance+1)
+}
+
 func (ds *DefaultSimilarity) idf(docFreq int64, numDocs int64) float32 {
 	return float32(math.Log(float64(numDocs)/float64(docFreq+1))) + 1.0
 }
@@ -122,6 +122,21 @@ func NewPerFieldSimilarityWrapper(spi PerFieldSimilarityWrapperSPI) *PerFieldSim
 	return &PerFieldSimilarityWrapper{spi: spi}
 }
 
+/* Returns the Similarity selected for the given field. */
+func (wrapper *PerFieldSimilarityWrapper) Get(name string) Similarity {
+	return wrapper.spi.Get(name)
+}
+
+/* Returns 1, as coordination factors can't be selected per field. */
+func (wrapper *PerFieldSimilarityWrapper) Coord(overlap, maxOverlap int) float32 {
+	return 1
+}
+
+/* Returns 1, as query normalization can't be selected per field. */
+func (wrapper *PerFieldSimilarityWrapper) QueryNorm(valueForNormalization float32) float32 {
+	return 1
+}
+
 func (wrapper *PerFieldSimilarityWrapper) ComputeNorm(state *index.FieldInvertState) int64 {
 	return wrapper.spi.Get(state.Name()).ComputeNorm(state)
 }
@@ -133,7 +148,8 @@ func (wrapper *PerFieldSimilarityWrapper) computeWeight(queryBoost float32,
 }
 
 func (wrapper *PerFieldSimilarityWrapper) simScorer(w SimWeight, ctx *index.AtomicReaderContext) (ss SimScorer, err error) {
-	panic("not implemented yet")
+	perFieldWeight := w.(*PerFieldSimWeight)
+	return perFieldWeight.delegate.simScorer(perFieldWeight.delegateWeight, ctx)
 }
 
 type PerFieldSimWeight struct {
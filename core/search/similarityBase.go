@@ -0,0 +1,396 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+	"reflect"
+)
+
+// search/similarities/BasicStats.java
+
+/*
+Stores all statistics commonly used by ranking methods.
+*/
+type BasicStats struct {
+	field string
+	// The number of documents.
+	numberOfDocuments int64
+	// The total number of tokens in the field.
+	numberOfFieldTokens int64
+	// The average field length.
+	avgFieldLength float32
+	// The document frequency.
+	docFreq int64
+	// The total number of occurrences of this term across all documents.
+	totalTermFreq int64
+
+	// Query's inner boost.
+	queryBoost float32
+	// Any outer query's boost.
+	topLevelBoost float32
+	// For most Similarities, the immediate and the top level query
+	// boosts are not handled differently. Hence, this field is just the
+	// product of the other two.
+	totalBoost float32
+}
+
+/* Constructor. Sets the query boost. */
+func NewBasicStats(field string, queryBoost float32) *BasicStats {
+	return &BasicStats{
+		field:      field,
+		queryBoost: queryBoost,
+		totalBoost: queryBoost,
+	}
+}
+
+func (stats *BasicStats) basic() *BasicStats {
+	return stats
+}
+
+/* Returns the number of documents. */
+func (stats *BasicStats) NumberOfDocuments() int64 {
+	return stats.numberOfDocuments
+}
+
+/*
+Returns the total number of tokens in the field.
+See Terms.SumTotalTermFreq().
+*/
+func (stats *BasicStats) NumberOfFieldTokens() int64 {
+	return stats.numberOfFieldTokens
+}
+
+/* Returns the average field length. */
+func (stats *BasicStats) AvgFieldLength() float32 {
+	return stats.avgFieldLength
+}
+
+/* Returns the document frequency. */
+func (stats *BasicStats) DocFreq() int64 {
+	return stats.docFreq
+}
+
+/* Returns the total number of occurrences of this term across all documents. */
+func (stats *BasicStats) TotalTermFreq() int64 {
+	return stats.totalTermFreq
+}
+
+/*
+The square of the raw normalization value, the query boost.
+*/
+func (stats *BasicStats) ValueForNormalization() float32 {
+	return stats.queryBoost * stats.queryBoost
+}
+
+/*
+No normalization is done. topLevelBoost is saved in the object,
+however.
+*/
+func (stats *BasicStats) Normalize(queryNorm, topLevelBoost float32) {
+	stats.topLevelBoost = topLevelBoost
+	stats.totalBoost = stats.queryBoost * topLevelBoost
+}
+
+/* Returns the total boost. */
+func (stats *BasicStats) TotalBoost() float32 {
+	return stats.totalBoost
+}
+
+/*
+The statistics of a query term a SimilarityBase scores with: a
+BasicStats, or a struct embedding it.
+*/
+type simStats interface {
+	SimWeight
+	basic() *BasicStats
+}
+
+// search/similarities/SimilarityBase.java
+
+type SimilarityBaseSPI interface {
+	// Factory method to return a custom stats object.
+	newStats(field string, queryBoost float32) simStats
+	// Fills all member fields defined in BasicStats in stats.
+	// Subclasses can override this method to fill additional stats.
+	fillBasicStats(stats simStats, collectionStats CollectionStatistics, termStats TermStatistics)
+	// Scores the document doc. Subclasses must apply their scoring
+	// formula in this method.
+	score(stats simStats, freq, docLen float32) float32
+	// Subclasses should implement this method to explain the score.
+	// expl already contains the score, the name of the class and the
+	// doc id, as well as the term frequency and its explanation;
+	// subclasses can add additional clauses to explain details of
+	// their scoring formulae.
+	addExplanation(expl *ExplanationImpl, stats simStats, doc int, freq, docLen float32)
+	String() string
+}
+
+/*
+A subclass of Similarity that provides a simplified API for its
+descendants. Subclasses are only required to implement the score()
+and String() methods. Implementing addExplanation() is optional,
+inasmuch as SimilarityBase already provides a basic explanation of
+the score and the term frequency. However, implementers of a subclass
+are encouraged to include as much detail about the scoring method as
+possible.
+
+Note: multi-word queries such as phrase queries are scored in a
+different way than Lucene's default ranking algorithm: whereas it
+"fakes" an IDF value for the phrase as a whole (since it does not
+know it), this class instead scores phrases as a summation of the
+individual term scores.
+*/
+type SimilarityBase struct {
+	spi SimilarityBaseSPI
+	// True if overlap tokens (tokens with a position of increment of
+	// zero) are discounted from the document's length.
+	discountOverlaps bool
+}
+
+func newSimilarityBase(spi SimilarityBaseSPI) *SimilarityBase {
+	return &SimilarityBase{spi: spi, discountOverlaps: true}
+}
+
+/*
+Determines whether overlap tokens (Tokens with 0 position increment)
+are ignored when computing norm. By default this is true, meaning
+overlap tokens do not count when computing norms.
+*/
+func (sim *SimilarityBase) SetDiscountOverlaps(v bool) {
+	sim.discountOverlaps = v
+}
+
+/* Returns true if overlap tokens are discounted from the document's length. */
+func (sim *SimilarityBase) DiscountOverlaps() bool {
+	return sim.discountOverlaps
+}
+
+/* Returns 1, as the models of SimilarityBase don't coordinate. */
+func (sim *SimilarityBase) Coord(overlap, maxOverlap int) float32 {
+	return 1
+}
+
+/* Returns 1, as the models of SimilarityBase don't normalize queries. */
+func (sim *SimilarityBase) QueryNorm(valueForNormalization float32) float32 {
+	return 1
+}
+
+func (sim *SimilarityBase) computeWeight(queryBoost float32,
+	collectionStats CollectionStatistics, termStats ...TermStatistics) SimWeight {
+
+	stats := make([]SimWeight, len(termStats))
+	for i, termStat := range termStats {
+		s := sim.spi.newStats(collectionStats.field, queryBoost)
+		sim.spi.fillBasicStats(s, collectionStats, termStat)
+		stats[i] = s
+	}
+	if len(stats) == 1 {
+		return stats[0]
+	}
+	return &multiStats{stats}
+}
+
+func (sim *SimilarityBase) newStats(field string, queryBoost float32) simStats {
+	return NewBasicStats(field, queryBoost)
+}
+
+func (sim *SimilarityBase) fillBasicStats(s simStats,
+	collectionStats CollectionStatistics, termStats TermStatistics) {
+
+	assert(collectionStats.sumTotalTermFreq == -1 ||
+		collectionStats.sumTotalTermFreq >= termStats.TotalTermFreq)
+	stats := s.basic()
+	numberOfDocuments := collectionStats.maxDoc
+
+	docFreq := termStats.DocFreq
+	totalTermFreq := termStats.TotalTermFreq
+
+	// codec does not supply totalTermFreq: substitute docFreq
+	if totalTermFreq == -1 {
+		totalTermFreq = docFreq
+	}
+
+	var numberOfFieldTokens int64
+	var avgFieldLength float32
+	if sumTotalTermFreq := collectionStats.sumTotalTermFreq; sumTotalTermFreq <= 0 {
+		// field does not exist; we have to provide something if
+		// codec doesn't supply these measures, or if someone omitted
+		// frequencies for the field... negative values cause NaN/Inf
+		// for some scorers.
+		numberOfFieldTokens = docFreq
+		avgFieldLength = 1
+	} else {
+		numberOfFieldTokens = sumTotalTermFreq
+		avgFieldLength = float32(numberOfFieldTokens) / float32(numberOfDocuments)
+	}
+
+	stats.numberOfDocuments = numberOfDocuments
+	stats.numberOfFieldTokens = numberOfFieldTokens
+	stats.avgFieldLength = avgFieldLength
+	stats.docFreq = docFreq
+	stats.totalTermFreq = totalTermFreq
+}
+
+func (sim *SimilarityBase) addExplanation(expl *ExplanationImpl, stats simStats,
+	doc int, freq, docLen float32) {
+}
+
+/*
+Explains the score. The implementation here provides a basic
+explanation in the format score(name-of-similarity, doc=doc-id,
+freq=term-frequency), computed from:, and attaches the score
+(computed via the score() method) and the explanation for the term
+frequency. Subclasses content with this format may add additional
+details in addExplanation().
+*/
+func (sim *SimilarityBase) explain(stats simStats, doc int, freq Explanation, docLen float32) Explanation {
+	result := NewExplanation(sim.spi.score(stats, freq.Value(), docLen), fmt.Sprintf(
+		"score(%v, doc=%v, freq=%v), computed from:", simpleName(sim.spi), doc, freq.Value()))
+	result.AddDetail(freq)
+	sim.spi.addExplanation(result, stats, doc, freq.Value(), docLen)
+	return result
+}
+
+func (sim *SimilarityBase) simScorer(stats SimWeight, ctx *index.AtomicReaderContext) (SimScorer, error) {
+	reader := ctx.Reader().(index.AtomicReader)
+	if multi, ok := stats.(*multiStats); ok {
+		// a multi term query (e.g. phrase). return the summation,
+		// scoring almost as if it were boolean query
+		subScorers := make([]SimScorer, len(multi.subStats))
+		for i, sub := range multi.subStats {
+			basicStats := sub.(simStats)
+			norms, err := reader.NormValues(basicStats.basic().field)
+			if err != nil {
+				return nil, err
+			}
+			subScorers[i] = &basicSimScorer{sim, basicStats, norms}
+		}
+		return &multiSimScorer{subScorers}, nil
+	}
+	basicStats := stats.(simStats)
+	norms, err := reader.NormValues(basicStats.basic().field)
+	if err != nil {
+		return nil, err
+	}
+	return &basicSimScorer{sim, basicStats, norms}, nil
+}
+
+/* Norm -> document length map. */
+var similarityBaseNormTable = func() []float32 {
+	table := make([]float32, 256)
+	for i := 1; i < 256; i++ {
+		floatNorm := util.Byte315ToFloat(byte(i))
+		table[i] = 1 / (floatNorm * floatNorm)
+	}
+	table[0] = 1 / table[255] // otherwise inf
+	return table
+}()
+
+/* Encodes the document length in the same way as TFIDFSimilarity. */
+func (sim *SimilarityBase) ComputeNorm(state *index.FieldInvertState) int64 {
+	numTerms := state.Length()
+	if sim.discountOverlaps {
+		numTerms -= state.NumOverlap()
+	}
+	return sim.encodeNormValue(state.Boost(), float32(numTerms))
+}
+
+/* Decodes a normalization factor (document length) stored in an index. */
+func (sim *SimilarityBase) decodeNormValue(norm int64) float32 {
+	return similarityBaseNormTable[int(norm&0xff)] // & 0xFF maps negative bytes to positive above 127
+}
+
+/* Encodes the length to a byte via SmallFloat. */
+func (sim *SimilarityBase) encodeNormValue(boost, length float32) int64 {
+	return int64(util.FloatToByte315(boost / float32(math.Sqrt(float64(length)))))
+}
+
+/* Returns the base two logarithm of x. */
+func log2(x float64) float64 {
+	// Put this to a 'util' class if we need more of these.
+	return math.Log(x) / math.Ln2
+}
+
+/* Returns the name of the struct of v, for explanations. */
+func simpleName(v interface{}) string {
+	return reflect.Indirect(reflect.ValueOf(v)).Type().Name()
+}
+
+/*
+Delegates the score() and explain() methods to SimilarityBase's.
+Reads the document lengths from the norms of the field.
+*/
+type basicSimScorer struct {
+	owner *SimilarityBase
+	stats simStats
+	norms NumericDocValues
+}
+
+func (s *basicSimScorer) docLen(doc int) float32 {
+	if s.norms == nil {
+		return 1
+	}
+	return s.owner.decodeNormValue(s.norms(doc))
+}
+
+func (s *basicSimScorer) Score(doc int, freq float32) float32 {
+	// We have to supply something in case norms are omitted
+	return s.owner.spi.score(s.stats, freq, s.docLen(doc))
+}
+
+func (s *basicSimScorer) explain(doc int, freq Explanation) Explanation {
+	return s.owner.explain(s.stats, doc, freq, s.docLen(doc))
+}
+
+func (s *basicSimScorer) computeSlopFactor(distance int) float32 {
+	return 1 / float32(distance+1)
+}
+
+// search/similarities/MultiSimilarity.java
+
+/* The stats of the terms of a multi term query, e.g. a phrase. */
+type multiStats struct {
+	subStats []SimWeight
+}
+
+func (stats *multiStats) ValueForNormalization() float32 {
+	var sum float32
+	for _, stat := range stats.subStats {
+		sum += stat.ValueForNormalization()
+	}
+	return sum / float32(len(stats.subStats))
+}
+
+func (stats *multiStats) Normalize(queryNorm, topLevelBoost float32) {
+	for _, stat := range stats.subStats {
+		stat.Normalize(queryNorm, topLevelBoost)
+	}
+}
+
+/* Scores a multi term query as the sum of the scores of its terms. */
+type multiSimScorer struct {
+	subScorers []SimScorer
+}
+
+func (s *multiSimScorer) Score(doc int, freq float32) float32 {
+	var sum float32
+	for _, subScorer := range s.subScorers {
+		sum += subScorer.Score(doc, freq)
+	}
+	return sum
+}
+
+func (s *multiSimScorer) explain(doc int, freq Explanation) Explanation {
+	expl := NewExplanation(s.Score(doc, freq.Value()), "sum of:")
+	for _, subScorer := range s.subScorers {
+		expl.AddDetail(subScorer.explain(doc, freq))
+	}
+	return expl
+}
+
+func (s *multiSimScorer) computeSlopFactor(distance int) float32 {
+	return s.subScorers[0].computeSlopFactor(distance)
+}
@@ -0,0 +1,299 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// search/Sort.java
+
+/*
+Encapsulates sort criteria for returned hits.
+
+The fields used to determine sort order must be carefully chosen.
+Documents must contain a single term in such a field, and the value
+of the term should indicate the document's relative position in a
+given sort order. The field must be indexed, but should not be
+tokenized, and does not need to be stored (unless you happen to want
+it back with the rest of your document data). Alternatively the
+field may be indexed with NUMERIC or SORTED doc values, in which case
+those values are used instead of uninverting the indexed terms.
+
+Valid types of values are: int32, int64, float32, float64 and
+strings. Strings are compared by their unicode sort order.
+*/
+type Sort struct {
+	fields []*SortField
+}
+
+/*
+Represents sorting by computed relevance. Using this sort criteria
+returns the same results as calling IndexSearcher.Search() without a
+sort criteria, only with slightly more overhead.
+*/
+var SORT_RELEVANCE = NewSort(FIELD_SCORE)
+
+/* Represents sorting by index order. */
+var SORT_INDEXORDER = NewSort(FIELD_DOC)
+
+/*
+Sets the sort to the given criteria in succession: the first
+SortField is checked first, but if it produces a tie, then the
+second SortField is used to break the tie, etc. Finally, if there is
+still a tie after all SortFields are checked, the internal Lucene
+docid is used to break it.
+*/
+func NewSort(fields ...*SortField) *Sort {
+	assert2(len(fields) > 0, "There must be at least 1 sort field")
+	return &Sort{fields}
+}
+
+/* Representation of the sort criteria. */
+func (s *Sort) Fields() []*SortField {
+	return s.fields
+}
+
+/* Returns true if the relevance score is needed to sort documents. */
+func (s *Sort) NeedsScores() bool {
+	for _, f := range s.fields {
+		if f.needsScores() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sort) String() string {
+	var buf bytes.Buffer
+	for i, f := range s.fields {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(f.String())
+	}
+	return buf.String()
+}
+
+// search/SortField.java
+
+/* Specifies the type of the terms to be sorted, or special types such as relevance or document order. */
+type SortFieldType int
+
+const (
+	// Sort by document score (relevance). Sort values are float32
+	// and higher values are at the front.
+	SORT_FIELD_SCORE = SortFieldType(iota)
+	// Sort by document number (index order). Sort values are int and
+	// lower values are at the front.
+	SORT_FIELD_DOC
+	// Sort using term values as strings. Sort values are []byte and
+	// lower values are at the front.
+	SORT_FIELD_STRING
+	// Sort using term values as encoded int32. Sort values are int32
+	// and lower values are at the front.
+	SORT_FIELD_INT
+	// Sort using term values as encoded float32. Sort values are
+	// float32 and lower values are at the front.
+	SORT_FIELD_FLOAT
+	// Sort using term values as encoded int64. Sort values are int64
+	// and lower values are at the front.
+	SORT_FIELD_LONG
+	// Sort using term values as encoded float64. Sort values are
+	// float64 and lower values are at the front.
+	SORT_FIELD_DOUBLE
+)
+
+func (t SortFieldType) String() string {
+	switch t {
+	case SORT_FIELD_SCORE:
+		return "SCORE"
+	case SORT_FIELD_DOC:
+		return "DOC"
+	case SORT_FIELD_STRING:
+		return "STRING"
+	case SORT_FIELD_INT:
+		return "INT"
+	case SORT_FIELD_FLOAT:
+		return "FLOAT"
+	case SORT_FIELD_LONG:
+		return "LONG"
+	case SORT_FIELD_DOUBLE:
+		return "DOUBLE"
+	}
+	return fmt.Sprintf("SortFieldType(%d)", int(t))
+}
+
+/*
+Stores information about how to sort documents by terms in an
+individual field. Fields must be indexed in order to sort by them.
+*/
+type SortField struct {
+	field        string
+	_type        SortFieldType
+	reverse      bool
+	parser       FieldCacheParser
+	missingValue interface{}
+}
+
+/* Represents sorting by document score (relevance). */
+var FIELD_SCORE = NewSortField("", SORT_FIELD_SCORE, false)
+
+/* Represents sorting by document number (index order). */
+var FIELD_DOC = NewSortField("", SORT_FIELD_DOC, false)
+
+/*
+Pass this to SetMissingValue() to have missing string values sort
+first.
+*/
+var STRING_FIRST = &struct{ name string }{"SortField.STRING_FIRST"}
+
+/*
+Pass this to SetMissingValue() to have missing string values sort
+last.
+*/
+var STRING_LAST = &struct{ name string }{"SortField.STRING_LAST"}
+
+/*
+Creates a sort, possibly in reverse, by terms in the given field with
+the type of term values explicitly given. field can only be empty
+when type is SCORE or DOC.
+*/
+func NewSortField(field string, typ SortFieldType, reverse bool) *SortField {
+	ans := &SortField{_type: typ, reverse: reverse}
+	if field == "" {
+		assert2(typ == SORT_FIELD_SCORE || typ == SORT_FIELD_DOC,
+			"field can only be empty when type is SCORE or DOC")
+	} else {
+		ans.field = field
+	}
+	return ans
+}
+
+/*
+Creates a sort, possibly in reverse, by terms in the given field,
+parsed to numeric values using a custom parser. The parser must be
+one of IntParser, LongParser, FloatParser or DoubleParser, which also
+determines the type of the sort.
+*/
+func NewSortFieldWithParser(field string, parser FieldCacheParser, reverse bool) *SortField {
+	switch parser.(type) {
+	case IntParser:
+		return &SortField{field: field, _type: SORT_FIELD_INT, reverse: reverse, parser: parser}
+	case FloatParser:
+		return &SortField{field: field, _type: SORT_FIELD_FLOAT, reverse: reverse, parser: parser}
+	case LongParser:
+		return &SortField{field: field, _type: SORT_FIELD_LONG, reverse: reverse, parser: parser}
+	case DoubleParser:
+		return &SortField{field: field, _type: SORT_FIELD_DOUBLE, reverse: reverse, parser: parser}
+	}
+	panic(fmt.Sprintf("Parser instance does not subclass existing numeric parser from FieldCache (got %v)", parser))
+}
+
+/*
+Sets the value used for documents missing this field. For numeric
+sorts the value must match the sort type (int32, int64, float32 or
+float64); for string sorts it must be STRING_FIRST or STRING_LAST.
+*/
+func (f *SortField) SetMissingValue(missingValue interface{}) {
+	switch f._type {
+	case SORT_FIELD_STRING:
+		assert2(missingValue == STRING_FIRST || missingValue == STRING_LAST,
+			"For STRING type, missing value must be either STRING_FIRST or STRING_LAST")
+	case SORT_FIELD_INT:
+		_, ok := missingValue.(int32)
+		assert2(ok, "Missing value must be int32 for INT sort type")
+	case SORT_FIELD_LONG:
+		_, ok := missingValue.(int64)
+		assert2(ok, "Missing value must be int64 for LONG sort type")
+	case SORT_FIELD_FLOAT:
+		_, ok := missingValue.(float32)
+		assert2(ok, "Missing value must be float32 for FLOAT sort type")
+	case SORT_FIELD_DOUBLE:
+		_, ok := missingValue.(float64)
+		assert2(ok, "Missing value must be float64 for DOUBLE sort type")
+	default:
+		panic(fmt.Sprintf("Missing value only works for numeric or STRING types (got %v)", f._type))
+	}
+	f.missingValue = missingValue
+}
+
+/* Returns the name of the field. Could return "" if the sort is by SCORE or DOC. */
+func (f *SortField) Field() string {
+	return f.field
+}
+
+/* Returns the type of contents in the field. */
+func (f *SortField) Type() SortFieldType {
+	return f._type
+}
+
+/* Returns the instance of a FieldCache parser that fits to the given sort type. */
+func (f *SortField) Parser() FieldCacheParser {
+	return f.parser
+}
+
+/* Returns whether the sort should be reversed. */
+func (f *SortField) Reverse() bool {
+	return f.reverse
+}
+
+func (f *SortField) needsScores() bool {
+	return f._type == SORT_FIELD_SCORE
+}
+
+func (f *SortField) String() string {
+	var buf bytes.Buffer
+	switch f._type {
+	case SORT_FIELD_SCORE:
+		buf.WriteString("<score>")
+	case SORT_FIELD_DOC:
+		buf.WriteString("<doc>")
+	case SORT_FIELD_STRING:
+		fmt.Fprintf(&buf, "<string: \"%v\">", f.field)
+	case SORT_FIELD_INT:
+		fmt.Fprintf(&buf, "<int: \"%v\">", f.field)
+	case SORT_FIELD_LONG:
+		fmt.Fprintf(&buf, "<long: \"%v\">", f.field)
+	case SORT_FIELD_FLOAT:
+		fmt.Fprintf(&buf, "<float: \"%v\">", f.field)
+	case SORT_FIELD_DOUBLE:
+		fmt.Fprintf(&buf, "<double: \"%v\">", f.field)
+	default:
+		fmt.Fprintf(&buf, "<???: \"%v\">", f.field)
+	}
+	if f.reverse {
+		buf.WriteString("!")
+	}
+	if f.missingValue != nil {
+		fmt.Fprintf(&buf, " missingValue=%v", f.missingValue)
+	}
+	return buf.String()
+}
+
+/*
+Returns the FieldComparator to use for sorting.
+
+numHits is the number of top hits the queue will store; sortPos is
+the position of this SortField within Sort. The comparator is
+primary if sortPos == 0, secondary if sortPos == 1, etc. Some
+comparators can optimize themselves when they are the primary sort.
+*/
+func (f *SortField) comparator(numHits, sortPos int) FieldComparator {
+	switch f._type {
+	case SORT_FIELD_SCORE:
+		return newRelevanceComparator(numHits)
+	case SORT_FIELD_DOC:
+		return newDocComparator(numHits)
+	case SORT_FIELD_INT:
+		return newIntComparator(numHits, f.field, f.parser, f.missingValue)
+	case SORT_FIELD_FLOAT:
+		return newFloatComparator(numHits, f.field, f.parser, f.missingValue)
+	case SORT_FIELD_LONG:
+		return newLongComparator(numHits, f.field, f.parser, f.missingValue)
+	case SORT_FIELD_DOUBLE:
+		return newDoubleComparator(numHits, f.field, f.parser, f.missingValue)
+	case SORT_FIELD_STRING:
+		return newTermOrdValComparator(numHits, f.field, f.missingValue == STRING_LAST)
+	}
+	panic(fmt.Sprintf("Illegal sort type: %v", f._type))
+}
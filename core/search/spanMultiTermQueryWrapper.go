@@ -0,0 +1,209 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/spans/SpanMultiTermQueryWrapper.java
+
+/* Implemented by every query embedding a MultiTermQuery. */
+type multiTermQuery interface {
+	Query
+	multiTermQuery() *MultiTermQuery
+}
+
+/*
+Wraps any MultiTermQuery as a SpanQuery, so it can be nested within
+other SpanQuery types. The query is rewritten by default to a
+SpanOrQuery containing the expanded terms, but this can be customized.
+
+Example:
+
+	wildcard := NewWildcardQuery(index.NewTerm("field", "bro?n"))
+	spanWildcard := NewSpanMultiTermQueryWrapper(wildcard)
+	// do something with spanWildcard, such as use it in a SpanFirstQuery
+
+The wrapped query shares its rewrite method with the wrapper, thus
+setting it on either of them affects both.
+*/
+type SpanMultiTermQueryWrapper struct {
+	*AbstractQuery
+	query multiTermQuery
+}
+
+/*
+Create a new SpanMultiTermQueryWrapper. The query must embed a
+MultiTermQuery, e.g. PrefixQuery, WildcardQuery or FuzzyQuery.
+
+NOTE: This will set RewriteMethod on the wrapped query, changing its
+rewrite method to a suitable one for spans. Be sure to not change the
+rewrite method on the wrapped query afterwards! Doing so will throw
+errors on rewriting this query!
+*/
+func NewSpanMultiTermQueryWrapper(query Query) *SpanMultiTermQueryWrapper {
+	mtq, ok := query.(multiTermQuery)
+	assert2(ok, "%v is not a MultiTermQuery", query)
+	ans := &SpanMultiTermQueryWrapper{query: mtq}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+
+	if method, ok := mtq.multiTermQuery().RewriteMethod().(topTermsRewrite); ok {
+		ans.SetRewriteMethod(NewTopTermsSpanBooleanQueryRewrite(method.Size()))
+	} else {
+		ans.SetRewriteMethod(SCORING_SPAN_QUERY_REWRITE)
+	}
+	return ans
+}
+
+/* Expert: returns the rewrite method */
+func (q *SpanMultiTermQueryWrapper) RewriteMethod() SpanRewriteMethod {
+	method, ok := q.query.multiTermQuery().RewriteMethod().(SpanRewriteMethod)
+	assert2(ok, "You can only use SpanMultiTermQueryWrapper with a suitable SpanRewriteMethod.")
+	return method
+}
+
+/*
+Expert: sets the rewrite method. This only makes sense to be a span
+rewrite method.
+*/
+func (q *SpanMultiTermQueryWrapper) SetRewriteMethod(method SpanRewriteMethod) {
+	q.query.multiTermQuery().SetRewriteMethod(method)
+}
+
+/* Returns the wrapped query */
+func (q *SpanMultiTermQueryWrapper) WrappedQuery() Query {
+	return q.query
+}
+
+func (q *SpanMultiTermQueryWrapper) Field() string {
+	return q.query.multiTermQuery().Field()
+}
+
+/* The wrapper holds no term until it is rewritten. */
+func (q *SpanMultiTermQueryWrapper) ExtractTerms(terms map[string]*index.Term) {}
+
+func (q *SpanMultiTermQueryWrapper) Spans(context *index.AtomicReaderContext, acceptDocs util.Bits,
+	termContexts map[string]*index.TermContext) (Spans, error) {
+
+	return nil, errors.New("Query should have been rewritten")
+}
+
+func (q *SpanMultiTermQueryWrapper) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newSpanWeight(q, searcher)
+}
+
+func (q *SpanMultiTermQueryWrapper) ToString(field string) string {
+	return fmt.Sprintf("SpanMultiTermQueryWrapper(%v)%v",
+		q.query.ToString(field), boostString(q.boost))
+}
+
+func (q *SpanMultiTermQueryWrapper) Rewrite(reader index.IndexReader) (Query, error) {
+	rewritten, err := q.query.Rewrite(reader)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rewritten.(SpanQuery); !ok {
+		return nil, errors.New(
+			"You can only use SpanMultiTermQueryWrapper with a suitable SpanRewriteMethod.")
+	}
+	rewritten.SetBoost(rewritten.Boost() * q.Boost()) // multiply boost
+	return rewritten, nil
+}
+
+/*
+Abstract type that defines how the query is rewritten to a SpanQuery.
+*/
+type SpanRewriteMethod interface {
+	RewriteMethod
+	RewriteSpan(reader index.IndexReader, query *MultiTermQuery) (SpanQuery, error)
+}
+
+/* Rewrite methods keeping only the top scoring terms. */
+type topTermsRewrite interface {
+	Size() int
+}
+
+/*
+A rewrite method that first translates each term into a SpanTermQuery
+in a SpanOrQuery, and keeps the scores as computed by the query.
+*/
+var SCORING_SPAN_QUERY_REWRITE = SpanRewriteMethod(scoringSpanQueryRewrite(0))
+
+type scoringSpanQueryRewrite int
+
+func (r scoringSpanQueryRewrite) Rewrite(reader index.IndexReader,
+	query *MultiTermQuery) (Query, error) {
+
+	return r.RewriteSpan(reader, query)
+}
+
+func (r scoringSpanQueryRewrite) RewriteSpan(reader index.IndexReader,
+	query *MultiTermQuery) (SpanQuery, error) {
+
+	result := NewSpanOrQuery()
+	// we accept all terms as SpanOrQuery has no limits
+	col := newTermStatesCollector(false)
+	if err := collectTerms(reader, query, col); err != nil {
+		return nil, err
+	}
+	for _, term := range col.sortedTerms() {
+		// TODO: would be nice to not lose term-state here.
+		q := NewSpanTermQuery(index.NewTermFromBytes(query.field, []byte(term)))
+		q.SetBoost(query.Boost() * col.boosts[term])
+		result.AddClause(q)
+	}
+	return result, nil
+}
+
+func (r scoringSpanQueryRewrite) String() string {
+	return "SCORING_SPAN_QUERY_REWRITE"
+}
+
+/*
+A rewrite method that first translates each term into a SpanTermQuery
+in a SpanOrQuery, and keeps the scores as computed by the query.
+
+This rewrite method only uses the top scoring terms so it will not
+overflow the boolean max clause count.
+*/
+type TopTermsSpanBooleanQueryRewrite struct {
+	size int
+}
+
+/* Create a TopTermsSpanBooleanQueryRewrite for at most size terms. */
+func NewTopTermsSpanBooleanQueryRewrite(size int) *TopTermsSpanBooleanQueryRewrite {
+	return &TopTermsSpanBooleanQueryRewrite{size}
+}
+
+/* Return the maximum priority queue size */
+func (r *TopTermsSpanBooleanQueryRewrite) Size() int {
+	return r.size
+}
+
+func (r *TopTermsSpanBooleanQueryRewrite) Rewrite(reader index.IndexReader,
+	query *MultiTermQuery) (Query, error) {
+
+	return r.RewriteSpan(reader, query)
+}
+
+func (r *TopTermsSpanBooleanQueryRewrite) RewriteSpan(reader index.IndexReader,
+	query *MultiTermQuery) (SpanQuery, error) {
+
+	scoreTerms, err := collectTopTerms(reader, query, r.size)
+	if err != nil {
+		return nil, err
+	}
+	result := NewSpanOrQuery()
+	for _, st := range scoreTerms {
+		q := NewSpanTermQuery(index.NewTermFromBytes(query.field, st.bytes))
+		q.SetBoost(query.Boost() * st.boost)
+		result.AddClause(q)
+	}
+	return result, nil
+}
+
+func (r *TopTermsSpanBooleanQueryRewrite) String() string {
+	return fmt.Sprintf("TopTermsSpanBooleanQueryRewrite(%v)", r.size)
+}
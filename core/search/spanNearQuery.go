@@ -0,0 +1,879 @@
+package search
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+	"sort"
+)
+
+// search/spans/SpanNearQuery.java
+
+/*
+Matches spans which are near one another. One can specify slop, the
+maximum number of intervening unmatched positions, as well as whether
+matches are required to be in-order.
+*/
+type SpanNearQuery struct {
+	*AbstractQuery
+	clauses         []SpanQuery
+	slop            int
+	inOrder         bool
+	field           string
+	collectPayloads bool
+}
+
+/* Calls NewSpanNearQueryWithPayloads(clauses, slop, inOrder, true). */
+func NewSpanNearQuery(clauses []SpanQuery, slop int, inOrder bool) *SpanNearQuery {
+	return NewSpanNearQueryWithPayloads(clauses, slop, inOrder, true)
+}
+
+/*
+Construct a SpanNearQuery. Matches spans matching a span from each
+clause, with up to slop total unmatched positions between them. When
+inOrder is true, the spans from each clause must be ordered as in
+clauses and must be non-overlapping.
+*/
+func NewSpanNearQueryWithPayloads(clauses []SpanQuery, slop int,
+	inOrder, collectPayloads bool) *SpanNearQuery {
+
+	ans := &SpanNearQuery{
+		clauses:         make([]SpanQuery, 0, len(clauses)),
+		slop:            slop,
+		inOrder:         inOrder,
+		collectPayloads: collectPayloads,
+	}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	for _, clause := range clauses {
+		if ans.field == "" { // check field
+			ans.field = clause.Field()
+		} else {
+			assert2(clause.Field() == "" || clause.Field() == ans.field,
+				"Clauses must have same field.")
+		}
+		ans.clauses = append(ans.clauses, clause)
+	}
+	return ans
+}
+
+/* Return the clauses whose spans are matched. */
+func (q *SpanNearQuery) Clauses() []SpanQuery {
+	return q.clauses
+}
+
+/* Return the maximum number of intervening unmatched positions permitted. */
+func (q *SpanNearQuery) Slop() int {
+	return q.slop
+}
+
+/* Return true if matches are required to be in-order. */
+func (q *SpanNearQuery) IsInOrder() bool {
+	return q.inOrder
+}
+
+func (q *SpanNearQuery) Field() string {
+	return q.field
+}
+
+func (q *SpanNearQuery) ExtractTerms(terms map[string]*index.Term) {
+	for _, clause := range q.clauses {
+		clause.ExtractTerms(terms)
+	}
+}
+
+func (q *SpanNearQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newSpanWeight(q, searcher)
+}
+
+func (q *SpanNearQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	buf.WriteString("spanNear([")
+	for i, clause := range q.clauses {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(clause.ToString(field))
+	}
+	fmt.Fprintf(&buf, "], %v, %v)", q.slop, q.inOrder)
+	buf.WriteString(boostString(q.boost))
+	return buf.String()
+}
+
+func (q *SpanNearQuery) Spans(context *index.AtomicReaderContext, acceptDocs util.Bits,
+	termContexts map[string]*index.TermContext) (Spans, error) {
+
+	switch len(q.clauses) {
+	case 0: // optimize 0-clause case
+		return NewSpanOrQuery().Spans(context, acceptDocs, termContexts)
+	case 1: // optimize 1-clause case
+		return q.clauses[0].Spans(context, acceptDocs, termContexts)
+	}
+	if q.inOrder {
+		return newNearSpansOrdered(q, context, acceptDocs, termContexts, q.collectPayloads)
+	}
+	return newNearSpansUnordered(q, context, acceptDocs, termContexts)
+}
+
+func (q *SpanNearQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	clauses, changed, err := rewriteSpanClauses(reader, q.clauses)
+	if err != nil || !changed {
+		return q, err
+	}
+	clone := NewSpanNearQueryWithPayloads(clauses, q.slop, q.inOrder, q.collectPayloads)
+	clone.SetBoost(q.Boost())
+	return clone, nil
+}
+
+/*
+Rewrites each of the clauses, returning a new slice and true if any
+of them rewrote to a different query.
+*/
+func rewriteSpanClauses(reader index.IndexReader, clauses []SpanQuery) ([]SpanQuery, bool, error) {
+	var rewritten []SpanQuery
+	for i, clause := range clauses {
+		query, err := rewriteSpanQuery(reader, clause)
+		if err != nil {
+			return nil, false, err
+		}
+		if query != clause { // clause rewrote: must clone
+			if rewritten == nil {
+				rewritten = append([]SpanQuery(nil), clauses...)
+			}
+			rewritten[i] = query
+		}
+	}
+	if rewritten == nil {
+		return clauses, false, nil
+	}
+	return rewritten, true, nil
+}
+
+func rewriteSpanQuery(reader index.IndexReader, clause SpanQuery) (SpanQuery, error) {
+	query, err := clause.Rewrite(reader)
+	if err != nil {
+		return nil, err
+	}
+	ans, ok := query.(SpanQuery)
+	assert2(ok, "%v should rewrite to a SpanQuery, but got %v", clause, query)
+	return ans, nil
+}
+
+// search/spans/NearSpansOrdered.java
+
+/*
+A Spans that is formed from the ordered subspans of a SpanNearQuery
+where the subspans do not overlap and have a maximum slop between
+them.
+
+The formed spans only contains minimum slop matches. The matching
+slop is computed from the distance(s) between the non overlapping
+matching Spans.
+
+Successive matches are always formed from the successive Spans of
+the SpanNearQuery.
+
+The formed spans may contain overlaps when the slop is at least 1.
+For example, when querying using
+
+	t1 t2 t3
+
+with slop at least 1, the fragment:
+
+	t1 t2 t1 t3 t2 t3
+
+matches twice:
+
+	t1 t2 .. t3
+	      t1 .. t2 t3
+
+Expert: only public for subclassing. Most implementation should not
+need this class.
+*/
+type NearSpansOrdered struct {
+	allowedSlop int
+	firstTime   bool
+	more        bool
+
+	// The spans in the same order as the SpanNearQuery
+	subSpans []Spans
+
+	// Indicates that all subSpans have same doc()
+	inSameDoc bool
+
+	matchDoc     int
+	matchStart   int
+	matchEnd     int
+	matchPayload [][]byte
+
+	subSpansByDoc []Spans
+
+	query           *SpanNearQuery // kept for String() only
+	collectPayloads bool
+}
+
+func newNearSpansOrdered(query *SpanNearQuery, context *index.AtomicReaderContext,
+	acceptDocs util.Bits, termContexts map[string]*index.TermContext,
+	collectPayloads bool) (*NearSpansOrdered, error) {
+
+	assert2(len(query.clauses) >= 2, "Less than 2 clauses: %v", query)
+	ans := &NearSpansOrdered{
+		allowedSlop:     query.slop,
+		firstTime:       true,
+		subSpans:        make([]Spans, len(query.clauses)),
+		matchDoc:        -1,
+		matchStart:      -1,
+		matchEnd:        -1,
+		subSpansByDoc:   make([]Spans, len(query.clauses)),
+		query:           query,
+		collectPayloads: collectPayloads,
+	}
+	for i, clause := range query.clauses {
+		var err error
+		if ans.subSpans[i], err = clause.Spans(context, acceptDocs, termContexts); err != nil {
+			return nil, err
+		}
+		ans.subSpansByDoc[i] = ans.subSpans[i] // used in toSameDoc()
+	}
+	return ans, nil
+}
+
+func (s *NearSpansOrdered) Doc() int   { return s.matchDoc }
+func (s *NearSpansOrdered) Start() int { return s.matchStart }
+func (s *NearSpansOrdered) End() int   { return s.matchEnd }
+
+func (s *NearSpansOrdered) SubSpans() []Spans {
+	return s.subSpans
+}
+
+func (s *NearSpansOrdered) Payload() ([][]byte, error) {
+	return s.matchPayload, nil
+}
+
+func (s *NearSpansOrdered) IsPayloadAvailable() (bool, error) {
+	return len(s.matchPayload) > 0, nil
+}
+
+func (s *NearSpansOrdered) Cost() int64 {
+	minCost := int64(math.MaxInt64)
+	for _, spans := range s.subSpans {
+		if cost := spans.Cost(); cost < minCost {
+			minCost = cost
+		}
+	}
+	return minCost
+}
+
+func (s *NearSpansOrdered) Next() (bool, error) {
+	if s.firstTime {
+		s.firstTime = false
+		for _, spans := range s.subSpans {
+			ok, err := spans.Next()
+			if err != nil || !ok {
+				s.more = false
+				return false, err
+			}
+		}
+		s.more = true
+	}
+	if s.collectPayloads {
+		s.matchPayload = nil
+	}
+	return s.advanceAfterOrdered()
+}
+
+func (s *NearSpansOrdered) SkipTo(target int) (bool, error) {
+	if s.firstTime {
+		s.firstTime = false
+		for _, spans := range s.subSpans {
+			ok, err := spans.SkipTo(target)
+			if err != nil || !ok {
+				s.more = false
+				return false, err
+			}
+		}
+		s.more = true
+	} else if s.more && s.subSpans[0].Doc() < target {
+		ok, err := s.subSpans[0].SkipTo(target)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			s.more = false
+			return false, nil
+		}
+		s.inSameDoc = false
+	}
+	if s.collectPayloads {
+		s.matchPayload = nil
+	}
+	return s.advanceAfterOrdered()
+}
+
+/*
+Advances the subSpans to just after an ordered match with a minimum
+slop that is smaller than the slop allowed by the SpanNearQuery.
+Returns true iff there is such a match.
+*/
+func (s *NearSpansOrdered) advanceAfterOrdered() (bool, error) {
+	for s.more {
+		if !s.inSameDoc {
+			ok, err := s.toSameDoc()
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		ok, err := s.stretchToOrder()
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			if ok, err = s.shrinkToAfterShortestMatch(); err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+	return false, nil // no more matches
+}
+
+type spansByDoc []Spans
+
+func (a spansByDoc) Len() int           { return len(a) }
+func (a spansByDoc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a spansByDoc) Less(i, j int) bool { return a[i].Doc() < a[j].Doc() }
+
+/* Advance the subSpans to the same document */
+func (s *NearSpansOrdered) toSameDoc() (bool, error) {
+	sort.Stable(spansByDoc(s.subSpansByDoc))
+	firstIndex := 0
+	maxDoc := s.subSpansByDoc[len(s.subSpansByDoc)-1].Doc()
+	for s.subSpansByDoc[firstIndex].Doc() != maxDoc {
+		ok, err := s.subSpansByDoc[firstIndex].SkipTo(maxDoc)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			s.more = false
+			s.inSameDoc = false
+			return false, nil
+		}
+		maxDoc = s.subSpansByDoc[firstIndex].Doc()
+		if firstIndex++; firstIndex == len(s.subSpansByDoc) {
+			firstIndex = 0
+		}
+	}
+	for _, spans := range s.subSpansByDoc {
+		assert2(spans.Doc() == maxDoc, "NearSpansOrdered.toSameDoc() spans %v\n"+
+			"  doc %v != maxDoc %v", spans, spans.Doc(), maxDoc)
+	}
+	s.inSameDoc = true
+	return true, nil
+}
+
+/*
+Check whether two Spans in the same document are ordered.
+
+Returns true iff spans1 starts before spans2 or the spans start at
+the same position, and spans1 ends before spans2.
+*/
+func docSpansOrdered(spans1, spans2 Spans) bool {
+	assert2(spans1.Doc() == spans2.Doc(), "doc1 %v != doc2 %v", spans1.Doc(), spans2.Doc())
+	return docSpanPositionsOrdered(spans1.Start(), spans1.End(), spans2.Start(), spans2.End())
+}
+
+/*
+Like docSpansOrdered(Spans, Spans), but use the spans starts and ends
+as parameters.
+*/
+func docSpanPositionsOrdered(start1, end1, start2, end2 int) bool {
+	if start1 == start2 {
+		return end1 < end2
+	}
+	return start1 < start2
+}
+
+/*
+Order the subSpans within the same document by advancing all later
+spans after the previous one.
+*/
+func (s *NearSpansOrdered) stretchToOrder() (bool, error) {
+	s.matchDoc = s.subSpans[0].Doc()
+	for i := 1; s.inSameDoc && i < len(s.subSpans); i++ {
+		for !docSpansOrdered(s.subSpans[i-1], s.subSpans[i]) {
+			ok, err := s.subSpans[i].Next()
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				s.inSameDoc = false
+				s.more = false
+				break
+			} else if s.matchDoc != s.subSpans[i].Doc() {
+				s.inSameDoc = false
+				break
+			}
+		}
+	}
+	return s.inSameDoc, nil
+}
+
+/*
+The subSpans are ordered in the same doc, so there is a possible
+match. Compute the slop while making the match as short as possible
+by advancing all subSpans except the last one in reverse order.
+*/
+func (s *NearSpansOrdered) shrinkToAfterShortestMatch() (bool, error) {
+	last := s.subSpans[len(s.subSpans)-1]
+	s.matchStart = last.Start()
+	s.matchEnd = last.End()
+	var possibleMatchPayloads [][]byte
+	if s.collectPayloads {
+		payload, err := s.availablePayload(last)
+		if err != nil {
+			return false, err
+		}
+		possibleMatchPayloads = append(possibleMatchPayloads, payload...)
+	}
+
+	var possiblePayload [][]byte
+
+	matchSlop := 0
+	lastStart, lastEnd := s.matchStart, s.matchEnd
+	for i := len(s.subSpans) - 2; i >= 0; i-- {
+		prevSpans := s.subSpans[i]
+		if s.collectPayloads {
+			payload, err := s.availablePayload(prevSpans)
+			if err != nil {
+				return false, err
+			}
+			if payload != nil {
+				possiblePayload = payload
+			}
+		}
+
+		prevStart, prevEnd := prevSpans.Start(), prevSpans.End()
+		for { // Advance prevSpans until after (lastStart, lastEnd)
+			ok, err := prevSpans.Next()
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				s.inSameDoc = false
+				s.more = false
+				break // Check remaining subSpans for final match.
+			} else if s.matchDoc != prevSpans.Doc() {
+				s.inSameDoc = false // The last subSpans is not advanced here.
+				break               // Check remaining subSpans for last match in this document.
+			} else {
+				ppStart, ppEnd := prevSpans.Start(), prevSpans.End()
+				if !docSpanPositionsOrdered(ppStart, ppEnd, lastStart, lastEnd) {
+					break // Check remaining subSpans.
+				}
+				// prevSpans still before (lastStart, lastEnd)
+				prevStart, prevEnd = ppStart, ppEnd
+				if s.collectPayloads {
+					payload, err := s.availablePayload(prevSpans)
+					if err != nil {
+						return false, err
+					}
+					if payload != nil {
+						possiblePayload = payload
+					}
+				}
+			}
+		}
+
+		if s.collectPayloads && possiblePayload != nil {
+			possibleMatchPayloads = append(possibleMatchPayloads, possiblePayload...)
+		}
+
+		assert(prevStart <= s.matchStart)
+		if s.matchStart > prevEnd { // Only non overlapping spans add to slop.
+			matchSlop += s.matchStart - prevEnd
+		}
+
+		// Do not break on (matchSlop > allowedSlop) here to make sure
+		// that subSpans[0] is advanced after the match, if any.
+		s.matchStart = prevStart
+		lastStart, lastEnd = prevStart, prevEnd
+	}
+
+	match := matchSlop <= s.allowedSlop // ordered and allowed slop
+
+	if s.collectPayloads && match && len(possibleMatchPayloads) > 0 {
+		s.matchPayload = append(s.matchPayload, possibleMatchPayloads...)
+	}
+	return match, nil
+}
+
+/* Returns a copy of the payload of spans, or nil if there is none. */
+func (s *NearSpansOrdered) availablePayload(spans Spans) ([][]byte, error) {
+	ok, err := spans.IsPayloadAvailable()
+	if err != nil || !ok {
+		return nil, err
+	}
+	payload, err := spans.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return append([][]byte{}, payload...), nil
+}
+
+func (s *NearSpansOrdered) String() string {
+	var pos string
+	switch {
+	case s.firstTime:
+		pos = "START"
+	case s.more:
+		pos = fmt.Sprintf("%v:%v-%v", s.Doc(), s.Start(), s.End())
+	default:
+		pos = "END"
+	}
+	return fmt.Sprintf("NearSpansOrdered(%v)@%v", s.query, pos)
+}
+
+// search/spans/NearSpansUnordered.java
+
+/*
+Similar to NearSpansOrdered, but for the unordered case.
+
+Expert: only public for subclassing. Most implementation should not
+need this class.
+*/
+type NearSpansUnordered struct {
+	query *SpanNearQuery
+
+	ordered  []*spansCell // spans in query order
+	subSpans []Spans
+	slop     int // from query
+
+	first *spansCell // linked list of spans
+	last  *spansCell // sorted by doc only
+
+	totalLength int // sum of current lengths
+
+	queue *PriorityQueue // sorted queue of spans
+	max   *spansCell     // max element in queue
+
+	more      bool // true iff not done
+	firstTime bool // true before first Next()
+}
+
+func newNearSpansUnordered(query *SpanNearQuery, context *index.AtomicReaderContext,
+	acceptDocs util.Bits, termContexts map[string]*index.TermContext) (*NearSpansUnordered, error) {
+
+	ans := &NearSpansUnordered{
+		query:     query,
+		slop:      query.slop,
+		ordered:   make([]*spansCell, len(query.clauses)),
+		subSpans:  make([]Spans, len(query.clauses)),
+		queue:     new(PriorityQueue),
+		more:      true,
+		firstTime: true,
+	}
+	ans.queue.less = func(i, j int) bool {
+		spans1, spans2 := ans.queue.items[i].(*spansCell), ans.queue.items[j].(*spansCell)
+		if spans1.Doc() == spans2.Doc() {
+			return docSpansOrdered(spans1, spans2)
+		}
+		return spans1.Doc() < spans2.Doc()
+	}
+	for i, clause := range query.clauses {
+		spans, err := clause.Spans(context, acceptDocs, termContexts)
+		if err != nil {
+			return nil, err
+		}
+		ans.ordered[i] = &spansCell{owner: ans, spans: spans, length: -1, index: i}
+		ans.subSpans[i] = spans
+	}
+	return ans, nil
+}
+
+/* Wraps a Spans, and can be used to form a linked list. */
+type spansCell struct {
+	owner  *NearSpansUnordered
+	spans  Spans
+	next   *spansCell
+	length int
+	index  int
+}
+
+func (c *spansCell) Next() (bool, error) {
+	ok, err := c.spans.Next()
+	if err != nil {
+		return false, err
+	}
+	return c.adjust(ok), nil
+}
+
+func (c *spansCell) SkipTo(target int) (bool, error) {
+	ok, err := c.spans.SkipTo(target)
+	if err != nil {
+		return false, err
+	}
+	return c.adjust(ok), nil
+}
+
+func (c *spansCell) adjust(condition bool) bool {
+	s := c.owner
+	if c.length != -1 {
+		s.totalLength -= c.length // subtract old length
+	}
+	if condition {
+		c.length = c.End() - c.Start()
+		s.totalLength += c.length // add new length
+
+		if s.max == nil || c.Doc() > s.max.Doc() ||
+			(c.Doc() == s.max.Doc() && c.End() > s.max.End()) {
+			s.max = c
+		}
+	}
+	s.more = condition
+	return condition
+}
+
+func (c *spansCell) Doc() int   { return c.spans.Doc() }
+func (c *spansCell) Start() int { return c.spans.Start() }
+func (c *spansCell) End() int   { return c.spans.End() }
+
+func (c *spansCell) Payload() ([][]byte, error) {
+	payload, err := c.spans.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return append([][]byte{}, payload...), nil
+}
+
+func (c *spansCell) IsPayloadAvailable() (bool, error) {
+	return c.spans.IsPayloadAvailable()
+}
+
+func (c *spansCell) Cost() int64 {
+	return c.spans.Cost()
+}
+
+func (c *spansCell) String() string {
+	return fmt.Sprintf("%v#%v", c.spans, c.index)
+}
+
+func (s *NearSpansUnordered) SubSpans() []Spans {
+	return s.subSpans
+}
+
+func (s *NearSpansUnordered) Next() (bool, error) {
+	if s.firstTime {
+		if err := s.initList(true); err != nil {
+			return false, err
+		}
+		s.listToQueue() // initialize queue
+		s.firstTime = false
+	} else if s.more {
+		ok, err := s.min().Next() // trigger further scanning
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			s.queue.updateTop() // maintain queue
+		} else {
+			s.more = false
+		}
+	}
+
+	for s.more {
+		queueStale := false
+
+		if s.min().Doc() != s.max.Doc() { // maintain list
+			s.queueToList()
+			queueStale = true
+		}
+
+		// skip to doc w/ all clauses
+		for s.more && s.first.Doc() < s.last.Doc() {
+			ok, err := s.first.SkipTo(s.last.Doc()) // skip first upto last
+			if err != nil {
+				return false, err
+			}
+			s.more = ok
+			s.firstToLast() // and move it to the end
+			queueStale = true
+		}
+
+		if !s.more {
+			return false, nil
+		}
+
+		// found doc w/ all clauses
+
+		if queueStale { // maintain the queue
+			s.listToQueue()
+		}
+
+		if s.atMatch() {
+			return true, nil
+		}
+
+		ok, err := s.min().Next()
+		if err != nil {
+			return false, err
+		}
+		if s.more = ok; s.more {
+			s.queue.updateTop() // maintain queue
+		}
+	}
+	return false, nil // no more matches
+}
+
+func (s *NearSpansUnordered) SkipTo(target int) (bool, error) {
+	if s.firstTime { // initialize
+		if err := s.initList(false); err != nil {
+			return false, err
+		}
+		for cell := s.first; s.more && cell != nil; cell = cell.next {
+			ok, err := cell.SkipTo(target) // skip all
+			if err != nil {
+				return false, err
+			}
+			s.more = ok
+		}
+		if s.more {
+			s.listToQueue()
+		}
+		s.firstTime = false
+	} else { // normal case
+		for s.more && s.min().Doc() < target { // skip as needed
+			ok, err := s.min().SkipTo(target)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				s.queue.updateTop()
+			} else {
+				s.more = false
+			}
+		}
+	}
+	if !s.more {
+		return false, nil
+	}
+	if s.atMatch() {
+		return true, nil
+	}
+	return s.Next()
+}
+
+func (s *NearSpansUnordered) min() *spansCell {
+	return s.queue.items[0].(*spansCell)
+}
+
+func (s *NearSpansUnordered) Doc() int   { return s.min().Doc() }
+func (s *NearSpansUnordered) Start() int { return s.min().Start() }
+func (s *NearSpansUnordered) End() int   { return s.max.End() }
+
+/*
+WARNING: The List is not necessarily in order of the the positions.
+*/
+func (s *NearSpansUnordered) Payload() ([][]byte, error) {
+	var matchPayload [][]byte
+	for cell := s.first; cell != nil; cell = cell.next {
+		ok, err := cell.IsPayloadAvailable()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			payload, err := cell.Payload()
+			if err != nil {
+				return nil, err
+			}
+			matchPayload = append(matchPayload, payload...)
+		}
+	}
+	return matchPayload, nil
+}
+
+func (s *NearSpansUnordered) IsPayloadAvailable() (bool, error) {
+	for pointer := s.min(); pointer != nil; pointer = pointer.next {
+		ok, err := pointer.IsPayloadAvailable()
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (s *NearSpansUnordered) Cost() int64 {
+	minCost := int64(math.MaxInt64)
+	for _, spans := range s.subSpans {
+		if cost := spans.Cost(); cost < minCost {
+			minCost = cost
+		}
+	}
+	return minCost
+}
+
+func (s *NearSpansUnordered) String() string {
+	var pos string
+	switch {
+	case s.firstTime:
+		pos = "START"
+	case s.more:
+		pos = fmt.Sprintf("%v:%v-%v", s.Doc(), s.Start(), s.End())
+	default:
+		pos = "END"
+	}
+	return fmt.Sprintf("NearSpansUnordered(%v)@%v", s.query, pos)
+}
+
+func (s *NearSpansUnordered) initList(next bool) error {
+	for i := 0; s.more && i < len(s.ordered); i++ {
+		cell := s.ordered[i]
+		if next {
+			ok, err := cell.Next() // move to first entry
+			if err != nil {
+				return err
+			}
+			s.more = ok
+		}
+		if s.more {
+			s.addToList(cell) // add to list
+		}
+	}
+	return nil
+}
+
+func (s *NearSpansUnordered) addToList(cell *spansCell) {
+	if s.last != nil { // add next to end of list
+		s.last.next = cell
+	} else {
+		s.first = cell
+	}
+	s.last = cell
+	cell.next = nil
+}
+
+func (s *NearSpansUnordered) firstToLast() {
+	s.last.next = s.first // move first to end of list
+	s.last = s.first
+	s.first = s.first.next
+	s.last.next = nil
+}
+
+func (s *NearSpansUnordered) queueToList() {
+	s.first, s.last = nil, nil
+	for s.queue.Len() > 0 {
+		s.addToList(heap.Pop(s.queue).(*spansCell))
+	}
+}
+
+func (s *NearSpansUnordered) listToQueue() {
+	s.queue.items = s.queue.items[:0] // rebuild queue
+	for cell := s.first; cell != nil; cell = cell.next {
+		heap.Push(s.queue, cell) // add to queue from list
+	}
+}
+
+func (s *NearSpansUnordered) atMatch() bool {
+	return s.min().Doc() == s.max.Doc() &&
+		s.max.End()-s.min().Start()-s.totalLength <= s.slop
+}
@@ -0,0 +1,218 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/spans/SpanNotQuery.java
+
+/*
+Removes matches which overlap with another SpanQuery or within a x
+tokens before or y tokens after another SpanQuery.
+*/
+type SpanNotQuery struct {
+	*AbstractQuery
+	include   SpanQuery
+	exclude   SpanQuery
+	pre, post int
+}
+
+/*
+Construct a SpanNotQuery matching spans from include which have no
+overlap with spans from exclude.
+*/
+func NewSpanNotQuery(include, exclude SpanQuery) *SpanNotQuery {
+	return NewSpanNotQueryWithPrePost(include, exclude, 0, 0)
+}
+
+/*
+Construct a SpanNotQuery matching spans from include which have no
+overlap with spans from exclude within dist tokens of include.
+*/
+func NewSpanNotQueryWithDist(include, exclude SpanQuery, dist int) *SpanNotQuery {
+	return NewSpanNotQueryWithPrePost(include, exclude, dist, dist)
+}
+
+/*
+Construct a SpanNotQuery matching spans from include which have no
+overlap with spans from exclude within pre tokens before or post
+tokens of include.
+*/
+func NewSpanNotQueryWithPrePost(include, exclude SpanQuery, pre, post int) *SpanNotQuery {
+	assert2(include.Field() == "" || exclude.Field() == "" || include.Field() == exclude.Field(),
+		"Clauses must have same field.")
+	ans := &SpanNotQuery{include: include, exclude: exclude}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	if pre > 0 {
+		ans.pre = pre
+	}
+	if post > 0 {
+		ans.post = post
+	}
+	return ans
+}
+
+/* Return the SpanQuery whose matches are filtered. */
+func (q *SpanNotQuery) Include() SpanQuery {
+	return q.include
+}
+
+/* Return the SpanQuery whose matches must not overlap those returned. */
+func (q *SpanNotQuery) Exclude() SpanQuery {
+	return q.exclude
+}
+
+func (q *SpanNotQuery) Field() string {
+	return q.include.Field()
+}
+
+func (q *SpanNotQuery) ExtractTerms(terms map[string]*index.Term) {
+	q.include.ExtractTerms(terms)
+}
+
+func (q *SpanNotQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newSpanWeight(q, searcher)
+}
+
+func (q *SpanNotQuery) ToString(field string) string {
+	return fmt.Sprintf("spanNot(%v, %v, %v, %v)%v", q.include.ToString(field),
+		q.exclude.ToString(field), q.pre, q.post, boostString(q.boost))
+}
+
+func (q *SpanNotQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	include, err := rewriteSpanQuery(reader, q.include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := rewriteSpanQuery(reader, q.exclude)
+	if err != nil {
+		return nil, err
+	}
+	if include == q.include && exclude == q.exclude {
+		return q, nil
+	}
+	clone := NewSpanNotQueryWithPrePost(include, exclude, q.pre, q.post)
+	clone.SetBoost(q.Boost())
+	return clone, nil
+}
+
+func (q *SpanNotQuery) Spans(context *index.AtomicReaderContext, acceptDocs util.Bits,
+	termContexts map[string]*index.TermContext) (Spans, error) {
+
+	includeSpans, err := q.include.Spans(context, acceptDocs, termContexts)
+	if err != nil {
+		return nil, err
+	}
+	excludeSpans, err := q.exclude.Spans(context, acceptDocs, termContexts)
+	if err != nil {
+		return nil, err
+	}
+	moreExclude, err := excludeSpans.Next()
+	if err != nil {
+		return nil, err
+	}
+	return &notSpans{
+		owner:        q,
+		includeSpans: includeSpans,
+		moreInclude:  true,
+		excludeSpans: excludeSpans,
+		moreExclude:  moreExclude,
+	}, nil
+}
+
+/* Spans of the include clause which are far enough from the exclude ones. */
+type notSpans struct {
+	owner        *SpanNotQuery
+	includeSpans Spans
+	moreInclude  bool
+	excludeSpans Spans
+	moreExclude  bool
+}
+
+func (s *notSpans) Next() (bool, error) {
+	var err error
+	if s.moreInclude { // move to next include
+		if s.moreInclude, err = s.includeSpans.Next(); err != nil {
+			return false, err
+		}
+	}
+	for s.moreInclude && s.moreExclude {
+		ok, err := s.accepted()
+		if err != nil || ok {
+			return ok, err
+		}
+		if s.moreInclude, err = s.includeSpans.Next(); err != nil { // intersected: keep scanning
+			return false, err
+		}
+	}
+	return s.moreInclude, nil
+}
+
+func (s *notSpans) SkipTo(target int) (bool, error) {
+	var err error
+	if s.moreInclude { // skip include
+		if s.moreInclude, err = s.includeSpans.SkipTo(target); err != nil {
+			return false, err
+		}
+	}
+	if !s.moreInclude {
+		return false, nil
+	}
+	ok, err := s.accepted()
+	if err != nil || ok {
+		return ok, err
+	}
+	return s.Next() // scan to next match
+}
+
+/*
+Advances the exclude spans past the current include span, and
+returns true if they don't intersect.
+*/
+func (s *notSpans) accepted() (bool, error) {
+	include, exclude := s.includeSpans, s.excludeSpans
+	var err error
+	if s.moreExclude && include.Doc() > exclude.Doc() { // skip exclude
+		if s.moreExclude, err = exclude.SkipTo(include.Doc()); err != nil {
+			return false, err
+		}
+	}
+	for s.moreExclude && include.Doc() == exclude.Doc() && // while exclude is before
+		exclude.End() <= include.Start()-s.owner.pre {
+		if s.moreExclude, err = exclude.Next(); err != nil { // increment exclude
+			return false, err
+		}
+	}
+	return !s.moreExclude || // if no intersection
+		include.Doc() != exclude.Doc() ||
+		include.End()+s.owner.post <= exclude.Start(), nil
+}
+
+func (s *notSpans) Doc() int   { return s.includeSpans.Doc() }
+func (s *notSpans) Start() int { return s.includeSpans.Start() }
+func (s *notSpans) End() int   { return s.includeSpans.End() }
+
+func (s *notSpans) Payload() ([][]byte, error) {
+	if ok, err := s.includeSpans.IsPayloadAvailable(); err != nil || !ok {
+		return nil, err
+	}
+	payload, err := s.includeSpans.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return append([][]byte{}, payload...), nil
+}
+
+func (s *notSpans) IsPayloadAvailable() (bool, error) {
+	return s.includeSpans.IsPayloadAvailable()
+}
+
+func (s *notSpans) Cost() int64 {
+	return s.includeSpans.Cost()
+}
+
+func (s *notSpans) String() string {
+	return fmt.Sprintf("spans(%v)", s.owner)
+}
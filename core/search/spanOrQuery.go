@@ -0,0 +1,228 @@
+package search
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/spans/SpanOrQuery.java
+
+/* Matches the union of its clauses. */
+type SpanOrQuery struct {
+	*AbstractQuery
+	clauses []SpanQuery
+	field   string
+}
+
+/* Construct a SpanOrQuery merging the provided clauses. */
+func NewSpanOrQuery(clauses ...SpanQuery) *SpanOrQuery {
+	ans := &SpanOrQuery{clauses: make([]SpanQuery, 0, len(clauses))}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	for _, clause := range clauses {
+		ans.AddClause(clause)
+	}
+	return ans
+}
+
+/* Adds a clause to this query */
+func (q *SpanOrQuery) AddClause(clause SpanQuery) {
+	if q.field == "" {
+		q.field = clause.Field()
+	} else {
+		assert2(clause.Field() == "" || clause.Field() == q.field,
+			"Clauses must have same field.")
+	}
+	q.clauses = append(q.clauses, clause)
+}
+
+/* Return the clauses whose spans are matched. */
+func (q *SpanOrQuery) Clauses() []SpanQuery {
+	return q.clauses
+}
+
+func (q *SpanOrQuery) Field() string {
+	return q.field
+}
+
+func (q *SpanOrQuery) ExtractTerms(terms map[string]*index.Term) {
+	for _, clause := range q.clauses {
+		clause.ExtractTerms(terms)
+	}
+}
+
+func (q *SpanOrQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newSpanWeight(q, searcher)
+}
+
+func (q *SpanOrQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	clauses, changed, err := rewriteSpanClauses(reader, q.clauses)
+	if err != nil || !changed {
+		return q, err
+	}
+	clone := NewSpanOrQuery(clauses...)
+	clone.SetBoost(q.Boost())
+	return clone, nil
+}
+
+func (q *SpanOrQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	buf.WriteString("spanOr([")
+	for i, clause := range q.clauses {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(clause.ToString(field))
+	}
+	buf.WriteString("])")
+	buf.WriteString(boostString(q.boost))
+	return buf.String()
+}
+
+func (q *SpanOrQuery) Spans(context *index.AtomicReaderContext, acceptDocs util.Bits,
+	termContexts map[string]*index.TermContext) (Spans, error) {
+
+	if len(q.clauses) == 1 { // optimize 1-clause case
+		return q.clauses[0].Spans(context, acceptDocs, termContexts)
+	}
+	return &orSpans{
+		owner:        q,
+		context:      context,
+		acceptDocs:   acceptDocs,
+		termContexts: termContexts,
+	}, nil
+}
+
+/* Merges the spans of all clauses, ordered by doc, start and end. */
+type orSpans struct {
+	owner        *SpanOrQuery
+	context      *index.AtomicReaderContext
+	acceptDocs   util.Bits
+	termContexts map[string]*index.TermContext
+	queue        *PriorityQueue
+	cost         int64 // summed up once the queue is initialized
+}
+
+func (s *orSpans) initSpanQueue(target int) (bool, error) {
+	s.queue = new(PriorityQueue)
+	s.queue.less = func(i, j int) bool {
+		spans1, spans2 := s.queue.items[i].(Spans), s.queue.items[j].(Spans)
+		if spans1.Doc() == spans2.Doc() {
+			if spans1.Start() == spans2.Start() {
+				return spans1.End() < spans2.End()
+			}
+			return spans1.Start() < spans2.Start()
+		}
+		return spans1.Doc() < spans2.Doc()
+	}
+	for _, clause := range s.owner.clauses {
+		spans, err := clause.Spans(s.context, s.acceptDocs, s.termContexts)
+		if err != nil {
+			return false, err
+		}
+		s.cost += spans.Cost()
+		var ok bool
+		if target == -1 {
+			ok, err = spans.Next()
+		} else {
+			ok, err = spans.SkipTo(target)
+		}
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			heap.Push(s.queue, spans)
+		}
+	}
+	return s.queue.Len() != 0, nil
+}
+
+func (s *orSpans) Next() (bool, error) {
+	if s.queue == nil {
+		return s.initSpanQueue(-1)
+	}
+	if s.queue.Len() == 0 { // all done
+		return false, nil
+	}
+	ok, err := s.top().Next()
+	if err != nil {
+		return false, err
+	}
+	if ok { // move to next
+		s.queue.updateTop()
+		return true, nil
+	}
+	heap.Pop(s.queue) // exhausted a clause
+	return s.queue.Len() != 0, nil
+}
+
+func (s *orSpans) top() Spans {
+	if s.queue == nil || s.queue.Len() == 0 {
+		return nil
+	}
+	return s.queue.items[0].(Spans)
+}
+
+func (s *orSpans) SkipTo(target int) (bool, error) {
+	if s.queue == nil {
+		return s.initSpanQueue(target)
+	}
+	skipCalled := false
+	for s.queue.Len() != 0 && s.top().Doc() < target {
+		ok, err := s.top().SkipTo(target)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			s.queue.updateTop()
+		} else {
+			heap.Pop(s.queue)
+		}
+		skipCalled = true
+	}
+	if skipCalled {
+		return s.queue.Len() != 0, nil
+	}
+	return s.Next()
+}
+
+func (s *orSpans) Doc() int   { return s.top().Doc() }
+func (s *orSpans) Start() int { return s.top().Start() }
+func (s *orSpans) End() int   { return s.top().End() }
+
+func (s *orSpans) Payload() ([][]byte, error) {
+	if ok, err := s.IsPayloadAvailable(); err != nil || !ok {
+		return nil, err
+	}
+	payload, err := s.top().Payload()
+	if err != nil {
+		return nil, err
+	}
+	return append([][]byte{}, payload...), nil
+}
+
+func (s *orSpans) IsPayloadAvailable() (bool, error) {
+	if top := s.top(); top != nil {
+		return top.IsPayloadAvailable()
+	}
+	return false, nil
+}
+
+func (s *orSpans) Cost() int64 {
+	return s.cost
+}
+
+func (s *orSpans) String() string {
+	var pos string
+	switch {
+	case s.queue == nil:
+		pos = "START"
+	case s.queue.Len() > 0:
+		pos = fmt.Sprintf("%v:%v-%v", s.Doc(), s.Start(), s.End())
+	default:
+		pos = "END"
+	}
+	return fmt.Sprintf("spans(%v)@%v", s.owner, pos)
+}
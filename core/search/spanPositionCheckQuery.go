@@ -0,0 +1,255 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/spans/SpanPositionCheckQuery.java
+
+/* Return value of SpanPositionCheckQuery.AcceptPosition(). */
+type SpanAcceptStatus int
+
+const (
+	// Indicates the match should be accepted
+	SPAN_ACCEPT_STATUS_YES = SpanAcceptStatus(1)
+	// Indicates the match should be rejected
+	SPAN_ACCEPT_STATUS_NO = SpanAcceptStatus(2)
+	// Indicates the match should be rejected, and the enumeration
+	// should advance to the next document.
+	SPAN_ACCEPT_STATUS_NO_AND_ADVANCE = SpanAcceptStatus(3)
+)
+
+type SpanPositionCheckQuerySPI interface {
+	QuerySPI
+	/*
+		Implementing types are required to return whether the current
+		position is a match for the passed in "match" SpanQuery.
+
+		This is only called if the underlying Spans.Next() for the match
+		is successful.
+	*/
+	AcceptPosition(spans Spans) (SpanAcceptStatus, error)
+}
+
+/* Base type for filtering a SpanQuery based on the position of a match. */
+type SpanPositionCheckQuery struct {
+	*AbstractQuery
+	spi   SpanPositionCheckQuerySPI
+	match SpanQuery
+}
+
+func NewSpanPositionCheckQuery(spi SpanPositionCheckQuerySPI, match SpanQuery) *SpanPositionCheckQuery {
+	return &SpanPositionCheckQuery{
+		AbstractQuery: NewAbstractQuery(spi),
+		spi:           spi,
+		match:         match,
+	}
+}
+
+/*
+Returns the SpanQuery whose matches are filtered.
+*/
+func (q *SpanPositionCheckQuery) Match() SpanQuery {
+	return q.match
+}
+
+func (q *SpanPositionCheckQuery) Field() string {
+	return q.match.Field()
+}
+
+func (q *SpanPositionCheckQuery) ExtractTerms(terms map[string]*index.Term) {
+	q.match.ExtractTerms(terms)
+}
+
+func (q *SpanPositionCheckQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newSpanWeight(q.value.(SpanQuery), searcher)
+}
+
+func (q *SpanPositionCheckQuery) Spans(context *index.AtomicReaderContext, acceptDocs util.Bits,
+	termContexts map[string]*index.TermContext) (Spans, error) {
+
+	spans, err := q.match.Spans(context, acceptDocs, termContexts)
+	if err != nil {
+		return nil, err
+	}
+	return &positionCheckSpans{q, spans}, nil
+}
+
+/*
+Returns the rewritten match query, or nil if it rewrote to itself,
+for the implementing types to clone themselves.
+*/
+func (q *SpanPositionCheckQuery) rewriteMatch(reader index.IndexReader) (SpanQuery, error) {
+	rewritten, err := rewriteSpanQuery(reader, q.match)
+	if err != nil || rewritten == q.match {
+		return nil, err
+	}
+	return rewritten, nil
+}
+
+type positionCheckSpans struct {
+	owner *SpanPositionCheckQuery
+	spans Spans
+}
+
+func (s *positionCheckSpans) Next() (bool, error) {
+	if ok, err := s.spans.Next(); err != nil || !ok {
+		return false, err
+	}
+	return s.doNext()
+}
+
+func (s *positionCheckSpans) SkipTo(target int) (bool, error) {
+	if ok, err := s.spans.SkipTo(target); err != nil || !ok {
+		return false, err
+	}
+	return s.doNext()
+}
+
+func (s *positionCheckSpans) doNext() (bool, error) {
+	for {
+		status, err := s.owner.spi.AcceptPosition(s)
+		if err != nil {
+			return false, err
+		}
+		var ok bool
+		switch status {
+		case SPAN_ACCEPT_STATUS_YES:
+			return true, nil
+		case SPAN_ACCEPT_STATUS_NO:
+			ok, err = s.spans.Next()
+		case SPAN_ACCEPT_STATUS_NO_AND_ADVANCE:
+			ok, err = s.spans.SkipTo(s.spans.Doc() + 1)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+}
+
+func (s *positionCheckSpans) Doc() int   { return s.spans.Doc() }
+func (s *positionCheckSpans) Start() int { return s.spans.Start() }
+func (s *positionCheckSpans) End() int   { return s.spans.End() }
+
+func (s *positionCheckSpans) Payload() ([][]byte, error) {
+	if ok, err := s.spans.IsPayloadAvailable(); err != nil || !ok {
+		return nil, err
+	}
+	payload, err := s.spans.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return append([][]byte{}, payload...), nil
+}
+
+func (s *positionCheckSpans) IsPayloadAvailable() (bool, error) {
+	return s.spans.IsPayloadAvailable()
+}
+
+func (s *positionCheckSpans) Cost() int64 {
+	return s.spans.Cost()
+}
+
+func (s *positionCheckSpans) String() string {
+	return fmt.Sprintf("spans(%v)", s.owner)
+}
+
+// search/spans/SpanPositionRangeQuery.java
+
+/*
+Checks to see if the Match() lies between a start and end position.
+*/
+type SpanPositionRangeQuery struct {
+	*SpanPositionCheckQuery
+	start, end int
+}
+
+func NewSpanPositionRangeQuery(match SpanQuery, start, end int) *SpanPositionRangeQuery {
+	ans := &SpanPositionRangeQuery{start: start, end: end}
+	ans.SpanPositionCheckQuery = NewSpanPositionCheckQuery(ans, match)
+	return ans
+}
+
+func (q *SpanPositionRangeQuery) AcceptPosition(spans Spans) (SpanAcceptStatus, error) {
+	assert2(spans.Start() != spans.End(), "start equals end: %v", spans.Start())
+	if spans.Start() >= q.end {
+		return SPAN_ACCEPT_STATUS_NO_AND_ADVANCE, nil
+	} else if spans.Start() >= q.start && spans.End() <= q.end {
+		return SPAN_ACCEPT_STATUS_YES, nil
+	}
+	return SPAN_ACCEPT_STATUS_NO, nil
+}
+
+/* Returns the minimum position permitted in a match. */
+func (q *SpanPositionRangeQuery) Start() int {
+	return q.start
+}
+
+/* Returns the maximum end position permitted in a match. */
+func (q *SpanPositionRangeQuery) End() int {
+	return q.end
+}
+
+func (q *SpanPositionRangeQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	match, err := q.rewriteMatch(reader)
+	if err != nil || match == nil {
+		return q, err
+	}
+	clone := NewSpanPositionRangeQuery(match, q.start, q.end)
+	clone.SetBoost(q.Boost())
+	return clone, nil
+}
+
+func (q *SpanPositionRangeQuery) ToString(field string) string {
+	return fmt.Sprintf("spanPosRange(%v, %v, %v)%v",
+		q.match.ToString(field), q.start, q.end, boostString(q.boost))
+}
+
+// search/spans/SpanFirstQuery.java
+
+/*
+Matches spans near the beginning of a field.
+
+This type is a specialization of SpanPositionRangeQuery in that it
+checks that the span occurs in the first end positions.
+*/
+type SpanFirstQuery struct {
+	*SpanPositionRangeQuery
+}
+
+/*
+Construct a SpanFirstQuery matching spans in match whose end position
+is less than or equal to end.
+*/
+func NewSpanFirstQuery(match SpanQuery, end int) *SpanFirstQuery {
+	ans := &SpanFirstQuery{&SpanPositionRangeQuery{start: 0, end: end}}
+	ans.SpanPositionCheckQuery = NewSpanPositionCheckQuery(ans, match)
+	return ans
+}
+
+func (q *SpanFirstQuery) AcceptPosition(spans Spans) (SpanAcceptStatus, error) {
+	assert2(spans.Start() != spans.End(), "start equals end: %v", spans.Start())
+	if spans.Start() >= q.end {
+		return SPAN_ACCEPT_STATUS_NO_AND_ADVANCE, nil
+	} else if spans.End() <= q.end {
+		return SPAN_ACCEPT_STATUS_YES, nil
+	}
+	return SPAN_ACCEPT_STATUS_NO, nil
+}
+
+func (q *SpanFirstQuery) Rewrite(reader index.IndexReader) (Query, error) {
+	match, err := q.rewriteMatch(reader)
+	if err != nil || match == nil {
+		return q, err
+	}
+	clone := NewSpanFirstQuery(match, q.end)
+	clone.SetBoost(q.Boost())
+	return clone, nil
+}
+
+func (q *SpanFirstQuery) ToString(field string) string {
+	return fmt.Sprintf("spanFirst(%v, %v)%v",
+		q.match.ToString(field), q.end, boostString(q.boost))
+}
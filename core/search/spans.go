@@ -0,0 +1,517 @@
+package search
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"sort"
+)
+
+// search/spans/Spans.java
+
+/*
+Expert: an enumeration of span matches. Used to implement span
+searching. Each span represents a range of term positions within a
+document. Matches are enumerated in order, by increasing document
+number, within that by increasing start position and finally by
+increasing end position.
+*/
+type Spans interface {
+	// Move to the next match, returning true iff any such exists.
+	Next() (bool, error)
+	/*
+		Skips to the first match beyond the current, whose document
+		number is greater than or equal to target.
+
+		The behavior of this method is undefined when called with
+		target <= current, or after the iterator has exhausted. Both
+		cases may result in unpredicted behavior.
+
+		Returns true iff there is such a match.
+	*/
+	SkipTo(target int) (bool, error)
+	// Returns the document number of the current match. Initially invalid.
+	Doc() int
+	// Returns the start position of the current match. Initially invalid.
+	Start() int
+	// Returns the end position of the current match. Initially invalid.
+	End() int
+	/*
+		Returns the payload data for the current span. This is invalid
+		until Next() is called for the first time. This method must not
+		be called more than once after each call of Next(). However,
+		most payloads are loaded lazily, so if the payload data for the
+		current position is not needed, this method may not be called at
+		all for performance reasons. An ordered SpanQuery does not lazy
+		load, so if you have payloads in your index and you do not want
+		ordered SpanNearQuerys to collect payloads, you can disable
+		collection with a constructor option.
+
+		Note that the return type is a collection, thus the ordering
+		should not be relied upon.
+	*/
+	Payload() ([][]byte, error)
+	/*
+		Checks if a payload can be loaded at this position.
+
+		Payloads can only be loaded once per call to Next().
+	*/
+	IsPayloadAvailable() (bool, error)
+	/*
+		Returns the estimated cost of this spans. This is generally an
+		upper bound of the number of documents this iterator might
+		match, but may be a rough heuristic, hardcoded value, or
+		otherwise completely inaccurate.
+	*/
+	Cost() int64
+}
+
+// search/spans/SpanQuery.java
+
+/* Base interface for span-based queries. */
+type SpanQuery interface {
+	Query
+	/*
+		Expert: returns the matches for this query in an index. Used
+		internally to search for spans.
+	*/
+	Spans(context *index.AtomicReaderContext, acceptDocs util.Bits,
+		termContexts map[string]*index.TermContext) (Spans, error)
+	/*
+		Returns the name of the field matched by this query, or empty
+		string if the query has no clause yet.
+
+		Note that this may return empty for the SpanOrQuery and
+		SpanNearQuery without any clause.
+	*/
+	Field() string
+	/*
+		Expert: adds all terms occurring in this query to the terms map,
+		keyed by Term.String(). Only works if this query is in its
+		rewritten form.
+	*/
+	ExtractTerms(terms map[string]*index.Term)
+}
+
+// search/spans/TermSpans.java
+
+/* Expert: public for extension only. */
+type TermSpans struct {
+	postings    DocsAndPositionsEnum
+	term        *index.Term
+	doc         int
+	freq        int
+	count       int
+	position    int
+	readPayload bool
+}
+
+func newTermSpans(postings DocsAndPositionsEnum, term *index.Term) *TermSpans {
+	return &TermSpans{
+		postings: postings,
+		term:     term,
+		doc:      -1,
+	}
+}
+
+func (s *TermSpans) Next() (bool, error) {
+	var err error
+	if s.count == s.freq {
+		if s.postings == nil {
+			return false, nil
+		}
+		if s.doc, err = s.postings.NextDoc(); err != nil || s.doc == NO_MORE_DOCS {
+			return false, err
+		}
+		if s.freq, err = s.postings.Freq(); err != nil {
+			return false, err
+		}
+		s.count = 0
+	}
+	if s.position, err = s.postings.NextPosition(); err != nil {
+		return false, err
+	}
+	s.count++
+	s.readPayload = false
+	return true, nil
+}
+
+func (s *TermSpans) SkipTo(target int) (bool, error) {
+	assert(target > s.doc)
+	var err error
+	if s.doc, err = s.postings.Advance(target); err != nil || s.doc == NO_MORE_DOCS {
+		return false, err
+	}
+	if s.freq, err = s.postings.Freq(); err != nil {
+		return false, err
+	}
+	s.count = 0
+	if s.position, err = s.postings.NextPosition(); err != nil {
+		return false, err
+	}
+	s.count++
+	s.readPayload = false
+	return true, nil
+}
+
+func (s *TermSpans) Doc() int   { return s.doc }
+func (s *TermSpans) Start() int { return s.position }
+func (s *TermSpans) End() int   { return s.position + 1 }
+
+func (s *TermSpans) Payload() ([][]byte, error) {
+	payload, err := s.postings.Payload()
+	if err != nil {
+		return nil, err
+	}
+	s.readPayload = true
+	if payload == nil {
+		return nil, nil
+	}
+	return [][]byte{append([]byte(nil), payload...)}, nil
+}
+
+func (s *TermSpans) IsPayloadAvailable() (bool, error) {
+	if s.readPayload {
+		return false, nil
+	}
+	payload, err := s.postings.Payload()
+	return payload != nil, err
+}
+
+func (s *TermSpans) Cost() int64 {
+	return s.postings.Cost()
+}
+
+func (s *TermSpans) String() string {
+	var pos string
+	switch s.doc {
+	case -1:
+		pos = "START"
+	case NO_MORE_DOCS:
+		pos = "END"
+	default:
+		pos = fmt.Sprintf("%v-%v", s.doc, s.position)
+	}
+	return fmt.Sprintf("spans(%v)@%v", s.term, pos)
+}
+
+/* Returned by SpanTermQuery when the term is not present in a segment. */
+var EMPTY_TERM_SPANS = Spans(emptyTermSpans(0))
+
+type emptyTermSpans int
+
+func (s emptyTermSpans) Next() (bool, error)               { return false, nil }
+func (s emptyTermSpans) SkipTo(target int) (bool, error)   { return false, nil }
+func (s emptyTermSpans) Doc() int                          { return NO_MORE_DOCS }
+func (s emptyTermSpans) Start() int                        { return -1 }
+func (s emptyTermSpans) End() int                          { return -1 }
+func (s emptyTermSpans) Payload() ([][]byte, error)        { return nil, nil }
+func (s emptyTermSpans) IsPayloadAvailable() (bool, error) { return false, nil }
+func (s emptyTermSpans) Cost() int64                       { return 0 }
+func (s emptyTermSpans) String() string                    { return "EMPTY_TERM_SPANS" }
+
+// search/spans/SpanTermQuery.java
+
+/*
+Matches spans containing a term. This should not be used for terms
+that are indexed at position Integer.MAX_VALUE.
+*/
+type SpanTermQuery struct {
+	*AbstractQuery
+	term *index.Term
+}
+
+/* Construct a SpanTermQuery matching the named term's spans. */
+func NewSpanTermQuery(term *index.Term) *SpanTermQuery {
+	ans := &SpanTermQuery{term: term}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/* Return the term whose spans are matched. */
+func (q *SpanTermQuery) Term() *index.Term {
+	return q.term
+}
+
+func (q *SpanTermQuery) Field() string {
+	return q.term.Field
+}
+
+func (q *SpanTermQuery) ExtractTerms(terms map[string]*index.Term) {
+	terms[q.term.String()] = q.term
+}
+
+func (q *SpanTermQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
+	return newSpanWeight(q, searcher)
+}
+
+func (q *SpanTermQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field == field {
+		buf.Write(q.term.Bytes)
+	} else {
+		buf.WriteString(q.term.String())
+	}
+	buf.WriteString(boostString(q.boost))
+	return buf.String()
+}
+
+func (q *SpanTermQuery) Spans(context *index.AtomicReaderContext, acceptDocs util.Bits,
+	termContexts map[string]*index.TermContext) (Spans, error) {
+
+	reader := context.Reader().(index.AtomicReader)
+	var state TermState
+	if termContext, ok := termContexts[q.term.String()]; ok {
+		state = termContext.State(context.Ord)
+	} else {
+		// this happens with span-not query, as it doesn't include the
+		// NOT side in ExtractTerms(), so we seek to the term now in this
+		// segment.
+		if terms := reader.Terms(q.term.Field); terms != nil {
+			termsEnum := terms.Iterator(nil)
+			ok, err := termsEnum.SeekExact(q.term.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				if state, err = termsEnum.TermState(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if state == nil { // term is not present in that reader
+		return EMPTY_TERM_SPANS, nil
+	}
+
+	termsEnum := reader.Terms(q.term.Field).Iterator(nil)
+	if err := termsEnum.SeekExactFromLast(q.term.Bytes, state); err != nil {
+		return nil, err
+	}
+	postings, err := termsEnum.DocsAndPositionsByFlags(acceptDocs, nil, DOCS_POSITIONS_ENUM_FLAG_PAYLOADS)
+	if err != nil {
+		return nil, err
+	}
+	if postings == nil {
+		// term does exist, but has no positions
+		return nil, errors.New(fmt.Sprintf(
+			"field '%v' was indexed without position data; cannot run SpanTermQuery (term=%v)",
+			q.term.Field, string(q.term.Bytes)))
+	}
+	return newTermSpans(postings, q.term), nil
+}
+
+// search/spans/SpanWeight.java
+
+/* Expert-only. Public for use by other weight implementations. */
+type SpanWeight struct {
+	*WeightImpl
+	similarity   Similarity
+	termContexts map[string]*index.TermContext
+	query        SpanQuery
+	stats        SimWeight
+}
+
+func newSpanWeight(query SpanQuery, searcher *IndexSearcher) (*SpanWeight, error) {
+	ans := &SpanWeight{
+		similarity:   searcher.similarity,
+		termContexts: make(map[string]*index.TermContext),
+		query:        query,
+	}
+	ans.WeightImpl = NewWeightImpl(ans)
+
+	extracted := make(map[string]*index.Term)
+	query.ExtractTerms(extracted)
+	terms := make([]*index.Term, 0, len(extracted))
+	for _, term := range extracted {
+		terms = append(terms, term)
+	}
+	sort.Sort(index.TermSorter(terms))
+
+	ctx := searcher.TopReaderContext()
+	termStats := make([]TermStatistics, len(terms))
+	for i, term := range terms {
+		state, err := index.NewTermContextFromTerm(ctx, term)
+		if err != nil {
+			return nil, err
+		}
+		termStats[i] = searcher.TermStatistics(term, state)
+		ans.termContexts[term.String()] = state
+	}
+	if field := query.Field(); field != "" {
+		ans.stats = ans.similarity.computeWeight(query.Boost(),
+			searcher.CollectionStatistics(field), termStats...)
+	}
+	return ans, nil
+}
+
+func (w *SpanWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.query)
+}
+
+func (w *SpanWeight) ValueForNormalization() float32 {
+	if w.stats == nil {
+		return 1
+	}
+	return w.stats.ValueForNormalization()
+}
+
+func (w *SpanWeight) Normalize(queryNorm, topLevelBoost float32) {
+	if w.stats != nil {
+		w.stats.Normalize(queryNorm, topLevelBoost)
+	}
+}
+
+func (w *SpanWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *SpanWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	if w.stats == nil {
+		return nil, nil
+	}
+	spans, err := w.query.Spans(context, acceptDocs, w.termContexts)
+	if err != nil {
+		return nil, err
+	}
+	simScorer, err := w.similarity.simScorer(w.stats, context)
+	if err != nil {
+		return nil, err
+	}
+	return newSpanScorer(spans, w, simScorer)
+}
+
+func (w *SpanWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
+	scorer, err := w.Scorer(context, context.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	if scorer != nil {
+		newDoc, err := scorer.Advance(doc)
+		if err != nil {
+			return nil, err
+		}
+		if newDoc == doc {
+			freq := scorer.(*SpanScorer).sloppyFreq()
+			docScorer, err := w.similarity.simScorer(w.stats, context)
+			if err != nil {
+				return nil, err
+			}
+			scoreExplanation := docScorer.explain(doc,
+				NewExplanation(freq, fmt.Sprintf("phraseFreq=%v", freq)))
+			ans := NewComplexExplanation(true, scoreExplanation.Value(),
+				fmt.Sprintf("weight(%v in %v) [%v], result of:",
+					w.query, doc, reflect.TypeOf(w.similarity)))
+			ans.AddDetail(scoreExplanation)
+			return ans, nil
+		}
+	}
+	return NewComplexExplanation(false, 0, "no matching term"), nil
+}
+
+// search/spans/SpanScorer.java
+
+/* Public for extension only. */
+type SpanScorer struct {
+	*abstractScorer
+	spans      Spans
+	more       bool
+	doc        int
+	freq       float32
+	numMatches int
+	docScorer  SimScorer
+}
+
+func newSpanScorer(spans Spans, weight Weight, docScorer SimScorer) (*SpanScorer, error) {
+	ans := &SpanScorer{
+		spans:     spans,
+		doc:       -1,
+		docScorer: docScorer,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	var err error
+	if ans.more, err = spans.Next(); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+func (s *SpanScorer) NextDoc() (int, error) {
+	ok, err := s.setFreqCurrentDoc()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		s.doc = NO_MORE_DOCS
+	}
+	return s.doc, nil
+}
+
+func (s *SpanScorer) Cost() int64 {
+	return s.spans.Cost()
+}
+
+func (s *SpanScorer) Advance(target int) (int, error) {
+	if !s.more {
+		s.doc = NO_MORE_DOCS
+		return s.doc, nil
+	}
+	var err error
+	if s.spans.Doc() < target { // setFreqCurrentDoc() leaves spans.Doc() ahead
+		if s.more, err = s.spans.SkipTo(target); err != nil {
+			return 0, err
+		}
+	}
+	return s.NextDoc()
+}
+
+func (s *SpanScorer) setFreqCurrentDoc() (bool, error) {
+	if !s.more {
+		return false, nil
+	}
+	s.doc = s.spans.Doc()
+	s.freq = 0
+	s.numMatches = 0
+	for s.more && s.doc == s.spans.Doc() {
+		matchLength := s.spans.End() - s.spans.Start()
+		s.freq += s.docScorer.computeSlopFactor(matchLength)
+		s.numMatches++
+		var err error
+		if s.more, err = s.spans.Next(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (s *SpanScorer) DocId() int {
+	return s.doc
+}
+
+func (s *SpanScorer) Score() (float32, error) {
+	return s.docScorer.Score(s.doc, s.freq), nil
+}
+
+func (s *SpanScorer) Freq() (int, error) {
+	return s.numMatches, nil
+}
+
+/*
+Returns the intermediate "sloppy freq" adjusted for edit distance.
+Only used by the explanation.
+*/
+func (s *SpanScorer) sloppyFreq() float32 {
+	return s.freq
+}
+
+func (s *SpanScorer) String() string {
+	return fmt.Sprintf("scorer(%v)", s.weight)
+}
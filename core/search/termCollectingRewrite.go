@@ -0,0 +1,392 @@
+package search
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"sort"
+)
+
+/*
+Returned when an attempt is made to add more than maxClauseCount
+clauses. This typically happens if a PrefixQuery, WildcardQuery or
+RegexpQuery is expanded to many terms during search.
+*/
+var ErrTooManyClauses = errors.New(fmt.Sprintf("maxClauseCount is set to %v", maxClauseCount))
+
+// search/TermCollectingRewrite.java
+
+type termCollector interface {
+	// Called on every new segment, before the terms of it are collected.
+	setReaderContext(topReaderContext index.IndexReaderContext,
+		readerContext *index.AtomicReaderContext)
+	// The next enum that will be used to collect terms.
+	setNextEnum(termsEnum TermsEnum)
+	// Return false to stop collecting.
+	collect(term []byte) (bool, error)
+}
+
+func collectTerms(reader index.IndexReader, query *MultiTermQuery,
+	collector termCollector) error {
+
+	topReaderContext := reader.Context()
+	for _, context := range topReaderContext.Leaves() {
+		terms := context.Reader().(index.AtomicReader).Terms(query.field)
+		if terms == nil {
+			// field does not exist
+			continue
+		}
+
+		termsEnum, err := query.spi.TermsEnum(terms)
+		if err != nil {
+			return err
+		}
+		assert(termsEnum != nil)
+
+		if termsEnum == EMPTY_TERMS_ENUM {
+			continue
+		}
+
+		collector.setReaderContext(topReaderContext, context)
+		collector.setNextEnum(termsEnum)
+		for {
+			term, err := termsEnum.Next()
+			if err != nil {
+				return err
+			}
+			if term == nil {
+				break
+			}
+			ok, err := collector.collect(term)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil // interrupt whole term collection, so also don't iterate other subReaders
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Collects the states of every visited term, keyed by the term bytes,
+summing the statistics of the same term across segments.
+*/
+type termStatesCollector struct {
+	// whether to fail with ErrTooManyClauses past maxClauseCount terms
+	checkMaxClauseCount bool
+	topReaderContext    index.IndexReaderContext
+	readerContext       *index.AtomicReaderContext
+	termsEnum           TermsEnum
+	boostAtt            BoostAttribute
+	termStates          map[string]*index.TermContext
+	boosts              map[string]float32
+}
+
+func newTermStatesCollector(checkMaxClauseCount bool) *termStatesCollector {
+	return &termStatesCollector{
+		checkMaxClauseCount: checkMaxClauseCount,
+		termStates:          make(map[string]*index.TermContext),
+		boosts:              make(map[string]float32),
+	}
+}
+
+/* Returns the collected terms, sorted by their bytes. */
+func (c *termStatesCollector) sortedTerms() []string {
+	terms := make([]string, 0, len(c.termStates))
+	for term, _ := range c.termStates {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+func (c *termStatesCollector) setReaderContext(topReaderContext index.IndexReaderContext,
+	readerContext *index.AtomicReaderContext) {
+	c.topReaderContext = topReaderContext
+	c.readerContext = readerContext
+}
+
+func (c *termStatesCollector) setNextEnum(termsEnum TermsEnum) {
+	c.termsEnum = termsEnum
+	c.boostAtt = boostAttribute(termsEnum.Attributes())
+}
+
+func (c *termStatesCollector) collect(term []byte) (bool, error) {
+	state, err := c.termsEnum.TermState()
+	if err != nil {
+		return false, err
+	}
+	assert(state != nil)
+	docFreq, err := c.termsEnum.DocFreq()
+	if err != nil {
+		return false, err
+	}
+	totalTermFreq, err := c.termsEnum.TotalTermFreq()
+	if err != nil {
+		return false, err
+	}
+
+	termState, ok := c.termStates[string(term)]
+	if !ok {
+		// new entry: we populate the entry initially
+		if c.checkMaxClauseCount && len(c.termStates) >= maxClauseCount {
+			return false, ErrTooManyClauses
+		}
+		termState = index.NewTermContext(c.topReaderContext)
+		c.termStates[string(term)] = termState
+		c.boosts[string(term)] = c.boostAtt.Boost()
+	}
+	// duplicate term: update docFreq
+	termState.Register(state, c.readerContext.Ord, docFreq, totalTermFreq)
+	return true, nil
+}
+
+// search/ScoringRewrite.java
+
+/*
+A rewrite method that first translates each term into SHOULD clause
+in a BooleanQuery, and keeps the scores as computed by the query.
+Note that typically such scores are meaningless to the user, and
+require non-trivial CPU to compute, so it's almost always better to
+use CONSTANT_SCORE_FILTER_REWRITE instead.
+
+NOTE: This rewrite method will hit ErrTooManyClauses if the number of
+terms exceeds maxClauseCount.
+*/
+var SCORING_BOOLEAN_QUERY_REWRITE = RewriteMethod(scoringBooleanQueryRewrite(0))
+
+type scoringBooleanQueryRewrite int
+
+func (r scoringBooleanQueryRewrite) Rewrite(reader index.IndexReader,
+	query *MultiTermQuery) (Query, error) {
+
+	return r.rewrite(reader, query)
+}
+
+func (r scoringBooleanQueryRewrite) rewrite(reader index.IndexReader,
+	query *MultiTermQuery) (*BooleanQuery, error) {
+
+	result := NewBooleanQueryDisableCoord(true)
+	col := newTermStatesCollector(true)
+	if err := collectTerms(reader, query, col); err != nil {
+		return nil, err
+	}
+
+	for _, term := range col.sortedTerms() {
+		tq := NewTermQueryWithContext(index.NewTermFromBytes(query.field, []byte(term)), col.termStates[term])
+		tq.SetBoost(query.Boost() * col.boosts[term])
+		result.Add(tq, SHOULD)
+	}
+	return result, nil
+}
+
+func (r scoringBooleanQueryRewrite) String() string {
+	return "SCORING_BOOLEAN_QUERY_REWRITE"
+}
+
+/*
+Like SCORING_BOOLEAN_QUERY_REWRITE except scores are not computed.
+Instead, each matching document receives a constant score equal to
+the query's boost.
+
+NOTE: This rewrite method will hit ErrTooManyClauses if the number of
+terms exceeds maxClauseCount.
+*/
+var CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE = RewriteMethod(constantScoreBooleanQueryRewrite(0))
+
+type constantScoreBooleanQueryRewrite int
+
+func (r constantScoreBooleanQueryRewrite) Rewrite(reader index.IndexReader,
+	query *MultiTermQuery) (Query, error) {
+
+	bq, err := scoringBooleanQueryRewrite(0).rewrite(reader, query)
+	if err != nil {
+		return nil, err
+	}
+	// strip the scores off
+	result := NewConstantScoreQuery(NewQueryWrapperFilter(bq))
+	result.SetBoost(query.Boost())
+	return result, nil
+}
+
+func (r constantScoreBooleanQueryRewrite) String() string {
+	return "CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE"
+}
+
+// search/TopTermsRewrite.java
+
+type scoreTerm struct {
+	bytes     []byte
+	boost     float32
+	termState *index.TermContext
+}
+
+/*
+A rewrite method that first translates each term into SHOULD clause
+in a BooleanQuery, and keeps the scores as computed by the query.
+
+This rewrite method only uses the top scoring terms so it will not
+overflow the boolean max clause count.
+*/
+type TopTermsScoringBooleanQueryRewrite struct {
+	size int
+}
+
+/*
+Create a TopTermsScoringBooleanQueryRewrite for at most size terms.
+
+NOTE: if maxClauseCount is smaller than size, then it will be used
+instead.
+*/
+func NewTopTermsScoringBooleanQueryRewrite(size int) *TopTermsScoringBooleanQueryRewrite {
+	return &TopTermsScoringBooleanQueryRewrite{size}
+}
+
+/* Return the maximum priority queue size */
+func (r *TopTermsScoringBooleanQueryRewrite) Size() int {
+	return r.size
+}
+
+func (r *TopTermsScoringBooleanQueryRewrite) Rewrite(reader index.IndexReader,
+	query *MultiTermQuery) (Query, error) {
+
+	maxSize := r.size
+	if maxSize > maxClauseCount {
+		maxSize = maxClauseCount
+	}
+	scoreTerms, err := collectTopTerms(reader, query, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	q := NewBooleanQueryDisableCoord(true)
+	for _, st := range scoreTerms {
+		tq := NewTermQueryWithContext(index.NewTermFromBytes(query.field, st.bytes), st.termState)
+		tq.SetBoost(query.Boost() * st.boost)
+		q.Add(tq, SHOULD)
+	}
+	return q, nil
+}
+
+func (r *TopTermsScoringBooleanQueryRewrite) String() string {
+	return fmt.Sprintf("TopTermsScoringBooleanQueryRewrite(%v)", r.size)
+}
+
+/*
+Collects at most maxSize terms with the greatest boosts, sorted by
+their bytes.
+*/
+func collectTopTerms(reader index.IndexReader, query *MultiTermQuery,
+	maxSize int) ([]*scoreTerm, error) {
+
+	// the least competitive term is on top: lowest boost, and for the
+	// same boost, the greatest term
+	stQueue := new(PriorityQueue)
+	stQueue.less = func(i, j int) bool {
+		a, b := stQueue.items[i].(*scoreTerm), stQueue.items[j].(*scoreTerm)
+		if a.boost == b.boost {
+			return bytes.Compare(a.bytes, b.bytes) > 0
+		}
+		return a.boost < b.boost
+	}
+	col := &topTermsCollector{
+		maxSize:      maxSize,
+		stQueue:      stQueue,
+		visitedTerms: make(map[string]*scoreTerm),
+	}
+	if err := collectTerms(reader, query, col); err != nil {
+		return nil, err
+	}
+
+	scoreTerms := make([]*scoreTerm, len(stQueue.items))
+	for i, v := range stQueue.items {
+		scoreTerms[i] = v.(*scoreTerm)
+	}
+	sort.Sort(scoreTermsByTerm(scoreTerms))
+	return scoreTerms, nil
+}
+
+type topTermsCollector struct {
+	topReaderContext index.IndexReaderContext
+	readerContext    *index.AtomicReaderContext
+	termsEnum        TermsEnum
+	boostAtt         BoostAttribute
+	maxSize          int
+	stQueue          *PriorityQueue
+	visitedTerms     map[string]*scoreTerm
+}
+
+func (c *topTermsCollector) setReaderContext(topReaderContext index.IndexReaderContext,
+	readerContext *index.AtomicReaderContext) {
+	c.topReaderContext = topReaderContext
+	c.readerContext = readerContext
+}
+
+func (c *topTermsCollector) setNextEnum(termsEnum TermsEnum) {
+	c.termsEnum = termsEnum
+	c.boostAtt = boostAttribute(termsEnum.Attributes())
+}
+
+func (c *topTermsCollector) collect(term []byte) (bool, error) {
+	boost := c.boostAtt.Boost()
+	// ignore uncompetitive hits
+	if c.stQueue.Len() == c.maxSize {
+		t := c.stQueue.items[0].(*scoreTerm)
+		if boost < t.boost {
+			return true, nil
+		}
+		if boost == t.boost && bytes.Compare(term, t.bytes) > 0 {
+			return true, nil
+		}
+	}
+
+	state, err := c.termsEnum.TermState()
+	if err != nil {
+		return false, err
+	}
+	assert(state != nil)
+	docFreq, err := c.termsEnum.DocFreq()
+	if err != nil {
+		return false, err
+	}
+	totalTermFreq, err := c.termsEnum.TotalTermFreq()
+	if err != nil {
+		return false, err
+	}
+
+	if t, ok := c.visitedTerms[string(term)]; ok {
+		// if the term is already in the PQ, only update docFreq of
+		// term in PQ
+		assert2(t.boost == boost, "boost should be equal in all segment TermsEnums")
+		t.termState.Register(state, c.readerContext.Ord, docFreq, totalTermFreq)
+		return true, nil
+	}
+
+	// add new entry in PQ, we must clone the term, else it may get
+	// overwritten!
+	st := &scoreTerm{
+		bytes:     append([]byte(nil), term...),
+		boost:     boost,
+		termState: index.NewTermContext(c.topReaderContext),
+	}
+	st.termState.Register(state, c.readerContext.Ord, docFreq, totalTermFreq)
+	c.visitedTerms[string(st.bytes)] = st
+	heap.Push(c.stQueue, st)
+	// possibly drop entries from queue
+	if c.stQueue.Len() > c.maxSize {
+		st = heap.Pop(c.stQueue).(*scoreTerm)
+		delete(c.visitedTerms, string(st.bytes))
+	}
+	return true, nil
+}
+
+type scoreTermsByTerm []*scoreTerm
+
+func (a scoreTermsByTerm) Len() int           { return len(a) }
+func (a scoreTermsByTerm) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a scoreTermsByTerm) Less(i, j int) bool { return bytes.Compare(a[i].bytes, a[j].bytes) < 0 }
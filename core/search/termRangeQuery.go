@@ -0,0 +1,257 @@
+package search
+
+import (
+	"bytes"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+)
+
+// search/TermRangeQuery.java
+
+/*
+A Query that matches documents within an range of terms.
+
+This query matches the documents looking for terms that fall into the
+supplied range according to bytes.Compare(). It is not intended for
+numerical ranges; use NumericRangeQuery instead.
+
+This query uses the CONSTANT_SCORE_FILTER_REWRITE rewrite method.
+*/
+type TermRangeQuery struct {
+	*MultiTermQuery
+	lowerTerm    []byte
+	upperTerm    []byte
+	includeLower bool
+	includeUpper bool
+}
+
+/*
+Constructs a query selecting all terms greater/equal than lowerTerm
+but less/equal than upperTerm.
+
+If an endpoint is nil, it is said to be "open". Either or both
+endpoints may be open. Open endpoints may not be exclusive (you can't
+select all but the first or last term without explicitly specifying
+the term to exclude.)
+*/
+func NewTermRangeQuery(field string, lowerTerm, upperTerm []byte,
+	includeLower, includeUpper bool) *TermRangeQuery {
+
+	ans := &TermRangeQuery{
+		lowerTerm:    lowerTerm,
+		upperTerm:    upperTerm,
+		includeLower: includeLower,
+		includeUpper: includeUpper,
+	}
+	ans.MultiTermQuery = NewMultiTermQuery(ans, field)
+	return ans
+}
+
+/*
+Factory that creates a new TermRangeQuery using strings for term
+text. An empty string is taken as an open endpoint.
+*/
+func NewTermRangeQueryFromStrings(field, lowerTerm, upperTerm string,
+	includeLower, includeUpper bool) *TermRangeQuery {
+
+	var lower, upper []byte
+	if lowerTerm != "" {
+		lower = []byte(lowerTerm)
+	}
+	if upperTerm != "" {
+		upper = []byte(upperTerm)
+	}
+	return NewTermRangeQuery(field, lower, upper, includeLower, includeUpper)
+}
+
+/* Returns the lower value of this range query, or nil if open */
+func (q *TermRangeQuery) LowerTerm() []byte { return q.lowerTerm }
+
+/* Returns the upper value of this range query, or nil if open */
+func (q *TermRangeQuery) UpperTerm() []byte { return q.upperTerm }
+
+/* Returns true if the lower endpoint is inclusive */
+func (q *TermRangeQuery) IncludesLower() bool { return q.includeLower }
+
+/* Returns true if the upper endpoint is inclusive */
+func (q *TermRangeQuery) IncludesUpper() bool { return q.includeUpper }
+
+func (q *TermRangeQuery) TermsEnum(terms Terms) (TermsEnum, error) {
+	if q.lowerTerm != nil && q.upperTerm != nil && bytes.Compare(q.lowerTerm, q.upperTerm) > 0 {
+		return EMPTY_TERMS_ENUM, nil
+	}
+
+	tenum := terms.Iterator(nil)
+	if (q.lowerTerm == nil || (q.includeLower && len(q.lowerTerm) == 0)) && q.upperTerm == nil {
+		return tenum, nil
+	}
+	return newTermRangeTermsEnum(tenum, q.lowerTerm, q.upperTerm, q.includeLower, q.includeUpper), nil
+}
+
+func (q *TermRangeQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.Field() != field {
+		buf.WriteString(q.Field())
+		buf.WriteRune(':')
+	}
+	if q.includeLower {
+		buf.WriteRune('[')
+	} else {
+		buf.WriteRune('{')
+	}
+	// TODO: all these toStrings for queries should just output the
+	// bytes, it might not be UTF-8!
+	if q.lowerTerm == nil {
+		buf.WriteRune('*')
+	} else if string(q.lowerTerm) == "*" {
+		buf.WriteString("\\*")
+	} else {
+		buf.Write(q.lowerTerm)
+	}
+	buf.WriteString(" TO ")
+	if q.upperTerm == nil {
+		buf.WriteRune('*')
+	} else if string(q.upperTerm) == "*" {
+		buf.WriteString("\\*")
+	} else {
+		buf.Write(q.upperTerm)
+	}
+	if q.includeUpper {
+		buf.WriteRune(']')
+	} else {
+		buf.WriteRune('}')
+	}
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+// search/TermRangeTermsEnum.java
+
+/*
+Subclass of FilteredTermsEnum for enumerating all terms that match
+the specified range parameters.
+
+Term enumerations are always ordered by Comparator. Each term in the
+enumeration is greater than all that precede it. The enumeration
+seeks to the lower bound first, and ends as soon as a term beyond the
+upper bound is reached.
+*/
+type TermRangeTermsEnum struct {
+	*index.FilteredTermsEnum
+
+	includeLower  bool
+	includeUpper  bool
+	lowerBytesRef []byte
+	upperBytesRef []byte
+}
+
+/*
+Enumerates all terms greater/equal than lowerTerm but less/equal than
+upperTerm.
+
+If an endpoint is nil, it is said to be "open". Either or both
+endpoints may be open. Open endpoints may not be exclusive (you can't
+select all but the first or last term without explicitly specifying
+the term to exclude.)
+*/
+func newTermRangeTermsEnum(tenum TermsEnum, lowerTerm, upperTerm []byte,
+	includeLower, includeUpper bool) *TermRangeTermsEnum {
+
+	ans := &TermRangeTermsEnum{
+		includeLower:  includeLower,
+		includeUpper:  includeUpper,
+		lowerBytesRef: lowerTerm,
+		upperBytesRef: upperTerm,
+	}
+	// do a little bit of normalization: open ended range queries
+	// should always be inclusive.
+	if lowerTerm == nil {
+		ans.lowerBytesRef = []byte{}
+		ans.includeLower = true
+	}
+	if upperTerm == nil {
+		ans.includeUpper = true
+	}
+
+	ans.FilteredTermsEnum = index.NewFilteredTermsEnum(ans, tenum, true)
+	ans.SetInitialSeekTerm(ans.lowerBytesRef)
+	return ans
+}
+
+func (e *TermRangeTermsEnum) Accept(term []byte) (index.AcceptStatus, error) {
+	if !e.includeLower && bytes.Equal(term, e.lowerBytesRef) {
+		return index.ACCEPT_STATUS_NO, nil
+	}
+
+	// Use this field's default sort ordering
+	if e.upperBytesRef != nil {
+		if cmp := bytes.Compare(e.upperBytesRef, term); cmp < 0 || (!e.includeUpper && cmp == 0) {
+			return index.ACCEPT_STATUS_END, nil
+		}
+	}
+	return index.ACCEPT_STATUS_YES, nil
+}
+
+// search/TermRangeFilter.java
+
+/*
+A Filter that restricts search results to a range of term values in a
+given field.
+
+This filter matches the documents looking for terms that fall into
+the supplied range according to bytes.Compare(). It is not intended
+for numerical ranges; use NumericRangeFilter instead.
+
+If you construct a large number of range filters with different
+ranges but on the same field, FieldCacheRangeFilter may have
+significantly better performance.
+*/
+type TermRangeFilter struct {
+	*MultiTermQueryWrapperFilter
+	query *TermRangeQuery
+}
+
+/*
+Creates a filter matching all terms between lowerTerm and upperTerm.
+A nil endpoint is open, in which case the matching include flag must
+be false.
+*/
+func NewTermRangeFilter(fieldName string, lowerTerm, upperTerm []byte,
+	includeLower, includeUpper bool) *TermRangeFilter {
+
+	query := NewTermRangeQuery(fieldName, lowerTerm, upperTerm, includeLower, includeUpper)
+	return &TermRangeFilter{newMultiTermQueryWrapperFilter(query.MultiTermQuery), query}
+}
+
+/*
+Factory that creates a new TermRangeFilter using strings for term
+text. An empty string is taken as an open endpoint.
+*/
+func NewTermRangeFilterFromStrings(field, lowerTerm, upperTerm string,
+	includeLower, includeUpper bool) *TermRangeFilter {
+
+	query := NewTermRangeQueryFromStrings(field, lowerTerm, upperTerm, includeLower, includeUpper)
+	return &TermRangeFilter{newMultiTermQueryWrapperFilter(query.MultiTermQuery), query}
+}
+
+/* Constructs a filter for field fieldName matching less than or equal to upperTerm. */
+func TermRangeFilterLess(fieldName string, upperTerm []byte) *TermRangeFilter {
+	return NewTermRangeFilter(fieldName, nil, upperTerm, false, true)
+}
+
+/* Constructs a filter for field fieldName matching greater than or equal to lowerTerm. */
+func TermRangeFilterMore(fieldName string, lowerTerm []byte) *TermRangeFilter {
+	return NewTermRangeFilter(fieldName, lowerTerm, nil, true, false)
+}
+
+/* Returns the lower value of this range filter */
+func (f *TermRangeFilter) LowerTerm() []byte { return f.query.LowerTerm() }
+
+/* Returns the upper value of this range filter */
+func (f *TermRangeFilter) UpperTerm() []byte { return f.query.UpperTerm() }
+
+/* Returns true if the lower endpoint is inclusive */
+func (f *TermRangeFilter) IncludesLower() bool { return f.query.IncludesLower() }
+
+/* Returns true if the upper endpoint is inclusive */
+func (f *TermRangeFilter) IncludesUpper() bool { return f.query.IncludesUpper() }
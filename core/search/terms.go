@@ -29,6 +29,22 @@ func NewTermQueryWithDocFreq(t *index.Term, docFreq int) *TermQuery {
 	return ans
 }
 
+/*
+Expert: constructs a TermQuery that will use the provided docFreq
+instead of looking up the docFreq against the searcher.
+*/
+func NewTermQueryWithContext(t *index.Term, states *index.TermContext) *TermQuery {
+	assert(states != nil)
+	ans := NewTermQueryWithDocFreq(t, states.DocFreq)
+	ans.perReaderTermState = states
+	return ans
+}
+
+/* Returns the term of this query. */
+func (q *TermQuery) Term() *index.Term {
+	return q.term
+}
+
 func (q *TermQuery) CreateWeight(ss *IndexSearcher) (w Weight, err error) {
 	ctx := ss.TopReaderContext()
 	var termState *index.TermContext
@@ -84,7 +100,7 @@ func NewTermWeight(owner *TermQuery, ss *IndexSearcher, termStates *index.TermCo
 			ss.TermStatistics(owner.term, termStates)),
 		termStates: termStates,
 	}
-	ans.WeightImpl = newWeightImpl(ans)
+	ans.WeightImpl = NewWeightImpl(ans)
 	return ans
 }
 
@@ -166,16 +182,16 @@ func (tw *TermWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanat
 				return nil, err
 			}
 			scoreExplanation := docScorer.explain(doc,
-				newExplanation(float32(freq), fmt.Sprintf("termFreq=%v", freq)))
-			ans := newComplexExplanation(true,
-				scoreExplanation.(*ExplanationImpl).value,
+				NewExplanation(float32(freq), fmt.Sprintf("termFreq=%v", freq)))
+			ans := NewComplexExplanation(true,
+				scoreExplanation.Value(),
 				fmt.Sprintf("weight(%v in %v) [%v], result of:",
 					tw.TermQuery, doc, reflect.TypeOf(tw.similarity)))
 			ans.details = []Explanation{scoreExplanation}
 			return ans, nil
 		}
 	}
-	return newComplexExplanation(false, 0, "no matching term"), nil
+	return NewComplexExplanation(false, 0, "no matching term"), nil
 }
 
 // search/TermScorer.java
@@ -210,6 +226,10 @@ func (ts *TermScorer) NextDoc() (d int, err error) {
 	return ts.docsEnum.NextDoc()
 }
 
+func (ts *TermScorer) Cost() int64 {
+	return ts.docsEnum.Cost()
+}
+
 func (ts *TermScorer) Score() (s float32, err error) {
 	assert(ts.DocId() != NO_MORE_DOCS)
 	freq, err := ts.docsEnum.Freq()
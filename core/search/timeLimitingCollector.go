@@ -0,0 +1,145 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"time"
+)
+
+// search/TimeLimitingCollector.java
+
+/* Details of a search that ran out of time. */
+type TimeExceededError struct {
+	TimeAllowed      time.Duration // allowed time
+	TimeElapsed      time.Duration // elapsed time when the search was stopped
+	LastDocCollected int           // last doc (absolute doc id) collected
+}
+
+func (e *TimeExceededError) Error() string {
+	return fmt.Sprintf("Elapsed time: %v. Exceeded allowed search time: %v.",
+		e.TimeElapsed, e.TimeAllowed)
+}
+
+/*
+The TimeLimitingCollector is used to timeout search requests that
+take longer than the maximum allowed search time limit. After this
+time is exceeded, the search stops collecting: the rest of the
+current segment and all following segments are skipped, leaving the
+wrapped collector with partial results. TimedOut() tells whether
+this happened.
+
+The clock is read for every collected doc, so it should be cheap;
+time.Now is fine in most cases.
+*/
+type TimeLimitingCollector struct {
+	collector   Collector
+	clock       func() time.Time
+	timeAllowed time.Duration
+	t0          time.Time
+	timeout     time.Time
+	greedy      bool
+	docBase     int
+	exceeded    *TimeExceededError
+}
+
+/*
+Create a TimeLimitingCollector wrapper over another Collector with a
+specified timeout. The time is measured by the given clock, starting
+when the first segment is collected unless SetBaseline() was called
+before.
+*/
+func NewTimeLimitingCollector(collector Collector, clock func() time.Time,
+	timeAllowed time.Duration) *TimeLimitingCollector {
+
+	assert2(collector != nil, "collector must not be nil")
+	assert2(clock != nil, "clock must not be nil")
+	return &TimeLimitingCollector{
+		collector:   collector,
+		clock:       clock,
+		timeAllowed: timeAllowed,
+	}
+}
+
+/*
+Sets the baseline for this collector. By default the collector's
+baseline is initialized once the first reader is passed to the
+collector. To include operations executed in prior to the actual
+document collection set the baseline through this method in your
+prelude.
+*/
+func (c *TimeLimitingCollector) SetBaseline(t0 time.Time) {
+	c.t0 = t0
+	c.timeout = t0.Add(c.timeAllowed)
+}
+
+/*
+Checks if this time limited collector is greedy in collecting the
+last hit. A non greedy collector, upon a timeout, would stop without
+collecting the doc that was being collected when the timeout was
+detected. A greedy one would first collect it, and then stop.
+
+The default is false.
+*/
+func (c *TimeLimitingCollector) IsGreedy() bool {
+	return c.greedy
+}
+
+/* Sets whether this time limited collector is greedy. */
+func (c *TimeLimitingCollector) SetGreedy(greedy bool) {
+	c.greedy = greedy
+}
+
+/*
+Returns true if the allowed time was exceeded, in which case the
+wrapped collector only holds the hits collected before.
+*/
+func (c *TimeLimitingCollector) TimedOut() bool {
+	return c.exceeded != nil
+}
+
+/* Returns the details of the timeout, or nil if not timed out. */
+func (c *TimeLimitingCollector) TimeExceeded() *TimeExceededError {
+	return c.exceeded
+}
+
+/*
+Calls Collect() on the decorated Collector unless the allowed time
+has passed, in which case ErrCollectionTerminated is returned.
+*/
+func (c *TimeLimitingCollector) Collect(doc int) error {
+	if c.exceeded != nil {
+		return ErrCollectionTerminated
+	}
+	if now := c.clock(); now.After(c.timeout) {
+		if c.greedy {
+			if err := c.collector.Collect(doc); err != nil {
+				return err
+			}
+		}
+		c.exceeded = &TimeExceededError{c.timeAllowed, now.Sub(c.t0), c.docBase + doc}
+		return ErrCollectionTerminated
+	}
+	return c.collector.Collect(doc)
+}
+
+func (c *TimeLimitingCollector) SetNextReader(ctx *index.AtomicReaderContext) error {
+	if c.exceeded != nil {
+		return ErrCollectionTerminated // no more segments once timed out
+	}
+	if err := c.collector.SetNextReader(ctx); err != nil {
+		return err
+	}
+	c.docBase = ctx.DocBase
+	if c.t0.IsZero() {
+		c.SetBaseline(c.clock())
+	}
+	return nil
+}
+
+func (c *TimeLimitingCollector) SetScorer(scorer Scorer) {
+	c.collector.SetScorer(scorer)
+}
+
+func (c *TimeLimitingCollector) AcceptsDocsOutOfOrder() bool {
+	return c.collector.AcceptsDocsOutOfOrder()
+}
@@ -0,0 +1,357 @@
+package search
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+)
+
+// search/FieldDoc.java
+
+/*
+Expert: A ScoreDoc which also contains information about how to sort
+the referenced document. In addition to the document number and
+score, this object contains an array of values for the document from
+the field(s) used to sort. For example, if the sort criteria was to
+sort by fields "a", "b" then "c", the fields object array will have
+three elements, corresponding respectively to the term values for
+the document in fields "a", "b" and "c". The class of each element in
+the array will be either int32, int64, float32, float64 or []byte
+depending on the type of values in the terms of each field.
+*/
+type FieldDoc struct {
+	*ScoreDoc
+	// Expert: The values which are used to sort the referenced
+	// document. The order of these will match the original sort
+	// criteria given by a Sort object. Each Object will have been
+	// returned from the Value() method corresponding FieldComparator
+	// used to sort this field.
+	Fields []interface{}
+}
+
+func (d *FieldDoc) String() string {
+	return fmt.Sprintf("%v fields=%v", d.ScoreDoc, d.Fields)
+}
+
+// search/TopFieldDocs.java
+
+/*
+Represents hits returned by IndexSearcher.SearchSorted(). FieldDocs
+holds the same hits as ScoreDocs, in the same order, with the sort
+values attached.
+*/
+type TopFieldDocs struct {
+	TopDocs
+	// The fields which were used to sort results by.
+	Fields []*SortField
+	// The top hits, with their sort values.
+	FieldDocs []*FieldDoc
+}
+
+// search/FieldValueHitQueue.java
+
+type fieldValueHitQueueEntry struct {
+	*ScoreDoc
+	slot int
+}
+
+func (e *fieldValueHitQueueEntry) String() string {
+	return fmt.Sprintf("slot:%v %v", e.slot, e.ScoreDoc)
+}
+
+/*
+Expert: A hit queue for sorting by hits by terms in more than one
+field. Uses FieldCache for maintaining internal term lookup tables.
+*/
+type fieldValueHitQueue struct {
+	*PriorityQueue
+	// Stores the sort criteria being used.
+	fields      []*SortField
+	comparators []FieldComparator
+	reverseMul  []int
+}
+
+func newFieldValueHitQueue(fields []*SortField, size int) *fieldValueHitQueue {
+	assert2(len(fields) > 0, "Sort must contain at least one field")
+	q := &fieldValueHitQueue{
+		PriorityQueue: &PriorityQueue{items: make([]interface{}, 0, size)},
+		fields:        fields,
+		comparators:   make([]FieldComparator, len(fields)),
+		reverseMul:    make([]int, len(fields)),
+	}
+	for i, field := range fields {
+		if field.reverse {
+			q.reverseMul[i] = -1
+		} else {
+			q.reverseMul[i] = 1
+		}
+		q.comparators[i] = field.comparator(size, i)
+	}
+	q.less = func(i, j int) bool {
+		return q.lessThan(q.items[i].(*fieldValueHitQueueEntry), q.items[j].(*fieldValueHitQueueEntry))
+	}
+	return q
+}
+
+func (q *fieldValueHitQueue) lessThan(hitA, hitB *fieldValueHitQueueEntry) bool {
+	assert(hitA != hitB)
+	assert(hitA.slot != hitB.slot)
+
+	for i, comparator := range q.comparators {
+		if c := q.reverseMul[i] * comparator.Compare(hitA.slot, hitB.slot); c != 0 {
+			// Short circuit
+			return c > 0
+		}
+	}
+
+	// avoid random sort order that could lead to duplicates
+	return hitA.Doc > hitB.Doc
+}
+
+/*
+Given a queue Entry, creates a corresponding FieldDoc that contains
+the values used to sort the given document. These values are not the
+raw values out of the index, but the internal representation of
+them. This is so the given search hit can be collated by a
+MultiSearcher with other search hits.
+*/
+func (q *fieldValueHitQueue) fillFields(entry *fieldValueHitQueueEntry) *FieldDoc {
+	fields := make([]interface{}, len(q.comparators))
+	for i, comparator := range q.comparators {
+		fields[i] = comparator.Value(entry.slot)
+	}
+	return &FieldDoc{entry.ScoreDoc, fields}
+}
+
+// search/TopFieldCollector.java
+
+/*
+A Collector that sorts by SortField using FieldComparators.
+
+Unlike Lucene, which specializes a collector class for each
+combination of options, a single implementation handles any number
+of comparators, in- or out-of-order scoring and score tracking.
+*/
+type TopFieldCollector struct {
+	*abstractTopDocsCollector
+	queue      *fieldValueHitQueue
+	numHits    int
+	fillFields bool
+
+	trackDocScores    bool
+	trackMaxScore     bool
+	docsScoredInOrder bool
+
+	maxScore  float32
+	queueFull bool
+	bottom    *fieldValueHitQueueEntry
+	docBase   int
+	scorer    Scorer
+
+	// sorted hits with their values, as populated by TopDocs()
+	fieldDocs []*FieldDoc
+}
+
+/*
+Creates a new TopFieldCollector from the given arguments.
+
+NOTE: The instances returned by this method pre-allocate a full array
+of length numHits.
+
+  - sort: the sort criteria (SortFields).
+  - numHits: the number of results to collect.
+  - fillFields: specifies whether the actual field values should be
+    returned on the results (FieldDoc).
+  - trackDocScores: specifies whether document scores should be
+    tracked and set on the results. Note that if set to false, then
+    the results' scores will be set to NaN. Setting this to true
+    affects performance, as it incurs the score computation on each
+    competitive result. Therefore if document scores are not required
+    by the application, it is recommended to set it to false.
+  - trackMaxScore: specifies whether the query's maxScore should be
+    tracked and set on the resulting TopDocs. Note that if set to
+    false, TopDocs.MaxScore() returns NaN. Setting this to true
+    affects performance as it incurs the score computation on each
+    result. Also, setting this true automatically sets trackDocScores
+    to true as well.
+  - docsScoredInOrder: specifies whether documents are scored in doc
+    Id order or not by the given Scorer in SetScorer().
+*/
+func NewTopFieldCollector(sort *Sort, numHits int, fillFields,
+	trackDocScores, trackMaxScore, docsScoredInOrder bool) *TopFieldCollector {
+
+	assert2(len(sort.fields) > 0, "Sort must contain at least one field")
+	assert2(numHits > 0, "numHits must be > 0; please use TotalHitCountCollector if you just need the total hit count")
+
+	c := &TopFieldCollector{
+		queue:             newFieldValueHitQueue(sort.fields, numHits),
+		numHits:           numHits,
+		fillFields:        fillFields,
+		trackDocScores:    trackDocScores || trackMaxScore,
+		trackMaxScore:     trackMaxScore,
+		docsScoredInOrder: docsScoredInOrder,
+		maxScore:          float32(math.NaN()),
+	}
+	if trackMaxScore {
+		c.maxScore = float32(math.Inf(-1))
+	}
+	c.abstractTopDocsCollector = newTopDocsCollector(c, c.queue.PriorityQueue)
+	return c
+}
+
+func (c *TopFieldCollector) updateBottom(doc int, score float32) {
+	c.bottom.Doc = c.docBase + doc
+	c.bottom.Score = score
+	c.bottom = c.pq.updateTop().(*fieldValueHitQueueEntry)
+}
+
+func (c *TopFieldCollector) add(slot, doc int, score float32) {
+	heap.Push(c.pq, &fieldValueHitQueueEntry{newScoreDoc(c.docBase+doc, score), slot})
+	c.queueFull = c.TotalHits == c.numHits
+	if c.queueFull {
+		c.bottom = c.pq.items[0].(*fieldValueHitQueueEntry)
+	}
+}
+
+/* Returns whether doc can take the place of the bottom entry. */
+func (c *TopFieldCollector) competitive(doc int) (bool, error) {
+	last := len(c.queue.comparators) - 1
+	for i, comparator := range c.queue.comparators {
+		cmp, err := comparator.CompareBottom(doc)
+		if err != nil {
+			return false, err
+		}
+		if cmp = c.queue.reverseMul[i] * cmp; cmp < 0 {
+			// Definitely not competitive.
+			return false, nil
+		} else if cmp > 0 {
+			// Definitely competitive.
+			return true, nil
+		} else if i == last {
+			// This is the equals case. If docs are visited in doc Id
+			// order, this doc cannot compete since the bottom entry favors
+			// lower doc Ids; otherwise break the tie by doc Id.
+			return !c.docsScoredInOrder && doc+c.docBase < c.bottom.Doc, nil
+		}
+	}
+	panic("should not be here")
+}
+
+func (c *TopFieldCollector) Collect(doc int) (err error) {
+	score := float32(math.NaN())
+	if c.trackMaxScore {
+		if score, err = c.scorer.Score(); err != nil {
+			return err
+		}
+		if score > c.maxScore {
+			c.maxScore = score
+		}
+	}
+	c.TotalHits++
+
+	if c.queueFull {
+		ok, err := c.competitive(doc)
+		if err != nil || !ok {
+			return err
+		}
+
+		// This hit is competitive - replace bottom element in queue &
+		// adjustTop
+		for _, comparator := range c.queue.comparators {
+			if err = comparator.Copy(c.bottom.slot, doc); err != nil {
+				return err
+			}
+		}
+
+		// Compute score only if it is competitive.
+		if c.trackDocScores && !c.trackMaxScore {
+			if score, err = c.scorer.Score(); err != nil {
+				return err
+			}
+		}
+		c.updateBottom(doc, score)
+
+		for _, comparator := range c.queue.comparators {
+			comparator.SetBottom(c.bottom.slot)
+		}
+	} else {
+		// Startup transient: queue hasn't gathered numHits yet
+		slot := c.TotalHits - 1
+		for _, comparator := range c.queue.comparators {
+			if err = comparator.Copy(slot, doc); err != nil {
+				return err
+			}
+		}
+
+		// Compute score only if it is competitive.
+		if c.trackDocScores && !c.trackMaxScore {
+			if score, err = c.scorer.Score(); err != nil {
+				return err
+			}
+		}
+		c.add(slot, doc, score)
+		if c.queueFull {
+			for _, comparator := range c.queue.comparators {
+				comparator.SetBottom(c.bottom.slot)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *TopFieldCollector) SetNextReader(context *index.AtomicReaderContext) (err error) {
+	c.docBase = context.DocBase
+	for i, comparator := range c.queue.comparators {
+		if c.queue.comparators[i], err = comparator.SetNextReader(context); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *TopFieldCollector) SetScorer(scorer Scorer) {
+	c.scorer = scorer
+	for _, comparator := range c.queue.comparators {
+		comparator.SetScorer(scorer)
+	}
+}
+
+func (c *TopFieldCollector) AcceptsDocsOutOfOrder() bool {
+	return !c.docsScoredInOrder
+}
+
+func (c *TopFieldCollector) populateResults(results []*ScoreDoc, howMany int) {
+	c.fieldDocs = make([]*FieldDoc, howMany)
+	for i := howMany - 1; i >= 0; i-- {
+		entry := heap.Pop(c.pq).(*fieldValueHitQueueEntry)
+		if c.fillFields {
+			// avoid casting if unnecessary.
+			c.fieldDocs[i] = c.queue.fillFields(entry)
+		} else {
+			c.fieldDocs[i] = &FieldDoc{ScoreDoc: entry.ScoreDoc}
+		}
+		results[i] = c.fieldDocs[i].ScoreDoc
+	}
+}
+
+func (c *TopFieldCollector) newTopDocs(results []*ScoreDoc, start int) TopDocs {
+	if results == nil {
+		c.fieldDocs = []*FieldDoc{}
+		// Set maxScore to NaN, in case this is a maxScore tracking
+		// collector.
+		return TopDocs{c.TotalHits, []*ScoreDoc{}, math.NaN()}
+	}
+	return TopDocs{c.TotalHits, results, float64(c.maxScore)}
+}
+
+/*
+Returns the top hits collected by this collector, with their sort
+values attached if fillFields was requested.
+
+NOTE: like TopDocs(), this can only be called once per search.
+*/
+func (c *TopFieldCollector) TopFieldDocs() TopFieldDocs {
+	topDocs := c.TopDocs()
+	return TopFieldDocs{topDocs, c.queue.fields, c.fieldDocs}
+}
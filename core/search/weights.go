@@ -38,7 +38,14 @@ type Weight interface {
 	ValueForNormalization() float32
 	/** Assigns the query normalization factor and boost from parent queries to this. */
 	Normalize(norm float32, topLevelBoost float32)
-	// Scorer(*index.AtomicReaderContext, util.Bits) (Scorer, error)
+	/*
+		Returns a Scorer which scores documents in order. A Scorer iterates
+		over documents matching this query in doc-id order.
+
+		NOTE: nil can be returned if no documents will be scored by this
+		query.
+	*/
+	Scorer(*index.AtomicReaderContext, util.Bits) (Scorer, error)
 	/**
 	 * Returns a {@link Scorer} which scores documents in/out-of order according
 	 * to <code>scoreDocsInOrder</code>.
@@ -74,7 +81,7 @@ type WeightImpl struct {
 	spi WeightImplSPI
 }
 
-func newWeightImpl(spi WeightImplSPI) *WeightImpl {
+func NewWeightImpl(spi WeightImplSPI) *WeightImpl {
 	return &WeightImpl{spi}
 }
 
@@ -108,7 +115,7 @@ func newDefaultScorer(scorer Scorer) *DefaultBulkScorer {
 
 func (s *DefaultBulkScorer) ScoreAndCollectUpto(collector Collector, max int) (ok bool, err error) {
 	collector.SetScorer(s.scorer)
-	if max == NO_MORE_DOCS {
+	if max == NO_MORE_DOCS && s.scorer.DocId() == -1 {
 		return false, s.scoreAll(collector, s.scorer)
 	}
 	doc := s.scorer.DocId()
@@ -135,7 +142,9 @@ func (s *DefaultBulkScorer) scoreRange(collector Collector,
 func (s *DefaultBulkScorer) scoreAll(collector Collector, scorer Scorer) (err error) {
 	var doc int
 	for doc, err = scorer.NextDoc(); doc != NO_MORE_DOCS && err == nil; doc, err = scorer.NextDoc() {
-		err = collector.Collect(doc)
+		if err = collector.Collect(doc); err != nil {
+			break
+		}
 	}
 	return
 }
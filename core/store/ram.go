@@ -140,7 +140,7 @@ func (rd *RAMDirectory) Sync(names []string) error {
 func (rd *RAMDirectory) OpenInput(name string, context IOContext) (in IndexInput, err error) {
 	rd.EnsureOpen()
 	if file, ok := rd.fileMap[name]; ok {
-		return newRAMInputStream(name, file)
+		return NewRAMInputStream(name, file)
 	}
 	return nil, errors.New(name)
 }
@@ -356,7 +356,7 @@ type RAMInputStream struct {
 	bufferLength   int
 }
 
-func newRAMInputStream(name string, f *RAMFile) (in *RAMInputStream, err error) {
+func NewRAMInputStream(name string, f *RAMFile) (in *RAMInputStream, err error) {
 	if !(f.length/BUFFER_SIZE < math.MaxInt32) {
 		return nil, errors.New(fmt.Sprintf("RAMInputStream too large length=%v: %v", f.length, name))
 	}
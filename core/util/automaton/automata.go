@@ -14,25 +14,38 @@ func MakeEmpty() *Automaton {
 }
 
 // Returns a new (deterministic) automaton that accepts only the empty string.
-func makeEmptyString() *Automaton {
-	panic("not implemented yet")
+func MakeEmptyString() *Automaton {
+	a := newEmptyAutomaton()
+	a.createState()
+	a.setAccept(0, true)
+	return a
+}
+
+// Returns a new (deterministic) automaton that accepts all strings.
+func MakeAnyString() *Automaton {
+	a := newEmptyAutomaton()
+	s := a.createState()
+	a.setAccept(s, true)
+	a.addTransitionRange(s, s, MIN_CODE_POINT, unicode.MaxRune)
+	a.finishState()
+	return a
 }
 
 // Returns a new (deterministic) automaton that accepts any single codepoint.
-func makeAnyChar() *Automaton {
-	return makeCharRange(MIN_CODE_POINT, unicode.MaxRune)
+func MakeAnyChar() *Automaton {
+	return MakeCharRange(MIN_CODE_POINT, unicode.MaxRune)
 }
 
 // Returns a new (deterministic) automaton that accepts a single codepoint of the given value.
-func makeChar(c int) *Automaton {
-	return makeCharRange(c, c)
+func MakeChar(c int) *Automaton {
+	return MakeCharRange(c, c)
 }
 
 /*
 Returns a new (deterministic) automaton that accepts a single rune
 whose value is in the given interval (including both end points)
 */
-func makeCharRange(min, max int) *Automaton {
+func MakeCharRange(min, max int) *Automaton {
 	if min > max {
 		return MakeEmpty()
 	}
@@ -48,7 +61,7 @@ func makeCharRange(min, max int) *Automaton {
 
 // L237
 // Returns a new (deterministic) automaton that accepts the single given string
-func makeString(s string) *Automaton {
+func MakeString(s string) *Automaton {
 	a := newEmptyAutomaton()
 	lastState := a.createState()
 	for _, r := range s {
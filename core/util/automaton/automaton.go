@@ -52,7 +52,7 @@ func (a *Automaton) createState() int {
 
 /* Set or clear this state as an accept state. */
 func (a *Automaton) setAccept(state int, accept bool) {
-	assert2(state < a.numStates(), "state=%v is out of bounds (numStates=%v)", state, a.numStates())
+	assert2(state < a.NumStates(), "state=%v is out of bounds (numStates=%v)", state, a.NumStates())
 	if accept {
 		a.isAccept.Set(int64(state))
 	} else {
@@ -65,10 +65,10 @@ Sugar to get all transitions for all states. This is object-heavy;
 it's better to iterate state by state instead.
 */
 func (a *Automaton) sortedTransitions() [][]*Transition {
-	numStates := a.numStates()
+	numStates := a.NumStates()
 	transitions := make([][]*Transition, numStates)
 	for s := 0; s < numStates; s++ {
-		numTransitions := a.numTransitions(s)
+		numTransitions := a.NumTransitions(s)
 		transitions[s] = make([]*Transition, numTransitions)
 		for t := 0; t < numTransitions; t++ {
 			transition := newTransition()
@@ -92,8 +92,8 @@ func (a *Automaton) addTransition(source, dest, label int) {
 /* Add a new transition with the specified source, dest, min, max. */
 func (a *Automaton) addTransitionRange(source, dest, min, max int) {
 	assert(len(a.transitions)%3 == 0)
-	assert2(source < a.numStates(), "source=%v is out of bounds (maxState is %v)", source, a.numStates()-1)
-	assert2(dest < a.numStates(), "dest=%v is out of bounds (maxState is %v)", dest, a.numStates()-1)
+	assert2(source < a.NumStates(), "source=%v is out of bounds (maxState is %v)", source, a.NumStates()-1)
+	assert2(dest < a.NumStates(), "dest=%v is out of bounds (maxState is %v)", dest, a.NumStates()-1)
 
 	if a.curState != source {
 		if a.curState != -1 {
@@ -120,10 +120,10 @@ simply copies those same transitions over to source.
 */
 func (a *Automaton) addEpsilon(source, dest int) {
 	t := newTransition()
-	count := a.initTransition(dest, t)
+	count := a.InitTransition(dest, t)
 	for i := 0; i < count; i++ {
-		a.nextTransition(t)
-		a.addTransitionRange(source, t.dest, t.min, t.max)
+		a.NextTransition(t)
+		a.addTransitionRange(source, t.Dest, t.Min, t.Max)
 	}
 	if a.IsAccept(dest) {
 		a.setAccept(source, true)
@@ -136,7 +136,7 @@ sequentially assigned (appended).
 */
 func (a *Automaton) copy(other *Automaton) {
 	// bulk copy and then fixup the state pointers
-	stateOffset := a.numStates()
+	stateOffset := a.NumStates()
 	a.states = append(a.states, other.states...)
 	for i := 0; i < len(other.states); i += 2 {
 		if a.states[stateOffset*2+i] != -1 {
@@ -243,12 +243,12 @@ func (a *Automaton) finishState() {
 }
 
 /* How many states this automaton has. */
-func (a *Automaton) numStates() int {
+func (a *Automaton) NumStates() int {
 	return len(a.states) / 2
 }
 
 /* How many transitions this state has. */
-func (a *Automaton) numTransitions(state int) int {
+func (a *Automaton) NumTransitions(state int) int {
 	if count := a.states[2*state+1]; count != -1 {
 		return count
 	}
@@ -335,20 +335,20 @@ Initialize the provided Transition to iterate through all transitions
 leaving the specified state. You must call nextTransition() to get
 each transition. Returns the number of transitions leaving this tate.
 */
-func (a *Automaton) initTransition(state int, t *Transition) int {
-	assert2(state < a.numStates(), "state=%v nextState=%v", state, a.numStates())
-	t.source = state
+func (a *Automaton) InitTransition(state int, t *Transition) int {
+	assert2(state < a.NumStates(), "state=%v nextState=%v", state, a.NumStates())
+	t.Source = state
 	t.transitionUpto = a.states[2*state]
-	return a.numTransitions(state)
+	return a.NumTransitions(state)
 }
 
 /* Iterate to the next transition after the provided one */
-func (a *Automaton) nextTransition(t *Transition) {
+func (a *Automaton) NextTransition(t *Transition) {
 	// make sure there is still a transition left
-	assert((t.transitionUpto + 3 - a.states[2*t.source]) <= 3*a.states[2*t.source+1])
-	t.dest = a.transitions[t.transitionUpto]
-	t.min = a.transitions[t.transitionUpto+1]
-	t.max = a.transitions[t.transitionUpto+2]
+	assert((t.transitionUpto + 3 - a.states[2*t.Source]) <= 3*a.states[2*t.Source+1])
+	t.Dest = a.transitions[t.transitionUpto]
+	t.Min = a.transitions[t.transitionUpto+1]
+	t.Max = a.transitions[t.transitionUpto+2]
 	t.transitionUpto += 3
 }
 
@@ -358,10 +358,10 @@ specified state.
 */
 func (a *Automaton) transition(state, index int, t *Transition) {
 	i := a.states[2*state] + 3*index
-	t.source = state
-	t.dest = a.transitions[i]
-	t.min = a.transitions[i+1]
-	t.max = a.transitions[i+2]
+	t.Source = state
+	t.Dest = a.transitions[i]
+	t.Min = a.transitions[i+1]
+	t.Max = a.transitions[i+2]
 }
 
 // L563
@@ -393,7 +393,7 @@ func (a *Automaton) startPoints() []int {
 }
 
 /* Performs lookup in transitions, assuming determinism. */
-func (a *Automaton) step(state, label int) int {
+func (a *Automaton) Step(state, label int) int {
 	assert(state >= 0)
 	assert(label >= 0)
 	if 2*state >= len(a.states) {
@@ -489,6 +489,23 @@ func (b *AutomatonBuilder) finish() *Automaton {
 	return b.a
 }
 
+/*
+Add a [virtual] epsilon transition between source and dest. Dest
+state must already have all transitions added because this method
+simply copies those same transitions over to source.
+*/
+func (b *AutomatonBuilder) addEpsilon(source, dest int) {
+	for upto, limit := 0, len(b.transitions); upto < limit; upto += 4 {
+		if b.transitions[upto] == dest {
+			b.transitions = append(b.transitions, source,
+				b.transitions[upto+1], b.transitions[upto+2], b.transitions[upto+3])
+		}
+	}
+	if b.isAccept(dest) {
+		b.setAccept(source, true)
+	}
+}
+
 func (b *AutomatonBuilder) createState() int {
 	return b.a.createState()
 }
@@ -502,18 +519,18 @@ func (b *AutomatonBuilder) isAccept(state int) bool {
 }
 
 func (b *AutomatonBuilder) copy(other *Automaton) {
-	offset := b.a.numStates()
-	otherNumStates := other.numStates()
+	offset := b.a.NumStates()
+	otherNumStates := other.NumStates()
 	for s := 0; s < otherNumStates; s++ {
 		newState := b.createState()
 		b.setAccept(newState, other.IsAccept(s))
 	}
 	t := newTransition()
 	for s := 0; s < otherNumStates; s++ {
-		count := other.initTransition(s, t)
+		count := other.InitTransition(s, t)
 		for i := 0; i < count; i++ {
-			other.nextTransition(t)
-			b.addTransitionRange(offset+s, offset+t.dest, t.min, t.max)
+			other.NextTransition(t)
+			b.addTransitionRange(offset+s, offset+t.Dest, t.Min, t.Max)
 		}
 	}
 }
@@ -14,26 +14,26 @@ func TestRegExpToAutomaton(t *testing.T) {
 	a := NewRegExp("[^ \t\r\n]+").ToAutomaton()
 	assert(a.deterministic)
 	assert(-1 == a.curState)
-	assert(2 == a.numStates())
+	assert(2 == a.NumStates())
 }
 
 func TestMinusSimple(t *testing.T) {
-	assert(sameLanguage(makeChar('b'), minus(makeCharRange('a', 'b'), makeChar('a'))))
-	assert(sameLanguage(MakeEmpty(), minus(makeChar('a'), makeChar('a'))))
+	assert(sameLanguage(MakeChar('b'), Minus(MakeCharRange('a', 'b'), MakeChar('a'))))
+	assert(sameLanguage(MakeEmpty(), Minus(MakeChar('a'), MakeChar('a'))))
 }
 
 func TestComplementSimple(t *testing.T) {
-	a := makeChar('a')
+	a := MakeChar('a')
 	assert(sameLanguage(a, complement(complement(a))))
 }
 
 func TestDeterminizeSimple(t *testing.T) {
 	a1 := complement(NewRegExpWithFlag("-", NONE).ToAutomaton())
 	a2 := NewRegExpWithFlag("ݖ|+", NONE).ToAutomaton()
-	a := concatenate(a1, a2)
+	a := Concatenate(a1, a2)
 	a = removeDeadStates(a)
-	a = determinize(a)
-	assert(a.numStates() == 4)
+	a = Determinize(a)
+	assert(a.NumStates() == 4)
 }
 
 // func TestStringUnion(t testing.T) {
@@ -154,16 +154,16 @@ func randomAutomaton(r *rand.Rand) *Automaton {
 	switch r.Intn(4) {
 	case 0:
 		// fmt.Println("DEBUG way 0")
-		return concatenate(a1, a2)
+		return Concatenate(a1, a2)
 	case 1:
 		// fmt.Println("DEBUG way 1")
-		return union(a1, a2)
+		return Union(a1, a2)
 	case 2:
 		// fmt.Println("DEBUG way 2")
-		return intersection(a1, a2)
+		return Intersection(a1, a2)
 	default:
 		// fmt.Println("DEBUG way 3")
-		return minus(a1, a2)
+		return Minus(a1, a2)
 	}
 }
 
@@ -218,7 +218,7 @@ Simple original brics implementation of determinize()
 Determinizes the given automaton using the given set of initial states.
 */
 func determinizeSimple(a *Automaton, initialset map[int]bool) *Automaton {
-	if a.numStates() == 0 {
+	if a.NumStates() == 0 {
 		return a
 	}
 	points := a.startPoints()
@@ -251,11 +251,11 @@ func determinizeSimple(a *Automaton, initialset map[int]bool) *Automaton {
 		for n, point := range points {
 			p := make(map[int]bool)
 			for q, _ := range s {
-				count := a.initTransition(q, t)
+				count := a.InitTransition(q, t)
 				for i := 0; i < count; i++ {
-					a.nextTransition(t)
-					if t.min <= point && point <= t.max {
-						p[t.dest] = true
+					a.NextTransition(t)
+					if t.Min <= point && point <= t.Max {
+						p[t.Dest] = true
 					}
 				}
 			}
@@ -0,0 +1,98 @@
+package automaton
+
+// util/automaton/CompiledAutomaton.java
+
+// Automata classification for query optimisation.
+type AutomatonType int
+
+const (
+	AUTOMATON_TYPE_NONE   = AutomatonType(1) // Automaton that accepts no strings.
+	AUTOMATON_TYPE_ALL    = AutomatonType(2) // Automaton that accepts all possible strings.
+	AUTOMATON_TYPE_SINGLE = AutomatonType(3) // Automaton that accepts only a single fixed string.
+	AUTOMATON_TYPE_NORMAL = AutomatonType(4) // Catch-all for any other automata.
+)
+
+/*
+Immutable class holding compiled details for a given Automaton. The
+Automaton is deterministic, must not have dead states but is not
+necessarily minimal.
+*/
+type CompiledAutomaton struct {
+	Type AutomatonType
+	/*
+		For AUTOMATON_TYPE_SINGLE this is the singleton term, as UTF-8
+		bytes.
+	*/
+	Term []byte
+	/*
+		Matcher for quickly determining if a []byte is accepted. Only
+		valid for AUTOMATON_TYPE_NORMAL.
+	*/
+	RunAutomaton *ByteRunAutomaton
+	/*
+		Two dimensional array of transitions, indexed by state number for
+		traversal. The state numbering is consistent with RunAutomaton.
+		Only valid for AUTOMATON_TYPE_NORMAL.
+	*/
+	Automaton *Automaton
+	/*
+		Shared common suffix accepted by the automaton. Only valid for
+		AUTOMATON_TYPE_NORMAL, and only when the automaton accepts an
+		infinite language.
+	*/
+	CommonSuffixRef []byte
+	/*
+		Indicates if the automaton accepts a finite set of strings. Only
+		valid for AUTOMATON_TYPE_NORMAL.
+	*/
+	Finite bool
+}
+
+/*
+Create this, passing simplify=true and finite computed from the
+automaton, so that we try to simplify the automaton.
+*/
+func NewCompiledAutomaton(automaton *Automaton) *CompiledAutomaton {
+	ans := new(CompiledAutomaton)
+	if automaton.NumStates() == 0 {
+		automaton = newEmptyAutomaton()
+		automaton.createState()
+	}
+
+	// Test whether the automaton is a "simple" form and if so, don't
+	// create a runAutomaton. Note that on a large automaton these
+	// tests could be costly:
+	if isEmpty(automaton) {
+		// matches nothing
+		ans.Type = AUTOMATON_TYPE_NONE
+		return ans
+	}
+	// NOTE: only approximate, because automaton may not be minimal:
+	if isTotal(automaton) {
+		// matches all possible strings
+		ans.Type = AUTOMATON_TYPE_ALL
+		return ans
+	}
+	automaton = Determinize(automaton)
+	if singleton, ok := getSingleton(automaton); ok {
+		// matches a fixed string
+		ans.Type = AUTOMATON_TYPE_SINGLE
+		runes := make([]rune, len(singleton))
+		for i, c := range singleton {
+			runes[i] = rune(c)
+		}
+		ans.Term = []byte(string(runes))
+		return ans
+	}
+
+	ans.Type = AUTOMATON_TYPE_NORMAL
+	ans.Finite = isFinite(automaton)
+
+	utf8 := new(UTF32ToUTF8).Convert(automaton)
+	if !ans.Finite {
+		ans.CommonSuffixRef = commonSuffixBytes(utf8)
+	}
+	ans.RunAutomaton = NewByteRunAutomaton(utf8, true)
+	ans.Automaton = ans.RunAutomaton.automaton
+	return ans
+}
@@ -0,0 +1,44 @@
+package automaton
+
+import (
+	"testing"
+)
+
+func TestCompiledAutomatonType(t *testing.T) {
+	assert(AUTOMATON_TYPE_NONE == NewCompiledAutomaton(MakeEmpty()).Type)
+	assert(AUTOMATON_TYPE_ALL == NewCompiledAutomaton(MakeAnyString()).Type)
+
+	c := NewCompiledAutomaton(NewRegExp("fo(o)").ToAutomaton())
+	assert(AUTOMATON_TYPE_SINGLE == c.Type)
+	assert2("foo" == string(c.Term), "term=%v", string(c.Term))
+
+	c = NewCompiledAutomaton(NewRegExp("f.*ar").ToAutomaton())
+	assert(AUTOMATON_TYPE_NORMAL == c.Type)
+	assert(!c.Finite)
+	assert2("ar" == string(c.CommonSuffixRef), "suffix=%v", string(c.CommonSuffixRef))
+
+	c = NewCompiledAutomaton(NewRegExp("ba[rz]{1,2}").ToAutomaton())
+	assert(AUTOMATON_TYPE_NORMAL == c.Type)
+	assert(c.Finite)
+}
+
+func TestByteRunAutomaton(t *testing.T) {
+	ra := NewByteRunAutomaton(NewRegExp("[a-zé]+ü?.").ToAutomaton(), false)
+	for _, s := range []string{"ab", "éé€", "abü中", "aü"} {
+		assert2(ra.Run([]byte(s)), "expect %v to be accepted", s)
+	}
+	for _, s := range []string{"", "a", "1a", "Éa", "aüüü"} {
+		assert2(!ra.Run([]byte(s)), "expect %v to be rejected", s)
+	}
+}
+
+func TestRepeatRange(t *testing.T) {
+	a := NewRegExp("(ab*){2,3}").ToAutomaton()
+	ra := NewByteRunAutomaton(a, false)
+	for _, s := range []string{"aa", "abab", "aabbba", "aaa"} {
+		assert2(ra.Run([]byte(s)), "expect %v to be accepted", s)
+	}
+	for _, s := range []string{"a", "ab", "aaaa", "b"} {
+		assert2(!ra.Run([]byte(s)), "expect %v to be rejected", s)
+	}
+}
@@ -0,0 +1,236 @@
+package automaton
+
+import (
+	"fmt"
+	"sort"
+)
+
+// util/automaton/Lev1ParametricDescription.java
+// util/automaton/Lev2ParametricDescription.java
+// util/automaton/Lev1TParametricDescription.java
+// util/automaton/Lev2TParametricDescription.java
+
+/*
+Lucene ships the parametric descriptions as tables generated offline
+by createLevAutomata.py. Here the same tables are computed once when
+the package is loaded, by exploring the parametric states of Schulz
+and Mihov's universal Levenshtein automaton.
+*/
+var (
+	lev1Tables  = newParametricTables(1, false)
+	lev2Tables  = newParametricTables(2, false)
+	lev1TTables = newParametricTables(1, true)
+	lev2TTables = newParametricTables(2, true)
+)
+
+/*
+A position of a parametric state: i is the offset into the word
+relative to the state's minimal boundary, e is the number of edits
+spent so far, and t marks a half-done transposition.
+*/
+type levPosition struct {
+	i, e int
+	t    bool
+}
+
+type levPositions []levPosition
+
+func (ps levPositions) Len() int      { return len(ps) }
+func (ps levPositions) Swap(i, j int) { ps[i], ps[j] = ps[j], ps[i] }
+func (ps levPositions) Less(i, j int) bool {
+	if ps[i].i != ps[j].i {
+		return ps[i].i < ps[j].i
+	}
+	if ps[i].e != ps[j].e {
+		return ps[i].e < ps[j].e
+	}
+	return !ps[i].t && ps[j].t
+}
+
+func (ps levPositions) key() string {
+	return fmt.Sprintf("%v", []levPosition(ps))
+}
+
+/*
+The transitions of all parametric states, for characteristic vectors
+of length k.
+*/
+type parametricTable struct {
+	k           int
+	toStates    []int // indexed by state<<k + vector, -1 for null state
+	offsetIncrs []int // indexed by state<<k + vector
+}
+
+type parametricTables struct {
+	n int
+	// minimal number of errors needed to accept, relative to the
+	// remaining length of the word, indexed by parametric state
+	minErrors []int
+	// indexed by the length of the characteristic vector, which
+	// ranges from 0 to 2n+1
+	tables []*parametricTable
+}
+
+/* Returns the table to use when length chars of the word remain. */
+func (t *parametricTables) forLength(length int) *parametricTable {
+	if length >= len(t.tables) {
+		return t.tables[len(t.tables)-1]
+	}
+	return t.tables[length]
+}
+
+func newParametricTables(n int, withTranspositions bool) *parametricTables {
+	g := &parametricGenerator{
+		n:                  n,
+		withTranspositions: withTranspositions,
+		index:              make(map[string]int),
+	}
+	// the initial state must be state 0
+	g.intern(levPositions{{0, 0, false}})
+
+	var transitions [][][2]int // [state][k<<(2n+2)|vector] -> {to, incr}
+	for state := 0; state < len(g.states); state++ {
+		row := make([][2]int, (2*n+2)<<uint(2*n+1))
+		for k := 0; k <= 2*n+1; k++ {
+			for vector := 0; vector < 1<<uint(k); vector++ {
+				to, incr := g.step(g.states[state], k, vector)
+				row[k<<uint(2*n+1)|vector] = [2]int{to, incr}
+			}
+		}
+		transitions = append(transitions, row)
+	}
+
+	ans := &parametricTables{n: n}
+	for _, ps := range g.states {
+		minError := 2*n + 2 // never accepting
+		for _, p := range ps {
+			if !p.t && p.e-p.i < minError {
+				minError = p.e - p.i
+			}
+		}
+		ans.minErrors = append(ans.minErrors, minError)
+	}
+	for k := 0; k <= 2*n+1; k++ {
+		t := &parametricTable{
+			k:           k,
+			toStates:    make([]int, len(g.states)<<uint(k)),
+			offsetIncrs: make([]int, len(g.states)<<uint(k)),
+		}
+		for state, row := range transitions {
+			for vector := 0; vector < 1<<uint(k); vector++ {
+				v := row[k<<uint(2*n+1)|vector]
+				t.toStates[state<<uint(k)+vector] = v[0]
+				t.offsetIncrs[state<<uint(k)+vector] = v[1]
+			}
+		}
+		ans.tables = append(ans.tables, t)
+	}
+	return ans
+}
+
+type parametricGenerator struct {
+	n                  int
+	withTranspositions bool
+	states             []levPositions
+	index              map[string]int
+}
+
+func (g *parametricGenerator) intern(ps levPositions) int {
+	key := ps.key()
+	if state, ok := g.index[key]; ok {
+		return state
+	}
+	state := len(g.states)
+	g.states = append(g.states, ps)
+	g.index[key] = state
+	return state
+}
+
+/*
+Computes the successor of a parametric state on a characteristic
+vector of length k, returning the successor state (-1 for the null
+state) and how far its minimal boundary moves along the word.
+*/
+func (g *parametricGenerator) step(ps levPositions, k, vector int) (int, int) {
+	bit := func(j int) bool {
+		return j < k && (vector>>uint(k-1-j))&1 == 1
+	}
+
+	var next levPositions
+	for _, p := range ps {
+		if p.t {
+			// complete the transposition
+			if bit(p.i) {
+				next = append(next, levPosition{p.i + 2, p.e, false})
+			}
+			continue
+		}
+		if bit(p.i) {
+			next = append(next, levPosition{p.i + 1, p.e, false})
+		}
+		if p.e < g.n {
+			// insertion
+			next = append(next, levPosition{p.i, p.e + 1, false})
+			// substitution
+			if p.i < k {
+				next = append(next, levPosition{p.i + 1, p.e + 1, false})
+			}
+			// deletions, followed by a match
+			for j := p.i + 1; j < k && j-p.i <= g.n-p.e; j++ {
+				if bit(j) {
+					next = append(next, levPosition{j + 1, p.e + j - p.i, false})
+				}
+			}
+			// start of a transposition
+			if g.withTranspositions && bit(p.i+1) {
+				next = append(next, levPosition{p.i, p.e + 1, true})
+			}
+		}
+	}
+	next = reducePositions(next)
+	if len(next) == 0 {
+		return -1, 0
+	}
+
+	// normalize, so that the minimal boundary is at offset 0
+	incr := next[0].i
+	for i, _ := range next {
+		next[i].i -= incr
+	}
+	return g.intern(next), incr
+}
+
+/*
+Removes duplicated positions, and those subsumed by another position,
+i.e. whose matched words are always matched by the other position as
+well. Returns the remaining positions in sorted order.
+*/
+func reducePositions(ps levPositions) levPositions {
+	sort.Sort(ps)
+	var ans levPositions
+	for i, p := range ps {
+		if i > 0 && p == ps[i-1] {
+			continue
+		}
+		subsumed := false
+		if !p.t {
+			for _, q := range ps {
+				if !q.t && q.e < p.e && abs(q.i-p.i) <= p.e-q.e {
+					subsumed = true
+					break
+				}
+			}
+		}
+		if !subsumed {
+			ans = append(ans, p)
+		}
+	}
+	return ans
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,289 @@
+package automaton
+
+import (
+	"sort"
+	"unicode"
+)
+
+// util/automaton/LevenshteinAutomata.java
+
+/*
+Maximum edit distance this type can generate an automaton for.
+*/
+const MAXIMUM_SUPPORTED_DISTANCE = 2
+
+/*
+Type to construct DFAs that match a word within some edit distance.
+
+Implements the algorithm described in: Schulz and Mihov: Fast String
+Correction with Levenshtein Automata
+*/
+type LevenshteinAutomata struct {
+	// the input word
+	word []int
+	// the automata alphabet
+	alphabet []int
+	// the maximum symbol in the alphabet (e.g. 255 for UTF-8 or 10FFFF
+	// for UTF-32)
+	alphaMax int
+
+	// the ranges outside of alphabet
+	rangeLower []int
+	rangeUpper []int
+
+	descriptions []*parametricDescription
+}
+
+/*
+Create a new LevenshteinAutomata for some input string. Optionally
+count transpositions as a primitive edit.
+*/
+func NewLevenshteinAutomata(input string, withTranspositions bool) *LevenshteinAutomata {
+	word := make([]int, 0, len(input))
+	for _, c := range input {
+		word = append(word, int(c))
+	}
+	return newLevenshteinAutomata(word, unicode.MaxRune, withTranspositions)
+}
+
+/*
+Expert: specify a custom maximum possible symbol (alphaMax); default
+is unicode.MaxRune.
+*/
+func newLevenshteinAutomata(word []int, alphaMax int, withTranspositions bool) *LevenshteinAutomata {
+	ans := &LevenshteinAutomata{word: word, alphaMax: alphaMax}
+
+	// calculate the alphabet
+	set := make(map[int]bool)
+	for _, v := range word {
+		assert2(v <= alphaMax, "alphaMax exceeded by symbol %v in word", v)
+		set[v] = true
+	}
+	ans.alphabet = make([]int, 0, len(set))
+	for v, _ := range set {
+		ans.alphabet = append(ans.alphabet, v)
+	}
+	sort.Ints(ans.alphabet)
+
+	// calculate the unicode range intervals that exclude the alphabet
+	// these are the ranges for all unicode characters not in the
+	// alphabet
+	lower := 0
+	for _, higher := range ans.alphabet {
+		if higher > lower {
+			ans.rangeLower = append(ans.rangeLower, lower)
+			ans.rangeUpper = append(ans.rangeUpper, higher-1)
+		}
+		lower = higher + 1
+	}
+	// add the final endpoint
+	if lower <= alphaMax {
+		ans.rangeLower = append(ans.rangeLower, lower)
+		ans.rangeUpper = append(ans.rangeUpper, alphaMax)
+	}
+
+	if withTranspositions {
+		ans.descriptions = []*parametricDescription{
+			nil, // for n=0, we do not need to go through the trouble
+			newParametricDescription(len(word), lev1TTables),
+			newParametricDescription(len(word), lev2TTables),
+		}
+	} else {
+		ans.descriptions = []*parametricDescription{
+			nil, // for n=0, we do not need to go through the trouble
+			newParametricDescription(len(word), lev1Tables),
+			newParametricDescription(len(word), lev2Tables),
+		}
+	}
+	return ans
+}
+
+/*
+Compute a DFA that accepts all strings within an edit distance of n.
+
+All automata have the following properties:
+
+  - They are deterministic (DFA).
+  - There are no transitions to dead states.
+  - They are not minimal (some transitions could be combined).
+
+Returns nil if n is greater than MAXIMUM_SUPPORTED_DISTANCE.
+*/
+func (la *LevenshteinAutomata) ToAutomaton(n int) *Automaton {
+	return la.ToAutomatonWithPrefix(n, "")
+}
+
+/*
+Compute a DFA that accepts all strings within an edit distance of n,
+matching the specified exact prefix.
+
+All automata have the following properties:
+
+  - They are deterministic (DFA).
+  - There are no transitions to dead states.
+  - They are not minimal (some transitions could be combined).
+
+Returns nil if n is greater than MAXIMUM_SUPPORTED_DISTANCE.
+*/
+func (la *LevenshteinAutomata) ToAutomatonWithPrefix(n int, prefix string) *Automaton {
+	assert(n >= 0)
+	if n == 0 {
+		runes := make([]rune, len(la.word))
+		for i, c := range la.word {
+			runes[i] = rune(c)
+		}
+		return MakeString(prefix + string(runes))
+	}
+
+	if n >= len(la.descriptions) {
+		return nil
+	}
+
+	rang := 2*n + 1
+	description := la.descriptions[n]
+	// the number of states is based on the length of the word and n
+	numStates := description.size()
+
+	a := newEmptyAutomaton()
+	lastState := -1
+	if prefix != "" {
+		// insert prefix
+		lastState = a.createState()
+		for _, c := range prefix {
+			state := a.createState()
+			a.addTransition(lastState, state, int(c))
+			lastState = state
+		}
+	}
+
+	stateOffset := a.NumStates()
+
+	// create all states, and mark as accept states if appropriate
+	for i := 0; i < numStates; i++ {
+		a.createState()
+		a.setAccept(i+stateOffset, description.isAccept(i))
+	}
+
+	// TODO: this creates bogus states/transitions (states are final,
+	// have self loops, and can't be reached from an init state)
+	for k := 0; k < numStates; k++ {
+		xpos := description.position(k)
+		if xpos < 0 {
+			continue
+		}
+		end := xpos + min(len(la.word)-xpos, rang)
+
+		for _, ch := range la.alphabet {
+			// get the characteristic vector at this position wrt ch
+			cvec := la.vector(ch, xpos, end)
+			if dest := description.transition(k, xpos, cvec); dest >= 0 {
+				a.addTransition(stateOffset+k, stateOffset+dest, ch)
+			}
+		}
+		// add transitions for all other chars in unicode. By definition,
+		// their characteristic vectors are always 0, because they do not
+		// exist in the input string.
+		if dest := description.transition(k, xpos, 0); dest >= 0 {
+			for r, lower := range la.rangeLower {
+				a.addTransitionRange(stateOffset+k, stateOffset+dest, lower, la.rangeUpper[r])
+			}
+		}
+	}
+
+	a.finishState()
+	if lastState != -1 {
+		a.addEpsilon(lastState, stateOffset)
+		a.finishState()
+	}
+	return a
+}
+
+/*
+Get the characteristic vector X(x, V) where V is
+substring(pos, end), with the first character of V in the most
+significant bit.
+*/
+func (la *LevenshteinAutomata) vector(x, pos, end int) int {
+	vector := 0
+	for i := pos; i < end; i++ {
+		vector <<= 1
+		if la.word[i] == x {
+			vector |= 1
+		}
+	}
+	return vector
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+/*
+A parametric description describes the states of a Levenshtein
+automaton independently of the input word: each absolute state of
+the automaton is a parametric state combined with an offset into the
+word.
+*/
+type parametricDescription struct {
+	w      int // input length
+	tables *parametricTables
+}
+
+func newParametricDescription(w int, tables *parametricTables) *parametricDescription {
+	return &parametricDescription{w, tables}
+}
+
+/* Return the number of states needed to compute a Levenshtein DFA */
+func (d *parametricDescription) size() int {
+	return len(d.tables.minErrors) * (d.w + 1)
+}
+
+/*
+Returns true if the state in any Levenshtein DFA is an accept state
+(final state).
+*/
+func (d *parametricDescription) isAccept(absState int) bool {
+	// decode absState -> state, offset
+	state := absState / (d.w + 1)
+	offset := absState % (d.w + 1)
+	assert(offset >= 0)
+	return d.w-offset+d.tables.minErrors[state] <= d.tables.n
+}
+
+/*
+Returns the position in the input word for a given state. This is the
+minimal boundary for the state.
+*/
+func (d *parametricDescription) position(absState int) int {
+	return absState % (d.w + 1)
+}
+
+/*
+Returns the state number for a transition from the given state,
+assuming position and characteristic vector vector.
+*/
+func (d *parametricDescription) transition(absState, position, vector int) int {
+	// null absState should never be passed in
+	assert(absState != -1)
+
+	// decode absState -> state, offset
+	state := absState / (d.w + 1)
+	offset := absState % (d.w + 1)
+	assert(offset >= 0)
+
+	t := d.tables.forLength(d.w - position)
+	next := t.toStates[state<<uint(t.k)+vector]
+	if next == -1 {
+		return -1 // null state
+	}
+	offset += t.offsetIncrs[state<<uint(t.k)+vector]
+	if offset > d.w {
+		// only reachable from states that can't occur in the automaton
+		return -1
+	}
+	// translate back to abs
+	return next*(d.w+1) + offset
+}
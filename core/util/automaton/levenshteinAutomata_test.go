@@ -0,0 +1,92 @@
+package automaton
+
+import (
+	"testing"
+)
+
+func TestLevenshteinAutomata(t *testing.T) {
+	for _, word := range []string{"", "a", "ab", "abc", "bab", "aaba", "abcab", "cabbac"} {
+		for _, transpositions := range []bool{false, true} {
+			builder := NewLevenshteinAutomata(word, transpositions)
+			for n := 0; n <= MAXIMUM_SUPPORTED_DISTANCE; n++ {
+				a := builder.ToAutomaton(n)
+				assert2(a != nil, "no automaton for %v with n=%v", word, n)
+				ra := NewByteRunAutomaton(a, false)
+				for _, s := range allStrings("abc", len(word)+n) {
+					expected := editDistance(word, s, transpositions) <= n
+					assert2(ra.Run([]byte(s)) == expected,
+						"word=%v n=%v transpositions=%v: expected %v for %v",
+						word, n, transpositions, expected, s)
+				}
+			}
+			assert(builder.ToAutomaton(MAXIMUM_SUPPORTED_DISTANCE+1) == nil)
+		}
+	}
+}
+
+func TestLevenshteinAutomataWithPrefix(t *testing.T) {
+	builder := NewLevenshteinAutomata("bc", true)
+	ra := NewByteRunAutomaton(builder.ToAutomatonWithPrefix(1, "ab"), false)
+	for _, s := range []string{"abbc", "abc", "abcb", "abbcc", "abac", "abxbc"} {
+		assert2(ra.Run([]byte(s)), "expect %v to be accepted", s)
+	}
+	for _, s := range []string{"bbc", "acbc", "ab", "abcbcb", "bc"} {
+		assert2(!ra.Run([]byte(s)), "expect %v to be rejected", s)
+	}
+}
+
+func TestLevenshteinAutomataUnicode(t *testing.T) {
+	ra := NewByteRunAutomaton(NewLevenshteinAutomata("café", false).ToAutomaton(1), false)
+	for _, s := range []string{"café", "cafe", "caf", "cafés", "caffé"} {
+		assert2(ra.Run([]byte(s)), "expect %v to be accepted", s)
+	}
+	for _, s := range []string{"cfe", "ca", "caffe"} {
+		assert2(!ra.Run([]byte(s)), "expect %v to be rejected", s)
+	}
+}
+
+// Returns all strings over the alphabet up to the given length.
+func allStrings(alphabet string, maxLength int) []string {
+	ans := []string{""}
+	last := []string{""}
+	for length := 1; length <= maxLength; length++ {
+		var next []string
+		for _, s := range last {
+			for _, c := range alphabet {
+				next = append(next, s+string(c))
+			}
+		}
+		ans = append(ans, next...)
+		last = next
+	}
+	return ans
+}
+
+/*
+Computes the Levenshtein distance between s1 and s2, optionally
+counting the transposition of adjacent chars as a single edit.
+*/
+func editDistance(s1, s2 string, transpositions bool) int {
+	a, b := []rune(s1), []rune(s2)
+	d := make([][]int, len(a)+1)
+	for i, _ := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= len(b); j++ {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(min(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if transpositions && i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -15,8 +15,8 @@ languages of the given automata.
 
 Complexity: linear in total number of states.
 */
-func concatenate(a1, a2 *Automaton) *Automaton {
-	return concatenateN([]*Automaton{a1, a2})
+func Concatenate(a1, a2 *Automaton) *Automaton {
+	return ConcatenateN([]*Automaton{a1, a2})
 }
 
 /*
@@ -25,16 +25,16 @@ languages of the given automata.
 
 Complexity: linear in total number of states.
 */
-func concatenateN(l []*Automaton) *Automaton {
+func ConcatenateN(l []*Automaton) *Automaton {
 	ans := newEmptyAutomaton()
 
 	// first pass: create all states
 	for _, a := range l {
-		if a.numStates() == 0 {
+		if a.NumStates() == 0 {
 			ans.finishState()
 			return ans
 		}
-		numStates := a.numStates()
+		numStates := a.NumStates()
 		for s := 0; s < numStates; s++ {
 			ans.createState()
 		}
@@ -45,7 +45,7 @@ func concatenateN(l []*Automaton) *Automaton {
 	stateOffset := 0
 	t := newTransition()
 	for i, a := range l {
-		numStates := a.numStates()
+		numStates := a.NumStates()
 
 		var nextA *Automaton
 		if i < len(l)-1 {
@@ -53,10 +53,10 @@ func concatenateN(l []*Automaton) *Automaton {
 		}
 
 		for s := 0; s < numStates; s++ {
-			numTransitions := a.initTransition(s, t)
+			numTransitions := a.InitTransition(s, t)
 			for j := 0; j < numTransitions; j++ {
-				a.nextTransition(t)
-				ans.addTransitionRange(stateOffset+s, stateOffset+t.dest, t.min, t.max)
+				a.NextTransition(t)
+				ans.addTransitionRange(stateOffset+s, stateOffset+t.Dest, t.Min, t.Max)
 			}
 
 			if a.IsAccept(s) {
@@ -66,14 +66,14 @@ func concatenateN(l []*Automaton) *Automaton {
 				for {
 					if followA != nil {
 						// adds a "virtual" epsilon transition:
-						numTransitions = followA.initTransition(0, t)
+						numTransitions = followA.InitTransition(0, t)
 						for j := 0; j < numTransitions; j++ {
-							followA.nextTransition(t)
-							ans.addTransitionRange(stateOffset+s, followOffset+numStates+t.dest, t.min, t.max)
+							followA.NextTransition(t)
+							ans.addTransitionRange(stateOffset+s, followOffset+numStates+t.Dest, t.Min, t.Max)
 						}
 						if followA.IsAccept(0) {
 							// keep chaning if followA accepts empty string
-							followOffset += followA.numStates()
+							followOffset += followA.NumStates()
 							if upto < len(l)-1 {
 								followA = l[upto+1]
 							} else {
@@ -94,7 +94,7 @@ func concatenateN(l []*Automaton) *Automaton {
 		stateOffset += numStates
 	}
 
-	if ans.numStates() == 0 {
+	if ans.NumStates() == 0 {
 		ans.createState()
 	}
 
@@ -112,7 +112,7 @@ func optional(a *Automaton) *Automaton {
 	ans := newEmptyAutomaton()
 	ans.createState()
 	ans.setAccept(0, true)
-	if a.numStates() > 0 {
+	if a.NumStates() > 0 {
 		ans.copy(a)
 		ans.addEpsilon(0, 1)
 	}
@@ -138,19 +138,19 @@ func repeat(a *Automaton) *Automaton {
 	b.copy(a)
 
 	t := newTransition()
-	count := a.initTransition(0, t)
+	count := a.InitTransition(0, t)
 	for i := 0; i < count; i++ {
-		a.nextTransition(t)
-		b.addTransitionRange(0, t.dest+1, t.min, t.max)
+		a.NextTransition(t)
+		b.addTransitionRange(0, t.Dest+1, t.Min, t.Max)
 	}
 
-	numStates := a.numStates()
+	numStates := a.NumStates()
 	for s := 0; s < numStates; s++ {
 		if a.IsAccept(s) {
-			count = a.initTransition(0, t)
+			count = a.InitTransition(0, t)
 			for i := 0; i < count; i++ {
-				a.nextTransition(t)
-				b.addTransitionRange(s+1, t.dest+1, t.min, t.max)
+				a.NextTransition(t)
+				b.addTransitionRange(s+1, t.Dest+1, t.Min, t.Max)
 			}
 		}
 	}
@@ -174,7 +174,59 @@ func repeatMin(a *Automaton, min int) *Automaton {
 		min--
 	}
 	as = append(as, repeat(a))
-	return concatenateN(as)
+	return ConcatenateN(as)
+}
+
+/*
+Returns an automaton that accepts between min and max (including
+both) concatenated repetitions of the language of the given
+automaton.
+
+Complexity: linear in number of states and in min and max.
+*/
+func repeatRange(a *Automaton, min, max int) *Automaton {
+	if min > max {
+		return MakeEmpty()
+	}
+
+	var prefix *Automaton
+	switch min {
+	case 0:
+		prefix = MakeEmptyString()
+	case 1:
+		prefix = a
+	default:
+		as := make([]*Automaton, min)
+		for i, _ := range as {
+			as[i] = a
+		}
+		prefix = ConcatenateN(as)
+	}
+
+	b := newAutomatonBuilder()
+	b.copy(prefix)
+	prevAcceptStates := acceptStates(prefix, 0)
+	for i := min; i < max; i++ {
+		numStates := b.a.NumStates()
+		b.copy(a)
+		for _, s := range prevAcceptStates {
+			b.addEpsilon(s, numStates)
+		}
+		prevAcceptStates = acceptStates(a, numStates)
+	}
+	return b.finish()
+}
+
+/* Returns the accept states of the given automaton, shifted by offset. */
+func acceptStates(a *Automaton, offset int) []int {
+	var ans []int
+	numStates := a.NumStates()
+	for s := 0; s < numStates; s++ {
+		if a.IsAccept(s) {
+			ans = append(ans, offset+s)
+		}
+	}
+	return ans
 }
 
 /*
@@ -184,8 +236,8 @@ the language of the given automaton.
 Complexity: linear in number of states (if already deterministic).
 */
 func complement(a *Automaton) *Automaton {
-	a = totalize(determinize(a))
-	numStates := a.numStates()
+	a = totalize(Determinize(a))
+	numStates := a.NumStates()
 	for p := 0; p < numStates; p++ {
 		a.setAccept(p, !a.IsAccept(p))
 	}
@@ -200,14 +252,14 @@ deterministic.
 
 Complexity: quadratic in number of states (if already deterministic).
 */
-func minus(a1, a2 *Automaton) *Automaton {
+func Minus(a1, a2 *Automaton) *Automaton {
 	if isEmpty(a1) || a1 == a2 {
 		return MakeEmpty()
 	}
 	if isEmpty(a2) {
 		return a1
 	}
-	return intersection(a1, complement(a2))
+	return Intersection(a1, complement(a2))
 }
 
 // Pair of states.
@@ -219,11 +271,11 @@ of the given automata. Never modifies the input automata languages.
 
 Complexity: quadratic in number of states.
 */
-func intersection(a1, a2 *Automaton) *Automaton {
-	if a1 == a2 || a1.numStates() == 0 {
+func Intersection(a1, a2 *Automaton) *Automaton {
+	if a1 == a2 || a1.NumStates() == 0 {
 		return a1
 	}
-	if a2.numStates() == 0 {
+	if a2.NumStates() == 0 {
 		return a2
 	}
 
@@ -245,12 +297,12 @@ func intersection(a1, a2 *Automaton) *Automaton {
 		t1 := transitions1[p.s1]
 		t2 := transitions2[p.s2]
 		for n1, b2 := 0, 0; n1 < len(t1); n1++ {
-			for b2 < len(t2) && t2[b2].max < t1[n1].min {
+			for b2 < len(t2) && t2[b2].Max < t1[n1].Min {
 				b2++
 			}
-			for n2 := b2; n2 < len(t2) && t1[n1].max >= t2[n2].min; n2++ {
-				if t2[n2].max >= t1[n1].min {
-					q := &StatePair{-1, t1[n1].dest, t2[n2].dest}
+			for n2 := b2; n2 < len(t2) && t1[n1].Max >= t2[n2].Min; n2++ {
+				if t2[n2].Max >= t1[n1].Min {
+					q := &StatePair{-1, t1[n1].Dest, t2[n2].Dest}
 					r, ok := newstates[hash(q)]
 					if !ok {
 						q.s = c.createState()
@@ -258,8 +310,8 @@ func intersection(a1, a2 *Automaton) *Automaton {
 						newstates[hash(q)] = q
 						r = q
 					}
-					min := or(t1[n1].min > t2[n2].min, t1[n1].min, t2[n2].min).(int)
-					max := or(t1[n1].max < t2[n2].max, t1[n1].max, t2[n2].max).(int)
+					min := or(t1[n1].Min > t2[n2].Min, t1[n1].Min, t2[n2].Min).(int)
+					max := or(t1[n1].Max < t2[n2].Max, t1[n1].Max, t2[n2].Max).(int)
 					c.addTransitionRange(p.s, r.s, min, max)
 				}
 			}
@@ -289,7 +341,7 @@ Cost is O(numTransitions+numStates).
 func hasDeadStates(a *Automaton) bool {
 	liveStates := liveStates(a)
 	numLive := liveStates.Cardinality()
-	numStates := a.numStates()
+	numStates := a.NumStates()
 	assert2(numLive <= int64(numStates), "numLive=%v numStates=%v %v", numLive, numStates, liveStates)
 	return numLive < int64(numStates)
 }
@@ -311,10 +363,10 @@ func subsetOf(a1, a2 *Automaton) bool {
 	assert2(a2.deterministic, "a2 must be deterministic")
 	assert(!hasDeadStatesFromInitial(a1))
 	assert2(!hasDeadStatesFromInitial(a2), "%v", a2)
-	if a1.numStates() == 0 {
+	if a1.NumStates() == 0 {
 		// empty language is always a subset of any other language
 		return true
-	} else if a2.numStates() == 0 {
+	} else if a2.NumStates() == 0 {
 		return isEmpty(a1)
 	}
 
@@ -337,21 +389,21 @@ func subsetOf(a1, a2 *Automaton) bool {
 		t2 := transitions2[p.s2]
 		for n1, b2, t1Len := 0, 0, len(t1); n1 < t1Len; n1++ {
 			t2Len := len(t2)
-			for b2 < t2Len && t2[b2].max < t1[n1].min {
+			for b2 < t2Len && t2[b2].Max < t1[n1].Min {
 				b2++
 			}
-			min1, max1 := t1[n1].min, t1[n1].max
+			min1, max1 := t1[n1].Min, t1[n1].Max
 
-			for n2 := b2; n2 < t2Len && t1[n1].max >= t2[n2].min; n2++ {
-				if t2[n2].min > min1 {
+			for n2 := b2; n2 < t2Len && t1[n1].Max >= t2[n2].Min; n2++ {
+				if t2[n2].Min > min1 {
 					return false
 				}
-				if t2[n2].max < unicode.MaxRune {
-					min1 = t2[n2].max + 1
+				if t2[n2].Max < unicode.MaxRune {
+					min1 = t2[n2].Max + 1
 				} else {
 					min1, max1 = unicode.MaxRune, MIN_CODE_POINT
 				}
-				q := &StatePair{-1, t1[n1].dest, t2[n2].dest}
+				q := &StatePair{-1, t1[n1].Dest, t2[n2].Dest}
 				if _, ok := visited[hash(q)]; !ok {
 					worklist.PushBack(q)
 					visited[hash(q)] = q
@@ -371,8 +423,8 @@ given automta.
 
 Complexity: linear in number of states.
 */
-func union(a1, a2 *Automaton) *Automaton {
-	return unionN([]*Automaton{a1, a2})
+func Union(a1, a2 *Automaton) *Automaton {
+	return UnionN([]*Automaton{a1, a2})
 }
 
 /*
@@ -381,7 +433,7 @@ given automata.
 
 Complexity: linear in number of states.
 */
-func unionN(l []*Automaton) *Automaton {
+func UnionN(l []*Automaton) *Automaton {
 	ans := newEmptyAutomaton()
 	// create initial state
 	ans.createState()
@@ -392,11 +444,11 @@ func unionN(l []*Automaton) *Automaton {
 	// add epsilon transition from new initial state
 	stateOffset := 1
 	for _, a := range l {
-		if a.numStates() == 0 {
+		if a.NumStates() == 0 {
 			continue
 		}
 		ans.addEpsilon(0, stateOffset)
-		stateOffset += a.numStates()
+		stateOffset += a.NumStates()
 	}
 	ans.finishState()
 	return removeDeadStates(ans)
@@ -408,7 +460,7 @@ type TransitionList struct {
 }
 
 func (l *TransitionList) add(t *Transition) {
-	l.transitions = append(l.transitions, t.dest, t.min, t.max)
+	l.transitions = append(l.transitions, t.Dest, t.Min, t.Max)
 }
 
 // Holds all transitions that start on this int point, or end at this
@@ -506,8 +558,8 @@ func (pts *PointTransitionSet) sort() {
 }
 
 func (pts *PointTransitionSet) add(t *Transition) {
-	pts.find(t.min).starts.add(t)
-	pts.find(1 + t.max).ends.add(t)
+	pts.find(t.Min).starts.add(t)
+	pts.find(1 + t.Max).ends.add(t)
 }
 
 func (pts *PointTransitionSet) String() string {
@@ -521,8 +573,8 @@ Split the code points in ranges, and merge overlapping states.
 
 Worst case complexity: exponential in number of states.
 */
-func determinize(a *Automaton) *Automaton {
-	if a.deterministic || a.numStates() <= 1 {
+func Determinize(a *Automaton) *Automaton {
+	if a.deterministic || a.NumStates() <= 1 {
 		return a
 	}
 
@@ -561,10 +613,10 @@ func determinize(a *Automaton) *Automaton {
 
 		// Collate all outgoing transitions by min/1+max
 		for _, s0 := range s.values {
-			numTransitions := a.numTransitions(s0)
-			a.initTransition(s0, t)
+			numTransitions := a.NumTransitions(s0)
+			a.InitTransition(s0, t)
 			for j := 0; j < numTransitions; j++ {
-				a.nextTransition(t)
+				a.NextTransition(t)
 				points.add(t)
 			}
 		}
@@ -643,11 +695,11 @@ func determinize(a *Automaton) *Automaton {
 // // L779
 // Returns true if the given automaton accepts no strings.
 func isEmpty(a *Automaton) bool {
-	if a.numStates() == 0 {
+	if a.NumStates() == 0 {
 		// common case: no states
 		return true
 	}
-	if !a.IsAccept(0) && a.numTransitions(0) == 0 {
+	if !a.IsAccept(0) && a.NumTransitions(0) == 0 {
 		// common case: just one initial state
 		return true
 	}
@@ -667,19 +719,136 @@ func isEmpty(a *Automaton) bool {
 		if a.IsAccept(state) {
 			return false
 		}
-		count := a.initTransition(state, t)
+		count := a.InitTransition(state, t)
 		for i := 0; i < count; i++ {
-			a.nextTransition(t)
-			if !seen.Get(int64(t.dest)) {
-				workList.PushBack(t.dest)
-				seen.Set(int64(t.dest))
+			a.NextTransition(t)
+			if !seen.Get(int64(t.Dest)) {
+				workList.PushBack(t.Dest)
+				seen.Set(int64(t.Dest))
+			}
+		}
+	}
+
+	return true
+}
+
+/*
+Returns true if the given automaton accepts all strings. The
+automaton must be minimized.
+*/
+func isTotal(a *Automaton) bool {
+	if a.IsAccept(0) && a.NumTransitions(0) == 1 {
+		t := newTransition()
+		a.transition(0, 0, t)
+		return t.Dest == 0 && t.Min == MIN_CODE_POINT && t.Max == unicode.MaxRune
+	}
+	return false
+}
+
+/*
+If this automaton accepts a single input, returns it. Otherwise
+returns false. The automaton must be deterministic.
+*/
+func getSingleton(a *Automaton) ([]int, bool) {
+	assert2(a.deterministic, "input automaton must be deterministic")
+	var singleton []int
+	visited := make(map[int]bool)
+	s := 0
+	t := newTransition()
+	for {
+		visited[s] = true
+		if !a.IsAccept(s) {
+			if a.NumTransitions(s) == 1 {
+				a.transition(s, 0, t)
+				if t.Min == t.Max && !visited[t.Dest] {
+					singleton = append(singleton, t.Min)
+					s = t.Dest
+					continue
+				}
 			}
+		} else if a.NumTransitions(s) == 0 {
+			return singleton, true
 		}
+		// Automaton accepts more than one string:
+		return nil, false
 	}
+}
 
+/*
+Returns true if the language of this automaton is finite. The
+automaton must not have any dead states.
+*/
+func isFinite(a *Automaton) bool {
+	if a.NumStates() == 0 {
+		return true
+	}
+	return isFiniteFrom(newTransition(), a, 0,
+		util.NewOpenBitSet(), util.NewOpenBitSet())
+}
+
+/*
+Checks whether there is a loop containing state. (This is sufficient
+since there are never transitions to dead states.)
+*/
+func isFiniteFrom(scratch *Transition, a *Automaton, state int,
+	path, visited *util.OpenBitSet) bool {
+
+	path.Set(int64(state))
+	numTransitions := a.NumTransitions(state)
+	for t := 0; t < numTransitions; t++ {
+		a.transition(state, t, scratch)
+		if path.Get(int64(scratch.Dest)) || !visited.Get(int64(scratch.Dest)) &&
+			!isFiniteFrom(scratch, a, scratch.Dest, path, visited) {
+			return false
+		}
+	}
+	path.Clear(int64(state))
+	visited.Set(int64(state))
 	return true
 }
 
+/*
+Returns the longest []byte that is a prefix of all accepted strings
+and visits each state at most once. The automaton must be
+deterministic, and its labels must be bytes.
+*/
+func commonPrefixBytes(a *Automaton) []byte {
+	var prefix []byte
+	if a.NumStates() == 0 {
+		return prefix
+	}
+	visited := make(map[int]bool)
+	s := 0
+	t := newTransition()
+	for {
+		visited[s] = true
+		if a.IsAccept(s) || a.NumTransitions(s) != 1 {
+			return prefix
+		}
+		a.transition(s, 0, t)
+		if t.Min != t.Max || visited[t.Dest] {
+			return prefix
+		}
+		prefix = append(prefix, byte(t.Min))
+		s = t.Dest
+	}
+}
+
+/*
+Returns the longest []byte that is a suffix of all accepted strings.
+Worst case complexity: exponential in number of states (this calls
+determinize). The automaton's labels must be bytes.
+*/
+func commonSuffixBytes(a *Automaton) []byte {
+	// reverse the language of the automaton, then reverse its common prefix.
+	r, _ := reverse(a)
+	suffix := commonPrefixBytes(Determinize(r))
+	for i, j := 0, len(suffix)-1; i < j; i, j = i+1, j-1 {
+		suffix[i], suffix[j] = suffix[j], suffix[i]
+	}
+	return suffix
+}
+
 // /*
 // Returns true if the given string is accepted by the autmaton.
 
@@ -718,7 +887,7 @@ func liveStates(a *Automaton) *util.OpenBitSet {
 
 /* Returns BitSet marking states reachable from the initial state. */
 func liveStatesFromInitial(a *Automaton) *util.OpenBitSet {
-	numStates := a.numStates()
+	numStates := a.NumStates()
 	live := util.NewOpenBitSet()
 	if numStates == 0 {
 		return live
@@ -730,12 +899,12 @@ func liveStatesFromInitial(a *Automaton) *util.OpenBitSet {
 	t := newTransition()
 	for workList.Len() > 0 {
 		s := workList.Remove(workList.Front()).(int)
-		count := a.initTransition(s, t)
+		count := a.InitTransition(s, t)
 		for i := 0; i < count; i++ {
-			a.nextTransition(t)
-			if !live.Get(int64(t.dest)) {
-				live.Set(int64(t.dest))
-				workList.PushBack(t.dest)
+			a.NextTransition(t)
+			if !live.Get(int64(t.Dest)) {
+				live.Set(int64(t.Dest))
+				workList.PushBack(t.Dest)
 			}
 		}
 	}
@@ -749,15 +918,15 @@ func liveStatesToAccept(a *Automaton) *util.OpenBitSet {
 
 	// NOTE: not quite the same thing as what SpecialOperations.reverse does:
 	t := newTransition()
-	numStates := a.numStates()
+	numStates := a.NumStates()
 	for s := 0; s < numStates; s++ {
 		builder.createState()
 	}
 	for s := 0; s < numStates; s++ {
-		count := a.initTransition(s, t)
+		count := a.InitTransition(s, t)
 		for i := 0; i < count; i++ {
-			a.nextTransition(t)
-			builder.addTransitionRange(t.dest, s, t.min, t.max)
+			a.NextTransition(t)
+			builder.addTransitionRange(t.Dest, s, t.Min, t.Max)
 		}
 	}
 	a2 := builder.finish()
@@ -778,12 +947,12 @@ func liveStatesToAccept(a *Automaton) *util.OpenBitSet {
 
 	for workList.Len() > 0 {
 		s = workList.Remove(workList.Front()).(int)
-		count := a2.initTransition(s, t)
+		count := a2.InitTransition(s, t)
 		for i := 0; i < count; i++ {
-			a2.nextTransition(t)
-			if !live.Get(int64(t.dest)) {
-				live.Set(int64(t.dest))
-				workList.PushBack(t.dest)
+			a2.NextTransition(t)
+			if !live.Get(int64(t.Dest)) {
+				live.Set(int64(t.Dest))
+				workList.PushBack(t.Dest)
 			}
 		}
 	}
@@ -797,7 +966,7 @@ reachable from the initial state or no accept state is reachable from
 it.)
 */
 func removeDeadStates(a *Automaton) *Automaton {
-	numStates := a.numStates()
+	numStates := a.NumStates()
 	liveSet := liveStates(a)
 
 	m := make([]int, numStates)
@@ -815,12 +984,12 @@ func removeDeadStates(a *Automaton) *Automaton {
 
 	for i := 0; i < numStates; i++ {
 		if liveSet.Get(int64(i)) {
-			numTransitions := a.initTransition(i, t)
+			numTransitions := a.InitTransition(i, t)
 			// filter out transitions to dead states:
 			for j := 0; j < numTransitions; j++ {
-				a.nextTransition(t)
-				if liveSet.Get(int64(t.dest)) {
-					ans.addTransitionRange(m[i], m[t.dest], t.min, t.max)
+				a.NextTransition(t)
+				if liveSet.Get(int64(t.Dest)) {
+					ans.addTransitionRange(m[i], m[t.Dest], t.Min, t.Max)
 				}
 			}
 		}
@@ -856,7 +1025,7 @@ func reverse(a *Automaton) (*Automaton, map[int]bool) {
 		return newEmptyAutomaton(), nil
 	}
 
-	numStates := a.numStates()
+	numStates := a.NumStates()
 
 	// build a new automaton with all edges reversed
 	b := newAutomatonBuilder()
@@ -872,11 +1041,11 @@ func reverse(a *Automaton) (*Automaton, map[int]bool) {
 
 	t := newTransition()
 	for s := 0; s < numStates; s++ {
-		numTransitions := a.numTransitions(s)
-		a.initTransition(s, t)
+		numTransitions := a.NumTransitions(s)
+		a.InitTransition(s, t)
 		for i := 0; i < numTransitions; i++ {
-			a.nextTransition(t)
-			b.addTransitionRange(t.dest+1, s+1, t.min, t.max)
+			a.NextTransition(t)
+			b.addTransitionRange(t.Dest+1, s+1, t.Min, t.Max)
 		}
 	}
 
@@ -900,7 +1069,7 @@ there is a transition.
 */
 func totalize(a *Automaton) *Automaton {
 	ans := newEmptyAutomaton()
-	numStates := a.numStates()
+	numStates := a.NumStates()
 	for i := 0; i < numStates; i++ {
 		ans.createState()
 		ans.setAccept(i, a.IsAccept(i))
@@ -912,15 +1081,15 @@ func totalize(a *Automaton) *Automaton {
 	t := newTransition()
 	for i := 0; i < numStates; i++ {
 		maxi := MIN_CODE_POINT
-		count := a.initTransition(i, t)
+		count := a.InitTransition(i, t)
 		for j := 0; j < count; j++ {
-			a.nextTransition(t)
-			ans.addTransitionRange(i, t.dest, t.min, t.max)
-			if t.min > maxi {
-				ans.addTransitionRange(i, deadState, maxi, t.min-1)
+			a.NextTransition(t)
+			ans.addTransitionRange(i, t.Dest, t.Min, t.Max)
+			if t.Min > maxi {
+				ans.addTransitionRange(i, deadState, maxi, t.Min-1)
 			}
-			if t.max+1 > maxi {
-				maxi = t.max + 1
+			if t.Max+1 > maxi {
+				maxi = t.Max + 1
 			}
 		}
 
@@ -154,16 +154,16 @@ func (re *RegExp) toAutomaton(automata map[string]*Automaton,
 		list = make([]*Automaton, 0)
 		list = re.findLeaves(re.exp1, REGEXP_UNION, list, automata, provider)
 		list = re.findLeaves(re.exp2, REGEXP_UNION, list, automata, provider)
-		a = unionN(list)
+		a = UnionN(list)
 		a = minimize(a)
 	case REGEXP_CONCATENATION:
 		list = make([]*Automaton, 0)
 		list = re.findLeaves(re.exp1, REGEXP_CONCATENATION, list, automata, provider)
 		list = re.findLeaves(re.exp2, REGEXP_CONCATENATION, list, automata, provider)
-		a = concatenateN(list)
+		a = ConcatenateN(list)
 		a = minimize(a)
 	case REGEXP_INTERSECTION:
-		a = intersection(re.exp1.toAutomaton(automata, provider),
+		a = Intersection(re.exp1.toAutomaton(automata, provider),
 			re.exp2.toAutomaton(automata, provider))
 		a = minimize(a)
 	case REGEXP_OPTIONAL:
@@ -176,22 +176,23 @@ func (re *RegExp) toAutomaton(automata map[string]*Automaton,
 		a = repeatMin(re.exp1.toAutomaton(automata, provider), re.min)
 		a = minimize(a)
 	case REGEXP_REPEAT_MINMAX:
-		panic("not implemented yet")
+		a = repeatRange(re.exp1.toAutomaton(automata, provider), re.min, re.max)
+		a = minimize(a)
 	case REGEXP_COMPLEMENT:
 		a = complement(re.exp1.toAutomaton(automata, provider))
 		a = minimize(a)
 	case REGEXP_CHAR:
-		a = makeChar(re.c)
+		a = MakeChar(re.c)
 	case REGEXP_CHAR_RANGE:
-		a = makeCharRange(re.from, re.to)
+		a = MakeCharRange(re.from, re.to)
 	case REGEXP_ANYCHAR:
-		a = makeAnyChar()
+		a = MakeAnyChar()
 	case REGEXP_EMPTY:
-		panic("not implemented yet")
+		a = MakeEmpty()
 	case REGEXP_STRING:
-		a = makeString(re.s)
+		a = MakeString(re.s)
 	case REGEXP_ANYSTRING:
-		panic("not implemented yet")
+		a = MakeAnyString()
 	case REGEXP_AUTOMATON:
 		panic("not implemented yet")
 	case REGEXP_INTERVAL:
@@ -247,7 +248,9 @@ func (re *RegExp) toStringBuilder(b *bytes.Buffer) *bytes.Buffer {
 		re.exp1.toStringBuilder(b)
 		fmt.Fprintf(b, "){%v,}", re.min)
 	case REGEXP_REPEAT_MINMAX:
-		panic("not implemented yet3")
+		b.WriteRune('(')
+		re.exp1.toStringBuilder(b)
+		fmt.Fprintf(b, "){%v,%v}", re.min, re.max)
 	case REGEXP_COMPLEMENT:
 		b.WriteString("~(")
 		re.exp1.toStringBuilder(b)
@@ -264,15 +267,15 @@ func (re *RegExp) toStringBuilder(b *bytes.Buffer) *bytes.Buffer {
 			b.WriteRune(rune(re.c))
 		}
 	case REGEXP_CHAR_RANGE:
-		panic("not implemented yet4")
+		fmt.Fprintf(b, "[\\%c-\\%c]", rune(re.from), rune(re.to))
 	case REGEXP_ANYCHAR:
 		b.WriteRune('.')
 	case REGEXP_EMPTY:
-		panic("not implemented yet5")
+		b.WriteRune('#')
 	case REGEXP_STRING:
 		fmt.Fprintf(b, "\"%v\"", re.s)
 	case REGEXP_ANYSTRING:
-		panic("not implemented yet7")
+		b.WriteRune('@')
 	case REGEXP_AUTOMATON:
 		panic("not implemented yet8")
 	case REGEXP_INTERVAL:
@@ -323,7 +326,7 @@ func makeString2RE(exp1, exp2 *RegExp) *RegExp {
 		b.WriteRune(rune(exp1.c))
 	}
 	if exp2.kind == REGEXP_STRING {
-		b.WriteString(exp1.s)
+		b.WriteString(exp2.s)
 	} else {
 		assert(REGEXP_CHAR == exp2.kind)
 		b.WriteRune(rune(exp2.c))
@@ -362,7 +365,12 @@ func makeRepeatMin(exp *RegExp, min int) *RegExp {
 }
 
 func makeRepeatRange(exp *RegExp, min, max int) *RegExp {
-	panic("not implemented yet")
+	return &RegExp{
+		kind: REGEXP_REPEAT_MINMAX,
+		exp1: exp,
+		min:  min,
+		max:  max,
+	}
 }
 
 func makeComplement(exp *RegExp) *RegExp {
@@ -413,7 +421,7 @@ func makeStringRE(s string) *RegExp {
 }
 
 func makeAnyStringRE() *RegExp {
-	return &RegExp{kind: REGEXP_STRING}
+	return &RegExp{kind: REGEXP_ANYSTRING}
 }
 
 func (re *RegExp) peek(s string) bool {
@@ -29,8 +29,8 @@ func (ra *RunAutomaton) charClass(c int) int {
 
 // Constructs a new RunAutomaton from a deterministic Automaton.
 func newRunAutomaton(a *Automaton, maxInterval int, tablesize bool) *RunAutomaton {
-	a = determinize(a)
-	size := a.numStates()
+	a = Determinize(a)
+	size := a.NumStates()
 	if size < 1 {
 		size = 1
 	}
@@ -51,18 +51,35 @@ func newRunAutomaton(a *Automaton, maxInterval int, tablesize bool) *RunAutomato
 	for n := 0; n < size; n++ {
 		ans.accept[n] = a.IsAccept(n)
 		for c, point := range ans.points {
-			dest := a.step(n, point)
+			dest := a.Step(n, point)
 			assert(dest == -1 || dest < size)
 			ans.transitions[n*nPoints+c] = dest
 		}
 	}
 	// Set alphabet table for optimal run performance.
 	if tablesize {
-		panic("not implemented yet")
+		ans.classmap = make([]int, maxInterval+1)
+		i := 0
+		for j := 0; j <= maxInterval; j++ {
+			if i+1 < nPoints && j == points[i+1] {
+				i++
+			}
+			ans.classmap[j] = i
+		}
 	}
 	return ans
 }
 
+// Returns whether the given state is an accept state.
+func (ra *RunAutomaton) IsAccept(state int) bool {
+	return ra.accept[state]
+}
+
+// Returns initial state.
+func (ra *RunAutomaton) InitialState() int {
+	return ra.initial
+}
+
 /*
 Returns the state obtained by reading the given char from the given
 state. Returns -1 if not obtaining any such state. (If the original
@@ -70,7 +87,7 @@ Automaton had no dead states, -1 is returned here if and only if a
 dead state is entered in an equivalent automaton with a total
 transition function.)
 */
-func (ra *RunAutomaton) step(state, c int) int {
+func (ra *RunAutomaton) Step(state, c int) int {
 	if ra.classmap == nil {
 		return ra.transitions[state*len(ra.points)+ra.charClass(c)]
 	} else {
@@ -88,3 +105,32 @@ func NewCharacterRunAutomaton(a *Automaton) *CharacterRunAutomaton {
 	ans.RunAutomaton = newRunAutomaton(a, unicode.MaxRune, false)
 	return ans
 }
+
+// util/automaton/ByteRunAutomaton.java
+
+// Automaton representation for matching UTF-8 []byte.
+type ByteRunAutomaton struct {
+	*RunAutomaton
+}
+
+/*
+Expert: if isBinary is true, the input is already byte-based,
+otherwise it's converted from UTF-32 to UTF-8 first.
+*/
+func NewByteRunAutomaton(a *Automaton, isBinary bool) *ByteRunAutomaton {
+	if !isBinary {
+		a = new(UTF32ToUTF8).Convert(a)
+	}
+	return &ByteRunAutomaton{newRunAutomaton(a, 256, true)}
+}
+
+// Returns true if the given byte array is accepted by this automaton.
+func (ra *ByteRunAutomaton) Run(s []byte) bool {
+	p := ra.initial
+	for _, b := range s {
+		if p = ra.Step(p, int(b)); p == -1 {
+			return false
+		}
+	}
+	return ra.accept[p]
+}
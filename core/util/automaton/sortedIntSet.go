@@ -33,6 +33,7 @@ func newSortedIntSet(capacity int) *SortedIntSet {
 	return &SortedIntSet{
 		values: make([]int, 0, capacity),
 		counts: make([]int, 0, capacity),
+		dict:   make(map[int]int),
 	}
 }
 
@@ -114,10 +115,11 @@ func (sis *SortedIntSet) decr(num int) {
 func (sis *SortedIntSet) computeHash() *FrozenIntSet {
 	// do nothing related to hash
 	if sis.useTreeMap {
-		if size := len(sis.dict); size > len(sis.values) {
+		if size := len(sis.dict); size > cap(sis.values) {
 			sis.values = make([]int, 0, size)
 			sis.counts = make([]int, 0, size)
 		}
+		sis.values = sis.values[:0]
 		for state, _ := range sis.dict {
 			sis.values = append(sis.values, state)
 		}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 )
 
+const BYTES_REF_HASH_DEFAULT_CAPACITY = 16
+
 /*
 BytesRefHash is a special purpose hash map like data structure
 optimized for BytesRef instances. BytesRefHash maintains mappings of
@@ -377,3 +379,56 @@ type BytesStartArray interface {
 	// clears the BytesStartArray and returns the cleared instance.
 	Clear() []int
 }
+
+/*
+Populates and returns a BytesRef with the bytes for the given
+bytesID.
+
+Note: the given bytesID must be a positive integer less than the
+current size (Size())
+*/
+func (h *BytesRefHash) Get(bytesId int, ref *BytesRef) *BytesRef {
+	assert2(h.bytesStart != nil, "bytesStart is null - not initialized")
+	assert2(bytesId < len(h.bytesStart), "bytesId exceeds byteStart len: %v", len(h.bytesStart))
+	h.pool.SetBytesRef(ref, h.bytesStart[bytesId])
+	return ref
+}
+
+/*
+A simple BytesStartArray that tracks memory allocation using a
+private Counter instance.
+*/
+type DirectBytesStartArray struct {
+	// TODO: can't we just merge this w/ TrackingDirectBytesStartArray...?
+	// Just add a ctor that makes a private bytesUsed?
+	initSize   int
+	bytesStart []int
+	bytesUsed  Counter
+}
+
+func NewDirectBytesStartArray(initSize int, counter Counter) *DirectBytesStartArray {
+	if counter == nil {
+		counter = NewCounter()
+	}
+	return &DirectBytesStartArray{initSize: initSize, bytesUsed: counter}
+}
+
+func (a *DirectBytesStartArray) Clear() []int {
+	a.bytesStart = nil
+	return nil
+}
+
+func (a *DirectBytesStartArray) Grow() []int {
+	assert(a.bytesStart != nil)
+	a.bytesStart = GrowIntSlice(a.bytesStart, len(a.bytesStart)+1)
+	return a.bytesStart
+}
+
+func (a *DirectBytesStartArray) Init() []int {
+	a.bytesStart = make([]int, Oversize(a.initSize, NUM_BYTES_INT))
+	return a.bytesStart
+}
+
+func (a *DirectBytesStartArray) BytesUsed() Counter {
+	return a.bytesUsed
+}
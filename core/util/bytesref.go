@@ -1,5 +1,9 @@
 package util
 
+import (
+	"sort"
+)
+
 // util/BytesRef.java
 
 /* An empty byte slice for convenience */
@@ -107,6 +111,13 @@ func UTF8SortedAsUnicodeLess(aBytes, bBytes []byte) bool {
 	return aLen < bLen
 }
 
+/*
+The sort order of terms as returned by BytesRefIterator.Comparator():
+UTF8 bytes compared as unsigned bytes, which matches unicode code
+point order. See UTF8SortedAsUnicodeLess().
+*/
+var UTF8SortedAsUnicodeComparator sort.Interface = BytesRefs(nil)
+
 type BytesRefs [][]byte
 
 func (br BytesRefs) Len() int {
@@ -0,0 +1,35 @@
+package util
+
+import (
+	. "github.com/balzaczyy/golucene/core/search/model"
+	. "github.com/balzaczyy/gounit"
+	"testing"
+)
+
+func TestFixedBitSetIterator(t *testing.T) {
+	b := NewFixedBitSetOf(200)
+	for _, i := range []int{0, 3, 63, 64, 130, 199} {
+		b.Set(i)
+	}
+	It(t).Should("Cardinality is 6").Verify(b.Cardinality() == 6)
+	It(t).Should("Bit 64 is set").Verify(b.At(64))
+	It(t).Should("Bit 65 is not set").Verify(!b.At(65))
+	i := b.NextSetBit(4)
+	It(t).Should("Next set bit after 4 is 63 (got %v)", i).Verify(i == 63)
+	i = b.NextSetBit(131)
+	It(t).Should("Next set bit after 131 is 199 (got %v)", i).Verify(i == 199)
+
+	b.Clear(3)
+	it, err := b.Iterator()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	var docs []int
+	for doc, err := it.NextDoc(); doc != NO_MORE_DOCS; doc, err = it.NextDoc() {
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		docs = append(docs, doc)
+	}
+	It(t).Should("unexpected docs: %v", docs).Verify(len(docs) == 5 && docs[1] == 63 && docs[4] == 199)
+
+	it, _ = b.Iterator()
+	doc, _ := it.Advance(65)
+	It(t).Should("Advance(65) lands on 130 (got %v)", doc).Verify(doc == 130)
+}
@@ -997,7 +997,7 @@ func (t *FST) FindTargetArc(labelToMatch int, follow *Arc, arc *Arc, in BytesRea
 			}
 		}
 		arc.posArcsStart = in.getPosition()
-		for low, high := 0, arc.numArcs-1; low < high; {
+		for low, high := 0, arc.numArcs-1; low <= high; {
 			// log.Println("    cycle")
 			mid := int(uint(low+high) / 2)
 			in.setPosition(arc.posArcsStart)
@@ -1,9 +1,326 @@
 package util
 
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
 // util/NumericUtils.java
 
 const (
 	// The default precision step used by LongField, DoubleField,
 	// NumericTokenStream, NumericRangeQuery, and NumericRangeFilter.
 	NUMERIC_PRECISION_STEP_DEFAULT = 16
+	// The default precision step used by IntField and FloatField.
+	NUMERIC_PRECISION_STEP_DEFAULT_32 = 8
+
+	// Longs are stored at lower precision by shifting off lower bits.
+	// The shift count is stored as SHIFT_START_LONG+shift in the first
+	// byte
+	NUMERIC_SHIFT_START_LONG = 0x20
+	// The maximum term length (used for []byte buffer size) for
+	// encoding int64 values.
+	NUMERIC_BUF_SIZE_LONG = 63/7 + 2
+
+	// Integers are stored at lower precision by shifting off lower
+	// bits. The shift count is stored as SHIFT_START_INT+shift in the
+	// first byte
+	NUMERIC_SHIFT_START_INT = 0x60
+	// The maximum term length (used for []byte buffer size) for
+	// encoding int32 values.
+	NUMERIC_BUF_SIZE_INT = 31/7 + 2
 )
+
+/*
+This is a helper class to generate prefix-encoded representations for
+numerical values and supplies converters to represent float/double
+values as sortable integers/longs.
+
+To quickly execute range queries in Apache Lucene, a range is divided
+recursively into multiple intervals for searching: The center of the
+range is searched only with the lowest possible precision in the trie,
+while the boundaries are matched more exactly. This reduces the
+number of terms dramatically.
+
+This class generates terms to achieve this: First the numerical
+integer values need to be converted to bytes. For that integer values
+(32 bit or 64 bit) are made unsigned and the bits are converted to
+ASCII chars with each 7 bit. The resulting byte[] is sortable like
+the original integer value (even using UTF-8 sort order). Each value
+is also prefixed (in the first char) by the shift value (number of
+bits removed) used during encoding.
+
+To also index floating point numbers, this class supplies two methods
+to convert them to integer values by changing their bit layout:
+DoubleToSortableLong(), FloatToSortableInt(). You will have no
+precision loss by converting floating point numbers to integers and
+back (only that the integer form is not usable). Other data types
+like dates can easily converted to longs or ints (e.g. date to long).
+
+For easy usage, the trie algorithm is implemented for indexing inside
+NumericTokenStream that can index int, long, float, and double. For
+querying, NumericRangeQuery and NumericRangeFilter implement the
+query part for the same data types.
+
+This class can also be used, to generate lexicographically sortable
+(according to UTF8SortedAsUnicodeLess()) representations of numeric
+data types for other usages (e.g. sorting).
+*/
+
+/*
+Returns prefix coded bits after reducing the precision by shift bits.
+This is method is used by NumericTokenStream. After encoding,
+bytes.Get() contains the encoded value.
+*/
+func LongToPrefixCodedBytes(val int64, shift int, bytes *BytesRefBuilder) {
+	assert2(shift&^0x3f == 0, "Illegal shift value, must be 0..63") // ensure shift is 0..63
+	nChars := (((63 - shift) * 37) >> 8) + 1                        // i/7 is the same as (i*37)>>8 for i in 0..63
+	bytes.Grow(NUMERIC_BUF_SIZE_LONG)
+	bytes.SetLength(nChars + 1) // one extra for the byte that contains the shift info
+	bytes.Set(0, byte(NUMERIC_SHIFT_START_LONG+shift))
+	sortableBits := uint64(val) ^ 0x8000000000000000
+	sortableBits >>= uint(shift)
+	for nChars > 0 {
+		// Store 7 bits per byte for compatibility with UTF-8 encoding of
+		// terms
+		bytes.Set(nChars, byte(sortableBits&0x7f))
+		nChars--
+		sortableBits >>= 7
+	}
+}
+
+/*
+Returns prefix coded bits after reducing the precision by shift bits.
+This is method is used by NumericTokenStream. After encoding,
+bytes.Get() contains the encoded value.
+*/
+func IntToPrefixCodedBytes(val int32, shift int, bytes *BytesRefBuilder) {
+	assert2(shift&^0x1f == 0, "Illegal shift value, must be 0..31") // ensure shift is 0..31
+	nChars := (((31 - shift) * 37) >> 8) + 1                        // i/7 is the same as (i*37)>>8 for i in 0..63
+	bytes.Grow(NUMERIC_BUF_SIZE_INT)
+	bytes.SetLength(nChars + 1) // one extra for the byte that contains the shift info
+	bytes.Set(0, byte(NUMERIC_SHIFT_START_INT+shift))
+	sortableBits := uint32(val) ^ 0x80000000
+	sortableBits >>= uint(shift)
+	for nChars > 0 {
+		// Store 7 bits per byte for compatibility with UTF-8 encoding of
+		// terms
+		bytes.Set(nChars, byte(sortableBits&0x7f))
+		nChars--
+		sortableBits >>= 7
+	}
+}
+
+/* Returns the shift value from a prefix encoded int64. */
+func PrefixCodedLongShift(val []byte) (int, error) {
+	shift := int(val[0]) - NUMERIC_SHIFT_START_LONG
+	if shift > 63 || shift < 0 {
+		return 0, errors.New(fmt.Sprintf(
+			"Invalid shift value (%v) in prefixCoded bytes (is encoded value really a LONG?)", shift))
+	}
+	return shift, nil
+}
+
+/* Returns the shift value from a prefix encoded int32. */
+func PrefixCodedIntShift(val []byte) (int, error) {
+	shift := int(val[0]) - NUMERIC_SHIFT_START_INT
+	if shift > 31 || shift < 0 {
+		return 0, errors.New(fmt.Sprintf(
+			"Invalid shift value (%v) in prefixCoded bytes (is encoded value really an INT?)", shift))
+	}
+	return shift, nil
+}
+
+/*
+Returns an int64 from prefixCoded bytes. Rightmost bits will be zero
+for lower precision codes. This method can be used to decode a term's
+value.
+*/
+func PrefixCodedToLong(val []byte) (int64, error) {
+	var sortableBits uint64
+	for i := 1; i < len(val); i++ {
+		sortableBits <<= 7
+		b := val[i]
+		if b >= 0x80 {
+			return 0, errors.New(fmt.Sprintf(
+				"Invalid prefixCoded numerical value representation (byte %x at position %v is invalid)", b, i))
+		}
+		sortableBits |= uint64(b)
+	}
+	shift, err := PrefixCodedLongShift(val)
+	if err != nil {
+		return 0, err
+	}
+	return int64((sortableBits << uint(shift)) ^ 0x8000000000000000), nil
+}
+
+/*
+Returns an int32 from prefixCoded bytes. Rightmost bits will be zero
+for lower precision codes. This method can be used to decode a term's
+value.
+*/
+func PrefixCodedToInt(val []byte) (int32, error) {
+	var sortableBits uint32
+	for i := 1; i < len(val); i++ {
+		sortableBits <<= 7
+		b := val[i]
+		if b >= 0x80 {
+			return 0, errors.New(fmt.Sprintf(
+				"Invalid prefixCoded numerical value representation (byte %x at position %v is invalid)", b, i))
+		}
+		sortableBits |= uint32(b)
+	}
+	shift, err := PrefixCodedIntShift(val)
+	if err != nil {
+		return 0, err
+	}
+	return int32((sortableBits << uint(shift)) ^ 0x80000000), nil
+}
+
+/*
+Converts a float64 value to a sortable signed int64. The value is
+converted by getting their IEEE 754 floating-point "double format"
+bit layout and then some bits are swapped, to be able to compare the
+result as int64. By this the precision is not reduced, but the value
+can easily used as an int64. The sort order (including NaN) is
+defined by math.Float64bits(); NaN is greater than positive infinity.
+*/
+func DoubleToSortableLong(val float64) int64 {
+	return sortableDoubleBits(int64(math.Float64bits(val)))
+}
+
+/* Converts a sortable int64 back to a float64. */
+func SortableLongToDouble(val int64) float64 {
+	return math.Float64frombits(uint64(sortableDoubleBits(val)))
+}
+
+/*
+Converts a float32 value to a sortable signed int32. The value is
+converted by getting their IEEE 754 floating-point "float format" bit
+layout and then some bits are swapped, to be able to compare the
+result as int32. By this the precision is not reduced, but the value
+can easily used as an int32. The sort order (including NaN) is
+defined by math.Float32bits(); NaN is greater than positive infinity.
+*/
+func FloatToSortableInt(val float32) int32 {
+	return sortableFloatBits(int32(math.Float32bits(val)))
+}
+
+/* Converts a sortable int32 back to a float32. */
+func SortableIntToFloat(val int32) float32 {
+	return math.Float32frombits(uint32(sortableFloatBits(val)))
+}
+
+/* Converts IEEE 754 representation of a double to sortable order (or back to the original) */
+func sortableDoubleBits(bits int64) int64 {
+	return bits ^ (bits>>63)&0x7fffffffffffffff
+}
+
+/* Converts IEEE 754 representation of a float to sortable order (or back to the original) */
+func sortableFloatBits(bits int32) int32 {
+	return bits ^ (bits>>31)&0x7fffffff
+}
+
+/*
+Callback for SplitLongRange(). It receives the prefix coded lower and
+upper bound of each sub-range, as they would be indexed by
+NumericTokenStream.
+*/
+type LongRangeBuilder func(minPrefixCoded, maxPrefixCoded []byte)
+
+/*
+Callback for SplitIntRange(). It receives the prefix coded lower and
+upper bound of each sub-range, as they would be indexed by
+NumericTokenStream.
+*/
+type IntRangeBuilder func(minPrefixCoded, maxPrefixCoded []byte)
+
+/*
+Splits an int64 range recursively. You may implement a builder that
+adds clauses to a BooleanQuery for each call to its AddRange()
+method.
+
+This method is used by NumericRangeQuery.
+*/
+func SplitLongRange(builder LongRangeBuilder, precisionStep int, minBound, maxBound int64) {
+	splitRange(precisionStep, 64, minBound, maxBound, func(min, max int64, shift int) {
+		minBytes, maxBytes := NewBytesRefBuilder(), NewBytesRefBuilder()
+		LongToPrefixCodedBytes(min, shift, minBytes)
+		LongToPrefixCodedBytes(max, shift, maxBytes)
+		builder(minBytes.Get().ToBytes(), maxBytes.Get().ToBytes())
+	})
+}
+
+/*
+Splits an int32 range recursively. You may implement a builder that
+adds clauses to a BooleanQuery for each call to its AddRange()
+method.
+
+This method is used by NumericRangeQuery.
+*/
+func SplitIntRange(builder IntRangeBuilder, precisionStep int, minBound, maxBound int32) {
+	splitRange(precisionStep, 32, int64(minBound), int64(maxBound), func(min, max int64, shift int) {
+		minBytes, maxBytes := NewBytesRefBuilder(), NewBytesRefBuilder()
+		IntToPrefixCodedBytes(int32(min), shift, minBytes)
+		IntToPrefixCodedBytes(int32(max), shift, maxBytes)
+		builder(minBytes.Get().ToBytes(), maxBytes.Get().ToBytes())
+	})
+}
+
+/* This helper does the splitting for both 32 and 64 bit. */
+func splitRange(precisionStep, valSize int, minBound, maxBound int64,
+	addRange func(min, max int64, shift int)) {
+
+	assert2(precisionStep >= 1, "precisionStep must be >=1")
+	if minBound > maxBound {
+		return
+	}
+	for shift := 0; ; shift += precisionStep {
+		// calculate new bounds for inner precision
+		diff := int64(1) << uint(shift+precisionStep)
+		mask := ((int64(1) << uint(precisionStep)) - 1) << uint(shift)
+		hasLower := (minBound & mask) != 0
+		hasUpper := (maxBound & mask) != mask
+		nextMinBound, nextMaxBound := minBound, maxBound
+		if hasLower {
+			nextMinBound += diff
+		}
+		if hasUpper {
+			nextMaxBound -= diff
+		}
+		nextMinBound &^= mask
+		nextMaxBound &^= mask
+		lowerWrapped := nextMinBound < minBound
+		upperWrapped := nextMaxBound > maxBound
+
+		if shift+precisionStep >= valSize || nextMinBound > nextMaxBound || lowerWrapped || upperWrapped {
+			// We are in the lowest precision or the next precision is not
+			// available.
+			addRangeWithShift(minBound, maxBound, shift, addRange)
+			// exit the split recursion loop
+			break
+		}
+
+		if hasLower {
+			addRangeWithShift(minBound, minBound|mask, shift, addRange)
+		}
+		if hasUpper {
+			addRangeWithShift(maxBound&^mask, maxBound, shift, addRange)
+		}
+
+		// recurse to next precision
+		minBound = nextMinBound
+		maxBound = nextMaxBound
+	}
+}
+
+/* Helper that delegates to the correct range builder */
+func addRangeWithShift(minBound, maxBound int64, shift int, addRange func(min, max int64, shift int)) {
+	// for the max bound set all lower bits (that were shifted away):
+	// this is important for testing or other usages of the splitted
+	// range (e.g. to reconstruct the full range). The prefixEncoding
+	// will remove the bits anyway, so they do not hurt!
+	maxBound |= (int64(1) << uint(shift)) - 1
+	addRange(minBound, maxBound, shift)
+}
@@ -0,0 +1,143 @@
+package util
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestLongPrefixCoding(t *testing.T) {
+	vals := []int64{math.MinInt64, math.MinInt64 + 1, -1 << 40, -1, 0, 1, 1 << 40, math.MaxInt64 - 1, math.MaxInt64}
+	var encoded [][]byte
+	for _, v := range vals {
+		for shift := 0; shift < 64; shift++ {
+			bytes := NewBytesRefBuilder()
+			LongToPrefixCodedBytes(v, shift, bytes)
+			decoded, err := PrefixCodedToLong(bytes.Get().ToBytes())
+			assert2(err == nil, "%v", err)
+			expected := v &^ ((int64(1) << uint(shift)) - 1)
+			assert2(decoded == expected, "expect %v for %v with shift=%v, but got %v", expected, v, shift, decoded)
+		}
+		bytes := NewBytesRefBuilder()
+		LongToPrefixCodedBytes(v, 0, bytes)
+		encoded = append(encoded, append([]byte(nil), bytes.Get().ToBytes()...))
+	}
+	// the encoded values must keep the order of the values
+	for i := 1; i < len(encoded); i++ {
+		assert2(UTF8SortedAsUnicodeLess(encoded[i-1], encoded[i]),
+			"expect %v < %v in encoded form", vals[i-1], vals[i])
+	}
+}
+
+func TestIntPrefixCoding(t *testing.T) {
+	vals := []int32{math.MinInt32, -1 << 20, -1, 0, 1, 1 << 20, math.MaxInt32}
+	var encoded [][]byte
+	for _, v := range vals {
+		for shift := 0; shift < 32; shift++ {
+			bytes := NewBytesRefBuilder()
+			IntToPrefixCodedBytes(v, shift, bytes)
+			decoded, err := PrefixCodedToInt(bytes.Get().ToBytes())
+			assert2(err == nil, "%v", err)
+			expected := v &^ ((int32(1) << uint(shift)) - 1)
+			assert2(decoded == expected, "expect %v for %v with shift=%v, but got %v", expected, v, shift, decoded)
+		}
+		bytes := NewBytesRefBuilder()
+		IntToPrefixCodedBytes(v, 0, bytes)
+		encoded = append(encoded, append([]byte(nil), bytes.Get().ToBytes()...))
+	}
+	for i := 1; i < len(encoded); i++ {
+		assert2(UTF8SortedAsUnicodeLess(encoded[i-1], encoded[i]),
+			"expect %v < %v in encoded form", vals[i-1], vals[i])
+	}
+
+	bytes := NewBytesRefBuilder()
+	IntToPrefixCodedBytes(1, 0, bytes)
+	_, err := PrefixCodedToLong(bytes.Get().ToBytes())
+	assert2(err != nil, "expect int encoding to be rejected as long")
+}
+
+func TestSortableFloatingPoints(t *testing.T) {
+	doubles := []float64{math.Inf(-1), -2.3e25, -1.0, -1e-300, math.Copysign(0, -1), 0.0, 1e-300, 1.0, 2.3e25, math.Inf(1), math.NaN()}
+	for i, v := range doubles {
+		sortable := DoubleToSortableLong(v)
+		back := SortableLongToDouble(sortable)
+		assert2(math.Float64bits(back) == math.Float64bits(v), "round trip of %v gave %v", v, back)
+		if i > 0 {
+			assert2(DoubleToSortableLong(doubles[i-1]) < sortable, "expect %v < %v", doubles[i-1], v)
+		}
+	}
+
+	floats := []float32{float32(math.Inf(-1)), -2.3e25, -1.0, -1e-30, float32(math.Copysign(0, -1)), 0.0, 1e-30, 1.0, 2.3e25, float32(math.Inf(1)), float32(math.NaN())}
+	for i, v := range floats {
+		sortable := FloatToSortableInt(v)
+		back := SortableIntToFloat(sortable)
+		assert2(math.Float32bits(back) == math.Float32bits(v), "round trip of %v gave %v", v, back)
+		if i > 0 {
+			assert2(FloatToSortableInt(floats[i-1]) < sortable, "expect %v < %v", floats[i-1], v)
+		}
+	}
+}
+
+/*
+Checks that the sub-ranges of SplitLongRange() exactly cover the
+range, by decoding the prefix coded bounds.
+*/
+func checkLongRangeSplit(lower, upper int64, precisionStep int) {
+	type subRange struct{ min, max int64 }
+	var ranges []subRange
+	SplitLongRange(func(minPrefixCoded, maxPrefixCoded []byte) {
+		min, err := PrefixCodedToLong(minPrefixCoded)
+		assert2(err == nil, "%v", err)
+		max, err := PrefixCodedToLong(maxPrefixCoded)
+		assert2(err == nil, "%v", err)
+		shift, err := PrefixCodedLongShift(maxPrefixCoded)
+		assert2(err == nil, "%v", err)
+		ranges = append(ranges, subRange{min, max | (int64(1)<<uint(shift) - 1)})
+	}, precisionStep, lower, upper)
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].min < ranges[j].min })
+	assert2(len(ranges) > 0, "no ranges for [%v, %v]", lower, upper)
+	assert2(ranges[0].min == lower, "expect lower bound %v, but got %v", lower, ranges[0].min)
+	for i := 1; i < len(ranges); i++ {
+		assert2(ranges[i].min == ranges[i-1].max+1,
+			"gap or overlap between %v and %v for [%v, %v]", ranges[i-1], ranges[i], lower, upper)
+	}
+	assert2(ranges[len(ranges)-1].max == upper, "expect upper bound %v, but got %v", upper, ranges[len(ranges)-1].max)
+}
+
+func TestSplitLongRange(t *testing.T) {
+	for _, precisionStep := range []int{1, 4, 8, 16, 64} {
+		checkLongRangeSplit(-5000, 9500, precisionStep)
+		checkLongRangeSplit(0, 0, precisionStep)
+		checkLongRangeSplit(-1, 1, precisionStep)
+		checkLongRangeSplit(math.MinInt64, math.MaxInt64, precisionStep)
+		checkLongRangeSplit(math.MinInt64, math.MinInt64+0xf, precisionStep)
+		checkLongRangeSplit(math.MaxInt64-0xfff, math.MaxInt64, precisionStep)
+		checkLongRangeSplit(1<<40-123, 1<<41+4567, precisionStep)
+	}
+
+	count := 0
+	SplitLongRange(func(min, max []byte) { count++ }, 4, 10, 9)
+	assert2(count == 0, "expect no ranges for an empty range, but got %v", count)
+}
+
+func TestSplitIntRange(t *testing.T) {
+	var mins, maxs []int32
+	SplitIntRange(func(minPrefixCoded, maxPrefixCoded []byte) {
+		min, err := PrefixCodedToInt(minPrefixCoded)
+		assert2(err == nil, "%v", err)
+		max, err := PrefixCodedToInt(maxPrefixCoded)
+		assert2(err == nil, "%v", err)
+		mins = append(mins, min)
+		maxs = append(maxs, max)
+	}, 8, -300, 300)
+	// [-300, -257] at full precision, [-256, 255] at shift 8 and
+	// [256, 300] at full precision
+	expectedMins := []int32{-300, 256, -256}
+	expectedMaxs := []int32{-257, 300, 0}
+	assert2(len(mins) == len(expectedMins), "expect %v ranges, but got %v", len(expectedMins), len(mins))
+	for i, min := range mins {
+		assert2(min == expectedMins[i] && maxs[i] == expectedMaxs[i],
+			"expect range %v: [%v, %v], but got [%v, %v]", i, expectedMins[i], expectedMaxs[i], min, maxs[i])
+	}
+}
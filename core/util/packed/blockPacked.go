@@ -0,0 +1,481 @@
+package packed
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+)
+
+// util/packed/AbstractBlockPackedWriter.java
+
+const (
+	BLOCK_PACKED_MIN_BLOCK_SIZE = 64
+	BLOCK_PACKED_MAX_BLOCK_SIZE = 1 << (30 - 3)
+
+	MIN_VALUE_EQUALS_0 = 1 << 0
+	BPV_SHIFT          = 1
+)
+
+/* Same as DataOutput.WriteVLong() but accepts negative values. */
+func writeVLong(out util.DataOutput, i int64) error {
+	k := 0
+	for (i&^0x7F) != 0 && k < 8 {
+		k++
+		if err := out.WriteByte(byte((i & 0x7F) | 0x80)); err != nil {
+			return err
+		}
+		i = int64(uint64(i) >> 7)
+	}
+	return out.WriteByte(byte(i))
+}
+
+type blockPackedFlusher interface {
+	flush() error
+}
+
+type abstractBlockPackedWriter struct {
+	flusher  blockPackedFlusher
+	out      util.DataOutput
+	values   []int64
+	blocks   []byte
+	off      int
+	ord      int64
+	finished bool
+}
+
+func newAbstractBlockPackedWriter(flusher blockPackedFlusher,
+	out util.DataOutput, blockSize int) *abstractBlockPackedWriter {
+
+	checkBlockSize(blockSize, BLOCK_PACKED_MIN_BLOCK_SIZE, BLOCK_PACKED_MAX_BLOCK_SIZE)
+	return &abstractBlockPackedWriter{
+		flusher: flusher,
+		out:     out,
+		values:  make([]int64, blockSize),
+	}
+}
+
+/* Append a new int64. */
+func (w *abstractBlockPackedWriter) Add(l int64) error {
+	assert2(!w.finished, "Already finished")
+	if w.off == len(w.values) {
+		if err := w.flusher.flush(); err != nil {
+			return err
+		}
+	}
+	w.values[w.off] = l
+	w.off++
+	w.ord++
+	return nil
+}
+
+/*
+Flush all buffered data to disk. This instance is not usable anymore
+after this method has been called.
+*/
+func (w *abstractBlockPackedWriter) Finish() error {
+	assert2(!w.finished, "Already finished")
+	if w.off > 0 {
+		if err := w.flusher.flush(); err != nil {
+			return err
+		}
+	}
+	w.finished = true
+	return nil
+}
+
+/* Return the number of values which have been added. */
+func (w *abstractBlockPackedWriter) Ord() int64 {
+	return w.ord
+}
+
+func (w *abstractBlockPackedWriter) writeValues(bitsRequired int) error {
+	encoder := GetPackedIntsEncoder(PACKED, VERSION_CURRENT, uint32(bitsRequired))
+	iterations := len(w.values) / encoder.ByteValueCount()
+	blockSize := encoder.ByteBlockCount() * iterations
+	if len(w.blocks) < blockSize {
+		w.blocks = make([]byte, blockSize)
+	}
+	for i := w.off; i < len(w.values); i++ {
+		w.values[i] = 0
+	}
+	encoder.encodeLongToByte(w.values, w.blocks, iterations)
+	blockCount := int(PackedFormat(PACKED).ByteCount(VERSION_CURRENT, int32(w.off), uint32(bitsRequired)))
+	return w.out.WriteBytes(w.blocks[:blockCount])
+}
+
+// util/packed/BlockPackedWriter.java
+
+/*
+A writer for large sequences of int64s.
+
+The sequence is divided into fixed-size blocks and for each block,
+the difference between each value and the minimum value of the block
+is encoded using as few bits as possible. Memory usage of this class
+is proportional to the block size. Each block has an overhead between
+1 and 10 bytes to store the minimum value and the number of bits per
+value of the block.
+
+Format:
+
+  - <BLock>^BlockCount
+  - BlockCount: ceil(ValueCount / BlockSize)
+  - Block: <Header, (Ints)>
+  - Header: <Token, (MinValue)>
+  - Token: a byte, first 7 bits are the number of bits per value
+    (bitsPerValue). If the 8th bit is 1, then MinValue (see next) is 0,
+    otherwise MinValue and needs to be decoded
+  - MinValue: a zigzag-encoded variable-length int64 whose value should
+    be added to every int from the block to restore the original values
+  - Ints: If the number of bits per value is 0, then there is nothing to
+    decode and all ints are equal to MinValue. Otherwise: BlockSize
+    packed ints encoded on exactly bitsPerValue bits per value. They are
+    the subtraction of the original values and MinValue
+*/
+type BlockPackedWriter struct {
+	*abstractBlockPackedWriter
+}
+
+func NewBlockPackedWriter(out util.DataOutput, blockSize int) *BlockPackedWriter {
+	ans := new(BlockPackedWriter)
+	ans.abstractBlockPackedWriter = newAbstractBlockPackedWriter(ans, out, blockSize)
+	return ans
+}
+
+func (w *BlockPackedWriter) flush() error {
+	assert(w.off > 0)
+	min, max := int64(math.MaxInt64), int64(math.MinInt64)
+	for _, v := range w.values[:w.off] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+
+	delta := max - min
+	bitsRequired := 0
+	if delta != 0 {
+		bitsRequired = UnsignedBitsRequired(delta)
+	}
+	if bitsRequired == 64 {
+		// no need to delta-encode
+		min = 0
+	} else if min > 0 {
+		// make min as small as possible so that writeVLong requires fewer bytes
+		if min = max - MaxValue(bitsRequired); min < 0 {
+			min = 0
+		}
+	}
+
+	token := bitsRequired << BPV_SHIFT
+	if min == 0 {
+		token |= MIN_VALUE_EQUALS_0
+	}
+	if err := w.out.WriteByte(byte(token)); err != nil {
+		return err
+	}
+
+	if min != 0 {
+		if err := writeVLong(w.out, util.ZigZagEncodeLong(min)-1); err != nil {
+			return err
+		}
+	}
+
+	if bitsRequired > 0 {
+		if min != 0 {
+			for i := 0; i < w.off; i++ {
+				w.values[i] -= min
+			}
+		}
+		if err := w.writeValues(bitsRequired); err != nil {
+			return err
+		}
+	}
+
+	w.off = 0
+	return nil
+}
+
+// util/packed/BlockPackedReaderIterator.java
+
+/* Same as DataInput.ReadVLong() but supports negative values. */
+func readVLong(in util.DataInput) (int64, error) {
+	var i int64
+	for shift := uint(0); shift < 56; shift += 7 {
+		b, err := in.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		i |= int64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return i, nil
+		}
+	}
+	b, err := in.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	return i | int64(b)<<56, nil
+}
+
+// util/packed/BlockPackedReader.java
+
+/*
+Provides random access to a stream written with BlockPackedWriter.
+Values are loaded in memory.
+*/
+type BlockPackedReader struct {
+	blockShift, blockMask int
+	valueCount            int64
+	minValues             []int64
+	subReaders            []PackedIntsReader
+}
+
+func NewBlockPackedReader(in util.DataInput, packedIntsVersion, blockSize int,
+	valueCount int64) (*BlockPackedReader, error) {
+
+	ans := &BlockPackedReader{
+		valueCount: valueCount,
+		blockShift: checkBlockSize(blockSize, BLOCK_PACKED_MIN_BLOCK_SIZE, BLOCK_PACKED_MAX_BLOCK_SIZE),
+		blockMask:  blockSize - 1,
+	}
+	n := numBlocks(valueCount, blockSize)
+	ans.subReaders = make([]PackedIntsReader, n)
+	for i := 0; i < n; i++ {
+		b, err := in.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		token := int(b)
+		bitsPerValue := token >> BPV_SHIFT
+		if bitsPerValue > 64 {
+			return nil, errors.New(fmt.Sprintf("Corrupted (bitsPerValue=%v)", bitsPerValue))
+		}
+		if (token & MIN_VALUE_EQUALS_0) == 0 {
+			if ans.minValues == nil {
+				ans.minValues = make([]int64, n)
+			}
+			v, err := readVLong(in)
+			if err != nil {
+				return nil, err
+			}
+			ans.minValues[i] = util.ZigZagDecodeLong(1 + v)
+		}
+		if bitsPerValue == 0 {
+			ans.subReaders[i] = newNilReader(blockSize)
+		} else {
+			size := int(valueCount - int64(i)*int64(blockSize))
+			if size > blockSize {
+				size = blockSize
+			}
+			if ans.subReaders[i], err = ReaderNoHeader(in, PACKED,
+				int32(packedIntsVersion), int32(size), uint32(bitsPerValue)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ans, nil
+}
+
+func (r *BlockPackedReader) Get(index int64) int64 {
+	assert(index >= 0 && index < r.valueCount)
+	block := int(uint64(index) >> uint(r.blockShift))
+	idx := int(index & int64(r.blockMask))
+	if r.minValues == nil {
+		return r.subReaders[block].Get(idx)
+	}
+	return r.minValues[block] + r.subReaders[block].Get(idx)
+}
+
+func (r *BlockPackedReader) RamBytesUsed() int64 {
+	size := util.SizeOf(r.minValues)
+	for _, reader := range r.subReaders {
+		size += reader.RamBytesUsed()
+	}
+	return size
+}
+
+// util/packed/MonotonicBlockPackedWriter.java
+
+/*
+A writer for large monotonically increasing sequences of positive
+int64s.
+
+The sequence is divided into fixed-size blocks and for each block,
+values are modeled after a linear function f: x -> A * x + B. The
+block encodes deltas from the expected values computed from this
+function using as few bits as possible. Each block has an overhead
+between 6 and 14 bytes.
+
+Format:
+
+  - <BLock>^BlockCount
+  - BlockCount: ceil(ValueCount / BlockSize)
+  - Block: <Header, (Ints)>
+  - Header: <B, A, BitsPerValue>
+  - B: the B from f: x -> A * x + B using a zig-zag encoded vLong
+  - A: the A from f: x -> A * x + B encoded using float32 bits
+  - BitsPerValue: a variable-length int
+  - Ints: if BitsPerValue is 0, then there is nothing to read and all
+    values perfectly match the result of the function. Otherwise, these
+    are the packed deltas from the expected value (computed from the
+    function) using exaclty BitsPerValue bits per value.
+*/
+type MonotonicBlockPackedWriter struct {
+	*abstractBlockPackedWriter
+}
+
+func NewMonotonicBlockPackedWriter(out util.DataOutput, blockSize int) *MonotonicBlockPackedWriter {
+	ans := new(MonotonicBlockPackedWriter)
+	ans.abstractBlockPackedWriter = newAbstractBlockPackedWriter(ans, out, blockSize)
+	return ans
+}
+
+func (w *MonotonicBlockPackedWriter) Add(l int64) error {
+	assert(l >= 0)
+	return w.abstractBlockPackedWriter.Add(l)
+}
+
+func monotonicExpected(origin int64, average float32, index int) int64 {
+	return origin + int64(average*float32(index))
+}
+
+func (w *MonotonicBlockPackedWriter) flush() error {
+	assert(w.off > 0)
+
+	var avg float32
+	if w.off > 1 {
+		avg = float32(w.values[w.off-1]-w.values[0]) / float32(w.off-1)
+	}
+	min := w.values[0]
+	// adjust min so that all deltas will be positive
+	for i := 1; i < w.off; i++ {
+		actual := w.values[i]
+		if expected := monotonicExpected(min, avg, i); expected > actual {
+			min -= expected - actual
+		}
+	}
+
+	var maxDelta int64
+	for i := 0; i < w.off; i++ {
+		w.values[i] -= monotonicExpected(min, avg, i)
+		if w.values[i] > maxDelta {
+			maxDelta = w.values[i]
+		}
+	}
+
+	if err := w.out.WriteVLong(util.ZigZagEncodeLong(min)); err != nil {
+		return err
+	}
+	if err := w.out.WriteInt(int32(math.Float32bits(avg))); err != nil {
+		return err
+	}
+	if maxDelta == 0 {
+		if err := w.out.WriteVInt(0); err != nil {
+			return err
+		}
+	} else {
+		bitsRequired := BitsRequired(maxDelta)
+		if err := w.out.WriteVInt(int32(bitsRequired)); err != nil {
+			return err
+		}
+		if err := w.writeValues(bitsRequired); err != nil {
+			return err
+		}
+	}
+
+	w.off = 0
+	return nil
+}
+
+// util/packed/MonotonicBlockPackedReader.java
+
+/*
+Provides random access to a stream written with
+MonotonicBlockPackedWriter. Values are loaded in memory.
+*/
+type MonotonicBlockPackedReader struct {
+	blockShift, blockMask int
+	valueCount            int64
+	minValues             []int64
+	averages              []float32
+	subReaders            []PackedIntsReader
+	zigZag                bool
+}
+
+func NewMonotonicBlockPackedReader(in util.DataInput, packedIntsVersion, blockSize int,
+	valueCount int64) (*MonotonicBlockPackedReader, error) {
+
+	ans := &MonotonicBlockPackedReader{
+		valueCount: valueCount,
+		blockShift: checkBlockSize(blockSize, BLOCK_PACKED_MIN_BLOCK_SIZE, BLOCK_PACKED_MAX_BLOCK_SIZE),
+		blockMask:  blockSize - 1,
+		zigZag:     packedIntsVersion < VERSION_MONOTONIC_WITHOUT_ZIGZAG,
+	}
+	n := numBlocks(valueCount, blockSize)
+	ans.minValues = make([]int64, n)
+	ans.averages = make([]float32, n)
+	ans.subReaders = make([]PackedIntsReader, n)
+	for i := 0; i < n; i++ {
+		v, err := in.ReadVLong()
+		if err != nil {
+			return nil, err
+		}
+		if ans.zigZag {
+			ans.minValues[i] = v
+		} else {
+			ans.minValues[i] = util.ZigZagDecodeLong(v)
+		}
+		bits, err := in.ReadInt()
+		if err != nil {
+			return nil, err
+		}
+		ans.averages[i] = math.Float32frombits(uint32(bits))
+		bitsPerValue, err := in.ReadVInt()
+		if err != nil {
+			return nil, err
+		}
+		if bitsPerValue > 64 {
+			return nil, errors.New(fmt.Sprintf("Corrupted (bitsPerValue=%v)", bitsPerValue))
+		}
+		if bitsPerValue == 0 {
+			ans.subReaders[i] = newNilReader(blockSize)
+		} else {
+			size := int(valueCount - int64(i)*int64(blockSize))
+			if size > blockSize {
+				size = blockSize
+			}
+			if ans.subReaders[i], err = ReaderNoHeader(in, PACKED,
+				int32(packedIntsVersion), int32(size), uint32(bitsPerValue)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ans, nil
+}
+
+func (r *MonotonicBlockPackedReader) Get(index int64) int64 {
+	assert(index >= 0 && index < r.valueCount)
+	block := int(uint64(index) >> uint(r.blockShift))
+	idx := int(index & int64(r.blockMask))
+	delta := r.subReaders[block].Get(idx)
+	if r.zigZag {
+		delta = util.ZigZagDecodeLong(delta)
+	}
+	return monotonicExpected(r.minValues[block], r.averages[block], idx) + delta
+}
+
+/* Returns the number of values */
+func (r *MonotonicBlockPackedReader) Size() int64 {
+	return r.valueCount
+}
+
+func (r *MonotonicBlockPackedReader) RamBytesUsed() int64 {
+	size := util.SizeOf(r.minValues) + int64(4*len(r.averages))
+	for _, reader := range r.subReaders {
+		size += reader.RamBytesUsed()
+	}
+	return size
+}
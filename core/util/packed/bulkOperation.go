@@ -2,6 +2,7 @@ package packed
 
 import (
 	"fmt"
+	"math"
 )
 
 // util/packed/BulkOperation.java
@@ -21,6 +22,7 @@ type BulkOperation interface {
 	// PackedIntsDecoder
 	decodeLongToLong(blocks, values []int64, iterations int)
 	decodeByteToLong(blocks []byte, values []int64, iterations int)
+	DecodeByteToInt(blocks []byte, values []int, iterations int)
 	/*
 		For every number of bits per value, there is a minumum number of
 		blocks (b) / values (v) you need to write an order to reach the next block
@@ -431,7 +433,7 @@ func (op *BulkOperationImpl) computeIterations(valueCount, ramBudget int) int {
 		return 1
 	} else if (iterations-1)*op.ByteValueCount() >= valueCount {
 		// don't allocate for more than the size of the reader
-		panic("not implemented yet")
+		return int(math.Ceil(float64(valueCount) / float64(op.ByteValueCount())))
 	} else {
 		return iterations
 	}
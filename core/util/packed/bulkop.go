@@ -81,7 +81,61 @@ func (p *BulkOperationPacked) decodeLongToLong(blocks, values []int64, iteration
 }
 
 func (p *BulkOperationPacked) decodeByteToLong(blocks []byte, values []int64, iterations int) {
-	panic("niy")
+	nextValue := int64(0)
+	bitsLeft := p.bitsPerValue
+	blocksOff, valuesOff := 0, 0
+	for i := 0; i < iterations*p.byteBlockCount; i++ {
+		bytes := int64(blocks[blocksOff])
+		blocksOff++
+		if bitsLeft > 8 {
+			// just buffer
+			bitsLeft -= 8
+			nextValue |= bytes << uint(bitsLeft)
+		} else {
+			// flush
+			bits := 8 - bitsLeft
+			values[valuesOff] = nextValue | int64(uint64(bytes)>>uint(bits))
+			valuesOff++
+			for bits >= p.bitsPerValue {
+				bits -= p.bitsPerValue
+				values[valuesOff] = int64(uint64(bytes)>>uint(bits)) & p.mask
+				valuesOff++
+			}
+			// then buffer
+			bitsLeft = p.bitsPerValue - bits
+			nextValue = (bytes & ((1 << uint(bits)) - 1)) << uint(bitsLeft)
+		}
+	}
+	assert(bitsLeft == p.bitsPerValue)
+}
+
+func (p *BulkOperationPacked) DecodeByteToInt(blocks []byte, values []int, iterations int) {
+	nextValue := 0
+	bitsLeft := p.bitsPerValue
+	blocksOff, valuesOff := 0, 0
+	for i := 0; i < iterations*p.byteBlockCount; i++ {
+		bytes := int(blocks[blocksOff])
+		blocksOff++
+		if bitsLeft > 8 {
+			// just buffer
+			bitsLeft -= 8
+			nextValue |= bytes << uint(bitsLeft)
+		} else {
+			// flush
+			bits := 8 - bitsLeft
+			values[valuesOff] = nextValue | (bytes >> uint(bits))
+			valuesOff++
+			for bits >= p.bitsPerValue {
+				bits -= p.bitsPerValue
+				values[valuesOff] = (bytes >> uint(bits)) & p.intMask
+				valuesOff++
+			}
+			// then buffer
+			bitsLeft = p.bitsPerValue - bits
+			nextValue = (bytes & ((1 << uint(bits)) - 1)) << uint(bitsLeft)
+		}
+	}
+	assert(bitsLeft == p.bitsPerValue)
 }
 
 func (p *BulkOperationPacked) encodeLongToLong(values, blocks []int64, iterations int) {
@@ -247,13 +301,47 @@ func (p *BulkOperationPackedSingleBlock) decodeLongToLong(blocks, values []int64
 	for i := 0; i < iterations; i++ {
 		block := blocks[blocksOffset]
 		blocksOffset++
-		valuesOffset = p.decodeLongs(block, values[valuesOffset:])
+		valuesOffset += p.decodeLongs(block, values[valuesOffset:])
 	}
 }
 
 func (p *BulkOperationPackedSingleBlock) decodeByteToLong(blocks []byte,
 	values []int64, iterations int) {
-	panic("niy")
+	blocksOffset, valuesOffset := 0, 0
+	for i := 0; i < iterations; i++ {
+		block := readLong(blocks[blocksOffset:])
+		blocksOffset += 8
+		valuesOffset += p.decodeLongs(block, values[valuesOffset:])
+	}
+}
+
+func (p *BulkOperationPackedSingleBlock) DecodeByteToInt(blocks []byte,
+	values []int, iterations int) {
+	blocksOffset, valuesOffset := 0, 0
+	for i := 0; i < iterations; i++ {
+		block := readLong(blocks[blocksOffset:])
+		blocksOffset += 8
+		valuesOffset += p.decodeInts(block, values[valuesOffset:])
+	}
+}
+
+func (p *BulkOperationPackedSingleBlock) decodeInts(block int64, values []int) int {
+	off := 0
+	values[off] = int(block & p.mask)
+	off++
+	for j := 1; j < p.valueCount; j++ {
+		block = int64(uint64(block) >> uint(p.bitsPerValue))
+		values[off] = int(block & p.mask)
+		off++
+	}
+	return off
+}
+
+func readLong(blocks []byte) int64 {
+	return int64(blocks[0])<<56 | int64(blocks[1])<<48 |
+		int64(blocks[2])<<40 | int64(blocks[3])<<32 |
+		int64(blocks[4])<<24 | int64(blocks[5])<<16 |
+		int64(blocks[6])<<8 | int64(blocks[7])
 }
 
 func (p *BulkOperationPackedSingleBlock) encodeLongToLong(values,
@@ -406,7 +406,7 @@ func newPacked64SingleBlock16(valueCount int32) *Packed64SingleBlock {
 		o := uint32(index) >> 2
 		b := index & 3
 		shift := uint32(b << 4)
-		ans.blocks[o] = (ans.blocks[o] & ^(int64(65335) << shift)) | (value << shift)
+		ans.blocks[o] = (ans.blocks[o] & ^(int64(65535) << shift)) | (value << shift)
 	}
 	return ans
 }
@@ -23,6 +23,10 @@ type PackedLongValuesBuilder interface {
 	Add(int64) PackedLongValuesBuilder
 }
 
+func PackedBuilder(acceptableOverheadRatio float32) PackedLongValuesBuilder {
+	return newPackedLongValuesBuilder(DEFAULT_PAGE_SIZE, acceptableOverheadRatio)
+}
+
 func DeltaPackedBuilder(acceptableOverheadRatio float32) PackedLongValuesBuilder {
 	return NewDeltaPackedLongValuesBuilder(DEFAULT_PAGE_SIZE, acceptableOverheadRatio)
 }
@@ -196,7 +200,7 @@ func (b *PackedLongValuesBuilderImpl) packFrom(values []int64,
 			bitsRequired = BitsRequired(maxValue)
 		}
 		mutable := MutableFor(len(values), bitsRequired, acceptableOverheadRatio)
-		for i := 0; i < len(values); i++ {
+		for i := 0; i < len(values); {
 			i += mutable.setBulk(i, values[i:])
 		}
 		b.values[block] = mutable
@@ -204,7 +208,11 @@ func (b *PackedLongValuesBuilderImpl) packFrom(values []int64,
 }
 
 func (b *PackedLongValuesBuilderImpl) grow(newBlockCount int) {
-	panic("niy")
+	b.ramBytesUsed -= util.ShallowSizeOf(b.values)
+	values := make([]PackedIntsReader, newBlockCount)
+	copy(values, b.values)
+	b.values = values
+	b.ramBytesUsed += util.ShallowSizeOf(b.values)
 }
 
 // util/packed/DeltaPackedLongValues.java
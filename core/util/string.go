@@ -44,6 +44,23 @@ var GOOD_FAST_HASH_SEED = func() uint32 {
 	}
 }()
 
+/*
+Compares two []byte, element by element, and returns the number of
+elements common to both arrays.
+*/
+func BytesDifference(left, right []byte) int {
+	n := len(left)
+	if len(right) < n {
+		n = len(right)
+	}
+	for i := 0; i < n; i++ {
+		if left[i] != right[i] {
+			return i
+		}
+	}
+	return n
+}
+
 /* Returns true iff the ref starts with the given prefix. Otherwise false. */
 func StartsWith(ref, prefix []byte) bool {
 	return sliceEquals(ref, prefix, 0)
@@ -0,0 +1,226 @@
+package core_test
+
+import (
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+const numBooleanDocs = 2000
+
+/*
+Writes numBooleanDocs documents whose "body" field is "common wN xM",
+where N = i%7 and M = i%3, so that "common" spans several postings
+blocks and skip levels.
+*/
+func openBooleanTestIndex(t *testing.T, path string) (store.Directory, index.IndexReader) {
+	return openTestIndex(t, path, nil, func(writer *index.IndexWriter) {
+		for i := 0; i < numBooleanDocs; i++ {
+			d := docu.NewDocument()
+			d.Add(docu.NewTextFieldFromString("body", fmt.Sprintf("common w%v x%v", i%7, i%3), docu.STORE_YES))
+			addTestDoc(t, writer, d)
+		}
+	})
+}
+
+func bodyTerm(text string) search.Query {
+	return search.NewTermQuery(index.NewTerm("body", text))
+}
+
+func TestBooleanRequiredClauses(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_boolean")
+	defer os.RemoveAll(".gltest_boolean")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		clauses  []search.Query
+		occurs   []search.Occur
+		expected int
+	}{
+		// pure conjunction; "w3" leads and "common" has to skip
+		{[]search.Query{bodyTerm("w3"), bodyTerm("common")},
+			[]search.Occur{search.MUST, search.MUST},
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 })},
+		{[]search.Query{bodyTerm("w3"), bodyTerm("x1")},
+			[]search.Occur{search.MUST, search.MUST},
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 && i%3 == 1 })},
+		// required with exclusion
+		{[]search.Query{bodyTerm("common"), bodyTerm("w3")},
+			[]search.Occur{search.MUST, search.MUST_NOT},
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 != 3 })},
+		{[]search.Query{bodyTerm("common"), bodyTerm("w3"), bodyTerm("x0")},
+			[]search.Occur{search.MUST, search.MUST_NOT, search.MUST_NOT},
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 != 3 && i%3 != 0 })},
+		// required with optional
+		{[]search.Query{bodyTerm("w3"), bodyTerm("x1")},
+			[]search.Occur{search.MUST, search.SHOULD},
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 })},
+		{[]search.Query{bodyTerm("w3"), bodyTerm("x1"), bodyTerm("x2"), bodyTerm("w4")},
+			[]search.Occur{search.MUST, search.SHOULD, search.SHOULD, search.MUST_NOT},
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 })},
+		// required clause missing from the index
+		{[]search.Query{bodyTerm("w3"), bodyTerm("nosuchterm")},
+			[]search.Occur{search.MUST, search.MUST},
+			0},
+		// pure disjunction with exclusion
+		{[]search.Query{bodyTerm("w1"), bodyTerm("w2"), bodyTerm("x0")},
+			[]search.Occur{search.SHOULD, search.SHOULD, search.MUST_NOT},
+			countDocs(numBooleanDocs, func(i int) bool { return (i%7 == 1 || i%7 == 2) && i%3 != 0 })},
+	}
+
+	for _, test := range tests {
+		q := search.NewBooleanQuery()
+		for i, clause := range test.clauses {
+			q.Add(clause, test.occurs[i])
+		}
+		verifyBooleanHits(t, searcher, q, test.expected)
+	}
+}
+
+func verifyBooleanHits(t *testing.T, searcher *search.IndexSearcher, q search.Query, expected int) {
+	res, err := searcher.SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect %v hits for '%v', but got %v", expected, q, res.TotalHits).
+		Verify(res.TotalHits == expected)
+
+	for _, hit := range res.ScoreDocs {
+		explain, err := searcher.Explain(q, hit.Doc)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("explain doesn't think doc %v is a match for '%v'", hit.Doc, q).Verify(explain.IsMatch())
+		It(t).Should("score doesn't match explanation (%v vs %v)", hit.Score, explain.Value()).
+			Verify(isSimilar(hit.Score, explain.Value(), 0.001))
+	}
+}
+
+func TestBooleanConjunctionCost(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_boolean")
+	defer os.RemoveAll(".gltest_boolean")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// the dense clause comes first, but the sparse one leads
+	q := search.NewBooleanQuery()
+	q.Add(bodyTerm("common"), search.MUST)
+	q.Add(bodyTerm("x1"), search.MUST)
+	q.Add(bodyTerm("w3"), search.MUST)
+	w, err := searcher.CreateNormalizedWeight(q)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	var cost int64
+	for _, leaf := range reader.Leaves() {
+		scorer, err := w.Scorer(leaf, nil)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		cost += scorer.Cost()
+	}
+	expected := countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 })
+	It(t).Should("expect the cost of w3 (%v), but got %v", expected, cost).Verify(cost == int64(expected))
+}
+
+func TestBooleanOptionalClauseRanksHigher(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_boolean")
+	defer os.RemoveAll(".gltest_boolean")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	q := search.NewBooleanQuery()
+	q.Add(bodyTerm("w3"), search.MUST)
+	q.Add(bodyTerm("x1"), search.SHOULD)
+	expected := countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 && i%3 == 1 })
+
+	res, err := searcher.SearchTop(q, expected)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect %v top hits, got %v", expected, len(res.ScoreDocs)).Assert(len(res.ScoreDocs) == expected)
+	for _, hit := range res.ScoreDocs {
+		It(t).Should("doc %v should match x1", hit.Doc).Verify(hit.Doc%7 == 3 && hit.Doc%3 == 1)
+	}
+}
+
+func TestBooleanMinimumShouldMatch(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_boolean")
+	defer os.RemoveAll(".gltest_boolean")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	wIn := func(i int, ws ...int) bool {
+		for _, w := range ws {
+			if i%7 == w {
+				return true
+			}
+		}
+		return false
+	}
+
+	tests := []struct {
+		clauses        []search.Query
+		occurs         []search.Occur
+		minShouldMatch int
+		expected       int
+	}{
+		// pure disjunction
+		{[]search.Query{bodyTerm("w1"), bodyTerm("w2"), bodyTerm("x1"), bodyTerm("x2")},
+			[]search.Occur{search.SHOULD, search.SHOULD, search.SHOULD, search.SHOULD}, 1,
+			countDocs(numBooleanDocs, func(i int) bool { return wIn(i, 1, 2) || i%3 != 0 })},
+		{[]search.Query{bodyTerm("w1"), bodyTerm("w2"), bodyTerm("x1"), bodyTerm("x2")},
+			[]search.Occur{search.SHOULD, search.SHOULD, search.SHOULD, search.SHOULD}, 2,
+			countDocs(numBooleanDocs, func(i int) bool { return wIn(i, 1, 2) && i%3 != 0 })},
+		{[]search.Query{bodyTerm("common"), bodyTerm("w1"), bodyTerm("x1"), bodyTerm("w2"), bodyTerm("x2")},
+			[]search.Occur{search.SHOULD, search.SHOULD, search.SHOULD, search.SHOULD, search.SHOULD}, 3,
+			countDocs(numBooleanDocs, func(i int) bool { return wIn(i, 1, 2) && i%3 != 0 })},
+		// no document has two different w terms
+		{[]search.Query{bodyTerm("w1"), bodyTerm("w2"), bodyTerm("w3"), bodyTerm("x1")},
+			[]search.Occur{search.SHOULD, search.SHOULD, search.SHOULD, search.SHOULD}, 3,
+			0},
+		// more than the number of optional clauses
+		{[]search.Query{bodyTerm("w1"), bodyTerm("x1")},
+			[]search.Occur{search.SHOULD, search.SHOULD}, 3,
+			0},
+		// as many as the optional clauses: a conjunction
+		{[]search.Query{bodyTerm("w1"), bodyTerm("x1")},
+			[]search.Occur{search.SHOULD, search.SHOULD}, 2,
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 == 1 && i%3 == 1 })},
+		// with exclusion
+		{[]search.Query{bodyTerm("w1"), bodyTerm("w2"), bodyTerm("x1"), bodyTerm("x2"), bodyTerm("x2")},
+			[]search.Occur{search.SHOULD, search.SHOULD, search.SHOULD, search.SHOULD, search.MUST_NOT}, 2,
+			countDocs(numBooleanDocs, func(i int) bool { return wIn(i, 1, 2) && i%3 == 1 })},
+		// with required clauses
+		{[]search.Query{bodyTerm("common"), bodyTerm("x1")},
+			[]search.Occur{search.MUST, search.SHOULD}, 1,
+			countDocs(numBooleanDocs, func(i int) bool { return i%3 == 1 })},
+		{[]search.Query{bodyTerm("common"), bodyTerm("w1"), bodyTerm("w2"), bodyTerm("x1")},
+			[]search.Occur{search.MUST, search.SHOULD, search.SHOULD, search.SHOULD}, 1,
+			countDocs(numBooleanDocs, func(i int) bool { return wIn(i, 1, 2) || i%3 == 1 })},
+		{[]search.Query{bodyTerm("common"), bodyTerm("w1"), bodyTerm("w2"), bodyTerm("x1")},
+			[]search.Occur{search.MUST, search.SHOULD, search.SHOULD, search.SHOULD}, 2,
+			countDocs(numBooleanDocs, func(i int) bool { return wIn(i, 1, 2) && i%3 == 1 })},
+	}
+
+	for _, test := range tests {
+		q := search.NewBooleanQuery()
+		for i, clause := range test.clauses {
+			q.Add(clause, test.occurs[i])
+		}
+		q.SetMinimumNumberShouldMatch(test.minShouldMatch)
+		verifyBooleanHits(t, searcher, q, test.expected)
+	}
+
+	q := search.NewBooleanQuery()
+	q.Add(bodyTerm("w1"), search.SHOULD)
+	q.Add(bodyTerm("x1"), search.SHOULD)
+	q.Add(bodyTerm("x2"), search.SHOULD)
+	q.SetMinimumNumberShouldMatch(2)
+	It(t).Should("unexpected string: %v", q).Verify(q.ToString("body") == "(w1 x1 x2)~2")
+
+	// doc 7 only matches w0 and x1
+	explain, err := searcher.Explain(q, 7)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("explain thinks doc 7 is a match for '%v'", q).Verify(!explain.IsMatch())
+}
@@ -0,0 +1,189 @@
+package core_test
+
+import (
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/queries/function"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/* Indexes docs documents, committing a new segment every perSegment ones. */
+func openSegmentedTestIndex(t *testing.T, path string, docs, perSegment int) (store.Directory, index.IndexReader) {
+	return openTestIndex(t, path, nil, func(writer *index.IndexWriter) {
+		for i := 0; i < docs; i++ {
+			d := docu.NewDocument()
+			body := strings.Repeat("common ", i%5+1) + fmt.Sprintf("m%v m%v", i%3, i%7)
+			d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+			d.Add(docu.NewFieldFromString("rank", fmt.Sprintf("%v", (i*37)%19-9), docu.STRING_FIELD_TYPE_NOT_STORED))
+			if i%4 != 0 {
+				d.Add(docu.NewFieldFromString("tag", fmt.Sprintf("t%02d", (i*7)%23), docu.STRING_FIELD_TYPE_NOT_STORED))
+			}
+			addTestDoc(t, writer, d)
+			if (i+1)%perSegment == 0 {
+				commitTestIndex(t, writer)
+			}
+		}
+	})
+}
+
+func verifySameHits(t *testing.T, q search.Query, expected, actual search.TopDocs) {
+	It(t).Should("expect %v total hits for '%v', but got %v", expected.TotalHits, q, actual.TotalHits).
+		Verify(actual.TotalHits == expected.TotalHits)
+	It(t).Should("expect %v hits for '%v', but got %v", len(expected.ScoreDocs), q, len(actual.ScoreDocs)).
+		Assert(len(actual.ScoreDocs) == len(expected.ScoreDocs))
+	for i, hit := range actual.ScoreDocs {
+		want := expected.ScoreDocs[i]
+		It(t).Should("expect hit %v of '%v' to be %v, but got %v", i, q, want, hit).
+			Verify(hit.Doc == want.Doc && hit.Score == want.Score && hit.ShardIndex() == want.ShardIndex())
+	}
+}
+
+func TestConcurrentSearch(t *testing.T) {
+	directory, reader := openSegmentedTestIndex(t, ".gltest_concurrent", 90, 10)
+	defer os.RemoveAll(".gltest_concurrent")
+	defer directory.Close()
+	defer reader.Close()
+	It(t).Should("expect several segments, but got %v", len(reader.Leaves())).Assert(len(reader.Leaves()) > 4)
+
+	serial := search.NewIndexSearcher(reader)
+	bq := search.NewBooleanQuery()
+	bq.Add(bodyQuery("m1"), search.MUST)
+	bq.Add(bodyQuery("m4"), search.SHOULD)
+	tf := function.NewTermFreqValueSource("body", "common", "body", []byte("common"))
+	queries := []search.Query{bodyQuery("common"), bodyQuery("m2"), bq,
+		search.NewPrefixQuery(index.NewTerm("body", "m")), search.NewMatchAllDocsQuery(),
+		function.NewBoostedQuery(bodyQuery("m5"), function.NewScaleFloatFunction(tf, 1, 2))}
+
+	for _, parallelism := range []int{1, 2, 3, 100} {
+		concurrent := search.NewIndexSearcherWithExecutor(reader, parallelism)
+		for _, q := range queries {
+			for _, n := range []int{1, 7, 100} {
+				expected, err := serial.SearchTop(q, n)
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				actual, err := concurrent.SearchTop(q, n)
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				verifySameHits(t, q, expected, actual)
+			}
+
+			// page through the hits
+			expected, err := serial.SearchTop(q, 100)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			var after *search.ScoreDoc
+			for page := 0; page*10 < len(expected.ScoreDocs); page++ {
+				actual, err := concurrent.SearchAfter(after, q, 10)
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				end := page*10 + 10
+				if end > len(expected.ScoreDocs) {
+					end = len(expected.ScoreDocs)
+				}
+				verifySameHits(t, q, search.TopDocs{
+					TotalHits: expected.TotalHits,
+					ScoreDocs: expected.ScoreDocs[page*10 : end],
+				}, actual)
+				after = actual.ScoreDocs[len(actual.ScoreDocs)-1]
+			}
+		}
+	}
+}
+
+func TestConcurrentSearchSorted(t *testing.T) {
+	directory, reader := openSegmentedTestIndex(t, ".gltest_concurrent_sorted", 90, 10)
+	defer os.RemoveAll(".gltest_concurrent_sorted")
+	defer directory.Close()
+	defer reader.Close()
+
+	tagLast := search.NewSortField("tag", search.SORT_FIELD_STRING, true)
+	tagLast.SetMissingValue(search.STRING_LAST)
+	sorts := []*search.Sort{
+		search.NewSort(search.NewSortField("rank", search.SORT_FIELD_INT, false)),
+		search.NewSort(search.NewSortField("rank", search.SORT_FIELD_INT, true), search.FIELD_DOC),
+		search.NewSort(search.NewSortField("tag", search.SORT_FIELD_STRING, false)),
+		search.NewSort(tagLast, search.NewSortField("rank", search.SORT_FIELD_INT, false)),
+		search.NewSort(search.FIELD_SCORE, search.NewSortField("rank", search.SORT_FIELD_INT, true)),
+		search.NewSort(search.NewSortField("", search.SORT_FIELD_DOC, true)),
+	}
+	queries := []search.Query{bodyQuery("common"), bodyQuery("m2"), search.NewMatchAllDocsQuery()}
+
+	serial := search.NewIndexSearcher(reader)
+	for _, parallelism := range []int{2, 3, 100} {
+		concurrent := search.NewIndexSearcherWithExecutor(reader, parallelism)
+		for _, sort := range sorts {
+			for _, q := range queries {
+				for _, n := range []int{1, 7, 100} {
+					expected, err := serial.SearchSorted(q, nil, n, sort)
+					It(t).Should("has no error: %v", err).Assert(err == nil)
+					actual, err := concurrent.SearchSorted(q, nil, n, sort)
+					It(t).Should("has no error: %v", err).Assert(err == nil)
+
+					It(t).Should("expect %v total hits for '%v', but got %v", expected.TotalHits, q, actual.TotalHits).
+						Verify(actual.TotalHits == expected.TotalHits)
+					It(t).Should("expect %v hits for '%v' sorted by %v, but got %v",
+						len(expected.FieldDocs), q, sort, len(actual.FieldDocs)).
+						Assert(len(actual.FieldDocs) == len(expected.FieldDocs))
+					for i, hit := range actual.FieldDocs {
+						want := expected.FieldDocs[i]
+						It(t).Should("expect hit %v of '%v' sorted by %v to be %v %v, but got %v %v",
+							i, q, sort, want.Doc, want.Fields, hit.Doc, hit.Fields).
+							Verify(hit.Doc == want.Doc && reflect.DeepEqual(hit.Fields, want.Fields))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMergeTopDocs(t *testing.T) {
+	shards := []search.TopDocs{
+		{TotalHits: 3, ScoreDocs: []*search.ScoreDoc{{Score: 3, Doc: 5}, {Score: 2, Doc: 1}, {Score: 1, Doc: 0}}},
+		{TotalHits: 0},
+		{TotalHits: 12, ScoreDocs: []*search.ScoreDoc{{Score: 2.5, Doc: 20}, {Score: 2, Doc: 14}}},
+	}
+	merged := search.MergeTopDocs(4, shards)
+	It(t).Should("expect 15 total hits, but got %v", merged.TotalHits).Verify(merged.TotalHits == 15)
+	expected := []struct{ doc, shard int }{{5, 0}, {20, 2}, {1, 0}, {14, 2}}
+	It(t).Should("expect %v hits, but got %v", len(expected), len(merged.ScoreDocs)).
+		Assert(len(merged.ScoreDocs) == len(expected))
+	for i, hit := range merged.ScoreDocs {
+		It(t).Should("expect doc %v of shard %v, but got %v", expected[i].doc, expected[i].shard, hit).
+			Verify(hit.Doc == expected[i].doc && hit.ShardIndex() == expected[i].shard)
+	}
+
+	merged = search.MergeTopDocs(10, shards[1:2])
+	It(t).Should("expect no hits, but got %v", merged.ScoreDocs).Verify(len(merged.ScoreDocs) == 0)
+}
+
+func TestMergeTopFieldDocs(t *testing.T) {
+	sort := search.NewSort(search.NewSortField("rank", search.SORT_FIELD_INT, true), search.FIELD_DOC)
+	fieldDoc := func(doc int, rank int32) *search.FieldDoc {
+		return &search.FieldDoc{ScoreDoc: &search.ScoreDoc{Doc: doc}, Fields: []interface{}{rank, doc}}
+	}
+	topFieldDocs := func(totalHits int, hits ...*search.FieldDoc) search.TopFieldDocs {
+		ans := search.TopFieldDocs{TopDocs: search.TopDocs{TotalHits: totalHits}, FieldDocs: hits}
+		for _, hit := range hits {
+			ans.ScoreDocs = append(ans.ScoreDocs, hit.ScoreDoc)
+		}
+		return ans
+	}
+	shards := []search.TopFieldDocs{
+		topFieldDocs(3, fieldDoc(5, 9), fieldDoc(1, 4), fieldDoc(0, -2)),
+		topFieldDocs(0),
+		topFieldDocs(12, fieldDoc(20, 7), fieldDoc(14, 4)),
+	}
+	merged := search.MergeTopFieldDocs(sort, 4, shards)
+	It(t).Should("expect 15 total hits, but got %v", merged.TotalHits).Verify(merged.TotalHits == 15)
+	expected := []struct{ doc, shard int }{{5, 0}, {20, 2}, {1, 0}, {14, 2}}
+	It(t).Should("expect %v hits, but got %v", len(expected), len(merged.FieldDocs)).
+		Assert(len(merged.FieldDocs) == len(expected) && len(merged.ScoreDocs) == len(expected))
+	for i, hit := range merged.FieldDocs {
+		It(t).Should("expect doc %v of shard %v, but got %v", expected[i].doc, expected[i].shard, hit).
+			Verify(hit.Doc == expected[i].doc && hit.ShardIndex() == expected[i].shard &&
+				hit.ScoreDoc == merged.ScoreDocs[i] && hit.Fields[1] == expected[i].doc)
+	}
+}
@@ -0,0 +1,98 @@
+package core_test
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+func bodyQuery(text string) search.Query {
+	return search.NewTermQuery(index.NewTerm("body", text))
+}
+
+func TestConstantScoreQuery(t *testing.T) {
+	directory, reader := openSimilarityTestIndex(t, ".gltest_constantscore", search.NewDefaultSimilarity())
+	defer os.RemoveAll(".gltest_constantscore")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	either := search.NewBooleanQuery()
+	either.Add(bodyQuery("apple"), search.SHOULD)
+	either.Add(bodyQuery("date"), search.SHOULD)
+
+	tests := []struct {
+		q        search.Query
+		str      string
+		expected int
+	}{
+		{search.NewConstantScoreQueryFromQuery(bodyQuery("apple")), "ConstantScore(body:apple)", 3},
+		{search.NewConstantScoreQueryFromQuery(either), "ConstantScore(body:apple body:date)", 4},
+		{search.NewConstantScoreQueryFromQuery(search.NewPrefixQuery(index.NewTerm("body", "ch"))),
+			"ConstantScore(body:ch*)", 3},
+		{search.NewConstantScoreQuery(search.NewQueryWrapperFilter(bodyQuery("banana"))),
+			"ConstantScore(QueryWrapperFilter(body:banana))", 3},
+	}
+	for _, test := range tests {
+		It(t).Should("expect '%v', but got '%v'", test.str, test.q).Verify(test.q.ToString("") == test.str)
+		test.q.SetBoost(3)
+		verifyBooleanHits(t, searcher, test.q, test.expected)
+
+		res, err := searcher.SearchTop(test.q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		for _, hit := range res.ScoreDocs {
+			// a single query is normalized by its own boost
+			It(t).Should("expect a constant score for '%v', but got %v", test.q, hit.Score).
+				Verify(hit.Score == 1)
+		}
+	}
+
+	// a filtering clause doesn't change the ranking of the scoring one
+	scoring, err := searcher.SearchTop(bodyQuery("cherry"), 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	filtered := search.NewBooleanQuery()
+	filtered.Add(bodyQuery("cherry"), search.MUST)
+	filtered.Add(search.NewConstantScoreQueryFromQuery(bodyQuery("date")), search.MUST)
+	verifyBooleanHits(t, searcher, filtered, 2)
+	res, err := searcher.SearchTop(filtered, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	var ranking []int
+	for _, hit := range scoring.ScoreDocs {
+		if hit.Doc == 2 || hit.Doc == 4 {
+			ranking = append(ranking, hit.Doc)
+		}
+	}
+	for i, hit := range res.ScoreDocs {
+		It(t).Should("expect doc %v at %v, but got %v", ranking[i], i, hit.Doc).Verify(hit.Doc == ranking[i])
+	}
+}
+
+func TestDisjunctionMaxQueryTieBreaker(t *testing.T) {
+	directory, reader := openSimilarityTestIndex(t, ".gltest_constantscore", search.NewDefaultSimilarity())
+	defer os.RemoveAll(".gltest_constantscore")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	for _, tie := range []float32{0, 0.5, 1} {
+		q := search.NewDisjunctionMaxQuery([]search.Query{
+			search.NewConstantScoreQueryFromQuery(bodyQuery("banana")),
+			search.NewConstantScoreQueryFromQuery(bodyQuery("date")),
+		}, tie)
+		verifyBooleanHits(t, searcher, q, 4)
+
+		// doc 2 matches both, while the others match only one
+		scores := make(map[int]float32)
+		res, err := searcher.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		for _, hit := range res.ScoreDocs {
+			scores[hit.Doc] = hit.Score
+		}
+		It(t).Should("expect docs 1, 3 and 4 to score alike, but got %v", scores).
+			Verify(scores[1] == scores[3] && scores[3] == scores[4])
+		It(t).Should("expect the max plus %v times the other, but got %v", tie, scores).
+			Verify(isSimilar(scores[2], scores[1]*(1+tie), 0.0001))
+	}
+}
@@ -0,0 +1,129 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+const numDeleteDocs = 100
+
+func openDeleteTestWriter(t *testing.T, path string) (store.Directory, *index.IndexWriter) {
+	directory, writer := openTestWriter(t, path, nil)
+	for i := 0; i < numDeleteDocs; i++ {
+		addTestDoc(t, writer, deleteTestDoc(i, "common"))
+	}
+	return directory, writer
+}
+
+func deleteTestDoc(i int, body string) *docu.Document {
+	d := docu.NewDocument()
+	d.Add(docu.NewFieldFromString("id", fmt.Sprintf("%v", i), docu.STRING_FIELD_TYPE_STORED))
+	d.Add(docu.NewTextFieldFromString("body", fmt.Sprintf("%v x%v", body, i%3), docu.STORE_NO))
+	return d
+}
+
+/* Commits and closes the writer, then checks the reopened index. */
+func verifyDeletes(t *testing.T, directory store.Directory, writer *index.IndexWriter,
+	numDocs int, tests map[search.Query]int) {
+
+	err := writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	It(t).Should("expect %v docs, but got %v", numDocs, reader.NumDocs()).
+		Verify(reader.NumDocs() == numDocs)
+
+	searcher := search.NewIndexSearcher(reader)
+	for q, expected := range tests {
+		res, err := searcher.Search(q, nil, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect %v hits for '%v', but got %v", expected, q, res.TotalHits).
+			Verify(res.TotalHits == expected)
+	}
+}
+
+func TestDeleteDocumentsByTerm(t *testing.T) {
+	directory, writer := openDeleteTestWriter(t, ".gltest_delete")
+	defer os.RemoveAll(".gltest_delete")
+	defer directory.Close()
+
+	err := writer.DeleteDocuments(index.NewTerm("id", "3"), index.NewTerm("id", "4"))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.DeleteDocuments(index.NewTerm("id", "nosuchid"))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	verifyDeletes(t, directory, writer, numDeleteDocs-2, map[search.Query]int{
+		search.NewTermQuery(index.NewTerm("id", "3")):        0,
+		search.NewTermQuery(index.NewTerm("id", "5")):        1,
+		search.NewTermQuery(index.NewTerm("body", "common")): numDeleteDocs - 2,
+	})
+}
+
+func TestDeleteDocumentsByQuery(t *testing.T) {
+	directory, writer := openDeleteTestWriter(t, ".gltest_delete")
+	defer os.RemoveAll(".gltest_delete")
+	defer directory.Close()
+
+	// flush the added docs first, so the query is resolved against an
+	// existing segment
+	err := writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	err = writer.DeleteDocumentsByQuery(search.NewQueryWrapperFilter(search.NewTermQuery(index.NewTerm("body", "x1"))))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	remaining := numDeleteDocs - countDocs(numDeleteDocs, func(i int) bool { return i%3 == 1 })
+	verifyDeletes(t, directory, writer, remaining, map[search.Query]int{
+		search.NewTermQuery(index.NewTerm("body", "x1")):     0,
+		search.NewTermQuery(index.NewTerm("body", "x2")):     countDocs(numDeleteDocs, func(i int) bool { return i%3 == 2 }),
+		search.NewTermQuery(index.NewTerm("body", "common")): remaining,
+	})
+}
+
+func TestUpdateDocument(t *testing.T) {
+	directory, writer := openDeleteTestWriter(t, ".gltest_delete")
+	defer os.RemoveAll(".gltest_delete")
+	defer directory.Close()
+
+	err := writer.UpdateDocument(index.NewTerm("id", "7"), deleteTestDoc(7, "updated").Fields(),
+		std.NewStandardAnalyzer())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	verifyDeletes(t, directory, writer, numDeleteDocs, map[search.Query]int{
+		search.NewTermQuery(index.NewTerm("id", "7")):         1,
+		search.NewTermQuery(index.NewTerm("body", "updated")): 1,
+		search.NewTermQuery(index.NewTerm("body", "common")):  numDeleteDocs - 1,
+	})
+}
+
+func TestDeleteAll(t *testing.T) {
+	directory, writer := openDeleteTestWriter(t, ".gltest_delete")
+	defer os.RemoveAll(".gltest_delete")
+	defer directory.Close()
+
+	err := writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	err = writer.DeleteAll()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	// the writer is still usable afterwards
+	err = writer.AddDocument(deleteTestDoc(0, "again").Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	verifyDeletes(t, directory, writer, 1, map[search.Query]int{
+		search.NewTermQuery(index.NewTerm("body", "again")):  1,
+		search.NewTermQuery(index.NewTerm("body", "common")): 0,
+	})
+}
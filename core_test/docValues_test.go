@@ -0,0 +1,104 @@
+package core_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/codec/spi"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+const numDocValuesDocs = 50
+
+func docValuesTestDoc(i int) *docu.Document {
+	d := docu.NewDocument()
+	d.Add(docu.NewFieldFromString("id", fmt.Sprintf("%v", i), docu.STRING_FIELD_TYPE_STORED))
+	if i%5 != 0 { // leave some documents without values
+		d.Add(docu.NewNumericDocValuesField("num", int64(i*1000-7)))
+		d.Add(docu.NewBinaryDocValuesField("bin", []byte(fmt.Sprintf("binary-%v", i))))
+		d.Add(docu.NewSortedDocValuesField("sorted", []byte(fmt.Sprintf("term%02d", i%17))))
+	}
+	if i%3 != 0 {
+		d.Add(docu.NewSortedSetDocValuesField("set", []byte(fmt.Sprintf("tag%v", i%7))))
+		d.Add(docu.NewSortedSetDocValuesField("set", []byte("common")))
+		// duplicates within a document are collapsed
+		d.Add(docu.NewSortedSetDocValuesField("set", []byte("common")))
+	}
+	return d
+}
+
+func TestDocValues(t *testing.T) {
+	directory, reader := openTestIndex(t, ".gltest_docvalues", nil, func(writer *index.IndexWriter) {
+		for i := 0; i < numDocValuesDocs; i++ {
+			addTestDoc(t, writer, docValuesTestDoc(i))
+		}
+	})
+	defer os.RemoveAll(".gltest_docvalues")
+	defer directory.Close()
+	defer reader.Close()
+
+	leaves := reader.Leaves()
+	It(t).Should("expect 1 segment, but got %v", len(leaves)).Assert(len(leaves) == 1)
+	ar := leaves[0].Reader().(index.AtomicReader)
+
+	num, err := ar.NumericDocValues("num")
+	It(t).Should("has no error: %v", err).Assert(err == nil && num != nil)
+	bin, err := ar.BinaryDocValues("bin")
+	It(t).Should("has no error: %v", err).Assert(err == nil && bin != nil)
+	sorted, err := ar.SortedDocValues("sorted")
+	It(t).Should("has no error: %v", err).Assert(err == nil && sorted != nil)
+	set, err := ar.SortedSetDocValues("set")
+	It(t).Should("has no error: %v", err).Assert(err == nil && set != nil)
+	numDocs, err := ar.DocsWithField("num")
+	It(t).Should("has no error: %v", err).Assert(err == nil && numDocs != nil)
+
+	// a field with a different doc values type is not returned
+	wrong, err := ar.NumericDocValues("bin")
+	It(t).Should("has no numeric values for 'bin'").Assert(err == nil && wrong == nil)
+
+	It(t).Should("expect 17 sorted values, but got %v", sorted.ValueCount()).
+		Verify(sorted.ValueCount() == 17)
+	It(t).Should("expect 8 sorted set values, but got %v", set.ValueCount()).
+		Verify(set.ValueCount() == 8)
+
+	for i := 0; i < numDocValuesDocs; i++ {
+		if i%5 != 0 {
+			It(t).Should("expect value for doc %v", i).Verify(numDocs.At(i))
+			It(t).Should("expect %v for doc %v, but got %v", i*1000-7, i, num(i)).
+				Verify(num(i) == int64(i*1000-7))
+			expected := fmt.Sprintf("binary-%v", i)
+			It(t).Should("expect '%v' for doc %v, but got '%v'", expected, i, string(bin.Get(i))).
+				Verify(string(bin.Get(i)) == expected)
+			expected = fmt.Sprintf("term%02d", i%17)
+			It(t).Should("expect ord %v for doc %v, but got %v", i%17, i, sorted.Ord(i)).
+				Verify(sorted.Ord(i) == i%17)
+			It(t).Should("expect '%v' for doc %v, but got '%v'", expected, i, string(sorted.Get(i))).
+				Verify(string(sorted.Get(i)) == expected)
+		} else {
+			It(t).Should("expect no value for doc %v", i).Verify(!numDocs.At(i))
+			It(t).Should("expect 0 for doc %v, but got %v", i, num(i)).Verify(num(i) == 0)
+			It(t).Should("expect empty binary for doc %v", i).Verify(len(bin.Get(i)) == 0)
+			It(t).Should("expect ord -1 for doc %v, but got %v", i, sorted.Ord(i)).
+				Verify(sorted.Ord(i) == -1)
+		}
+
+		var values [][]byte
+		set.SetDocument(i)
+		for ord := set.NextOrd(); ord != spi.NO_MORE_ORDS; ord = set.NextOrd() {
+			values = append(values, append([]byte(nil), set.LookupOrd(ord)...))
+		}
+		if i%3 != 0 {
+			It(t).Should("expect 2 values for doc %v, but got %v", i, len(values)).
+				Assert(len(values) == 2)
+			It(t).Should("expect sorted values for doc %v, but got %q", i, values).
+				Verify(string(values[0]) == "common" &&
+					bytes.Equal(values[1], []byte(fmt.Sprintf("tag%v", i%7))))
+		} else {
+			It(t).Should("expect no values for doc %v, but got %q", i, values).
+				Verify(len(values) == 0)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package core_test
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/queries"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+/* Counts how many times the wrapped filter is asked for a DocIdSet. */
+type countingFilter struct {
+	search.Filter
+	count int32
+}
+
+func (f *countingFilter) DocIdSet(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (search.DocIdSet, error) {
+
+	atomic.AddInt32(&f.count, 1)
+	return f.Filter.DocIdSet(context, acceptDocs)
+}
+
+func (f *countingFilter) String() string {
+	return fmt.Sprintf("%v", f.Filter)
+}
+
+func TestFilteredQuery(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_filter")
+	defer os.RemoveAll(".gltest_filter")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	disjunction := search.NewBooleanQuery()
+	disjunction.Add(bodyTerm("w3"), search.SHOULD)
+	disjunction.Add(bodyTerm("w4"), search.SHOULD)
+
+	// QueryWrapperFilter has no random access bits, while the others
+	// are backed by FixedBitSets
+	filters := []search.Filter{
+		search.NewQueryWrapperFilter(bodyTerm("x1")),
+		queries.NewTermsFilter(index.NewTerm("body", "x1")),
+		search.NewCachingWrapperFilter(search.NewQueryWrapperFilter(bodyTerm("x1"))),
+	}
+	strategies := []search.FilterStrategy{
+		search.RANDOM_ACCESS_FILTER_STRATEGY,
+		search.LEAP_FROG_FILTER_FIRST_STRATEGY,
+		search.LEAP_FROG_QUERY_FIRST_STRATEGY,
+		search.QUERY_FIRST_FILTER_STRATEGY,
+	}
+	tests := []struct {
+		q        search.Query
+		expected int
+	}{
+		{bodyTerm("common"), countDocs(numBooleanDocs, func(i int) bool { return i%3 == 1 })},
+		{bodyTerm("w3"), countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 && i%3 == 1 })},
+		{disjunction, countDocs(numBooleanDocs, func(i int) bool { return (i%7 == 3 || i%7 == 4) && i%3 == 1 })},
+		{bodyTerm("x2"), 0},
+	}
+
+	for _, filter := range filters {
+		for _, strategy := range strategies {
+			for _, test := range tests {
+				q := search.NewFilteredQueryWithStrategy(test.q, filter, strategy)
+				verifyBooleanHits(t, searcher, q, test.expected)
+			}
+		}
+	}
+}
+
+func TestFilteredQueryExplain(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_filter")
+	defer os.RemoveAll(".gltest_filter")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	q := search.NewFilteredQuery(bodyTerm("common"), search.NewQueryWrapperFilter(bodyTerm("x1")))
+	It(t).Should("unexpected string: %v", q).
+		Verify(q.ToString("body") == "filtered(common)->QueryWrapperFilter(body:x1)")
+
+	explain, err := searcher.Explain(q, 1)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("doc 1 should match '%v'", q).Verify(explain.IsMatch())
+
+	explain, err = searcher.Explain(q, 2)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("doc 2 should not match '%v'", q).Verify(!explain.IsMatch())
+}
+
+func TestSearchWithFilter(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_filter")
+	defer os.RemoveAll(".gltest_filter")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		filter   search.Filter
+		expected int
+	}{
+		{nil, countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 })},
+		{queries.NewTermsFilter(index.NewTerm("body", "x1"), index.NewTerm("body", "x2")),
+			countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 && i%3 != 0 })},
+		{queries.NewTermsFilterForField("body", []byte("nosuchterm")), 0},
+		{queries.NewTermsFilter(index.NewTerm("nosuchfield", "x1")), 0},
+	}
+
+	for _, test := range tests {
+		res, err := searcher.Search(bodyTerm("w3"), test.filter, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect %v hits with filter '%v', but got %v", test.expected, test.filter, res.TotalHits).
+			Verify(res.TotalHits == test.expected)
+	}
+}
+
+func TestTermsFilterToString(t *testing.T) {
+	f := queries.NewTermsFilter(
+		index.NewTerm("body", "x2"),
+		index.NewTerm("title", "a"),
+		index.NewTerm("body", "x1"),
+		index.NewTerm("body", "x2"))
+	It(t).Should("unexpected string: %v", f).Verify(f.String() == "body:x1 body:x2 title:a")
+}
+
+func TestCachingWrapperFilter(t *testing.T) {
+	directory, reader := openBooleanTestIndex(t, ".gltest_filter")
+	defer os.RemoveAll(".gltest_filter")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	inner := &countingFilter{Filter: search.NewQueryWrapperFilter(bodyTerm("x1"))}
+	filter := search.NewCachingWrapperFilter(inner)
+	expected := countDocs(numBooleanDocs, func(i int) bool { return i%7 == 3 && i%3 == 1 })
+	leaves := len(reader.Leaves())
+
+	for i := 0; i < 3; i++ {
+		res, err := searcher.Search(bodyTerm("w3"), filter, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect %v hits, but got %v", expected, res.TotalHits).Verify(res.TotalHits == expected)
+		It(t).Should("inner filter should be called once per segment (%v vs %v)", inner.count, leaves).
+			Verify(int(inner.count) == leaves)
+	}
+
+	// a filter matching nothing is cached as well
+	inner = &countingFilter{Filter: search.NewQueryWrapperFilter(bodyTerm("nosuchterm"))}
+	filter = search.NewCachingWrapperFilter(inner)
+	for i := 0; i < 2; i++ {
+		res, err := searcher.Search(bodyTerm("w3"), filter, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect no hits, but got %v", res.TotalHits).Verify(res.TotalHits == 0)
+		It(t).Should("inner filter should be called once per segment (%v vs %v)", inner.count, leaves).
+			Verify(int(inner.count) == leaves)
+	}
+}
+
+func TestCachingWrapperFilterConcurrently(t *testing.T) {
+	directory, reader := openSegmentedTestIndex(t, ".gltest_filter_concurrent", 90, 10)
+	defer os.RemoveAll(".gltest_filter_concurrent")
+	defer directory.Close()
+	defer reader.Close()
+
+	expected, err := search.NewIndexSearcher(reader).Search(bodyQuery("common"),
+		search.NewQueryWrapperFilter(bodyQuery("m2")), 100)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	inner := &countingFilter{Filter: search.NewQueryWrapperFilter(bodyQuery("m2"))}
+	filter := search.NewCachingWrapperFilter(inner)
+	searcher := search.NewIndexSearcherWithExecutor(reader, 3)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				res, err := searcher.Search(bodyQuery("common"), filter, 100)
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				verifySameHits(t, bodyQuery("common"), expected, res)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every segment is cached by now
+	called := atomic.LoadInt32(&inner.count)
+	It(t).Should("expect at least %v calls, but got %v", len(reader.Leaves()), called).
+		Verify(int(called) >= len(reader.Leaves()))
+	res, err := searcher.Search(bodyQuery("common"), filter, 100)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	verifySameHits(t, bodyQuery("common"), expected, res)
+	It(t).Should("inner filter should not be called once cached (%v vs %v)", inner.count, called).
+		Verify(atomic.LoadInt32(&inner.count) == called)
+}
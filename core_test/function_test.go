@@ -0,0 +1,169 @@
+package core_test
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/queries/function"
+	. "github.com/balzaczyy/gounit"
+	"math"
+	"os"
+	"testing"
+)
+
+/* Shifts the "int" field of openSortTestIndex() to positive values. */
+func positiveIntSource() function.ValueSource {
+	return function.NewLinearFloatFunction(function.NewIntFieldSource("int"), 1, 21)
+}
+
+func TestFunctionQuery(t *testing.T) {
+	directory, reader := openSortTestIndex(t, ".gltest_function")
+	defer os.RemoveAll(".gltest_function")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	intVal := func(i int) float64 { return float64(sortTestDoc(i).i) + 21 }
+	tf := func(i int) float64 { return float64(i%4 + 1) }
+	termFreq := function.NewTermFreqValueSource("body", "common", "body", []byte("common"))
+
+	tests := []struct {
+		vs       function.ValueSource
+		str      string
+		expected func(i int) float64
+	}{
+		{positiveIntSource(), "1*float(int(int))+21", intVal},
+		{termFreq, "termfreq(body,common)", tf},
+		{function.NewNormValueSource("body"), "norm(body)",
+			func(i int) float64 { return 1 / math.Sqrt(decodedLength(i%4+2)) }},
+		{function.NewSumFloatFunction(termFreq, function.NewConstValueSource(0.5)),
+			"sum(termfreq(body,common),const(0.5))", func(i int) float64 { return tf(i) + 0.5 }},
+		{function.NewProductFloatFunction(termFreq, positiveIntSource()),
+			"product(termfreq(body,common),1*float(int(int))+21)",
+			func(i int) float64 { return tf(i) * intVal(i) }},
+		{function.NewMaxFloatFunction(termFreq, function.NewConstValueSource(2)),
+			"max(termfreq(body,common),const(2))", func(i int) float64 { return math.Max(tf(i), 2) }},
+		{function.NewReciprocalFloatFunction(termFreq, 1, 1, 1), "1/(1*float(termfreq(body,common))+1)",
+			func(i int) float64 { return 1 / (tf(i) + 1) }},
+		{function.NewLogFloatFunction(positiveIntSource()), "log(1*float(int(int))+21)",
+			func(i int) float64 { return math.Log10(intVal(i)) }},
+		// int values range from -20 to 19, spread over both segments
+		{function.NewScaleFloatFunction(function.NewIntFieldSource("int"), 1, 2), "scale(int(int),1,2)",
+			func(i int) float64 { return (float64(sortTestDoc(i).i)+20)/39 + 1 }},
+	}
+	for _, test := range tests {
+		q := function.NewFunctionQuery(test.vs)
+		It(t).Should("expect '%v', but got '%v'", test.str, q).Verify(q.ToString("") == test.str)
+		verifyBooleanHits(t, searcher, q, numSortDocs)
+
+		res, err := searcher.SearchTop(q, numSortDocs)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		for _, hit := range res.ScoreDocs {
+			expected := float32(test.expected(hit.Doc))
+			It(t).Should("expect %v for doc %v of '%v', but got %v", expected, hit.Doc, q, hit.Score).
+				Verify(isSimilar(hit.Score, expected, 0.0001))
+		}
+	}
+
+	// norms can't be decoded without a TFIDFSimilarity
+	searcher.SetSimilarity(search.NewBM25Similarity())
+	_, err := searcher.Explain(function.NewFunctionQuery(function.NewNormValueSource("body")), 0)
+	It(t).Should("expect an error for norms of BM25Similarity").Verify(err != nil)
+}
+
+/* Combines the scores by addition rather than multiplication. */
+type sumScoreQuery struct {
+	*function.CustomScoreQuery
+}
+
+func (q *sumScoreQuery) CustomScoreProvider(context *index.AtomicReaderContext) (function.CustomScoreProvider, error) {
+	return &sumScoreProvider{function.NewDefaultCustomScoreProvider(context)}, nil
+}
+
+type sumScoreProvider struct {
+	*function.DefaultCustomScoreProvider
+}
+
+func (p *sumScoreProvider) CustomScore(doc int, subQueryScore float32, valSrcScores []float32) (float32, error) {
+	for _, score := range valSrcScores {
+		subQueryScore += score
+	}
+	return subQueryScore, nil
+}
+
+func (p *sumScoreProvider) CustomExplain(doc int, subQueryExpl search.Explanation,
+	valSrcExpls []search.Explanation) (search.Explanation, error) {
+
+	value := subQueryExpl.Value()
+	for _, expl := range valSrcExpls {
+		value += expl.Value()
+	}
+	exp := search.NewExplanation(value, "custom score: sum of:")
+	exp.AddDetail(subQueryExpl)
+	for _, expl := range valSrcExpls {
+		exp.AddDetail(expl)
+	}
+	return exp, nil
+}
+
+func TestBoostedAndCustomScoreQueries(t *testing.T) {
+	directory, reader := openSortTestIndex(t, ".gltest_function")
+	defer os.RemoveAll(".gltest_function")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	res, err := searcher.SearchTop(bodyQuery("even"), numSortDocs)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	relevance := make(map[int]float32)
+	for _, hit := range res.ScoreDocs {
+		relevance[hit.Doc] = hit.Score
+	}
+	popularity := func(i int) float32 { return float32(sortTestDoc(i).i) + 21 }
+
+	boosted := function.NewBoostedQuery(bodyQuery("even"), positiveIntSource())
+	strict := function.NewCustomScoreQuery(bodyQuery("even"), function.NewFunctionQuery(positiveIntSource()))
+	strict.SetStrict(true)
+	sum := new(sumScoreQuery)
+	sum.CustomScoreQuery = function.NewCustomScoreQueryWithSPI(sum, bodyQuery("even"),
+		function.NewFunctionQuery(positiveIntSource()))
+	sum.SetStrict(true)
+
+	tests := []struct {
+		q        search.Query
+		str      string
+		expected func(i int) float32
+	}{
+		{boosted, "boost(body:even,1*float(int(int))+21)",
+			func(i int) float32 { return relevance[i] * popularity(i) }},
+		{strict, "custom(body:even, 1*float(int(int))+21) STRICT",
+			func(i int) float32 { return relevance[i] * popularity(i) }},
+		{sum, "custom(body:even, 1*float(int(int))+21) STRICT",
+			func(i int) float32 { return relevance[i] + popularity(i) }},
+	}
+	for _, test := range tests {
+		It(t).Should("expect '%v', but got '%v'", test.str, test.q).Verify(test.q.ToString("") == test.str)
+		verifyBooleanHits(t, searcher, test.q, numSortDocs/2)
+
+		res, err := searcher.SearchTop(test.q, numSortDocs)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect %v hits, but got %v", numSortDocs/2, res.TotalHits).Verify(res.TotalHits == numSortDocs/2)
+		for _, hit := range res.ScoreDocs {
+			expected := test.expected(hit.Doc)
+			It(t).Should("expect %v for doc %v of '%v', but got %v", expected, hit.Doc, test.q, hit.Score).
+				Verify(isSimilar(hit.Score, expected, 0.0001))
+		}
+
+		// boosting scales the scores and explanations alike
+		test.q.SetBoost(2)
+		verifyBooleanHits(t, searcher, test.q, numSortDocs/2)
+	}
+
+	// without function queries the sub query scores are kept
+	verifyBooleanHits(t, searcher, function.NewCustomScoreQuery(bodyQuery("odd")), numSortDocs/2)
+
+	// function queries match all documents within boolean queries
+	bq := search.NewBooleanQuery()
+	bq.Add(boosted, search.SHOULD)
+	bq.Add(function.NewFunctionQuery(function.NewConstValueSource(1)), search.SHOULD)
+	verifyBooleanHits(t, searcher, bq, numSortDocs)
+}
@@ -0,0 +1,114 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+var fuzzyWords = []string{"aaaaa", "aaaab", "aaabb", "aabbb", "abbbb", "bbbbb", "ddddd", "abcde", "abdce"}
+
+/* Writes one document per word of fuzzyWords into its "field" field. */
+func openFuzzyTestIndex(t *testing.T, path string) (store.Directory, index.IndexReader) {
+	return openTestIndex(t, path, nil, func(writer *index.IndexWriter) {
+		for _, word := range fuzzyWords {
+			d := docu.NewDocument()
+			d.Add(docu.NewTextFieldFromString("field", word, docu.STORE_YES))
+			addTestDoc(t, writer, d)
+		}
+	})
+}
+
+func TestFuzzyQuery(t *testing.T) {
+	directory, reader := openFuzzyTestIndex(t, ".gltest_fuzzy")
+	defer os.RemoveAll(".gltest_fuzzy")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		text           string
+		maxEdits       int
+		prefixLength   int
+		transpositions bool
+		expected       []int // docs, in the order of their scores
+	}{
+		{"aaaaa", 0, 0, true, []int{0}},
+		{"aaaaa", 1, 0, true, []int{0, 1}},
+		{"aaaaa", 2, 0, true, []int{0, 1, 2}},
+		{"bbbbb", 2, 0, true, []int{5, 4, 3}},
+		{"aaaaa", 2, 4, true, []int{0, 1}},
+		{"aaaaa", 2, 5, true, []int{0}},
+		{"aaaaa", 2, 6, true, []int{0}},
+		{"xxxxx", 2, 0, true, nil},
+		{"aaaac", 1, 0, true, []int{0, 1}},
+		{"abcde", 1, 0, true, []int{7, 8}},
+		{"abcde", 1, 0, false, []int{7}},
+		{"abcde", 2, 0, false, []int{7, 8}},
+	}
+	for _, test := range tests {
+		q := search.NewFuzzyQueryWithOptions(index.NewTerm("field", test.text),
+			test.maxEdits, test.prefixLength, search.FUZZY_DEFAULT_MAX_EXPANSIONS, test.transpositions)
+		verifyBooleanHits(t, searcher, q, len(test.expected))
+
+		res, err := searcher.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		for i, hit := range res.ScoreDocs {
+			It(t).Should("expect doc %v at %v for '%v', but got %v", test.expected[i], i, q, hit.Doc).
+				Verify(hit.Doc == test.expected[i])
+		}
+	}
+}
+
+func TestFuzzyQueryMaxExpansions(t *testing.T) {
+	directory, reader := openFuzzyTestIndex(t, ".gltest_fuzzy")
+	defer os.RemoveAll(".gltest_fuzzy")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// only the closest terms are kept
+	q := search.NewFuzzyQueryWithOptions(index.NewTerm("field", "aaaaa"), 2, 0, 2, true)
+	res, err := searcher.SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 2 hits, but got %v", res.TotalHits).Assert(res.TotalHits == 2)
+	It(t).Should("expect doc 0 first, but got %v", res.ScoreDocs[0].Doc).Verify(res.ScoreDocs[0].Doc == 0)
+	It(t).Should("expect doc 1 second, but got %v", res.ScoreDocs[1].Doc).Verify(res.ScoreDocs[1].Doc == 1)
+}
+
+func TestFuzzyQueryParser(t *testing.T) {
+	tests := []struct {
+		text     string
+		maxEdits int
+		str      string
+	}{
+		{"aaaaa~1", 1, "field:aaaaa~1"},
+		{"aaaaa~", 2, "field:aaaaa~2"},
+		{"BBBBB~2", 2, "field:bbbbb~2"},
+		{"aaaaa~0", 0, "field:aaaaa~0"},
+		{"aaaaa~0.5", 2, "field:aaaaa~2"},
+	}
+	for _, test := range tests {
+		parser := classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+		q, err := parser.Parse(test.text)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		fq, ok := q.(*search.FuzzyQuery)
+		It(t).Should("expect a fuzzy query for '%v', but got %v", test.text, q).Assert(ok)
+		It(t).Should("expect %v edits, but got %v", test.maxEdits, fq.MaxEdits()).
+			Verify(fq.MaxEdits() == test.maxEdits)
+		It(t).Should("expect '%v', but got '%v'", test.str, fq).
+			Verify(fmt.Sprintf("%v", fq) == test.str)
+	}
+
+	parser := classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+	_, err := parser.Parse("aaaaa~1.5")
+	It(t).Should("expect fractional edit distances to be rejected").Verify(err != nil)
+}
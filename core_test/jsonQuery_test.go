@@ -0,0 +1,197 @@
+package core_test
+
+import (
+	"encoding/json"
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/queryparser/jsonquery"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+func TestJSONQueryParse(t *testing.T) {
+	tests := []struct {
+		text string
+		str  string
+	}{
+		{`{"term": {"title": "Apple"}}`, "title:Apple"},
+		{`{"term": {"title": {"value": "apple", "boost": 2}}}`, "title:apple^2"},
+		{`{"terms": {"title": ["apple", "banana"]}}`, "title:apple title:banana"},
+		{`{"terms": {"title": ["apple"], "boost": 0.5}}`, "(title:apple)^0.5"},
+		{`{"match": {"body": "Red apples, the BEST"}}`, "body:red body:apples body:best"},
+		{`{"match": {"body": {"query": "red apples", "operator": "and"}}}`, "+body:red +body:apples"},
+		{`{"match": {"body": {"query": "Apple", "boost": 3}}}`, "body:apple^3"},
+		{`{"match": {"body": "the"}}`, ""},
+		{`{"match_all": {}}`, "*:*"},
+		{`{"match_all": {"boost": 2}}`, "*:*^2"},
+		{`{"bool": {}}`, ""},
+		{`{"bool": {
+			"must": {"term": {"title": "apple"}},
+			"should": [{"term": {"title": "banana"}}, {"match": {"body": "cherry"}}],
+			"must_not": [{"term": {"title": "date"}}]
+		}}`, "+title:apple title:banana body:cherry -title:date"},
+		{`{"bool": {"should": [{"term": {"a": "x"}}, {"term": {"a": "y"}}], "minimum_should_match": 1,
+			"boost": 2}}`, "(a:x a:y)~1^2"},
+		{`{"bool": {"must": {"bool": {"should": [{"term": {"a": "x"}}, {"term": {"a": "y"}}]}}}}`,
+			"+(a:x a:y)"},
+	}
+	parser := jsonquery.NewParser(std.NewStandardAnalyzer())
+	for _, test := range tests {
+		q, err := parser.Parse([]byte(test.text))
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		It(t).Should("expect '%v' for '%v', but got '%v'", test.str, test.text, q).
+			Verify(fmt.Sprintf("%v", q) == test.str)
+	}
+}
+
+func TestJSONQueryErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		path string
+	}{
+		{`{"term": `, "$"},
+		{`[]`, "$"},
+		{`{}`, "$"},
+		{`{"term": {"a": "x"}, "match_all": {}}`, "$"},
+		{`{"fuzzy": {"a": "x"}}`, "$"},
+		{`{"term": "x"}`, "$.term"},
+		{`{"term": {}}`, "$.term"},
+		{`{"term": {"a": "x", "b": "y"}}`, "$.term"},
+		{`{"term": {"a": 1}}`, "$.term.a.value"},
+		{`{"term": {"a": null}}`, "$.term.a.value"},
+		{`{"term": {"a": {"boost": 2}}}`, "$.term.a"},
+		{`{"term": {"a": {"value": "x", "boost": "high"}}}`, "$.term.a.boost"},
+		{`{"term": {"a": {"value": "x", "slop": 2}}}`, "$.term.a"},
+		{`{"term": {"my field": {"value": 2}}}`, `$.term["my field"].value`},
+		{`{"terms": {"a": "x"}}`, "$.terms.a"},
+		{`{"terms": {"a": ["x", 2]}}`, "$.terms.a[1]"},
+		{`{"terms": {"a": ["x"], "b": ["y"]}}`, "$.terms"},
+		{`{"terms": {"boost": 2}}`, "$.terms"},
+		{`{"match": {"a": {"query": "x", "operator": "xor"}}}`, "$.match.a.operator"},
+		{`{"match_all": {"a": 1}}`, "$.match_all"},
+		{`{"bool": {"filter": []}}`, "$.bool"},
+		{`{"bool": {"must": [{"term": {"a": "x"}}, {"trem": {"a": "x"}}]}}`, "$.bool.must[1]"},
+		{`{"bool": {"should": {"bool": {"must_not": [1]}}}}`, "$.bool.should.bool.must_not[0]"},
+		{`{"bool": {"must": [], "minimum_should_match": 1.5}}`, "$.bool.minimum_should_match"},
+		{`{"bool": {"disable_coord": "yes"}}`, "$.bool.disable_coord"},
+	}
+	parser := jsonquery.NewParser(std.NewStandardAnalyzer())
+	for _, test := range tests {
+		q, err := parser.Parse([]byte(test.text))
+		It(t).Should("expect an error for '%v', but got '%v'", test.text, q).Assert(err != nil)
+		pe, ok := err.(*jsonquery.ParseError)
+		It(t).Should("expect a ParseError for '%v', but got %T", test.text, err).Assert(ok)
+		It(t).Should("expect path '%v' for '%v', but got '%v'", test.path, test.text, pe).
+			Verify(pe.Path == test.path)
+	}
+}
+
+func TestJSONQueryMarshal(t *testing.T) {
+	tests := []string{
+		`{"term":{"title":"apple"}}`,
+		`{"term":{"title":{"boost":2,"value":"apple"}}}`,
+		`{"match_all":{"boost":0.5}}`,
+		`{"bool":{"disable_coord":true,"should":[{"term":{"title":"apple"}},{"term":{"title":"banana"}}]}}`,
+		`{"bool":{"boost":2,"minimum_should_match":1,"must":[{"match_all":{}}],` +
+			`"must_not":[{"term":{"a":"x"}}],"should":[{"bool":{"must":[{"term":{"a":"y"}}]}}]}}`,
+	}
+	parser := jsonquery.NewParser(std.NewStandardAnalyzer())
+	for _, text := range tests {
+		q, err := parser.Parse([]byte(text))
+		It(t).Should("has no error for '%v': %v", text, err).Assert(err == nil)
+		data, err := parser.Marshal(q)
+		It(t).Should("has no error for '%v': %v", text, err).Assert(err == nil)
+		It(t).Should("expect '%v', but got '%s'", text, data).Verify(string(data) == text)
+	}
+
+	// queries built by other means are written in their canonical form
+	q, err := parser.Parse([]byte(`{"terms": {"title": ["apple", "banana"]}}`))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	data, err := parser.Marshal(q)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	q2, err := parser.Parse(data)
+	It(t).Should("has no error for '%s': %v", data, err).Assert(err == nil)
+	It(t).Should("expect the same query, but got '%v' and '%v'", q, q2).
+		Verify(q.ToString("") == q2.ToString(""))
+
+	_, err = parser.Marshal(search.NewPrefixQuery(index.NewTerm("title", "app")))
+	It(t).Should("expect an error for unregistered queries").Verify(err != nil)
+	_, err = parser.Marshal(search.NewTermQuery(index.NewTermFromBytes("id", []byte{0x20, 0xff})))
+	It(t).Should("expect an error for binary terms").Verify(err != nil)
+}
+
+func TestJSONQueryRegistry(t *testing.T) {
+	parser := jsonquery.NewParser(std.NewStandardAnalyzer())
+	parser.AddBuilder("prefix", jsonquery.QueryBuilderFunc(
+		func(p *jsonquery.Parser, path string, body json.RawMessage) (search.Query, error) {
+			obj, err := jsonquery.DecodeObject(path, body)
+			if err != nil {
+				return nil, err
+			}
+			fields, err := jsonquery.CheckKeys(path, obj, "key", "fruit")
+			if err != nil {
+				return nil, err
+			}
+			bq := search.NewBooleanQueryDisableCoord(true)
+			for _, field := range fields {
+				var prefix string
+				if err = jsonquery.Decode(jsonquery.FieldPath(path, field), obj[field], &prefix); err != nil {
+					return nil, err
+				}
+				bq.Add(search.NewPrefixQuery(index.NewTerm(field, prefix)), search.SHOULD)
+			}
+			return bq, nil
+		}))
+	parser.AddMarshaler(&search.PrefixQuery{}, jsonquery.QueryMarshalerFunc(
+		func(p *jsonquery.Parser, q search.Query) (interface{}, error) {
+			prefix := q.(*search.PrefixQuery).Prefix()
+			return map[string]interface{}{
+				"prefix": map[string]string{prefix.Field: string(prefix.Bytes)},
+			}, nil
+		}))
+
+	q, err := parser.Parse([]byte(`{"bool": {"must": {"prefix": {"fruit": "ap"}}}}`))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect '+(fruit:ap*)', but got '%v'", q).Verify(q.ToString("") == "+(fruit:ap*)")
+
+	data, err := parser.Marshal(q)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	expected := `{"bool":{"must":[{"bool":{"disable_coord":true,"should":[{"prefix":{"fruit":"ap"}}]}}]}}`
+	It(t).Should("expect '%v', but got '%s'", expected, data).Verify(string(data) == expected)
+
+	_, err = parser.Parse([]byte(`{"bool": {"must": {"prefix": {"fruit": 1}}}}`))
+	pe, ok := err.(*jsonquery.ParseError)
+	It(t).Should("expect a ParseError, but got %v", err).Assert(ok)
+	It(t).Should("expect path '$.bool.must.prefix.fruit', but got '%v'", pe.Path).
+		Verify(pe.Path == "$.bool.must.prefix.fruit")
+
+	directory, reader := openMultiTermTestIndex(t, ".gltest_jsonquery")
+	defer os.RemoveAll(".gltest_jsonquery")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	fruits := func(match func(fruit string) bool) int {
+		return countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return match(fruit) }))
+	}
+	searches := []struct {
+		text     string
+		expected int
+	}{
+		{`{"match_all": {}}`, numMultiTermDocs},
+		{`{"match": {"fruit": "Apple, or BANANA"}}`, fruits(func(f string) bool { return f != "apricot" })},
+		{`{"terms": {"key": ["k0000", "k0001", "k0002", "k9999"]}}`, 3},
+		{`{"bool": {"must": {"prefix": {"fruit": "ap"}}, "must_not": {"term": {"fruit": "apricot"}}}}`,
+			fruits(func(f string) bool { return f == "apple" })},
+		{`{"bool": {"should": [{"term": {"key": "k0003"}}, {"term": {"fruit": "apple"}},
+			{"term": {"fruit": "banana"}}], "minimum_should_match": 2}}`, 1},
+	}
+	for _, test := range searches {
+		q, err := parser.Parse([]byte(test.text))
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		verifyBooleanHits(t, searcher, q, test.expected)
+	}
+}
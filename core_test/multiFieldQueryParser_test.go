@@ -0,0 +1,174 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+func TestMultiFieldQueryParserSyntax(t *testing.T) {
+	fields := []string{"title", "body"}
+	tests := []struct {
+		text   string
+		or     string
+		and    string
+		disMax string
+	}{
+		{"apple", "title:apple body:apple", "title:apple body:apple",
+			"(title:apple | body:apple)~0.1"},
+		{"apple banana", "(title:apple body:apple) (title:banana body:banana)",
+			"+(title:apple body:apple) +(title:banana body:banana)",
+			"(title:apple | body:apple)~0.1 (title:banana | body:banana)~0.1"},
+		{"+apple -banana", "+(title:apple body:apple) -(title:banana body:banana)",
+			"+(title:apple body:apple) -(title:banana body:banana)",
+			"+(title:apple | body:apple)~0.1 -(title:banana | body:banana)~0.1"},
+		{"title:apple banana", "title:apple (title:banana body:banana)",
+			"+title:apple +(title:banana body:banana)",
+			"title:apple (title:banana | body:banana)~0.1"},
+		{`"apple banana"~2`, `title:"apple banana"~2 body:"apple banana"~2`,
+			`title:"apple banana"~2 body:"apple banana"~2`,
+			`(title:"apple banana"~2 | body:"apple banana"~2)~0.1`},
+		{"app*", "title:app* body:app*", "title:app* body:app*",
+			"(title:app* | body:app*)~0.1"},
+		{"a?ple", "title:a?ple body:a?ple", "title:a?ple body:a?ple",
+			"(title:a?ple | body:a?ple)~0.1"},
+		{"apple~1", "title:apple~1 body:apple~1", "title:apple~1 body:apple~1",
+			"(title:apple~1 | body:apple~1)~0.1"},
+		{"[a TO b]", "title:[a TO b] body:[a TO b]", "title:[a TO b] body:[a TO b]",
+			"(title:[a TO b] | body:[a TO b])~0.1"},
+		{"apple the", "title:apple body:apple", "title:apple body:apple",
+			"(title:apple | body:apple)~0.1"},
+	}
+	for _, test := range tests {
+		parser := classic.NewMultiFieldQueryParser(util.VERSION_LATEST, fields, std.NewStandardAnalyzer())
+		q, err := parser.Parse(test.text)
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		It(t).Should("expect '%v' for '%v', but got '%v'", test.or, test.text, q).
+			Verify(fmt.Sprintf("%v", q) == test.or)
+
+		parser = classic.NewMultiFieldQueryParser(util.VERSION_LATEST, fields, std.NewStandardAnalyzer())
+		parser.SetDefaultOperator(classic.OP_AND)
+		q, err = parser.Parse(test.text)
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		It(t).Should("expect '%v' for '%v', but got '%v'", test.and, test.text, q).
+			Verify(fmt.Sprintf("%v", q) == test.and)
+
+		parser = classic.NewMultiFieldQueryParser(util.VERSION_LATEST, fields, std.NewStandardAnalyzer())
+		parser.SetUseDisjunctionMax(true)
+		parser.SetTieBreakerMultiplier(0.1)
+		q, err = parser.Parse(test.text)
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		It(t).Should("expect '%v' for '%v', but got '%v'", test.disMax, test.text, q).
+			Verify(fmt.Sprintf("%v", q) == test.disMax)
+	}
+
+	boosts := map[string]float32{"title": 5, "body": 10}
+	parser := classic.NewMultiFieldQueryParserWithBoosts(util.VERSION_LATEST, fields,
+		std.NewStandardAnalyzer(), boosts)
+	parser.SetDefaultOperator(classic.OP_AND)
+	q, err := parser.Parse("apple banana^2 app*")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	expected := "+(title:apple^5 body:apple^10) +((title:banana^5 body:banana^10)^2) +(title:app* body:app*)"
+	It(t).Should("expect '%v', but got '%v'", expected, q).Verify(fmt.Sprintf("%v", q) == expected)
+
+	parser = classic.NewMultiFieldQueryParser(util.VERSION_LATEST, fields, std.NewStandardAnalyzer())
+	q, err = parser.Parse("the")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect an empty query for stopwords, but got '%v'", q).Verify(fmt.Sprintf("%v", q) == "")
+
+	_, err = parser.Parse("*pple")
+	It(t).Should("expect leading wildcard to be rejected").Verify(err != nil)
+}
+
+func TestMultiFieldQueryParserSearch(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_multifield")
+	defer os.RemoveAll(".gltest_multifield")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	fields := []string{"key", "fruit"}
+	tests := []struct {
+		text string
+		or   int
+		and  int
+	}{
+		{"apple", countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return fruit == "apple" })),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return fruit == "apple" }))},
+		{"apple k0001", countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return fruit == "apple" || key == "k0001"
+		})), 0},
+		{"apple k0003", countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return fruit == "apple" || key == "k0003"
+		})), 1},
+		{"k001* banana", countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return fruit == "banana" || key[:4] == "k001"
+		})), countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return fruit == "banana" && key[:4] == "k001"
+		}))},
+		{"fruit:apple k00*", countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return fruit == "apple" || key[:3] == "k00"
+		})), countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return fruit == "apple" && key[:3] == "k00"
+		}))},
+	}
+	for _, test := range tests {
+		for _, useDisMax := range []bool{false, true} {
+			parser := classic.NewMultiFieldQueryParserWithBoosts(util.VERSION_LATEST, fields,
+				std.NewStandardAnalyzer(), map[string]float32{"key": 2})
+			parser.SetUseDisjunctionMax(useDisMax)
+			parser.SetTieBreakerMultiplier(0.5)
+			q, err := parser.Parse(test.text)
+			It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+			verifyBooleanHits(t, searcher, q, test.or)
+
+			parser.SetDefaultOperator(classic.OP_AND)
+			q, err = parser.Parse(test.text)
+			It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+			verifyBooleanHits(t, searcher, q, test.and)
+		}
+	}
+}
+
+func TestDisjunctionMaxQuery(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_dismax")
+	defer os.RemoveAll(".gltest_dismax")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	disjuncts := []search.Query{
+		search.NewTermQuery(index.NewTerm("fruit", "apple")),
+		search.NewTermQuery(index.NewTerm("key", "k0003")),
+		search.NewTermQuery(index.NewTerm("key", "k0004")),
+	}
+	expected := countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool { return fruit == "apple" })) + 1
+
+	// the score of doc 3 relative to doc 0, which only matches fruit:apple
+	var ratios []float32
+	for _, tie := range []float32{0, 0.5} {
+		q := search.NewDisjunctionMaxQuery(disjuncts, tie)
+		verifyBooleanHits(t, searcher, q, expected)
+
+		res, err := searcher.SearchTop(q, 1)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect doc 3 to match both disjuncts and rank first, but got %v",
+			res.ScoreDocs[0].Doc).Verify(res.ScoreDocs[0].Doc == 3)
+		explain, err := searcher.Explain(q, 0)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		ratios = append(ratios, res.ScoreDocs[0].Score/explain.Value())
+	}
+	It(t).Should("expect the tie breaker to raise the score (%v vs %v)", ratios[0], ratios[1]).
+		Verify(ratios[1] > ratios[0])
+
+	q := search.NewDisjunctionMaxQuery(disjuncts[:1], 0)
+	q.SetBoost(2)
+	It(t).Should("expect '(fruit:apple)^2', but got '%v'", q).Verify(q.ToString("") == "(fruit:apple)^2")
+	verifyBooleanHits(t, searcher, q, expected-1)
+}
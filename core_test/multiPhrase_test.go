@@ -0,0 +1,176 @@
+package core_test
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	. "github.com/balzaczyy/gounit"
+	"io"
+	"os"
+	"testing"
+)
+
+/* Injects "quick" at the same position after each "fast". */
+type synonymFilter struct {
+	*analysis.TokenFilter
+	input      analysis.TokenStream
+	termAtt    ta.CharTermAttribute
+	posIncrAtt ta.PositionIncrementAttribute
+	pending    []rune
+}
+
+func newSynonymFilter(in analysis.TokenStream) *synonymFilter {
+	ans := &synonymFilter{
+		TokenFilter: analysis.NewTokenFilter(in),
+		input:       in,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(ta.CharTermAttribute)
+	ans.posIncrAtt = ans.Attributes().Add("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+	return ans
+}
+
+func (f *synonymFilter) IncrementToken() (bool, error) {
+	if f.pending != nil {
+		f.termAtt.CopyBuffer(f.pending)
+		f.posIncrAtt.SetPositionIncrement(0)
+		f.pending = nil
+		return true, nil
+	}
+	ok, err := f.input.IncrementToken()
+	if ok && string(f.termAtt.Buffer()[:f.termAtt.Length()]) == "fast" {
+		f.pending = []rune("quick")
+	}
+	return ok, err
+}
+
+func (f *synonymFilter) Reset() error {
+	f.pending = nil
+	return f.TokenFilter.Reset()
+}
+
+type readerSetter func(io.RuneReader) error
+
+func (f readerSetter) SetReader(reader io.RuneReader) error {
+	return f(reader)
+}
+
+/* StandardAnalyzer followed by synonymFilter. */
+type synonymAnalyzer struct {
+	*analysis.AnalyzerImpl
+	delegate *std.StandardAnalyzer
+}
+
+func newSynonymAnalyzer() *synonymAnalyzer {
+	ans := &synonymAnalyzer{
+		AnalyzerImpl: analysis.NewAnalyzer(),
+		delegate:     std.NewStandardAnalyzer(),
+	}
+	ans.Spi = ans
+	return ans
+}
+
+func (a *synonymAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *analysis.TokenStreamComponents {
+	components := a.delegate.CreateComponents(fieldName, reader)
+	return analysis.NewTokenStreamComponents(readerSetter(components.SetReader),
+		newSynonymFilter(components.TokenStream()))
+}
+
+func bodyTerms(texts ...string) []*index.Term {
+	ans := make([]*index.Term, len(texts))
+	for i, text := range texts {
+		ans[i] = index.NewTerm("body", text)
+	}
+	return ans
+}
+
+func newBodyMultiPhrase(slop int, termArrays ...[]*index.Term) *search.MultiPhraseQuery {
+	q := search.NewMultiPhraseQuery()
+	for _, terms := range termArrays {
+		q.Add(terms...)
+	}
+	q.SetSlop(slop)
+	return q
+}
+
+func TestMultiPhraseQuery(t *testing.T) {
+	directory, reader := openPhraseTestIndex(t, ".gltest_multiphrase")
+	defer os.RemoveAll(".gltest_multiphrase")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		q        *search.MultiPhraseQuery
+		expected int
+	}{
+		// exact
+		{newBodyMultiPhrase(0, bodyTerms("quick"), bodyTerms("brown", "red")), countDocs(numPhraseDocs, isPhraseBody(0, 2, 3))},
+		{newBodyMultiPhrase(0, bodyTerms("quick", "brown"), bodyTerms("fox", "quick")), countDocs(numPhraseDocs, isPhraseBody(0, 1, 2, 3))},
+		{newBodyMultiPhrase(0, bodyTerms("quick"), bodyTerms("purple", "brown")), countDocs(numPhraseDocs, isPhraseBody(0, 3))},
+		{newBodyMultiPhrase(0, bodyTerms("quick"), bodyTerms("purple", "orange")), 0},
+		// a single position is rewritten to a disjunction
+		{newBodyMultiPhrase(0, bodyTerms("fast", "red")), countDocs(numPhraseDocs, isPhraseBody(2))},
+		// sloppy
+		{newBodyMultiPhrase(1, bodyTerms("brown", "red"), bodyTerms("quick")), countDocs(numPhraseDocs, isPhraseBody(1))},
+		{newBodyMultiPhrase(2, bodyTerms("brown", "red"), bodyTerms("quick")), countDocs(numPhraseDocs, isPhraseBody(0, 1, 2, 3))},
+		// sloppy with terms repeating across positions; in body 0 both
+		// positions collide on the only "quick", so it is not a match
+		{newBodyMultiPhrase(2, bodyTerms("quick", "brown"), bodyTerms("quick")), countDocs(numPhraseDocs, isPhraseBody(1, 3))},
+	}
+
+	for _, test := range tests {
+		verifyBooleanHits(t, searcher, test.q, test.expected)
+	}
+}
+
+func TestMultiPhraseQueryToString(t *testing.T) {
+	q := search.NewMultiPhraseQuery()
+	q.Add(bodyTerms("quick")...)
+	q.Add(bodyTerms("brown", "red")...)
+	q.AddAt(bodyTerms("fox"), 3)
+	It(t).Should("unexpected string: %v", q).Verify(q.ToString("body") == `"quick (brown red) ? fox"`)
+	q.SetSlop(2)
+	q.SetBoost(3)
+	It(t).Should("unexpected string: %v", q).Verify(q.ToString("") == `body:"quick (brown red) ? fox"~2^3`)
+}
+
+func TestQueryBuilderSynonyms(t *testing.T) {
+	directory, reader := openPhraseTestIndex(t, ".gltest_multiphrase")
+	defer os.RemoveAll(".gltest_multiphrase")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	builder := classic.NewQueryBuilder(newSynonymAnalyzer())
+	tests := []struct {
+		q        search.Query
+		str      string
+		expected int
+	}{
+		// only one position, with synonyms
+		{builder.CreateBooleanQuery("body", "fast", search.SHOULD),
+			"fast quick", numPhraseDocs},
+		{builder.CreatePhraseQuery("body", "fast", 0),
+			"fast quick", numPhraseDocs},
+		// synonyms mixed with other positions
+		{builder.CreateBooleanQuery("body", "fast red", search.SHOULD),
+			"(fast quick) red", numPhraseDocs},
+		{builder.CreateBooleanQuery("body", "fast red", search.MUST),
+			"+(fast quick) +red", countDocs(numPhraseDocs, isPhraseBody(2))},
+		// phrases with synonyms
+		{builder.CreatePhraseQuery("body", "fast brown", 0),
+			`"(fast quick) brown"`, countDocs(numPhraseDocs, isPhraseBody(0, 3))},
+		{builder.CreatePhraseQuery("body", "fast brown", 1),
+			`"(fast quick) brown"~1`, countDocs(numPhraseDocs, isPhraseBody(0, 2, 3))},
+		{builder.CreatePhraseQuery("body", "brown fast fox", 0),
+			`"brown (fast quick) fox"`, countDocs(numPhraseDocs, isPhraseBody(1))},
+	}
+
+	for _, test := range tests {
+		It(t).Should("unexpected string: %v", test.q).Verify(test.q.ToString("body") == test.str)
+		verifyBooleanHits(t, searcher, test.q, test.expected)
+	}
+}
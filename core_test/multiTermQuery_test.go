@@ -0,0 +1,202 @@
+package core_test
+
+import (
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const numMultiTermDocs = 2000
+
+var multiTermFruits = []string{"apple", "apricot", "banana"}
+
+/*
+Writes numMultiTermDocs documents whose "key" field is "kNNNN" for
+doc N, so that the terms dictionary has nested and floor blocks, and
+whose "fruit" field is one of multiTermFruits.
+*/
+func openMultiTermTestIndex(t *testing.T, path string) (store.Directory, index.IndexReader) {
+	return openTestIndex(t, path, nil, func(writer *index.IndexWriter) {
+		for i := 0; i < numMultiTermDocs; i++ {
+			d := docu.NewDocument()
+			d.Add(docu.NewTextFieldFromString("key", fmt.Sprintf("k%04d", i), docu.STORE_YES))
+			d.Add(docu.NewTextFieldFromString("fruit", multiTermFruits[i%3], docu.STORE_NO))
+			addTestDoc(t, writer, d)
+		}
+	})
+}
+
+/* Matches the test documents by their "key" and "fruit" values. */
+func isMultiTermDoc(match func(key, fruit string) bool) func(i int) bool {
+	return func(i int) bool {
+		return match(fmt.Sprintf("k%04d", i), multiTermFruits[i%3])
+	}
+}
+
+type multiTermQuery interface {
+	search.Query
+	SetRewriteMethod(search.RewriteMethod)
+}
+
+func TestMultiTermQuery(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_multiterm")
+	defer os.RemoveAll(".gltest_multiterm")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		query    multiTermQuery
+		expected int
+	}{
+		{search.NewPrefixQuery(index.NewTerm("key", "k01")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(key, _ string) bool { return strings.HasPrefix(key, "k01") }))},
+		{search.NewPrefixQuery(index.NewTerm("fruit", "ap")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return strings.HasPrefix(fruit, "ap") }))},
+		{search.NewWildcardQuery(index.NewTerm("key", "k?1?5")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(key, _ string) bool { return key[2] == '1' && key[4] == '5' }))},
+		{search.NewWildcardQuery(index.NewTerm("key", "*99")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(key, _ string) bool { return strings.HasSuffix(key, "99") }))},
+		{search.NewWildcardQuery(index.NewTerm("fruit", "banana")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return fruit == "banana" }))},
+		{search.NewWildcardQuery(index.NewTerm("fruit", "*")), numMultiTermDocs},
+		{search.NewWildcardQuery(index.NewTerm("fruit", "cherry*")), 0},
+		{search.NewRegexpQuery(index.NewTerm("key", "k0[0-4]9[0-9]")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(key, _ string) bool { return key[1] == '0' && key[2] <= '4' && key[3] == '9' }))},
+		{search.NewRegexpQuery(index.NewTerm("key", "k1.*7")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(key, _ string) bool { return key[1] == '1' && key[4] == '7' }))},
+		{search.NewRegexpQuery(index.NewTerm("fruit", "a(pple|pricot)")),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return fruit != "banana" }))},
+	}
+
+	for _, method := range []search.RewriteMethod{
+		search.CONSTANT_SCORE_FILTER_REWRITE,
+		search.CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE,
+		search.SCORING_BOOLEAN_QUERY_REWRITE,
+	} {
+		for _, test := range tests {
+			test.query.SetRewriteMethod(method)
+			verifyBooleanHits(t, searcher, test.query, test.expected)
+		}
+	}
+}
+
+func TestMultiTermQueryTopTermsRewrite(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_multiterm")
+	defer os.RemoveAll(".gltest_multiterm")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// all terms share the same boost, so the smallest ones are kept
+	q := search.NewPrefixQuery(index.NewTerm("key", "k0"))
+	q.SetRewriteMethod(search.NewTopTermsScoringBooleanQueryRewrite(5))
+	res, err := searcher.SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 5 hits, but got %v", res.TotalHits).Assert(res.TotalHits == 5)
+	for _, hit := range res.ScoreDocs {
+		It(t).Should("doc %v should be one of the top terms", hit.Doc).Verify(hit.Doc < 5)
+	}
+
+	// more terms than maxClauseCount
+	q = search.NewPrefixQuery(index.NewTerm("key", "k"))
+	q.SetRewriteMethod(search.SCORING_BOOLEAN_QUERY_REWRITE)
+	_, err = searcher.SearchTop(q, 10)
+	It(t).Should("expect too many clauses, but got %v", err).Verify(err == search.ErrTooManyClauses)
+
+	q.SetRewriteMethod(search.NewTopTermsScoringBooleanQueryRewrite(numMultiTermDocs))
+	res, err = searcher.SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 1024 hits, but got %v", res.TotalHits).Verify(res.TotalHits == 1024)
+}
+
+func TestMultiTermQueryToString(t *testing.T) {
+	tests := []struct {
+		query    search.Query
+		expected string
+	}{
+		{search.NewPrefixQuery(index.NewTerm("key", "k01")), "key:k01*"},
+		{search.NewWildcardQuery(index.NewTerm("key", "k?1?5")), "key:k?1?5"},
+		{search.NewRegexpQuery(index.NewTerm("key", "k1.*7")), "key:/k1.*7/"},
+	}
+	for _, test := range tests {
+		It(t).Should("expect '%v', but got '%v'", test.expected, test.query).
+			Verify(fmt.Sprintf("%v", test.query) == test.expected)
+		It(t).Should("expect field to be omitted").
+			Verify(test.query.ToString("key") == test.expected[len("key:"):])
+	}
+}
+
+func TestMultiTermsIntersect(t *testing.T) {
+	directory, reader := openSegmentedTestIndex(t, ".gltest_multiterms", 90, 10)
+	defer os.RemoveAll(".gltest_multiterms")
+	defer directory.Close()
+	defer reader.Close()
+
+	terms := index.GetMultiTerms(reader, "body")
+	It(t).Should("expect terms of body").Assert(terms != nil)
+	compiled := automaton.NewCompiledAutomaton(automaton.NewRegExp("m[0-2]").ToAutomaton())
+	termsEnum, err := terms.Intersect(compiled, nil)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect a byte comparator").Verify(termsEnum.Comparator() != nil)
+
+	var found []string
+	for {
+		term, err := termsEnum.Next()
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		if term == nil {
+			break
+		}
+		found = append(found, string(term))
+		k := len(found) - 1
+		var expected []int
+		for i := 0; i < 90; i++ {
+			if i%3 == k || i%7 == k {
+				expected = append(expected, i)
+			}
+		}
+		df, err := termsEnum.DocFreq()
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect docFreq %v for %v, but got %v", len(expected), string(term), df).
+			Verify(df == len(expected))
+
+		// docs are merged across segments in global doc id order
+		docs, err := termsEnum.Docs(nil, nil)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		var actual []int
+		for doc, err := docs.NextDoc(); doc != model.NO_MORE_DOCS; doc, err = docs.NextDoc() {
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			actual = append(actual, doc)
+		}
+		It(t).Should("expect docs %v for %v, but got %v", expected, string(term), actual).
+			Verify(fmt.Sprintf("%v", actual) == fmt.Sprintf("%v", expected))
+
+		docs, err = termsEnum.Docs(nil, docs)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		doc, err := docs.Advance(50)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		want := expected[sort.SearchInts(expected, 50)]
+		It(t).Should("expect to advance to doc %v, but got %v", want, doc).Verify(doc == want)
+	}
+	It(t).Should("expect [m0 m1 m2], but got %v", found).
+		Verify(fmt.Sprintf("%v", found) == "[m0 m1 m2]")
+
+	// the whole term dictionary, merged and deduplicated
+	termsEnum = terms.Iterator(nil)
+	found = nil
+	for term, err := termsEnum.Next(); term != nil; term, err = termsEnum.Next() {
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		found = append(found, string(term))
+	}
+	It(t).Should("expect [common m0 .. m6], but got %v", found).
+		Verify(fmt.Sprintf("%v", found) == "[common m0 m1 m2 m3 m4 m5 m6]")
+}
@@ -0,0 +1,146 @@
+package core_test
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+func leafReader(reader index.IndexReader, i int) *index.SegmentReader {
+	return reader.Leaves()[i].Reader().(*index.SegmentReader)
+}
+
+func TestNRTReader(t *testing.T) {
+	directory, writer := openDeleteTestWriter(t, ".gltest_nrt")
+	defer os.RemoveAll(".gltest_nrt")
+	defer directory.Close()
+
+	// nothing is committed yet, but the writer's reader sees everything
+	r1, err := index.OpenDirectoryReaderFromWriter(writer, true)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer r1.Close()
+	It(t).Should("expect %v docs, but got %v", numDeleteDocs, r1.NumDocs()).
+		Verify(r1.NumDocs() == numDeleteDocs)
+	n := countHits(t, r1, search.NewTermQuery(index.NewTerm("body", "common")))
+	It(t).Should("expect %v hits, but got %v", numDeleteDocs, n).Verify(n == numDeleteDocs)
+	It(t).Should("be current").Verify(r1.IsCurrent())
+
+	r, err := index.OpenIfChanged(r1)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect nil for an unchanged reader").Verify(r == nil)
+
+	for i := 0; i < 10; i++ {
+		err = writer.AddDocument(deleteTestDoc(numDeleteDocs+i, "fresh").Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.DeleteDocuments(index.NewTerm("id", "3"))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("not be current").Verify(!r1.IsCurrent())
+
+	r2, err := index.OpenIfChanged(r1)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect a new reader").Assert(r2 != nil)
+	defer r2.Close()
+	It(t).Should("expect %v docs, but got %v", numDeleteDocs+9, r2.NumDocs()).
+		Verify(r2.NumDocs() == numDeleteDocs+9)
+	n = countHits(t, r2, search.NewTermQuery(index.NewTerm("body", "fresh")))
+	It(t).Should("expect 10 hits, but got %v", n).Verify(n == 10)
+	n = countHits(t, r2, search.NewTermQuery(index.NewTerm("id", "3")))
+	It(t).Should("expect deleted doc to be gone, but got %v hits", n).Verify(n == 0)
+
+	// the old reader is a point-in-time view
+	It(t).Should("expect %v docs, but got %v", numDeleteDocs, r1.NumDocs()).
+		Verify(r1.NumDocs() == numDeleteDocs)
+	n = countHits(t, r1, search.NewTermQuery(index.NewTerm("id", "3")))
+	It(t).Should("expect 1 hit in the old reader, but got %v", n).Verify(n == 1)
+
+	It(t).Should("expect 2 segments, but got %v", len(r2.Leaves())).Assert(len(r2.Leaves()) == 2)
+	It(t).Should("expect the unchanged segment to share its core").
+		Verify(leafReader(r1, 0).CoreCacheKey() == leafReader(r2, 0).CoreCacheKey())
+
+	r, err = index.OpenIfChanged(r2)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect nil for an unchanged reader").Verify(r == nil)
+
+	err = writer.AddDocument(deleteTestDoc(numDeleteDocs+10, "late").Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	// once the writer is closed, reopening falls back to the last commit
+	r, err = index.OpenIfChanged(r2)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect a new reader").Assert(r != nil)
+	defer r.Close()
+	It(t).Should("expect %v docs, but got %v", numDeleteDocs+10, r.NumDocs()).
+		Verify(r.NumDocs() == numDeleteDocs+10)
+	n = countHits(t, r, search.NewTermQuery(index.NewTerm("body", "late")))
+	It(t).Should("expect 1 hit, but got %v", n).Verify(n == 1)
+}
+
+func TestOpenIfChanged(t *testing.T) {
+	directory, writer := openDeleteTestWriter(t, ".gltest_reopen")
+	defer os.RemoveAll(".gltest_reopen")
+	defer directory.Close()
+
+	err := writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	r1, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer r1.Close()
+
+	r, err := index.OpenIfChanged(r1)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect nil for an unchanged reader").Verify(r == nil)
+
+	// uncommitted changes are invisible to a reader opened from the directory
+	err = writer.AddDocument(deleteTestDoc(numDeleteDocs, "fresh").Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.DeleteDocuments(index.NewTerm("id", "3"))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	r, err = index.OpenIfChanged(r1)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect nil before commit").Verify(r == nil)
+
+	err = writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	r2, err := index.OpenIfChanged(r1)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect a new reader").Assert(r2 != nil)
+	defer r2.Close()
+	It(t).Should("expect %v docs, but got %v", numDeleteDocs, r2.NumDocs()).
+		Verify(r2.NumDocs() == numDeleteDocs)
+	It(t).Should("expect 2 segments, but got %v", len(r2.Leaves())).Assert(len(r2.Leaves()) == 2)
+	// new deletes on the first segment: new reader, same core
+	It(t).Should("expect a new segment reader for changed deletes").
+		Verify(leafReader(r1, 0) != leafReader(r2, 0))
+	It(t).Should("expect the segment to share its core").
+		Verify(leafReader(r1, 0).CoreCacheKey() == leafReader(r2, 0).CoreCacheKey())
+	n := countHits(t, r2, search.NewTermQuery(index.NewTerm("id", "3")))
+	It(t).Should("expect deleted doc to be gone, but got %v hits", n).Verify(n == 0)
+
+	err = writer.AddDocument(deleteTestDoc(numDeleteDocs+1, "fresh").Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	r3, err := index.OpenIfChanged(r2)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect a new reader").Assert(r3 != nil)
+	defer r3.Close()
+	It(t).Should("expect 3 segments, but got %v", len(r3.Leaves())).Assert(len(r3.Leaves()) == 3)
+	for i := 0; i < 2; i++ {
+		It(t).Should("expect unchanged segment %v to be shared", i).
+			Verify(leafReader(r2, i) == leafReader(r3, i))
+	}
+	n = countHits(t, r3, search.NewTermQuery(index.NewTerm("body", "fresh")))
+	It(t).Should("expect 2 hits, but got %v", n).Verify(n == 2)
+}
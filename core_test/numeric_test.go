@@ -0,0 +1,201 @@
+package core_test
+
+import (
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	. "github.com/balzaczyy/gounit"
+	"math"
+	"os"
+	"testing"
+)
+
+const numNumericDocs = 200
+
+/* The values indexed for doc i; the values decrease from doc 100 on. */
+func numericTestInt(i int) int32 {
+	if i < 100 {
+		return int32(i*37 - 3000)
+	}
+	return int32(10000 - i*13)
+}
+
+func numericTestLong(i int) int64     { return int64(numericTestInt(i)) * 1000000007 }
+func numericTestFloat(i int) float32  { return float32(numericTestInt(i)) / 8 }
+func numericTestDouble(i int) float64 { return float64(numericTestInt(i)) / 3 }
+func numericTestAll(i int) bool       { return true }
+func numericTestNone(i int) bool      { return false }
+
+/* Returns pointers to range bounds. */
+func i32(v int32) *int32     { return &v }
+func i64(v int64) *int64     { return &v }
+func f32(v float32) *float32 { return &v }
+func f64(v float64) *float64 { return &v }
+
+/*
+Indexes numNumericDocs documents with numeric fields of all types, in
+two segments. The "precise" field uses a precision step of 2.
+*/
+func openNumericTestIndex(t *testing.T, path string) (store.Directory, index.IndexReader) {
+	preciseType := docu.NewFieldTypeFrom(docu.INT_FIELD_TYPE_NOT_STORED)
+	preciseType.SetNumericPrecisionStep(2)
+
+	return openTestIndex(t, path, nil, func(writer *index.IndexWriter) {
+		for i := 0; i < numNumericDocs; i++ {
+			d := docu.NewDocument()
+			d.Add(docu.NewTextFieldFromString("body", "common", docu.STORE_NO))
+			d.Add(docu.NewIntField("int", numericTestInt(i), docu.STORE_YES))
+			d.Add(docu.NewLongField("long", numericTestLong(i), docu.STORE_YES))
+			d.Add(docu.NewFloatField("float", numericTestFloat(i), docu.STORE_YES))
+			d.Add(docu.NewDoubleField("double", numericTestDouble(i), docu.STORE_YES))
+			d.Add(docu.NewIntFieldWithType("precise", numericTestInt(i), preciseType))
+			addTestDoc(t, writer, d)
+			if i == numNumericDocs/2 {
+				commitTestIndex(t, writer)
+			}
+		}
+	})
+}
+
+func TestNumericRangeQuery(t *testing.T) {
+	directory, reader := openNumericTestIndex(t, ".gltest_numeric")
+	defer os.RemoveAll(".gltest_numeric")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		q     *search.NumericRangeQuery
+		match func(i int) bool
+	}{
+		{search.NewIntRangeQuery("int", i32(-1000), i32(2000), true, true),
+			func(i int) bool { v := numericTestInt(i); return v >= -1000 && v <= 2000 }},
+		{search.NewIntRangeQuery("int", i32(-1000), i32(2000), false, false),
+			func(i int) bool { v := numericTestInt(i); return v > -1000 && v < 2000 }},
+		{search.NewIntRangeQuery("int", i32(numericTestInt(10)), i32(numericTestInt(20)), true, false),
+			func(i int) bool { v := numericTestInt(i); return v >= numericTestInt(10) && v < numericTestInt(20) }},
+		{search.NewIntRangeQuery("int", i32(numericTestInt(10)), i32(numericTestInt(10)), true, true),
+			func(i int) bool { return numericTestInt(i) == numericTestInt(10) }},
+		{search.NewIntRangeQuery("int", i32(numericTestInt(10)), i32(numericTestInt(10)), false, true), numericTestNone},
+		{search.NewIntRangeQuery("int", i32(5000), i32(-5000), true, true), numericTestNone},
+		{search.NewIntRangeQuery("int", nil, i32(0), true, false),
+			func(i int) bool { return numericTestInt(i) < 0 }},
+		{search.NewIntRangeQuery("int", i32(0), nil, true, true),
+			func(i int) bool { return numericTestInt(i) >= 0 }},
+		{search.NewIntRangeQuery("int", nil, nil, true, true), numericTestAll},
+		{search.NewIntRangeQuery("int", i32(math.MinInt32), i32(math.MaxInt32), false, false), numericTestAll},
+		{search.NewLongRangeQuery("long", i64(numericTestLong(3)), i64(numericTestLong(150)), true, true),
+			func(i int) bool { v := numericTestLong(i); return v >= numericTestLong(3) && v <= numericTestLong(150) }},
+		{search.NewLongRangeQuery("long", nil, i64(-1), true, true),
+			func(i int) bool { return numericTestLong(i) <= -1 }},
+		{search.NewLongRangeQueryWithStep("long", util.NUMERIC_PRECISION_STEP_DEFAULT, i64(-12345678901), i64(98765432109), false, true),
+			func(i int) bool { v := numericTestLong(i); return v > -12345678901 && v <= 98765432109 }},
+		{search.NewFloatRangeQuery("float", f32(-100.5), f32(300.25), true, true),
+			func(i int) bool { v := numericTestFloat(i); return v >= -100.5 && v <= 300.25 }},
+		{search.NewFloatRangeQuery("float", f32(float32(math.Inf(-1))), f32(0), true, true),
+			func(i int) bool { return numericTestFloat(i) <= 0 }},
+		{search.NewDoubleRangeQuery("double", f64(-250), nil, false, true),
+			func(i int) bool { return numericTestDouble(i) > -250 }},
+		{search.NewDoubleRangeQuery("double", f64(math.NaN()), f64(math.NaN()), true, true), numericTestNone},
+		{search.NewIntRangeQueryWithStep("precise", 2, i32(-777), i32(1234), true, true),
+			func(i int) bool { v := numericTestInt(i); return v >= -777 && v <= 1234 }},
+	}
+	for _, test := range tests {
+		expected := countDocs(numNumericDocs, test.match)
+		res, err := searcher.SearchTop(test.q, numNumericDocs)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect %v hits for %v, but got %v", expected, test.q, res.TotalHits).
+			Verify(res.TotalHits == expected)
+		for _, hit := range res.ScoreDocs {
+			It(t).Should("unexpected hit %v for %v", hit.Doc, test.q).Verify(test.match(hit.Doc))
+		}
+	}
+
+	q := search.NewIntRangeQuery("int", nil, i32(2000), true, false)
+	It(t).Should("expect 'int:[* TO 2000}', but got '%v'", q).Verify(fmt.Sprintf("%v", q) == "int:[* TO 2000}")
+}
+
+func TestNumericRangeFilter(t *testing.T) {
+	directory, reader := openNumericTestIndex(t, ".gltest_numeric")
+	defer os.RemoveAll(".gltest_numeric")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		f     search.Filter
+		match func(i int) bool
+	}{
+		{search.NewIntRangeFilter("int", i32(-1000), i32(2000), true, true),
+			func(i int) bool { v := numericTestInt(i); return v >= -1000 && v <= 2000 }},
+		{search.NewLongRangeFilter("long", nil, i64(0), true, false),
+			func(i int) bool { return numericTestLong(i) < 0 }},
+		{search.NewFloatRangeFilter("float", f32(0), nil, true, true),
+			func(i int) bool { return numericTestFloat(i) >= 0 }},
+		{search.NewDoubleRangeFilter("double", f64(-100), f64(100), false, false),
+			func(i int) bool { v := numericTestDouble(i); return v > -100 && v < 100 }},
+	}
+	for _, test := range tests {
+		expected := countDocs(numNumericDocs, test.match)
+		res, err := searcher.Search(bodyTerm("common"), test.f, numNumericDocs)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect %v hits for %v, but got %v", expected, test.f, res.TotalHits).
+			Verify(res.TotalHits == expected)
+		for _, hit := range res.ScoreDocs {
+			It(t).Should("unexpected hit %v for %v", hit.Doc, test.f).Verify(test.match(hit.Doc))
+		}
+	}
+}
+
+func TestNumericStoredAndSorted(t *testing.T) {
+	directory, reader := openNumericTestIndex(t, ".gltest_numeric")
+	defer os.RemoveAll(".gltest_numeric")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// stored numeric values are returned as numbers and strings
+	for _, i := range []int{0, 42, 150} {
+		d, err := reader.Document(i)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect int %v, but got %v", numericTestInt(i), d.Get("int")).
+			Verify(d.Get("int") == fmt.Sprintf("%v", numericTestInt(i)))
+		It(t).Should("expect long %v, but got %v", numericTestLong(i), d.Get("long")).
+			Verify(d.Get("long") == fmt.Sprintf("%v", numericTestLong(i)))
+		for _, f := range d.Fields() {
+			switch f.Name() {
+			case "float":
+				It(t).Should("expect float %v, but got %v", numericTestFloat(i), f.NumericValue()).
+					Verify(f.NumericValue() == numericTestFloat(i))
+			case "double":
+				It(t).Should("expect double %v, but got %v", numericTestDouble(i), f.NumericValue()).
+					Verify(f.NumericValue() == numericTestDouble(i))
+			}
+		}
+	}
+
+	// numeric fields can be sorted by
+	sortFields := []*search.SortField{
+		search.NewSortField("int", search.SORT_FIELD_INT, false),
+		search.NewSortField("long", search.SORT_FIELD_LONG, true),
+		search.NewSortField("float", search.SORT_FIELD_FLOAT, false),
+		search.NewSortField("double", search.SORT_FIELD_DOUBLE, true),
+	}
+	for _, sortField := range sortFields {
+		hits, err := searcher.SearchSorted(bodyTerm("common"), nil, numNumericDocs, search.NewSort(sortField))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect %v hits, but got %v", numNumericDocs, len(hits.ScoreDocs)).
+			Assert(len(hits.ScoreDocs) == numNumericDocs)
+		for j := 1; j < len(hits.ScoreDocs); j++ {
+			prev, cur := numericTestInt(hits.ScoreDocs[j-1].Doc), numericTestInt(hits.ScoreDocs[j].Doc)
+			ordered := prev <= cur
+			if sortField.Reverse() {
+				ordered = prev >= cur
+			}
+			It(t).Should("expect sorted hits by %v, but got %v before %v", sortField, prev, cur).Verify(ordered)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package core_test
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+const numPhraseDocs = 1000
+
+var phraseBodies = []string{
+	"quick brown fox jumps",
+	"brown quick fox",
+	"quick red brown fox",
+	"fox jumps quick quick brown",
+}
+
+/*
+Writes numPhraseDocs documents whose "body" field cycles through
+phraseBodies, so that positions of the common terms span several
+postings blocks.
+*/
+func openPhraseTestIndex(t *testing.T, path string) (store.Directory, index.IndexReader) {
+	return openTestIndex(t, path, nil, func(writer *index.IndexWriter) {
+		for i := 0; i < numPhraseDocs; i++ {
+			d := docu.NewDocument()
+			d.Add(docu.NewTextFieldFromString("body", phraseBodies[i%len(phraseBodies)], docu.STORE_YES))
+			addTestDoc(t, writer, d)
+		}
+	})
+}
+
+func newBodyPhrase(slop int, texts ...string) *search.PhraseQuery {
+	q := search.NewPhraseQuery()
+	for _, text := range texts {
+		q.Add(index.NewTerm("body", text))
+	}
+	q.SetSlop(slop)
+	return q
+}
+
+/* Matches the test documents whose body is one of the given phraseBodies. */
+func isPhraseBody(bodies ...int) func(i int) bool {
+	return func(i int) bool {
+		for _, b := range bodies {
+			if i%len(phraseBodies) == b {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestPhraseQuery(t *testing.T) {
+	directory, reader := openPhraseTestIndex(t, ".gltest_phrase")
+	defer os.RemoveAll(".gltest_phrase")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		q        *search.PhraseQuery
+		expected int
+	}{
+		// exact
+		{newBodyPhrase(0, "quick", "brown"), countDocs(numPhraseDocs, isPhraseBody(0, 3))},
+		{newBodyPhrase(0, "brown", "fox"), countDocs(numPhraseDocs, isPhraseBody(0, 2))},
+		{newBodyPhrase(0, "quick", "brown", "fox", "jumps"), countDocs(numPhraseDocs, isPhraseBody(0))},
+		{newBodyPhrase(0, "quick", "quick", "brown"), countDocs(numPhraseDocs, isPhraseBody(3))},
+		{newBodyPhrase(0, "quick", "purple"), 0},
+		// sloppy
+		{newBodyPhrase(1, "quick", "brown"), countDocs(numPhraseDocs, isPhraseBody(0, 2, 3))},
+		{newBodyPhrase(2, "quick", "brown"), countDocs(numPhraseDocs, isPhraseBody(0, 1, 2, 3))},
+		{newBodyPhrase(1, "brown", "quick"), countDocs(numPhraseDocs, isPhraseBody(1))},
+		{newBodyPhrase(2, "brown", "quick"), countDocs(numPhraseDocs, isPhraseBody(0, 1, 3))},
+		// sloppy with repeating terms
+		{newBodyPhrase(1, "quick", "quick"), countDocs(numPhraseDocs, isPhraseBody(3))},
+		{newBodyPhrase(2, "quick", "brown", "quick"), countDocs(numPhraseDocs, isPhraseBody(3))},
+	}
+
+	for _, test := range tests {
+		verifyBooleanHits(t, searcher, test.q, test.expected)
+	}
+}
+
+func TestPhraseQueryRanksExactHigher(t *testing.T) {
+	directory, reader := openPhraseTestIndex(t, ".gltest_phrase")
+	defer os.RemoveAll(".gltest_phrase")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// "quick brown" is exact in bodies 0 and 3, off by one in body 2
+	res, err := searcher.SearchTop(newBodyPhrase(1, "quick", "brown"), numPhraseDocs)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	exact := countDocs(numPhraseDocs, isPhraseBody(0, 3))
+	for i, hit := range res.ScoreDocs {
+		isExact := hit.Doc%len(phraseBodies) != 2
+		It(t).Should("hit %v (doc %v) is ranked unexpectedly", i, hit.Doc).Verify(isExact == (i < exact))
+	}
+}
+
+func TestPhraseQueryToString(t *testing.T) {
+	q := newBodyPhrase(0, "quick", "brown")
+	It(t).Should("unexpected string: %v", q).Verify(q.ToString("body") == `"quick brown"`)
+	q.SetSlop(3)
+	q.SetBoost(2)
+	It(t).Should("unexpected string: %v", q).Verify(q.ToString("") == `body:"quick brown"~3^2`)
+
+	q = search.NewPhraseQuery()
+	q.AddAt(index.NewTerm("body", "quick"), 0)
+	q.AddAt(index.NewTerm("body", "fast"), 0)
+	q.AddAt(index.NewTerm("body", "fox"), 2)
+	It(t).Should("unexpected string: %v", q).Verify(q.ToString("body") == `"quick|fast ? fox"`)
+}
+
+func TestQueryBuilderPhraseQuery(t *testing.T) {
+	directory, reader := openPhraseTestIndex(t, ".gltest_phrase")
+	defer os.RemoveAll(".gltest_phrase")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	builder := classic.NewQueryBuilder(std.NewStandardAnalyzer())
+	tests := []struct {
+		text     string
+		slop     int
+		str      string
+		expected int
+	}{
+		{"quick brown", 0, `"quick brown"`, countDocs(numPhraseDocs, isPhraseBody(0, 3))},
+		{"Quick Brown", 2, `"quick brown"~2`, countDocs(numPhraseDocs, isPhraseBody(0, 1, 2, 3))},
+		// stop words leave a gap in positions
+		{"quick the brown", 0, `"quick ? brown"`, countDocs(numPhraseDocs, isPhraseBody(2, 3))},
+	}
+
+	for _, test := range tests {
+		q := builder.CreatePhraseQuery("body", test.text, test.slop)
+		_, ok := q.(*search.PhraseQuery)
+		It(t).Should("expect a PhraseQuery for '%v', got %v", test.text, q).Assert(ok)
+		It(t).Should("unexpected string: %v", q).Verify(q.ToString("body") == test.str)
+		verifyBooleanHits(t, searcher, q, test.expected)
+	}
+
+	// a single token is still a plain TermQuery
+	q := builder.CreatePhraseQuery("body", "quick", 0)
+	_, ok := q.(*search.TermQuery)
+	It(t).Should("expect a TermQuery, got %v", q).Verify(ok)
+}
@@ -0,0 +1,192 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestQueryParserSyntax(t *testing.T) {
+	tests := []struct {
+		text string
+		str  string
+	}{
+		{"apple banana", "field:apple field:banana"},
+		{"apple AND banana", "+field:apple +field:banana"},
+		{"apple && banana", "+field:apple +field:banana"},
+		{"apple OR banana", "field:apple field:banana"},
+		{"apple || banana", "field:apple field:banana"},
+		{"apple NOT banana", "field:apple -field:banana"},
+		{"apple !banana", "field:apple -field:banana"},
+		{"+apple -banana cherry", "+field:apple -field:banana field:cherry"},
+		{"-apple", "-field:apple"},
+		{"apple AND NOT banana", "+field:apple -field:banana"},
+		{"(apple OR banana) AND cherry", "+(field:apple field:banana) +field:cherry"},
+		{"apple AND (banana OR (cherry -date))", "+field:apple +(field:banana (field:cherry -field:date))"},
+		{"title:apple body:banana", "title:apple body:banana"},
+		{"title:(apple banana)", "title:apple title:banana"},
+		{"apple^2", "field:apple^2"},
+		{"apple^0.5 banana", "field:apple^0.5 field:banana"},
+		{"(apple banana)^3", "(field:apple field:banana)^3"},
+		{`"apple banana"`, `field:"apple banana"`},
+		{`"apple banana"~3`, `field:"apple banana"~3`},
+		{`"apple banana"~3^2`, `field:"apple banana"~3^2`},
+		{`title:"apple banana"`, `title:"apple banana"`},
+		{"app*", "field:app*"},
+		{"App*", "field:app*"},
+		{"a?p*e", "field:a?p*e"},
+		{"ap*le", "field:ap*le"},
+		{"/ap+le/", "field:/ap+le/"},
+		{"apple~", "field:apple~2"},
+		{"apple~1^2", "field:apple~1^2"},
+		{"apple^2~1", "field:apple~1^2"},
+		{"*:*", "*:*"},
+		{"42", "field:42"},
+		{`a\:b`, "field:a:b"},
+		{`\(apple\)`, "field:apple"},
+		{`title\:x:apple`, "title:x:apple"},
+		{"apple. banana,", "field:apple field:banana"},
+		{"apple - banana", "field:apple field:banana"},
+		{"[a TO b] AND c", "+field:[a TO b] +field:c"},
+		{"[a TO b]^2", "field:[a TO b]^2"},
+		{"the", ""},
+	}
+	for _, test := range tests {
+		parser := classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+		q, err := parser.Parse(test.text)
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		It(t).Should("expect '%v' for '%v', but got '%v'", test.str, test.text, q).
+			Verify(fmt.Sprintf("%v", q) == test.str)
+	}
+}
+
+func TestQueryParserOptions(t *testing.T) {
+	tests := []struct {
+		text string
+		str  string
+	}{
+		{"apple banana", "+field:apple +field:banana"},
+		{"apple OR banana", "field:apple field:banana"},
+		{"apple OR banana cherry", "field:apple field:banana +field:cherry"},
+		{"apple -banana", "+field:apple -field:banana"},
+	}
+	for _, test := range tests {
+		parser := classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+		parser.SetDefaultOperator(classic.OP_AND)
+		q, err := parser.Parse(test.text)
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		It(t).Should("expect '%v' for '%v', but got '%v'", test.str, test.text, q).
+			Verify(fmt.Sprintf("%v", q) == test.str)
+	}
+
+	parser := classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+	parser.SetPhraseSlop(2)
+	q, err := parser.Parse(`"apple banana" "cherry date"~1`)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect default phrase slop, but got '%v'", q).
+		Verify(fmt.Sprintf("%v", q) == `field:"apple banana"~2 field:"cherry date"~1`)
+
+	parser = classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+	parser.SetAllowLeadingWildcard(true)
+	q, err = parser.Parse("*ple ?pple *")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect leading wildcards, but got '%v'", q).
+		Verify(fmt.Sprintf("%v", q) == "field:*ple field:?pple field:*")
+
+	parser = classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+	parser.SetLowercaseExpandedTerms(false)
+	q, err = parser.Parse("App*")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 'field:App*', but got '%v'", q).Verify(fmt.Sprintf("%v", q) == "field:App*")
+
+	escaped := classic.Escape(`a+b:(c)`)
+	It(t).Should("expect escaped string, but got '%v'", escaped).Verify(escaped == `a\+b\:\(c\)`)
+}
+
+func TestQueryParserErrors(t *testing.T) {
+	tests := []struct {
+		text   string
+		column int
+	}{
+		{"(apple", 6},
+		{"apple)", 5},
+		{"field:", 6},
+		{"apple AND OR banana", 10},
+		{"apple AND", 9},
+		{"apple^", 6},
+		{"apple^x", 8},
+		{"[a TO", 5},
+		{"[a TO b", 7},
+		{`"unterminated`, 14},
+		{"*apple", 0},
+		{"?pple", 0},
+		{"title:*", 6},
+		{"apple\\", 7},
+		{`\u00`, 0},
+		{`\u00zz`, 0},
+		{"apple~1.5", 0},
+		{"/[ap/", 0},
+		{"apple banana:", 13},
+	}
+	for _, test := range tests {
+		parser := classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+		q, err := parser.Parse(test.text)
+		It(t).Should("expect an error for '%v', but got '%v'", test.text, q).Assert(err != nil)
+		pe, ok := err.(*classic.ParseError)
+		It(t).Should("expect a ParseError for '%v', but got %T", test.text, err).Assert(ok)
+		It(t).Should("expect line 1 for '%v', but got %v", test.text, pe.Line).Verify(pe.Line == 1)
+		It(t).Should("expect column %v for '%v', but got %v", test.column, test.text, pe.Column).
+			Verify(pe.Column == test.column)
+		It(t).Should("expect the query in the message, but got '%v'", pe).
+			Verify(strings.HasPrefix(pe.Error(), fmt.Sprintf("Cannot parse '%v': ", test.text)))
+	}
+
+	parser := classic.NewQueryParser(util.VERSION_LATEST, "field", std.NewStandardAnalyzer())
+	terms := make([]string, search.MaxClauseCount()+1)
+	for i := range terms {
+		terms[i] = fmt.Sprintf("t%v", i)
+	}
+	_, err := parser.Parse(strings.Join(terms, " "))
+	It(t).Should("expect too many clauses to be rejected").Verify(err != nil)
+}
+
+func TestQueryParserSearch(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_queryparser")
+	defer os.RemoveAll(".gltest_queryparser")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	fruits := func(match func(fruit string) bool) int {
+		return countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return match(fruit) }))
+	}
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"*:*", numMultiTermDocs},
+		{"apple", fruits(func(f string) bool { return f == "apple" })},
+		{"apple OR banana", fruits(func(f string) bool { return f != "apricot" })},
+		{"ap*", fruits(func(f string) bool { return f != "banana" })},
+		{"*:* -apple", fruits(func(f string) bool { return f != "apple" })},
+		{"ap* AND NOT apricot", fruits(func(f string) bool { return f == "apple" })},
+		{"/ba.*a/", fruits(func(f string) bool { return f == "banana" })},
+		{"bananna~1", fruits(func(f string) bool { return f == "banana" })},
+		{"+key:k00* +(apple banana)", countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return strings.HasPrefix(key, "k00") && fruit != "apricot"
+		}))},
+		{"key:k01?0 key:k1999", 11},
+	}
+	for _, test := range tests {
+		parser := classic.NewQueryParser(util.VERSION_LATEST, "fruit", std.NewStandardAnalyzer())
+		q, err := parser.Parse(test.text)
+		It(t).Should("has no error for '%v': %v", test.text, err).Assert(err == nil)
+		verifyBooleanHits(t, searcher, q, test.expected)
+	}
+}
@@ -0,0 +1,57 @@
+package core_test
+
+import (
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+func TestSearchAfter(t *testing.T) {
+	directory, reader := openSortTestIndex(t, ".gltest_searchafter")
+	defer os.RemoveAll(".gltest_searchafter")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// a pure disjunction is scored out of order by BooleanScorer
+	disjunction := search.NewBooleanQuery()
+	disjunction.Add(bodyTerm("even"), search.SHOULD)
+	disjunction.Add(bodyTerm("common"), search.SHOULD)
+
+	for _, q := range []search.Query{bodyTerm("common"), bodyTerm("odd"), disjunction} {
+		expected, err := searcher.Search(q, nil, numSortDocs)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+
+		for _, pageSize := range []int{1, 3, 7, numSortDocs} {
+			var hits []*search.ScoreDoc
+			var after *search.ScoreDoc
+			for {
+				page, err := searcher.SearchAfter(after, q, pageSize)
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				It(t).Should("expect %v total hits, but got %v", expected.TotalHits, page.TotalHits).
+					Verify(page.TotalHits == expected.TotalHits)
+				It(t).Should("expect at most %v hits, but got %v", pageSize, len(page.ScoreDocs)).
+					Assert(len(page.ScoreDocs) <= pageSize)
+				if len(page.ScoreDocs) == 0 {
+					break
+				}
+				hits = append(hits, page.ScoreDocs...)
+				after = page.ScoreDocs[len(page.ScoreDocs)-1]
+			}
+
+			It(t).Should("expect %v hits for %v with page size %v, but got %v",
+				len(expected.ScoreDocs), q, pageSize, len(hits)).
+				Assert(len(hits) == len(expected.ScoreDocs))
+			for i, hit := range hits {
+				want := expected.ScoreDocs[i]
+				It(t).Should("expect %v at %v for %v with page size %v, but got %v",
+					want, i, q, pageSize, hit).
+					Verify(hit.Doc == want.Doc && hit.Score == want.Score)
+			}
+		}
+	}
+
+	_, err := searcher.SearchAfter(&search.ScoreDoc{Doc: numSortDocs}, bodyTerm("common"), 10)
+	It(t).Should("expect error for out-of-range after").Verify(err != nil)
+}
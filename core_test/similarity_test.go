@@ -0,0 +1,291 @@
+package core_test
+
+import (
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	. "github.com/balzaczyy/gounit"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+var similarityBodies = []string{
+	"apple",
+	"apple apple banana",
+	"apple banana cherry date elderberry fig grape",
+	"banana cherry",
+	"cherry date",
+}
+
+/*
+Writes one document per similarityBodies entry, with the text in both
+the "body" and "title" fields, using the given Similarity for norms.
+*/
+func openSimilarityTestIndex(t *testing.T, path string, sim index.Similarity) (store.Directory, index.IndexReader) {
+	return openTestIndex(t, path, sim, func(writer *index.IndexWriter) {
+		for _, body := range similarityBodies {
+			d := docu.NewDocument()
+			d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_YES))
+			d.Add(docu.NewTextFieldFromString("title", body, docu.STORE_YES))
+			addTestDoc(t, writer, d)
+		}
+	})
+}
+
+/* Returns the document length as decoded from the lossy single byte norm. */
+func decodedLength(length int) float64 {
+	f := float64(util.Byte315ToFloat(byte(util.FloatToByte315(float32(1 / math.Sqrt(float64(length)))))))
+	return 1 / (f * f)
+}
+
+/* Computes the BM25 score of a term of the "body" field by hand. */
+func expectedBM25Score(k1, b float32, term string, doc int) float64 {
+	var df, freq, total int
+	for i, body := range similarityBodies {
+		words := strings.Fields(body)
+		total += len(words)
+		n := 0
+		for _, w := range words {
+			if w == term {
+				n++
+			}
+		}
+		if n > 0 {
+			df++
+		}
+		if i == doc {
+			freq = n
+		}
+	}
+	numDocs := len(similarityBodies)
+	idf := math.Log(1 + (float64(numDocs-df)+0.5)/(float64(df)+0.5))
+	avgdl := float64(total) / float64(numDocs)
+	dl := decodedLength(len(strings.Fields(similarityBodies[doc])))
+	tf := float64(freq)
+	return idf * tf * float64(k1+1) / (tf + float64(k1)*(1-float64(b)+float64(b)*dl/avgdl))
+}
+
+func TestBM25Similarity(t *testing.T) {
+	directory, reader := openSimilarityTestIndex(t, ".gltest_similarity", search.NewBM25Similarity())
+	defer os.RemoveAll(".gltest_similarity")
+	defer directory.Close()
+	defer reader.Close()
+
+	for _, params := range [][2]float32{{1.2, 0.75}, {2, 0}, {0.5, 1}} {
+		sim := search.NewBM25SimilarityWithParams(params[0], params[1])
+		searcher := search.NewIndexSearcher(reader)
+		searcher.SetSimilarity(sim)
+
+		for _, term := range []string{"apple", "banana", "cherry", "fig"} {
+			q := search.NewTermQuery(index.NewTerm("body", term))
+			res, err := searcher.SearchTop(q, 10)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			for _, hit := range res.ScoreDocs {
+				expected := expectedBM25Score(params[0], params[1], term, hit.Doc)
+				It(t).Should("expect score %v for '%v' in doc %v with %v, but got %v",
+					expected, q, hit.Doc, sim, hit.Score).
+					Verify(isSimilar(hit.Score, float32(expected), 0.0001))
+
+				explain, err := searcher.Explain(q, hit.Doc)
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				It(t).Should("score doesn't match explanation (%v vs %v)", hit.Score, explain.Value()).
+					Verify(isSimilar(hit.Score, explain.Value(), 0.0001))
+				It(t).Should("expect tfNorm in explanation: %v", explain).
+					Verify(strings.Contains(fmt.Sprintf("%v", explain), "tfNorm, computed from:"))
+			}
+		}
+	}
+
+	// without length normalization, repeating a term is all that counts
+	searcher := search.NewIndexSearcher(reader)
+	searcher.SetSimilarity(search.NewBM25SimilarityWithParams(1.2, 0))
+	q := search.NewBooleanQuery()
+	q.Add(search.NewTermQuery(index.NewTerm("body", "apple")), search.SHOULD)
+	q.Add(search.NewTermQuery(index.NewTerm("body", "fig")), search.SHOULD)
+	verifyBooleanHits(t, searcher, q, 3)
+	res, err := searcher.SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect doc 2 first, but got %v", res.ScoreDocs[0].Doc).Verify(res.ScoreDocs[0].Doc == 2)
+	It(t).Should("expect doc 1 with two apples ahead of doc 0, but got %v and %v",
+		res.ScoreDocs[1], res.ScoreDocs[2]).
+		Verify(res.ScoreDocs[1].Doc == 1 && res.ScoreDocs[1].Score > res.ScoreDocs[2].Score)
+}
+
+type bodyBM25Similarity struct {
+	*search.PerFieldSimilarityWrapper
+	bm25, tfidf search.Similarity
+}
+
+func newBodyBM25Similarity() *bodyBM25Similarity {
+	ans := &bodyBM25Similarity{
+		bm25:  search.NewBM25Similarity(),
+		tfidf: search.NewDefaultSimilarity(),
+	}
+	ans.PerFieldSimilarityWrapper = search.NewPerFieldSimilarityWrapper(ans)
+	return ans
+}
+
+/* BM25 ignores the query norm, so TF-IDF fields may normalize. */
+func (sim *bodyBM25Similarity) QueryNorm(valueForNormalization float32) float32 {
+	return sim.tfidf.QueryNorm(valueForNormalization)
+}
+
+func (sim *bodyBM25Similarity) Get(field string) search.Similarity {
+	if field == "body" {
+		return sim.bm25
+	}
+	return sim.tfidf
+}
+
+func TestPerFieldSimilarityWrapper(t *testing.T) {
+	perField := newBodyBM25Similarity()
+	directory, reader := openSimilarityTestIndex(t, ".gltest_similarity", perField)
+	defer os.RemoveAll(".gltest_similarity")
+	defer directory.Close()
+	defer reader.Close()
+
+	searcher := search.NewIndexSearcher(reader)
+	searcher.SetSimilarity(perField)
+	bm25 := search.NewIndexSearcher(reader)
+	bm25.SetSimilarity(search.NewBM25Similarity())
+	tfidf := search.NewIndexSearcher(reader)
+	tfidf.SetSimilarity(search.NewDefaultSimilarity())
+
+	for _, test := range []struct {
+		field    string
+		expected *search.IndexSearcher
+	}{
+		{"body", bm25},
+		{"title", tfidf},
+	} {
+		for _, term := range []string{"apple", "cherry"} {
+			q := search.NewTermQuery(index.NewTerm(test.field, term))
+			verifyBooleanHits(t, searcher, q, 3)
+			res, err := searcher.SearchTop(q, 10)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			expected, err := test.expected.SearchTop(q, 10)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			for i, hit := range res.ScoreDocs {
+				It(t).Should("expect score %v for '%v' in doc %v, but got %v",
+					expected.ScoreDocs[i].Score, q, hit.Doc, hit.Score).
+					Verify(hit.Doc == expected.ScoreDocs[i].Doc &&
+						isSimilar(hit.Score, expected.ScoreDocs[i].Score, 0.0001))
+			}
+		}
+	}
+}
+
+func TestSimilarityBaseModels(t *testing.T) {
+	directory, reader := openSimilarityTestIndex(t, ".gltest_similarity", search.NewDefaultSimilarity())
+	defer os.RemoveAll(".gltest_similarity")
+	defer directory.Close()
+	defer reader.Close()
+
+	sims := []struct {
+		sim  search.Similarity
+		name string
+	}{
+		{search.NewLMDirichletSimilarity(), "LM Dirichlet(2000.000000)"},
+		{search.NewLMDirichletSimilarityWithMu(5), "LM Dirichlet(5.000000)"},
+		{search.NewLMJelinekMercerSimilarity(0.1), "LM Jelinek-Mercer(0.100000)"},
+		{search.NewDFRSimilarity(search.NewBasicModelIn(), search.NewAfterEffectL(),
+			search.NewNormalizationH1()), "DFR I(n)L1"},
+		{search.NewDFRSimilarity(search.NewBasicModelG(), search.NewAfterEffectB(),
+			search.NewNormalizationH2()), "DFR GB2"},
+		{search.NewDFRSimilarity(search.NewBasicModelIF(), search.NewNoAfterEffect(),
+			search.NewNormalizationZ()), "DFR I(F)Z(0.3)"},
+		{search.NewDFRSimilarity(search.NewBasicModelIne(), search.NewAfterEffectL(),
+			search.NewNormalizationH3()), "DFR I(ne)L3(800)"},
+		{search.NewDFRSimilarity(search.NewBasicModelBE(), search.NewAfterEffectB(),
+			search.NewNoNormalization()), "DFR BeB"},
+		{search.NewDFRSimilarity(search.NewBasicModelD(), search.NewAfterEffectL(),
+			search.NewNormalizationH1()), "DFR DL1"},
+		{search.NewDFRSimilarity(search.NewBasicModelP(), search.NewAfterEffectL(),
+			search.NewNormalizationH2()), "DFR PL2"},
+		{search.NewIBSimilarity(search.NewDistributionLL(), search.NewLambdaDF(),
+			search.NewNormalizationH1()), "IB LL-D1"},
+		{search.NewIBSimilarity(search.NewDistributionSPL(), search.NewLambdaTTF(),
+			search.NewNormalizationZ()), "IB SPL-LZ(0.3)"},
+	}
+	for _, test := range sims {
+		It(t).Should("expect '%v', but got '%v'", test.name, test.sim).
+			Verify(fmt.Sprintf("%v", test.sim) == test.name)
+
+		searcher := search.NewIndexSearcher(reader)
+		searcher.SetSimilarity(test.sim)
+		for _, term := range []string{"apple", "banana", "cherry", "fig"} {
+			q := search.NewTermQuery(index.NewTerm("body", term))
+			expected := 0
+			for _, body := range similarityBodies {
+				if strings.Contains(body, term) {
+					expected++
+				}
+			}
+			verifyBooleanHits(t, searcher, q, expected)
+		}
+
+		// phrases are scored as the sum of their terms
+		q := search.NewPhraseQuery()
+		q.Add(index.NewTerm("body", "apple"))
+		q.Add(index.NewTerm("body", "banana"))
+		q.SetBoost(2)
+		verifyBooleanHits(t, searcher, q, 2)
+	}
+}
+
+func TestSimilarityBaseFormulas(t *testing.T) {
+	directory, reader := openSimilarityTestIndex(t, ".gltest_similarity", search.NewDefaultSimilarity())
+	defer os.RemoveAll(".gltest_similarity")
+	defer directory.Close()
+	defer reader.Close()
+
+	// "apple" occurs 4 times in 3 of the 5 documents, which have 15
+	// tokens in total; doc 1 has 3 tokens, and 2 apples
+	const N, n, F, tokens, tf = 5.0, 3.0, 4.0, 15.0, 2.0
+	avgdl, docLen := tokens/N, decodedLength(3)
+	q := search.NewTermQuery(index.NewTerm("body", "apple"))
+
+	tests := []struct {
+		sim      search.Similarity
+		expected float64
+	}{
+		// log(1 + ((1-λ)·tf/dl) / (λ·p(w|C)))
+		{search.NewLMJelinekMercerSimilarity(0.7),
+			math.Log(1 + (0.3*tf/docLen)/(0.7*(F+1)/(tokens+1)))},
+		// log(1 + tf/(μ·p(w|C))) + log(μ/(dl+μ))
+		{search.NewLMDirichletSimilarityWithMu(5),
+			math.Log(1+tf/(5*(F+1)/(tokens+1))) + math.Log(5/(docLen+5))},
+		// tfn·log2((N+1)/(n+0.5)) · 1/(tfn+1), with tfn = tf·avgdl/dl
+		{search.NewDFRSimilarity(search.NewBasicModelIn(), search.NewAfterEffectL(),
+			search.NewNormalizationH1()),
+			tf * avgdl / docLen * math.Log2((N+1)/(n+0.5)) / (tf*avgdl/docLen + 1)},
+		// -log(λ/(tfn+λ)), with λ = (n+1)/(N+1)
+		{search.NewIBSimilarity(search.NewDistributionLL(), search.NewLambdaDF(),
+			search.NewNormalizationH1()),
+			-math.Log(((n + 1) / (N + 1)) / (tf*avgdl/docLen + (n+1)/(N+1)))},
+	}
+	for _, test := range tests {
+		searcher := search.NewIndexSearcher(reader)
+		searcher.SetSimilarity(test.sim)
+		res, err := searcher.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		var score float32 = -1
+		for _, hit := range res.ScoreDocs {
+			if hit.Doc == 1 {
+				score = hit.Score
+			}
+		}
+		It(t).Should("expect score %v of doc 1 with %v, but got %v", test.expected, test.sim, score).
+			Verify(isSimilar(score, float32(test.expected), 0.0001))
+
+		explain, err := searcher.Explain(q, 1)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect the model in explanation: %v", explain).
+			Verify(strings.Contains(fmt.Sprintf("%v", explain), "computed from:"))
+	}
+}
@@ -0,0 +1,132 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/queryparser/simple"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSimpleQueryParserSyntax(t *testing.T) {
+	tests := []struct {
+		text string
+		str  string
+	}{
+		{"apple", "field:apple"},
+		{"apple banana", "field:apple field:banana"},
+		{"apple+banana", "+field:apple +field:banana"},
+		{"apple + banana | cherry", "(+field:apple +field:banana) field:cherry"},
+		{"apple | banana + cherry", "+(field:apple field:banana) +field:cherry"},
+		{"apple + (banana | cherry)", "+field:apple +(field:banana field:cherry)"},
+		{"-apple", "-field:apple *:*"},
+		{"--apple", "field:apple"},
+		{"apple -banana", "field:apple (-field:banana *:*)"},
+		{`"apple banana"`, `field:"apple banana"`},
+		{`"apple banana"~2 cherry`, `field:"apple banana"~2 field:cherry`},
+		{"app*", "field:app*"},
+		{"apple~1", "field:apple~1"},
+		{"apple~9", "field:apple~2"},
+		{"apple~0", "field:apple"},
+		{`apple\*`, "field:apple"},
+		{`\-apple`, "field:apple"},
+		{"apple-banana", "field:apple field:banana"},
+
+		// malformed input degrades to terms
+		{"(apple", "field:apple"},
+		{"apple)", "field:apple"},
+		{`"apple banana`, "field:apple field:banana"},
+		{"+apple", "field:apple"},
+		{"apple +", "field:apple"},
+		{"apple ()", "field:apple"},
+		{`""`, ""},
+		{"apple~x", "field:apple"},
+		{`"apple banana"~`, `field:"apple banana"`},
+		{"*", ""},
+		{"-", ""},
+		{"the", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		parser := simple.NewSimpleQueryParser(std.NewStandardAnalyzer(), "field")
+		q := parser.Parse(test.text)
+		It(t).Should("expect a query for '%v'", test.text).Assert(q != nil)
+		It(t).Should("expect '%v' for '%v', but got '%v'", test.str, test.text, q).
+			Verify(fmt.Sprintf("%v", q) == test.str)
+	}
+}
+
+func TestSimpleQueryParserOptions(t *testing.T) {
+	weights := map[string]float32{"title": 5, "body": 1}
+	parser := simple.NewSimpleQueryParserWithWeights(std.NewStandardAnalyzer(), weights)
+	q := parser.Parse("apple ban*")
+	expected := "(body:apple title:apple^5) (body:ban* title:ban*^5)"
+	It(t).Should("expect '%v', but got '%v'", expected, q).Verify(fmt.Sprintf("%v", q) == expected)
+
+	parser = simple.NewSimpleQueryParser(std.NewStandardAnalyzer(), "field")
+	parser.SetDefaultOperator(search.MUST)
+	q = parser.Parse("apple banana | cherry")
+	expected = "(+field:apple +field:banana) field:cherry"
+	It(t).Should("expect '%v', but got '%v'", expected, q).Verify(fmt.Sprintf("%v", q) == expected)
+
+	tests := []struct {
+		flags int
+		text  string
+		str   string
+	}{
+		{simple.ALL_OPERATORS &^ simple.AND_OPERATOR, "apple+banana", "field:apple field:banana"},
+		{simple.ALL_OPERATORS &^ simple.NOT_OPERATOR, "-apple", "field:apple"},
+		{simple.ALL_OPERATORS &^ simple.OR_OPERATOR, "apple|banana", "field:apple field:banana"},
+		{simple.ALL_OPERATORS &^ simple.PREFIX_OPERATOR, "app*", "field:app"},
+		{simple.ALL_OPERATORS &^ simple.PHRASE_OPERATOR, `"apple banana"`, "field:apple field:banana"},
+		{simple.ALL_OPERATORS &^ simple.PRECEDENCE_OPERATORS, "(apple | banana) + cherry",
+			"+(field:apple field:banana) +field:cherry"},
+		{simple.ALL_OPERATORS &^ simple.FUZZY_OPERATOR, "apple~1", "field:apple field:1"},
+		{simple.ALL_OPERATORS &^ simple.NEAR_OPERATOR, `"apple banana"~1`, `field:"apple banana" field:1`},
+		{simple.ALL_OPERATORS &^ simple.WHITESPACE_OPERATOR, "apple banana", "field:apple field:banana"},
+		{0, "apple + -banana", "field:apple field:banana"},
+	}
+	for _, test := range tests {
+		parser := simple.NewSimpleQueryParserWithFlags(std.NewStandardAnalyzer(),
+			map[string]float32{"field": 1}, test.flags)
+		q := parser.Parse(test.text)
+		It(t).Should("expect '%v' for '%v' with flags %x, but got '%v'", test.str, test.text, test.flags, q).
+			Verify(fmt.Sprintf("%v", q) == test.str)
+	}
+}
+
+func TestSimpleQueryParserSearch(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_simpleqp")
+	defer os.RemoveAll(".gltest_simpleqp")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	fruits := func(match func(fruit string) bool) int {
+		return countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return match(fruit) }))
+	}
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"apple", fruits(func(f string) bool { return f == "apple" })},
+		{"apple | banana", fruits(func(f string) bool { return f != "apricot" })},
+		{"ap*", fruits(func(f string) bool { return f != "banana" })},
+		{"-apple", fruits(func(f string) bool { return f != "apple" })},
+		{"ap* + -apricot", fruits(func(f string) bool { return f == "apple" })},
+		{"bananna~1", fruits(func(f string) bool { return f == "banana" })},
+		{"k0001 | (apple + k0003)", 2},
+		{"((k00* + banana) | k1999", countDocs(numMultiTermDocs, isMultiTermDoc(func(key, fruit string) bool {
+			return strings.HasPrefix(key, "k00") && fruit == "banana" || key == "k1999"
+		}))},
+		{"the", 0},
+	}
+	for _, test := range tests {
+		parser := simple.NewSimpleQueryParserWithWeights(std.NewStandardAnalyzer(),
+			map[string]float32{"key": 1, "fruit": 1})
+		verifyBooleanHits(t, searcher, parser.Parse(test.text), test.expected)
+	}
+}
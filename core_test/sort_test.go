@@ -0,0 +1,291 @@
+package core_test
+
+import (
+	"bytes"
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const numSortDocs = 40
+
+/* Per-document values indexed by openSortTestIndex(). */
+type sortTestValues struct {
+	i      int32
+	l      int64
+	f      float32
+	d      float64
+	s      string // empty if missing
+	hasL   bool
+	dv     int64
+	parity string
+}
+
+func sortTestDoc(i int) sortTestValues {
+	v := sortTestValues{
+		i:    int32((i*37)%numSortDocs - 20),
+		l:    int64((i*11)%7) * 1000000000000,
+		f:    float32((i*13)%numSortDocs)/4 - 5,
+		d:    float64((i*17)%numSortDocs)/8 - 2.5,
+		hasL: i%10 != 0,
+		dv:   int64(i % 5),
+	}
+	if i%8 != 0 {
+		v.s = fmt.Sprintf("s%02d", (i*7)%numSortDocs)
+	}
+	if i%2 == 0 {
+		v.parity = "even"
+	} else {
+		v.parity = "odd"
+	}
+	return v
+}
+
+/* Returns the long value, or missing if the document has none. */
+func (v sortTestValues) long(missing int64) int64 {
+	if v.hasL {
+		return v.l
+	}
+	return missing
+}
+
+func openSortTestIndex(t *testing.T, path string) (store.Directory, index.IndexReader) {
+	return openTestIndex(t, path, nil, func(writer *index.IndexWriter) {
+		for i := 0; i < numSortDocs; i++ {
+			v := sortTestDoc(i)
+			d := docu.NewDocument()
+			body := strings.Repeat("common ", i%4+1) + v.parity
+			d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+			d.Add(docu.NewFieldFromString("int", fmt.Sprintf("%v", v.i), docu.STRING_FIELD_TYPE_NOT_STORED))
+			if v.hasL {
+				d.Add(docu.NewFieldFromString("long", fmt.Sprintf("%v", v.l), docu.STRING_FIELD_TYPE_NOT_STORED))
+			}
+			d.Add(docu.NewFieldFromString("float", fmt.Sprintf("%v", v.f), docu.STRING_FIELD_TYPE_NOT_STORED))
+			d.Add(docu.NewFieldFromString("double", fmt.Sprintf("%v", v.d), docu.STRING_FIELD_TYPE_NOT_STORED))
+			if v.s != "" {
+				d.Add(docu.NewFieldFromString("str", v.s, docu.STRING_FIELD_TYPE_NOT_STORED))
+			}
+			d.Add(docu.NewNumericDocValuesField("dv", v.dv))
+			addTestDoc(t, writer, d)
+
+			if i == numSortDocs/2-1 {
+				// flush half of the documents into their own segment
+				commitTestIndex(t, writer)
+			}
+		}
+	})
+}
+
+/* Returns matching doc IDs ordered by less, ties broken by doc ID. */
+func expectedSortOrder(match func(i int) bool, less func(a, b int) bool) []int {
+	var docs []int
+	for i := 0; i < numSortDocs; i++ {
+		if match(i) {
+			docs = append(docs, i)
+		}
+	}
+	sort.SliceStable(docs, func(a, b int) bool {
+		return less(docs[a], docs[b])
+	})
+	return docs
+}
+
+func verifySortedHits(t *testing.T, hits search.TopFieldDocs, expected []int,
+	value func(doc int) interface{}) {
+
+	It(t).Should("expect %v hits, but got %v", len(expected), hits.TotalHits).
+		Assert(hits.TotalHits == len(expected))
+	It(t).Should("expect %v docs, but got %v", len(expected), len(hits.FieldDocs)).
+		Assert(len(hits.ScoreDocs) == len(expected) && len(hits.FieldDocs) == len(expected))
+	for i, hit := range hits.FieldDocs {
+		It(t).Should("expect doc %v at %v, but got %v", expected[i], i, hit.Doc).
+			Assert(hit.Doc == expected[i] && hit.ScoreDoc == hits.ScoreDocs[i])
+		It(t).Should("expect 1 sort value, but got %v", len(hit.Fields)).Assert(len(hit.Fields) == 1)
+		if want, ok := value(hit.Doc).([]byte); ok {
+			got, _ := hit.Fields[0].([]byte)
+			It(t).Should("expect %q for doc %v, but got %q", want, hit.Doc, hit.Fields[0]).
+				Verify(bytes.Equal(got, want) && (got == nil) == (want == nil))
+		} else {
+			It(t).Should("expect %v for doc %v, but got %v", value(hit.Doc), hit.Doc, hit.Fields[0]).
+				Verify(hit.Fields[0] == value(hit.Doc))
+		}
+	}
+}
+
+func TestSortByField(t *testing.T) {
+	directory, reader := openSortTestIndex(t, ".gltest_sort")
+	defer os.RemoveAll(".gltest_sort")
+	defer directory.Close()
+	defer reader.Close()
+	It(t).Should("expect 2 segments, but got %v", len(reader.Leaves())).
+		Assert(len(reader.Leaves()) == 2)
+	searcher := search.NewIndexSearcher(reader)
+
+	all := func(i int) bool { return true }
+	even := func(i int) bool { return i%2 == 0 }
+	longMissing := search.NewSortField("long", search.SORT_FIELD_LONG, false)
+	longMissing.SetMissingValue(int64(-1))
+	strLast := search.NewSortField("str", search.SORT_FIELD_STRING, false)
+	strLast.SetMissingValue(search.STRING_LAST)
+
+	tests := []struct {
+		field *search.SortField
+		match func(i int) bool
+		less  func(a, b int) bool
+		value func(doc int) interface{}
+	}{
+		{
+			search.NewSortField("int", search.SORT_FIELD_INT, false), all,
+			func(a, b int) bool { return sortTestDoc(a).i < sortTestDoc(b).i },
+			func(doc int) interface{} { return sortTestDoc(doc).i },
+		},
+		{
+			search.NewSortField("int", search.SORT_FIELD_INT, true), even,
+			func(a, b int) bool { return sortTestDoc(a).i > sortTestDoc(b).i },
+			func(doc int) interface{} { return sortTestDoc(doc).i },
+		},
+		{
+			// missing values default to 0
+			search.NewSortField("long", search.SORT_FIELD_LONG, false), all,
+			func(a, b int) bool { return sortTestDoc(a).long(0) < sortTestDoc(b).long(0) },
+			func(doc int) interface{} { return sortTestDoc(doc).long(0) },
+		},
+		{
+			longMissing, all,
+			func(a, b int) bool { return sortTestDoc(a).long(-1) < sortTestDoc(b).long(-1) },
+			func(doc int) interface{} { return sortTestDoc(doc).long(-1) },
+		},
+		{
+			search.NewSortField("float", search.SORT_FIELD_FLOAT, false), all,
+			func(a, b int) bool { return sortTestDoc(a).f < sortTestDoc(b).f },
+			func(doc int) interface{} { return sortTestDoc(doc).f },
+		},
+		{
+			search.NewSortField("double", search.SORT_FIELD_DOUBLE, true), all,
+			func(a, b int) bool { return sortTestDoc(a).d > sortTestDoc(b).d },
+			func(doc int) interface{} { return sortTestDoc(doc).d },
+		},
+		{
+			// doc values are used when the field has them
+			search.NewSortField("dv", search.SORT_FIELD_LONG, true), all,
+			func(a, b int) bool { return sortTestDoc(a).dv > sortTestDoc(b).dv },
+			func(doc int) interface{} { return sortTestDoc(doc).dv },
+		},
+		{
+			// missing strings sort first by default
+			search.NewSortField("str", search.SORT_FIELD_STRING, false), all,
+			func(a, b int) bool { return sortTestDoc(a).s < sortTestDoc(b).s },
+			sortTestString,
+		},
+		{
+			strLast, all,
+			func(a, b int) bool {
+				va, vb := sortTestDoc(a).s, sortTestDoc(b).s
+				if va == "" || vb == "" {
+					return va != "" && vb == ""
+				}
+				return va < vb
+			},
+			sortTestString,
+		},
+		{
+			search.NewSortField("str", search.SORT_FIELD_STRING, true), even,
+			func(a, b int) bool { return sortTestDoc(a).s > sortTestDoc(b).s },
+			sortTestString,
+		},
+		{
+			search.FIELD_DOC, all,
+			func(a, b int) bool { return a < b },
+			func(doc int) interface{} { return doc },
+		},
+	}
+
+	for _, test := range tests {
+		q := bodyTerm("common")
+		var filter search.Filter
+		if !test.match(1) {
+			filter = search.NewQueryWrapperFilter(bodyTerm("even"))
+		}
+		t.Logf("Sort by %v", test.field)
+		hits, err := searcher.SearchSorted(q, filter, numSortDocs, search.NewSort(test.field))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		verifySortedHits(t, hits, expectedSortOrder(test.match, test.less), test.value)
+
+		// fewer hits than matching documents keeps the top of the order
+		hits, err = searcher.SearchSorted(q, filter, 5, search.NewSort(test.field))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		expected := expectedSortOrder(test.match, test.less)
+		It(t).Should("expect %v total hits, but got %v", len(expected), hits.TotalHits).
+			Verify(hits.TotalHits == len(expected))
+		hits.TotalHits = 5
+		verifySortedHits(t, hits, expected[:5], test.value)
+	}
+}
+
+func sortTestString(doc int) interface{} {
+	if s := sortTestDoc(doc).s; s != "" {
+		return []byte(s)
+	}
+	return []byte(nil)
+}
+
+func TestSortByMultipleFields(t *testing.T) {
+	directory, reader := openSortTestIndex(t, ".gltest_sort")
+	defer os.RemoveAll(".gltest_sort")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// dv has only 5 distinct values; ties are broken by int, reversed
+	s := search.NewSort(
+		search.NewSortField("dv", search.SORT_FIELD_LONG, false),
+		search.NewSortField("int", search.SORT_FIELD_INT, true))
+	hits, err := searcher.SearchSorted(bodyTerm("common"), nil, 10, s)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	expected := expectedSortOrder(func(i int) bool { return true }, func(a, b int) bool {
+		va, vb := sortTestDoc(a), sortTestDoc(b)
+		if va.dv != vb.dv {
+			return va.dv < vb.dv
+		}
+		return va.i > vb.i
+	})
+	It(t).Should("expect 10 hits, but got %v", len(hits.FieldDocs)).Assert(len(hits.FieldDocs) == 10)
+	for i, hit := range hits.FieldDocs {
+		v := sortTestDoc(hit.Doc)
+		It(t).Should("expect doc %v at %v, but got %v", expected[i], i, hit.Doc).
+			Verify(hit.Doc == expected[i])
+		It(t).Should("expect values [%v %v], but got %v", v.dv, v.i, hit.Fields).
+			Verify(len(hit.Fields) == 2 && hit.Fields[0] == v.dv && hit.Fields[1] == v.i)
+	}
+}
+
+func TestSortByRelevance(t *testing.T) {
+	directory, reader := openSortTestIndex(t, ".gltest_sort")
+	defer os.RemoveAll(".gltest_sort")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	q := bodyTerm("common")
+	expected, err := searcher.Search(q, nil, numSortDocs)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	hits, err := searcher.SearchSorted(q, nil, numSortDocs, search.SORT_RELEVANCE)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	It(t).Should("expect %v hits, but got %v", len(expected.ScoreDocs), len(hits.FieldDocs)).
+		Assert(len(hits.FieldDocs) == len(expected.ScoreDocs))
+	for i, hit := range hits.FieldDocs {
+		want := expected.ScoreDocs[i]
+		It(t).Should("expect doc %v at %v, but got %v", want.Doc, i, hit.Doc).
+			Verify(hit.Doc == want.Doc)
+		It(t).Should("expect score %v for doc %v, but got %v", want.Score, hit.Doc, hit.Fields[0]).
+			Verify(hit.Fields[0] == want.Score)
+	}
+}
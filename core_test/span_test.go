@@ -0,0 +1,239 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/gounit"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func spanTerm(text string) search.SpanQuery {
+	return search.NewSpanTermQuery(index.NewTerm("body", text))
+}
+
+func spanNear(slop int, inOrder bool, clauses ...search.SpanQuery) search.SpanQuery {
+	return search.NewSpanNearQuery(clauses, slop, inOrder)
+}
+
+func TestSpanQueries(t *testing.T) {
+	directory, reader := openSimilarityTestIndex(t, ".gltest_span", search.NewDefaultSimilarity())
+	defer os.RemoveAll(".gltest_span")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		q        search.SpanQuery
+		str      string
+		expected int
+	}{
+		{spanTerm("apple"), "body:apple", 3},
+		// date is 2 positions after apple in doc 2 only
+		{spanNear(2, true, spanTerm("apple"), spanTerm("date")), "spanNear([body:apple, body:date], 2, true)", 1},
+		{spanNear(1, true, spanTerm("apple"), spanTerm("date")), "spanNear([body:apple, body:date], 1, true)", 0},
+		{spanNear(0, true, spanTerm("banana"), spanTerm("cherry")), "spanNear([body:banana, body:cherry], 0, true)", 2},
+		{spanNear(5, true, spanTerm("date"), spanTerm("banana")), "spanNear([body:date, body:banana], 5, true)", 0},
+		{spanNear(1, false, spanTerm("date"), spanTerm("banana")), "spanNear([body:date, body:banana], 1, false)", 1},
+		{spanNear(5, false, spanTerm("grape"), spanTerm("apple")), "spanNear([body:grape, body:apple], 5, false)", 1},
+		{spanNear(4, false, spanTerm("grape"), spanTerm("apple")), "spanNear([body:grape, body:apple], 4, false)", 0},
+		{spanNear(1, true, spanTerm("apple"), spanTerm("banana"), spanTerm("cherry")),
+			"spanNear([body:apple, body:banana, body:cherry], 1, true)", 1},
+		{search.NewSpanOrQuery(spanTerm("apple"), spanTerm("date")), "spanOr([body:apple, body:date])", 4},
+		{search.NewSpanNotQuery(spanTerm("banana"), spanTerm("apple")), "spanNot(body:banana, body:apple, 0, 0)", 3},
+		{search.NewSpanNotQueryWithDist(spanTerm("banana"), spanTerm("apple"), 1),
+			"spanNot(body:banana, body:apple, 1, 1)", 1},
+		{search.NewSpanFirstQuery(spanTerm("cherry"), 1), "spanFirst(body:cherry, 1)", 1},
+		{search.NewSpanFirstQuery(spanTerm("cherry"), 2), "spanFirst(body:cherry, 2)", 2},
+		{search.NewSpanPositionRangeQuery(spanTerm("cherry"), 1, 3), "spanPosRange(body:cherry, 1, 3)", 2},
+	}
+	for _, test := range tests {
+		It(t).Should("expect '%v', but got '%v'", test.str, test.q).Verify(test.q.ToString("") == test.str)
+		verifyBooleanHits(t, searcher, test.q, test.expected)
+		test.q.SetBoost(2)
+		verifyBooleanHits(t, searcher, test.q, test.expected)
+	}
+
+	// span queries nest within boolean queries
+	bq := search.NewBooleanQuery()
+	bq.Add(spanNear(1, false, spanTerm("cherry"), spanTerm("banana")), search.MUST)
+	bq.Add(bodyQuery("date"), search.SHOULD)
+	verifyBooleanHits(t, searcher, bq, 2)
+
+	// a single span term matches with slop factor 1/(1+1) per occurrence
+	spanRes, err := searcher.SearchTop(spanTerm("apple"), 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	termRes, err := searcher.SearchTop(bodyQuery("apple"), 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	scores := make(map[int]float32)
+	for _, hit := range termRes.ScoreDocs {
+		scores[hit.Doc] = hit.Score
+	}
+	for _, hit := range spanRes.ScoreDocs {
+		expected := scores[hit.Doc] * float32(math.Sqrt(0.5))
+		It(t).Should("expect %v for doc %v, but got %v", expected, hit.Doc, hit.Score).
+			Verify(isSimilar(hit.Score, expected, 0.0001))
+	}
+}
+
+func TestSpanMultiTermQueryWrapper(t *testing.T) {
+	directory, reader := openSimilarityTestIndex(t, ".gltest_span", search.NewDefaultSimilarity())
+	defer os.RemoveAll(".gltest_span")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	prefix := search.NewSpanMultiTermQueryWrapper(search.NewPrefixQuery(index.NewTerm("body", "ch")))
+	It(t).Should("expect the scoring span rewrite, but got %v", prefix.RewriteMethod()).
+		Verify(prefix.RewriteMethod() == search.SCORING_SPAN_QUERY_REWRITE)
+	It(t).Should("expect the wrapper string, but got %v", prefix).
+		Verify(prefix.ToString("") == "SpanMultiTermQueryWrapper(body:ch*)")
+	verifyBooleanHits(t, searcher, prefix, 3)
+	verifyBooleanHits(t, searcher, spanNear(0, true, prefix, spanTerm("date")), 2)
+
+	fuzzy := search.NewSpanMultiTermQueryWrapper(search.NewFuzzyQuery(index.NewTerm("body", "banan")))
+	_, ok := fuzzy.RewriteMethod().(*search.TopTermsSpanBooleanQueryRewrite)
+	It(t).Should("expect the top terms span rewrite, but got %v", fuzzy.RewriteMethod()).Verify(ok)
+	fuzzy.SetBoost(3)
+	rewritten, err := fuzzy.Rewrite(reader)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect the boost to carry over, but got %v", rewritten.Boost()).Verify(rewritten.Boost() == 3)
+	verifyBooleanHits(t, searcher, fuzzy, 3)
+
+	wildcard := search.NewSpanMultiTermQueryWrapper(search.NewWildcardQuery(index.NewTerm("body", "*rry")))
+	verifyBooleanHits(t, searcher, search.NewSpanFirstQuery(wildcard, 2), 2)
+	verifyBooleanHits(t, searcher, search.NewSpanNotQuery(spanTerm("banana"), wildcard), 3)
+	verifyBooleanHits(t, searcher, spanNear(0, true, spanTerm("banana"), wildcard), 2)
+}
+
+/*
+Sets the upper-cased term as the payload of each token, except for
+the terms starting with "nopay".
+*/
+type payloadFilter struct {
+	*analysis.TokenFilter
+	input      analysis.TokenStream
+	termAtt    CharTermAttribute
+	payloadAtt PayloadAttribute
+}
+
+func newPayloadFilter(in analysis.TokenStream) *payloadFilter {
+	ans := &payloadFilter{TokenFilter: analysis.NewTokenFilter(in), input: in}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.payloadAtt = ans.Attributes().Add("PayloadAttribute").(PayloadAttribute)
+	return ans
+}
+
+func (f *payloadFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if err != nil || !ok {
+		return false, err
+	}
+	term := string(f.termAtt.Buffer()[:f.termAtt.Length()])
+	if strings.HasPrefix(term, "nopay") {
+		f.payloadAtt.SetPayload(nil)
+	} else {
+		f.payloadAtt.SetPayload([]byte(strings.ToUpper(term)))
+	}
+	return true, nil
+}
+
+/* The standard analyzer, followed by a payloadFilter. */
+type payloadAnalyzer struct {
+	*analysis.AnalyzerImpl
+	delegate *std.StandardAnalyzer
+}
+
+func newPayloadAnalyzer() *payloadAnalyzer {
+	ans := &payloadAnalyzer{AnalyzerImpl: analysis.NewAnalyzer(), delegate: std.NewStandardAnalyzer()}
+	ans.Spi = ans
+	return ans
+}
+
+func (a *payloadAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *analysis.TokenStreamComponents {
+	components := a.delegate.CreateComponents(fieldName, reader)
+	ans := analysis.NewTokenStreamComponents(nil, newPayloadFilter(components.TokenStream()))
+	ans.SetReader = components.SetReader
+	return ans
+}
+
+func TestSpanQueriesWithPayloads(t *testing.T) {
+	// enough documents and positions for full blocks and skip data
+	const numDocs = 300
+	directory, writer := openTestWriterWithAnalyzer(t, ".gltest_span_payloads", nil, newPayloadAnalyzer())
+	defer os.RemoveAll(".gltest_span_payloads")
+	defer directory.Close()
+	for i := 0; i < numDocs; i++ {
+		d := docu.NewDocument()
+		body := fmt.Sprintf("common w%v common x%v nopay%v common", i%7, i%5, i%3)
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		addTestDoc(t, writer, d)
+	}
+	err := writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	verifyBooleanHits(t, searcher, spanTerm("common"), numDocs)
+	verifyBooleanHits(t, searcher, spanNear(0, true, spanTerm("w3"), spanTerm("common")),
+		countDocs(numDocs, func(i int) bool { return i%7 == 3 }))
+	verifyBooleanHits(t, searcher, spanNear(2, false, spanTerm("nopay0"), spanTerm("w6")),
+		countDocs(numDocs, func(i int) bool { return i%3 == 0 && i%7 == 6 }))
+	near := spanNear(0, true, spanTerm("x2"), spanTerm("nopay1"), spanTerm("common"))
+	verifyBooleanHits(t, searcher, near, countDocs(numDocs, func(i int) bool { return i%5 == 2 && i%3 == 1 }))
+
+	// the payloads are read back along with the positions
+	tests := []struct {
+		q        search.SpanQuery
+		payloads []string
+		matches  int
+	}{
+		{spanTerm("common"), []string{"COMMON"}, 3 * numDocs},
+		{spanTerm("nopay2"), nil, countDocs(numDocs, func(i int) bool { return i%3 == 2 })},
+		{near, []string{"COMMON", "X2"}, countDocs(numDocs, func(i int) bool { return i%5 == 2 && i%3 == 1 })},
+	}
+	for _, test := range tests {
+		matches := 0
+		for _, ctx := range reader.Leaves() {
+			spans, err := test.q.Spans(ctx, nil, map[string]*index.TermContext{})
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			for {
+				ok, err := spans.Next()
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				if !ok {
+					break
+				}
+				matches++
+				available, err := spans.IsPayloadAvailable()
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				It(t).Should("expect payload available to be %v for '%v'", test.payloads != nil, test.q).
+					Assert(available == (test.payloads != nil))
+				if !available {
+					continue
+				}
+				payload, err := spans.Payload()
+				It(t).Should("has no error: %v", err).Assert(err == nil)
+				var actual []string
+				for _, p := range payload {
+					actual = append(actual, string(p))
+				}
+				sort.Strings(actual)
+				It(t).Should("expect payloads %v for '%v' in doc %v, but got %v", test.payloads, test.q, spans.Doc(), actual).
+					Assert(strings.Join(actual, ",") == strings.Join(test.payloads, ","))
+			}
+		}
+		It(t).Should("expect %v matches for '%v', but got %v", test.matches, test.q, matches).
+			Verify(matches == test.matches)
+	}
+}
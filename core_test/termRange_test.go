@@ -0,0 +1,174 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTermRangeQuery(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_termrange")
+	defer os.RemoveAll(".gltest_termrange")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	keyRange := func(lower, upper string, includeLower, includeUpper bool) int {
+		return countDocs(numMultiTermDocs, isMultiTermDoc(func(key, _ string) bool {
+			return (lower == "" || key > lower || (includeLower && key == lower)) &&
+				(upper == "" || key < upper || (includeUpper && key == upper))
+		}))
+	}
+
+	// small ranges, which can be rewritten to boolean queries too
+	tests := []struct {
+		query    *search.TermRangeQuery
+		expected int
+	}{
+		{search.NewTermRangeQueryFromStrings("key", "k0100", "k0199", true, true),
+			keyRange("k0100", "k0199", true, true)},
+		{search.NewTermRangeQueryFromStrings("key", "k0100", "k0199", false, false),
+			keyRange("k0100", "k0199", false, false)},
+		{search.NewTermRangeQueryFromStrings("key", "k0100", "k0199", true, false),
+			keyRange("k0100", "k0199", true, false)},
+		{search.NewTermRangeQueryFromStrings("key", "k0100", "k0100", true, true), 1},
+		{search.NewTermRangeQueryFromStrings("key", "k0100", "k0100", false, true), 0},
+		{search.NewTermRangeQueryFromStrings("key", "k0199", "k0100", true, true), 0},
+		{search.NewTermRangeQueryFromStrings("key", "k09", "k1", true, true),
+			keyRange("k09", "k1", true, true)},
+		{search.NewTermRangeQueryFromStrings("key", "k1990", "", true, true),
+			keyRange("k1990", "", true, true)},
+		{search.NewTermRangeQueryFromStrings("key", "", "k0010", true, false),
+			keyRange("", "k0010", true, false)},
+		{search.NewTermRangeQueryFromStrings("fruit", "apple", "b", false, true),
+			countDocs(numMultiTermDocs, isMultiTermDoc(func(_, fruit string) bool { return fruit == "apricot" }))},
+		{search.NewTermRangeQueryFromStrings("fruit", "c", "", true, true), 0},
+	}
+	for _, method := range []search.RewriteMethod{
+		search.CONSTANT_SCORE_FILTER_REWRITE,
+		search.CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE,
+		search.SCORING_BOOLEAN_QUERY_REWRITE,
+	} {
+		for _, test := range tests {
+			test.query.SetRewriteMethod(method)
+			verifyBooleanHits(t, searcher, test.query, test.expected)
+		}
+	}
+
+	// large and fully open ranges
+	verifyBooleanHits(t, searcher, search.NewTermRangeQuery("key", nil, nil, true, true), numMultiTermDocs)
+	verifyBooleanHits(t, searcher, search.NewTermRangeQueryFromStrings("key", "k0500", "", false, true),
+		keyRange("k0500", "", false, true))
+
+	// as a filter
+	f := search.NewTermRangeFilterFromStrings("key", "k0300", "k0400", true, false)
+	res, err := searcher.Search(search.NewTermRangeQuery("fruit", nil, nil, true, true), f, numMultiTermDocs)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 100 hits, but got %v", res.TotalHits).Verify(res.TotalHits == 100)
+
+	q := search.NewTermRangeQueryFromStrings("key", "a", "m", true, false)
+	It(t).Should("expect 'key:[a TO m}', but got '%v'", q).Verify(fmt.Sprintf("%v", q) == "key:[a TO m}")
+	q = search.NewTermRangeQuery("key", nil, []byte("m"), false, true)
+	It(t).Should("expect '{* TO m]', but got '%v'", q.ToString("key")).Verify(q.ToString("key") == "{* TO m]")
+}
+
+func TestTermRangeQueryParser(t *testing.T) {
+	directory, reader := openMultiTermTestIndex(t, ".gltest_termrange")
+	defer os.RemoveAll(".gltest_termrange")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	tests := []struct {
+		text     string
+		str      string
+		expected int
+	}{
+		{"[k0100 TO k0199]", "key:[k0100 TO k0199]", 100},
+		{"{k0100 TO k0199}", "key:{k0100 TO k0199}", 98},
+		{"[k0100 TO k0199}", "key:[k0100 TO k0199}", 99},
+		{"{K0100 k0199]", "key:{k0100 TO k0199]", 99},
+		{`["k0100" TO "k0110"]`, "key:[k0100 TO k0110]", 11},
+		{"[k1990 TO *]", "key:[k1990 TO *]", 10},
+		{"[* TO k0005}", "key:[* TO k0005}", 5},
+		{"[* TO *]", "key:[* TO *]", numMultiTermDocs},
+		{"[k1998 TO k1999] k0000", "key:[k1998 TO k1999] key:k0000", 3},
+	}
+	for _, test := range tests {
+		parser := classic.NewQueryParser(util.VERSION_LATEST, "key", std.NewStandardAnalyzer())
+		q, err := parser.Parse(test.text)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect '%v', but got '%v'", test.str, q).Verify(fmt.Sprintf("%v", q) == test.str)
+		verifyBooleanHits(t, searcher, q, test.expected)
+	}
+
+	// dates are converted to the configured resolution
+	dateTests := []struct {
+		resolution docu.Resolution
+		text       string
+		str        string
+	}{
+		{docu.RESOLUTION_DAY, "[1/2/2010 TO 1/4/2010]", "date:[20100102 TO 20100104]"},
+		{docu.RESOLUTION_HOUR, "[1/2/2010 TO 1/4/2010]", "date:[2010010200 TO 2010010423]"},
+		{docu.RESOLUTION_HOUR, "[1/2/10 TO 1/4/10}", "date:[2010010200 TO 2010010400}"},
+		{docu.RESOLUTION_YEAR, "[1/2/2010 TO *]", "date:[2010 TO *]"},
+		{docu.RESOLUTION_DAY, "[a TO 1/4/2010]", "date:[a TO 20100104]"},
+	}
+	for _, test := range dateTests {
+		parser := classic.NewQueryParser(util.VERSION_LATEST, "date", std.NewStandardAnalyzer())
+		parser.SetTimeZone(time.UTC)
+		parser.SetDateResolution(test.resolution)
+		q, err := parser.Parse(test.text)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect '%v', but got '%v'", test.str, q).Verify(fmt.Sprintf("%v", q) == test.str)
+	}
+
+	// field specific resolutions take precedence, and dates are left
+	// untouched without any resolution
+	parser := classic.NewQueryParser(util.VERSION_LATEST, "date", std.NewStandardAnalyzer())
+	q, err := parser.Parse("[1/2/2010 TO 1/4/2010]")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect raw dates, but got '%v'", q).Verify(fmt.Sprintf("%v", q) == "date:[1/2/2010 TO 1/4/2010]")
+	parser.SetTimeZone(time.UTC)
+	parser.SetDateResolution(docu.RESOLUTION_DAY)
+	parser.SetFieldDateResolution("date", docu.RESOLUTION_MONTH)
+	q, err = parser.Parse("[1/2/2010 TO 1/4/2010]")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect month resolution, but got '%v'", q).Verify(fmt.Sprintf("%v", q) == "date:[201001 TO 201001]")
+}
+
+func TestDateTools(t *testing.T) {
+	date := time.Date(2004, 9, 21, 13, 50, 11, 123456789, time.UTC)
+	tests := []struct {
+		resolution docu.Resolution
+		str        string
+	}{
+		{docu.RESOLUTION_YEAR, "2004"},
+		{docu.RESOLUTION_MONTH, "200409"},
+		{docu.RESOLUTION_DAY, "20040921"},
+		{docu.RESOLUTION_HOUR, "2004092113"},
+		{docu.RESOLUTION_MINUTE, "200409211350"},
+		{docu.RESOLUTION_SECOND, "20040921135011"},
+		{docu.RESOLUTION_MILLISECOND, "20040921135011123"},
+	}
+	for _, test := range tests {
+		s := docu.DateToString(date, test.resolution)
+		It(t).Should("expect '%v', but got '%v'", test.str, s).Verify(s == test.str)
+		parsed, err := docu.StringToDate(s)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		rounded := docu.DateRound(date, test.resolution)
+		It(t).Should("expect %v, but got %v", rounded, parsed).Verify(parsed.Equal(rounded))
+	}
+	ms := date.UnixNano() / int64(time.Millisecond)
+	It(t).Should("expect time string to equal date string").
+		Verify(docu.TimeToString(ms, docu.RESOLUTION_SECOND) == "20040921135011")
+	_, err := docu.StringToDate("20041")
+	It(t).Should("expect invalid date string to be rejected").Verify(err != nil)
+}
@@ -0,0 +1,90 @@
+package core_test
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/analysis"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+)
+
+/*
+Opens an IndexWriter on a fresh index at path, using the standard
+analyzer and sim, or the default similarity if sim is nil.
+*/
+func openTestWriter(t *testing.T, path string, sim index.Similarity) (store.Directory, *index.IndexWriter) {
+	return openTestWriterWithAnalyzer(t, path, sim, std.NewStandardAnalyzer())
+}
+
+/* Like openTestWriter(), but analyzes the fields with analyzer. */
+func openTestWriterWithAnalyzer(t *testing.T, path string, sim index.Similarity,
+	analyzer analysis.Analyzer) (store.Directory, *index.IndexWriter) {
+
+	// test files run in name order, so TestBefore may not have run yet
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+
+	os.RemoveAll(path)
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, analyzer)
+	if sim != nil {
+		conf.SetSimilarity(sim)
+	}
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	return directory, writer
+}
+
+/*
+Creates a fresh index at path (see openTestWriter()) whose documents
+are written by docs, then closes the writer and opens a reader on the
+index.
+*/
+func openTestIndex(t *testing.T, path string, sim index.Similarity,
+	docs func(*index.IndexWriter)) (store.Directory, index.IndexReader) {
+
+	directory, writer := openTestWriter(t, path, sim)
+	docs(writer)
+	err := writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	return directory, reader
+}
+
+func addTestDoc(t *testing.T, writer *index.IndexWriter, d *docu.Document) {
+	err := writer.AddDocument(d.Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+}
+
+/* Commits the writer, so that the following documents go to a new segment. */
+func commitTestIndex(t *testing.T, writer *index.IndexWriter) {
+	err := writer.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+}
+
+/* Returns how many of the documents 0 .. n-1 match. */
+func countDocs(n int, match func(i int) bool) (count int) {
+	for i := 0; i < n; i++ {
+		if match(i) {
+			count++
+		}
+	}
+	return
+}
+
+/* Returns the number of documents of reader matching q. */
+func countHits(t *testing.T, reader index.IndexReader, q search.Query) int {
+	res, err := search.NewIndexSearcher(reader).Search(q, nil, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	return res.TotalHits
+}
@@ -0,0 +1,154 @@
+package core_test
+
+import (
+	"context"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/gounit"
+	"os"
+	"testing"
+	"time"
+)
+
+/* Counts the collected docs, calling onCollect (if any) for each one. */
+type countingCollector struct {
+	count     int
+	onCollect func(c *countingCollector) error
+}
+
+func (c *countingCollector) SetScorer(s search.Scorer) {}
+
+func (c *countingCollector) Collect(doc int) error {
+	c.count++
+	if c.onCollect != nil {
+		return c.onCollect(c)
+	}
+	return nil
+}
+
+func (c *countingCollector) SetNextReader(ctx *index.AtomicReaderContext) error {
+	return nil
+}
+
+func (c *countingCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+func TestSearchCancellation(t *testing.T) {
+	directory, reader := openSegmentedTestIndex(t, ".gltest_cancel", 90, 10)
+	defer os.RemoveAll(".gltest_cancel")
+	defer directory.Close()
+	defer reader.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, searcher := range []*search.IndexSearcher{
+		search.NewIndexSearcher(reader), search.NewIndexSearcherWithExecutor(reader, 3)} {
+
+		_, err := searcher.SearchTopContext(ctx, bodyQuery("common"), 10)
+		It(t).Should("expect context.Canceled, but got %v", err).Verify(err == context.Canceled)
+		_, err = searcher.SearchAfterContext(ctx, nil, bodyQuery("common"), 10)
+		It(t).Should("expect context.Canceled, but got %v", err).Verify(err == context.Canceled)
+		_, err = searcher.SearchSortedContext(ctx, bodyQuery("common"), nil, 10, search.SORT_INDEXORDER)
+		It(t).Should("expect context.Canceled, but got %v", err).Verify(err == context.Canceled)
+	}
+
+	// cancelling in the middle of a search stops it at the next check
+	searcher := search.NewIndexSearcher(reader)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	c := &countingCollector{onCollect: func(c *countingCollector) error {
+		if c.count == 15 {
+			cancel()
+		}
+		return nil
+	}}
+	err := searcher.SearchCollector(ctx, search.NewMatchAllDocsQuery(), nil, c)
+	It(t).Should("expect context.Canceled, but got %v", err).Verify(err == context.Canceled)
+	It(t).Should("expect the search to stop early, but collected %v docs", c.count).
+		Verify(c.count >= 15 && c.count < 90)
+
+	// a live context doesn't change the results
+	expected, err := searcher.SearchTop(bodyQuery("m1"), 100)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	actual, err := searcher.SearchTopContext(ctx, bodyQuery("m1"), 100)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	verifySameHits(t, bodyQuery("m1"), expected, actual)
+}
+
+func TestCollectionTerminated(t *testing.T) {
+	directory, reader := openSegmentedTestIndex(t, ".gltest_terminated", 90, 10)
+	defer os.RemoveAll(".gltest_terminated")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	// collect at most 3 docs per segment
+	perSegment := 0
+	c := &countingCollector{onCollect: func(c *countingCollector) error {
+		if perSegment++; perSegment%3 == 0 {
+			return search.ErrCollectionTerminated
+		}
+		return nil
+	}}
+	err := searcher.SearchCollector(context.Background(), search.NewMatchAllDocsQuery(), nil, c)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	segments := len(reader.Leaves())
+	It(t).Should("expect %v docs, but collected %v", 3*segments, c.count).Verify(c.count == 3*segments)
+
+	// the same through the context-free SPI method
+	w, err := searcher.CreateNormalizedWeight(search.NewMatchAllDocsQuery())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	c.count, perSegment = 0, 0
+	err = searcher.SearchLWC(reader.Leaves(), w, c)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect %v docs, but collected %v", 3*segments, c.count).Verify(c.count == 3*segments)
+}
+
+/* Returns a clock which advances by one millisecond on each reading. */
+func tickingClock() func() time.Time {
+	now := time.Unix(0, 0)
+	return func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+}
+
+func TestTimeLimitingCollector(t *testing.T) {
+	directory, reader := openSegmentedTestIndex(t, ".gltest_timelimit", 90, 10)
+	defer os.RemoveAll(".gltest_timelimit")
+	defer directory.Close()
+	defer reader.Close()
+	searcher := search.NewIndexSearcher(reader)
+
+	for _, greedy := range []bool{false, true} {
+		// the baseline takes the first reading, so docs are collected
+		// until the 22nd one
+		expected := 20
+		if greedy {
+			expected++
+		}
+		tdc := search.NewTopScoreDocCollector(100, nil, true)
+		tlc := search.NewTimeLimitingCollector(tdc, tickingClock(), 20*time.Millisecond)
+		tlc.SetGreedy(greedy)
+		err := searcher.SearchCollector(context.Background(), search.NewMatchAllDocsQuery(), nil, tlc)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect the search to time out").Assert(tlc.TimedOut())
+		res := tdc.TopDocs()
+		It(t).Should("expect %v partial hits, but got %v", expected, res.TotalHits).Verify(res.TotalHits == expected)
+		exceeded := tlc.TimeExceeded()
+		It(t).Should("expect last doc 20, but got %v", exceeded.LastDocCollected).Verify(exceeded.LastDocCollected == 20)
+		It(t).Should("expect 21ms elapsed, but got %v", exceeded.TimeElapsed).
+			Verify(exceeded.TimeElapsed == 21*time.Millisecond)
+	}
+
+	tdc := search.NewTopScoreDocCollector(100, nil, true)
+	tlc := search.NewTimeLimitingCollector(tdc, time.Now, time.Hour)
+	err := searcher.SearchCollector(context.Background(), search.NewMatchAllDocsQuery(), nil, tlc)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect no time out, but got %v", tlc.TimeExceeded()).Verify(!tlc.TimedOut())
+	res := tdc.TopDocs()
+	It(t).Should("expect 90 hits, but got %v", res.TotalHits).Verify(res.TotalHits == 90)
+}
@@ -0,0 +1,188 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// queries/function/BoostedQuery.java
+
+/*
+Query that is boosted by a ValueSource: the score of each matching
+document of the sub query is multiplied by the value of the function.
+*/
+type BoostedQuery struct {
+	*search.AbstractQuery
+	q        search.Query
+	boostVal ValueSource
+}
+
+func NewBoostedQuery(subQuery search.Query, boostVal ValueSource) *BoostedQuery {
+	ans := &BoostedQuery{q: subQuery, boostVal: boostVal}
+	ans.AbstractQuery = search.NewAbstractQuery(ans)
+	return ans
+}
+
+func (q *BoostedQuery) Query() search.Query {
+	return q.q
+}
+
+func (q *BoostedQuery) ValueSource() ValueSource {
+	return q.boostVal
+}
+
+func (q *BoostedQuery) Rewrite(reader index.IndexReader) (search.Query, error) {
+	newQ, err := q.q.Rewrite(reader)
+	if err != nil || newQ == q.q {
+		return q, err
+	}
+	clone := NewBoostedQuery(newQ, q.boostVal)
+	clone.SetBoost(q.Boost())
+	return clone, nil
+}
+
+func (q *BoostedQuery) CreateWeight(searcher *search.IndexSearcher) (search.Weight, error) {
+	return newBoostedWeight(q, searcher)
+}
+
+func (q *BoostedQuery) ToString(field string) string {
+	return fmt.Sprintf("boost(%v,%v)%v", q.q.ToString(field), q.boostVal.Description(), boostString(q.Boost()))
+}
+
+func boostString(boost float32) string {
+	if boost != 1.0 {
+		return fmt.Sprintf("^%v", boost)
+	}
+	return ""
+}
+
+type boostedWeight struct {
+	*search.WeightImpl
+	owner    *BoostedQuery
+	searcher *search.IndexSearcher
+	qWeight  search.Weight
+	fcontext map[interface{}]interface{}
+}
+
+func newBoostedWeight(owner *BoostedQuery, searcher *search.IndexSearcher) (*boostedWeight, error) {
+	qWeight, err := owner.q.CreateWeight(searcher)
+	if err != nil {
+		return nil, err
+	}
+	ans := &boostedWeight{
+		owner:    owner,
+		searcher: searcher,
+		qWeight:  qWeight,
+		fcontext: NewContext(searcher),
+	}
+	ans.WeightImpl = search.NewWeightImpl(ans)
+	if err = owner.boostVal.CreateWeight(ans.fcontext, searcher); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+func (w *boostedWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.owner)
+}
+
+func (w *boostedWeight) ValueForNormalization() float32 {
+	sum := w.qWeight.ValueForNormalization()
+	boost := w.owner.Boost()
+	return sum * boost * boost
+}
+
+func (w *boostedWeight) Normalize(norm float32, topLevelBoost float32) {
+	w.qWeight.Normalize(norm, topLevelBoost*w.owner.Boost())
+}
+
+func (w *boostedWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *boostedWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (search.Scorer, error) {
+
+	subQueryScorer, err := w.qWeight.Scorer(context, acceptDocs)
+	if err != nil || subQueryScorer == nil {
+		return nil, err
+	}
+	vals, err := w.owner.boostVal.Values(w.fcontext, context)
+	if err != nil {
+		return nil, err
+	}
+	return &boostedScorer{w, subQueryScorer, vals}, nil
+}
+
+func (w *boostedWeight) Explain(readerContext *index.AtomicReaderContext, doc int) (search.Explanation, error) {
+	subQueryExpl, err := w.qWeight.Explain(readerContext, doc)
+	if err != nil || !subQueryExpl.IsMatch() {
+		return subQueryExpl, err
+	}
+	vals, err := w.owner.boostVal.Values(w.fcontext, readerContext)
+	if err != nil {
+		return nil, err
+	}
+	val, err := vals.FloatVal(doc)
+	if err != nil {
+		return nil, err
+	}
+	valsExpl, err := vals.Explain(doc)
+	if err != nil {
+		return nil, err
+	}
+	res := search.NewComplexExplanation(true, subQueryExpl.Value()*val,
+		fmt.Sprintf("%v, product of:", w.owner))
+	res.AddDetail(subQueryExpl)
+	res.AddDetail(valsExpl)
+	return res, nil
+}
+
+/*
+Multiplies the score of the sub scorer by the function value. The
+boost of the query is already part of the sub score, as it has been
+passed down to the sub weight upon normalization.
+*/
+type boostedScorer struct {
+	weight *boostedWeight
+	scorer search.Scorer
+	vals   FunctionValues
+}
+
+func (s *boostedScorer) Weight() search.Weight {
+	return s.weight
+}
+
+func (s *boostedScorer) DocId() int {
+	return s.scorer.DocId()
+}
+
+func (s *boostedScorer) NextDoc() (int, error) {
+	return s.scorer.NextDoc()
+}
+
+func (s *boostedScorer) Advance(target int) (int, error) {
+	return s.scorer.Advance(target)
+}
+
+func (s *boostedScorer) Cost() int64 {
+	return s.scorer.Cost()
+}
+
+func (s *boostedScorer) Score() (float32, error) {
+	score, err := s.scorer.Score()
+	if err != nil {
+		return 0, err
+	}
+	val, err := s.vals.FloatVal(s.scorer.DocId())
+	if err != nil {
+		return 0, err
+	}
+	return clampScore(score * val), nil
+}
+
+func (s *boostedScorer) Freq() (int, error) {
+	return s.scorer.Freq()
+}
@@ -0,0 +1,52 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// queries/function/valuesource/ConstValueSource.java
+
+/* ConstValueSource returns a constant for all documents */
+type ConstValueSource struct {
+	constant float32
+}
+
+func NewConstValueSource(constant float32) *ConstValueSource {
+	return &ConstValueSource{constant}
+}
+
+func (vs *ConstValueSource) Description() string {
+	return fmt.Sprintf("const(%v)", vs.constant)
+}
+
+func (vs *ConstValueSource) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+	return nil
+}
+
+func (vs *ConstValueSource) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	ans := &constValues{owner: vs}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *ConstValueSource) String() string {
+	return vs.Description()
+}
+
+type constValues struct {
+	*FloatDocValues
+	owner *ConstValueSource
+}
+
+func (v *constValues) FloatVal(doc int) (float32, error) {
+	return v.owner.constant, nil
+}
+
+func (v *constValues) ToString(doc int) (string, error) {
+	return v.owner.Description(), nil
+}
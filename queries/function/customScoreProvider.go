@@ -0,0 +1,87 @@
+package function
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// queries/CustomScoreProvider.java
+
+/*
+An instance of this type is created for each index segment that is
+scored by a CustomScoreQuery, and computes the custom score of its
+documents. Embed DefaultCustomScoreProvider to only override the
+methods of interest.
+*/
+type CustomScoreProvider interface {
+	/*
+		Compute a custom score by the subQuery score and a number of
+		FunctionQuery scores.
+
+		Implementations can override this method to modify the custom
+		score. valSrcScores holds one score per FunctionQuery, and is
+		empty if there is none.
+
+		The default computation herein is a multiplication of given
+		scores:
+
+			ModifiedScore = subQueryScore * valSrcScores[0] * valSrcScores[1] * ...
+	*/
+	CustomScore(doc int, subQueryScore float32, valSrcScores []float32) (float32, error)
+	/*
+		Explain the custom score. Whenever overriding CustomScore(), this
+		method should also be overridden to provide the correct
+		explanation for the part of the custom scoring.
+	*/
+	CustomExplain(doc int, subQueryExpl search.Explanation,
+		valSrcExpls []search.Explanation) (search.Explanation, error)
+}
+
+/*
+The default CustomScoreProvider, which multiplies the sub query score
+with the scores of all FunctionQueries.
+*/
+type DefaultCustomScoreProvider struct {
+	context *index.AtomicReaderContext
+}
+
+/*
+Creates a new instance of the provider type for the given
+IndexReader.
+*/
+func NewDefaultCustomScoreProvider(context *index.AtomicReaderContext) *DefaultCustomScoreProvider {
+	return &DefaultCustomScoreProvider{context}
+}
+
+/* Returns the segment this provider computes scores for. */
+func (p *DefaultCustomScoreProvider) Context() *index.AtomicReaderContext {
+	return p.context
+}
+
+func (p *DefaultCustomScoreProvider) CustomScore(doc int, subQueryScore float32,
+	valSrcScores []float32) (float32, error) {
+
+	score := subQueryScore
+	for _, valSrcScore := range valSrcScores {
+		score *= valSrcScore
+	}
+	return score, nil
+}
+
+func (p *DefaultCustomScoreProvider) CustomExplain(doc int, subQueryExpl search.Explanation,
+	valSrcExpls []search.Explanation) (search.Explanation, error) {
+
+	if len(valSrcExpls) == 0 {
+		return subQueryExpl, nil
+	}
+	valSrcScore := float32(1)
+	for _, valSrcExpl := range valSrcExpls {
+		valSrcScore *= valSrcExpl.Value()
+	}
+	exp := search.NewExplanation(valSrcScore*subQueryExpl.Value(), "custom score: product of:")
+	exp.AddDetail(subQueryExpl)
+	for _, valSrcExpl := range valSrcExpls {
+		exp.AddDetail(valSrcExpl)
+	}
+	return exp, nil
+}
@@ -0,0 +1,370 @@
+package function
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// queries/CustomScoreQuery.java
+
+type CustomScoreQuerySPI interface {
+	/*
+		Returns a CustomScoreProvider that calculates the custom scores for
+		the given IndexReader. The default implementation returns a
+		DefaultCustomScoreProvider.
+	*/
+	CustomScoreProvider(context *index.AtomicReaderContext) (CustomScoreProvider, error)
+}
+
+/*
+Query that sets document score as a programmatic function of several
+(sub) scores:
+
+1. the score of its subQuery (any query)
+2. (optional) the score of its FunctionQuery (or queries).
+
+Subclasses can modify the computation by overriding
+CustomScoreProvider().
+*/
+type CustomScoreQuery struct {
+	*search.AbstractQuery
+	spi            CustomScoreQuerySPI
+	subQuery       search.Query
+	scoringQueries []search.Query // never nil (empty if there are no valSrcQueries)
+	strict         bool           // if true, valueSource part of query does not take part in weights normalization.
+}
+
+/*
+Create a CustomScoreQuery over input subQuery and the optional
+FunctionQueries, whose scores are combined by the default
+CustomScoreProvider.
+*/
+func NewCustomScoreQuery(subQuery search.Query, scoringQueries ...*FunctionQuery) *CustomScoreQuery {
+	ans := newCustomScoreQuery(nil, subQuery, scoringQueries)
+	ans.spi = ans
+	return ans
+}
+
+/*
+Create a CustomScoreQuery whose scores are combined by the
+CustomScoreProvider returned from the given SPI.
+*/
+func NewCustomScoreQueryWithSPI(spi CustomScoreQuerySPI, subQuery search.Query,
+	scoringQueries ...*FunctionQuery) *CustomScoreQuery {
+
+	return newCustomScoreQuery(spi, subQuery, scoringQueries)
+}
+
+func newCustomScoreQuery(spi CustomScoreQuerySPI, subQuery search.Query,
+	scoringQueries []*FunctionQuery) *CustomScoreQuery {
+
+	assert2(subQuery != nil, "<subquery> must not be null!")
+	ans := &CustomScoreQuery{spi: spi, subQuery: subQuery}
+	ans.AbstractQuery = search.NewAbstractQuery(ans)
+	ans.scoringQueries = make([]search.Query, len(scoringQueries))
+	for i, q := range scoringQueries {
+		ans.scoringQueries[i] = q
+	}
+	return ans
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (q *CustomScoreQuery) CustomScoreProvider(context *index.AtomicReaderContext) (CustomScoreProvider, error) {
+	return NewDefaultCustomScoreProvider(context), nil
+}
+
+func (q *CustomScoreQuery) Rewrite(reader index.IndexReader) (search.Query, error) {
+	var clone *CustomScoreQuery
+	sq, err := q.subQuery.Rewrite(reader)
+	if err != nil {
+		return nil, err
+	}
+	if sq != q.subQuery {
+		clone = q.clone()
+		clone.subQuery = sq
+	}
+	for i, scoringQuery := range q.scoringQueries {
+		v, err := scoringQuery.Rewrite(reader)
+		if err != nil {
+			return nil, err
+		}
+		if v != scoringQuery {
+			if clone == nil {
+				clone = q.clone()
+			}
+			clone.scoringQueries[i] = v
+		}
+	}
+	if clone == nil {
+		return q, nil
+	}
+	return clone, nil
+}
+
+/* Returns a copy which keeps the SPI of this query. */
+func (q *CustomScoreQuery) clone() *CustomScoreQuery {
+	ans := &CustomScoreQuery{
+		spi:            q.spi,
+		subQuery:       q.subQuery,
+		scoringQueries: append([]search.Query{}, q.scoringQueries...),
+		strict:         q.strict,
+	}
+	if q.spi == q {
+		ans.spi = ans
+	}
+	ans.AbstractQuery = search.NewAbstractQuery(ans)
+	ans.SetBoost(q.Boost())
+	return ans
+}
+
+func (q *CustomScoreQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	buf.WriteString("custom(")
+	buf.WriteString(q.subQuery.ToString(field))
+	for _, scoringQuery := range q.scoringQueries {
+		buf.WriteString(", ")
+		buf.WriteString(scoringQuery.ToString(field))
+	}
+	buf.WriteString(")")
+	if q.strict {
+		buf.WriteString(" STRICT")
+	}
+	buf.WriteString(boostString(q.Boost()))
+	return buf.String()
+}
+
+/*
+Checks if this is strict custom scoring. In strict custom scoring,
+the ValueSource part does not participate in weight normalization.
+This may be useful when one wants full control over how scores are
+modified, and does not care about normalizing by the ValueSource
+part. One particular case where this is useful if for testing this
+query.
+
+Note: only has effect when the ValueSource part is not nil.
+*/
+func (q *CustomScoreQuery) IsStrict() bool {
+	return q.strict
+}
+
+/* Set the strict mode of this query. */
+func (q *CustomScoreQuery) SetStrict(strict bool) {
+	q.strict = strict
+}
+
+/* The sub-query that CustomScoreQuery wraps, affecting both the score and which documents match. */
+func (q *CustomScoreQuery) SubQuery() search.Query {
+	return q.subQuery
+}
+
+/* The scoring queries that only affect the score of CustomScoreQuery. */
+func (q *CustomScoreQuery) ScoringQueries() []search.Query {
+	return q.scoringQueries
+}
+
+func (q *CustomScoreQuery) CreateWeight(searcher *search.IndexSearcher) (search.Weight, error) {
+	return newCustomWeight(q, searcher)
+}
+
+type customWeight struct {
+	*search.WeightImpl
+	owner          *CustomScoreQuery
+	subQueryWeight search.Weight
+	valSrcWeights  []search.Weight
+	qStrict        bool
+	queryWeight    float32
+}
+
+func newCustomWeight(owner *CustomScoreQuery, searcher *search.IndexSearcher) (*customWeight, error) {
+	subQueryWeight, err := owner.subQuery.CreateWeight(searcher)
+	if err != nil {
+		return nil, err
+	}
+	valSrcWeights := make([]search.Weight, len(owner.scoringQueries))
+	for i, scoringQuery := range owner.scoringQueries {
+		if valSrcWeights[i], err = scoringQuery.CreateWeight(searcher); err != nil {
+			return nil, err
+		}
+	}
+	ans := &customWeight{
+		owner:          owner,
+		subQueryWeight: subQueryWeight,
+		valSrcWeights:  valSrcWeights,
+		qStrict:        owner.strict,
+	}
+	ans.WeightImpl = search.NewWeightImpl(ans)
+	return ans, nil
+}
+
+func (w *customWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.owner)
+}
+
+func (w *customWeight) ValueForNormalization() float32 {
+	sum := w.subQueryWeight.ValueForNormalization()
+	for _, valSrcWeight := range w.valSrcWeights {
+		if w.qStrict {
+			valSrcWeight.ValueForNormalization() // do not include ValueSource part in the query normalization
+		} else {
+			sum += valSrcWeight.ValueForNormalization()
+		}
+	}
+	return sum
+}
+
+func (w *customWeight) Normalize(norm float32, topLevelBoost float32) {
+	// note we DONT incorporate our boost, nor pass down any
+	// topLevelBoost (e.g. from outer BQ), as there is no guarantee that
+	// the CustomScoreProvider's function obeys the distributive law...
+	// it might call sqrt() on the subQuery score or some other
+	// arbitrary function other than multiplication. so, instead boosts
+	// are applied directly in Score()
+	w.subQueryWeight.Normalize(norm, 1)
+	for _, valSrcWeight := range w.valSrcWeights {
+		if w.qStrict {
+			valSrcWeight.Normalize(1, 1) // do not normalize the ValueSource part
+		} else {
+			valSrcWeight.Normalize(norm, 1)
+		}
+	}
+	w.queryWeight = topLevelBoost * w.owner.Boost()
+}
+
+func (w *customWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *customWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (search.Scorer, error) {
+
+	subQueryScorer, err := w.subQueryWeight.Scorer(context, acceptDocs)
+	if err != nil || subQueryScorer == nil {
+		return nil, err
+	}
+	valSrcScorers := make([]search.Scorer, len(w.valSrcWeights))
+	for i, valSrcWeight := range w.valSrcWeights {
+		if valSrcScorers[i], err = valSrcWeight.Scorer(context, acceptDocs); err != nil {
+			return nil, err
+		}
+	}
+	provider, err := w.owner.spi.CustomScoreProvider(context)
+	if err != nil {
+		return nil, err
+	}
+	return &customScorer{
+		weight:         w,
+		qWeight:        w.queryWeight,
+		subQueryScorer: subQueryScorer,
+		valSrcScorers:  valSrcScorers,
+		provider:       provider,
+		vScores:        make([]float32, len(valSrcScorers)),
+	}, nil
+}
+
+func (w *customWeight) Explain(context *index.AtomicReaderContext, doc int) (search.Explanation, error) {
+	subQueryExpl, err := w.subQueryWeight.Explain(context, doc)
+	if err != nil || !subQueryExpl.IsMatch() {
+		return subQueryExpl, err
+	}
+	// match
+	valSrcExpls := make([]search.Explanation, len(w.valSrcWeights))
+	for i, valSrcWeight := range w.valSrcWeights {
+		if valSrcExpls[i], err = valSrcWeight.Explain(context, doc); err != nil {
+			return nil, err
+		}
+	}
+	provider, err := w.owner.spi.CustomScoreProvider(context)
+	if err != nil {
+		return nil, err
+	}
+	customExp, err := provider.CustomExplain(doc, subQueryExpl, valSrcExpls)
+	if err != nil {
+		return nil, err
+	}
+	res := search.NewComplexExplanation(true, w.queryWeight*customExp.Value(),
+		fmt.Sprintf("%v, product of:", w.owner))
+	res.AddDetail(customExp)
+	res.AddDetail(search.NewExplanation(w.queryWeight, "queryWeight"))
+	return res, nil
+}
+
+/*
+A scorer that applies a (callback) function on scores of the
+subQuery.
+*/
+type customScorer struct {
+	weight         *customWeight
+	qWeight        float32
+	subQueryScorer search.Scorer
+	valSrcScorers  []search.Scorer
+	provider       CustomScoreProvider
+	vScores        []float32 // reused in Score() to avoid allocating this array for each doc
+}
+
+func (s *customScorer) Weight() search.Weight {
+	return s.weight
+}
+
+func (s *customScorer) DocId() int {
+	return s.subQueryScorer.DocId()
+}
+
+func (s *customScorer) NextDoc() (int, error) {
+	doc, err := s.subQueryScorer.NextDoc()
+	if err != nil {
+		return 0, err
+	}
+	return doc, s.advanceValSrcScorers(doc)
+}
+
+func (s *customScorer) Advance(target int) (int, error) {
+	doc, err := s.subQueryScorer.Advance(target)
+	if err != nil {
+		return 0, err
+	}
+	return doc, s.advanceValSrcScorers(doc)
+}
+
+func (s *customScorer) Cost() int64 {
+	return s.subQueryScorer.Cost()
+}
+
+func (s *customScorer) advanceValSrcScorers(doc int) error {
+	if doc == NO_MORE_DOCS {
+		return nil
+	}
+	for _, valSrcScorer := range s.valSrcScorers {
+		if _, err := valSrcScorer.Advance(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *customScorer) Score() (float32, error) {
+	var err error
+	for i, valSrcScorer := range s.valSrcScorers {
+		if s.vScores[i], err = valSrcScorer.Score(); err != nil {
+			return 0, err
+		}
+	}
+	subQueryScore, err := s.subQueryScorer.Score()
+	if err != nil {
+		return 0, err
+	}
+	score, err := s.provider.CustomScore(s.subQueryScorer.DocId(), subQueryScore, s.vScores)
+	return s.qWeight * score, err
+}
+
+func (s *customScorer) Freq() (int, error) {
+	return s.subQueryScorer.Freq()
+}
@@ -0,0 +1,199 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/search"
+	"strconv"
+)
+
+// queries/function/docvalues/FloatDocValues.java
+
+/*
+Abstract FunctionValues implementation which supports retrieving
+float32 values. Implementations only need to provide FloatVal().
+*/
+type FloatDocValues struct {
+	spi FunctionValues
+	vs  ValueSource
+}
+
+func NewFloatDocValues(spi FunctionValues, vs ValueSource) *FloatDocValues {
+	return &FloatDocValues{spi, vs}
+}
+
+func (v *FloatDocValues) IntVal(doc int) (int32, error) {
+	f, err := v.spi.FloatVal(doc)
+	return int32(f), err
+}
+
+func (v *FloatDocValues) LongVal(doc int) (int64, error) {
+	f, err := v.spi.FloatVal(doc)
+	return int64(f), err
+}
+
+func (v *FloatDocValues) DoubleVal(doc int) (float64, error) {
+	f, err := v.spi.FloatVal(doc)
+	return float64(f), err
+}
+
+func (v *FloatDocValues) StrVal(doc int) (string, error) {
+	f, err := v.spi.FloatVal(doc)
+	return fmt.Sprintf("%v", f), err
+}
+
+func (v *FloatDocValues) Exists(doc int) (bool, error) {
+	return true, nil
+}
+
+func (v *FloatDocValues) Explain(doc int) (search.Explanation, error) {
+	return explainValues(v.spi, doc)
+}
+
+func (v *FloatDocValues) ToString(doc int) (string, error) {
+	s, err := v.spi.StrVal(doc)
+	return v.vs.Description() + "=" + s, err
+}
+
+// queries/function/docvalues/DoubleDocValues.java
+
+/*
+Abstract FunctionValues implementation which supports retrieving
+float64 values. Implementations only need to provide DoubleVal().
+*/
+type DoubleDocValues struct {
+	spi FunctionValues
+	vs  ValueSource
+}
+
+func NewDoubleDocValues(spi FunctionValues, vs ValueSource) *DoubleDocValues {
+	return &DoubleDocValues{spi, vs}
+}
+
+func (v *DoubleDocValues) FloatVal(doc int) (float32, error) {
+	f, err := v.spi.DoubleVal(doc)
+	return float32(f), err
+}
+
+func (v *DoubleDocValues) IntVal(doc int) (int32, error) {
+	f, err := v.spi.DoubleVal(doc)
+	return int32(f), err
+}
+
+func (v *DoubleDocValues) LongVal(doc int) (int64, error) {
+	f, err := v.spi.DoubleVal(doc)
+	return int64(f), err
+}
+
+func (v *DoubleDocValues) StrVal(doc int) (string, error) {
+	f, err := v.spi.DoubleVal(doc)
+	return fmt.Sprintf("%v", f), err
+}
+
+func (v *DoubleDocValues) Exists(doc int) (bool, error) {
+	return true, nil
+}
+
+func (v *DoubleDocValues) Explain(doc int) (search.Explanation, error) {
+	return explainValues(v.spi, doc)
+}
+
+func (v *DoubleDocValues) ToString(doc int) (string, error) {
+	s, err := v.spi.StrVal(doc)
+	return v.vs.Description() + "=" + s, err
+}
+
+// queries/function/docvalues/IntDocValues.java
+
+/*
+Abstract FunctionValues implementation which supports retrieving
+int32 values. Implementations only need to provide IntVal().
+*/
+type IntDocValues struct {
+	spi FunctionValues
+	vs  ValueSource
+}
+
+func NewIntDocValues(spi FunctionValues, vs ValueSource) *IntDocValues {
+	return &IntDocValues{spi, vs}
+}
+
+func (v *IntDocValues) FloatVal(doc int) (float32, error) {
+	n, err := v.spi.IntVal(doc)
+	return float32(n), err
+}
+
+func (v *IntDocValues) LongVal(doc int) (int64, error) {
+	n, err := v.spi.IntVal(doc)
+	return int64(n), err
+}
+
+func (v *IntDocValues) DoubleVal(doc int) (float64, error) {
+	n, err := v.spi.IntVal(doc)
+	return float64(n), err
+}
+
+func (v *IntDocValues) StrVal(doc int) (string, error) {
+	n, err := v.spi.IntVal(doc)
+	return strconv.Itoa(int(n)), err
+}
+
+func (v *IntDocValues) Exists(doc int) (bool, error) {
+	return true, nil
+}
+
+func (v *IntDocValues) Explain(doc int) (search.Explanation, error) {
+	return explainValues(v.spi, doc)
+}
+
+func (v *IntDocValues) ToString(doc int) (string, error) {
+	s, err := v.spi.StrVal(doc)
+	return v.vs.Description() + "=" + s, err
+}
+
+// queries/function/docvalues/LongDocValues.java
+
+/*
+Abstract FunctionValues implementation which supports retrieving
+int64 values. Implementations only need to provide LongVal().
+*/
+type LongDocValues struct {
+	spi FunctionValues
+	vs  ValueSource
+}
+
+func NewLongDocValues(spi FunctionValues, vs ValueSource) *LongDocValues {
+	return &LongDocValues{spi, vs}
+}
+
+func (v *LongDocValues) FloatVal(doc int) (float32, error) {
+	n, err := v.spi.LongVal(doc)
+	return float32(n), err
+}
+
+func (v *LongDocValues) IntVal(doc int) (int32, error) {
+	n, err := v.spi.LongVal(doc)
+	return int32(n), err
+}
+
+func (v *LongDocValues) DoubleVal(doc int) (float64, error) {
+	n, err := v.spi.LongVal(doc)
+	return float64(n), err
+}
+
+func (v *LongDocValues) StrVal(doc int) (string, error) {
+	n, err := v.spi.LongVal(doc)
+	return strconv.FormatInt(n, 10), err
+}
+
+func (v *LongDocValues) Exists(doc int) (bool, error) {
+	return true, nil
+}
+
+func (v *LongDocValues) Explain(doc int) (search.Explanation, error) {
+	return explainValues(v.spi, doc)
+}
+
+func (v *LongDocValues) ToString(doc int) (string, error) {
+	s, err := v.spi.StrVal(doc)
+	return v.vs.Description() + "=" + s, err
+}
@@ -0,0 +1,268 @@
+package function
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// queries/function/valuesource/FieldCacheSource.java
+
+/*
+A base type for ValueSource implementations that retrieve values for
+a single field from the FieldCache.
+*/
+type FieldCacheSource struct {
+	field string
+	cache search.FieldCache
+}
+
+func newFieldCacheSource(field string) *FieldCacheSource {
+	return &FieldCacheSource{field, search.DEFAULT_FIELD_CACHE}
+}
+
+func (vs *FieldCacheSource) Field() string {
+	return vs.field
+}
+
+func (vs *FieldCacheSource) FieldCache() search.FieldCache {
+	return vs.cache
+}
+
+func (vs *FieldCacheSource) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+	return nil
+}
+
+/* Loads the Bits of documents which have a value for the field. */
+func (vs *FieldCacheSource) docsWithField(readerContext *index.AtomicReaderContext) (util.Bits, error) {
+	return vs.cache.DocsWithField(readerContext.Reader().(index.AtomicReader), vs.field)
+}
+
+// queries/function/valuesource/IntFieldSource.java
+
+/*
+Obtains int32 field values from the FieldCache and makes those values
+available as other numeric types, casting as needed.
+*/
+type IntFieldSource struct {
+	*FieldCacheSource
+	parser search.IntParser
+}
+
+func NewIntFieldSource(field string) *IntFieldSource {
+	return NewIntFieldSourceWithParser(field, nil)
+}
+
+func NewIntFieldSourceWithParser(field string, parser search.IntParser) *IntFieldSource {
+	return &IntFieldSource{newFieldCacheSource(field), parser}
+}
+
+func (vs *IntFieldSource) Description() string {
+	return "int(" + vs.field + ")"
+}
+
+func (vs *IntFieldSource) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	arr, err := vs.cache.Ints(readerContext.Reader().(index.AtomicReader), vs.field, vs.parser, true)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := vs.docsWithField(readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &intFieldValues{arr: arr, valid: valid}
+	ans.IntDocValues = NewIntDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *IntFieldSource) String() string {
+	return vs.Description()
+}
+
+type intFieldValues struct {
+	*IntDocValues
+	arr   search.FieldCacheInts
+	valid util.Bits
+}
+
+func (v *intFieldValues) IntVal(doc int) (int32, error) {
+	return v.arr(doc), nil
+}
+
+func (v *intFieldValues) Exists(doc int) (bool, error) {
+	return v.arr(doc) != 0 || v.valid.At(doc), nil
+}
+
+// queries/function/valuesource/LongFieldSource.java
+
+/*
+Obtains int64 field values from the FieldCache and makes those values
+available as other numeric types, casting as needed.
+*/
+type LongFieldSource struct {
+	*FieldCacheSource
+	parser search.LongParser
+}
+
+func NewLongFieldSource(field string) *LongFieldSource {
+	return NewLongFieldSourceWithParser(field, nil)
+}
+
+func NewLongFieldSourceWithParser(field string, parser search.LongParser) *LongFieldSource {
+	return &LongFieldSource{newFieldCacheSource(field), parser}
+}
+
+func (vs *LongFieldSource) Description() string {
+	return "long(" + vs.field + ")"
+}
+
+func (vs *LongFieldSource) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	arr, err := vs.cache.Longs(readerContext.Reader().(index.AtomicReader), vs.field, vs.parser, true)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := vs.docsWithField(readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &longFieldValues{arr: arr, valid: valid}
+	ans.LongDocValues = NewLongDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *LongFieldSource) String() string {
+	return vs.Description()
+}
+
+type longFieldValues struct {
+	*LongDocValues
+	arr   search.FieldCacheLongs
+	valid util.Bits
+}
+
+func (v *longFieldValues) LongVal(doc int) (int64, error) {
+	return v.arr(doc), nil
+}
+
+func (v *longFieldValues) Exists(doc int) (bool, error) {
+	return v.arr(doc) != 0 || v.valid.At(doc), nil
+}
+
+// queries/function/valuesource/FloatFieldSource.java
+
+/*
+Obtains float32 field values from the FieldCache and makes those
+values available as other numeric types, casting as needed.
+*/
+type FloatFieldSource struct {
+	*FieldCacheSource
+	parser search.FloatParser
+}
+
+func NewFloatFieldSource(field string) *FloatFieldSource {
+	return NewFloatFieldSourceWithParser(field, nil)
+}
+
+func NewFloatFieldSourceWithParser(field string, parser search.FloatParser) *FloatFieldSource {
+	return &FloatFieldSource{newFieldCacheSource(field), parser}
+}
+
+func (vs *FloatFieldSource) Description() string {
+	return "float(" + vs.field + ")"
+}
+
+func (vs *FloatFieldSource) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	arr, err := vs.cache.Floats(readerContext.Reader().(index.AtomicReader), vs.field, vs.parser, true)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := vs.docsWithField(readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &floatFieldValues{arr: arr, valid: valid}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *FloatFieldSource) String() string {
+	return vs.Description()
+}
+
+type floatFieldValues struct {
+	*FloatDocValues
+	arr   search.FieldCacheFloats
+	valid util.Bits
+}
+
+func (v *floatFieldValues) FloatVal(doc int) (float32, error) {
+	return v.arr(doc), nil
+}
+
+func (v *floatFieldValues) Exists(doc int) (bool, error) {
+	return v.arr(doc) != 0 || v.valid.At(doc), nil
+}
+
+// queries/function/valuesource/DoubleFieldSource.java
+
+/*
+Obtains float64 field values from the FieldCache and makes those
+values available as other numeric types, casting as needed.
+*/
+type DoubleFieldSource struct {
+	*FieldCacheSource
+	parser search.DoubleParser
+}
+
+func NewDoubleFieldSource(field string) *DoubleFieldSource {
+	return NewDoubleFieldSourceWithParser(field, nil)
+}
+
+func NewDoubleFieldSourceWithParser(field string, parser search.DoubleParser) *DoubleFieldSource {
+	return &DoubleFieldSource{newFieldCacheSource(field), parser}
+}
+
+func (vs *DoubleFieldSource) Description() string {
+	return "double(" + vs.field + ")"
+}
+
+func (vs *DoubleFieldSource) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	arr, err := vs.cache.Doubles(readerContext.Reader().(index.AtomicReader), vs.field, vs.parser, true)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := vs.docsWithField(readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &doubleFieldValues{arr: arr, valid: valid}
+	ans.DoubleDocValues = NewDoubleDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *DoubleFieldSource) String() string {
+	return vs.Description()
+}
+
+type doubleFieldValues struct {
+	*DoubleDocValues
+	arr   search.FieldCacheDoubles
+	valid util.Bits
+}
+
+func (v *doubleFieldValues) DoubleVal(doc int) (float64, error) {
+	return v.arr(doc), nil
+}
+
+func (v *doubleFieldValues) Exists(doc int) (bool, error) {
+	return v.arr(doc) != 0 || v.valid.At(doc), nil
+}
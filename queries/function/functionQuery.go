@@ -0,0 +1,195 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+)
+
+// queries/function/FunctionQuery.java
+
+/*
+Returns a score for each document based on a ValueSource, often some
+function of the value of a field.
+
+Note: This API is experimental and may change in non backward-compatible ways in the future
+*/
+type FunctionQuery struct {
+	*search.AbstractQuery
+	fn ValueSource
+}
+
+func NewFunctionQuery(fn ValueSource) *FunctionQuery {
+	ans := &FunctionQuery{fn: fn}
+	ans.AbstractQuery = search.NewAbstractQuery(ans)
+	return ans
+}
+
+/* Returns the associated ValueSource */
+func (q *FunctionQuery) ValueSource() ValueSource {
+	return q.fn
+}
+
+func (q *FunctionQuery) CreateWeight(searcher *search.IndexSearcher) (search.Weight, error) {
+	return newFunctionWeight(q, searcher)
+}
+
+func (q *FunctionQuery) ToString(field string) string {
+	if boost := q.Boost(); boost != 1 {
+		return fmt.Sprintf("(%v)^%v", q.fn.Description(), boost)
+	}
+	return q.fn.Description()
+}
+
+type functionWeight struct {
+	*search.WeightImpl
+	owner       *FunctionQuery
+	searcher    *search.IndexSearcher
+	queryNorm   float32
+	queryWeight float32
+	context     map[interface{}]interface{}
+}
+
+func newFunctionWeight(owner *FunctionQuery, searcher *search.IndexSearcher) (*functionWeight, error) {
+	ans := &functionWeight{
+		owner:    owner,
+		searcher: searcher,
+		context:  NewContext(searcher),
+	}
+	ans.WeightImpl = search.NewWeightImpl(ans)
+	if err := owner.fn.CreateWeight(ans.context, searcher); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+func (w *functionWeight) String() string {
+	return fmt.Sprintf("weight(%v)", w.owner)
+}
+
+func (w *functionWeight) ValueForNormalization() float32 {
+	w.queryWeight = w.owner.Boost()
+	return w.queryWeight * w.queryWeight
+}
+
+func (w *functionWeight) Normalize(norm float32, topLevelBoost float32) {
+	w.queryNorm = norm * topLevelBoost
+	w.queryWeight *= w.queryNorm
+}
+
+func (w *functionWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *functionWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (search.Scorer, error) {
+
+	return newAllScorer(context, acceptDocs, w, w.queryWeight)
+}
+
+func (w *functionWeight) Explain(context *index.AtomicReaderContext, doc int) (search.Explanation, error) {
+	scorer, err := newAllScorer(context, context.Reader().(index.AtomicReader).LiveDocs(), w, w.queryWeight)
+	if err != nil {
+		return nil, err
+	}
+	return scorer.explain(doc)
+}
+
+/* Scores every live document with the value of the function. */
+type allScorer struct {
+	weight     *functionWeight
+	qWeight    float32
+	doc        int
+	maxDoc     int
+	vals       FunctionValues
+	acceptDocs util.Bits
+}
+
+func newAllScorer(context *index.AtomicReaderContext, acceptDocs util.Bits,
+	w *functionWeight, qWeight float32) (*allScorer, error) {
+
+	vals, err := w.owner.fn.Values(w.context, context)
+	if err != nil {
+		return nil, err
+	}
+	return &allScorer{
+		weight:     w,
+		qWeight:    qWeight,
+		doc:        -1,
+		maxDoc:     context.Reader().MaxDoc(),
+		vals:       vals,
+		acceptDocs: acceptDocs,
+	}, nil
+}
+
+func (s *allScorer) Weight() search.Weight {
+	return s.weight
+}
+
+func (s *allScorer) DocId() int {
+	return s.doc
+}
+
+/* Instead of matching all docs, we could also embed a query. The score could be ValueSource.Score(docid, score) */
+func (s *allScorer) NextDoc() (int, error) {
+	for s.doc++; s.doc < s.maxDoc; s.doc++ {
+		if s.acceptDocs == nil || s.acceptDocs.At(s.doc) {
+			return s.doc, nil
+		}
+	}
+	s.doc = NO_MORE_DOCS
+	return s.doc, nil
+}
+
+func (s *allScorer) Cost() int64 {
+	return int64(s.maxDoc)
+}
+
+func (s *allScorer) Advance(target int) (int, error) {
+	s.doc = target - 1
+	return s.NextDoc()
+}
+
+func (s *allScorer) Score() (float32, error) {
+	val, err := s.vals.FloatVal(s.doc)
+	if err != nil {
+		return 0, err
+	}
+	return clampScore(s.qWeight * val), nil
+}
+
+func (s *allScorer) Freq() (int, error) {
+	return 1, nil
+}
+
+func (s *allScorer) explain(doc int) (search.Explanation, error) {
+	val, err := s.vals.FloatVal(doc)
+	if err != nil {
+		return nil, err
+	}
+	valsExpl, err := s.vals.Explain(doc)
+	if err != nil {
+		return nil, err
+	}
+	result := search.NewComplexExplanation(true, s.qWeight*val,
+		fmt.Sprintf("FunctionQuery(%v), product of:", s.weight.owner.fn.Description()))
+	result.AddDetail(valsExpl)
+	result.AddDetail(search.NewExplanation(s.weight.owner.Boost(), "boost"))
+	result.AddDetail(search.NewExplanation(s.weight.queryNorm, "queryNorm"))
+	return result, nil
+}
+
+/*
+Current priority queues can't handle NaN and -Infinity, so map to
+-math.MaxFloat32. This conditional handles both -infinity and NaN
+since comparisons with NaN are always false.
+*/
+func clampScore(score float32) float32 {
+	if score > float32(math.Inf(-1)) {
+		return score
+	}
+	return -math.MaxFloat32
+}
@@ -0,0 +1,67 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// queries/function/valuesource/LinearFloatFunction.java
+
+/*
+LinearFloatFunction implements a linear function over another
+ValueSource.
+
+Normally Used as an argument to a FunctionQuery
+
+	f(source) = slope*source+intercept
+*/
+type LinearFloatFunction struct {
+	source           ValueSource
+	slope, intercept float32
+}
+
+func NewLinearFloatFunction(source ValueSource, slope, intercept float32) *LinearFloatFunction {
+	return &LinearFloatFunction{source, slope, intercept}
+}
+
+func (vs *LinearFloatFunction) Description() string {
+	return fmt.Sprintf("%v*float(%v)+%v", vs.slope, vs.source.Description(), vs.intercept)
+}
+
+func (vs *LinearFloatFunction) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+	return vs.source.CreateWeight(context, searcher)
+}
+
+func (vs *LinearFloatFunction) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	vals, err := vs.source.Values(context, readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &linearFloatValues{owner: vs, vals: vals}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *LinearFloatFunction) String() string {
+	return vs.Description()
+}
+
+type linearFloatValues struct {
+	*FloatDocValues
+	owner *LinearFloatFunction
+	vals  FunctionValues
+}
+
+func (v *linearFloatValues) FloatVal(doc int) (float32, error) {
+	f, err := v.vals.FloatVal(doc)
+	return f*v.owner.slope + v.owner.intercept, err
+}
+
+func (v *linearFloatValues) ToString(doc int) (string, error) {
+	s, err := v.vals.ToString(doc)
+	return fmt.Sprintf("%v*float(%v)+%v", v.owner.slope, s, v.owner.intercept), err
+}
@@ -0,0 +1,184 @@
+package function
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"math"
+	"strings"
+)
+
+// queries/function/valuesource/MultiFloatFunction.java
+
+type MultiFloatFunctionSPI interface {
+	Name() string
+	Func(doc int, valsArr []FunctionValues) (float32, error)
+}
+
+/*
+Abstract ValueSource implementation which wraps multiple ValueSources
+and applies an extendible float function to their values.
+*/
+type MultiFloatFunction struct {
+	spi     MultiFloatFunctionSPI
+	sources []ValueSource
+}
+
+func NewMultiFloatFunction(spi MultiFloatFunctionSPI, sources []ValueSource) *MultiFloatFunction {
+	return &MultiFloatFunction{spi, sources}
+}
+
+func (vs *MultiFloatFunction) Description() string {
+	descs := make([]string, len(vs.sources))
+	for i, source := range vs.sources {
+		descs[i] = source.Description()
+	}
+	return vs.spi.Name() + "(" + strings.Join(descs, ",") + ")"
+}
+
+func (vs *MultiFloatFunction) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+
+	for _, source := range vs.sources {
+		if err := source.CreateWeight(context, searcher); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vs *MultiFloatFunction) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	valsArr := make([]FunctionValues, len(vs.sources))
+	for i, source := range vs.sources {
+		vals, err := source.Values(context, readerContext)
+		if err != nil {
+			return nil, err
+		}
+		valsArr[i] = vals
+	}
+	ans := &multiFloatValues{owner: vs, valsArr: valsArr}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *MultiFloatFunction) String() string {
+	return vs.Description()
+}
+
+type multiFloatValues struct {
+	*FloatDocValues
+	owner   *MultiFloatFunction
+	valsArr []FunctionValues
+}
+
+func (v *multiFloatValues) FloatVal(doc int) (float32, error) {
+	return v.owner.spi.Func(doc, v.valsArr)
+}
+
+func (v *multiFloatValues) ToString(doc int) (string, error) {
+	strs := make([]string, len(v.valsArr))
+	for i, vals := range v.valsArr {
+		s, err := vals.ToString(doc)
+		if err != nil {
+			return "", err
+		}
+		strs[i] = s
+	}
+	return v.owner.spi.Name() + "(" + strings.Join(strs, ",") + ")", nil
+}
+
+// queries/function/valuesource/SumFloatFunction.java
+
+/* SumFloatFunction returns the sum of its components. */
+type SumFloatFunction struct {
+	*MultiFloatFunction
+}
+
+func NewSumFloatFunction(sources ...ValueSource) *SumFloatFunction {
+	ans := new(SumFloatFunction)
+	ans.MultiFloatFunction = NewMultiFloatFunction(ans, sources)
+	return ans
+}
+
+func (f *SumFloatFunction) Name() string {
+	return "sum"
+}
+
+func (f *SumFloatFunction) Func(doc int, valsArr []FunctionValues) (float32, error) {
+	var val float32
+	for _, vals := range valsArr {
+		v, err := vals.FloatVal(doc)
+		if err != nil {
+			return 0, err
+		}
+		val += v
+	}
+	return val, nil
+}
+
+// queries/function/valuesource/ProductFloatFunction.java
+
+/* ProductFloatFunction returns the product of its components. */
+type ProductFloatFunction struct {
+	*MultiFloatFunction
+}
+
+func NewProductFloatFunction(sources ...ValueSource) *ProductFloatFunction {
+	ans := new(ProductFloatFunction)
+	ans.MultiFloatFunction = NewMultiFloatFunction(ans, sources)
+	return ans
+}
+
+func (f *ProductFloatFunction) Name() string {
+	return "product"
+}
+
+func (f *ProductFloatFunction) Func(doc int, valsArr []FunctionValues) (float32, error) {
+	val := float32(1)
+	for _, vals := range valsArr {
+		v, err := vals.FloatVal(doc)
+		if err != nil {
+			return 0, err
+		}
+		val *= v
+	}
+	return val, nil
+}
+
+// queries/function/valuesource/MaxFloatFunction.java
+
+/*
+MaxFloatFunction returns the max of its components, or 0 if there is
+no component.
+*/
+type MaxFloatFunction struct {
+	*MultiFloatFunction
+}
+
+func NewMaxFloatFunction(sources ...ValueSource) *MaxFloatFunction {
+	ans := new(MaxFloatFunction)
+	ans.MultiFloatFunction = NewMultiFloatFunction(ans, sources)
+	return ans
+}
+
+func (f *MaxFloatFunction) Name() string {
+	return "max"
+}
+
+func (f *MaxFloatFunction) Func(doc int, valsArr []FunctionValues) (float32, error) {
+	if len(valsArr) == 0 {
+		return 0, nil
+	}
+	val := float32(math.Inf(-1))
+	for _, vals := range valsArr {
+		v, err := vals.FloatVal(doc)
+		if err != nil {
+			return 0, err
+		}
+		if v > val {
+			val = v
+		}
+	}
+	return val, nil
+}
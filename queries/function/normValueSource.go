@@ -0,0 +1,95 @@
+package function
+
+import (
+	"errors"
+	"github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// queries/function/valuesource/NormValueSource.java
+
+/*
+Function that returns the decoded norm for every document.
+
+Note that the configured Similarity for the field must be a
+TFIDFSimilarity (such as DefaultSimilarity), which is able to decode
+the norm values.
+*/
+type NormValueSource struct {
+	field string
+}
+
+func NewNormValueSource(field string) *NormValueSource {
+	return &NormValueSource{field}
+}
+
+func (vs *NormValueSource) Description() string {
+	return "norm(" + vs.field + ")"
+}
+
+func (vs *NormValueSource) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+	context["searcher"] = searcher
+	return nil
+}
+
+func (vs *NormValueSource) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	searcher := context["searcher"].(*search.IndexSearcher)
+	decoder := asNormDecoder(searcher.Similarity(), vs.field)
+	if decoder == nil {
+		return nil, errors.New("requires a TFIDFSimilarity (such as DefaultSimilarity)")
+	}
+	norms, err := readerContext.Reader().(index.AtomicReader).NormValues(vs.field)
+	if err != nil {
+		return nil, err
+	}
+	ans := &normValues{decoder: decoder, norms: norms}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *NormValueSource) String() string {
+	return vs.Description()
+}
+
+/* Similarities like TFIDFSimilarity which can decode encoded norms. */
+type normDecoder interface {
+	DecodeNormValue(norm int64) float32
+}
+
+/*
+Returns the norm decoder of the similarity for the given field,
+unwrapping any PerFieldSimilarityWrapper, or nil if the similarity
+cannot decode norms.
+*/
+func asNormDecoder(sim search.Similarity, field string) normDecoder {
+	for {
+		wrapper, ok := sim.(interface {
+			Get(name string) search.Similarity
+		})
+		if !ok {
+			break
+		}
+		sim = wrapper.Get(field)
+	}
+	if decoder, ok := sim.(normDecoder); ok {
+		return decoder
+	}
+	return nil
+}
+
+type normValues struct {
+	*FloatDocValues
+	decoder normDecoder
+	norms   spi.NumericDocValues // nil if the field omits norms
+}
+
+func (v *normValues) FloatVal(doc int) (float32, error) {
+	if v.norms == nil {
+		return 0, nil
+	}
+	return v.decoder.DecodeNormValue(v.norms(doc)), nil
+}
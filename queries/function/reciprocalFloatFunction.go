@@ -0,0 +1,85 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// queries/function/valuesource/ReciprocalFloatFunction.java
+
+/*
+ReciprocalFloatFunction implements a reciprocal function
+f(x) = a/(mx+b), based on the float value of a field or function as
+exported by ValueSource.
+
+When a and b are equal, and x>=0, this function has a maximum value
+of 1 that drops as x increases. Increasing the value of a and b
+together results in a movement of the entire function to a flatter
+part of the curve.
+
+These properties make this an idea function for boosting more recent
+documents.
+
+Example:
+
+	recip(ms(NOW,mydatefield),3.16e-11,1,1)
+
+A multiplier of 3.16e-11 changes the units from milliseconds to
+years (since there are about 3.16e10 milliseconds per year). Thus,
+a very recent date will yield a value close to 1/(0+1) or 1, a date
+a year in the past will get a multiplier of about 1/(1+1) or 1/2,
+and date two years old will yield 1/(2+1) or 1/3.
+*/
+type ReciprocalFloatFunction struct {
+	source  ValueSource
+	m, a, b float32
+}
+
+/*
+f(source) = a/(m*float(source)+b)
+*/
+func NewReciprocalFloatFunction(source ValueSource, m, a, b float32) *ReciprocalFloatFunction {
+	return &ReciprocalFloatFunction{source, m, a, b}
+}
+
+func (vs *ReciprocalFloatFunction) Description() string {
+	return fmt.Sprintf("%v/(%v*float(%v)+%v)", vs.a, vs.m, vs.source.Description(), vs.b)
+}
+
+func (vs *ReciprocalFloatFunction) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+	return vs.source.CreateWeight(context, searcher)
+}
+
+func (vs *ReciprocalFloatFunction) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	vals, err := vs.source.Values(context, readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &reciprocalFloatValues{owner: vs, vals: vals}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *ReciprocalFloatFunction) String() string {
+	return vs.Description()
+}
+
+type reciprocalFloatValues struct {
+	*FloatDocValues
+	owner *ReciprocalFloatFunction
+	vals  FunctionValues
+}
+
+func (v *reciprocalFloatValues) FloatVal(doc int) (float32, error) {
+	f, err := v.vals.FloatVal(doc)
+	return v.owner.a / (v.owner.m*f + v.owner.b), err
+}
+
+func (v *reciprocalFloatValues) ToString(doc int) (string, error) {
+	s, err := v.vals.ToString(doc)
+	return fmt.Sprintf("%v/(%v*float(%v)+%v)", v.owner.a, v.owner.m, s, v.owner.b), err
+}
@@ -0,0 +1,140 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"math"
+)
+
+// queries/function/valuesource/ScaleFloatFunction.java
+
+/*
+Scales values to be between min and max.
+
+This implementation currently traverses all of the source values to
+obtain their min and max.
+
+This implementation currently cannot distinguish when documents have
+been deleted or documents that have no value, and 0.0 values will be
+used for these cases. This means that if values are normally all in
+the range 5 to 10 for example, then a deleted document or one with no
+value will cause the scaled value to be 0.
+*/
+type ScaleFloatFunction struct {
+	source   ValueSource
+	min, max float32
+}
+
+func NewScaleFloatFunction(source ValueSource, min, max float32) *ScaleFloatFunction {
+	return &ScaleFloatFunction{source, min, max}
+}
+
+func (vs *ScaleFloatFunction) Description() string {
+	return fmt.Sprintf("scale(%v,%v,%v)", vs.source.Description(), vs.min, vs.max)
+}
+
+/*
+Computes the min and max values of the source up front, so that
+Values() only reads the shared context, which is then safe to use
+from several segments at once.
+*/
+func (vs *ScaleFloatFunction) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+
+	if err := vs.source.CreateWeight(context, searcher); err != nil {
+		return err
+	}
+	info, err := vs.createScaleInfo(context, searcher.TopReaderContext().Leaves())
+	if err != nil {
+		return err
+	}
+	context[vs] = info
+	return nil
+}
+
+type scaleInfo struct {
+	minVal, maxVal float32
+}
+
+/* Computes the min and max source values over all segments of the index. */
+func (vs *ScaleFloatFunction) createScaleInfo(context map[interface{}]interface{},
+	leaves []*index.AtomicReaderContext) (*scaleInfo, error) {
+
+	minVal, maxVal := float32(math.Inf(1)), float32(math.Inf(-1))
+	for _, leaf := range leaves {
+		maxDoc := leaf.Reader().MaxDoc()
+		vals, err := vs.source.Values(context, leaf)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < maxDoc; i++ {
+			val, err := vals.FloatVal(i)
+			if err != nil {
+				return nil, err
+			}
+			if math.IsInf(float64(val), 0) || math.IsNaN(float64(val)) {
+				// +Inf, -Inf or NaN don't make sense to factor into the
+				// scale function
+				continue
+			}
+			if val < minVal {
+				minVal = val
+			}
+			if val > maxVal {
+				maxVal = val
+			}
+		}
+	}
+	if math.IsInf(float64(minVal), 1) {
+		// must have been an empty index
+		minVal, maxVal = 0, 0
+	}
+	return &scaleInfo{minVal, maxVal}, nil
+}
+
+func (vs *ScaleFloatFunction) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	info, ok := context[vs].(*scaleInfo)
+	if !ok { // CreateWeight() wasn't called
+		var err error
+		if info, err = vs.createScaleInfo(context, index.TopLevelContext(readerContext).Leaves()); err != nil {
+			return nil, err
+		}
+	}
+	var scale float32
+	if info.maxVal != info.minVal {
+		scale = (vs.max - vs.min) / (info.maxVal - info.minVal)
+	}
+	vals, err := vs.source.Values(context, readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &scaleFloatValues{owner: vs, vals: vals, scale: scale, info: info}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *ScaleFloatFunction) String() string {
+	return vs.Description()
+}
+
+type scaleFloatValues struct {
+	*FloatDocValues
+	owner *ScaleFloatFunction
+	vals  FunctionValues
+	scale float32
+	info  *scaleInfo
+}
+
+func (v *scaleFloatValues) FloatVal(doc int) (float32, error) {
+	f, err := v.vals.FloatVal(doc)
+	return (f-v.info.minVal)*v.scale + v.owner.min, err
+}
+
+func (v *scaleFloatValues) ToString(doc int) (string, error) {
+	s, err := v.vals.ToString(doc)
+	return fmt.Sprintf("scale(%v,toMin=%v,toMax=%v,fromMin=%v,fromMax=%v)",
+		s, v.owner.min, v.owner.max, v.info.minVal, v.info.maxVal), err
+}
@@ -0,0 +1,88 @@
+package function
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"math"
+)
+
+// queries/function/valuesource/SimpleFloatFunction.java
+
+type SimpleFloatFunctionSPI interface {
+	Name() string
+	Func(doc int, vals FunctionValues) (float32, error)
+}
+
+/*
+A simple float function with a single argument.
+*/
+type SimpleFloatFunction struct {
+	spi    SimpleFloatFunctionSPI
+	source ValueSource
+}
+
+func NewSimpleFloatFunction(spi SimpleFloatFunctionSPI, source ValueSource) *SimpleFloatFunction {
+	return &SimpleFloatFunction{spi, source}
+}
+
+func (vs *SimpleFloatFunction) Description() string {
+	return vs.spi.Name() + "(" + vs.source.Description() + ")"
+}
+
+func (vs *SimpleFloatFunction) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+	return vs.source.CreateWeight(context, searcher)
+}
+
+func (vs *SimpleFloatFunction) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	vals, err := vs.source.Values(context, readerContext)
+	if err != nil {
+		return nil, err
+	}
+	ans := &simpleFloatValues{owner: vs, vals: vals}
+	ans.FloatDocValues = NewFloatDocValues(ans, vs)
+	return ans, nil
+}
+
+func (vs *SimpleFloatFunction) String() string {
+	return vs.Description()
+}
+
+type simpleFloatValues struct {
+	*FloatDocValues
+	owner *SimpleFloatFunction
+	vals  FunctionValues
+}
+
+func (v *simpleFloatValues) FloatVal(doc int) (float32, error) {
+	return v.owner.spi.Func(doc, v.vals)
+}
+
+func (v *simpleFloatValues) ToString(doc int) (string, error) {
+	s, err := v.vals.ToString(doc)
+	return v.owner.spi.Name() + "(" + s + ")", err
+}
+
+// queries/function/valuesource/LogFloatFunction.java
+
+/* Function that returns the base 10 logarithm of the specified value. */
+type LogFloatFunction struct {
+	*SimpleFloatFunction
+}
+
+func NewLogFloatFunction(source ValueSource) *LogFloatFunction {
+	ans := new(LogFloatFunction)
+	ans.SimpleFloatFunction = NewSimpleFloatFunction(ans, source)
+	return ans
+}
+
+func (f *LogFloatFunction) Name() string {
+	return "log"
+}
+
+func (f *LogFloatFunction) Func(doc int, vals FunctionValues) (float32, error) {
+	v, err := vals.FloatVal(doc)
+	return float32(math.Log10(float64(v))), err
+}
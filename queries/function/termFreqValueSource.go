@@ -0,0 +1,109 @@
+package function
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// queries/function/valuesource/TermFreqValueSource.java
+
+/*
+Function that returns the raw term frequency of the given term in
+every document; 0 if the document doesn't contain the term.
+*/
+type TermFreqValueSource struct {
+	field        string
+	val          string
+	indexedField string
+	indexedBytes []byte
+}
+
+func NewTermFreqValueSource(field, val, indexedField string, indexedBytes []byte) *TermFreqValueSource {
+	return &TermFreqValueSource{field, val, indexedField, indexedBytes}
+}
+
+func (vs *TermFreqValueSource) Description() string {
+	return fmt.Sprintf("termfreq(%v,%v)", vs.field, string(vs.indexedBytes))
+}
+
+func (vs *TermFreqValueSource) CreateWeight(context map[interface{}]interface{},
+	searcher *search.IndexSearcher) error {
+	return nil
+}
+
+func (vs *TermFreqValueSource) Values(context map[interface{}]interface{},
+	readerContext *index.AtomicReaderContext) (FunctionValues, error) {
+
+	fields := readerContext.Reader().(index.AtomicReader).Fields()
+	var terms Terms
+	if fields != nil {
+		terms = fields.Terms(vs.indexedField)
+	}
+	ans := &termFreqValues{owner: vs, terms: terms}
+	ans.IntDocValues = NewIntDocValues(ans, vs)
+	if err := ans.reset(); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+func (vs *TermFreqValueSource) String() string {
+	return vs.Description()
+}
+
+type termFreqValues struct {
+	*IntDocValues
+	owner            *TermFreqValueSource
+	terms            Terms
+	docs             DocsEnum // nil if the term doesn't exist
+	atDoc            int
+	lastDocRequested int
+}
+
+func (v *termFreqValues) reset() (err error) {
+	v.docs = nil
+	if v.terms != nil {
+		termsEnum := v.terms.Iterator(nil)
+		var ok bool
+		if ok, err = termsEnum.SeekExact(v.owner.indexedBytes); err != nil {
+			return err
+		}
+		if ok {
+			if v.docs, err = termsEnum.Docs(nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+	v.atDoc = -1
+	v.lastDocRequested = -1
+	return nil
+}
+
+func (v *termFreqValues) IntVal(doc int) (int32, error) {
+	var err error
+	if doc < v.lastDocRequested {
+		// out-of-order access.... reset
+		if err = v.reset(); err != nil {
+			return 0, err
+		}
+	}
+	v.lastDocRequested = doc
+	if v.docs == nil {
+		return 0, nil
+	}
+	if v.atDoc < doc {
+		if v.atDoc, err = v.docs.Advance(doc); err != nil {
+			return 0, err
+		}
+	}
+	if v.atDoc > doc {
+		// term doesn't match this document... either because we hit the
+		// end, or because the next doc is after this doc.
+		return 0, nil
+	}
+	// a match!
+	freq, err := v.docs.Freq()
+	return int32(freq), err
+}
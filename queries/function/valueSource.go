@@ -0,0 +1,69 @@
+package function
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// queries/function/ValueSource.java
+
+/*
+Instantiates FunctionValues for a particular reader.
+
+Often used when creating a FunctionQuery.
+*/
+type ValueSource interface {
+	/*
+		Gets the values for this reader and the context that was
+		previously passed to CreateWeight()
+	*/
+	Values(context map[interface{}]interface{},
+		readerContext *index.AtomicReaderContext) (FunctionValues, error)
+	// description of field, used in explain()
+	Description() string
+	/*
+		Implementations should propagate CreateWeight to sub-ValueSources
+		which can optionally store weight info in the context. The context
+		object will be passed to Values() where this info can be
+		retrieved.
+	*/
+	CreateWeight(context map[interface{}]interface{}, searcher *search.IndexSearcher) error
+}
+
+/*
+Returns a new non-threadsafe context map.
+*/
+func NewContext(searcher *search.IndexSearcher) map[interface{}]interface{} {
+	return map[interface{}]interface{}{"searcher": searcher}
+}
+
+// queries/function/FunctionValues.java
+
+/*
+Represents field values as different types. Normally created via a
+ValueSource for a particular field and reader.
+*/
+type FunctionValues interface {
+	FloatVal(doc int) (float32, error)
+	IntVal(doc int) (int32, error)
+	LongVal(doc int) (int64, error)
+	DoubleVal(doc int) (float64, error)
+	StrVal(doc int) (string, error)
+	// Returns true if there is a value for this document
+	Exists(doc int) (bool, error)
+	Explain(doc int) (search.Explanation, error)
+	ToString(doc int) (string, error)
+}
+
+/* The default explanation: the float value, described by ToString(). */
+func explainValues(vals FunctionValues, doc int) (search.Explanation, error) {
+	value, err := vals.FloatVal(doc)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := vals.ToString(doc)
+	if err != nil {
+		return nil, err
+	}
+	return search.NewExplanation(value, desc), nil
+}
@@ -0,0 +1,154 @@
+package queries
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// queries/TermsFilter.java
+
+/*
+Constructs a filter for docs matching any of the terms added to this
+class. Unlike a RangeFilter this can be used for filtering on multiple
+terms that are not necessarily in a sequence. An example might be a
+collection of primary keys from a database query result or perhaps a
+choice of "category" labels picked by the end user. As a filter, this
+is much faster than the equivalent query (a BooleanQuery with many
+"should" TermQueries)
+*/
+type TermsFilter struct {
+	fields []string            // sorted field names
+	terms  map[string][][]byte // sorted, unique terms per field
+}
+
+/* Creates a new TermsFilter from the given list of terms. */
+func NewTermsFilter(terms ...*index.Term) *TermsFilter {
+	assert2(len(terms) > 0, "You must specify at least one term")
+	ans := &TermsFilter{terms: make(map[string][][]byte)}
+	for _, term := range terms {
+		assert2(term != nil, "Term must not be null")
+		ans.add(term.Field, term.Bytes)
+	}
+	ans.seal()
+	return ans
+}
+
+/* Creates a new TermsFilter from the given bytes list for a single field. */
+func NewTermsFilterForField(field string, terms ...[]byte) *TermsFilter {
+	assert2(len(terms) > 0, "You must specify at least one term")
+	ans := &TermsFilter{terms: make(map[string][][]byte)}
+	for _, term := range terms {
+		assert2(term != nil, "Term must not be null")
+		ans.add(field, term)
+	}
+	ans.seal()
+	return ans
+}
+
+func (f *TermsFilter) add(field string, term []byte) {
+	if _, ok := f.terms[field]; !ok {
+		f.fields = append(f.fields, field)
+	}
+	f.terms[field] = append(f.terms[field], term)
+}
+
+/* sorts fields and terms, and removes duplicated terms */
+func (f *TermsFilter) seal() {
+	sort.Strings(f.fields)
+	for field, terms := range f.terms {
+		sort.Sort(byteSlices(terms))
+		unique := terms[:1]
+		for _, term := range terms[1:] {
+			if !bytes.Equal(term, unique[len(unique)-1]) {
+				unique = append(unique, term)
+			}
+		}
+		f.terms[field] = unique
+	}
+}
+
+func (f *TermsFilter) DocIdSet(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (search.DocIdSet, error) {
+
+	reader := context.Reader().(index.AtomicReader)
+	var result *util.FixedBitSet // lazy init if needed - no need to create a big bitset ahead of time
+	fields := reader.Fields()
+	if fields == nil {
+		return nil, nil
+	}
+	var termsEnum TermsEnum
+	var docs DocsEnum
+	for _, field := range f.fields {
+		terms := fields.Terms(field)
+		if terms == nil {
+			continue
+		}
+		termsEnum = terms.Iterator(termsEnum)
+		for _, term := range f.terms[field] {
+			ok, err := termsEnum.SeekExact(term)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if docs, err = termsEnum.Docs(acceptDocs, docs); err != nil {
+				return nil, err
+			}
+			if result == nil {
+				doc, err := docs.NextDoc()
+				if err != nil {
+					return nil, err
+				}
+				if doc != NO_MORE_DOCS {
+					result = util.NewFixedBitSetOf(reader.MaxDoc())
+					// lazy init but don't do it in the hot loop since we
+					// could read many docs
+					result.Set(doc)
+					if err = result.Or(docs); err != nil {
+						return nil, err
+					}
+				}
+			} else if err = result.Or(docs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func (f *TermsFilter) String() string {
+	var buf bytes.Buffer
+	for _, field := range f.fields {
+		for _, term := range f.terms[field] {
+			if buf.Len() > 0 {
+				buf.WriteRune(' ')
+			}
+			buf.WriteString(field)
+			buf.WriteRune(':')
+			buf.Write(term)
+		}
+	}
+	return buf.String()
+}
+
+type byteSlices [][]byte
+
+func (s byteSlices) Len() int           { return len(s) }
+func (s byteSlices) Less(i, j int) bool { return bytes.Compare(s[i], s[j]) < 0 }
+func (s byteSlices) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
@@ -0,0 +1,234 @@
+package classic
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// queryparser/classic/MultiFieldQueryParser.java
+
+/*
+A QueryParser which constructs queries to search multiple fields.
+
+Terms that are not qualified by a field are expanded into one query
+per field. By default these are combined into a BooleanQuery, so that
+e.g. "apple" with the fields title and body is parsed as
+
+	(title:apple body:apple)
+
+If SetUseDisjunctionMax(true) is set, they are combined into a
+DisjunctionMaxQuery instead, so that a term is scored by the field it
+matches best:
+
+	(title:apple | body:apple)
+
+The default operator is applied to the expanded terms as a whole, so
+with OP_AND "apple banana" becomes
+
+	+(title:apple body:apple) +(title:banana body:banana)
+*/
+type MultiFieldQueryParser struct {
+	*QueryParser
+	fields []string
+	boosts map[string]float32
+
+	useDisMax            bool
+	tieBreakerMultiplier float32
+}
+
+/*
+Creates a MultiFieldQueryParser, which expands terms without field
+across the given fields.
+*/
+func NewMultiFieldQueryParser(matchVersion util.Version, fields []string,
+	analyzer analysis.Analyzer) *MultiFieldQueryParser {
+
+	ans := &MultiFieldQueryParser{fields: fields}
+	ans.QueryParser = NewQueryParser(matchVersion, "", analyzer)
+	// dispatch the query factory methods to the multi field versions
+	ans.spi = ans
+	return ans
+}
+
+/*
+Creates a MultiFieldQueryParser, and allows passing of a map with
+term to boost, and the boost to apply to each term.
+
+It will, when Parse(query) is called, construct a query like this
+(assuming the query consists of two terms and you specify the two
+fields title and body):
+
+	(title:term1 body:term1) (title:term2 body:term2)
+
+When setDefaultOperator(OP_AND) is set, the result will be:
+
+	+(title:term1 body:term1) +(title:term2 body:term2)
+
+When you pass a boost (title=>5 body=>10) you can get
+
+	+(title:term1^5 body:term1^10) +(title:term2^5 body:term2^10)
+
+In other words, all the query's terms must appear, but it doesn't
+matter in what fields they appear.
+*/
+func NewMultiFieldQueryParserWithBoosts(matchVersion util.Version, fields []string,
+	analyzer analysis.Analyzer, boosts map[string]float32) *MultiFieldQueryParser {
+
+	ans := NewMultiFieldQueryParser(matchVersion, fields, analyzer)
+	ans.boosts = boosts
+	return ans
+}
+
+/* Returns the fields unqualified terms are expanded across. */
+func (qp *MultiFieldQueryParser) Fields() []string {
+	return qp.fields
+}
+
+/*
+Sets whether the queries of a term in the different fields are
+combined into a DisjunctionMaxQuery, rather than a BooleanQuery.
+Default is false.
+*/
+func (qp *MultiFieldQueryParser) SetUseDisjunctionMax(useDisMax bool) {
+	qp.useDisMax = useDisMax
+}
+
+func (qp *MultiFieldQueryParser) UseDisjunctionMax() bool {
+	return qp.useDisMax
+}
+
+/*
+Sets the tie breaker multiplier of the DisjunctionMaxQuery's created
+when SetUseDisjunctionMax(true) is set. Default is 0, which scores a
+term by the best matching field alone.
+*/
+func (qp *MultiFieldQueryParser) SetTieBreakerMultiplier(tieBreakerMultiplier float32) {
+	qp.tieBreakerMultiplier = tieBreakerMultiplier
+}
+
+func (qp *MultiFieldQueryParser) TieBreakerMultiplier() float32 {
+	return qp.tieBreakerMultiplier
+}
+
+func (qp *MultiFieldQueryParser) fieldQueryWithSlop(field, queryText string, slop int) (search.Query, error) {
+	if field == "" {
+		var queries []search.Query
+		for _, f := range qp.fields {
+			q, err := qp.QueryParserBase.fieldQuery(f, queryText, true)
+			if err != nil {
+				return nil, err
+			}
+			if q != nil {
+				qp.applyBoost(f, q)
+				applySlop(q, slop)
+				queries = append(queries, q)
+			}
+		}
+		return qp.combine(queries)
+	}
+	return qp.QueryParserBase.fieldQueryWithSlop(field, queryText, slop)
+}
+
+func (qp *MultiFieldQueryParser) fieldQuery(field, queryText string, quoted bool) (search.Query, error) {
+	if field == "" {
+		var queries []search.Query
+		for _, f := range qp.fields {
+			q, err := qp.QueryParserBase.fieldQuery(f, queryText, quoted)
+			if err != nil {
+				return nil, err
+			}
+			if q != nil {
+				qp.applyBoost(f, q)
+				queries = append(queries, q)
+			}
+		}
+		return qp.combine(queries)
+	}
+	return qp.QueryParserBase.fieldQuery(field, queryText, quoted)
+}
+
+/* If the user passes a map of boosts, gets the boost of the field from it and applies it. */
+func (qp *MultiFieldQueryParser) applyBoost(field string, q search.Query) {
+	if boost, ok := qp.boosts[field]; ok {
+		q.SetBoost(boost)
+	}
+}
+
+func (qp *MultiFieldQueryParser) fuzzyQuery(field, termStr string, minSimilarity float32) (search.Query, error) {
+	if field == "" {
+		return qp.expand(func(f string) (search.Query, error) {
+			return qp.QueryParserBase.fuzzyQuery(f, termStr, minSimilarity)
+		})
+	}
+	return qp.QueryParserBase.fuzzyQuery(field, termStr, minSimilarity)
+}
+
+func (qp *MultiFieldQueryParser) prefixQuery(field, termStr string) (search.Query, error) {
+	if field == "" {
+		return qp.expand(func(f string) (search.Query, error) {
+			return qp.QueryParserBase.prefixQuery(f, termStr)
+		})
+	}
+	return qp.QueryParserBase.prefixQuery(field, termStr)
+}
+
+func (qp *MultiFieldQueryParser) wildcardQuery(field, termStr string) (search.Query, error) {
+	if field == "" {
+		return qp.expand(func(f string) (search.Query, error) {
+			return qp.QueryParserBase.wildcardQuery(f, termStr)
+		})
+	}
+	return qp.QueryParserBase.wildcardQuery(field, termStr)
+}
+
+func (qp *MultiFieldQueryParser) regexpQuery(field, termStr string) (search.Query, error) {
+	if field == "" {
+		return qp.expand(func(f string) (search.Query, error) {
+			return qp.QueryParserBase.regexpQuery(f, termStr)
+		})
+	}
+	return qp.QueryParserBase.regexpQuery(field, termStr)
+}
+
+func (qp *MultiFieldQueryParser) rangeQuery(field string, part1, part2 *string,
+	startInclusive, endInclusive bool) (search.Query, error) {
+
+	if field == "" {
+		return qp.expand(func(f string) (search.Query, error) {
+			return qp.QueryParserBase.rangeQuery(f, part1, part2, startInclusive, endInclusive)
+		})
+	}
+	return qp.QueryParserBase.rangeQuery(field, part1, part2, startInclusive, endInclusive)
+}
+
+/* Creates the query of each field, and combines them. */
+func (qp *MultiFieldQueryParser) expand(query func(field string) (search.Query, error)) (search.Query, error) {
+	queries := make([]search.Query, len(qp.fields))
+	for i, f := range qp.fields {
+		q, err := query(f)
+		if err != nil {
+			return nil, err
+		}
+		queries[i] = q
+	}
+	return qp.combine(queries)
+}
+
+/*
+Combines the queries of a term in the different fields, either into
+a DisjunctionMaxQuery, or into a BooleanQuery of optional clauses.
+*/
+func (qp *MultiFieldQueryParser) combine(queries []search.Query) (search.Query, error) {
+	if len(queries) == 0 { // happens for stopwords
+		return nil, nil
+	}
+	if qp.useDisMax {
+		return search.NewDisjunctionMaxQuery(queries, qp.tieBreakerMultiplier), nil
+	}
+	clauses := make([]*search.BooleanClause, len(queries))
+	for i, q := range queries {
+		clauses[i] = qp.newBooleanClause(q, search.SHOULD)
+	}
+	return qp.booleanQueryDisableCoord(clauses, true)
+}
@@ -0,0 +1,86 @@
+package classic
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// queryparser/classic/ParseException.java
+
+/*
+This error is returned when parse errors are encountered. Line and
+Column locate the offending token in the query string; both are zero
+if the error is not tied to any position.
+*/
+type ParseError struct {
+	Line, Column int
+	msg          string
+}
+
+/*
+Generates a ParseError from the last successfully consumed token and
+the token kinds that were expected instead of the one following it.
+*/
+func newParseErrorFromToken(currentToken *Token, expectedTokenSequences [][]int) *ParseError {
+	var expected bytes.Buffer
+	maxSize := 0
+	for _, seq := range expectedTokenSequences {
+		if maxSize < len(seq) {
+			maxSize = len(seq)
+		}
+		for _, kind := range seq {
+			expected.WriteString(tokenImage[kind])
+			expected.WriteRune(' ')
+		}
+		if seq[len(seq)-1] != 0 {
+			expected.WriteString("...")
+		}
+		expected.WriteString("\n    ")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("Encountered \"")
+	tok := currentToken.next
+	for i := 0; i < maxSize; i++ {
+		if i != 0 {
+			buf.WriteRune(' ')
+		}
+		if tok.kind == 0 {
+			buf.WriteString(tokenImage[0])
+			break
+		}
+		fmt.Fprintf(&buf, " %v \"%v \"", tokenImage[tok.kind], addEscapes(tok.image))
+		tok = tok.next
+	}
+	next := currentToken.next
+	fmt.Fprintf(&buf, "\" at line %v, column %v.\n", next.beginLine, next.beginColumn)
+	if len(expectedTokenSequences) == 1 {
+		buf.WriteString("Was expecting:\n    ")
+	} else {
+		buf.WriteString("Was expecting one of:\n    ")
+	}
+	buf.Write(expected.Bytes())
+	return &ParseError{next.beginLine, next.beginColumn, buf.String()}
+}
+
+func newParseError(msg string) *ParseError {
+	return &ParseError{msg: msg}
+}
+
+func (err *ParseError) Error() string {
+	return err.msg
+}
+
+/*
+Locates err at the given token, unless it is already a positioned
+ParseError.
+*/
+func atToken(err error, t *Token) error {
+	if pe, ok := err.(*ParseError); ok {
+		if pe.Line == 0 && pe.Column == 0 {
+			pe.Line, pe.Column = t.beginLine, t.beginColumn
+		}
+		return pe
+	}
+	return &ParseError{t.beginLine, t.beginColumn, err.Error()}
+}
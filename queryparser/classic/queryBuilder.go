@@ -19,6 +19,37 @@ func newQueryBuilder() *QueryBuilder {
 	}
 }
 
+/* Creates a new QueryBuilder using the given analyzer. */
+func NewQueryBuilder(analyzer analysis.Analyzer) *QueryBuilder {
+	ans := newQueryBuilder()
+	ans.analyzer = analyzer
+	return ans
+}
+
+// L76
+/*
+Creates a boolean query from the query text, combining the terms
+with operator, which should be either search.SHOULD or search.MUST.
+
+Returns nil if the analysis of the query text produces no terms.
+*/
+func (qp *QueryBuilder) CreateBooleanQuery(field, queryText string, operator search.Occur) search.Query {
+	if operator != search.SHOULD && operator != search.MUST {
+		panic("invalid operator: only SHOULD or MUST are allowed")
+	}
+	return qp.createFieldQuery(qp.analyzer, operator, field, queryText, false, 0)
+}
+
+// L100
+/*
+Creates a phrase query from the query text, with the specified slop.
+
+Returns nil if the analysis of the query text produces no terms.
+*/
+func (qp *QueryBuilder) CreatePhraseQuery(field, queryText string, phraseSlop int) search.Query {
+	return qp.createFieldQuery(qp.analyzer, search.MUST, field, queryText, true, phraseSlop)
+}
+
 // L193
 func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 	operator search.Occur, field, queryText string, quoted bool, phraseSlop int) search.Query {
@@ -94,7 +125,20 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 				// no phrase query:
 
 				if positionCount == 1 {
-					panic("not implemented yet")
+					// simple case: only one position, with synonyms
+					q := qp.newBooleanQuery(true)
+					for i := 0; i < numTokens; i++ {
+						hasNext, err := buffer.IncrementToken()
+						if err != nil {
+							continue // safe to ignore error, because we know the number of tokens
+						}
+						assert(hasNext)
+						termAtt.FillBytesRef()
+
+						currentQuery := qp.newTermQuery(index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes()))
+						q.Add(currentQuery, search.SHOULD)
+					}
+					return q
 				} else {
 					// multiple positions
 					q := qp.newBooleanQuery(false)
@@ -108,7 +152,14 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 						termAtt.FillBytesRef()
 
 						if posIncrAtt != nil && posIncrAtt.PositionIncrement() == 0 {
-							panic("not implemented yet")
+							bq, ok := currentQuery.(*search.BooleanQuery)
+							if !ok {
+								t := currentQuery
+								bq = qp.newBooleanQuery(true)
+								bq.Add(t, search.SHOULD)
+								currentQuery = bq
+							}
+							bq.Add(qp.newTermQuery(index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes())), search.SHOULD)
 						} else {
 							if currentQuery != nil {
 								q.Add(currentQuery, operator)
@@ -120,10 +171,68 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 					return q
 				}
 			} else {
-				panic("not implemented yet")
+				// phrase query:
+				mpq := qp.newMultiPhraseQuery()
+				mpq.SetSlop(phraseSlop)
+				var multiTerms []*index.Term
+				position := -1
+				for i := 0; i < numTokens; i++ {
+					hasNext, err := buffer.IncrementToken()
+					if err != nil {
+						continue // safe to ignore error, because we know the number of tokens
+					}
+					assert(hasNext)
+					termAtt.FillBytesRef()
+					positionIncrement := 1
+					if posIncrAtt != nil {
+						positionIncrement = posIncrAtt.PositionIncrement()
+					}
+
+					if positionIncrement > 0 && len(multiTerms) > 0 {
+						if qp.enablePositionIncrements {
+							mpq.AddAt(multiTerms, position)
+						} else {
+							mpq.Add(multiTerms...)
+						}
+						multiTerms = nil
+					}
+					position += positionIncrement
+					multiTerms = append(multiTerms, index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes()))
+				}
+				if qp.enablePositionIncrements {
+					mpq.AddAt(multiTerms, position)
+				} else {
+					mpq.Add(multiTerms...)
+				}
+				return mpq
 			}
 		} else {
-			panic("not implemented yet")
+			// phrase query:
+			pq := qp.newPhraseQuery()
+			pq.SetSlop(phraseSlop)
+			position := -1
+
+			for i := 0; i < numTokens; i++ {
+				hasNext, err := buffer.IncrementToken()
+				if err != nil {
+					continue // safe to ignore error, because we know the number of tokens
+				}
+				assert(hasNext)
+				termAtt.FillBytesRef()
+				positionIncrement := 1
+				if posIncrAtt != nil {
+					positionIncrement = posIncrAtt.PositionIncrement()
+				}
+
+				term := index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes())
+				if qp.enablePositionIncrements {
+					position += positionIncrement
+					pq.AddAt(term, position)
+				} else {
+					pq.Add(term)
+				}
+			}
+			return pq
 		}
 		panic("should not be here")
 	}
@@ -137,3 +246,11 @@ func (qp *QueryBuilder) newBooleanQuery(disableCoord bool) *search.BooleanQuery
 func (qp *QueryBuilder) newTermQuery(term *index.Term) search.Query {
 	return search.NewTermQuery(term)
 }
+
+func (qp *QueryBuilder) newPhraseQuery() *search.PhraseQuery {
+	return search.NewPhraseQuery()
+}
+
+func (qp *QueryBuilder) newMultiPhraseQuery() *search.MultiPhraseQuery {
+	return search.NewMultiPhraseQuery()
+}
@@ -1,8 +1,6 @@
 package classic
 
 import (
-	"errors"
-	// "fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
 	"github.com/balzaczyy/golucene/core/search"
 	"github.com/balzaczyy/golucene/core/util"
@@ -16,6 +14,27 @@ var (
 	OP_AND = Operator(2)
 )
 
+/*
+This class is generated by JavaCC. The most important method is
+Parse().
+
+The syntax for query strings is as follows: A Query is a series of
+clauses. A clause may be prefixed by a plus (+) or a minus (-) sign,
+indicating that the clause is required or prohibited respectively;
+or a term followed by a colon, indicating the field to be searched.
+A clause may be either a term, or a nested query enclosed in
+parentheses.
+
+Thus, in BNF, the query grammar is:
+
+	Query  ::= ( Clause )*
+	Clause ::= ["+", "-"] [<TERM> ":"] ( <TERM> | "(" Query ")" )
+
+Malformed input is reported as a *ParseError carrying the line and
+column of the offending token.
+
+Note that QueryParser is not thread-safe.
+*/
 type QueryParser struct {
 	*QueryParserBase
 
@@ -31,13 +50,17 @@ type QueryParser struct {
 	jj_2_rtns []*JJCalls
 	jj_rescan bool
 	jj_gc     int
+
+	jj_kind int
 }
 
+/* Create a query parser. */
 func NewQueryParser(matchVersion util.Version, f string, a analysis.Analyzer) *QueryParser {
 	qp := &QueryParser{
 		token_source: newTokenManager(newFastCharStream(strings.NewReader(""))),
 		jj_la1:       make([]int, 21),
 		jj_2_rtns:    make([]*JJCalls, 1),
+		jj_kind:      -1,
 	}
 	qp.QueryParserBase = newQueryParserBase(qp)
 	qp.ReInit(newFastCharStream(strings.NewReader("")))
@@ -55,7 +78,25 @@ func (qp *QueryParser) conjunction() (int, error) {
 	}
 	switch qp.jj_ntk {
 	case AND, OR:
-		panic("niy")
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case AND:
+			if _, err := qp.jj_consume_token(AND); err != nil {
+				return 0, err
+			}
+			ret = CONJ_AND
+		case OR:
+			if _, err := qp.jj_consume_token(OR); err != nil {
+				return 0, err
+			}
+			ret = CONJ_OR
+		default:
+			qp.jj_la1[0] = qp.jj_gen
+			_, err := qp.jj_consume_token(-1)
+			return 0, err
+		}
 	default:
 		qp.jj_la1[1] = qp.jj_gen
 	}
@@ -69,13 +110,37 @@ func (qp *QueryParser) modifiers() (ret int, err error) {
 	}
 	switch qp.jj_ntk {
 	case NOT, PLUS, MINUS:
-		panic("not implemented yet")
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case PLUS:
+			if _, err = qp.jj_consume_token(PLUS); err != nil {
+				return
+			}
+			ret = MOD_REQ
+		case MINUS:
+			if _, err = qp.jj_consume_token(MINUS); err != nil {
+				return
+			}
+			ret = MOD_NOT
+		case NOT:
+			if _, err = qp.jj_consume_token(NOT); err != nil {
+				return
+			}
+			ret = MOD_NOT
+		default:
+			qp.jj_la1[2] = qp.jj_gen
+			_, err = qp.jj_consume_token(-1)
+			return
+		}
 	default:
 		qp.jj_la1[3] = qp.jj_gen
 	}
 	return
 }
 
+/* This makes sure that there is no garbage after the query string */
 func (qp *QueryParser) TopLevelQuery(field string) (q search.Query, err error) {
 	if q, err = qp.Query(field); err != nil {
 		return nil, err
@@ -127,19 +192,44 @@ func (qp *QueryParser) Query(field string) (q search.Query, err error) {
 	}
 	if len(clauses) == 1 && firstQuery != nil {
 		return firstQuery, nil
-	} else {
-		return qp.booleanQuery(clauses)
 	}
+	return qp.booleanQuery(clauses)
 }
 
 func (qp *QueryParser) clause(field string) (q search.Query, err error) {
+	var fieldToken, boost *Token
 	if qp.jj_2_1(2) {
-		panic("not implemented yet")
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case TERM:
+			if fieldToken, err = qp.jj_consume_token(TERM); err != nil {
+				return nil, err
+			}
+			if _, err = qp.jj_consume_token(COLON); err != nil {
+				return nil, err
+			}
+			if field, err = qp.discardEscapeChar(fieldToken.image); err != nil {
+				return nil, atToken(err, fieldToken)
+			}
+		case STAR:
+			if _, err = qp.jj_consume_token(STAR); err != nil {
+				return nil, err
+			}
+			if _, err = qp.jj_consume_token(COLON); err != nil {
+				return nil, err
+			}
+			field = "*"
+		default:
+			qp.jj_la1[5] = qp.jj_gen
+			_, err = qp.jj_consume_token(-1)
+			return nil, err
+		}
 	}
 	if qp.jj_ntk == -1 {
 		qp.get_jj_ntk()
 	}
-	var boost *Token
 	switch qp.jj_ntk {
 	case BAREOPER, STAR, QUOTED, TERM, PREFIXTERM, WILDTERM,
 		REGEXPTERM, RANGEIN_START, RANGEEX_START, NUMBER:
@@ -147,20 +237,45 @@ func (qp *QueryParser) clause(field string) (q search.Query, err error) {
 			return nil, err
 		}
 	case LPAREN:
-		panic("not implemented yet")
-	default:
-		qp.jj_la1[7] = qp.jj_gen
-		if _, err = qp.jj_consume_token(-1); err != nil {
+		if _, err = qp.jj_consume_token(LPAREN); err != nil {
+			return nil, err
+		}
+		if q, err = qp.Query(field); err != nil {
 			return nil, err
 		}
-		return nil, errors.New("parse error")
+		if _, err = qp.jj_consume_token(RPAREN); err != nil {
+			return nil, err
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case CARAT:
+			if boost, err = qp.carat(); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[6] = qp.jj_gen
+		}
+	default:
+		qp.jj_la1[7] = qp.jj_gen
+		_, err = qp.jj_consume_token(-1)
+		return nil, err
 	}
 	return qp.handleBoost(q, boost), nil
 }
 
+/* Consumes a boost, i.e. CARAT followed by NUMBER, and returns the NUMBER. */
+func (qp *QueryParser) carat() (boost *Token, err error) {
+	if _, err = qp.jj_consume_token(CARAT); err != nil {
+		return nil, err
+	}
+	return qp.jj_consume_token(NUMBER)
+}
+
 func (qp *QueryParser) term(field string) (q search.Query, err error) {
-	var term, boost, fuzzySlop /*, goop1, goop2*/ *Token
-	var prefix, wildcard, fuzzy, regexp /*, startInc, endInc*/ bool
+	var term, boost, fuzzySlop, goop1, goop2 *Token
+	var prefix, wildcard, fuzzy, regexp, startInc, endInc bool
 	if qp.jj_ntk == -1 {
 		qp.get_jj_ntk()
 	}
@@ -171,30 +286,41 @@ func (qp *QueryParser) term(field string) (q search.Query, err error) {
 		}
 		switch qp.jj_ntk {
 		case TERM:
-			if term, err = qp.jj_consume_token(TERM); err != nil {
-				return nil, err
-			}
+			term, err = qp.jj_consume_token(TERM)
 		case STAR:
-			panic("not implemented yet")
+			term, err = qp.jj_consume_token(STAR)
+			wildcard = true
 		case PREFIXTERM:
-			panic("not implemented yet")
+			term, err = qp.jj_consume_token(PREFIXTERM)
+			prefix = true
 		case WILDTERM:
-			panic("not implemented yet")
+			term, err = qp.jj_consume_token(WILDTERM)
+			wildcard = true
 		case REGEXPTERM:
-			panic("not implemented yet")
+			term, err = qp.jj_consume_token(REGEXPTERM)
+			regexp = true
 		case NUMBER:
-			panic("not implemented yet")
+			term, err = qp.jj_consume_token(NUMBER)
 		case BAREOPER:
-			panic("not implemented yet")
+			if term, err = qp.jj_consume_token(BAREOPER); err == nil {
+				term.image = term.image[:1]
+			}
 		default:
-			panic("not implemented yet")
+			qp.jj_la1[8] = qp.jj_gen
+			_, err = qp.jj_consume_token(-1)
+		}
+		if err != nil {
+			return nil, err
 		}
 		if qp.jj_ntk == -1 {
 			qp.get_jj_ntk()
 		}
 		switch qp.jj_ntk {
 		case FUZZY_SLOP:
-			panic("not implemented yet")
+			if fuzzySlop, err = qp.jj_consume_token(FUZZY_SLOP); err != nil {
+				return nil, err
+			}
+			fuzzy = true
 		default:
 			qp.jj_la1[9] = qp.jj_gen
 		}
@@ -203,33 +329,187 @@ func (qp *QueryParser) term(field string) (q search.Query, err error) {
 		}
 		switch qp.jj_ntk {
 		case CARAT:
-			panic("not implemented yet")
+			if boost, err = qp.carat(); err != nil {
+				return nil, err
+			}
+			if qp.jj_ntk == -1 {
+				qp.get_jj_ntk()
+			}
+			switch qp.jj_ntk {
+			case FUZZY_SLOP:
+				if fuzzySlop, err = qp.jj_consume_token(FUZZY_SLOP); err != nil {
+					return nil, err
+				}
+				fuzzy = true
+			default:
+				qp.jj_la1[10] = qp.jj_gen
+			}
 		default:
 			qp.jj_la1[11] = qp.jj_gen
 		}
 		if q, err = qp.handleBareTokenQuery(field, term, fuzzySlop, prefix, wildcard, fuzzy, regexp); err != nil {
-			return nil, err
+			return nil, atToken(err, term)
 		}
 
 	case RANGEIN_START, RANGEEX_START:
-		panic("not implemented yet")
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case RANGEIN_START:
+			if _, err = qp.jj_consume_token(RANGEIN_START); err != nil {
+				return nil, err
+			}
+			startInc = true
+		case RANGEEX_START:
+			if _, err = qp.jj_consume_token(RANGEEX_START); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[12] = qp.jj_gen
+			_, err = qp.jj_consume_token(-1)
+			return nil, err
+		}
+		if goop1, err = qp.rangeGoop(13); err != nil {
+			return nil, err
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case RANGE_TO:
+			if _, err = qp.jj_consume_token(RANGE_TO); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[14] = qp.jj_gen
+		}
+		if goop2, err = qp.rangeGoop(15); err != nil {
+			return nil, err
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case RANGEIN_END:
+			if _, err = qp.jj_consume_token(RANGEIN_END); err != nil {
+				return nil, err
+			}
+			endInc = true
+		case RANGEEX_END:
+			if _, err = qp.jj_consume_token(RANGEEX_END); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[16] = qp.jj_gen
+			_, err = qp.jj_consume_token(-1)
+			return nil, err
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case CARAT:
+			if boost, err = qp.carat(); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[17] = qp.jj_gen
+		}
+		var startOpen, endOpen bool
+		if goop1.kind == RANGE_QUOTED {
+			goop1.image = goop1.image[1 : len(goop1.image)-1]
+		} else if goop1.image == "*" {
+			startOpen = true
+		}
+		if goop2.kind == RANGE_QUOTED {
+			goop2.image = goop2.image[1 : len(goop2.image)-1]
+		} else if goop2.image == "*" {
+			endOpen = true
+		}
+		var part1, part2 *string
+		if !startOpen {
+			var s string
+			if s, err = qp.discardEscapeChar(goop1.image); err != nil {
+				return nil, atToken(err, goop1)
+			}
+			part1 = &s
+		}
+		if !endOpen {
+			var s string
+			if s, err = qp.discardEscapeChar(goop2.image); err != nil {
+				return nil, atToken(err, goop2)
+			}
+			part2 = &s
+		}
+		if q, err = qp.spi.rangeQuery(field, part1, part2, startInc, endInc); err != nil {
+			return nil, err
+		}
+
 	case QUOTED:
-		panic("not implemented yet")
+		if term, err = qp.jj_consume_token(QUOTED); err != nil {
+			return nil, err
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case FUZZY_SLOP:
+			if fuzzySlop, err = qp.jj_consume_token(FUZZY_SLOP); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[18] = qp.jj_gen
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case CARAT:
+			if boost, err = qp.carat(); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[19] = qp.jj_gen
+		}
+		if q, err = qp.handleQuotedTerm(field, term, fuzzySlop); err != nil {
+			return nil, atToken(err, term)
+		}
+
 	default:
-		panic("not implemented yet")
+		qp.jj_la1[20] = qp.jj_gen
+		_, err = qp.jj_consume_token(-1)
+		return nil, err
 	}
 	return qp.handleBoost(q, boost), nil
 }
 
+/* Consumes one endpoint of a range, either a RANGE_GOOP or a RANGE_QUOTED. */
+func (qp *QueryParser) rangeGoop(la1 int) (goop *Token, err error) {
+	if qp.jj_ntk == -1 {
+		qp.get_jj_ntk()
+	}
+	switch qp.jj_ntk {
+	case RANGE_GOOP:
+		return qp.jj_consume_token(RANGE_GOOP)
+	case RANGE_QUOTED:
+		return qp.jj_consume_token(RANGE_QUOTED)
+	default:
+		qp.jj_la1[la1] = qp.jj_gen
+		return qp.jj_consume_token(-1)
+	}
+}
+
 // L473
 func (qp *QueryParser) jj_2_1(xla int) (ok bool) {
 	qp.jj_la = xla
 	qp.jj_lastpos = qp.token
 	qp.jj_scanpos = qp.token
 	defer func() {
-		// Disable following recover() to deal with dev panic
 		if err := recover(); err == lookAheadSuccess {
 			ok = true
+		} else if err != nil {
+			panic(err)
 		}
 		qp.jj_save(0, xla)
 	}()
@@ -257,7 +537,47 @@ func (qp *QueryParser) jj_3R_3() bool {
 		qp.jj_scan_token(COLON)
 }
 
+/*
+Expected token kinds at each choice point, indexed as jj_la1. Only
+used to report what was expected when the parser fails.
+*/
+var jj_la1_0 = []int64{
+	jjKinds(AND, OR),
+	jjKinds(AND, OR),
+	jjKinds(NOT, PLUS, MINUS),
+	jjKinds(NOT, PLUS, MINUS),
+	jjKinds(AND, OR, NOT, PLUS, MINUS, BAREOPER, LPAREN, STAR, QUOTED,
+		TERM, PREFIXTERM, WILDTERM, REGEXPTERM, RANGEIN_START,
+		RANGEEX_START, NUMBER),
+	jjKinds(TERM, STAR),
+	jjKinds(CARAT),
+	jjKinds(BAREOPER, LPAREN, STAR, QUOTED, TERM, PREFIXTERM, WILDTERM,
+		REGEXPTERM, RANGEIN_START, RANGEEX_START, NUMBER),
+	jjKinds(BAREOPER, STAR, TERM, PREFIXTERM, WILDTERM, REGEXPTERM, NUMBER),
+	jjKinds(FUZZY_SLOP),
+	jjKinds(FUZZY_SLOP),
+	jjKinds(CARAT),
+	jjKinds(RANGEIN_START, RANGEEX_START),
+	jjKinds(RANGE_GOOP, RANGE_QUOTED),
+	jjKinds(RANGE_TO),
+	jjKinds(RANGE_GOOP, RANGE_QUOTED),
+	jjKinds(RANGEIN_END, RANGEEX_END),
+	jjKinds(CARAT),
+	jjKinds(FUZZY_SLOP),
+	jjKinds(CARAT),
+	jjKinds(BAREOPER, STAR, QUOTED, TERM, PREFIXTERM, WILDTERM,
+		REGEXPTERM, RANGEIN_START, RANGEEX_START, NUMBER),
+}
+
+func jjKinds(kinds ...int) (mask int64) {
+	for _, kind := range kinds {
+		mask |= int64(1) << uint(kind)
+	}
+	return
+}
+
 // L540
+/* Reinitialise. */
 func (qp *QueryParser) ReInit(stream CharStream) {
 	qp.token_source.ReInit(stream)
 	qp.token = new(Token)
@@ -285,12 +605,19 @@ func (qp *QueryParser) jj_consume_token(kind int) (*Token, error) {
 		qp.jj_gen++
 		if qp.jj_gc++; qp.jj_gc > 100 {
 			qp.jj_gc = 0
-			panic("not implemented yet")
+			for _, c := range qp.jj_2_rtns {
+				for ; c != nil; c = c.next {
+					if c.gen < qp.jj_gen {
+						c.first = nil
+					}
+				}
+			}
 		}
 		return qp.token, nil
 	}
 	qp.token = oldToken
-	panic("not implemented yet")
+	qp.jj_kind = kind
+	return nil, qp.generateParseError()
 }
 
 type LookAheadSuccess bool
@@ -307,7 +634,7 @@ func (qp *QueryParser) jj_scan_token(kind int) bool {
 			qp.jj_lastpos = nextToken
 		} else {
 			qp.jj_scanpos = qp.jj_scanpos.next
-			qp.jj_lastpos = qp.jj_scanpos.next
+			qp.jj_lastpos = qp.jj_scanpos
 		}
 	} else {
 		qp.jj_scanpos = qp.jj_scanpos.next
@@ -335,14 +662,40 @@ func (qp *QueryParser) get_jj_ntk() int {
 	return qp.jj_ntk
 }
 
+// L652
+/* Generate ParseError. */
+func (qp *QueryParser) generateParseError() *ParseError {
+	var la1tokens [len(tokenImage)]bool
+	if qp.jj_kind >= 0 {
+		la1tokens[qp.jj_kind] = true
+		qp.jj_kind = -1
+	}
+	for i, gen := range qp.jj_la1 {
+		if gen == qp.jj_gen {
+			for j := range la1tokens {
+				if jj_la1_0[i]&(int64(1)<<uint(j)) != 0 {
+					la1tokens[j] = true
+				}
+			}
+		}
+	}
+	var expected [][]int
+	for i, ok := range la1tokens {
+		if ok {
+			expected = append(expected, []int{i})
+		}
+	}
+	return newParseErrorFromToken(qp.token, expected)
+}
+
 // L738
 
 func (qp *QueryParser) jj_save(index, xla int) {
 	p := qp.jj_2_rtns[index]
 	for p.gen > qp.jj_gen {
 		if p.next == nil {
-			p = new(JJCalls)
-			p.next = p
+			p.next = new(JJCalls)
+			p = p.next
 			break
 		}
 		p = p.next
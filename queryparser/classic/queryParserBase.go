@@ -1,11 +1,16 @@
 package classic
 
 import (
-	"errors"
+	"bytes"
 	"fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
 	"github.com/balzaczyy/golucene/core/search"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -23,6 +28,15 @@ const (
 type QueryParserBaseSPI interface {
 	ReInit(CharStream)
 	TopLevelQuery(string) (search.Query, error)
+
+	// factory methods for the queries of the individual clauses
+	fieldQuery(field, queryText string, quoted bool) (search.Query, error)
+	fieldQueryWithSlop(field, queryText string, slop int) (search.Query, error)
+	fuzzyQuery(field, termStr string, minSimilarity float32) (search.Query, error)
+	prefixQuery(field, termStr string) (search.Query, error)
+	wildcardQuery(field, termStr string) (search.Query, error)
+	regexpQuery(field, termStr string) (search.Query, error)
+	rangeQuery(field string, part1, part2 *string, startInclusive, endInclusive bool) (search.Query, error)
 }
 
 type QueryParserBase struct {
@@ -36,6 +50,19 @@ type QueryParserBase struct {
 	phraseSlop int
 
 	autoGeneratePhraseQueries bool
+	allowLeadingWildcard      bool
+
+	multiTermRewriteMethod search.RewriteMethod
+	lowercaseExpandedTerms bool
+	fuzzyMinSim            float32
+	fuzzyPrefixLength      int
+
+	// the default date resolution
+	dateResolution *docu.Resolution
+	// maps field names to date resolutions
+	fieldToDateResolution map[string]docu.Resolution
+	// the time zone range dates are parsed in
+	timeZone *time.Location
 }
 
 func newQueryParserBase(spi QueryParserBaseSPI) *QueryParserBase {
@@ -43,14 +70,40 @@ func newQueryParserBase(spi QueryParserBaseSPI) *QueryParserBase {
 		QueryBuilder: newQueryBuilder(),
 		spi:          spi,
 		operator:     OP_OR,
+
+		multiTermRewriteMethod: search.CONSTANT_SCORE_FILTER_REWRITE,
+		lowercaseExpandedTerms: true,
+		timeZone:               time.Local,
+		fuzzyMinSim:            search.FUZZY_DEFAULT_MAX_EDITS,
+		fuzzyPrefixLength:      search.FUZZY_DEFAULT_PREFIX_LENGTH,
 	}
 }
 
 // L116
+/*
+Parses a query string, returning a Query. Malformed query strings are
+reported as a *ParseError.
+*/
 func (qp *QueryParserBase) Parse(query string) (res search.Query, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// lexical errors are raised by the token manager
+			tme, ok := r.(*TokenManagerError)
+			if !ok {
+				panic(r)
+			}
+			res, err = nil, &ParseError{tme.Line, tme.Column,
+				fmt.Sprintf("Cannot parse '%v': %v", query, tme)}
+		}
+	}()
+
 	qp.spi.ReInit(newFastCharStream(strings.NewReader(query)))
 	if res, err = qp.spi.TopLevelQuery(qp.field); err != nil {
-		return nil, errors.New(fmt.Sprintf("Cannot parse '%v': %v", query, err))
+		var line, column int
+		if pe, ok := err.(*ParseError); ok {
+			line, column = pe.Line, pe.Column
+		}
+		return nil, &ParseError{line, column, fmt.Sprintf("Cannot parse '%v': %v", query, err)}
 	}
 	if res != nil {
 		return res, nil
@@ -58,6 +111,179 @@ func (qp *QueryParserBase) Parse(query string) (res search.Query, err error) {
 	return qp.newBooleanQuery(false), nil
 }
 
+/*
+Returns the default field, used for query terms that do not specify
+one.
+*/
+func (qp *QueryParserBase) Field() string {
+	return qp.field
+}
+
+/*
+Set to true if phrase queries will be automatically generated when
+the analyzer returns more than one term from whitespace delimited
+text. NOTE: this behavior may not be suitable for all languages.
+
+Set to false if phrase queries should only be generated when
+surrounded by double quotes.
+*/
+func (qp *QueryParserBase) SetAutoGeneratePhraseQueries(value bool) {
+	qp.autoGeneratePhraseQueries = value
+}
+
+func (qp *QueryParserBase) AutoGeneratePhraseQueries() bool {
+	return qp.autoGeneratePhraseQueries
+}
+
+/* Returns the minimal similarity for fuzzy queries. */
+func (qp *QueryParserBase) FuzzyMinSim() float32 {
+	return qp.fuzzyMinSim
+}
+
+/*
+Set the minimum similarity for fuzzy queries. Default is 2.
+*/
+func (qp *QueryParserBase) SetFuzzyMinSim(fuzzyMinSim float32) {
+	qp.fuzzyMinSim = fuzzyMinSim
+}
+
+/* Returns the prefix length for fuzzy queries. */
+func (qp *QueryParserBase) FuzzyPrefixLength() int {
+	return qp.fuzzyPrefixLength
+}
+
+/*
+Set the prefix length for fuzzy queries. Default is 0.
+*/
+func (qp *QueryParserBase) SetFuzzyPrefixLength(fuzzyPrefixLength int) {
+	qp.fuzzyPrefixLength = fuzzyPrefixLength
+}
+
+/* Sets the default slop for phrases. If zero, then exact phrase matches are required. Default value is zero. */
+func (qp *QueryParserBase) SetPhraseSlop(phraseSlop int) {
+	qp.phraseSlop = phraseSlop
+}
+
+/* Returns the default slop for phrases. */
+func (qp *QueryParserBase) PhraseSlop() int {
+	return qp.phraseSlop
+}
+
+/*
+Set to true to allow leading wildcard characters.
+
+When set, * or ? are allowed as the first character of a
+PrefixQuery and WildcardQuery. Note that this can produce very slow
+queries on big indexes. Default: false.
+*/
+func (qp *QueryParserBase) SetAllowLeadingWildcard(allowLeadingWildcard bool) {
+	qp.allowLeadingWildcard = allowLeadingWildcard
+}
+
+func (qp *QueryParserBase) AllowLeadingWildcard() bool {
+	return qp.allowLeadingWildcard
+}
+
+/*
+Sets the boolean operator of the QueryParser. In default mode
+(OP_OR) terms without any modifiers are considered optional: for
+example "capital of Hungary" is equal to "capital OR of OR Hungary".
+
+In OP_AND mode terms are considered to be in conjunction: the above
+mentioned query is parsed as "capital AND of AND Hungary".
+*/
+func (qp *QueryParserBase) SetDefaultOperator(op Operator) {
+	qp.operator = op
+}
+
+/* Gets implicit operator setting, which will be either OP_AND or OP_OR. */
+func (qp *QueryParserBase) DefaultOperator() Operator {
+	return qp.operator
+}
+
+/*
+Whether terms of wildcard, prefix, fuzzy and range queries are to be
+automatically lower-cased or not. Default is true.
+*/
+func (qp *QueryParserBase) SetLowercaseExpandedTerms(lowercaseExpandedTerms bool) {
+	qp.lowercaseExpandedTerms = lowercaseExpandedTerms
+}
+
+func (qp *QueryParserBase) LowercaseExpandedTerms() bool {
+	return qp.lowercaseExpandedTerms
+}
+
+/*
+By default QueryParser uses CONSTANT_SCORE_FILTER_REWRITE when
+creating a PrefixQuery, WildcardQuery or TermRangeQuery. This
+implementation is generally preferable because it a) Runs faster b)
+Does not have the scarcity of terms unduly influence score c) avoids
+any ErrTooManyClauses error. However, if your application really
+needs to use the old-fashioned BooleanQuery expansion rewriting and
+the above points are not relevant then use this to change the rewrite
+method.
+*/
+func (qp *QueryParserBase) SetMultiTermRewriteMethod(method search.RewriteMethod) {
+	qp.multiTermRewriteMethod = method
+}
+
+func (qp *QueryParserBase) MultiTermRewriteMethod() search.RewriteMethod {
+	return qp.multiTermRewriteMethod
+}
+
+// L351
+/* Sets the time zone that dates in range queries are parsed in. */
+func (qp *QueryParserBase) SetTimeZone(timeZone *time.Location) {
+	qp.timeZone = timeZone
+}
+
+func (qp *QueryParserBase) TimeZone() *time.Location {
+	return qp.timeZone
+}
+
+/*
+Sets the default date resolution used by range queries for fields for
+which no specific date resolutions has been set. Field specific
+resolutions can be set with SetFieldDateResolution().
+*/
+func (qp *QueryParserBase) SetDateResolution(dateResolution docu.Resolution) {
+	qp.dateResolution = &dateResolution
+}
+
+/*
+Sets the date resolution used by range queries for a specific field.
+*/
+func (qp *QueryParserBase) SetFieldDateResolution(fieldName string, dateResolution docu.Resolution) {
+	if fieldName == "" {
+		panic("Field must not be empty.")
+	}
+	if qp.fieldToDateResolution == nil {
+		// lazily initialize map
+		qp.fieldToDateResolution = make(map[string]docu.Resolution)
+	}
+	qp.fieldToDateResolution[fieldName] = dateResolution
+}
+
+/*
+Returns the date resolution that is used by RangeQueries for the
+given field. Returns false if no default or field specific date
+resolution has been set for the given field.
+*/
+func (qp *QueryParserBase) DateResolution(fieldName string) (docu.Resolution, bool) {
+	if fieldName == "" {
+		panic("Field must not be empty.")
+	}
+	if resolution, ok := qp.fieldToDateResolution[fieldName]; ok {
+		return resolution, true
+	}
+	// no field specific date resolution set; return default date
+	// resolution instead
+	if qp.dateResolution != nil {
+		return *qp.dateResolution, true
+	}
+	return 0, false
+}
+
 // L408
 func (qp *QueryParserBase) addClause(clauses []*search.BooleanClause,
 	conj, mods int, q search.Query) []*search.BooleanClause {
@@ -66,11 +292,20 @@ func (qp *QueryParserBase) addClause(clauses []*search.BooleanClause,
 	// If this term is introduced by AND, make the preceding term required,
 	// unless it's already prohibited
 	if len(clauses) > 0 && conj == CONJ_AND {
-		panic("not implemented yet")
+		if c := clauses[len(clauses)-1]; !c.IsProhibited() {
+			c.SetOccur(search.MUST)
+		}
 	}
 
 	if len(clauses) > 0 && qp.operator == OP_AND && conj == CONJ_OR {
-		panic("not implemented yet")
+		// If this term is introduced by OR, make the preceding term
+		// optional, unless it's prohibited (that means we leave -a OR b
+		// but +a OR b-->a OR b) notice if the input is a OR b, first term
+		// is parsed as required; without this modification a OR b would
+		// parsed as +a OR b
+		if c := clauses[len(clauses)-1]; !c.IsProhibited() {
+			c.SetOccur(search.SHOULD)
+		}
 	}
 
 	// We might have been passed an empty query; the term might have been
@@ -88,20 +323,22 @@ func (qp *QueryParserBase) addClause(clauses []*search.BooleanClause,
 			required = true
 		}
 	} else {
-		panic("not implemented yet")
+		// We set PROHIBITED if we're introduced by NOT or -; We set
+		// REQUIRED if not PROHIBITED and not introduced by OR
+		prohibited = (mods == MOD_NOT)
+		required = (!prohibited && conj != CONJ_OR)
 	}
 	if required {
-		panic("not implemented yet")
+		return append(clauses, qp.newBooleanClause(q, search.MUST))
 	} else if !prohibited {
 		return append(clauses, qp.newBooleanClause(q, search.SHOULD))
-	} else {
-		panic("not implemented yet")
 	}
+	return append(clauses, qp.newBooleanClause(q, search.MUST_NOT))
 }
 
 // L461
-func (qp *QueryParserBase) fieldQuery(field, queryText string, quoted bool) search.Query {
-	return qp.newFieldQuery(qp.analyzer, field, queryText, quoted)
+func (qp *QueryParserBase) fieldQuery(field, queryText string, quoted bool) (search.Query, error) {
+	return qp.newFieldQuery(qp.analyzer, field, queryText, quoted), nil
 }
 
 func (qp *QueryParserBase) newFieldQuery(analyzer analysis.Analyzer,
@@ -117,7 +354,150 @@ func (qp *QueryParserBase) newFieldQuery(analyzer analysis.Analyzer,
 		quoted || qp.autoGeneratePhraseQueries, qp.phraseSlop)
 }
 
+/*
+Base implementation delegates to fieldQuery(field, queryText, true).
+This method may be overridden, for example, to return a SpanNearQuery
+instead of a PhraseQuery.
+*/
+func (qp *QueryParserBase) fieldQueryWithSlop(field, queryText string, slop int) (search.Query, error) {
+	query, err := qp.spi.fieldQuery(field, queryText, true)
+	if err != nil {
+		return nil, err
+	}
+	applySlop(query, slop)
+	return query, nil
+}
+
+func applySlop(query search.Query, slop int) {
+	switch q := query.(type) {
+	case *search.PhraseQuery:
+		q.SetSlop(slop)
+	case *search.MultiPhraseQuery:
+		q.SetSlop(slop)
+	}
+}
+
+/*
+Builds a new FuzzyQuery instance
+*/
+func (qp *QueryParserBase) newFuzzyQuery(term *index.Term, minimumSimilarity float32,
+	prefixLength int) search.Query {
+
+	// FuzzyQuery doesn't yet allow constant score rewrite
+	numEdits := search.FloatToEdits(minimumSimilarity, utf8.RuneCount(term.Bytes))
+	return search.NewFuzzyQueryWithEdits(term, numEdits, prefixLength)
+}
+
+// L480
+/*
+Returns the range query for the given bounds, where a nil bound is
+open. If a date resolution is configured for the field, bounds that
+parse as short dates (e.g. 1/2/2006) are converted to date strings
+of that resolution first; an inclusive upper date includes the whole
+day.
+*/
+func (qp *QueryParserBase) rangeQuery(field string, part1, part2 *string,
+	startInclusive, endInclusive bool) (search.Query, error) {
+
+	if qp.lowercaseExpandedTerms {
+		if part1 != nil {
+			s := strings.ToLower(*part1)
+			part1 = &s
+		}
+		if part2 != nil {
+			s := strings.ToLower(*part2)
+			part2 = &s
+		}
+	}
+
+	if resolution, ok := qp.DateResolution(field); ok {
+		if part1 != nil {
+			if d1, ok := qp.parseShortDate(*part1); ok {
+				s := docu.DateToString(d1, resolution)
+				part1 = &s
+			}
+		}
+		if part2 != nil {
+			if d2, ok := qp.parseShortDate(*part2); ok {
+				if endInclusive {
+					// The user can only specify the date, not the time, so
+					// make sure the time is set to the latest possible time
+					// of that date to really include all documents:
+					y, m, d := d2.Date()
+					d2 = time.Date(y, m, d, 23, 59, 59, 999*int(time.Millisecond), qp.timeZone)
+				}
+				s := docu.DateToString(d2, resolution)
+				part2 = &s
+			}
+		}
+	}
+	return qp.newRangeQuery(field, part1, part2, startInclusive, endInclusive), nil
+}
+
+// layouts of the dates accepted in range queries
+var shortDateLayouts = []string{"1/2/2006", "1/2/06"}
+
+func (qp *QueryParserBase) parseShortDate(s string) (time.Time, bool) {
+	for _, layout := range shortDateLayouts {
+		if d, err := time.ParseInLocation(layout, s, qp.timeZone); err == nil {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+/*
+Builds a new TermRangeQuery instance
+*/
+func (qp *QueryParserBase) newRangeQuery(field string, part1, part2 *string,
+	startInclusive, endInclusive bool) search.Query {
+
+	var start, end []byte
+	if part1 != nil {
+		start = []byte(*part1)
+	}
+	if part2 != nil {
+		end = []byte(*part2)
+	}
+	query := search.NewTermRangeQuery(field, start, end, startInclusive, endInclusive)
+	query.SetRewriteMethod(qp.multiTermRewriteMethod)
+	return query
+}
+
 // L539
+/*
+Builds a new PrefixQuery instance
+*/
+func (qp *QueryParserBase) newPrefixQuery(prefix *index.Term) search.Query {
+	query := search.NewPrefixQuery(prefix)
+	query.SetRewriteMethod(qp.multiTermRewriteMethod)
+	return query
+}
+
+/*
+Builds a new RegexpQuery instance
+*/
+func (qp *QueryParserBase) newRegexpQuery(regexp *index.Term) search.Query {
+	query := search.NewRegexpQuery(regexp)
+	query.SetRewriteMethod(qp.multiTermRewriteMethod)
+	return query
+}
+
+/*
+Builds a new MatchAllDocsQuery instance
+*/
+func (qp *QueryParserBase) newMatchAllDocsQuery() search.Query {
+	return search.NewMatchAllDocsQuery()
+}
+
+/*
+Builds a new WildcardQuery instance
+*/
+func (qp *QueryParserBase) newWildcardQuery(t *index.Term) search.Query {
+	query := search.NewWildcardQuery(t)
+	query.SetRewriteMethod(qp.multiTermRewriteMethod)
+	return query
+}
 
 func (qp *QueryParserBase) newBooleanClause(q search.Query, occur search.Occur) *search.BooleanClause {
 	return search.NewBooleanClause(q, occur)
@@ -139,6 +519,9 @@ func (qp *QueryParserBase) booleanQueryDisableCoord(clauses []*search.BooleanCla
 	if len(clauses) == 0 {
 		return nil, nil // all clause words were filetered away by the analyzer.
 	}
+	if len(clauses) > search.MaxClauseCount() {
+		return nil, newParseError("too many boolean clauses")
+	}
 	query := qp.newBooleanQuery(disableCoord)
 	for _, clause := range clauses {
 		query.AddClause(clause)
@@ -146,6 +529,95 @@ func (qp *QueryParserBase) booleanQueryDisableCoord(clauses []*search.BooleanCla
 	return query, nil
 }
 
+/*
+Factory method for generating a query. Called when parser parses an
+input term token that contains one or more wildcard characters (? and
+*), but is not a prefix term token (one that has just a single *
+character at the end)
+
+Depending on settings, prefix term may be lower-cased automatically.
+It will not go through the default Analyzer, however, since normal
+Analyzers are unlikely to work properly with wildcard templates.
+
+Can be overridden by extending classes, to provide custom handling
+for wildcard queries, which may be necessary due to missing analyzer
+calls.
+*/
+func (qp *QueryParserBase) wildcardQuery(field, termStr string) (search.Query, error) {
+	if field == "*" && termStr == "*" {
+		return qp.newMatchAllDocsQuery(), nil
+	}
+	if !qp.allowLeadingWildcard && (strings.HasPrefix(termStr, "*") || strings.HasPrefix(termStr, "?")) {
+		return nil, newParseError("'*' or '?' not allowed as first character in WildcardQuery")
+	}
+	if qp.lowercaseExpandedTerms {
+		termStr = strings.ToLower(termStr)
+	}
+	return qp.newWildcardQuery(index.NewTerm(field, termStr)), nil
+}
+
+/*
+Factory method for generating a query. Called when parser parses an
+input term token that contains a regular expression query.
+
+Depending on settings, pattern term may be lower-cased automatically.
+It will not go through the default Analyzer, however, since normal
+Analyzers are unlikely to work properly with regular expression
+templates.
+*/
+func (qp *QueryParserBase) regexpQuery(field, termStr string) (q search.Query, err error) {
+	if qp.lowercaseExpandedTerms {
+		termStr = strings.ToLower(termStr)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			// the regular expression is malformed
+			msg, ok := r.(string)
+			if !ok {
+				panic(r)
+			}
+			q, err = nil, newParseError(fmt.Sprintf("Invalid regular expression '%v': %v", termStr, msg))
+		}
+	}()
+	return qp.newRegexpQuery(index.NewTerm(field, termStr)), nil
+}
+
+/*
+Factory method for generating a query (similar to wildcardQuery).
+Called when parser parses an input term token that uses prefix
+notation; that is, contains a single '*' wildcard character as its
+last character. Since this is a special case of generic wildcard
+term, and such a query can be optimized easily, this usually results
+in a different query object.
+
+Depending on settings, a prefix term may be lower-cased
+automatically. It will not go through the default Analyzer, however,
+since normal Analyzers are unlikely to work properly with wildcard
+templates.
+*/
+func (qp *QueryParserBase) prefixQuery(field, termStr string) (search.Query, error) {
+	if !qp.allowLeadingWildcard && strings.HasPrefix(termStr, "*") {
+		return nil, newParseError("'*' not allowed as first character in PrefixQuery")
+	}
+	if qp.lowercaseExpandedTerms {
+		termStr = strings.ToLower(termStr)
+	}
+	return qp.newPrefixQuery(index.NewTerm(field, termStr)), nil
+}
+
+/*
+Factory method for generating a query (similar to getWildcardQuery).
+Called when parser parses an input term token that has the fuzzy
+suffix (~) appended.
+*/
+func (qp *QueryParserBase) fuzzyQuery(field, termStr string, minSimilarity float32) (search.Query, error) {
+	if qp.lowercaseExpandedTerms {
+		termStr = strings.ToLower(termStr)
+	}
+	t := index.NewTerm(field, termStr)
+	return qp.newFuzzyQuery(t, minSimilarity, qp.fuzzyPrefixLength), nil
+}
+
 // L827
 func (qp *QueryParserBase) handleBareTokenQuery(qField string,
 	term, fuzzySlop *Token, prefix, wildcard, fuzzy, regexp bool) (q search.Query, err error) {
@@ -155,58 +627,154 @@ func (qp *QueryParserBase) handleBareTokenQuery(qField string,
 		return nil, err
 	}
 	if wildcard {
-		panic("not implemented yet")
+		return qp.spi.wildcardQuery(qField, term.image)
 	} else if prefix {
-		panic("not implemented yet")
+		if termImage, err = qp.discardEscapeChar(term.image[:len(term.image)-1]); err != nil {
+			return nil, err
+		}
+		return qp.spi.prefixQuery(qField, termImage)
 	} else if regexp {
-		panic("not implemented yet")
+		return qp.spi.regexpQuery(qField, term.image[1:len(term.image)-1])
 	} else if fuzzy {
-		panic("not implemented yet")
+		return qp.handleBareFuzzy(qField, fuzzySlop, termImage)
 	} else {
-		return qp.fieldQuery(qField, termImage, false), nil
+		return qp.spi.fieldQuery(qField, termImage, false)
+	}
+}
+
+func (qp *QueryParserBase) handleBareFuzzy(qField string, fuzzySlop *Token,
+	termImage string) (search.Query, error) {
+
+	fms := qp.fuzzyMinSim
+	if v, err := strconv.ParseFloat(fuzzySlop.image[1:], 32); err == nil {
+		fms = float32(v)
+	}
+	if fms < 0 {
+		return nil, newParseError("Minimum similarity for a FuzzyQuery has to be between 0.0f and 1.0f !")
+	} else if fms >= 1 && fms != float32(int(fms)) {
+		return nil, newParseError("Fractional edit distances are not allowed!")
 	}
+	return qp.spi.fuzzyQuery(qField, termImage, fms)
+}
+
+// L856
+func (qp *QueryParserBase) handleQuotedTerm(qField string, term, fuzzySlop *Token) (search.Query, error) {
+	s := qp.phraseSlop // default
+	if fuzzySlop != nil {
+		if v, err := strconv.ParseFloat(fuzzySlop.image[1:], 32); err == nil {
+			s = int(v)
+		}
+	}
+	termImage, err := qp.discardEscapeChar(term.image[1 : len(term.image)-1])
+	if err != nil {
+		return nil, err
+	}
+	return qp.spi.fieldQueryWithSlop(qField, termImage, s)
 }
 
 // L876
 func (qp *QueryParserBase) handleBoost(q search.Query, boost *Token) search.Query {
 	if boost != nil {
-		panic("not implemented yet")
+		f := float32(1)
+		if v, err := strconv.ParseFloat(boost.image, 32); err == nil {
+			f = float32(v)
+		}
+		// avoid boosting null queries, such as those caused by stop words
+		if q != nil {
+			q.SetBoost(f)
+		}
 	}
 	return q
 }
 
 // L906
-func (qp *QueryParserBase) discardEscapeChar(input string) (string, error) {
-	output := make([]rune, len(input))
+/*
+Returns a string where the escape char has been removed, or kept
+only once if there was a double escape.
 
-	length := 0
+Supports escaped unicode characters, e.g. translates \u0041 to A.
+*/
+func (qp *QueryParserBase) discardEscapeChar(input string) (string, error) {
+	// Create char array to hold unescaped char sequence
+	output := make([]rune, 0, len(input))
 
+	// The first escape character seen, if any
 	lastCharWasEscapeChar := false
 
+	// The multiplier the current unicode digit must be multiplied with.
+	// E.g. the first digit must be multiplied with 16^3, the second with
+	// 16^2...
 	codePointMultiplier := 0
 
-	// codePoint := 0
+	// Used to calculate the codepoint of the escaped unicode character
+	codePoint := 0
 
 	for _, curChar := range input {
 		if codePointMultiplier > 0 {
-			panic("not implemented yet")
+			v, err := hexToInt(curChar)
+			if err != nil {
+				return "", err
+			}
+			codePoint += v * codePointMultiplier
+			codePointMultiplier >>= 4
+			if codePointMultiplier == 0 {
+				output = append(output, rune(codePoint))
+				codePoint = 0
+			}
 		} else if lastCharWasEscapeChar {
-			panic("not implemented yet")
+			if curChar == 'u' {
+				// found an escaped unicode character
+				codePointMultiplier = 16 * 16 * 16
+			} else {
+				// this character was escaped
+				output = append(output, curChar)
+			}
+			lastCharWasEscapeChar = false
 		} else {
 			if curChar == '\\' {
 				lastCharWasEscapeChar = true
 			} else {
-				output[length] = curChar
-				length++
+				output = append(output, curChar)
 			}
 		}
 	}
 
 	if codePointMultiplier > 0 {
-		return "", errors.New("Truncated unicode escape sequence.")
+		return "", newParseError("Truncated unicode escape sequence.")
 	}
 	if lastCharWasEscapeChar {
-		return "", errors.New("Term can not end with escape character.")
+		return "", newParseError("Term can not end with escape character.")
 	}
 	return string(output), nil
 }
+
+/* Returns the numeric value of the hexadecimal character */
+func hexToInt(c rune) (int, error) {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0'), nil
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10), nil
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10), nil
+	}
+	return 0, newParseError(fmt.Sprintf("Non-hex character in Unicode escape sequence: %c", c))
+}
+
+/*
+Returns a string where those characters that QueryParser expects to
+be escaped are escaped by a preceding \.
+*/
+func Escape(s string) string {
+	var buf bytes.Buffer
+	for _, c := range s {
+		// These characters are part of the query syntax and must be escaped
+		switch c {
+		case '\\', '+', '-', '!', '(', ')', ':', '^', '[', ']', '"', '{', '}',
+			'~', '*', '?', '|', '&', '/':
+			buf.WriteRune('\\')
+		}
+		buf.WriteRune(c)
+	}
+	return buf.String()
+}
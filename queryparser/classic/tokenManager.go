@@ -5,9 +5,6 @@ import (
 )
 
 var jjbitVec0 = []int64{1, 0, 0, 0}
-var jjbitVec4 = []uint64{
-	0xfffefffffffffffe, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff,
-}
 
 var jjnextStates = []int{
 	37, 39, 40, 17, 18, 20, 42, 45, 31, 46, 43, 22, 23, 25, 26, 24,
@@ -16,7 +13,7 @@ var jjnextStates = []int{
 }
 
 var jjstrLiteralImages = map[int]string{
-	0: "", 11: "\u0053", 12: "\055",
+	0: "", 11: "\053", 12: "\055",
 	14: "\050", 15: "\051", 16: "\072", 17: "\052", 18: "\136",
 	25: "\133", 26: "\173", 28: "\124\117", 29: "\135", 30: "\175",
 }
@@ -54,36 +51,302 @@ func newTokenManager(stream CharStream) *TokenManager {
 
 // L41
 
+func (tm *TokenManager) jjStopAtPos(pos, kind int) int {
+	tm.jjmatchedKind = kind
+	tm.jjmatchedPos = pos
+	return pos + 1
+}
+
+/*
+Matches the longest DEFAULT token starting at curChar, following the
+token definitions of QueryParser.jj:
+
+	<AND:           ("AND" | "&&") >
+	<OR:            ("OR" | "||") >
+	<NOT:           ("NOT" | "!") >
+	<PLUS:          "+" >
+	<MINUS:         "-" >
+	<BAREOPER:      ("+"|"-"|"!") <_WHITESPACE> >
+	<LPAREN:        "(" >
+	<RPAREN:        ")" >
+	<COLON:         ":" >
+	<STAR:          "*" >
+	<CARAT:         "^" > : Boost
+	<QUOTED:        "\"" (<_QUOTED_CHAR>)* "\"">
+	<TERM:          <_TERM_START_CHAR> (<_TERM_CHAR>)* >
+	<FUZZY_SLOP:    "~" ( (<_NUM_CHAR>)+ ( "." (<_NUM_CHAR>)+ )? )? >
+	<PREFIXTERM:    ("*") | ( <_TERM_START_CHAR> (<_TERM_CHAR>)* "*" ) >
+	<WILDTERM:      (<_TERM_START_CHAR> | [ "*", "?" ]) (<_TERM_CHAR> | ( [ "*", "?" ] ))* >
+	<REGEXPTERM:    "/" (~[ "/" ] | "\\/" )* "/" >
+	<RANGEIN_START: "[" > : Range
+	<RANGEEX_START: "{" > : Range
+
+Like the generated DFA, ties between tokens of the same length are
+broken in favor of the token declared first. Returns the number of
+chars read, as jjMoveNfa_1() does.
+*/
 func (tm *TokenManager) jjMoveStringLiteralDfa0_2() int {
-	switch tm.curChar {
-	case 40:
-		panic("not implemented yet")
-	case 41:
-		panic("not implemented yet")
-	case 42:
-		panic("not implemented yet")
-	case 43:
-		panic("not implemented yet")
-	case 45:
-		panic("not implemented yet")
-	case 58:
-		panic("not implemented yet")
-	case 91:
-		panic("not implemented yet")
-	case 94:
-		panic("not implemented yet")
-	case 123:
-		panic("not implemented yet")
-	default:
-		return tm.jjMoveNfa_2(0, 0)
+	m := &defaultMatcher{tm: tm, chars: []rune{tm.curChar}}
+	m.match(_UNUSED, m.whitespace()) // the skipped <_WHITESPACE>
+	m.match(AND, m.literal("AND", "&&"))
+	m.match(OR, m.literal("OR", "||"))
+	m.match(NOT, m.literal("NOT", "!"))
+	m.match(PLUS, m.literal("+"))
+	m.match(MINUS, m.literal("-"))
+	m.match(BAREOPER, m.bareOper())
+	m.match(LPAREN, m.literal("("))
+	m.match(RPAREN, m.literal(")"))
+	m.match(COLON, m.literal(":"))
+	m.match(STAR, m.literal("*"))
+	m.match(CARAT, m.literal("^"))
+	m.match(QUOTED, m.quoted())
+	m.match(TERM, m.term())
+	m.match(FUZZY_SLOP, m.fuzzySlop())
+	m.match(PREFIXTERM, m.prefixTerm())
+	m.match(WILDTERM, m.wildTerm())
+	m.match(REGEXPTERM, m.regexpTerm())
+	m.match(RANGEIN_START, m.literal("["))
+	m.match(RANGEEX_START, m.literal("{"))
+	return len(m.chars)
+}
+
+type defaultMatcher struct {
+	tm    *TokenManager
+	chars []rune // chars read so far, starting with the token start
+	eof   bool
+}
+
+/* Records a match of the given length, if it's the longest so far. */
+func (m *defaultMatcher) match(kind, length int) {
+	if length > 0 && (m.tm.jjmatchedKind == 0x7fffffff || length-1 > m.tm.jjmatchedPos) {
+		m.tm.jjmatchedKind = kind
+		m.tm.jjmatchedPos = length - 1
+	}
+}
+
+/* Returns the char at position i, reading ahead as needed. */
+func (m *defaultMatcher) at(i int) (rune, bool) {
+	for len(m.chars) <= i && !m.eof {
+		c, err := m.tm.input_stream.readChar()
+		if err != nil {
+			m.eof = true
+			break
+		}
+		m.tm.curChar = c
+		m.chars = append(m.chars, c)
+	}
+	if i < len(m.chars) {
+		return m.chars[i], true
+	}
+	return 0, false
+}
+
+func (m *defaultMatcher) literal(images ...string) int {
+	for _, image := range images {
+		i := 0
+		for _, c := range image {
+			if r, ok := m.at(i); !ok || r != c {
+				break
+			}
+			i++
+		}
+		if i == len(image) {
+			return i
+		}
+	}
+	return 0
+}
+
+func isWhitespace(c rune) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\u3000':
+		return true
+	}
+	return false
+}
+
+func (m *defaultMatcher) whitespace() int {
+	if c, _ := m.at(0); isWhitespace(c) {
+		return 1
+	}
+	return 0
+}
+
+func (m *defaultMatcher) bareOper() int {
+	if c, _ := m.at(0); c == '+' || c == '-' || c == '!' {
+		if c, ok := m.at(1); ok && isWhitespace(c) {
+			return 2
+		}
+	}
+	return 0
+}
+
+/* Returns the length of the _ESCAPED_CHAR at i, or 0. */
+func (m *defaultMatcher) escapedChar(i int) int {
+	if c, _ := m.at(i); c == '\\' {
+		if _, ok := m.at(i + 1); ok {
+			return 2
+		}
+	}
+	return 0
+}
+
+/* Returns the length of the _TERM_START_CHAR at i, or 0. */
+func (m *defaultMatcher) termStartChar(i int) int {
+	c, ok := m.at(i)
+	if !ok {
+		return 0
+	}
+	switch c {
+	case ' ', '\t', '\n', '\r', '\u3000', '+', '-', '!', '(', ')', ':', '^',
+		'[', ']', '"', '{', '}', '~', '*', '?', '/':
+		return 0
+	case '\\':
+		return m.escapedChar(i)
+	}
+	return 1
+}
+
+/* Returns the length of the _TERM_CHAR at i, or 0. */
+func (m *defaultMatcher) termChar(i int) int {
+	if c, _ := m.at(i); c == '-' || c == '+' {
+		return 1
+	}
+	return m.termStartChar(i)
+}
+
+func (m *defaultMatcher) isWildcard(i int) bool {
+	c, _ := m.at(i)
+	return c == '*' || c == '?'
+}
+
+func (m *defaultMatcher) term() int {
+	i := m.termStartChar(0)
+	if i == 0 {
+		return 0
+	}
+	for n := m.termChar(i); n > 0; n = m.termChar(i) {
+		i += n
 	}
+	return i
 }
 
-// L87
+func (m *defaultMatcher) prefixTerm() int {
+	if i := m.term(); i > 0 {
+		if c, _ := m.at(i); c == '*' {
+			return i + 1
+		}
+	}
+	return m.literal("*")
+}
+
+func (m *defaultMatcher) wildTerm() int {
+	i := m.termStartChar(0)
+	if i == 0 {
+		if !m.isWildcard(0) {
+			return 0
+		}
+		i = 1
+	}
+	for {
+		if n := m.termChar(i); n > 0 {
+			i += n
+		} else if m.isWildcard(i) {
+			i++
+		} else {
+			return i
+		}
+	}
+}
+
+func (m *defaultMatcher) digits(i int) int {
+	n := 0
+	for {
+		if c, ok := m.at(i + n); !ok || c < '0' || c > '9' {
+			return n
+		}
+		n++
+	}
+}
+
+func (m *defaultMatcher) fuzzySlop() int {
+	if c, _ := m.at(0); c != '~' {
+		return 0
+	}
+	i := 1
+	if n := m.digits(i); n > 0 {
+		i += n
+		if c, _ := m.at(i); c == '.' {
+			if n = m.digits(i + 1); n > 0 {
+				i += 1 + n
+			}
+		}
+	}
+	return i
+}
+
+func (m *defaultMatcher) quoted() int {
+	if c, _ := m.at(0); c != '"' {
+		return 0
+	}
+	for i := 1; ; {
+		c, ok := m.at(i)
+		switch {
+		case !ok:
+			return 0
+		case c == '"':
+			return i + 1
+		case c == '\\':
+			if n := m.escapedChar(i); n > 0 {
+				i += n
+			} else {
+				return 0
+			}
+		default:
+			i++
+		}
+	}
+}
+
+func (m *defaultMatcher) regexpTerm() int {
+	if c, _ := m.at(0); c != '/' {
+		return 0
+	}
+	// the body may end at any unescaped '/', but an escaped '/' may as
+	// well continue it; keep the longest match
+	longest := 0
+	inBody, afterEscape := true, false
+	for i := 1; inBody || afterEscape; i++ {
+		c, ok := m.at(i)
+		if !ok {
+			break
+		}
+		wasInBody, wasAfterEscape := inBody, afterEscape
+		inBody, afterEscape = false, false
+		if wasInBody {
+			if c == '/' {
+				longest = i + 1
+			} else {
+				inBody = true
+				afterEscape = c == '\\'
+			}
+		}
+		if wasAfterEscape && c == '/' {
+			inBody = true
+		}
+	}
+	return longest
+}
+
+// L33
+/* Matches a NUMBER in the Boost state. */
+func (tm *TokenManager) jjMoveStringLiteralDfa0_0() int {
+	return tm.jjMoveNfa_0(0, 0)
+}
 
-func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
+func (tm *TokenManager) jjMoveNfa_0(startState, curPos int) int {
 	startsAt := 0
-	tm.jjnewStateCnt = 49
+	tm.jjnewStateCnt = 3
 	i := 1
 	tm.jjstateSet[0] = startState
 	kind := 0x7fffffff
@@ -92,238 +355,188 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 			tm.reInitRounds()
 		}
 		if tm.curChar < 64 {
-			l := int64(1 << uint(tm.curChar))
+			l := uint64(1) << uint(tm.curChar)
 			for {
 				i--
 				switch tm.jjstateSet[i] {
-				case 49, 33:
-					if (0xfbff7cf8ffffd9ff & uint64(l)) != 0 {
-						if kind > 23 {
-							kind = 23
-						}
-						tm.jjCheckNAddTwoStates(33, 34)
-					}
 				case 0:
-					if (0xfbff54f8ffffd9ff & uint64(l)) != 0 {
-						if kind > 23 {
-							kind = 23
+					if (0x3ff000000000000 & l) != 0 {
+						if kind > 27 {
+							kind = 27
 						}
-						tm.jjCheckNAddTwoStates(33, 34)
-					} else if (0x100002600 & l) != 0 {
-						if kind > 7 {
-							kind = 7
-						}
-					} else if (0x280200000000 & l) != 0 {
-						panic("not implemented yet")
-					} else if tm.curChar == 47 {
-						panic("not implemented yet")
-					} else if tm.curChar == 34 {
-						panic("not implemented yet")
-					}
-					if (0x7bff50f8ffffd9ff & l) != 0 {
-						if kind > 20 {
-							kind = 20
-						}
-						tm.jjCheckNAddStates(6, 10)
-					} else if tm.curChar == 42 {
-						panic("not implemented yet")
-					} else if tm.curChar == 33 {
-						panic("not implemented yet")
-					}
-					if tm.curChar == 38 {
-						panic("not implemented yet")
+						tm.jjAddStates(31, 33)
 					}
-
-				case 4:
-					panic("not implemented yet")
-				case 5:
-					panic("not implemented yet")
-				case 13:
-					panic("not implemented yet")
-				case 14:
-					panic("not implemented yet")
-				case 15:
-					panic("not implemented yet")
-				case 16:
-					panic("not implemented yet")
-				case 17:
-					panic("not implemented yet")
-				case 19:
-					panic("not implemented yet")
-				case 20:
-					panic("not implemented yet")
-				case 22:
-					panic("not implemented yet")
-				case 23:
-					panic("not implemented yet")
-				case 24:
-					panic("not implemented yet")
-				case 25:
-					panic("not implemented yet")
-				case 27:
-					panic("not implemented yet")
-				case 28:
-					panic("not implemented yet")
-				case 30:
-					panic("not implemented yet")
-				case 31:
-					if tm.curChar == 42 && kind > 22 {
-						kind = 22
+				case 1:
+					if tm.curChar == 46 {
+						tm.jjCheckNAdd(2)
 					}
-				case 32:
-					panic("not implemented yet")
-				case 35:
-					panic("not implemented yet")
-				case 36, 38:
-					panic("not implemented yet")
-				case 37:
-					panic("not implemented yet")
-				case 40:
-					panic("not implemented yet")
-				case 41:
-					panic("not implemented yet")
-				case 42:
-					if (0x7bff78f8ffffd9ff & l) != 0 {
-						if kind > 20 {
-							kind = 20
+				case 2:
+					if (0x3ff000000000000 & l) != 0 {
+						if kind > 27 {
+							kind = 27
 						}
-						tm.jjCheckNAddTwoStates(42, 43)
+						tm.jjCheckNAdd(2)
 					}
-				case 44:
-					panic("not implemented yet")
-				case 45:
-					if (0x7bff78f8ffffd9ff & l) != 0 {
-						tm.jjCheckNAddStates(18, 20)
-					}
-				case 47:
-					panic("not implemented yet")
 				}
 				if i == startsAt {
 					break
 				}
 			}
-		} else if tm.curChar < 128 {
-			l := int64(1) << (uint(tm.curChar) & 077)
+		} // no state accepts any char beyond '?'
+		if kind != 0x7fffffff {
+			tm.jjmatchedKind = kind
+			tm.jjmatchedPos = curPos
+			kind = 0x7fffffff
+		}
+		curPos++
+		i = tm.jjnewStateCnt
+		tm.jjnewStateCnt = startsAt
+		startsAt = 3 - tm.jjnewStateCnt
+		if i == startsAt {
+			return curPos
+		}
+		var err error
+		if tm.curChar, err = tm.input_stream.readChar(); err != nil {
+			return curPos
+		}
+	}
+}
+
+// L775
+
+func (tm *TokenManager) jjStopStringLiteralDfa_1(pos int, active0 int64) int {
+	switch pos {
+	case 0:
+		if (active0 & 0x10000000) != 0 {
+			tm.jjmatchedKind = 32
+			return 6
+		}
+		return -1
+	default:
+		return -1
+	}
+}
+
+func (tm *TokenManager) jjStartNfa_1(pos int, active0 int64) int {
+	return tm.jjMoveNfa_1(tm.jjStopStringLiteralDfa_1(pos, active0), pos+1)
+}
+
+func (tm *TokenManager) jjMoveStringLiteralDfa0_1() int {
+	switch tm.curChar {
+	case 84:
+		return tm.jjMoveStringLiteralDfa1_1(0x10000000)
+	case 93:
+		return tm.jjStopAtPos(0, 29)
+	case 125:
+		return tm.jjStopAtPos(0, 30)
+	default:
+		return tm.jjMoveNfa_1(0, 0)
+	}
+}
+
+func (tm *TokenManager) jjMoveStringLiteralDfa1_1(active0 int64) int {
+	var err error
+	if tm.curChar, err = tm.input_stream.readChar(); err != nil {
+		tm.jjStopStringLiteralDfa_1(0, active0)
+		return 1
+	}
+	switch tm.curChar {
+	case 79:
+		if (active0 & 0x10000000) != 0 {
+			return tm.jjStartNfaWithStates_1(1, 28, 6)
+		}
+	}
+	return tm.jjStartNfa_1(0, active0)
+}
+
+func (tm *TokenManager) jjStartNfaWithStates_1(pos, kind, state int) int {
+	tm.jjmatchedKind = kind
+	tm.jjmatchedPos = pos
+	var err error
+	if tm.curChar, err = tm.input_stream.readChar(); err != nil {
+		return pos + 1
+	}
+	return tm.jjMoveNfa_1(state, pos+1)
+}
+
+func (tm *TokenManager) jjMoveNfa_1(startState, curPos int) int {
+	startsAt := 0
+	tm.jjnewStateCnt = 7
+	i := 1
+	tm.jjstateSet[0] = startState
+	kind := 0x7fffffff
+	for {
+		if tm.jjround++; tm.jjround == 0x7fffffff {
+			tm.reInitRounds()
+		}
+		if tm.curChar < 64 {
+			l := uint64(1) << uint(tm.curChar)
 			for {
 				i--
 				switch tm.jjstateSet[i] {
-				case 49:
-					panic("niy")
 				case 0:
-					if (0x97ffffff87ffffff & uint64(l)) != 0 {
-						if kind > 20 {
-							kind = 20
+					if (0xfffffffeffffffff & l) != 0 {
+						if kind > 32 {
+							kind = 32
 						}
-						tm.jjCheckNAddStates(6, 10)
-					} else if tm.curChar == 92 {
-						panic("niy")
-					} else if tm.curChar == 126 {
-						panic("niy")
+						tm.jjCheckNAdd(6)
 					}
-					if (0x97ffffff87ffffff & uint64(l)) != 0 {
-						if kind > 23 {
-							kind = 23
+					if (0x100002600 & l) != 0 {
+						if kind > 7 {
+							kind = 7
 						}
-						tm.jjCheckNAddTwoStates(33, 34)
-					}
-					switch tm.curChar {
-					case 78:
-						panic("niy")
-					case 124:
-						panic("niy")
-					case 79:
-						panic("niy")
-					case 65:
-						panic("niy")
+					} else if tm.curChar == 34 {
+						tm.jjCheckNAddTwoStates(2, 4)
 					}
 				case 1:
-					panic("niy")
+					if tm.curChar == 34 {
+						tm.jjCheckNAddTwoStates(2, 4)
+					}
 				case 2:
-					panic("niy")
+					if (0xfffffffbffffffff & l) != 0 {
+						tm.jjCheckNAddStates(33, 36)
+					}
 				case 3:
-					panic("niy")
-				case 6:
-					panic("niy")
-				case 7:
-					panic("niy")
-				case 8:
-					panic("niy")
-				case 9:
-					panic("niy")
-				case 10:
-					panic("niy")
-				case 11:
-					panic("niy")
-				case 12:
-					panic("niy")
-				case 17:
-					panic("niy")
-				case 18:
-					panic("niy")
-				case 19:
-					panic("niy")
-				case 21:
-					panic("niy")
-				case 25:
-					panic("niy")
-				case 26:
-					panic("niy")
-				case 27:
-					panic("niy")
-				case 28:
-					panic("niy")
-				case 29:
-					panic("niy")
-				case 30:
-					panic("niy")
-				case 32:
-					panic("niy")
-				case 33:
-					if (0x97ffffff87ffffff & uint64(l)) != 0 {
-						if kind > 23 {
-							kind = 23
-						}
-						tm.jjCheckNAddTwoStates(33, 34)
+					if tm.curChar == 34 {
+						tm.jjCheckNAddStates(33, 36)
 					}
-				case 34:
-					if tm.curChar == 92 {
-						tm.jjCheckNAddTwoStates(35, 35)
+				case 5:
+					if tm.curChar == 34 && kind > 31 {
+						kind = 31
 					}
-				case 35:
-					panic("niy")
-				case 37:
-					panic("niy")
-				case 39:
-					panic("niy")
-				case 41:
-					panic("niy")
-				case 42:
-					if (0x97ffffff87ffffff & uint64(l)) != 0 {
-						if kind > 20 {
-							kind = 20
+				case 6:
+					if (0xfffffffeffffffff & l) != 0 {
+						if kind > 32 {
+							kind = 32
 						}
-						tm.jjCheckNAddTwoStates(42, 43)
+						tm.jjCheckNAdd(6)
 					}
-				case 43:
-					if tm.curChar == 92 {
-						tm.jjCheckNAddTwoStates(44, 44)
-					}
-				case 44:
-					panic("niy")
-				case 45:
-					if (0x97ffffff87ffffff & uint64(l)) != 0 {
-						tm.jjCheckNAddStates(18, 20)
+				}
+				if i == startsAt {
+					break
+				}
+			}
+		} else if tm.curChar < 128 {
+			l := uint64(1) << (uint(tm.curChar) & 077)
+			for {
+				i--
+				switch tm.jjstateSet[i] {
+				case 0, 6:
+					if (0xdfffffffdfffffff & l) != 0 {
+						if kind > 32 {
+							kind = 32
+						}
+						tm.jjCheckNAdd(6)
 					}
-				case 46:
+				case 2:
+					tm.jjAddStates(33, 36)
+				case 4:
 					if tm.curChar == 92 {
-						tm.jjCheckNAddTwoStates(47, 47)
+						tm.jjstateSet[tm.jjnewStateCnt] = 3
+						tm.jjnewStateCnt++
 					}
-				case 47:
-					panic("niy")
-				case 48:
-					panic("niy")
 				}
-				if i <= startsAt { // ==?
+				if i == startsAt {
 					break
 				}
 			}
@@ -336,66 +549,29 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 			for {
 				i--
 				switch tm.jjstateSet[i] {
-				case 49, 33:
-					if jjCanMove_2(hiByte, i1, i2, l1, l2) {
-						if kind > 23 {
-							kind = 23
-						}
-						tm.jjCheckNAddTwoStates(33, 34)
-					}
 				case 0:
 					if jjCanMove_0(hiByte, i1, i2, l1, l2) {
 						if kind > 7 {
 							kind = 7
 						}
 					}
-					if jjCanMove_2(hiByte, i1, i2, l1, l2) {
-						if kind > 23 {
-							kind = 23
+					if jjCanMove_1(hiByte, i1, i2, l1, l2) {
+						if kind > 32 {
+							kind = 32
 						}
-						tm.jjCheckNAddTwoStates(33, 34)
+						tm.jjCheckNAdd(6)
 					}
-					if jjCanMove_2(hiByte, i1, i2, l1, l2) {
-						if kind > 20 {
-							kind = 20
-						}
-						tm.jjCheckNAddStates(6, 10)
+				case 2:
+					if jjCanMove_1(hiByte, i1, i2, l1, l2) {
+						tm.jjAddStates(33, 36)
 					}
-				case 15:
-					panic("not implemented yet")
-				case 17, 19:
-					panic("not implemented yet")
-				case 25:
-					panic("not implemented yet")
-				case 27:
-					panic("not implemented yet")
-				case 28:
-					panic("not implemented yet")
-				case 30:
-					panic("not implemented yet")
-				case 32:
-					panic("not implemented yet")
-				case 35:
-					panic("not implemented yet")
-				case 37:
-					panic("not implemented yet")
-				case 41:
-					panic("not implemented yet")
-				case 42:
-					if jjCanMove_2(hiByte, i1, i2, l1, l2) {
-						if kind > 20 {
-							kind = 20
+				case 6:
+					if jjCanMove_1(hiByte, i1, i2, l1, l2) {
+						if kind > 32 {
+							kind = 32
 						}
-						tm.jjCheckNAddTwoStates(42, 43)
-					}
-				case 44:
-					panic("not implemented yet")
-				case 45:
-					if jjCanMove_2(hiByte, i1, i2, l1, l2) {
-						tm.jjCheckNAddStates(18, 20)
+						tm.jjCheckNAdd(6)
 					}
-				case 47:
-					panic("not implemented yet")
 				}
 				if i == startsAt {
 					break
@@ -410,7 +586,7 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 		curPos++
 		i = tm.jjnewStateCnt
 		tm.jjnewStateCnt = startsAt
-		startsAt = 49 - tm.jjnewStateCnt
+		startsAt = 7 - tm.jjnewStateCnt
 		if i == startsAt {
 			return curPos
 		}
@@ -419,25 +595,19 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 			return curPos
 		}
 	}
-	panic("should not be here")
 }
 
 func jjCanMove_0(hiByte, i1, i2 int, l1, l2 int64) bool {
 	switch hiByte {
 	case 48:
-		return (jjbitVec0[i2] & 12) != 0
+		return (jjbitVec0[i2] & l2) != 0
 	}
 	return false
 }
 
-func jjCanMove_2(hiByte, i1, i2 int, l1, l2 int64) bool {
-	switch hiByte {
-	case 0:
-		panic("not implemented yet")
-	case 48:
-		panic("not implemented yet")
-	}
-	return (jjbitVec4[i1] & uint64(l1)) != 0
+// Any character beyond ASCII is part of a range term.
+func jjCanMove_1(hiByte, i1, i2 int, l1, l2 int64) bool {
+	return true
 }
 
 func (tm *TokenManager) ReInit(stream CharStream) {
@@ -490,9 +660,13 @@ func (tm *TokenManager) nextToken() (matchedToken *Token) {
 
 		switch tm.curLexState {
 		case 0:
-			panic("not implemented yet")
+			tm.jjmatchedKind = 0x7fffffff
+			tm.jjmatchedPos = 0
+			curPos = tm.jjMoveStringLiteralDfa0_0()
 		case 1:
-			panic("not implemented yet")
+			tm.jjmatchedKind = 0x7fffffff
+			tm.jjmatchedPos = 0
+			curPos = tm.jjMoveStringLiteralDfa0_1()
 		case 2:
 			tm.jjmatchedKind = 0x7fffffff
 			tm.jjmatchedPos = 0
@@ -505,14 +679,13 @@ func (tm *TokenManager) nextToken() (matchedToken *Token) {
 			}
 			if (jjtoToken[tm.jjmatchedKind>>6] & (int64(1) << uint(tm.jjmatchedKind&077))) != 0 {
 				matchedToken = tm.jjFillToken()
-				if jjnewLexState[tm.jjmatchedKind] != -1 {
-					panic("not implemented yet")
+				if n := jjnewLexState[tm.jjmatchedKind]; n != -1 {
+					tm.curLexState = n
 				}
 				return matchedToken
 			} else {
 				if n := jjnewLexState[tm.jjmatchedKind]; n != -1 {
-					panic("niy")
-					// tm.curLexState = n
+					tm.curLexState = n
 				}
 				continue
 			}
@@ -545,6 +718,13 @@ func (tm *TokenManager) nextToken() (matchedToken *Token) {
 }
 
 // L1137
+func (tm *TokenManager) jjAddStates(start, end int) {
+	for ; start < end; start++ {
+		tm.jjstateSet[tm.jjnewStateCnt] = jjnextStates[start]
+		tm.jjnewStateCnt++
+	}
+}
+
 func (tm *TokenManager) jjCheckNAdd(state int) {
 	if tm.jjrounds[state] != tm.jjround {
 		tm.jjstateSet[tm.jjnewStateCnt] = state
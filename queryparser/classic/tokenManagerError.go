@@ -1,5 +1,12 @@
 package classic
 
+import (
+	"bytes"
+	"fmt"
+)
+
+// queryparser/classic/TokenMgrError.java
+
 const (
 	LEXICAL_ERROR = iota
 	STATIC_LEXER_ERROR
@@ -7,14 +14,83 @@ const (
 	LOOP_DETECTED
 )
 
+/* Token Manager Error. */
 type TokenManagerError struct {
+	// Line and Column of the lexical error, if any.
+	Line, Column int
+	msg          string
+	// Indicates the reason why the error is raised. It will have one of
+	// the above values.
+	errorCode int
 }
 
+/*
+Returns a detailed message for the Error when it is raised by the
+token manager to indicate a lexical error.
+
+Parameters:
+
+	eofSeen     : indicates if EOF caused the lexical error
+	lexState    : lexical state in which this error occurred
+	errorLine   : line number when the error occurred
+	errorColumn : column number when the error occurred
+	errorAfter  : prefix that was seen before this error occurred
+	curChar     : the offending character
+*/
 func newTokenMgrError(eofSeen bool, lexState, errorLine, errorColumn int,
 	errorAfter string, curChar rune, reason int) *TokenManagerError {
-	panic("not implemented yet")
+
+	var encountered string
+	if eofSeen {
+		encountered = "<EOF> "
+	} else {
+		encountered = fmt.Sprintf("\"%v\" (%v), ", addEscapes(string(curChar)), int(curChar))
+	}
+	return &TokenManagerError{
+		Line:   errorLine,
+		Column: errorColumn,
+		msg: fmt.Sprintf("Lexical error at line %v, column %v.  Encountered: %vafter : \"%v\"",
+			errorLine, errorColumn, encountered, addEscapes(errorAfter)),
+		errorCode: reason,
+	}
 }
 
 func (err *TokenManagerError) Error() string {
-	panic("not implemented yet")
+	return err.msg
+}
+
+/*
+Replaces unprintable characters by their escaped (or unicode escaped)
+equivalents in the given string.
+*/
+func addEscapes(str string) string {
+	var buf bytes.Buffer
+	for _, ch := range str {
+		switch ch {
+		case 0:
+		case '\b':
+			buf.WriteString("\\b")
+		case '\t':
+			buf.WriteString("\\t")
+		case '\n':
+			buf.WriteString("\\n")
+		case '\f':
+			buf.WriteString("\\f")
+		case '\r':
+			buf.WriteString("\\r")
+		case '"':
+			buf.WriteString("\\\"")
+		case '\'':
+			buf.WriteString("\\'")
+		case '\\':
+			buf.WriteString("\\\\")
+		default:
+			if ch < 0x20 || ch > 0x7e {
+				fmt.Fprintf(&buf, "\\u%04x", ch)
+			} else {
+				buf.WriteRune(ch)
+			}
+		}
+	}
+	return buf.String()
 }
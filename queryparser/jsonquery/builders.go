@@ -0,0 +1,289 @@
+package jsonquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	"sort"
+)
+
+// Modeled after queryparser/xml/builders
+
+/*
+Decodes the parameters of the single field query types, which are
+either {"field": value}, or {"field": {valueKey: value, ...options}}
+to give options. Returns the field, and the parameter object with the
+value under valueKey.
+*/
+func fieldParams(path string, body json.RawMessage, valueKey string) (
+	field, fieldPath string, params map[string]json.RawMessage, err error) {
+
+	obj, err := DecodeObject(path, body)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if len(obj) != 1 {
+		return "", "", nil, NewParseError(path, fmt.Sprintf(
+			"expected exactly one field, but got %v", len(obj)))
+	}
+	for field, raw := range obj {
+		fieldPath = FieldPath(path, field)
+		if kind(raw) == '{' {
+			if params, err = DecodeObject(fieldPath, raw); err != nil {
+				return "", "", nil, err
+			}
+			if _, ok := params[valueKey]; !ok {
+				return "", "", nil, NewParseError(fieldPath, fmt.Sprintf("missing %q", valueKey))
+			}
+		} else {
+			params = map[string]json.RawMessage{valueKey: raw}
+		}
+		return field, fieldPath, params, nil
+	}
+	panic("should not be here")
+}
+
+/* Applies the optional boost of the query at path. */
+func applyBoost(path string, params map[string]json.RawMessage, q search.Query) error {
+	if raw, ok := params["boost"]; ok {
+		var boost float32
+		if err := Decode(FieldPath(path, "boost"), raw, &boost); err != nil {
+			return err
+		}
+		q.SetBoost(boost)
+	}
+	return nil
+}
+
+// queryparser/xml/builders/TermQueryBuilder.java
+
+/*
+Builds a TermQuery from
+
+	{"term": {"field": "value"}}
+	{"term": {"field": {"value": "value", "boost": 2}}}
+*/
+func termQuery(parser *Parser, path string, body json.RawMessage) (search.Query, error) {
+	field, fieldPath, params, err := fieldParams(path, body, "value")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = CheckKeys(fieldPath, params, "value", "boost"); err != nil {
+		return nil, err
+	}
+	var value string
+	if err = Decode(FieldPath(fieldPath, "value"), params["value"], &value); err != nil {
+		return nil, err
+	}
+	q := search.NewTermQuery(index.NewTerm(field, value))
+	if err = applyBoost(fieldPath, params, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// queryparser/xml/builders/TermsQueryBuilder.java
+
+/*
+Builds a BooleanQuery of optional TermQuery's, with coord disabled,
+from
+
+	{"terms": {"field": ["value1", "value2"], "boost": 2}}
+*/
+func termsQuery(parser *Parser, path string, body json.RawMessage) (search.Query, error) {
+	obj, err := DecodeObject(path, body)
+	if err != nil {
+		return nil, err
+	}
+	var field string
+	for key := range obj {
+		if key == "boost" {
+			continue
+		}
+		if field != "" {
+			fields := []string{field, key}
+			sort.Strings(fields)
+			return nil, NewParseError(path, fmt.Sprintf(
+				"expected exactly one field, but got %q and %q", fields[0], fields[1]))
+		}
+		field = key
+	}
+	if field == "" {
+		return nil, NewParseError(path, "missing the field")
+	}
+	fieldPath := FieldPath(path, field)
+	if kind(obj[field]) != '[' {
+		return nil, NewParseError(fieldPath, fmt.Sprintf(
+			"expected an array, but got %s", describe(obj[field])))
+	}
+	values, err := DecodeArray(fieldPath, obj[field])
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > search.MaxClauseCount() {
+		return nil, NewParseError(fieldPath, fmt.Sprintf(
+			"too many terms, the maximum is %v", search.MaxClauseCount()))
+	}
+	bq := search.NewBooleanQueryDisableCoord(true)
+	for i, raw := range values {
+		var value string
+		if err = Decode(ElemPath(fieldPath, i), raw, &value); err != nil {
+			return nil, err
+		}
+		bq.Add(search.NewTermQuery(index.NewTerm(field, value)), search.SHOULD)
+	}
+	if err = applyBoost(path, obj, bq); err != nil {
+		return nil, err
+	}
+	return bq, nil
+}
+
+// queryparser/xml/builders/BooleanQueryBuilder.java
+
+var occurs = map[string]search.Occur{
+	"must":     search.MUST,
+	"should":   search.SHOULD,
+	"must_not": search.MUST_NOT,
+}
+
+/*
+Builds a BooleanQuery from
+
+	{"bool": {
+		"must": [query...], "should": [query...], "must_not": [query...],
+		"minimum_should_match": 1, "disable_coord": false, "boost": 2
+	}}
+
+where a single query may be given without the enclosing array.
+*/
+func booleanQuery(parser *Parser, path string, body json.RawMessage) (search.Query, error) {
+	obj, err := DecodeObject(path, body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = CheckKeys(path, obj, "must", "should", "must_not",
+		"minimum_should_match", "disable_coord", "boost"); err != nil {
+		return nil, err
+	}
+
+	var disableCoord bool
+	if raw, ok := obj["disable_coord"]; ok {
+		if err = Decode(FieldPath(path, "disable_coord"), raw, &disableCoord); err != nil {
+			return nil, err
+		}
+	}
+	bq := search.NewBooleanQueryDisableCoord(disableCoord)
+	for _, name := range []string{"must", "should", "must_not"} {
+		raw, ok := obj[name]
+		if !ok {
+			continue
+		}
+		occurPath := FieldPath(path, name)
+		clauses, err := DecodeArray(occurPath, raw)
+		if err != nil {
+			return nil, err
+		}
+		for i, clause := range clauses {
+			clausePath := occurPath
+			if kind(raw) == '[' {
+				clausePath = ElemPath(occurPath, i)
+			}
+			if len(bq.Clauses()) == search.MaxClauseCount() {
+				return nil, NewParseError(clausePath, fmt.Sprintf(
+					"too many clauses, the maximum is %v", search.MaxClauseCount()))
+			}
+			q, err := parser.ParseQuery(clausePath, clause)
+			if err != nil {
+				return nil, err
+			}
+			bq.Add(q, occurs[name])
+		}
+	}
+	if raw, ok := obj["minimum_should_match"]; ok {
+		var min int
+		if err = Decode(FieldPath(path, "minimum_should_match"), raw, &min); err != nil {
+			return nil, err
+		}
+		bq.SetMinimumNumberShouldMatch(min)
+	}
+	if err = applyBoost(path, obj, bq); err != nil {
+		return nil, err
+	}
+	return bq, nil
+}
+
+// queryparser/xml/builders/UserInputQueryBuilder.java
+
+/*
+Builds the query of the terms the parser's analyzer produces for the
+text of a field, from
+
+	{"match": {"field": "text"}}
+	{"match": {"field": {"query": "text", "operator": "and", "boost": 2}}}
+
+The terms are combined with the operator, which is "or" by default.
+A text without any term, e.g. only stop words, matches nothing.
+*/
+func matchQuery(parser *Parser, path string, body json.RawMessage) (search.Query, error) {
+	field, fieldPath, params, err := fieldParams(path, body, "query")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = CheckKeys(fieldPath, params, "query", "operator", "boost"); err != nil {
+		return nil, err
+	}
+	var text string
+	if err = Decode(FieldPath(fieldPath, "query"), params["query"], &text); err != nil {
+		return nil, err
+	}
+	operator := search.SHOULD
+	if raw, ok := params["operator"]; ok {
+		var op string
+		opPath := FieldPath(fieldPath, "operator")
+		if err = Decode(opPath, raw, &op); err != nil {
+			return nil, err
+		}
+		switch op {
+		case "or", "OR":
+		case "and", "AND":
+			operator = search.MUST
+		default:
+			return nil, NewParseError(opPath, fmt.Sprintf(
+				`expected "and" or "or", but got %q`, op))
+		}
+	}
+
+	q := classic.NewQueryBuilder(parser.analyzer).CreateBooleanQuery(field, text, operator)
+	if q == nil {
+		q = search.NewBooleanQuery()
+	}
+	if err = applyBoost(fieldPath, params, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// queryparser/xml/builders/MatchAllDocsQueryBuilder.java
+
+/*
+Builds a MatchAllDocsQuery from
+
+	{"match_all": {}}
+	{"match_all": {"boost": 2}}
+*/
+func matchAllDocsQuery(parser *Parser, path string, body json.RawMessage) (search.Query, error) {
+	obj, err := DecodeObject(path, body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = CheckKeys(path, obj, "boost"); err != nil {
+		return nil, err
+	}
+	q := search.NewMatchAllDocsQuery()
+	if err = applyBoost(path, obj, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
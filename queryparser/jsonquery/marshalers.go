@@ -0,0 +1,66 @@
+package jsonquery
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/search"
+	"unicode/utf8"
+)
+
+/* A JSON object, whose keys encoding/json writes in sorted order. */
+type object map[string]interface{}
+
+/* Writes the boost of q, unless it's the default. */
+func withBoost(obj object, q search.Query) object {
+	if boost := q.Boost(); boost != 1 {
+		obj["boost"] = boost
+	}
+	return obj
+}
+
+/* Writes a TermQuery in the short form of term, unless it's boosted. */
+func marshalTermQuery(parser *Parser, q search.Query) (interface{}, error) {
+	term := q.(*search.TermQuery).Term()
+	if !utf8.Valid(term.Bytes) {
+		return nil, errors.New(fmt.Sprintf("cannot marshal term of field %v which is not UTF-8 text", term.Field))
+	}
+	var value interface{} = string(term.Bytes)
+	if q.Boost() != 1 {
+		value = withBoost(object{"value": value}, q)
+	}
+	return object{"term": object{term.Field: value}}, nil
+}
+
+/* Writes a BooleanQuery as bool, including only the non default options. */
+func marshalBooleanQuery(parser *Parser, q search.Query) (interface{}, error) {
+	bq := q.(*search.BooleanQuery)
+	body := object{}
+	for _, clause := range bq.Clauses() {
+		var name string
+		switch clause.Occur() {
+		case search.MUST:
+			name = "must"
+		case search.SHOULD:
+			name = "should"
+		case search.MUST_NOT:
+			name = "must_not"
+		}
+		sub, err := parser.MarshalValue(clause.Query())
+		if err != nil {
+			return nil, err
+		}
+		clauses, _ := body[name].([]interface{})
+		body[name] = append(clauses, sub)
+	}
+	if min := bq.MinimumNumberShouldMatch(); min != 0 {
+		body["minimum_should_match"] = min
+	}
+	if bq.IsCoordDisabled() {
+		body["disable_coord"] = true
+	}
+	return object{"bool": withBoost(body, q)}, nil
+}
+
+func marshalMatchAllDocsQuery(parser *Parser, q search.Query) (interface{}, error) {
+	return object{"match_all": withBoost(object{}, q)}, nil
+}
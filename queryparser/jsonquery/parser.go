@@ -0,0 +1,302 @@
+/*
+Package jsonquery parses a JSON description of a query into a
+search.Query, and writes a search.Query back to JSON.
+
+A query is a JSON object with a single key naming its type, whose
+value holds the parameters of that type:
+
+	{"bool": {
+		"must": [
+			{"term": {"title": "apple"}},
+			{"match": {"body": {"query": "red fruit", "operator": "and"}}}
+		],
+		"should": {"terms": {"color": ["red", "green"], "boost": 2}},
+		"must_not": {"term": {"status": "rotten"}}
+	}}
+
+The core types are term, terms, bool, match and match_all, and all of
+them take an optional boost. New types are plugged in by registering
+a QueryBuilder with Parser.AddBuilder, and, to write them back, a
+QueryMarshaler with Parser.AddMarshaler.
+
+Invalid descriptions are reported as a ParseError, whose Path locates
+the offending value, e.g. "$.bool.must[1].term".
+*/
+package jsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/search"
+	"reflect"
+	"regexp"
+	"sort"
+)
+
+// Modeled after queryparser/xml/CoreParser.java
+
+/* Builds the query of a registered type from its JSON parameters. */
+type QueryBuilder interface {
+	/*
+		Returns the query described by body, which is the value of the
+		type key of the query object located at path. Nested queries are
+		parsed with parser.ParseQuery.
+	*/
+	Query(parser *Parser, path string, body json.RawMessage) (search.Query, error)
+}
+
+/* An adapter to use an ordinary function as a QueryBuilder. */
+type QueryBuilderFunc func(parser *Parser, path string, body json.RawMessage) (search.Query, error)
+
+func (f QueryBuilderFunc) Query(parser *Parser, path string, body json.RawMessage) (search.Query, error) {
+	return f(parser, path, body)
+}
+
+/* Writes a query of a registered Go type as a JSON value. */
+type QueryMarshaler interface {
+	/*
+		Returns the value encoding/json marshals to the JSON object of q.
+		Nested queries are converted with parser.MarshalValue.
+	*/
+	MarshalQuery(parser *Parser, q search.Query) (interface{}, error)
+}
+
+/* An adapter to use an ordinary function as a QueryMarshaler. */
+type QueryMarshalerFunc func(parser *Parser, q search.Query) (interface{}, error)
+
+func (f QueryMarshalerFunc) MarshalQuery(parser *Parser, q search.Query) (interface{}, error) {
+	return f(parser, q)
+}
+
+/*
+Parser converts between JSON query descriptions and queries. Text of
+match queries is analyzed with the given Analyzer, which receives the
+field name, so a per field analyzer analyzes each field its own way.
+*/
+type Parser struct {
+	analyzer   analysis.Analyzer
+	builders   map[string]QueryBuilder
+	marshalers map[reflect.Type]QueryMarshaler
+}
+
+/* Creates a Parser which knows the core query types. */
+func NewParser(analyzer analysis.Analyzer) *Parser {
+	ans := &Parser{
+		analyzer:   analyzer,
+		builders:   make(map[string]QueryBuilder),
+		marshalers: make(map[reflect.Type]QueryMarshaler),
+	}
+	ans.AddBuilder("term", QueryBuilderFunc(termQuery))
+	ans.AddBuilder("terms", QueryBuilderFunc(termsQuery))
+	ans.AddBuilder("bool", QueryBuilderFunc(booleanQuery))
+	ans.AddBuilder("match", QueryBuilderFunc(matchQuery))
+	ans.AddBuilder("match_all", QueryBuilderFunc(matchAllDocsQuery))
+
+	ans.AddMarshaler(&search.TermQuery{}, QueryMarshalerFunc(marshalTermQuery))
+	ans.AddMarshaler(&search.BooleanQuery{}, QueryMarshalerFunc(marshalBooleanQuery))
+	ans.AddMarshaler(&search.MatchAllDocsQuery{}, QueryMarshalerFunc(marshalMatchAllDocsQuery))
+	return ans
+}
+
+/* Returns the analyzer of match queries. */
+func (p *Parser) Analyzer() analysis.Analyzer {
+	return p.analyzer
+}
+
+/* Registers the builder of the query type name, replacing any previous one. */
+func (p *Parser) AddBuilder(name string, builder QueryBuilder) {
+	p.builders[name] = builder
+}
+
+/*
+Registers the marshaler of the queries of the same Go type as
+prototype, replacing any previous one.
+*/
+func (p *Parser) AddMarshaler(prototype search.Query, marshaler QueryMarshaler) {
+	p.marshalers[reflect.TypeOf(prototype)] = marshaler
+}
+
+/* Parses a JSON query description. */
+func (p *Parser) Parse(data []byte) (search.Query, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, NewParseError("$", err.Error())
+	}
+	return p.ParseQuery("$", raw)
+}
+
+/* Parses the query object located at path. */
+func (p *Parser) ParseQuery(path string, raw json.RawMessage) (search.Query, error) {
+	obj, err := DecodeObject(path, raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(obj) != 1 {
+		return nil, NewParseError(path, fmt.Sprintf(
+			"expected a query object with exactly one type, but got %v keys", len(obj)))
+	}
+	for name, body := range obj {
+		builder, ok := p.builders[name]
+		if !ok {
+			return nil, NewParseError(path, fmt.Sprintf("unknown query type %q", name))
+		}
+		return builder.Query(p, FieldPath(path, name), body)
+	}
+	panic("should not be here")
+}
+
+/* Returns the JSON description of q. */
+func (p *Parser) Marshal(q search.Query) ([]byte, error) {
+	v, err := p.MarshalValue(q)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+/*
+Returns the value encoding/json marshals to the JSON description of
+q, for marshalers of queries wrapping other queries.
+*/
+func (p *Parser) MarshalValue(q search.Query) (interface{}, error) {
+	marshaler, ok := p.marshalers[reflect.TypeOf(q)]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("cannot marshal query of type %T: %v", q, q))
+	}
+	return marshaler.MarshalQuery(p, q)
+}
+
+// queryparser/xml/ParserException.java
+
+/* This error is returned when a JSON query description is invalid. */
+type ParseError struct {
+	// JSON path of the invalid value, e.g. $.bool.must[0].term
+	Path string
+	msg  string
+}
+
+func NewParseError(path, msg string) *ParseError {
+	return &ParseError{path, msg}
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%v: %v", err.Path, err.msg)
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+/* Returns the JSON path of the member name of the object at path. */
+func FieldPath(path, name string) string {
+	if identifier.MatchString(name) {
+		return path + "." + name
+	}
+	quoted, _ := json.Marshal(name)
+	return fmt.Sprintf("%v[%s]", path, quoted)
+}
+
+/* Returns the JSON path of the i-th element of the array at path. */
+func ElemPath(path string, i int) string {
+	return fmt.Sprintf("%v[%v]", path, i)
+}
+
+/* Decodes the JSON object at path. */
+func DecodeObject(path string, raw json.RawMessage) (map[string]json.RawMessage, error) {
+	var obj map[string]json.RawMessage
+	if kind(raw) != '{' || json.Unmarshal(raw, &obj) != nil {
+		return nil, NewParseError(path, fmt.Sprintf("expected an object, but got %s", describe(raw)))
+	}
+	return obj, nil
+}
+
+/*
+Decodes the JSON array at path. A single value which is not an array
+is decoded as an array of that one value.
+*/
+func DecodeArray(path string, raw json.RawMessage) ([]json.RawMessage, error) {
+	if kind(raw) != '[' {
+		return []json.RawMessage{raw}, nil
+	}
+	var arr []json.RawMessage
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		return nil, NewParseError(path, err.Error())
+	}
+	return arr, nil
+}
+
+/*
+Decodes the JSON value at path into v, which should point to a
+string, a number or a bool.
+*/
+func Decode(path string, raw json.RawMessage, v interface{}) error {
+	// null would silently leave v as is
+	if err := json.Unmarshal(raw, v); err != nil || kind(raw) == 'n' {
+		var expected string
+		switch reflect.TypeOf(v).Elem().Kind() {
+		case reflect.String:
+			expected = "a string"
+		case reflect.Bool:
+			expected = "a boolean"
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			expected = "an integer"
+		case reflect.Float32, reflect.Float64:
+			expected = "a number"
+		default:
+			expected = fmt.Sprintf("a %v", reflect.TypeOf(v).Elem())
+		}
+		return NewParseError(path, fmt.Sprintf("expected %v, but got %s", expected, describe(raw)))
+	}
+	return nil
+}
+
+/*
+Checks the object at path has no other keys than the given ones, and
+returns its keys in sorted order.
+*/
+func CheckKeys(path string, obj map[string]json.RawMessage, allowed ...string) ([]string, error) {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		found := false
+		for _, k := range allowed {
+			if k == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, NewParseError(path, fmt.Sprintf("unknown parameter %q", key))
+		}
+	}
+	return keys, nil
+}
+
+/* Returns the first character of a JSON value, which tells its kind. */
+func kind(raw json.RawMessage) byte {
+	if raw = bytes.TrimSpace(raw); len(raw) == 0 {
+		return 0
+	}
+	return raw[0]
+}
+
+/* Returns the kind of a JSON value, for error messages. */
+func describe(raw json.RawMessage) string {
+	switch kind(raw) {
+	case '{':
+		return "an object"
+	case '[':
+		return "an array"
+	case '"':
+		return "a string"
+	case 't', 'f':
+		return "a boolean"
+	case 'n':
+		return "null"
+	}
+	return "a number"
+}
@@ -0,0 +1,605 @@
+package simple
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	"github.com/balzaczyy/golucene/queryparser/classic"
+	"sort"
+	"strconv"
+)
+
+// queryparser/simple/SimpleQueryParser.java
+
+// Flags of the operators the parser recognizes.
+const (
+	// Enables AND operator (+)
+	AND_OPERATOR = 1 << iota
+	// Enables NOT operator (-)
+	NOT_OPERATOR
+	// Enables OR operator (|)
+	OR_OPERATOR
+	// Enables PREFIX operator (*)
+	PREFIX_OPERATOR
+	// Enables PHRASE operator (")
+	PHRASE_OPERATOR
+	// Enables PRECEDENCE operators: ( and )
+	PRECEDENCE_OPERATORS
+	// Enables ESCAPE operator (\)
+	ESCAPE_OPERATOR
+	// Enables WHITESPACE operators: ' ' '\n' '\r' '\t'
+	WHITESPACE_OPERATOR
+	// Enables FUZZY operators: (~) on single terms
+	FUZZY_OPERATOR
+	// Enables NEAR operators: (~) on phrases
+	NEAR_OPERATOR
+
+	// Enables all operators
+	ALL_OPERATORS = -1
+)
+
+/*
+SimpleQueryParser is used to parse human readable query syntax.
+
+The main idea behind this parser is that a person should be able to
+type whatever they want to represent a query, and this parser will
+do its best to interpret what to search for no matter how poorly
+composed the request may be. Tokens are considered to be any of a
+term, phrase, or subquery for the operations described below.
+Whitespace including ' ' '\n' '\r' and '\t' and certain operators may
+be used to delimit tokens ( ) + | " .
+
+Any errors in query syntax will be ignored and the parser will
+attempt to decipher what it can; however, this may mean odd or
+unexpected results. The query operators are:
+
+	'+' specifies AND operation: token1+token2
+	'|' specifies OR operation: token1|token2
+	'-' negates a single token: -token0
+	'"' creates phrases of terms: "term1 term2 ..."
+	'*' at the end of terms specifies prefix query: term*
+	'~N' at the end of terms specifies fuzzy query: term~1
+	'~N' at the end of phrases specifies near query: "term1 term2"~5
+	'(' and ')' specifies precedence: token1 + (token2 | token3)
+
+The default operator is OR if no other operator is specified. For
+example, the following will OR token1 and token2 together:
+token1 token2
+
+Normal operator precedence will be simple order from right to left.
+For example, the following will evaluate token1 OR token2 first,
+then AND with token3:
+
+	token1 | token2 + token3
+
+An individual term may contain any possible character with certain
+characters requiring escaping using a '\'. The following characters
+will need to be escaped in terms and phrases: + | " ( ) ' \
+
+The '-' operator is a special case. On individual terms (not
+phrases) the first character of a term that is - must be escaped;
+however, any '-' characters beyond the first character do not need
+to be escaped. For example:
+
+	-term1   -- Specifies NOT operation against term1
+	\-term1  -- Searches for the term -term1.
+	term-1   -- Searches for the term term-1.
+	term\-1  -- Searches for the term term-1.
+
+The '*' operator is a special case. On individual terms (not
+phrases) the last character of a term that is '*' must be escaped;
+however, any '*' characters before the last character do not need
+to be escaped:
+
+	term1*  --  Searches for the prefix term1
+	term1\* --  Searches for the term term1*
+	term*1  --  Searches for the term term*1
+	term\*1 --  Searches for the term term*1
+
+Note that above examples consider the terms before text processing.
+*/
+type SimpleQueryParser struct {
+	*classic.QueryBuilder
+	// Map of fields to query against with their weights
+	weights map[string]float32
+	// fields of weights, in the order the queries are created
+	fields []string
+	// flags to the parser (to turn features on/off)
+	flags int
+
+	defaultOperator search.Occur
+}
+
+/* Creates a new parser searching over a single field. */
+func NewSimpleQueryParser(analyzer analysis.Analyzer, field string) *SimpleQueryParser {
+	return NewSimpleQueryParserWithWeights(analyzer, map[string]float32{field: 1})
+}
+
+/* Creates a new parser searching over multiple fields with different weights. */
+func NewSimpleQueryParserWithWeights(analyzer analysis.Analyzer,
+	weights map[string]float32) *SimpleQueryParser {
+
+	return NewSimpleQueryParserWithFlags(analyzer, weights, ALL_OPERATORS)
+}
+
+/*
+Creates a new parser with custom flags used to enable/disable certain
+features.
+*/
+func NewSimpleQueryParserWithFlags(analyzer analysis.Analyzer,
+	weights map[string]float32, flags int) *SimpleQueryParser {
+
+	fields := make([]string, 0, len(weights))
+	for field := range weights {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return &SimpleQueryParser{
+		QueryBuilder:    classic.NewQueryBuilder(analyzer),
+		weights:         weights,
+		fields:          fields,
+		flags:           flags,
+		defaultOperator: search.SHOULD,
+	}
+}
+
+/* Returns the implicit operator setting, which will be either SHOULD or MUST. */
+func (p *SimpleQueryParser) DefaultOperator() search.Occur {
+	return p.defaultOperator
+}
+
+/*
+Sets the implicit operator setting, which must be either SHOULD or
+MUST.
+*/
+func (p *SimpleQueryParser) SetDefaultOperator(operator search.Occur) {
+	if operator != search.SHOULD && operator != search.MUST {
+		panic("invalid operator: only SHOULD or MUST are allowed")
+	}
+	p.defaultOperator = operator
+}
+
+/*
+Parses the query text and returns parsed query. It never fails:
+malformed syntax is interpreted as well as possible, and an empty
+BooleanQuery is returned if nothing searchable is left.
+*/
+func (p *SimpleQueryParser) Parse(queryText string) search.Query {
+	data := []rune(queryText)
+	buffer := make([]rune, len(data))
+
+	state := newState(data, buffer, 0, len(data))
+	p.parseSubQuery(state)
+	if state.top == nil {
+		return search.NewBooleanQuery()
+	}
+	return state.top
+}
+
+func (p *SimpleQueryParser) enabled(flag int) bool {
+	return p.flags&flag != 0
+}
+
+func (p *SimpleQueryParser) parseSubQuery(state *state) {
+	for state.index < state.length {
+		switch ch := state.data[state.index]; {
+		case ch == '(' && p.enabled(PRECEDENCE_OPERATORS):
+			// the beginning of a subquery has been found
+			p.consumeSubQuery(state)
+		case ch == ')' && p.enabled(PRECEDENCE_OPERATORS):
+			// this is an extraneous character so it is ignored
+			state.index++
+		case ch == '"' && p.enabled(PHRASE_OPERATOR):
+			// the beginning of a phrase has been found
+			p.consumePhrase(state)
+		case ch == '+' && p.enabled(AND_OPERATOR):
+			// an and operation has been explicitly set; if an operator is
+			// found, but a term has not been found yet, the operator is
+			// ignored
+			if state.currentOperation == 0 && state.top != nil {
+				state.currentOperation = search.MUST
+			}
+			state.index++
+		case ch == '|' && p.enabled(OR_OPERATOR):
+			// an or operation has been explicitly set; if an operator is
+			// found, but a term has not been found yet, the operator is
+			// ignored
+			if state.currentOperation == 0 && state.top != nil {
+				state.currentOperation = search.SHOULD
+			}
+			state.index++
+		case ch == '-' && p.enabled(NOT_OPERATOR):
+			// a not operator has been found, so increase the not count;
+			// two not operators in a row negate each other
+			state.not++
+			state.index++
+			// continue so the not operator is not reset before the next
+			// character is determined
+			continue
+		case isWhitespace(ch) && p.enabled(WHITESPACE_OPERATOR):
+			// ignore any whitespace found as it may have already been used
+			// a delimiter across a term (or phrase or subquery) or is
+			// simply extraneous
+			state.index++
+		default:
+			// the beginning of a token has been found
+			p.consumeToken(state)
+		}
+		// reset the not operator as even whitespace is not allowed when
+		// specifying the not operation for a term (or phrase or subquery)
+		state.not = 0
+	}
+}
+
+func (p *SimpleQueryParser) consumeSubQuery(state *state) {
+	assert(p.enabled(PRECEDENCE_OPERATORS))
+	state.index++
+	start := state.index
+	precedence := 1
+	escaped := false
+
+	for state.index < state.length {
+		if !escaped {
+			ch := state.data[state.index]
+			if ch == '\\' && p.enabled(ESCAPE_OPERATOR) {
+				// an escape character has been found so whatever character
+				// is next will become part of the subquery unless the escape
+				// character is the last one in the data
+				escaped = true
+				state.index++
+				continue
+			} else if ch == '(' {
+				// increase the precedence as there is a subquery in the
+				// current subquery
+				precedence++
+			} else if ch == ')' {
+				precedence--
+				if precedence == 0 {
+					// this should be the end of the current subquery all
+					// characters found will used for creating the subquery
+					break
+				}
+			}
+		}
+		escaped = false
+		state.index++
+	}
+
+	if state.index == state.length {
+		// a closing parenthesis was never found so the opening
+		// parenthesis is considered extraneous and will be ignored
+		state.index = start
+	} else if state.index == start {
+		// a closing parenthesis was found immediately after the opening
+		// parenthesis so the current operation is reset since it would
+		// have been applied to this subquery
+		state.currentOperation = 0
+		state.index++
+	} else {
+		// a complete subquery has been found and is recursively parsed
+		// by starting over with a new state object
+		subState := newState(state.data, state.buffer, start, state.index)
+		p.parseSubQuery(subState)
+		p.buildQueryTree(state, subState.top)
+		state.index++
+	}
+}
+
+func (p *SimpleQueryParser) consumePhrase(state *state) {
+	assert(p.enabled(PHRASE_OPERATOR))
+	state.index++
+	start := state.index
+	copied := 0
+	escaped := false
+	hasSlop := false
+
+	for state.index < state.length {
+		if !escaped {
+			ch := state.data[state.index]
+			if ch == '\\' && p.enabled(ESCAPE_OPERATOR) {
+				// an escape character has been found so whatever character
+				// is next will become part of the phrase unless the escape
+				// character is the last one in the data
+				escaped = true
+				state.index++
+				continue
+			} else if ch == '"' {
+				// if there are still characters after the closing ", check
+				// for a tilde
+				if state.length > state.index+1 && state.data[state.index+1] == '~' &&
+					p.enabled(NEAR_OPERATOR) {
+
+					state.index++
+					// check for characters after the tilde
+					hasSlop = state.length > state.index+1
+				}
+				// this should be the end of the phrase all characters found
+				// will used for creating the phrase query
+				break
+			}
+		}
+		escaped = false
+		state.buffer[copied] = state.data[state.index]
+		copied++
+		state.index++
+	}
+
+	if state.index == state.length {
+		// a closing double quote was never found so the opening double
+		// quote is considered extraneous and will be ignored
+		state.index = start
+	} else if state.index == start {
+		// a closing double quote was found immediately after the opening
+		// double quote so the current operation is reset since it would
+		// have been applied to this phrase
+		state.currentOperation = 0
+		state.index++
+	} else {
+		// a complete phrase has been found and is parsed through the
+		// analyzer from the given field
+		phrase := string(state.buffer[:copied])
+		if hasSlop {
+			// the slop is consumed up to the character which finishes it
+			p.buildQueryTree(state, p.newPhraseQuery(phrase, p.parseFuzziness(state)))
+		} else {
+			p.buildQueryTree(state, p.newPhraseQuery(phrase, 0))
+			state.index++
+		}
+	}
+}
+
+func (p *SimpleQueryParser) consumeToken(state *state) {
+	copied := 0
+	escaped := false
+	prefix := false
+	fuzzy := false
+
+	for state.index < state.length {
+		if !escaped {
+			ch := state.data[state.index]
+			if ch == '\\' && p.enabled(ESCAPE_OPERATOR) {
+				// an escape character has been found so whatever character
+				// is next will become part of the term unless the escape
+				// character is the last one in the data
+				escaped = true
+				prefix = false
+				state.index++
+				continue
+			} else if p.tokenFinished(state) {
+				// this should be the end of the term all characters found
+				// will used for creating the term query
+				break
+			} else if copied > 0 && ch == '~' && p.enabled(FUZZY_OPERATOR) {
+				fuzzy = true
+				break
+			}
+
+			// wildcard tracks whether or not the last character was a '*'
+			// operator that hasn't been escaped there must be at least one
+			// valid character before searching for a prefixed set of terms
+			prefix = copied > 0 && ch == '*' && p.enabled(PREFIX_OPERATOR)
+		}
+
+		escaped = false
+		state.buffer[copied] = state.data[state.index]
+		copied++
+		state.index++
+	}
+
+	if copied > 0 {
+		var branch search.Query
+		if fuzzy && p.enabled(FUZZY_OPERATOR) {
+			token := string(state.buffer[:copied])
+			fuzziness := p.parseFuzziness(state)
+			// edit distance has a maximum, limit to the maximum supported
+			if fuzziness > automaton.MAXIMUM_SUPPORTED_DISTANCE {
+				fuzziness = automaton.MAXIMUM_SUPPORTED_DISTANCE
+			}
+			if fuzziness == 0 {
+				branch = p.newDefaultQuery(token)
+			} else {
+				branch = p.newFuzzyQuery(token, fuzziness)
+			}
+		} else if prefix {
+			// if a term is found with a closing '*' it is considered to be
+			// a prefix query and will have prefix added as an option
+			branch = p.newPrefixQuery(string(state.buffer[:copied-1]))
+		} else {
+			// a standard term has been found so it will be run through the
+			// entire analysis chain from the specified schema field
+			branch = p.newDefaultQuery(string(state.buffer[:copied]))
+		}
+		p.buildQueryTree(state, branch)
+	}
+}
+
+/*
+buildQueryTree should be called after a term, phrase, or subquery is
+consumed to be added to our existing query tree. It will only add to
+the existing tree if the branch contained in state is not nil.
+*/
+func (p *SimpleQueryParser) buildQueryTree(state *state, branch search.Query) {
+	if branch == nil {
+		return
+	}
+
+	// modify our branch to a BooleanQuery wrapper for not; this is
+	// necessary any time a term, phrase, or subquery is negated
+	if state.not%2 == 1 {
+		nq := search.NewBooleanQuery()
+		nq.Add(branch, search.MUST_NOT)
+		nq.Add(search.NewMatchAllDocsQuery(), search.SHOULD)
+		branch = nq
+	}
+
+	if state.top == nil {
+		// first term (or phrase or subquery) found and will begin our
+		// query tree
+		state.top = branch
+	} else {
+		// more than one term (or phrase or subquery) found; set
+		// currentOperation to the default if no other operation is
+		// explicitly set
+		if state.currentOperation == 0 {
+			state.currentOperation = p.defaultOperator
+		}
+
+		// operational change requiring a new parent node; this occurs if
+		// the previous operation is not the same as current operation
+		// because the previous operation must be evaluated separately to
+		// preserve the proper precedence and the current operation will
+		// take over as the top of the tree
+		if state.previousOperation != state.currentOperation {
+			bq := search.NewBooleanQuery()
+			bq.Add(state.top, state.currentOperation)
+			state.top = bq
+		}
+
+		// rather than failing, terms beyond the maximum number of clauses
+		// are dropped
+		if bq := state.top.(*search.BooleanQuery); len(bq.Clauses()) < search.MaxClauseCount() {
+			bq.Add(branch, state.currentOperation)
+		}
+		state.previousOperation = state.currentOperation
+	}
+
+	// reset the current operation as it was intended to be applied to
+	// the incoming term (or phrase or subquery)
+	state.currentOperation = 0
+}
+
+/*
+Helper parsing fuzziness from parsing state. Returns slop/edit
+distance, 0 in the case of non-parsing slop/edit string.
+*/
+func (p *SimpleQueryParser) parseFuzziness(state *state) int {
+	if state.data[state.index] != '~' {
+		return 0
+	}
+	var slopText []rune
+	for state.index < state.length {
+		state.index++
+		// it's possible that the ~ was at the end, so check after
+		// incrementing to make sure we don't go out of bounds
+		if state.index < state.length {
+			if p.tokenFinished(state) {
+				break
+			}
+			slopText = append(slopText, state.data[state.index])
+		}
+	}
+	// errors parsing fuzziness are swallowed
+	fuzziness, _ := strconv.Atoi(string(slopText))
+	if fuzziness < 0 { // negative -> 0
+		fuzziness = 0
+	}
+	return fuzziness
+}
+
+/* Helper returning true if the state has reached the end of token. */
+func (p *SimpleQueryParser) tokenFinished(state *state) bool {
+	switch ch := state.data[state.index]; {
+	case ch == '"':
+		return p.enabled(PHRASE_OPERATOR)
+	case ch == '|':
+		return p.enabled(OR_OPERATOR)
+	case ch == '+':
+		return p.enabled(AND_OPERATOR)
+	case ch == '(' || ch == ')':
+		return p.enabled(PRECEDENCE_OPERATORS)
+	case isWhitespace(ch):
+		return p.enabled(WHITESPACE_OPERATOR)
+	}
+	return false
+}
+
+func isWhitespace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+/* Factory method to generate a standard query (no phrase or prefix operators). */
+func (p *SimpleQueryParser) newDefaultQuery(text string) search.Query {
+	bq := search.NewBooleanQueryDisableCoord(true)
+	for _, field := range p.fields {
+		if q := p.CreateBooleanQuery(field, text, p.defaultOperator); q != nil {
+			q.SetBoost(p.weights[field])
+			bq.Add(q, search.SHOULD)
+		}
+	}
+	return simplify(bq)
+}
+
+/* Factory method to generate a fuzzy query. */
+func (p *SimpleQueryParser) newFuzzyQuery(text string, fuzziness int) search.Query {
+	bq := search.NewBooleanQueryDisableCoord(true)
+	for _, field := range p.fields {
+		q := search.NewFuzzyQueryWithEdits(index.NewTerm(field, text), fuzziness, 0)
+		q.SetBoost(p.weights[field])
+		bq.Add(q, search.SHOULD)
+	}
+	return simplify(bq)
+}
+
+/* Factory method to generate a phrase query with slop. */
+func (p *SimpleQueryParser) newPhraseQuery(text string, slop int) search.Query {
+	bq := search.NewBooleanQueryDisableCoord(true)
+	for _, field := range p.fields {
+		if q := p.CreatePhraseQuery(field, text, slop); q != nil {
+			q.SetBoost(p.weights[field])
+			bq.Add(q, search.SHOULD)
+		}
+	}
+	return simplify(bq)
+}
+
+/* Factory method to generate a prefix query. */
+func (p *SimpleQueryParser) newPrefixQuery(text string) search.Query {
+	bq := search.NewBooleanQueryDisableCoord(true)
+	for _, field := range p.fields {
+		prefix := search.NewPrefixQuery(index.NewTerm(field, text))
+		prefix.SetBoost(p.weights[field])
+		bq.Add(prefix, search.SHOULD)
+	}
+	return simplify(bq)
+}
+
+/* Helper to simplify boolean queries with 0 or 1 clause */
+func simplify(bq *search.BooleanQuery) search.Query {
+	switch clauses := bq.Clauses(); len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		return clauses[0].Query()
+	}
+	return bq
+}
+
+/* state of the parser, shared by a query and its subqueries */
+type state struct {
+	data   []rune
+	buffer []rune
+	index  int
+	length int
+
+	currentOperation  search.Occur // 0 if not set
+	previousOperation search.Occur
+	not               int
+
+	top search.Query
+}
+
+func newState(data, buffer []rune, index, length int) *state {
+	return &state{
+		data:   data,
+		buffer: buffer,
+		index:  index,
+		length: length,
+	}
+}
+
+func assert(ok bool) {
+	if !ok {
+		panic("assert fail")
+	}
+}
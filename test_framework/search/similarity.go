@@ -11,26 +11,59 @@ import (
 
 // search/RandomSimilarityProvider.java
 
+var basicModels = []BasicModel{
+	NewBasicModelG(),
+	NewBasicModelIF(),
+	NewBasicModelIn(),
+	NewBasicModelIne(),
+	// TODO: enable BasicModelBE, BasicModelD, BasicModelP
+}
+
+var afterEffects = []AfterEffect{
+	NewAfterEffectB(),
+	NewAfterEffectL(),
+	NewNoAfterEffect(),
+}
+
+// TODO: if we enable NoNormalization, we have to deal with a couple
+// tricky things
+var normalizations = []Normalization{
+	NewNormalizationH1(),
+	NewNormalizationH2(),
+	NewNormalizationH3(),
+	NewNormalizationZ(),
+}
+
+var distributions = []Distribution{
+	NewDistributionLL(),
+	NewDistributionSPL(),
+}
+
+var lambdas = []Lambda{
+	NewLambdaDF(),
+	NewLambdaTTF(),
+}
+
 var allSims = func() []Similarity {
 	var ans []Similarity
 	ans = append(ans, NewDefaultSimilarity())
-	// ans = append(ans, newBM25Similarity())
-	// for _, basicModel := range BASIC_MODELS {
-	// 	for _, afterEffect := range AFTER_EFFECTS {
-	// 		for _, normalization := range NORMALIZATIONS {
-	// 			ans = append(ans, newDFRSimilarity(basicModel, afterEffect, normalization))
-	// 		}
-	// 	}
-	// }
-	// for _, distribution := range DISTRIBUTIONS {
-	// 	for _, lambda := range LAMBDAS {
-	// 		for _, normalization := range NORMALIZATIONS {
-	// 			ans = append(ans, newIBSimilarity(ditribution, lambda, normalization))
-	// 		}
-	// 	}
-	// }
-	// ans = append(ans, newLMJelinekMercerSimilarity(0.1))
-	// ans = append(ans, newLMJelinekMercerSimilarity(0.7))
+	ans = append(ans, NewBM25Similarity())
+	for _, basicModel := range basicModels {
+		for _, afterEffect := range afterEffects {
+			for _, normalization := range normalizations {
+				ans = append(ans, NewDFRSimilarity(basicModel, afterEffect, normalization))
+			}
+		}
+	}
+	for _, distribution := range distributions {
+		for _, lambda := range lambdas {
+			for _, normalization := range normalizations {
+				ans = append(ans, NewIBSimilarity(distribution, lambda, normalization))
+			}
+		}
+	}
+	ans = append(ans, NewLMJelinekMercerSimilarity(0.1))
+	ans = append(ans, NewLMJelinekMercerSimilarity(0.7))
 	return ans
 }()
 